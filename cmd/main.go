@@ -1,16 +1,27 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"cluster-resource-insight/internal/collector"
 	"cluster-resource-insight/internal/config"
 	"cluster-resource-insight/internal/database"
+	"cluster-resource-insight/internal/historystore"
 	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/remotewrite"
 	"cluster-resource-insight/internal/server"
+	"cluster-resource-insight/internal/service"
 )
 
+// shutdownTimeout 收到退出信号后等待处理中请求完成的最长时间
+const shutdownTimeout = 15 * time.Second
+
 func main() {
 	var configPath = flag.String("config", "config.toml", "配置文件路径")
 	var migrate = flag.Bool("migrate", false, "执行数据库迁移")
@@ -38,12 +49,15 @@ func main() {
 
 	// 初始化数据库连接
 	dbConfig := &database.DatabaseConfig{
+		Driver:   appConfig.Database.Driver,
 		Host:     appConfig.Database.Host,
 		Port:     appConfig.Database.Port,
 		Username: appConfig.Database.Username,
 		Password: appConfig.Database.Password,
 		DBName:   appConfig.Database.Database,
 		Charset:  appConfig.Database.Charset,
+		SSLMode:  appConfig.Database.SSLMode,
+		Path:     appConfig.Database.Path,
 	}
 
 	if err := database.InitDatabase(dbConfig); err != nil {
@@ -66,6 +80,42 @@ func main() {
 		logger.Fatal("数据库表检查和自动迁移失败: %v", err)
 	}
 
+	// 初始化历史数据存储
+	storeConfig := &historystore.Config{
+		Backend:       appConfig.HistoryStore.Backend,
+		Path:          appConfig.HistoryStore.TSDBPath,
+		RetentionDays: appConfig.HistoryStore.RetentionDays,
+	}
+	if err := historystore.InitHistoryStore(storeConfig); err != nil {
+		logger.Fatal("历史数据存储初始化失败: %v", err)
+	}
+	defer historystore.CloseHistoryStore()
+
+	// 初始化 Prometheus remote write 推送
+	if rwConfig := appConfig.RemoteWrite; rwConfig.Enabled {
+		writerConfig := &remotewrite.Config{
+			URL:                  rwConfig.URL,
+			Timeout:              time.Duration(rwConfig.TimeoutSeconds) * time.Second,
+			QueueCapacity:        rwConfig.QueueCapacity,
+			MaxSamplesPerRequest: rwConfig.MaxSamplesPerRequest,
+			MaxRetries:           rwConfig.MaxRetries,
+			MinBackoff:           time.Duration(rwConfig.MinBackoffMillis) * time.Millisecond,
+			MaxBackoff:           time.Duration(rwConfig.MaxBackoffMillis) * time.Millisecond,
+			BasicAuthUsername:    rwConfig.BasicAuthUsername,
+			BasicAuthPassword:    rwConfig.BasicAuthPassword,
+			BearerToken:          rwConfig.BearerToken,
+			Headers:              rwConfig.Headers,
+			ExternalLabels:       rwConfig.ExternalLabels,
+		}
+		if err := remotewrite.InitRemoteWriter(writerConfig); err != nil {
+			logger.Fatal("remote write 初始化失败: %v", err)
+		}
+		defer remotewrite.CloseRemoteWriter()
+	}
+
+	// 恢复服务重启前未结束的资源调整健康观察
+	service.NewChangeService().ResumeHealthWatches()
+
 	// 创建空的资源收集器（多集群模式下会从数据库动态创建）
 	logger.Info("使用多集群模式，将从数据库动态创建资源收集器")
 	resourceCollector := &collector.ResourceCollector{}
@@ -75,7 +125,29 @@ func main() {
 	srv.Setup()
 
 	// 启动服务器
-	if err := srv.Start(); err != nil {
-		logger.Fatal("服务器启动失败: %v", err)
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- srv.Start()
+	}()
+
+	// 收到退出信号或服务器异常退出后优雅关闭服务器、调度服务和健康观察，
+	// 返回后由defer依次关闭remote write、历史数据存储、数据库和日志
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case err := <-serverErr:
+		if err != nil {
+			logger.Error("服务器运行失败: %v", err)
+		}
+	case sig := <-quit:
+		logger.Info("收到信号 %v，正在关闭服务器...", sig)
+	}
+	signal.Stop(quit)
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Error("服务器关闭超时: %v", err)
 	}
+	logger.Info("服务器已关闭")
 }
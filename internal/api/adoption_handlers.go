@@ -0,0 +1,66 @@
+package api
+
+import (
+	"strconv"
+
+	"cluster-resource-insight/internal/collector"
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/response"
+	"cluster-resource-insight/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecommendationSnapshotRequest 手动记录资源建议快照的请求体
+type RecommendationSnapshotRequest struct {
+	ClusterID uint   `json:"cluster_id" binding:"required"` // 集群ID
+	Namespace string `json:"namespace"`                     // 命名空间，为空时记录全部命名空间
+}
+
+// CreateRecommendationSnapshots 记录资源建议快照 - 作为后续判断建议是否被采纳的基准
+func CreateRecommendationSnapshots(multiCollector *collector.MultiClusterResourceCollector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req RecommendationSnapshotRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest("请求参数错误: "+err.Error(), c)
+			return
+		}
+
+		saved, err := multiCollector.SnapshotRecommendations(c.Request.Context(), req.ClusterID, req.Namespace)
+		if err != nil {
+			logger.Error("记录资源建议快照失败: %v", err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(gin.H{"saved": saved}, c)
+	}
+}
+
+// GetAdoptionReport 获取建议采纳报告 - 按团队和命名空间汇总采纳情况、实际节省及调整前后的稳定性
+func GetAdoptionReport(adoptionService *service.AdoptionService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := service.AdoptionReportRequest{
+			Namespace: c.Query("namespace"),
+			Team:      c.Query("team"),
+		}
+		if clusterIDStr := c.Query("cluster_id"); clusterIDStr != "" {
+			id, err := strconv.ParseUint(clusterIDStr, 10, 32)
+			if err != nil {
+				response.BadRequest("集群ID格式错误", c)
+				return
+			}
+			clusterID := uint(id)
+			req.ClusterID = &clusterID
+		}
+
+		report, err := adoptionService.GetAdoptionReport(req)
+		if err != nil {
+			logger.Error("生成建议采纳报告失败: %v", err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(report, c)
+	}
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"errors"
+
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/response"
+	"cluster-resource-insight/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QueryHistoryAggregation 历史数据聚合查询 - 按分组维度和时间桶返回指定指标的聚合序列，供图表使用
+func QueryHistoryAggregation(aggregationService *service.AggregationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req service.AggregationRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest("请求参数错误: "+err.Error(), c)
+			return
+		}
+
+		if err := aggregationService.Validate(&req); err != nil {
+			response.BadRequest(err.Error(), c)
+			return
+		}
+
+		result, err := aggregationService.Query(req)
+		if err != nil {
+			if errors.Is(err, service.ErrAggregationLimit) {
+				response.BadRequest(err.Error(), c)
+				return
+			}
+			logger.Error("历史数据聚合查询失败: %v", err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(result, c)
+	}
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"strconv"
+
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/response"
+	"cluster-resource-insight/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAnomalies 获取使用量异常记录 - 返回当前值、基线值和偏离程度
+func GetAnomalies(anomalyService *service.AnomalyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := service.AnomalyQuery{
+			Namespace:   c.Query("namespace"),
+			Metric:      c.Query("metric"),
+			AnomalyType: c.Query("type"),
+		}
+
+		if clusterIDStr := c.Query("cluster_id"); clusterIDStr != "" {
+			id, err := strconv.ParseUint(clusterIDStr, 10, 32)
+			if err != nil {
+				response.BadRequest("集群ID格式错误", c)
+				return
+			}
+			query.ClusterID = uint(id)
+		}
+
+		hours, err := strconv.Atoi(c.DefaultQuery("hours", "24"))
+		if err != nil || hours <= 0 {
+			hours = 24
+		}
+		query.Hours = hours
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+		if err != nil || limit <= 0 || limit > 1000 {
+			limit = 100
+		}
+		query.Limit = limit
+
+		data, err := anomalyService.GetAnomalies(query)
+		if err != nil {
+			logger.Error("获取异常记录失败: %v", err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(gin.H{
+			"data":  data,
+			"hours": hours,
+			"count": len(data),
+		}, c)
+	}
+}
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"strconv"
+
+	"cluster-resource-insight/internal/collector"
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/response"
+	"cluster-resource-insight/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChangeReviewRequest 审批、拒绝或回滚变更单的请求体
+type ChangeReviewRequest struct {
+	Operator string `json:"operator"` // 操作人
+	Reason   string `json:"reason"`   // 拒绝或回滚原因
+}
+
+// parseChangeID 解析路径中的变更单ID
+func parseChangeID(c *gin.Context) (uint, bool) {
+	changeID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest("无效的变更单ID", c)
+		return 0, false
+	}
+	return uint(changeID), true
+}
+
+// ProposeChange 提交资源调整变更单 - 未指定容器配置时使用资源建议值，提交后执行服务端试运行
+func ProposeChange(changeService *service.ChangeService, multiCollector *collector.MultiClusterResourceCollector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req service.ProposeChangeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest("请求参数错误: "+err.Error(), c)
+			return
+		}
+		if !service.IsValidWorkloadKind(req.WorkloadKind) {
+			response.BadRequest("不支持的工作负载类型: "+req.WorkloadKind, c)
+			return
+		}
+
+		if len(req.Containers) == 0 {
+			containers, err := multiCollector.RecommendWorkloadResources(c.Request.Context(), req.ClusterID, req.Namespace, req.WorkloadKind, req.WorkloadName)
+			if err != nil {
+				logger.Error("计算资源建议失败: %v", err)
+				response.BadRequest(err.Error(), c)
+				return
+			}
+			req.Containers = containers
+		}
+
+		change, err := changeService.ProposeChange(c.Request.Context(), &req)
+		if err != nil {
+			logger.Error("提交资源调整变更单失败: %v", err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(change, c)
+	}
+}
+
+// ListChanges 获取资源调整变更单列表
+func ListChanges(changeService *service.ChangeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var clusterID uint
+		if clusterIDStr := c.Query("cluster_id"); clusterIDStr != "" {
+			id, err := strconv.ParseUint(clusterIDStr, 10, 32)
+			if err != nil {
+				response.BadRequest("集群ID格式错误", c)
+				return
+			}
+			clusterID = uint(id)
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit <= 0 || limit > 500 {
+			limit = 50
+		}
+
+		changes, err := changeService.ListChanges(clusterID, c.Query("status"), limit)
+		if err != nil {
+			logger.Error("查询资源调整变更单失败: %v", err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(gin.H{
+			"data":  changes,
+			"count": len(changes),
+		}, c)
+	}
+}
+
+// GetChange 获取资源调整变更单详情
+func GetChange(changeService *service.ChangeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		changeID, ok := parseChangeID(c)
+		if !ok {
+			return
+		}
+
+		change, err := changeService.GetChange(changeID)
+		if err != nil {
+			response.NotFound(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(change, c)
+	}
+}
+
+// ApproveChange 审批并应用资源调整变更单
+func ApproveChange(changeService *service.ChangeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		changeID, ok := parseChangeID(c)
+		if !ok {
+			return
+		}
+
+		var req ChangeReviewRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest("请求参数错误: "+err.Error(), c)
+			return
+		}
+		if req.Operator == "" {
+			response.BadRequest("审批人不能为空", c)
+			return
+		}
+
+		change, err := changeService.ApproveChange(c.Request.Context(), changeID, req.Operator)
+		if err != nil {
+			logger.Error("审批资源调整变更单 %d 失败: %v", changeID, err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(change, c)
+	}
+}
+
+// RejectChange 拒绝资源调整变更单
+func RejectChange(changeService *service.ChangeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		changeID, ok := parseChangeID(c)
+		if !ok {
+			return
+		}
+
+		var req ChangeReviewRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest("请求参数错误: "+err.Error(), c)
+			return
+		}
+
+		change, err := changeService.RejectChange(changeID, req.Operator, req.Reason)
+		if err != nil {
+			logger.Error("拒绝资源调整变更单 %d 失败: %v", changeID, err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(change, c)
+	}
+}
+
+// RollbackChange 回滚资源调整变更单，恢复应用前的资源配置
+func RollbackChange(changeService *service.ChangeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		changeID, ok := parseChangeID(c)
+		if !ok {
+			return
+		}
+
+		var req ChangeReviewRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest("请求参数错误: "+err.Error(), c)
+			return
+		}
+		reason := req.Reason
+		if reason == "" {
+			reason = "手动回滚"
+		}
+		if req.Operator != "" {
+			reason = req.Operator + ": " + reason
+		}
+
+		change, err := changeService.RollbackChange(c.Request.Context(), changeID, reason)
+		if err != nil {
+			logger.Error("回滚资源调整变更单 %d 失败: %v", changeID, err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(change, c)
+	}
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/response"
+	"cluster-resource-insight/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListConfigChanges 资源配置变更记录 - 按集群、命名空间、工作负载或Pod查询容器 request/limit 的变更历史
+// 路径中的命名空间、工作负载和Pod参数优先于同名查询参数
+func ListConfigChanges(configChangeService *service.ConfigChangeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query service.ConfigChangeQuery
+		if err := c.ShouldBindQuery(&query); err != nil {
+			response.BadRequest("请求参数格式错误: "+err.Error(), c)
+			return
+		}
+		if namespace := c.Param("namespace"); namespace != "" {
+			query.Namespace = namespace
+		}
+		if kind := c.Param("kind"); kind != "" {
+			query.WorkloadKind = kind
+		}
+		if name := c.Param("name"); name != "" {
+			query.WorkloadName = name
+		}
+		if pod := c.Param("pod"); pod != "" {
+			query.PodName = pod
+		}
+		if query.PodName != "" && query.ClusterID == 0 {
+			response.BadRequest("按Pod查询时必须指定cluster_id", c)
+			return
+		}
+		if !query.StartTime.IsZero() && !query.EndTime.IsZero() && query.EndTime.Before(query.StartTime) {
+			response.BadRequest("结束时间不能早于开始时间", c)
+			return
+		}
+
+		changes, err := configChangeService.ListChanges(query)
+		if err != nil {
+			logger.Error("查询资源配置变更记录失败: %v", err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(gin.H{"changes": changes, "total": len(changes)}, c)
+	}
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"strconv"
+
+	"cluster-resource-insight/internal/collector"
+	"cluster-resource-insight/internal/config"
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCostAllocation 获取成本分摊报告 - 将闲置容量和系统开销成本按策略分摊到各命名空间
+func GetCostAllocation(multiCollector *collector.MultiClusterResourceCollector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger.Info("获取成本分摊报告...")
+
+		clusterIDStr := c.Query("cluster_id")
+		idleStrategy := c.Query("idle_strategy")
+		systemStrategy := c.Query("system_strategy")
+
+		var targetClusterID *uint
+		if clusterIDStr != "" {
+			id, err := strconv.ParseUint(clusterIDStr, 10, 32)
+			if err != nil {
+				response.BadRequest("集群ID格式错误", c)
+				return
+			}
+			clusterID := uint(id)
+			targetClusterID = &clusterID
+		}
+
+		if idleStrategy != "" && !config.IsValidCostStrategy(idleStrategy) {
+			response.BadRequest("无效的闲置容量分摊策略: "+idleStrategy, c)
+			return
+		}
+		if systemStrategy != "" && !config.IsValidCostStrategy(systemStrategy) {
+			response.BadRequest("无效的系统开销分摊策略: "+systemStrategy, c)
+			return
+		}
+
+		report, err := multiCollector.GetCostAllocation(c.Request.Context(), targetClusterID, idleStrategy, systemStrategy)
+		if err != nil {
+			logger.Error("获取成本分摊报告失败: %v", err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		logger.Info("成本分摊报告生成完成: 集群数=%d, 总成本=%.2f, 闲置成本=%.2f",
+			report.ClustersAnalyzed, report.TotalCost, report.TotalIdleCost)
+
+		response.OkWithData(report, c)
+	}
+}
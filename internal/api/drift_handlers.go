@@ -0,0 +1,48 @@
+package api
+
+import (
+	"strconv"
+	"strings"
+
+	"cluster-resource-insight/internal/collector"
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetWorkloadDrift 获取跨集群配置漂移 - 对比同一工作负载在各集群中的请求、限制、副本数和实际使用量
+func GetWorkloadDrift(multiCollector *collector.MultiClusterResourceCollector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := collector.DriftAnalysisRequest{
+			Namespace:  c.Query("namespace"),
+			MatchLabel: c.Query("match_label"),
+		}
+
+		for _, part := range strings.Split(c.Query("cluster_ids"), ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			id, err := strconv.ParseUint(part, 10, 32)
+			if err != nil {
+				response.BadRequest("集群ID格式错误", c)
+				return
+			}
+			req.ClusterIDs = append(req.ClusterIDs, uint(id))
+		}
+		if len(req.ClusterIDs) == 1 {
+			response.BadRequest("至少需要选择两个集群进行对比", c)
+			return
+		}
+
+		report, err := multiCollector.AnalyzeCrossClusterDrift(c.Request.Context(), req)
+		if err != nil {
+			logger.Error("跨集群配置漂移分析失败: %v", err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(report, c)
+	}
+}
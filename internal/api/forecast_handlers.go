@@ -0,0 +1,105 @@
+package api
+
+import (
+	"strconv"
+
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/response"
+	"cluster-resource-insight/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseForecastParams 解析预测接口通用的集群ID和预测天数参数
+func parseForecastParams(c *gin.Context) (uint, int, bool) {
+	clusterIDStr := c.Query("cluster_id")
+	if clusterIDStr == "" {
+		response.BadRequest("集群ID不能为空", c)
+		return 0, 0, false
+	}
+	id, err := strconv.ParseUint(clusterIDStr, 10, 32)
+	if err != nil {
+		response.BadRequest("集群ID格式错误", c)
+		return 0, 0, false
+	}
+
+	days := 0
+	if daysStr := c.Query("days"); daysStr != "" {
+		days, err = strconv.Atoi(daysStr)
+		if err != nil || days <= 0 || days > 365 {
+			response.BadRequest("预测天数必须在1-365之间", c)
+			return 0, 0, false
+		}
+	}
+
+	return uint(id), days, true
+}
+
+// GetClusterForecast 获取集群容量预测 - 预测未来CPU/内存请求量和使用量及可分配容量耗尽时间
+func GetClusterForecast(forecastService *service.ForecastService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clusterID, days, ok := parseForecastParams(c)
+		if !ok {
+			return
+		}
+
+		result, err := forecastService.GetClusterForecast(clusterID, days)
+		if err != nil {
+			logger.Error("获取集群容量预测失败: %v", err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(result, c)
+	}
+}
+
+// GetNamespaceForecast 获取命名空间资源预测 - 配置了ResourceQuota时给出配额耗尽时间
+func GetNamespaceForecast(forecastService *service.ForecastService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clusterID, days, ok := parseForecastParams(c)
+		if !ok {
+			return
+		}
+
+		namespace := c.Query("namespace")
+		if namespace == "" {
+			response.BadRequest("命名空间不能为空", c)
+			return
+		}
+
+		result, err := forecastService.GetNamespaceForecast(clusterID, namespace, days)
+		if err != nil {
+			logger.Error("获取命名空间资源预测失败: %v", err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(result, c)
+	}
+}
+
+// GetQuotaForecasts 获取ResourceQuota耗尽预测 - 预测每个配额资源项的用尽时间
+func GetQuotaForecasts(forecastService *service.ForecastService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clusterID, days, ok := parseForecastParams(c)
+		if !ok {
+			return
+		}
+
+		namespace := c.Query("namespace")
+		data, err := forecastService.GetQuotaForecasts(clusterID, namespace, days)
+		if err != nil {
+			logger.Error("获取资源配额预测失败: %v", err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(gin.H{
+			"data":       data,
+			"cluster_id": clusterID,
+			"namespace":  namespace,
+			"count":      len(data),
+		}, c)
+	}
+}
@@ -63,7 +63,7 @@ func GetTrendData(historyService *service.HistoryService) gin.HandlerFunc {
 			hours = 24
 		}
 
-		data, err := historyService.GetTrendData(clusterID, namespace, podName, hours)
+		data, tier, err := historyService.GetTrendData(clusterID, namespace, podName, hours)
 		if err != nil {
 			logger.Error("获取趋势数据失败: %v", err)
 			response.InternalServerError(err.Error(), c)
@@ -76,6 +76,7 @@ func GetTrendData(historyService *service.HistoryService) gin.HandlerFunc {
 			"namespace":  namespace,
 			"pod_name":   podName,
 			"hours":      hours,
+			"tier":       tier,
 			"count":      len(data),
 		}, c)
 	}
@@ -148,9 +149,9 @@ func GetSystemTrendData(historyService *service.HistoryService) gin.HandlerFunc
 			hours = 24 // 默认24小时
 		}
 
-		// 限制查询范围，避免性能问题
-		if hours > 168 { // 最大7天
-			hours = 168
+		// 限制查询范围，避免性能问题；超过原始数据粒度需要的范围会使用汇总数据
+		if hours > 8760 { // 最大1年
+			hours = 8760
 		}
 
 		// 解析集群ID参数
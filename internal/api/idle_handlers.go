@@ -0,0 +1,31 @@
+package api
+
+import (
+	"strconv"
+
+	"cluster-resource-insight/internal/collector"
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetIdleResourceReport 获取闲置资源报告 - 识别闲置工作负载、持续失败的CronJob、残留Job和闲置PVC
+func GetIdleResourceReport(multiCollector *collector.MultiClusterResourceCollector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clusterID, err := strconv.ParseUint(c.Query("cluster_id"), 10, 32)
+		if err != nil {
+			response.BadRequest("集群ID格式错误", c)
+			return
+		}
+
+		report, err := multiCollector.ScanIdleResources(c.Request.Context(), uint(clusterID), c.Query("namespace"))
+		if err != nil {
+			logger.Error("扫描闲置资源失败: %v", err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(report, c)
+	}
+}
@@ -0,0 +1,20 @@
+package api
+
+import (
+	"cluster-resource-insight/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// ServeMetrics 导出 Prometheus 指标 - 以 OpenMetrics 格式返回各集群最近一次采集的分析结果
+func ServeMetrics(exporter *service.MetricsExporter) gin.HandlerFunc {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(exporter)
+	handler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{EnableOpenMetrics: true})
+
+	return func(c *gin.Context) {
+		handler.ServeHTTP(c.Writer, c.Request)
+	}
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"cluster-resource-insight/internal/collector"
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/response"
+	"cluster-resource-insight/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseOptionalClusterID 解析可选的cluster_id查询参数
+func parseOptionalClusterID(c *gin.Context) (*uint, bool) {
+	clusterIDStr := c.Query("cluster_id")
+	if clusterIDStr == "" {
+		return nil, true
+	}
+	id, err := strconv.ParseUint(clusterIDStr, 10, 32)
+	if err != nil {
+		response.BadRequest("集群ID格式错误", c)
+		return nil, false
+	}
+	clusterID := uint(id)
+	return &clusterID, true
+}
+
+// GetPolicies 获取当前生效的资源策略及无法编译的策略
+func GetPolicies() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		set, err := collector.LoadPolicies()
+		if err != nil {
+			logger.Error("加载资源策略失败: %v", err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(set, c)
+	}
+}
+
+// GetPolicyCompliance 获取最近一次策略检查的合规评分 - 集群整体及各命名空间
+func GetPolicyCompliance(policyService *service.PolicyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clusterID, ok := parseOptionalClusterID(c)
+		if !ok {
+			return
+		}
+
+		result, err := policyService.GetLatestCompliance(clusterID, c.Query("namespace"))
+		if err != nil {
+			logger.Error("获取合规评分失败: %v", err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(result, c)
+	}
+}
+
+// GetPolicyComplianceHistory 获取合规评分历史 - 未指定命名空间时返回集群整体评分
+func GetPolicyComplianceHistory(policyService *service.PolicyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clusterID, ok := parseOptionalClusterID(c)
+		if !ok {
+			return
+		}
+		if clusterID == nil {
+			response.BadRequest("集群ID不能为空", c)
+			return
+		}
+
+		days := 7
+		if daysStr := c.Query("days"); daysStr != "" {
+			parsed, err := strconv.Atoi(daysStr)
+			if err != nil || parsed <= 0 || parsed > service.PolicyHistoryDays() {
+				response.BadRequest("天数必须在1到合规历史保留天数之间", c)
+				return
+			}
+			days = parsed
+		}
+
+		history, err := policyService.GetComplianceHistory(*clusterID, c.Query("namespace"), time.Now().AddDate(0, 0, -days))
+		if err != nil {
+			logger.Error("获取合规评分历史失败: %v", err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(history, c)
+	}
+}
+
+// GetPolicyViolations 获取违规列表 - 默认只返回未解决的违规
+func GetPolicyViolations(policyService *service.PolicyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clusterID, ok := parseOptionalClusterID(c)
+		if !ok {
+			return
+		}
+
+		req := service.PolicyViolationQuery{
+			ClusterID:       clusterID,
+			Namespace:       c.Query("namespace"),
+			PolicyName:      c.Query("policy"),
+			Severity:        c.Query("severity"),
+			IncludeResolved: c.Query("include_resolved") == "true",
+			Limit:           500,
+		}
+		if limitStr := c.Query("limit"); limitStr != "" {
+			limit, err := strconv.Atoi(limitStr)
+			if err != nil || limit <= 0 || limit > 5000 {
+				response.BadRequest("limit必须在1-5000之间", c)
+				return
+			}
+			req.Limit = limit
+		}
+
+		violations, err := policyService.GetViolations(req)
+		if err != nil {
+			logger.Error("获取违规列表失败: %v", err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(gin.H{"violations": violations, "total": len(violations)}, c)
+	}
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"strconv"
+
+	"cluster-resource-insight/internal/collector"
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetQoSDistribution 获取QoS分布统计 - 按命名空间和节点汇总QoS等级分布，并评估Pod驱逐风险
+func GetQoSDistribution(multiCollector *collector.MultiClusterResourceCollector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var targetClusterID *uint
+		if clusterIDStr := c.Query("cluster_id"); clusterIDStr != "" {
+			id, err := strconv.ParseUint(clusterIDStr, 10, 32)
+			if err != nil {
+				response.BadRequest("集群ID格式错误", c)
+				return
+			}
+			clusterID := uint(id)
+			targetClusterID = &clusterID
+		}
+
+		report, err := multiCollector.GetQoSDistribution(c.Request.Context(), targetClusterID)
+		if err != nil {
+			logger.Error("获取QoS分布统计失败: %v", err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(report, c)
+	}
+}
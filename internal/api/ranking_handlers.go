@@ -0,0 +1,54 @@
+package api
+
+import (
+	"strconv"
+
+	"cluster-resource-insight/internal/collector"
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetProblemRanking 获取问题严重程度排名 - 按加权因子为Pod或工作负载打分，返回各因子得分明细
+func GetProblemRanking(multiCollector *collector.MultiClusterResourceCollector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clusterID, ok := parseOptionalClusterID(c)
+		if !ok {
+			return
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit <= 0 || limit > 5000 {
+			response.BadRequest("limit必须在1-5000之间", c)
+			return
+		}
+
+		level := c.DefaultQuery("level", collector.RankingLevelPod)
+		if level != collector.RankingLevelPod && level != collector.RankingLevelWorkload {
+			response.BadRequest("level必须为pod或workload", c)
+			return
+		}
+
+		result, err := multiCollector.RankProblems(c.Request.Context(), collector.RankingRequest{
+			ClusterID: clusterID,
+			Namespace: c.Query("namespace"),
+			Level:     level,
+			Limit:     limit,
+		})
+		if err != nil {
+			logger.Error("获取问题严重程度排名失败: %v", err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(result, c)
+	}
+}
+
+// GetRankingFactors 获取当前参与排名的因子及权重
+func GetRankingFactors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		response.OkWithData(collector.GetRankingFactors(), c)
+	}
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/response"
+	"cluster-resource-insight/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRollupHistory 查询降采样汇总数据 - 按Pod、工作负载或命名空间返回小时/天级的平均值、P95、最大值和最小值
+func GetRollupHistory(rollupService *service.RollupService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clusterID, ok := parseOptionalClusterID(c)
+		if !ok {
+			return
+		}
+
+		scope := c.DefaultQuery("scope", service.RollupScopeNamespace)
+		if scope != service.RollupScopePod && scope != service.RollupScopeWorkload && scope != service.RollupScopeNamespace {
+			response.BadRequest("scope 仅支持 pod、workload、namespace", c)
+			return
+		}
+
+		tier := c.DefaultQuery("tier", service.TierAuto)
+		if tier != service.TierAuto && tier != service.TierHourly && tier != service.TierDaily {
+			response.BadRequest("tier 仅支持 auto、hourly、daily", c)
+			return
+		}
+
+		// 时间范围：优先使用 start/end（RFC3339），否则按 hours 回溯
+		end := time.Now()
+		if endStr := c.Query("end"); endStr != "" {
+			parsed, err := time.Parse(time.RFC3339, endStr)
+			if err != nil {
+				response.BadRequest("结束时间格式错误，应为RFC3339格式", c)
+				return
+			}
+			end = parsed
+		}
+		var start time.Time
+		if startStr := c.Query("start"); startStr != "" {
+			parsed, err := time.Parse(time.RFC3339, startStr)
+			if err != nil {
+				response.BadRequest("开始时间格式错误，应为RFC3339格式", c)
+				return
+			}
+			start = parsed
+		} else {
+			hours, err := strconv.Atoi(c.DefaultQuery("hours", "168"))
+			if err != nil || hours <= 0 {
+				hours = 168
+			}
+			start = end.Add(-time.Duration(hours) * time.Hour)
+		}
+		if !start.Before(end) {
+			response.BadRequest("开始时间必须早于结束时间", c)
+			return
+		}
+
+		query := service.RollupQuery{
+			Scope:        scope,
+			Namespace:    c.Query("namespace"),
+			WorkloadKind: c.Query("workload_kind"),
+			WorkloadName: c.Query("workload_name"),
+			PodName:      c.Query("pod_name"),
+			Start:        start,
+			End:          end,
+			Tier:         tier,
+		}
+		if clusterID != nil {
+			query.ClusterID = *clusterID
+		}
+
+		series, err := rollupService.QueryRollups(query)
+		if err != nil {
+			logger.Error("查询汇总数据失败: %v", err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(gin.H{
+			"data":  series,
+			"count": len(series.Data),
+		}, c)
+	}
+}
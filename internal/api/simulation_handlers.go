@@ -0,0 +1,72 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"cluster-resource-insight/internal/collector"
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SimulateBinPacking 装箱模拟 - 按资源建议或自定义请求量重新装箱，评估可下线的节点数量
+func SimulateBinPacking(multiCollector *collector.MultiClusterResourceCollector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req collector.BinPackingRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest("请求参数错误: "+err.Error(), c)
+			return
+		}
+
+		switch req.Mode {
+		case "", collector.SimulationModeCurrent, collector.SimulationModeRecommendations:
+		case collector.SimulationModeOverrides:
+			if len(req.Overrides) == 0 {
+				response.BadRequest("overrides模式需要提供请求量覆盖列表", c)
+				return
+			}
+		default:
+			response.BadRequest("无效的模拟模式: "+req.Mode, c)
+			return
+		}
+
+		result, err := multiCollector.SimulateBinPacking(c.Request.Context(), req)
+		if err != nil {
+			logger.Error("装箱模拟失败: %v", err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(result, c)
+	}
+}
+
+// ExportRecommendations 导出资源建议 - 生成strategic-merge补丁、kustomize补丁、kubectl脚本和Helm values片段的归档下载
+func ExportRecommendations(multiCollector *collector.MultiClusterResourceCollector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req collector.RecommendationExportRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest("请求参数错误: "+err.Error(), c)
+			return
+		}
+
+		switch req.Format {
+		case "", collector.ExportFormatZip, collector.ExportFormatTar:
+		default:
+			response.BadRequest("无效的导出格式: "+req.Format, c)
+			return
+		}
+
+		export, err := multiCollector.ExportRecommendations(c.Request.Context(), req)
+		if err != nil {
+			logger.Error("导出资源建议失败: %v", err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.FileName))
+		c.Data(http.StatusOK, export.ContentType, export.Content)
+	}
+}
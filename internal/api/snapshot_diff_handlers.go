@@ -0,0 +1,80 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/response"
+	"cluster-resource-insight/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSnapshotDiff 采集快照对比 - 按两个时间点或两个采集批次ID计算Pod、工作负载、规格和使用量的变更集
+func GetSnapshotDiff(diffService *service.SnapshotDiffService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req service.SnapshotDiffRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			response.BadRequest("请求参数格式错误: "+err.Error(), c)
+			return
+		}
+
+		if err := diffService.Validate(&req); err != nil {
+			response.BadRequest(err.Error(), c)
+			return
+		}
+
+		result, err := diffService.Diff(c.Request.Context(), req)
+		if err != nil {
+			if errors.Is(err, service.ErrSnapshotNotFound) {
+				response.NotFound(err.Error(), c)
+				return
+			}
+			logger.Error("采集快照对比失败: %v", err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(result, c)
+	}
+}
+
+// GetCollectionRuns 获取采集批次列表 - 供快照对比选择批次ID
+func GetCollectionRuns(diffService *service.SnapshotDiffService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var clusterID uint
+		if clusterIDStr := c.Query("cluster_id"); clusterIDStr != "" {
+			id, err := strconv.ParseUint(clusterIDStr, 10, 32)
+			if err != nil {
+				response.BadRequest("集群ID格式错误", c)
+				return
+			}
+			clusterID = uint(id)
+		}
+
+		hours, err := strconv.Atoi(c.DefaultQuery("hours", "48"))
+		if err != nil || hours <= 0 {
+			hours = 48
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "200"))
+		if err != nil || limit <= 0 || limit > 1000 {
+			limit = 200
+		}
+
+		runs, err := diffService.ListRuns(clusterID, time.Now().Add(-time.Duration(hours)*time.Hour), time.Time{}, limit)
+		if err != nil {
+			logger.Error("获取采集批次失败: %v", err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(gin.H{
+			"data":       runs,
+			"cluster_id": clusterID,
+			"hours":      hours,
+			"count":      len(runs),
+		}, c)
+	}
+}
@@ -188,3 +188,28 @@ func GetResourceDistribution(multiCollector *collector.MultiClusterResourceColle
 		response.OkWithData(stats, c)
 	}
 }
+
+// GetNodeOvercommit 获取节点超售统计 - 对比各节点限制量、使用量与可分配量，列出超售贡献最大的Pod
+func GetNodeOvercommit(multiCollector *collector.MultiClusterResourceCollector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var targetClusterID *uint
+		if clusterIDStr := c.Query("cluster_id"); clusterIDStr != "" {
+			id, err := strconv.ParseUint(clusterIDStr, 10, 32)
+			if err != nil {
+				response.BadRequest("集群ID格式错误", c)
+				return
+			}
+			clusterID := uint(id)
+			targetClusterID = &clusterID
+		}
+
+		stats, err := multiCollector.GetNodeOvercommitStats(c.Request.Context(), targetClusterID)
+		if err != nil {
+			logger.Error("获取节点超售统计失败: %v", err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(stats, c)
+	}
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"strconv"
+
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/response"
+	"cluster-resource-insight/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseRequiredClusterID 解析必填的集群ID参数
+func parseRequiredClusterID(c *gin.Context) (uint, bool) {
+	clusterIDStr := c.Query("cluster_id")
+	if clusterIDStr == "" {
+		response.BadRequest("集群ID不能为空", c)
+		return 0, false
+	}
+	id, err := strconv.ParseUint(clusterIDStr, 10, 32)
+	if err != nil {
+		response.BadRequest("集群ID格式错误", c)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// GetUsageHeatmap 获取低谷时段热力图 - 按星期和小时返回工作负载或命名空间的相对负载和利用率
+func GetUsageHeatmap(profileService *service.UsageProfileService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clusterID, ok := parseRequiredClusterID(c)
+		if !ok {
+			return
+		}
+
+		namespace := c.Query("namespace")
+		if namespace == "" {
+			response.BadRequest("命名空间不能为空", c)
+			return
+		}
+
+		workloadKind := c.Query("workload_kind")
+		workloadName := c.Query("workload_name")
+		if (workloadKind == "") != (workloadName == "") {
+			response.BadRequest("workload_kind 和 workload_name 需要同时指定", c)
+			return
+		}
+
+		heatmap, err := profileService.GetUsageHeatmap(clusterID, namespace, workloadKind, workloadName)
+		if err != nil {
+			logger.Error("获取使用画像热力图失败: %v", err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(heatmap, c)
+	}
+}
+
+// GetScaleScheduleRecommendations 获取定时伸缩建议 - 返回低谷时段、cron表达式、KEDA配置和预计节省
+func GetScaleScheduleRecommendations(profileService *service.UsageProfileService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clusterID, ok := parseRequiredClusterID(c)
+		if !ok {
+			return
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit <= 0 || limit > 500 {
+			limit = 50
+		}
+
+		report, err := profileService.GetScaleScheduleRecommendations(clusterID, c.Query("namespace"), limit)
+		if err != nil {
+			logger.Error("获取定时伸缩建议失败: %v", err)
+			response.InternalServerError(err.Error(), c)
+			return
+		}
+
+		response.OkWithData(report, c)
+	}
+}
@@ -0,0 +1,120 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/models"
+	"cluster-resource-insight/internal/service"
+)
+
+// SnapshotRecommendations 为集群中可调整的工作负载生成资源建议并记录快照，作为后续采纳跟踪的基准
+// 参数:
+//   - ctx: 上下文对象
+//   - clusterID: 集群ID
+//   - namespace: 命名空间筛选，为空时记录全部命名空间
+//
+// 返回:
+//   - int: 新记录的快照数量
+//   - error: 收集或保存过程中的错误信息
+func (mc *MultiClusterResourceCollector) SnapshotRecommendations(ctx context.Context, clusterID uint, namespace string) (int, error) {
+	cluster, err := mc.clusterService.GetClusterByID(clusterID)
+	if err != nil {
+		return 0, fmt.Errorf("获取集群配置失败: %v", err)
+	}
+
+	singleCollector, err := mc.newClusterCollector(cluster)
+	if err != nil {
+		return 0, err
+	}
+
+	pods, err := singleCollector.collectAllPodsWithoutFiltering(ctx, cluster.ClusterName)
+	if err != nil {
+		return 0, fmt.Errorf("收集集群 %s Pod数据失败: %v", cluster.ClusterName, err)
+	}
+
+	var workloads []WorkloadRecommendation
+	for _, workload := range BuildWorkloadRecommendations(pods) {
+		if namespace != "" && workload.Namespace != namespace {
+			continue
+		}
+		if _, ok := patchableWorkloadKinds[workload.WorkloadKind]; !ok || !hasMetricsBasedContainer(workload) {
+			continue
+		}
+		workloads = append(workloads, workload)
+	}
+
+	saved, err := mc.saveRecommendationSnapshots(ctx, singleCollector, cluster, workloads, service.RecommendationSourceManual)
+	if err != nil {
+		return 0, err
+	}
+
+	logger.Info("集群 %s 记录资源建议快照 %d 条", cluster.ClusterName, saved)
+	return saved, nil
+}
+
+// recordRecommendationSnapshots 在建议生成时记录快照，失败只记录日志，不影响建议本身的返回
+func (mc *MultiClusterResourceCollector) recordRecommendationSnapshots(ctx context.Context, singleCollector *ResourceCollector, cluster *models.ClusterConfig, workloads []WorkloadRecommendation, source string) {
+	if mc.adoptionService == nil {
+		return
+	}
+	if _, err := mc.saveRecommendationSnapshots(ctx, singleCollector, cluster, workloads, source); err != nil {
+		logger.Error("记录集群 %s 资源建议快照失败: %v", cluster.ClusterName, err)
+	}
+}
+
+// saveRecommendationSnapshots 将工作负载建议按单Pod汇总后保存为快照，团队取自命名空间标签
+func (mc *MultiClusterResourceCollector) saveRecommendationSnapshots(ctx context.Context, singleCollector *ResourceCollector, cluster *models.ClusterConfig, workloads []WorkloadRecommendation, source string) (int, error) {
+	if len(workloads) == 0 {
+		return 0, nil
+	}
+
+	teams, err := singleCollector.collectNamespaceTeams(ctx, mc.adoptionService.TeamLabel())
+	if err != nil {
+		// 团队信息缺失不影响快照记录
+		logger.Warn("获取集群 %s 命名空间团队标签失败: %v", cluster.ClusterName, err)
+	}
+
+	inputs := make([]service.RecommendationSnapshotInput, 0, len(workloads))
+	for _, workload := range workloads {
+		input := service.RecommendationSnapshotInput{
+			ClusterID:    cluster.ID,
+			Namespace:    workload.Namespace,
+			Team:         teams[workload.Namespace],
+			WorkloadKind: workload.WorkloadKind,
+			WorkloadName: workload.WorkloadName,
+			Source:       source,
+			PodCount:     workload.PodCount,
+		}
+		for _, container := range workload.Containers {
+			input.CurrentCPURequest += container.CurrentCPURequest
+			input.CurrentCPULimit += container.CurrentCPULimit
+			input.CurrentMemoryRequest += container.CurrentMemoryRequest
+			input.CurrentMemoryLimit += container.CurrentMemoryLimit
+			input.RecommendedCPURequest += container.RecommendedCPURequest
+			input.RecommendedCPULimit += container.RecommendedCPULimit
+			input.RecommendedMemoryRequest += container.RecommendedMemoryRequest
+			input.RecommendedMemoryLimit += container.RecommendedMemoryLimit
+		}
+		inputs = append(inputs, input)
+	}
+
+	return mc.adoptionService.SaveRecommendationSnapshots(inputs)
+}
+
+// collectNamespaceTeams 读取各命名空间的团队标签
+func (rc *ResourceCollector) collectNamespaceTeams(ctx context.Context, teamLabel string) (map[string]string, error) {
+	namespaceLabels, err := rc.collectNamespaceLabels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	teams := make(map[string]string, len(namespaceLabels))
+	for namespace, labels := range namespaceLabels {
+		if team := labels[teamLabel]; team != "" {
+			teams[namespace] = team
+		}
+	}
+	return teams, nil
+}
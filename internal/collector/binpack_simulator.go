@@ -0,0 +1,426 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"cluster-resource-insight/internal/logger"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// 模拟调度使用的请求量来源
+const (
+	SimulationModeCurrent         = "current"         // 使用当前请求量，评估现有碎片化程度
+	SimulationModeRecommendations = "recommendations" // 使用资源建议值
+	SimulationModeOverrides       = "overrides"       // 使用用户提供的请求量覆盖
+)
+
+// RequestOverride 用户提供的请求量覆盖 - 按工作负载或Pod名称匹配，值为单个Pod的请求总量
+type RequestOverride struct {
+	Namespace     string `json:"namespace"`      // 命名空间
+	WorkloadName  string `json:"workload_name"`  // 工作负载名称，优先匹配
+	PodName       string `json:"pod_name"`       // Pod名称，工作负载名称为空时使用
+	CPURequest    int64  `json:"cpu_request"`    // CPU请求量 (millicores)，0表示保持不变
+	MemoryRequest int64  `json:"memory_request"` // 内存请求量 (bytes)，0表示保持不变
+}
+
+// BinPackingRequest 装箱模拟请求
+type BinPackingRequest struct {
+	ClusterID uint              `json:"cluster_id" binding:"required"` // 集群ID
+	Mode      string            `json:"mode"`                          // 请求量来源：current/recommendations/overrides
+	Overrides []RequestOverride `json:"overrides"`                     // 请求量覆盖列表，overrides模式使用
+}
+
+// SimulatedNode 模拟后的节点状态
+type SimulatedNode struct {
+	NodeName               string  `json:"node_name"`                // 节点名称
+	AllocatableCPU         int64   `json:"allocatable_cpu"`          // 可分配CPU (millicores)
+	AllocatableMemory      int64   `json:"allocatable_memory"`       // 可分配内存 (bytes)
+	CurrentCPURequest      int64   `json:"current_cpu_request"`      // 当前CPU请求量
+	CurrentMemoryRequest   int64   `json:"current_memory_request"`   // 当前内存请求量
+	CurrentPodCount        int     `json:"current_pod_count"`        // 当前Pod数量
+	SimulatedCPURequest    int64   `json:"simulated_cpu_request"`    // 模拟后CPU请求量
+	SimulatedMemoryRequest int64   `json:"simulated_memory_request"` // 模拟后内存请求量
+	SimulatedPodCount      int     `json:"simulated_pod_count"`      // 模拟后Pod数量
+	CPUUtilizationPct      float64 `json:"cpu_utilization_pct"`      // 模拟后CPU请求占比
+	MemoryUtilizationPct   float64 `json:"memory_utilization_pct"`   // 模拟后内存请求占比
+	Drainable              bool    `json:"drainable"`                // 是否可以排空下线
+	Excluded               bool    `json:"excluded"`                 // 是否不参与调度（不可调度或未就绪）
+}
+
+// UnplacedPod 模拟中无法放置的Pod
+type UnplacedPod struct {
+	Namespace string `json:"namespace"`
+	PodName   string `json:"pod_name"`
+	Reason    string `json:"reason"`
+}
+
+// BinPackingResult 装箱模拟结果
+type BinPackingResult struct {
+	ClusterID                 uint            `json:"cluster_id"`
+	ClusterName               string          `json:"cluster_name"`
+	Mode                      string          `json:"mode"`
+	CurrentNodeCount          int             `json:"current_node_count"`          // 当前可调度节点数
+	MinimumNodeCount          int             `json:"minimum_node_count"`          // 模拟后所需最少节点数
+	RemovableNodeCount        int             `json:"removable_node_count"`        // 可下线节点数
+	DrainableNodes            []string        `json:"drainable_nodes"`             // 可排空的节点列表
+	TotalCPURequestBefore     int64           `json:"total_cpu_request_before"`    // 模拟前CPU请求总量
+	TotalCPURequestAfter      int64           `json:"total_cpu_request_after"`     // 模拟后CPU请求总量
+	TotalMemoryRequestBefore  int64           `json:"total_memory_request_before"` // 模拟前内存请求总量
+	TotalMemoryRequestAfter   int64           `json:"total_memory_request_after"`  // 模拟后内存请求总量
+	Nodes                     []SimulatedNode `json:"nodes"`                       // 各节点模拟结果
+	UnplacedPods              []UnplacedPod   `json:"unplaced_pods"`               // 无法放置的Pod
+	ApproximationDescriptions []string        `json:"approximation_descriptions"`  // 模拟的近似说明
+	GeneratedAt               time.Time       `json:"generated_at"`
+}
+
+// simPod 模拟中的Pod
+type simPod struct {
+	info          *PodResourceInfo
+	cpuRequest    int64
+	memoryRequest int64
+}
+
+// simNode 模拟中的节点
+type simNode struct {
+	info       NodeCapacityInfo
+	result     *SimulatedNode
+	freeCPU    int64
+	freeMemory int64
+	pods       []*simPod
+	opened     bool
+}
+
+// BinPackingSimulator 装箱模拟器 - 按调度器约束把Pod重新装入尽量少的节点
+type BinPackingSimulator struct {
+	mode      string
+	overrides []RequestOverride
+}
+
+// NewBinPackingSimulator 创建装箱模拟器
+func NewBinPackingSimulator(mode string, overrides []RequestOverride) *BinPackingSimulator {
+	if mode == "" {
+		mode = SimulationModeRecommendations
+	}
+	return &BinPackingSimulator{mode: mode, overrides: overrides}
+}
+
+// Simulate 执行装箱模拟
+// 采用首次适应递减算法：Pod按资源占比从大到小排序，优先放入已启用节点，放不下时再启用新节点，
+// 最终未启用的可调度节点即为可排空节点。DaemonSet和静态Pod随节点保留，不参与重新调度。
+func (bs *BinPackingSimulator) Simulate(nodes []NodeCapacityInfo, pods []PodResourceInfo) *BinPackingResult {
+	result := &BinPackingResult{
+		Mode:           bs.mode,
+		DrainableNodes: []string{},
+		Nodes:          []SimulatedNode{},
+		UnplacedPods:   []UnplacedPod{},
+		ApproximationDescriptions: []string{
+			"仅考虑CPU和内存请求量、nodeSelector、NoSchedule/NoExecute污点与容忍",
+			"反亲和仅处理拓扑域为 kubernetes.io/hostname 的必需规则",
+			"未考虑节点亲和表达式、拓扑分布约束、PodDisruptionBudget和本地存储",
+		},
+		GeneratedAt: time.Now(),
+	}
+
+	nodeMap := make(map[string]*simNode)
+	var candidates []*simNode
+	var totalCPU, totalMemory int64
+	for i := range nodes {
+		node := &simNode{
+			info:       nodes[i],
+			freeCPU:    nodes[i].AllocatableCPU,
+			freeMemory: nodes[i].AllocatableMemory,
+			result: &SimulatedNode{
+				NodeName:          nodes[i].NodeName,
+				AllocatableCPU:    nodes[i].AllocatableCPU,
+				AllocatableMemory: nodes[i].AllocatableMemory,
+				Excluded:          nodes[i].Unschedulable || !nodes[i].Ready,
+			},
+		}
+		nodeMap[node.info.NodeName] = node
+		if !node.result.Excluded {
+			candidates = append(candidates, node)
+			totalCPU += node.info.AllocatableCPU
+			totalMemory += node.info.AllocatableMemory
+		}
+	}
+	result.CurrentNodeCount = len(candidates)
+
+	// 统计当前状态，并预留DaemonSet Pod的资源
+	var movable []*simPod
+	for i := range pods {
+		pod := &pods[i]
+		currentCPU, currentMemory := podContainerRequests(pod)
+		sp := &simPod{info: pod}
+		sp.cpuRequest, sp.memoryRequest = bs.simulatedRequests(pod, currentCPU, currentMemory)
+
+		result.TotalCPURequestBefore += currentCPU
+		result.TotalMemoryRequestBefore += currentMemory
+		result.TotalCPURequestAfter += sp.cpuRequest
+		result.TotalMemoryRequestAfter += sp.memoryRequest
+
+		node := nodeMap[pod.NodeName]
+		if node != nil {
+			node.result.CurrentCPURequest += currentCPU
+			node.result.CurrentMemoryRequest += currentMemory
+			node.result.CurrentPodCount++
+		}
+
+		if pod.Scheduling != nil && pod.Scheduling.IsDaemonSet {
+			if node != nil && !node.result.Excluded {
+				node.place(sp)
+			}
+			continue
+		}
+		movable = append(movable, sp)
+	}
+
+	// 节点按容量从大到小、当前负载从高到低排序，优先保留大节点和繁忙节点
+	sort.SliceStable(candidates, func(i, j int) bool {
+		si := share(candidates[i].info.AllocatableCPU, totalCPU) + share(candidates[i].info.AllocatableMemory, totalMemory)
+		sj := share(candidates[j].info.AllocatableCPU, totalCPU) + share(candidates[j].info.AllocatableMemory, totalMemory)
+		if si != sj {
+			return si > sj
+		}
+		li := share(candidates[i].result.CurrentCPURequest, totalCPU) + share(candidates[i].result.CurrentMemoryRequest, totalMemory)
+		lj := share(candidates[j].result.CurrentCPURequest, totalCPU) + share(candidates[j].result.CurrentMemoryRequest, totalMemory)
+		if li != lj {
+			return li > lj
+		}
+		return candidates[i].info.NodeName < candidates[j].info.NodeName
+	})
+
+	// Pod按主导资源占比从大到小排序
+	sort.SliceStable(movable, func(i, j int) bool {
+		di := maxFloat(share(movable[i].cpuRequest, totalCPU), share(movable[i].memoryRequest, totalMemory))
+		dj := maxFloat(share(movable[j].cpuRequest, totalCPU), share(movable[j].memoryRequest, totalMemory))
+		return di > dj
+	})
+
+	for _, sp := range movable {
+		placed := false
+		lastReason := "没有可调度节点"
+
+		// 先尝试已启用节点，再按顺序启用新节点
+		for _, opened := range []bool{true, false} {
+			for _, node := range candidates {
+				if node.opened != opened {
+					continue
+				}
+				if ok, reason := node.fits(sp); !ok {
+					lastReason = reason
+					continue
+				}
+				node.place(sp)
+				node.opened = true
+				placed = true
+				break
+			}
+			if placed {
+				break
+			}
+		}
+
+		if !placed {
+			result.UnplacedPods = append(result.UnplacedPods, UnplacedPod{
+				Namespace: sp.info.Namespace,
+				PodName:   sp.info.PodName,
+				Reason:    lastReason,
+			})
+		}
+	}
+
+	for _, node := range nodes {
+		sn := nodeMap[node.NodeName]
+		if !sn.result.Excluded {
+			if sn.opened {
+				result.MinimumNodeCount++
+			} else {
+				sn.result.Drainable = true
+				result.DrainableNodes = append(result.DrainableNodes, node.NodeName)
+			}
+		}
+		for _, p := range sn.pods {
+			sn.result.SimulatedCPURequest += p.cpuRequest
+			sn.result.SimulatedMemoryRequest += p.memoryRequest
+			sn.result.SimulatedPodCount++
+		}
+		if sn.info.AllocatableCPU > 0 {
+			sn.result.CPUUtilizationPct = float64(sn.result.SimulatedCPURequest) / float64(sn.info.AllocatableCPU) * 100
+		}
+		if sn.info.AllocatableMemory > 0 {
+			sn.result.MemoryUtilizationPct = float64(sn.result.SimulatedMemoryRequest) / float64(sn.info.AllocatableMemory) * 100
+		}
+		result.Nodes = append(result.Nodes, *sn.result)
+	}
+	result.RemovableNodeCount = len(result.DrainableNodes)
+
+	return result
+}
+
+// simulatedRequests 根据模拟模式计算Pod的请求量
+func (bs *BinPackingSimulator) simulatedRequests(pod *PodResourceInfo, currentCPU, currentMemory int64) (int64, int64) {
+	switch bs.mode {
+	case SimulationModeRecommendations:
+		if len(pod.Containers) == 0 {
+			return currentCPU, currentMemory
+		}
+		return BuildPodRecommendation(*pod).RecommendedRequests()
+	case SimulationModeOverrides:
+		for _, override := range bs.overrides {
+			if override.Namespace != pod.Namespace {
+				continue
+			}
+			if (override.WorkloadName != "" && override.WorkloadName == pod.WorkloadName) ||
+				(override.WorkloadName == "" && override.PodName == pod.PodName) {
+				cpu, memory := currentCPU, currentMemory
+				if override.CPURequest > 0 {
+					cpu = override.CPURequest
+				}
+				if override.MemoryRequest > 0 {
+					memory = override.MemoryRequest
+				}
+				return cpu, memory
+			}
+		}
+	}
+	return currentCPU, currentMemory
+}
+
+// fits 判断Pod能否放入节点，不能放入时返回原因
+func (n *simNode) fits(sp *simPod) (bool, string) {
+	if sp.cpuRequest > n.freeCPU || sp.memoryRequest > n.freeMemory {
+		return false, "节点剩余资源不足"
+	}
+
+	scheduling := sp.info.Scheduling
+	if scheduling == nil {
+		return true, ""
+	}
+
+	for key, value := range scheduling.NodeSelector {
+		if n.info.Labels[key] != value {
+			return false, fmt.Sprintf("不满足nodeSelector %s=%s", key, value)
+		}
+	}
+
+	for i := range n.info.Taints {
+		taint := &n.info.Taints[i]
+		if taint.Effect != corev1.TaintEffectNoSchedule && taint.Effect != corev1.TaintEffectNoExecute {
+			continue
+		}
+		if !toleratesTaint(scheduling.Tolerations, taint) {
+			return false, fmt.Sprintf("无法容忍污点 %s", taint.ToString())
+		}
+	}
+
+	// 双向检查反亲和：新Pod不能与已放置的匹配Pod同节点，已放置Pod的规则也不能匹配新Pod
+	podLabels := labels.Set(scheduling.Labels)
+	for _, placed := range n.pods {
+		if placed.info.Namespace != sp.info.Namespace || placed.info.Scheduling == nil {
+			continue
+		}
+		placedLabels := labels.Set(placed.info.Scheduling.Labels)
+		for _, selector := range scheduling.AntiAffinitySelectors {
+			if selector.Matches(placedLabels) {
+				return false, "违反Pod反亲和规则"
+			}
+		}
+		for _, selector := range placed.info.Scheduling.AntiAffinitySelectors {
+			if selector.Matches(podLabels) {
+				return false, "违反Pod反亲和规则"
+			}
+		}
+	}
+
+	return true, ""
+}
+
+// place 将Pod放入节点
+func (n *simNode) place(sp *simPod) {
+	n.pods = append(n.pods, sp)
+	n.freeCPU -= sp.cpuRequest
+	n.freeMemory -= sp.memoryRequest
+}
+
+// toleratesTaint 判断容忍列表是否能容忍指定污点
+func toleratesTaint(tolerations []corev1.Toleration, taint *corev1.Taint) bool {
+	for i := range tolerations {
+		if tolerations[i].ToleratesTaint(taint) {
+			return true
+		}
+	}
+	return false
+}
+
+// podContainerRequests 计算Pod清单中的真实请求总量（不含默认填充值）
+func podContainerRequests(pod *PodResourceInfo) (int64, int64) {
+	if len(pod.Containers) == 0 {
+		return pod.CPURequest, pod.MemoryRequest
+	}
+	var cpu, memory int64
+	for _, container := range pod.Containers {
+		cpu += container.CPURequest
+		memory += container.MemoryRequest
+	}
+	return cpu, memory
+}
+
+// share 计算数值在总量中的占比
+func share(value, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(value) / float64(total)
+}
+
+// maxFloat 返回两个float64中的较大值
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// SimulateBinPacking 对指定集群执行装箱模拟
+// 参数:
+//   - ctx: 上下文对象，用于控制请求生命周期和超时
+//   - req: 模拟请求参数
+//
+// 返回:
+//   - *BinPackingResult: 模拟结果，包含最少节点数、各节点利用率和可排空节点
+//   - error: 模拟过程中的错误信息
+func (mc *MultiClusterResourceCollector) SimulateBinPacking(ctx context.Context, req BinPackingRequest) (*BinPackingResult, error) {
+	cluster, err := mc.clusterService.GetClusterByID(req.ClusterID)
+	if err != nil {
+		return nil, fmt.Errorf("获取集群配置失败: %v", err)
+	}
+
+	singleCollector, err := mc.newClusterCollector(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := singleCollector.collectNodeCapacity(ctx, cluster.ClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("获取集群 %s 节点容量失败: %v", cluster.ClusterName, err)
+	}
+
+	pods, err := singleCollector.collectAllPodsWithoutFiltering(ctx, cluster.ClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("收集集群 %s Pod数据失败: %v", cluster.ClusterName, err)
+	}
+
+	result := NewBinPackingSimulator(req.Mode, req.Overrides).Simulate(nodes, pods)
+	result.ClusterID = cluster.ID
+	result.ClusterName = cluster.ClusterName
+
+	logger.Info("集群 %s 装箱模拟完成: 当前节点 %d 个, 最少需要 %d 个, 可排空 %d 个, 无法放置Pod %d 个",
+		cluster.ClusterName, result.CurrentNodeCount, result.MinimumNodeCount, result.RemovableNodeCount, len(result.UnplacedPods))
+
+	return result, nil
+}
@@ -0,0 +1,248 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const gib = int64(1) << 30
+
+func testNode(name string, cpu, memoryGiB int64) NodeCapacityInfo {
+	return NodeCapacityInfo{NodeName: name, AllocatableCPU: cpu, AllocatableMemory: memoryGiB * gib, Ready: true}
+}
+
+func testPod(name, node string, cpu, memoryGiB int64) PodResourceInfo {
+	return PodResourceInfo{
+		PodName:       name,
+		Namespace:     "default",
+		NodeName:      node,
+		WorkloadKind:  "Deployment",
+		WorkloadName:  "web",
+		CPURequest:    cpu,
+		MemoryRequest: memoryGiB * gib,
+		Containers:    []ContainerResourceInfo{{Name: "app", CPURequest: cpu, MemoryRequest: memoryGiB * gib}},
+	}
+}
+
+func withScheduling(pod PodResourceInfo, scheduling *PodSchedulingInfo) PodResourceInfo {
+	pod.Scheduling = scheduling
+	return pod
+}
+
+func TestBinPackingSimulate(t *testing.T) {
+	webSelector := labels.SelectorFromSet(labels.Set{"app": "web"})
+	antiAffinity := &PodSchedulingInfo{Labels: map[string]string{"app": "web"}, AntiAffinitySelectors: []labels.Selector{webSelector}}
+	noSchedule := corev1.Taint{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}
+
+	ssdNode := testNode("node-b", 4000, 8)
+	ssdNode.Labels = map[string]string{"disk": "ssd"}
+	taintedNode := testNode("node-a", 8000, 16)
+	taintedNode.Taints = []corev1.Taint{noSchedule}
+	unschedulable := testNode("node-b", 4000, 8)
+	unschedulable.Unschedulable = true
+	notReady := testNode("node-c", 4000, 8)
+	notReady.Ready = false
+	noContainers := testPod("web-1", "node-a", 1000, 1)
+	noContainers.Containers = nil
+
+	tests := []struct {
+		name          string
+		mode          string
+		overrides     []RequestOverride
+		nodes         []NodeCapacityInfo
+		pods          []PodResourceInfo
+		wantCurrent   int
+		wantMinimum   int
+		wantDrainable []string
+		wantUnplaced  []string
+		wantCPUAfter  int64
+	}{
+		{
+			name:          "空输入",
+			mode:          SimulationModeCurrent,
+			wantDrainable: []string{},
+			wantUnplaced:  []string{},
+		},
+		{
+			name:          "小Pod合并到一个节点",
+			mode:          SimulationModeCurrent,
+			nodes:         []NodeCapacityInfo{testNode("node-a", 4000, 8), testNode("node-b", 4000, 8), testNode("node-c", 4000, 8)},
+			pods:          []PodResourceInfo{testPod("web-1", "node-a", 1000, 1), testPod("web-2", "node-b", 1000, 1), testPod("web-3", "node-c", 1000, 1)},
+			wantCurrent:   3,
+			wantMinimum:   1,
+			wantDrainable: []string{"node-b", "node-c"},
+			wantUnplaced:  []string{},
+			wantCPUAfter:  3000,
+		},
+		{
+			name:          "不可调度和未就绪节点不参与模拟",
+			mode:          SimulationModeCurrent,
+			nodes:         []NodeCapacityInfo{testNode("node-a", 4000, 8), unschedulable, notReady},
+			pods:          []PodResourceInfo{testPod("web-1", "node-b", 1000, 1), testPod("web-2", "node-c", 1000, 1)},
+			wantCurrent:   1,
+			wantMinimum:   1,
+			wantDrainable: []string{},
+			wantUnplaced:  []string{},
+			wantCPUAfter:  2000,
+		},
+		{
+			name:          "超出节点容量的Pod无法放置",
+			mode:          SimulationModeCurrent,
+			nodes:         []NodeCapacityInfo{testNode("node-a", 4000, 8)},
+			pods:          []PodResourceInfo{testPod("web-1", "node-a", 8000, 1)},
+			wantCurrent:   1,
+			wantDrainable: []string{"node-a"},
+			wantUnplaced:  []string{"web-1"},
+			wantCPUAfter:  8000,
+		},
+		{
+			name:  "只放入满足nodeSelector的节点",
+			mode:  SimulationModeCurrent,
+			nodes: []NodeCapacityInfo{testNode("node-a", 8000, 16), ssdNode},
+			pods: []PodResourceInfo{
+				withScheduling(testPod("web-1", "node-a", 1000, 1), &PodSchedulingInfo{NodeSelector: map[string]string{"disk": "ssd"}}),
+			},
+			wantCurrent:   2,
+			wantMinimum:   1,
+			wantDrainable: []string{"node-a"},
+			wantUnplaced:  []string{},
+			wantCPUAfter:  1000,
+		},
+		{
+			name:          "不容忍污点的Pod避开污点节点",
+			mode:          SimulationModeCurrent,
+			nodes:         []NodeCapacityInfo{taintedNode, testNode("node-b", 4000, 8)},
+			pods:          []PodResourceInfo{withScheduling(testPod("web-1", "node-a", 1000, 1), &PodSchedulingInfo{})},
+			wantCurrent:   2,
+			wantMinimum:   1,
+			wantDrainable: []string{"node-a"},
+			wantUnplaced:  []string{},
+			wantCPUAfter:  1000,
+		},
+		{
+			name:  "容忍污点的Pod可以放入污点节点",
+			mode:  SimulationModeCurrent,
+			nodes: []NodeCapacityInfo{taintedNode, testNode("node-b", 4000, 8)},
+			pods: []PodResourceInfo{withScheduling(testPod("web-1", "node-b", 1000, 1), &PodSchedulingInfo{
+				Tolerations: []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "gpu", Effect: corev1.TaintEffectNoSchedule}},
+			})},
+			wantCurrent:   2,
+			wantMinimum:   1,
+			wantDrainable: []string{"node-b"},
+			wantUnplaced:  []string{},
+			wantCPUAfter:  1000,
+		},
+		{
+			name:  "反亲和的Pod分散到不同节点",
+			mode:  SimulationModeCurrent,
+			nodes: []NodeCapacityInfo{testNode("node-a", 4000, 8), testNode("node-b", 4000, 8), testNode("node-c", 4000, 8)},
+			pods: []PodResourceInfo{
+				withScheduling(testPod("web-1", "node-a", 1000, 1), antiAffinity),
+				withScheduling(testPod("web-2", "node-b", 1000, 1), antiAffinity),
+				withScheduling(testPod("web-3", "node-c", 1000, 1), antiAffinity),
+				withScheduling(testPod("web-4", "node-c", 1000, 1), antiAffinity),
+			},
+			wantCurrent:   3,
+			wantMinimum:   3,
+			wantDrainable: []string{},
+			wantUnplaced:  []string{"web-4"},
+			wantCPUAfter:  4000,
+		},
+		{
+			name:  "DaemonSet Pod保留在原节点且不阻止排空",
+			mode:  SimulationModeCurrent,
+			nodes: []NodeCapacityInfo{testNode("node-a", 4000, 8), testNode("node-b", 4000, 8)},
+			pods: []PodResourceInfo{
+				withScheduling(testPod("agent-a", "node-a", 3500, 1), &PodSchedulingInfo{IsDaemonSet: true}),
+				withScheduling(testPod("agent-b", "node-b", 500, 1), &PodSchedulingInfo{IsDaemonSet: true}),
+				testPod("web-1", "node-a", 1000, 1),
+			},
+			wantCurrent:   2,
+			wantMinimum:   1,
+			wantDrainable: []string{"node-a"},
+			wantUnplaced:  []string{},
+			wantCPUAfter:  5000,
+		},
+		{
+			name:          "建议模式下没有容器明细的Pod保持当前请求量",
+			mode:          SimulationModeRecommendations,
+			nodes:         []NodeCapacityInfo{testNode("node-a", 4000, 8)},
+			pods:          []PodResourceInfo{noContainers},
+			wantCurrent:   1,
+			wantMinimum:   1,
+			wantDrainable: []string{},
+			wantUnplaced:  []string{},
+			wantCPUAfter:  1000,
+		},
+		{
+			name: "覆盖模式按工作负载替换请求量",
+			mode: SimulationModeOverrides,
+			overrides: []RequestOverride{
+				{Namespace: "default", WorkloadName: "web", CPURequest: 250},
+				{Namespace: "other", WorkloadName: "web", CPURequest: 4000},
+			},
+			nodes:         []NodeCapacityInfo{testNode("node-a", 4000, 8), testNode("node-b", 4000, 8)},
+			pods:          []PodResourceInfo{testPod("web-1", "node-a", 3000, 1), testPod("web-2", "node-b", 3000, 1)},
+			wantCurrent:   2,
+			wantMinimum:   1,
+			wantDrainable: []string{"node-b"},
+			wantUnplaced:  []string{},
+			wantCPUAfter:  500,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewBinPackingSimulator(tt.mode, tt.overrides).Simulate(tt.nodes, tt.pods)
+
+			if result.CurrentNodeCount != tt.wantCurrent || result.MinimumNodeCount != tt.wantMinimum {
+				t.Errorf("当前节点数 %d、最少节点数 %d，期望 %d、%d", result.CurrentNodeCount, result.MinimumNodeCount, tt.wantCurrent, tt.wantMinimum)
+			}
+			if !reflect.DeepEqual(result.DrainableNodes, tt.wantDrainable) || result.RemovableNodeCount != len(tt.wantDrainable) {
+				t.Errorf("可排空节点 %v，期望 %v", result.DrainableNodes, tt.wantDrainable)
+			}
+			unplaced := []string{}
+			for _, pod := range result.UnplacedPods {
+				unplaced = append(unplaced, pod.PodName)
+			}
+			if !reflect.DeepEqual(unplaced, tt.wantUnplaced) {
+				t.Errorf("无法放置的Pod %v，期望 %v", unplaced, tt.wantUnplaced)
+			}
+			if result.TotalCPURequestAfter != tt.wantCPUAfter {
+				t.Errorf("模拟后CPU请求总量 %d，期望 %d", result.TotalCPURequestAfter, tt.wantCPUAfter)
+			}
+			if len(result.Nodes) != len(tt.nodes) {
+				t.Errorf("节点结果 %d 个，期望 %d 个", len(result.Nodes), len(tt.nodes))
+			}
+		})
+	}
+}
+
+func TestBinPackingUnplacedReason(t *testing.T) {
+	tainted := testNode("node-a", 4000, 8)
+	tainted.Taints = []corev1.Taint{{Key: "dedicated", Value: "gpu", Effect: corev1.TaintEffectNoSchedule}}
+	hddNode := testNode("node-a", 4000, 8)
+	hddNode.Labels = map[string]string{"disk": "hdd"}
+
+	tests := []struct {
+		name       string
+		nodes      []NodeCapacityInfo
+		pod        PodResourceInfo
+		wantReason string
+	}{
+		{"没有节点", nil, testPod("web-1", "", 1000, 1), "没有可调度节点"},
+		{"资源不足", []NodeCapacityInfo{testNode("node-a", 4000, 8)}, testPod("web-1", "node-a", 1000, 16), "节点剩余资源不足"},
+		{"不满足nodeSelector", []NodeCapacityInfo{hddNode}, withScheduling(testPod("web-1", "node-a", 1000, 1), &PodSchedulingInfo{NodeSelector: map[string]string{"disk": "ssd"}}), "不满足nodeSelector disk=ssd"},
+		{"无法容忍污点", []NodeCapacityInfo{tainted}, withScheduling(testPod("web-1", "node-a", 1000, 1), &PodSchedulingInfo{}), "无法容忍污点 dedicated=gpu:NoSchedule"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewBinPackingSimulator(SimulationModeCurrent, nil).Simulate(tt.nodes, []PodResourceInfo{tt.pod})
+			if len(result.UnplacedPods) != 1 || result.UnplacedPods[0].Reason != tt.wantReason {
+				t.Errorf("无法放置的Pod %+v，期望原因 %q", result.UnplacedPods, tt.wantReason)
+			}
+		})
+	}
+}
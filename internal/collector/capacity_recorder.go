@@ -0,0 +1,37 @@
+package collector
+
+import (
+	"context"
+
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/models"
+)
+
+// saveForecastSnapshots 保存集群容量和资源配额快照，并检查预计耗尽告警
+// 参数:
+//   - ctx: 上下文对象
+//   - singleCollector: 已连接目标集群的单集群收集器
+//   - cluster: 集群配置
+//   - nodes: 集群节点容量信息，为nil时跳过容量快照
+//   - pods: 本次采集的全部Pod数据
+func (mc *MultiClusterResourceCollector) saveForecastSnapshots(ctx context.Context, singleCollector *ResourceCollector, cluster models.ClusterConfig, nodes []NodeCapacityInfo, pods []PodResourceInfo) {
+	if nodes == nil {
+		logger.Warn("集群 %s 缺少节点容量数据，跳过容量快照", cluster.ClusterName)
+	} else if err := mc.forecastService.SaveCapacitySnapshot(cluster.ID, BuildCapacitySnapshot(nodes, pods)); err != nil {
+		logger.Error("保存集群 %s 容量快照失败: %v", cluster.ClusterName, err)
+	}
+
+	quotas, err := singleCollector.collectResourceQuotas(ctx)
+	if err != nil {
+		logger.Error("获取集群 %s 资源配额失败，跳过配额快照: %v", cluster.ClusterName, err)
+	} else if err := mc.forecastService.SaveQuotaSnapshots(cluster.ID, ConvertToServiceQuotas(quotas)); err != nil {
+		logger.Error("保存集群 %s 资源配额快照失败: %v", cluster.ClusterName, err)
+	}
+
+	// 检查容量和配额的预计耗尽时间
+	if mc.activityService != nil {
+		if err := mc.forecastService.CheckExhaustionAlerts(cluster.ID, cluster.ClusterName); err != nil {
+			logger.Error("检查集群 %s 容量耗尽告警失败: %v", cluster.ClusterName, err)
+		}
+	}
+}
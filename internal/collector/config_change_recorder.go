@@ -0,0 +1,235 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/models"
+	"cluster-resource-insight/internal/service"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// recordConfigChanges 读取集群中工作负载的Pod模板资源配置，与上次采集比较并记录变化
+// 工作负载列表不完整时跳过本次检测，避免误删基线
+func (mc *MultiClusterResourceCollector) recordConfigChanges(ctx context.Context, singleCollector *ResourceCollector, cluster *models.ClusterConfig) {
+	observations, err := singleCollector.collectWorkloadSpecs(ctx)
+	if err != nil {
+		logger.Error("获取集群 %s 工作负载资源配置失败: %v", cluster.ClusterName, err)
+		return
+	}
+
+	changes, err := mc.configChangeService.RecordObservations(cluster.ID, observations, time.Now())
+	if err != nil {
+		logger.Error("记录集群 %s 资源配置变更失败: %v", cluster.ClusterName, err)
+		return
+	}
+	if len(changes) == 0 {
+		return
+	}
+
+	workloads := make(map[string]bool)
+	for _, change := range changes {
+		workloads[change.Namespace+"/"+change.WorkloadKind+"/"+change.WorkloadName] = true
+	}
+	logger.Info("集群 %s 检测到 %d 个工作负载的 %d 项容器资源配置变化", cluster.ClusterName, len(workloads), len(changes))
+
+	if mc.activityService != nil {
+		message := fmt.Sprintf("集群 %s 有 %d 个工作负载修改了容器资源配置", cluster.ClusterName, len(workloads))
+		if err := mc.activityService.RecordActivity("info", "资源配置变更", message, "collector", cluster.ID, map[string]interface{}{
+			"workload_count":  len(workloads),
+			"container_count": len(changes),
+		}); err != nil {
+			logger.Error("记录资源配置变更活动失败: %v", err)
+		}
+	}
+}
+
+// deploymentRevisionAnnotation Deployment 控制器写在 ReplicaSet 上的发布版本注解
+const deploymentRevisionAnnotation = "deployment.kubernetes.io/revision"
+
+// collectWorkloadSpecs 列出所有 Deployment、StatefulSet、DaemonSet 的Pod模板资源配置、发布版本和拥有容器资源字段的字段管理者
+func (rc *ResourceCollector) collectWorkloadSpecs(ctx context.Context) ([]service.WorkloadSpecObservation, error) {
+	var observations []service.WorkloadSpecObservation
+	add := func(objectMeta metav1.ObjectMeta, kind, revision string, template corev1.PodTemplateSpec) {
+		observation := service.WorkloadSpecObservation{
+			Namespace:     objectMeta.Namespace,
+			WorkloadKind:  kind,
+			WorkloadName:  objectMeta.Name,
+			Containers:    make([]service.ContainerResourceSpec, 0, len(template.Spec.Containers)),
+			Revision:      revision,
+			FieldManagers: resourceFieldManagers(objectMeta.ManagedFields),
+		}
+		for _, container := range extractContainerResources(&corev1.Pod{Spec: template.Spec}, nil) {
+			observation.Containers = append(observation.Containers, service.ContainerResourceSpec{
+				Name:          container.Name,
+				CPURequest:    container.CPURequest,
+				CPULimit:      container.CPULimit,
+				MemoryRequest: container.MemoryRequest,
+				MemoryLimit:   container.MemoryLimit,
+			})
+		}
+		observations = append(observations, observation)
+	}
+
+	deployments, err := rc.kubeClient.AppsV1().Deployments(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取Deployment列表失败: %v", err)
+	}
+	replicaSets, err := rc.kubeClient.AppsV1().ReplicaSets(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取ReplicaSet列表失败: %v", err)
+	}
+	ownedReplicaSets := make(map[types.UID][]*appsv1.ReplicaSet)
+	for i := range replicaSets.Items {
+		replicaSet := &replicaSets.Items[i]
+		if owner := metav1.GetControllerOf(replicaSet); owner != nil && owner.Kind == "Deployment" {
+			ownedReplicaSets[owner.UID] = append(ownedReplicaSets[owner.UID], replicaSet)
+		}
+	}
+	for i := range deployments.Items {
+		deployment := &deployments.Items[i]
+		add(deployment.ObjectMeta, "Deployment", deploymentRevision(deployment, ownedReplicaSets[deployment.UID]), deployment.Spec.Template)
+	}
+
+	statefulSets, err := rc.kubeClient.AppsV1().StatefulSets(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取StatefulSet列表失败: %v", err)
+	}
+	for _, statefulSet := range statefulSets.Items {
+		add(statefulSet.ObjectMeta, "StatefulSet", statefulSet.Status.UpdateRevision, statefulSet.Spec.Template)
+	}
+
+	daemonSets, err := rc.kubeClient.AppsV1().DaemonSets(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取DaemonSet列表失败: %v", err)
+	}
+	for _, daemonSet := range daemonSets.Items {
+		// DaemonSet 没有版本号注解，使用模板代数作为发布版本
+		revision := daemonSet.Annotations["deprecated.daemonset.template.generation"]
+		if revision == "" {
+			revision = strconv.FormatInt(daemonSet.Generation, 10)
+		}
+		add(daemonSet.ObjectMeta, "DaemonSet", revision, daemonSet.Spec.Template)
+	}
+
+	return observations, nil
+}
+
+// deploymentRevision 返回Deployment当前Pod模板对应的发布版本
+// Deployment 上的版本号注解在控制器创建新ReplicaSet之后才更新，修改模板后的首次采集读到的仍是旧版本，
+// 因此取模板（忽略 pod-template-hash 标签）与当前模板一致且版本最新的ReplicaSet的版本号；
+// 控制器尚未创建该ReplicaSet时（如Deployment已暂停），新ReplicaSet的版本号将是现有最大版本号加1
+func deploymentRevision(deployment *appsv1.Deployment, replicaSets []*appsv1.ReplicaSet) string {
+	var maxRevision, matchedRevision int64
+	for _, replicaSet := range replicaSets {
+		revision, err := strconv.ParseInt(replicaSet.Annotations[deploymentRevisionAnnotation], 10, 64)
+		if err != nil {
+			continue
+		}
+		if revision > maxRevision {
+			maxRevision = revision
+		}
+		if revision > matchedRevision && templateEqualIgnoreHash(&replicaSet.Spec.Template, &deployment.Spec.Template) {
+			matchedRevision = revision
+		}
+	}
+
+	switch {
+	case matchedRevision > 0:
+		return strconv.FormatInt(matchedRevision, 10)
+	case maxRevision > 0:
+		return strconv.FormatInt(maxRevision+1, 10)
+	default:
+		return deployment.Annotations[deploymentRevisionAnnotation]
+	}
+}
+
+// templateEqualIgnoreHash 比较ReplicaSet与Deployment的Pod模板，忽略控制器添加的 pod-template-hash 标签
+func templateEqualIgnoreHash(replicaSetTemplate, deploymentTemplate *corev1.PodTemplateSpec) bool {
+	template := replicaSetTemplate.DeepCopy()
+	delete(template.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+	return apiequality.Semantic.DeepEqual(template, deploymentTemplate)
+}
+
+// resourceFieldManagers 从 managedFields 中找出拥有容器资源字段的条目，以及各条目拥有的具体字段
+func resourceFieldManagers(entries []metav1.ManagedFieldsEntry) []service.ResourceFieldManager {
+	var managers []service.ResourceFieldManager
+	for _, entry := range entries {
+		if entry.Subresource != "" || entry.FieldsV1 == nil {
+			continue
+		}
+		fields := ownedContainerResources(entry.FieldsV1.Raw)
+		if len(fields) == 0 {
+			continue
+		}
+		manager := service.ResourceFieldManager{
+			Manager:   entry.Manager,
+			Operation: string(entry.Operation),
+			Fields:    fields,
+		}
+		if entry.Time != nil {
+			managedAt := entry.Time.Time
+			manager.Time = &managedAt
+		}
+		managers = append(managers, manager)
+	}
+	return managers
+}
+
+// ownedContainerResources 解析 FieldsV1 中 spec.template.spec.containers 下各容器拥有的资源字段
+// 容器以 k:{"name":"app"} 形式的键标识，资源字段如 f:resources.f:requests.f:cpu 转换为 requests.cpu
+func ownedContainerResources(raw []byte) map[string][]string {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil
+	}
+
+	node := fields
+	for _, path := range []string{"f:spec", "f:template", "f:spec", "f:containers"} {
+		next, ok := node[path].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		node = next
+	}
+
+	owned := make(map[string][]string)
+	for key, container := range node {
+		var containerKey struct {
+			Name string `json:"name"`
+		}
+		if !strings.HasPrefix(key, "k:") || json.Unmarshal([]byte(strings.TrimPrefix(key, "k:")), &containerKey) != nil {
+			continue
+		}
+		containerFields, _ := container.(map[string]interface{})
+		resources, ok := containerFields["f:resources"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, group := range []string{"requests", "limits"} {
+			groupFields, ok := resources["f:"+group].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for _, resource := range []string{"cpu", "memory"} {
+				if _, ok := groupFields["f:"+resource]; ok {
+					owned[containerKey.Name] = append(owned[containerKey.Name], group+"."+resource)
+				}
+			}
+		}
+	}
+	if len(owned) == 0 {
+		return nil
+	}
+	return owned
+}
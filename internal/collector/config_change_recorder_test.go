@@ -0,0 +1,174 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// podTemplate 构造Pod模板，hash 非空时模拟控制器在ReplicaSet模板上添加的 pod-template-hash 标签
+func podTemplate(image, hash string) corev1.PodTemplateSpec {
+	template := corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: image}}},
+	}
+	if hash != "" {
+		template.Labels[appsv1.DefaultDeploymentUniqueLabelKey] = hash
+	}
+	return template
+}
+
+// revisionReplicaSet 构造带版本号注解的ReplicaSet
+func revisionReplicaSet(revision, image string) *appsv1.ReplicaSet {
+	return &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{deploymentRevisionAnnotation: revision}},
+		Spec:       appsv1.ReplicaSetSpec{Template: podTemplate(image, "hash-"+revision)},
+	}
+}
+
+func TestDeploymentRevision(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotation  string // Deployment 上的版本号注解
+		replicaSets []*appsv1.ReplicaSet
+		want        string
+	}{
+		{"没有ReplicaSet时使用Deployment注解", "3", nil, "3"},
+		{"没有ReplicaSet也没有注解", "", nil, ""},
+		{
+			name:        "取模板一致的ReplicaSet版本，忽略滞后的Deployment注解",
+			annotation:  "1",
+			replicaSets: []*appsv1.ReplicaSet{revisionReplicaSet("1", "web:v1"), revisionReplicaSet("2", "web:v2")},
+			want:        "2",
+		},
+		{
+			name:        "回滚后取模板一致且版本最新的ReplicaSet",
+			annotation:  "3",
+			replicaSets: []*appsv1.ReplicaSet{revisionReplicaSet("1", "web:v2"), revisionReplicaSet("2", "web:v1"), revisionReplicaSet("3", "web:v2")},
+			want:        "3",
+		},
+		{
+			name:        "尚未创建新ReplicaSet时为现有最大版本加1",
+			annotation:  "2",
+			replicaSets: []*appsv1.ReplicaSet{revisionReplicaSet("2", "web:v1"), revisionReplicaSet("1", "web:v0")},
+			want:        "3",
+		},
+		{
+			name:        "忽略版本号注解无效的ReplicaSet",
+			annotation:  "1",
+			replicaSets: []*appsv1.ReplicaSet{revisionReplicaSet("abc", "web:v2"), revisionReplicaSet("1", "web:v1")},
+			want:        "2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deployment := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}},
+				Spec:       appsv1.DeploymentSpec{Template: podTemplate("web:v2", "")},
+			}
+			if tt.annotation != "" {
+				deployment.Annotations[deploymentRevisionAnnotation] = tt.annotation
+			}
+			if got := deploymentRevision(deployment, tt.replicaSets); got != tt.want {
+				t.Errorf("得到 %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOwnedContainerResources(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want map[string][]string
+	}{
+		{"无效JSON", `{"f:spec":`, nil},
+		{"空对象", `{}`, nil},
+		{"没有容器字段", `{"f:metadata":{"f:labels":{}},"f:spec":{"f:replicas":{}}}`, nil},
+		{"容器没有资源字段", `{"f:spec":{"f:template":{"f:spec":{"f:containers":{"k:{\"name\":\"app\"}":{".":{},"f:image":{}}}}}}}`, nil},
+		{
+			name: "按固定顺序返回请求和限制字段",
+			raw:  `{"f:spec":{"f:template":{"f:spec":{"f:containers":{"k:{\"name\":\"app\"}":{"f:resources":{"f:limits":{"f:memory":{}},"f:requests":{"f:memory":{},"f:cpu":{}}}}}}}}}`,
+			want: map[string][]string{"app": {"requests.cpu", "requests.memory", "limits.memory"}},
+		},
+		{
+			name: "多个容器分别返回，忽略非容器键和其他资源",
+			raw: `{"f:spec":{"f:template":{"f:spec":{"f:containers":{
+				".":{},
+				"k:{\"name\":\"app\"}":{"f:resources":{"f:requests":{"f:cpu":{},"f:ephemeral-storage":{}}}},
+				"k:{\"name\":\"sidecar\"}":{"f:resources":{"f:limits":{"f:cpu":{}}}},
+				"k:{\"name\":\"init\"}":{"f:image":{}},
+				"k:not-json":{"f:resources":{"f:requests":{"f:cpu":{}}}}
+			}}}}}`,
+			want: map[string][]string{"app": {"requests.cpu"}, "sidecar": {"limits.cpu"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ownedContainerResources([]byte(tt.raw)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("得到 %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResourceFieldManagers(t *testing.T) {
+	cpuRequest := `{"f:spec":{"f:template":{"f:spec":{"f:containers":{"k:{\"name\":\"app\"}":{"f:resources":{"f:requests":{"f:cpu":{}}}}}}}}}`
+	memoryLimit := `{"f:spec":{"f:template":{"f:spec":{"f:containers":{"k:{\"name\":\"app\"}":{"f:resources":{"f:limits":{"f:memory":{}}}}}}}}}`
+	managedAt := metav1.NewTime(time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC))
+	entry := func(manager string, operation metav1.ManagedFieldsOperationType, subresource, raw string, at *metav1.Time) metav1.ManagedFieldsEntry {
+		e := metav1.ManagedFieldsEntry{Manager: manager, Operation: operation, Subresource: subresource, Time: at}
+		if raw != "" {
+			e.FieldsV1 = &metav1.FieldsV1{Raw: []byte(raw)}
+		}
+		return e
+	}
+
+	tests := []struct {
+		name    string
+		entries []metav1.ManagedFieldsEntry
+		want    []string // 管理者/操作类型
+	}{
+		{"没有managedFields", nil, nil},
+		{
+			name: "多个管理者分别拥有资源字段",
+			entries: []metav1.ManagedFieldsEntry{
+				entry("helm", metav1.ManagedFieldsOperationApply, "", memoryLimit, &managedAt),
+				entry("kubectl-edit", metav1.ManagedFieldsOperationUpdate, "", cpuRequest, nil),
+			},
+			want: []string{"helm/Apply", "kubectl-edit/Update"},
+		},
+		{
+			name: "忽略子资源、没有字段和不拥有资源字段的条目",
+			entries: []metav1.ManagedFieldsEntry{
+				entry("kube-controller-manager", metav1.ManagedFieldsOperationUpdate, "status", cpuRequest, &managedAt),
+				entry("empty", metav1.ManagedFieldsOperationUpdate, "", "", &managedAt),
+				entry("labeler", metav1.ManagedFieldsOperationUpdate, "", `{"f:metadata":{"f:labels":{}}}`, &managedAt),
+				entry("vpa", metav1.ManagedFieldsOperationUpdate, "", cpuRequest, &managedAt),
+			},
+			want: []string{"vpa/Update"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			managers := resourceFieldManagers(tt.entries)
+			var got []string
+			for _, manager := range managers {
+				got = append(got, manager.Manager+"/"+manager.Operation)
+				if len(manager.Fields["app"]) != 1 {
+					t.Errorf("管理者 %s 拥有的字段 %v，期望 app 的1个资源字段", manager.Manager, manager.Fields)
+				}
+				if manager.Time != nil && !manager.Time.Equal(managedAt.Time) {
+					t.Errorf("管理者 %s 写入时间 %v，期望 %v", manager.Manager, manager.Time, managedAt.Time)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("得到 %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -10,25 +10,84 @@ func ConvertToServicePods(pods []PodResourceInfo) []service.PodResourceInfo {
 
 	for i, pod := range pods {
 		servicePods[i] = service.PodResourceInfo{
-			PodName:        pod.PodName,
-			Namespace:      pod.Namespace,
-			NodeName:       pod.NodeName,
-			ClusterName:    pod.ClusterName,
-			MemoryUsage:    pod.MemoryUsage,
-			MemoryRequest:  pod.MemoryRequest,
-			MemoryLimit:    pod.MemoryLimit,
-			MemoryReqPct:   pod.MemoryReqPct,
-			MemoryLimitPct: pod.MemoryLimitPct,
-			CPUUsage:       pod.CPUUsage,
-			CPURequest:     pod.CPURequest,
-			CPULimit:       pod.CPULimit,
-			CPUReqPct:      pod.CPUReqPct,
-			CPULimitPct:    pod.CPULimitPct,
-			Status:         pod.Status,
-			Issues:         pod.Issues,
-			CreationTime:   pod.CreationTime,
+			PodName:                pod.PodName,
+			Namespace:              pod.Namespace,
+			NodeName:               pod.NodeName,
+			ClusterName:            pod.ClusterName,
+			WorkloadKind:           pod.WorkloadKind,
+			WorkloadName:           pod.WorkloadName,
+			QoSClass:               pod.QoSClass,
+			MemoryUsage:            pod.MemoryUsage,
+			MemoryRequest:          pod.MemoryRequest,
+			MemoryLimit:            pod.MemoryLimit,
+			MemoryReqPct:           pod.MemoryReqPct,
+			MemoryLimitPct:         pod.MemoryLimitPct,
+			CPUUsage:               pod.CPUUsage,
+			CPURequest:             pod.CPURequest,
+			CPULimit:               pod.CPULimit,
+			CPUReqPct:              pod.CPUReqPct,
+			CPULimitPct:            pod.CPULimitPct,
+			Status:                 pod.Status,
+			Issues:                 pod.Issues,
+			CreationTime:           pod.CreationTime,
+			MetricsAvailable:       pod.MetricsAvailable,
+			CPUMetricsAvailable:    pod.CPUMetricsAvailable,
+			MemoryMetricsAvailable: pod.MemoryMetricsAvailable,
+			RestartCount:           pod.RestartCount,
+			OOMKilled:              pod.OOMKilled,
+		}
+		if pod.Scheduling != nil {
+			servicePods[i].Labels = pod.Scheduling.Labels
 		}
 	}
 
 	return servicePods
-}
\ No newline at end of file
+}
+
+// ConvertToServiceQuotas 将collector.ResourceQuotaInfo转换为service.QuotaUsageInfo
+func ConvertToServiceQuotas(quotas []ResourceQuotaInfo) []service.QuotaUsageInfo {
+	serviceQuotas := make([]service.QuotaUsageInfo, len(quotas))
+
+	for i, quota := range quotas {
+		serviceQuotas[i] = service.QuotaUsageInfo{
+			Namespace: quota.Namespace,
+			QuotaName: quota.QuotaName,
+			Resource:  quota.Resource,
+			Hard:      quota.Hard,
+			Used:      quota.Used,
+		}
+	}
+
+	return serviceQuotas
+}
+
+// BuildCapacitySnapshot 根据节点容量和Pod数据汇总集群容量快照
+// 请求量取容器实际配置，使用量只累加来自真实metrics的数据，避免默认值和估算值影响容量预测
+func BuildCapacitySnapshot(nodes []NodeCapacityInfo, pods []PodResourceInfo) service.CapacitySnapshotInfo {
+	snapshot := service.CapacitySnapshotInfo{
+		NodeCount:    len(nodes),
+		PodCount:     len(pods),
+		UsageMissing: true,
+	}
+
+	for _, node := range nodes {
+		snapshot.AllocatableCPU += node.AllocatableCPU
+		snapshot.AllocatableMemory += node.AllocatableMemory
+	}
+	for i := range pods {
+		pod := &pods[i]
+		cpuRequest, memoryRequest := podContainerRequests(pod)
+		snapshot.CPURequest += cpuRequest
+		snapshot.MemoryRequest += memoryRequest
+		if pod.CPUMetricsAvailable {
+			snapshot.CPUUsage += pod.CPUUsage
+			snapshot.UsageMissing = false
+		}
+		if pod.MemoryMetricsAvailable {
+			snapshot.MemoryUsage += pod.MemoryUsage
+			snapshot.UsageMissing = false
+		}
+	}
+
+	return snapshot
+}
@@ -0,0 +1,388 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"cluster-resource-insight/internal/config"
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/models"
+	"cluster-resource-insight/pkg/cost"
+)
+
+// 成本分摊策略，定义见配置包
+const (
+	CostStrategyProportionalRequests = config.CostStrategyProportionalRequests
+	CostStrategyProportionalUsage    = config.CostStrategyProportionalUsage
+	CostStrategyEven                 = config.CostStrategyEven
+	CostStrategySeparate             = config.CostStrategySeparate
+)
+
+// NamespaceCostAllocation 命名空间成本分摊结果 - 包含直接成本和分摊后的全量成本
+type NamespaceCostAllocation struct {
+	NamespaceName   string  `json:"namespace_name"`    // 命名空间名称
+	ClusterName     string  `json:"cluster_name"`      // 所属集群名称
+	PodCount        int     `json:"pod_count"`         // Pod数量
+	CPURequest      int64   `json:"cpu_request"`       // CPU请求总量 (millicores)
+	MemoryRequest   int64   `json:"memory_request"`    // 内存请求总量 (bytes)
+	CPUUsage        int64   `json:"cpu_usage"`         // CPU使用总量 (millicores)
+	MemoryUsage     int64   `json:"memory_usage"`      // 内存使用总量 (bytes)
+	DirectCost      float64 `json:"direct_cost"`       // 按请求量计算的直接月度成本
+	IdleCost        float64 `json:"idle_cost"`         // 分摊到的闲置容量成本
+	SystemCost      float64 `json:"system_cost"`       // 分摊到的系统开销成本
+	FullyLoadedCost float64 `json:"fully_loaded_cost"` // 全量月度成本
+	CostIncreasePct float64 `json:"cost_increase_pct"` // 全量成本相对直接成本的增幅百分比
+}
+
+// CostLineItem 独立成本项 - 采用separate策略时闲置容量或系统开销单独列示
+type CostLineItem struct {
+	Name   string  `json:"name"`   // 成本项名称：idle/system
+	CPU    int64   `json:"cpu"`    // CPU资源量 (millicores)
+	Memory int64   `json:"memory"` // 内存资源量 (bytes)
+	Cost   float64 `json:"cost"`   // 月度成本
+}
+
+// ClusterCostAllocation 集群成本分摊结果 - 包含可分配容量、闲置容量、系统开销及各命名空间分摊明细
+type ClusterCostAllocation struct {
+	ClusterID   uint   `json:"cluster_id"`   // 集群ID
+	ClusterName string `json:"cluster_name"` // 集群名称
+	NodeCount   int    `json:"node_count"`   // 节点数量
+
+	AllocatableCPU    int64 `json:"allocatable_cpu"`    // 可分配CPU总量 (millicores)
+	AllocatableMemory int64 `json:"allocatable_memory"` // 可分配内存总量 (bytes)
+	TenantCPU         int64 `json:"tenant_cpu"`         // 租户CPU请求量 (millicores)
+	TenantMemory      int64 `json:"tenant_memory"`      // 租户内存请求量 (bytes)
+	SystemCPU         int64 `json:"system_cpu"`         // 系统命名空间CPU请求量 (millicores)
+	SystemMemory      int64 `json:"system_memory"`      // 系统命名空间内存请求量 (bytes)
+	IdleCPU           int64 `json:"idle_cpu"`           // 未被请求的闲置CPU (millicores)
+	IdleMemory        int64 `json:"idle_memory"`        // 未被请求的闲置内存 (bytes)
+
+	TotalCost        float64 `json:"total_cost"`         // 集群可分配容量月度总成本
+	TenantDirectCost float64 `json:"tenant_direct_cost"` // 租户直接成本
+	SystemCost       float64 `json:"system_cost"`        // 系统开销成本
+	IdleCost         float64 `json:"idle_cost"`          // 闲置容量成本
+	IdleCostPct      float64 `json:"idle_cost_pct"`      // 闲置成本占总成本百分比
+
+	IdleStrategy   string                    `json:"idle_strategy"`   // 闲置容量分摊策略
+	SystemStrategy string                    `json:"system_strategy"` // 系统开销分摊策略
+	Namespaces     []NamespaceCostAllocation `json:"namespaces"`      // 租户命名空间分摊明细
+	LineItems      []CostLineItem            `json:"line_items"`      // 未分摊的独立成本项
+}
+
+// CostAllocationReport 多集群成本分摊报告
+type CostAllocationReport struct {
+	Clusters         []ClusterCostAllocation `json:"clusters"`          // 各集群分摊结果
+	TotalCost        float64                 `json:"total_cost"`        // 所有集群月度总成本
+	TotalIdleCost    float64                 `json:"total_idle_cost"`   // 所有集群闲置成本
+	TotalSystemCost  float64                 `json:"total_system_cost"` // 所有集群系统开销成本
+	Pricing          *cost.Model             `json:"pricing"`           // 使用的单价模型
+	ClustersAnalyzed int                     `json:"clusters_analyzed"` // 参与核算的集群数量
+	GeneratedAt      time.Time               `json:"generated_at"`      // 报告生成时间
+}
+
+// CostAllocator 成本分摊计算器 - 计算闲置容量和系统开销并按策略分摊到租户命名空间
+type CostAllocator struct {
+	model            *cost.Model
+	systemNamespaces map[string]bool
+	idleStrategy     string
+	systemStrategy   string
+}
+
+// NewCostAllocator 创建成本分摊计算器，未指定的策略和配置从配置文件读取
+// 参数:
+//   - idleStrategy: 闲置容量分摊策略，为空时使用配置值
+//   - systemStrategy: 系统开销分摊策略，为空时使用配置值
+func NewCostAllocator(idleStrategy, systemStrategy string) *CostAllocator {
+	systemNamespaces := []string{"kube-system", "kube-public", "kube-node-lease"}
+
+	if costConfig := config.GetCostConfig(); costConfig != nil {
+		if len(costConfig.SystemNamespaces) > 0 {
+			systemNamespaces = costConfig.SystemNamespaces
+		}
+		if idleStrategy == "" {
+			idleStrategy = costConfig.IdleStrategy
+		}
+		if systemStrategy == "" {
+			systemStrategy = costConfig.SystemStrategy
+		}
+	}
+
+	if idleStrategy == "" {
+		idleStrategy = CostStrategyProportionalRequests
+	}
+	if systemStrategy == "" {
+		systemStrategy = CostStrategyProportionalRequests
+	}
+
+	nsSet := make(map[string]bool, len(systemNamespaces))
+	for _, ns := range systemNamespaces {
+		nsSet[ns] = true
+	}
+
+	return &CostAllocator{
+		model:            cost.DefaultModel(),
+		systemNamespaces: nsSet,
+		idleStrategy:     idleStrategy,
+		systemStrategy:   systemStrategy,
+	}
+}
+
+// AllocateCluster 计算单个集群的成本分摊
+// 参数:
+//   - clusterID: 集群ID
+//   - clusterName: 集群名称
+//   - nodes: 集群节点容量信息
+//   - pods: 集群内所有运行中的Pod
+//
+// 返回:
+//   - *ClusterCostAllocation: 集群成本分摊结果
+func (ca *CostAllocator) AllocateCluster(clusterID uint, clusterName string, nodes []NodeCapacityInfo, pods []PodResourceInfo) *ClusterCostAllocation {
+	result := &ClusterCostAllocation{
+		ClusterID:      clusterID,
+		ClusterName:    clusterName,
+		NodeCount:      len(nodes),
+		IdleStrategy:   ca.idleStrategy,
+		SystemStrategy: ca.systemStrategy,
+		Namespaces:     []NamespaceCostAllocation{},
+		LineItems:      []CostLineItem{},
+	}
+
+	for _, node := range nodes {
+		result.AllocatableCPU += node.AllocatableCPU
+		result.AllocatableMemory += node.AllocatableMemory
+	}
+
+	// 按命名空间汇总请求量和使用量，系统命名空间单独计入系统开销
+	// 请求量取容器实际配置，未配置请求的Pod不计费；使用量只统计来自真实metrics的数据
+	nsMap := make(map[string]*NamespaceCostAllocation)
+	for i := range pods {
+		pod := &pods[i]
+		cpuRequest, memoryRequest := podContainerRequests(pod)
+		if ca.systemNamespaces[pod.Namespace] {
+			result.SystemCPU += cpuRequest
+			result.SystemMemory += memoryRequest
+			continue
+		}
+
+		ns, exists := nsMap[pod.Namespace]
+		if !exists {
+			ns = &NamespaceCostAllocation{
+				NamespaceName: pod.Namespace,
+				ClusterName:   clusterName,
+			}
+			nsMap[pod.Namespace] = ns
+		}
+		ns.PodCount++
+		ns.CPURequest += cpuRequest
+		ns.MemoryRequest += memoryRequest
+		if pod.CPUMetricsAvailable {
+			ns.CPUUsage += pod.CPUUsage
+		}
+		if pod.MemoryMetricsAvailable {
+			ns.MemoryUsage += pod.MemoryUsage
+		}
+
+		result.TenantCPU += cpuRequest
+		result.TenantMemory += memoryRequest
+	}
+
+	// 闲置容量 = 可分配量 - 所有请求量（超卖时按0计算）
+	result.IdleCPU = maxInt64(0, result.AllocatableCPU-result.TenantCPU-result.SystemCPU)
+	result.IdleMemory = maxInt64(0, result.AllocatableMemory-result.TenantMemory-result.SystemMemory)
+
+	// 请求量超过可分配容量时按比例折算请求成本，使总成本等于可分配容量的成本
+	cpuScale := overcommitScale(result.AllocatableCPU, result.TenantCPU+result.SystemCPU)
+	memoryScale := overcommitScale(result.AllocatableMemory, result.TenantMemory+result.SystemMemory)
+	requestCost := func(cpu, memory int64) float64 {
+		return ca.model.CPUMonthlyCost(cpu)*cpuScale + ca.model.MemoryMonthlyCost(memory)*memoryScale
+	}
+
+	idleCPUCost := ca.model.CPUMonthlyCost(result.IdleCPU)
+	idleMemoryCost := ca.model.MemoryMonthlyCost(result.IdleMemory)
+	systemCPUCost := ca.model.CPUMonthlyCost(result.SystemCPU) * cpuScale
+	systemMemoryCost := ca.model.MemoryMonthlyCost(result.SystemMemory) * memoryScale
+
+	result.IdleCost = idleCPUCost + idleMemoryCost
+	result.SystemCost = systemCPUCost + systemMemoryCost
+	result.TenantDirectCost = requestCost(result.TenantCPU, result.TenantMemory)
+	result.TotalCost = result.TenantDirectCost + result.SystemCost + result.IdleCost
+	if result.TotalCost > 0 {
+		result.IdleCostPct = result.IdleCost / result.TotalCost * 100
+	}
+
+	namespaces := make([]*NamespaceCostAllocation, 0, len(nsMap))
+	for _, ns := range nsMap {
+		ns.DirectCost = requestCost(ns.CPURequest, ns.MemoryRequest)
+		namespaces = append(namespaces, ns)
+	}
+
+	// 按策略分摊闲置容量成本
+	if ca.idleStrategy == CostStrategySeparate || len(namespaces) == 0 {
+		result.LineItems = append(result.LineItems, CostLineItem{
+			Name: "idle", CPU: result.IdleCPU, Memory: result.IdleMemory, Cost: result.IdleCost,
+		})
+	} else {
+		cpuWeights, memoryWeights := ca.allocationWeights(namespaces, ca.idleStrategy)
+		for i, ns := range namespaces {
+			ns.IdleCost = idleCPUCost*cpuWeights[i] + idleMemoryCost*memoryWeights[i]
+		}
+	}
+
+	// 按策略分摊系统开销成本
+	if ca.systemStrategy == CostStrategySeparate || len(namespaces) == 0 {
+		result.LineItems = append(result.LineItems, CostLineItem{
+			Name: "system", CPU: result.SystemCPU, Memory: result.SystemMemory, Cost: result.SystemCost,
+		})
+	} else {
+		cpuWeights, memoryWeights := ca.allocationWeights(namespaces, ca.systemStrategy)
+		for i, ns := range namespaces {
+			ns.SystemCost = systemCPUCost*cpuWeights[i] + systemMemoryCost*memoryWeights[i]
+		}
+	}
+
+	for _, ns := range namespaces {
+		ns.FullyLoadedCost = ns.DirectCost + ns.IdleCost + ns.SystemCost
+		if ns.DirectCost > 0 {
+			ns.CostIncreasePct = (ns.FullyLoadedCost - ns.DirectCost) / ns.DirectCost * 100
+		}
+		result.Namespaces = append(result.Namespaces, *ns)
+	}
+
+	// 按全量成本从高到低排序
+	sort.Slice(result.Namespaces, func(i, j int) bool {
+		return result.Namespaces[i].FullyLoadedCost > result.Namespaces[j].FullyLoadedCost
+	})
+
+	return result
+}
+
+// allocationWeights 根据分摊策略计算各命名空间的CPU和内存分摊权重
+// 权重之和为1；当所选指标全为0时退化为平均分摊
+func (ca *CostAllocator) allocationWeights(namespaces []*NamespaceCostAllocation, strategy string) ([]float64, []float64) {
+	cpuValues := make([]float64, len(namespaces))
+	memoryValues := make([]float64, len(namespaces))
+
+	for i, ns := range namespaces {
+		switch strategy {
+		case CostStrategyProportionalUsage:
+			cpuValues[i] = float64(ns.CPUUsage)
+			memoryValues[i] = float64(ns.MemoryUsage)
+		case CostStrategyEven:
+			cpuValues[i] = 1
+			memoryValues[i] = 1
+		default:
+			cpuValues[i] = float64(ns.CPURequest)
+			memoryValues[i] = float64(ns.MemoryRequest)
+		}
+	}
+
+	return normalizeWeights(cpuValues), normalizeWeights(memoryValues)
+}
+
+// normalizeWeights 将数值归一化为权重，总和为0时返回平均权重
+func normalizeWeights(values []float64) []float64 {
+	weights := make([]float64, len(values))
+	if len(values) == 0 {
+		return weights
+	}
+
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+
+	for i, v := range values {
+		if total > 0 {
+			weights[i] = v / total
+		} else {
+			weights[i] = 1.0 / float64(len(values))
+		}
+	}
+	return weights
+}
+
+// overcommitScale 请求总量超过可分配容量时的成本折算比例，未超卖或缺少节点容量时为1
+func overcommitScale(allocatable, requested int64) float64 {
+	if allocatable <= 0 || requested <= allocatable {
+		return 1
+	}
+	return float64(allocatable) / float64(requested)
+}
+
+// maxInt64 返回两个int64中的较大值
+func maxInt64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// GetCostAllocation 获取多集群成本分摊报告 - 计算闲置容量与系统开销并分摊到各命名空间
+// 参数:
+//   - ctx: 上下文对象，用于控制请求生命周期和超时
+//   - clusterID: 可选的集群ID筛选条件，为nil时核算所有集群
+//   - idleStrategy: 闲置容量分摊策略，为空时使用配置值
+//   - systemStrategy: 系统开销分摊策略，为空时使用配置值
+//
+// 返回:
+//   - *CostAllocationReport: 成本分摊报告
+//   - error: 核算过程中的错误信息
+func (mc *MultiClusterResourceCollector) GetCostAllocation(ctx context.Context, clusterID *uint, idleStrategy, systemStrategy string) (*CostAllocationReport, error) {
+	clusters, err := mc.clusterService.GetAllClusters()
+	if err != nil {
+		return nil, fmt.Errorf("获取集群列表失败: %v", err)
+	}
+
+	if clusterID != nil {
+		targetCluster, found := findClusterByID(clusters, *clusterID)
+		if !found {
+			return nil, fmt.Errorf("集群ID %d 不存在", *clusterID)
+		}
+		clusters = []models.ClusterConfig{targetCluster}
+	}
+
+	allocator := NewCostAllocator(idleStrategy, systemStrategy)
+	report := &CostAllocationReport{
+		Clusters:    []ClusterCostAllocation{},
+		Pricing:     allocator.model,
+		GeneratedAt: time.Now(),
+	}
+
+	for _, cluster := range clusters {
+		if cluster.Status != "online" {
+			continue
+		}
+
+		singleCollector, err := mc.newClusterCollector(&cluster)
+		if err != nil {
+			logger.Error("%v，跳过成本核算", err)
+			continue
+		}
+
+		nodes, err := singleCollector.collectNodeCapacity(ctx, cluster.ClusterName)
+		if err != nil {
+			logger.Error("获取集群 %s 节点容量失败，跳过成本核算: %v", cluster.ClusterName, err)
+			continue
+		}
+
+		pods, err := singleCollector.collectAllPodsWithoutFiltering(ctx, cluster.ClusterName)
+		if err != nil {
+			logger.Error("收集集群 %s Pod数据失败，跳过成本核算: %v", cluster.ClusterName, err)
+			continue
+		}
+
+		allocation := allocator.AllocateCluster(cluster.ID, cluster.ClusterName, nodes, pods)
+		report.Clusters = append(report.Clusters, *allocation)
+		report.TotalCost += allocation.TotalCost
+		report.TotalIdleCost += allocation.IdleCost
+		report.TotalSystemCost += allocation.SystemCost
+		report.ClustersAnalyzed++
+
+		logger.Info("集群 %s 成本核算完成: 总成本=%.2f, 闲置成本=%.2f (%.1f%%), 系统开销=%.2f",
+			cluster.ClusterName, allocation.TotalCost, allocation.IdleCost, allocation.IdleCostPct, allocation.SystemCost)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,243 @@
+package collector
+
+import (
+	"math"
+	"testing"
+
+	"cluster-resource-insight/pkg/cost"
+)
+
+// unitCostAllocator 每核CPU和每GiB内存月度成本均为1的分摊计算器
+func unitCostAllocator(idleStrategy, systemStrategy string) *CostAllocator {
+	ca := NewCostAllocator(idleStrategy, systemStrategy)
+	ca.model = cost.NewModel(1/cost.HoursPerMonth, 1/cost.HoursPerMonth)
+	return ca
+}
+
+// costPod 构造成本分摊测试Pod，请求量单位为核和GiB，使用量小于0表示该项指标缺失
+func costPod(namespace string, cpuCores, memoryGiB, cpuUsageCores, memoryUsageGiB float64) PodResourceInfo {
+	pod := testPod(namespace+"-pod", "node-a", int64(cpuCores*1000), 0)
+	pod.Namespace = namespace
+	pod.MemoryRequest = int64(memoryGiB * float64(gib))
+	pod.Containers[0].MemoryRequest = pod.MemoryRequest
+	if cpuUsageCores >= 0 {
+		pod.CPUUsage, pod.CPUMetricsAvailable = int64(cpuUsageCores*1000), true
+	} else {
+		// 缺失的指标可能带有估算值，不应参与按使用量分摊
+		pod.CPUUsage = 5000
+	}
+	if memoryUsageGiB >= 0 {
+		pod.MemoryUsage, pod.MemoryMetricsAvailable = int64(memoryUsageGiB*float64(gib)), true
+	} else {
+		pod.MemoryUsage = 5 * gib
+	}
+	pod.MetricsAvailable = pod.CPUMetricsAvailable && pod.MemoryMetricsAvailable
+	return pod
+}
+
+func TestAllocateCluster(t *testing.T) {
+	nodes := []NodeCapacityInfo{testNode("node-a", 6000, 6), testNode("node-b", 4000, 4)}
+	// 可分配10核10GiB；租户a请求2核2GiB，租户b请求4核2GiB且缺少内存指标，系统命名空间请求2核2GiB
+	// 闲置容量为2核4GiB，成本6；系统开销成本4
+	pods := []PodResourceInfo{
+		costPod("a", 2, 2, 1, 1),
+		costPod("b", 4, 2, 3, -1),
+		costPod("kube-system", 2, 2, 1, 1),
+	}
+
+	tests := []struct {
+		name           string
+		idleStrategy   string
+		systemStrategy string
+		nodes          []NodeCapacityInfo
+		pods           []PodResourceInfo
+		wantTotal      float64
+		wantIdle       map[string]float64 // 各命名空间分摊到的闲置成本
+		wantSystem     map[string]float64 // 各命名空间分摊到的系统开销成本
+		wantLineItems  map[string]float64 // 独立列示的成本项
+	}{
+		{
+			name:          "空输入",
+			idleStrategy:  CostStrategyProportionalRequests,
+			wantLineItems: map[string]float64{"idle": 0, "system": 0},
+		},
+		{
+			name:           "按请求量分摊",
+			idleStrategy:   CostStrategyProportionalRequests,
+			systemStrategy: CostStrategyProportionalRequests,
+			nodes:          nodes,
+			pods:           pods,
+			wantTotal:      20,
+			// CPU权重 1/3、2/3，内存权重 1/2、1/2
+			wantIdle:      map[string]float64{"a": 2.0/3 + 2, "b": 4.0/3 + 2},
+			wantSystem:    map[string]float64{"a": 2.0/3 + 1, "b": 4.0/3 + 1},
+			wantLineItems: map[string]float64{},
+		},
+		{
+			name:           "按使用量分摊时缺失的指标不计入",
+			idleStrategy:   CostStrategyProportionalUsage,
+			systemStrategy: CostStrategySeparate,
+			nodes:          nodes,
+			pods:           pods,
+			wantTotal:      20,
+			// CPU权重 1/4、3/4，b缺少内存指标，内存全部分摊给a
+			wantIdle:      map[string]float64{"a": 0.5 + 4, "b": 1.5},
+			wantSystem:    map[string]float64{"a": 0, "b": 0},
+			wantLineItems: map[string]float64{"system": 4},
+		},
+		{
+			name:           "所有租户都缺少指标时按使用量分摊退化为平均分摊",
+			idleStrategy:   CostStrategyProportionalUsage,
+			systemStrategy: CostStrategySeparate,
+			nodes:          nodes,
+			pods:           []PodResourceInfo{costPod("a", 2, 2, -1, -1), costPod("b", 4, 2, -1, -1), costPod("kube-system", 2, 2, 1, 1)},
+			wantTotal:      20,
+			wantIdle:       map[string]float64{"a": 3, "b": 3},
+			wantSystem:     map[string]float64{"a": 0, "b": 0},
+			wantLineItems:  map[string]float64{"system": 4},
+		},
+		{
+			name:           "平均分摊",
+			idleStrategy:   CostStrategyEven,
+			systemStrategy: CostStrategyEven,
+			nodes:          nodes,
+			pods:           pods,
+			wantTotal:      20,
+			wantIdle:       map[string]float64{"a": 3, "b": 3},
+			wantSystem:     map[string]float64{"a": 2, "b": 2},
+			wantLineItems:  map[string]float64{},
+		},
+		{
+			name:           "单独列示",
+			idleStrategy:   CostStrategySeparate,
+			systemStrategy: CostStrategySeparate,
+			nodes:          nodes,
+			pods:           pods,
+			wantTotal:      20,
+			wantIdle:       map[string]float64{"a": 0, "b": 0},
+			wantSystem:     map[string]float64{"a": 0, "b": 0},
+			wantLineItems:  map[string]float64{"idle": 6, "system": 4},
+		},
+		{
+			name:           "只有系统命名空间时闲置和系统开销单独列示",
+			idleStrategy:   CostStrategyProportionalRequests,
+			systemStrategy: CostStrategyProportionalRequests,
+			nodes:          nodes,
+			pods:           []PodResourceInfo{costPod("kube-system", 2, 2, 1, 1)},
+			wantTotal:      20,
+			wantLineItems:  map[string]float64{"idle": 16, "system": 4},
+		},
+		{
+			name:           "请求量超过可分配容量时按比例折算",
+			idleStrategy:   CostStrategyProportionalRequests,
+			systemStrategy: CostStrategyProportionalRequests,
+			nodes:          []NodeCapacityInfo{testNode("node-a", 4000, 8)},
+			// CPU请求8核超过可分配4核，按0.5折算；内存闲置2GiB
+			pods:          []PodResourceInfo{costPod("a", 6, 4, 1, 1), costPod("kube-system", 2, 2, 1, 1)},
+			wantTotal:     12,
+			wantIdle:      map[string]float64{"a": 2},
+			wantSystem:    map[string]float64{"a": 1 + 2},
+			wantLineItems: map[string]float64{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := unitCostAllocator(tt.idleStrategy, tt.systemStrategy).AllocateCluster(1, "test", tt.nodes, tt.pods)
+
+			if !nearlyEqual(result.TotalCost, tt.wantTotal) {
+				t.Errorf("总成本 %.4f，期望 %.4f", result.TotalCost, tt.wantTotal)
+			}
+			if len(result.Namespaces) != len(tt.wantIdle) {
+				t.Fatalf("命名空间 %d 个，期望 %d 个", len(result.Namespaces), len(tt.wantIdle))
+			}
+
+			// 各命名空间全量成本与独立成本项之和等于集群总成本
+			allocated := 0.0
+			for _, ns := range result.Namespaces {
+				if !nearlyEqual(ns.IdleCost, tt.wantIdle[ns.NamespaceName]) || !nearlyEqual(ns.SystemCost, tt.wantSystem[ns.NamespaceName]) {
+					t.Errorf("命名空间 %s 闲置成本 %.4f、系统开销 %.4f，期望 %.4f、%.4f", ns.NamespaceName,
+						ns.IdleCost, ns.SystemCost, tt.wantIdle[ns.NamespaceName], tt.wantSystem[ns.NamespaceName])
+				}
+				allocated += ns.FullyLoadedCost
+			}
+			if len(result.LineItems) != len(tt.wantLineItems) {
+				t.Errorf("独立成本项 %+v，期望 %v", result.LineItems, tt.wantLineItems)
+			}
+			for _, item := range result.LineItems {
+				if want, ok := tt.wantLineItems[item.Name]; !ok || !nearlyEqual(item.Cost, want) {
+					t.Errorf("独立成本项 %s 成本 %.4f，期望 %v", item.Name, item.Cost, tt.wantLineItems)
+				}
+				allocated += item.Cost
+			}
+			if !nearlyEqual(allocated, result.TotalCost) {
+				t.Errorf("分摊后成本合计 %.4f，与总成本 %.4f 不一致", allocated, result.TotalCost)
+			}
+		})
+	}
+}
+
+func TestAllocateClusterUsageOnlyFromMeasuredPods(t *testing.T) {
+	pods := []PodResourceInfo{
+		costPod("a", 1, 1, 0.5, 1),
+		costPod("a", 1, 1, -1, 2),
+		costPod("a", 1, 1, 0.25, -1),
+	}
+	result := unitCostAllocator(CostStrategySeparate, CostStrategySeparate).AllocateCluster(1, "test", nil, pods)
+	if len(result.Namespaces) != 1 {
+		t.Fatalf("命名空间 %d 个，期望 1 个", len(result.Namespaces))
+	}
+	ns := result.Namespaces[0]
+	if ns.PodCount != 3 || ns.CPUUsage != 750 || ns.MemoryUsage != 3*gib {
+		t.Errorf("Pod数 %d、CPU使用量 %d、内存使用量 %d，期望 3、750、%d", ns.PodCount, ns.CPUUsage, ns.MemoryUsage, 3*gib)
+	}
+}
+
+func TestNormalizeWeights(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   []float64
+	}{
+		{"空输入", nil, []float64{}},
+		{"按比例", []float64{1, 3}, []float64{0.25, 0.75}},
+		{"全为0时平均分摊", []float64{0, 0, 0, 0}, []float64{0.25, 0.25, 0.25, 0.25}},
+		{"部分为0", []float64{0, 2}, []float64{0, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := normalizeWeights(tt.values)
+			if len(got) != len(tt.want) {
+				t.Fatalf("得到 %v，期望 %v", got, tt.want)
+			}
+			for i := range got {
+				if !nearlyEqual(got[i], tt.want[i]) {
+					t.Errorf("得到 %v，期望 %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestOvercommitScale(t *testing.T) {
+	tests := []struct {
+		name                   string
+		allocatable, requested int64
+		want                   float64
+	}{
+		{"未超卖", 4000, 3000, 1},
+		{"请求量等于容量", 4000, 4000, 1},
+		{"超卖", 4000, 8000, 0.5},
+		{"缺少节点容量", 0, 8000, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := overcommitScale(tt.allocatable, tt.requested); got != tt.want {
+				t.Errorf("得到 %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func nearlyEqual(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(1, math.Abs(b))
+}
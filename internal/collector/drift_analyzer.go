@@ -0,0 +1,487 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"cluster-resource-insight/internal/config"
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/models"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// 规格失衡方向
+const (
+	DriftOverProvisioned  = "over_provisioned"  // 相对其他集群使用率明显偏低，请求量偏大
+	DriftUnderProvisioned = "under_provisioned" // 相对其他集群使用率明显偏高且已超过请求量
+)
+
+// driftSettings 跨集群配置漂移分析参数
+type driftSettings struct {
+	matchLabel     string
+	misSizedFactor float64
+}
+
+// DriftAnalysisRequest 跨集群配置漂移分析请求
+type DriftAnalysisRequest struct {
+	ClusterIDs []uint // 参与对比的集群，为空时对比所有在线集群
+	Namespace  string // 命名空间筛选，为空时不限
+	MatchLabel string // 匹配标签，为空时使用配置值
+}
+
+// WorkloadDriftEntry 工作负载在单个集群中的配置和使用情况，资源量均为单Pod数值
+type WorkloadDriftEntry struct {
+	ClusterID              uint     `json:"cluster_id"`
+	ClusterName            string   `json:"cluster_name"`
+	Namespace              string   `json:"namespace"`
+	Kind                   string   `json:"kind"`
+	Name                   string   `json:"name"`
+	Replicas               int32    `json:"replicas"`                 // 期望副本数
+	RunningPods            int      `json:"running_pods"`             // 实际采集到的Pod数量
+	CPURequest             int64    `json:"cpu_request"`              // 单Pod CPU请求量 (millicores)
+	CPULimit               int64    `json:"cpu_limit"`                // 单Pod CPU限制量 (millicores)
+	MemoryRequest          int64    `json:"memory_request"`           // 单Pod内存请求量 (bytes)
+	MemoryLimit            int64    `json:"memory_limit"`             // 单Pod内存限制量 (bytes)
+	CPUUsage               int64    `json:"cpu_usage"`                // 单Pod平均CPU使用量 (millicores)
+	MemoryUsage            int64    `json:"memory_usage"`             // 单Pod平均内存使用量 (bytes)
+	CPUUsageRatio          float64  `json:"cpu_usage_ratio"`          // CPU使用量/请求量
+	MemoryUsageRatio       float64  `json:"memory_usage_ratio"`       // 内存使用量/请求量
+	MetricsAvailable       bool     `json:"metrics_available"`        // 使用量是否来自真实监控数据，任一指标可用即为true
+	CPUMetricsAvailable    bool     `json:"cpu_metrics_available"`    // CPU使用量是否来自真实监控数据
+	MemoryMetricsAvailable bool     `json:"memory_metrics_available"` // 内存使用量是否来自真实监控数据
+	MisSizing              string   `json:"mis_sizing"`               // 规格失衡方向，为空表示正常
+	MisSizingReasons       []string `json:"mis_sizing_reasons"`       // 规格失衡原因
+}
+
+// DriftDiff 单个字段在各集群间的差异
+type DriftDiff struct {
+	Field       string           `json:"field"`        // 字段名：replicas/cpu_request/cpu_limit/memory_request/memory_limit/cpu_usage/memory_usage
+	Values      map[string]int64 `json:"values"`       // 各集群取值，键为 集群名/命名空间
+	MinValue    int64            `json:"min_value"`    // 最小值
+	MaxValue    int64            `json:"max_value"`    // 最大值
+	SpreadRatio float64          `json:"spread_ratio"` // 最大值/最小值，最小值为0时为0
+}
+
+// WorkloadDriftGroup 跨集群匹配到的同一工作负载
+type WorkloadDriftGroup struct {
+	MatchKey string               `json:"match_key"` // 匹配键
+	Kind     string               `json:"kind"`      // 工作负载类型
+	Clusters []string             `json:"clusters"`  // 出现的集群
+	Entries  []WorkloadDriftEntry `json:"entries"`   // 各集群中的配置和使用情况
+	Diffs    []DriftDiff          `json:"diffs"`     // 存在差异的字段
+	MisSized bool                 `json:"mis_sized"` // 是否有集群规格明显失衡
+}
+
+// CrossClusterDriftReport 跨集群配置漂移报告
+type CrossClusterDriftReport struct {
+	MatchBy           string               `json:"match_by"`           // 匹配方式：namespace/name 或 label:<key>
+	MisSizedFactor    float64              `json:"mis_sized_factor"`   // 规格失衡判定倍数
+	ClustersAnalyzed  []string             `json:"clusters_analyzed"`  // 参与对比的集群
+	WorkloadsCompared int                  `json:"workloads_compared"` // 在至少两个集群中出现的工作负载数
+	DriftedWorkloads  int                  `json:"drifted_workloads"`  // 配置存在差异的工作负载数
+	MisSizedWorkloads int                  `json:"mis_sized_workloads"`
+	Groups            []WorkloadDriftGroup `json:"groups"` // 规格失衡和差异较多的排在前面
+	GeneratedAt       time.Time            `json:"generated_at"`
+}
+
+// loadDriftSettings 读取跨集群配置漂移配置，未配置项使用默认值
+func loadDriftSettings() driftSettings {
+	settings := driftSettings{
+		misSizedFactor: 3,
+	}
+
+	if driftConfig := config.GetDriftConfig(); driftConfig != nil {
+		settings.matchLabel = driftConfig.MatchLabel
+		if driftConfig.MisSizedFactor > 1 {
+			settings.misSizedFactor = driftConfig.MisSizedFactor
+		}
+	}
+
+	return settings
+}
+
+// AnalyzeCrossClusterDrift 匹配多个集群中的同一工作负载，对比请求、限制、副本数和实际使用量
+// 参数:
+//   - ctx: 上下文对象
+//   - req: 分析请求，包括参与对比的集群、命名空间和匹配标签
+//
+// 返回:
+//   - *CrossClusterDriftReport: 漂移报告
+//   - error: 分析过程中的错误信息
+func (mc *MultiClusterResourceCollector) AnalyzeCrossClusterDrift(ctx context.Context, req DriftAnalysisRequest) (*CrossClusterDriftReport, error) {
+	settings := loadDriftSettings()
+	if req.MatchLabel != "" {
+		settings.matchLabel = req.MatchLabel
+	}
+
+	clusters, err := mc.clusterService.GetAllClusters()
+	if err != nil {
+		return nil, fmt.Errorf("获取集群列表失败: %v", err)
+	}
+
+	if len(req.ClusterIDs) > 0 {
+		selected := make([]models.ClusterConfig, 0, len(req.ClusterIDs))
+		for _, id := range req.ClusterIDs {
+			cluster, found := findClusterByID(clusters, id)
+			if !found {
+				return nil, fmt.Errorf("集群ID %d 不存在", id)
+			}
+			selected = append(selected, cluster)
+		}
+		clusters = selected
+	}
+
+	report := &CrossClusterDriftReport{
+		MatchBy:          "namespace/name",
+		MisSizedFactor:   settings.misSizedFactor,
+		ClustersAnalyzed: []string{},
+		Groups:           []WorkloadDriftGroup{},
+		GeneratedAt:      time.Now(),
+	}
+	if settings.matchLabel != "" {
+		report.MatchBy = "label:" + settings.matchLabel
+	}
+
+	groups := make(map[string]*WorkloadDriftGroup)
+	for _, cluster := range clusters {
+		if cluster.Status != "online" {
+			continue
+		}
+
+		singleCollector, err := mc.newClusterCollector(&cluster)
+		if err != nil {
+			logger.Error("%v，跳过漂移分析", err)
+			continue
+		}
+
+		entries, err := singleCollector.collectDriftEntries(ctx, cluster, req.Namespace)
+		if err != nil {
+			logger.Error("收集集群 %s 工作负载失败，跳过漂移分析: %v", cluster.ClusterName, err)
+			continue
+		}
+		report.ClustersAnalyzed = append(report.ClustersAnalyzed, cluster.ClusterName)
+
+		for _, entry := range entries {
+			key := driftMatchKey(entry, settings.matchLabel)
+			group, ok := groups[key]
+			if !ok {
+				group = &WorkloadDriftGroup{MatchKey: key, Kind: entry.workload.Kind}
+				groups[key] = group
+			}
+			group.Entries = append(group.Entries, entry.workload)
+		}
+	}
+
+	for _, group := range groups {
+		clusterSet := make(map[string]bool)
+		for _, entry := range group.Entries {
+			if !clusterSet[entry.ClusterName] {
+				clusterSet[entry.ClusterName] = true
+				group.Clusters = append(group.Clusters, entry.ClusterName)
+			}
+		}
+		// 只在一个集群出现的工作负载无法比较
+		if len(group.Clusters) < 2 {
+			continue
+		}
+
+		group.Diffs = compareDriftEntries(group.Entries, settings.misSizedFactor)
+		group.MisSized = markMisSizedEntries(group.Entries, settings.misSizedFactor)
+
+		report.WorkloadsCompared++
+		if len(group.Diffs) > 0 {
+			report.DriftedWorkloads++
+		}
+		if group.MisSized {
+			report.MisSizedWorkloads++
+		}
+		report.Groups = append(report.Groups, *group)
+	}
+
+	sort.Slice(report.Groups, func(i, j int) bool {
+		if report.Groups[i].MisSized != report.Groups[j].MisSized {
+			return report.Groups[i].MisSized
+		}
+		if len(report.Groups[i].Diffs) != len(report.Groups[j].Diffs) {
+			return len(report.Groups[i].Diffs) > len(report.Groups[j].Diffs)
+		}
+		return report.Groups[i].MatchKey < report.Groups[j].MatchKey
+	})
+
+	logger.Info("跨集群配置漂移分析完成: 对比 %d 个集群, %d 个工作负载存在差异, %d 个规格失衡",
+		len(report.ClustersAnalyzed), report.DriftedWorkloads, report.MisSizedWorkloads)
+	return report, nil
+}
+
+// driftCandidate 单集群中的工作负载及其标签
+type driftCandidate struct {
+	workload WorkloadDriftEntry
+	labels   map[string]string
+}
+
+// collectDriftEntries 收集集群中Deployment、StatefulSet和DaemonSet的模板配置，并汇总实际使用量
+func (rc *ResourceCollector) collectDriftEntries(ctx context.Context, cluster models.ClusterConfig, namespace string) ([]driftCandidate, error) {
+	listNamespace := namespace
+	if listNamespace == "" {
+		listNamespace = metav1.NamespaceAll
+	}
+
+	var candidates []driftCandidate
+	add := func(objectMeta metav1.ObjectMeta, kind string, replicas int32, template corev1.PodTemplateSpec) {
+		entry := WorkloadDriftEntry{
+			ClusterID:        cluster.ID,
+			ClusterName:      cluster.ClusterName,
+			Namespace:        objectMeta.Namespace,
+			Kind:             kind,
+			Name:             objectMeta.Name,
+			Replicas:         replicas,
+			MisSizingReasons: []string{},
+		}
+		entry.CPURequest, entry.MemoryRequest = podSpecRequests(template.Spec)
+		entry.CPULimit, entry.MemoryLimit = podSpecLimits(template.Spec)
+
+		// 标签优先取工作负载本身，缺失时取Pod模板
+		labels := make(map[string]string, len(template.Labels)+len(objectMeta.Labels))
+		for k, v := range template.Labels {
+			labels[k] = v
+		}
+		for k, v := range objectMeta.Labels {
+			labels[k] = v
+		}
+		candidates = append(candidates, driftCandidate{workload: entry, labels: labels})
+	}
+
+	deployments, err := rc.kubeClient.AppsV1().Deployments(listNamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取Deployment列表失败: %v", err)
+	}
+	for _, deployment := range deployments.Items {
+		replicas := int32(1)
+		if deployment.Spec.Replicas != nil {
+			replicas = *deployment.Spec.Replicas
+		}
+		add(deployment.ObjectMeta, "Deployment", replicas, deployment.Spec.Template)
+	}
+
+	statefulSets, err := rc.kubeClient.AppsV1().StatefulSets(listNamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取StatefulSet列表失败: %v", err)
+	}
+	for _, statefulSet := range statefulSets.Items {
+		replicas := int32(1)
+		if statefulSet.Spec.Replicas != nil {
+			replicas = *statefulSet.Spec.Replicas
+		}
+		add(statefulSet.ObjectMeta, "StatefulSet", replicas, statefulSet.Spec.Template)
+	}
+
+	daemonSets, err := rc.kubeClient.AppsV1().DaemonSets(listNamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取DaemonSet列表失败: %v", err)
+	}
+	for _, daemonSet := range daemonSets.Items {
+		add(daemonSet.ObjectMeta, "DaemonSet", daemonSet.Status.DesiredNumberScheduled, daemonSet.Spec.Template)
+	}
+
+	pods, err := rc.collectAllPodsWithoutFiltering(ctx, cluster.ClusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	// 使用量只统计对应指标有真实监控数据的Pod，平均值按各指标的有效Pod数计算
+	type usageSum struct {
+		count                    int
+		cpuMeasured, memMeasured int
+		cpu, memory              int64
+	}
+	usage := make(map[string]*usageSum)
+	for _, pod := range pods {
+		key := pod.Namespace + "/" + pod.WorkloadKind + "/" + pod.WorkloadName
+		sum, ok := usage[key]
+		if !ok {
+			sum = &usageSum{}
+			usage[key] = sum
+		}
+		sum.count++
+		if pod.CPUMetricsAvailable {
+			sum.cpuMeasured++
+			sum.cpu += pod.CPUUsage
+		}
+		if pod.MemoryMetricsAvailable {
+			sum.memMeasured++
+			sum.memory += pod.MemoryUsage
+		}
+	}
+
+	for i := range candidates {
+		entry := &candidates[i].workload
+		sum, ok := usage[entry.Namespace+"/"+entry.Kind+"/"+entry.Name]
+		if !ok || sum.count == 0 {
+			continue
+		}
+		entry.RunningPods = sum.count
+		entry.CPUMetricsAvailable = sum.cpuMeasured > 0
+		entry.MemoryMetricsAvailable = sum.memMeasured > 0
+		entry.MetricsAvailable = entry.CPUMetricsAvailable || entry.MemoryMetricsAvailable
+		if entry.CPUMetricsAvailable {
+			entry.CPUUsage = sum.cpu / int64(sum.cpuMeasured)
+			if entry.CPURequest > 0 {
+				entry.CPUUsageRatio = float64(entry.CPUUsage) / float64(entry.CPURequest)
+			}
+		}
+		if entry.MemoryMetricsAvailable {
+			entry.MemoryUsage = sum.memory / int64(sum.memMeasured)
+			if entry.MemoryRequest > 0 {
+				entry.MemoryUsageRatio = float64(entry.MemoryUsage) / float64(entry.MemoryRequest)
+			}
+		}
+	}
+
+	return candidates, nil
+}
+
+// driftMatchKey 生成跨集群匹配键，配置了匹配标签且工作负载带有该标签时按标签值匹配
+func driftMatchKey(candidate driftCandidate, matchLabel string) string {
+	if matchLabel != "" {
+		if value := candidate.labels[matchLabel]; value != "" {
+			return candidate.workload.Kind + "/" + value
+		}
+	}
+	return candidate.workload.Namespace + "/" + candidate.workload.Kind + "/" + candidate.workload.Name
+}
+
+// compareDriftEntries 找出各集群间取值不同的字段
+// 请求、限制和副本数只要不一致即视为差异；使用量天然存在波动，最大值超过最小值的指定倍数才视为差异
+func compareDriftEntries(entries []WorkloadDriftEntry, usageFactor float64) []DriftDiff {
+	fields := []struct {
+		name     string
+		isUsage  bool
+		value    func(WorkloadDriftEntry) int64
+		measured func(WorkloadDriftEntry) bool
+	}{
+		{"replicas", false, func(e WorkloadDriftEntry) int64 { return int64(e.Replicas) }, nil},
+		{"cpu_request", false, func(e WorkloadDriftEntry) int64 { return e.CPURequest }, nil},
+		{"cpu_limit", false, func(e WorkloadDriftEntry) int64 { return e.CPULimit }, nil},
+		{"memory_request", false, func(e WorkloadDriftEntry) int64 { return e.MemoryRequest }, nil},
+		{"memory_limit", false, func(e WorkloadDriftEntry) int64 { return e.MemoryLimit }, nil},
+		{"cpu_usage", true, func(e WorkloadDriftEntry) int64 { return e.CPUUsage }, func(e WorkloadDriftEntry) bool { return e.CPUMetricsAvailable }},
+		{"memory_usage", true, func(e WorkloadDriftEntry) int64 { return e.MemoryUsage }, func(e WorkloadDriftEntry) bool { return e.MemoryMetricsAvailable }},
+	}
+
+	diffs := []DriftDiff{}
+	for _, field := range fields {
+		diff := DriftDiff{Field: field.name, Values: make(map[string]int64)}
+		first := true
+		for _, entry := range entries {
+			// 没有运行中Pod或该指标没有真实监控数据的集群不参与使用量对比
+			if field.isUsage && (entry.RunningPods == 0 || !field.measured(entry)) {
+				continue
+			}
+			value := field.value(entry)
+			diff.Values[entry.ClusterName+"/"+entry.Namespace] = value
+			if first || value < diff.MinValue {
+				diff.MinValue = value
+			}
+			if first || value > diff.MaxValue {
+				diff.MaxValue = value
+			}
+			first = false
+		}
+		if len(diff.Values) < 2 || diff.MinValue == diff.MaxValue {
+			continue
+		}
+		if diff.MinValue > 0 {
+			diff.SpreadRatio = float64(diff.MaxValue) / float64(diff.MinValue)
+		}
+		if field.isUsage && diff.SpreadRatio > 0 && diff.SpreadRatio < usageFactor {
+			continue
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs
+}
+
+// markMisSizedEntries 将各集群的使用量/请求量比例与其他集群的中位数比较，标记规格明显失衡的集群
+// 返回是否存在失衡的集群
+func markMisSizedEntries(entries []WorkloadDriftEntry, factor float64) bool {
+	misSized := false
+	resources := []struct {
+		label string
+		ratio func(WorkloadDriftEntry) float64
+	}{
+		{"CPU", func(e WorkloadDriftEntry) float64 { return e.CPUUsageRatio }},
+		{"内存", func(e WorkloadDriftEntry) float64 { return e.MemoryUsageRatio }},
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+		if entry.RunningPods == 0 || !entry.MetricsAvailable {
+			continue
+		}
+
+		for _, resource := range resources {
+			self := resource.ratio(*entry)
+			if self == 0 {
+				continue
+			}
+
+			var others []float64
+			for j, other := range entries {
+				if j == i || other.ClusterName == entry.ClusterName || other.RunningPods == 0 || !other.MetricsAvailable {
+					continue
+				}
+				if ratio := resource.ratio(other); ratio > 0 {
+					others = append(others, ratio)
+				}
+			}
+			if len(others) == 0 {
+				continue
+			}
+			median := medianOf(others)
+
+			switch {
+			case self < median/factor:
+				entry.MisSizing = DriftOverProvisioned
+				entry.MisSizingReasons = append(entry.MisSizingReasons, fmt.Sprintf("%s使用量仅为请求量的 %.0f%%，其他集群中位数为 %.0f%%", resource.label, self*100, median*100))
+			case self > median*factor && self > 1:
+				entry.MisSizing = DriftUnderProvisioned
+				entry.MisSizingReasons = append(entry.MisSizingReasons, fmt.Sprintf("%s使用量达到请求量的 %.0f%%，其他集群中位数为 %.0f%%", resource.label, self*100, median*100))
+			}
+		}
+
+		if entry.MisSizing != "" {
+			misSized = true
+		}
+	}
+
+	return misSized
+}
+
+// medianOf 计算中位数
+func medianOf(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// podSpecLimits 计算Pod模板中各容器的限制总量
+func podSpecLimits(spec corev1.PodSpec) (int64, int64) {
+	var cpu, memory int64
+	for _, container := range spec.Containers {
+		if quantity, ok := container.Resources.Limits[corev1.ResourceCPU]; ok {
+			cpu += quantity.MilliValue()
+		}
+		if quantity, ok := container.Resources.Limits[corev1.ResourceMemory]; ok {
+			memory += quantity.Value()
+		}
+	}
+	return cpu, memory
+}
@@ -0,0 +1,513 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"cluster-resource-insight/internal/config"
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/service"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// 闲置资源类型
+const (
+	IdleTypeIdleWorkload   = "idle_workload"   // 长期几乎没有CPU活动的工作负载
+	IdleTypeFailingCronJob = "failing_cronjob" // 最近的Job持续失败的CronJob
+	IdleTypeCompletedJob   = "completed_job"   // 已结束但长期未清理的Job
+	IdleTypeUnboundPVC     = "unbound_pvc"     // 未绑定的PVC
+	IdleTypeUnusedPVC      = "unused_pvc"      // 已绑定但没有Pod挂载的PVC
+)
+
+// idleSettings 闲置资源扫描参数
+type idleSettings struct {
+	lookbackDays         int
+	minIdleDays          int
+	cpuIdleThreshold     int64
+	completedJobHours    int
+	cronJobFailureStreak int
+}
+
+// IdleFinding 单条闲置资源发现
+type IdleFinding struct {
+	Type               string                 `json:"type"`                // 闲置类型
+	Namespace          string                 `json:"namespace"`           // 命名空间
+	Kind               string                 `json:"kind"`                // 资源类型
+	Name               string                 `json:"name"`                // 资源名称
+	Reason             string                 `json:"reason"`              // 判定原因
+	IdleDays           float64                `json:"idle_days"`           // 闲置天数
+	LastActivity       *time.Time             `json:"last_activity"`       // 最近一次活动时间，未知时为空
+	Evidence           map[string]interface{} `json:"evidence"`            // 判定依据
+	ReclaimableCPU     int64                  `json:"reclaimable_cpu"`     // 可回收CPU请求量 (millicores)
+	ReclaimableMemory  int64                  `json:"reclaimable_memory"`  // 可回收内存请求量 (bytes)
+	ReclaimableStorage int64                  `json:"reclaimable_storage"` // 可回收存储容量 (bytes)
+}
+
+// IdleNamespaceSummary 命名空间闲置资源汇总
+type IdleNamespaceSummary struct {
+	Namespace          string         `json:"namespace"`
+	FindingCount       int            `json:"finding_count"`
+	CountByType        map[string]int `json:"count_by_type"`
+	ReclaimableCPU     int64          `json:"reclaimable_cpu"`
+	ReclaimableMemory  int64          `json:"reclaimable_memory"`
+	ReclaimableStorage int64          `json:"reclaimable_storage"`
+}
+
+// IdleResourceReport 集群闲置资源报告
+type IdleResourceReport struct {
+	ClusterID          uint                   `json:"cluster_id"`
+	ClusterName        string                 `json:"cluster_name"`
+	LookbackDays       int                    `json:"lookback_days"`
+	Findings           []IdleFinding          `json:"findings"`
+	Namespaces         []IdleNamespaceSummary `json:"namespaces"`
+	CountByType        map[string]int         `json:"count_by_type"`
+	ReclaimableCPU     int64                  `json:"reclaimable_cpu"`
+	ReclaimableMemory  int64                  `json:"reclaimable_memory"`
+	ReclaimableStorage int64                  `json:"reclaimable_storage"`
+	GeneratedAt        time.Time              `json:"generated_at"`
+}
+
+// loadIdleSettings 读取闲置资源扫描配置，未配置项使用默认值
+func loadIdleSettings() idleSettings {
+	settings := idleSettings{
+		lookbackDays:         14,
+		minIdleDays:          7,
+		cpuIdleThreshold:     5,
+		completedJobHours:    72,
+		cronJobFailureStreak: 3,
+	}
+
+	if idleConfig := config.GetIdleConfig(); idleConfig != nil {
+		if idleConfig.LookbackDays > 0 {
+			settings.lookbackDays = idleConfig.LookbackDays
+		}
+		if idleConfig.MinIdleDays > 0 {
+			settings.minIdleDays = idleConfig.MinIdleDays
+		}
+		if idleConfig.CPUIdleThreshold > 0 {
+			settings.cpuIdleThreshold = idleConfig.CPUIdleThreshold
+		}
+		if idleConfig.CompletedJobHours > 0 {
+			settings.completedJobHours = idleConfig.CompletedJobHours
+		}
+		if idleConfig.CronJobFailureStreak > 0 {
+			settings.cronJobFailureStreak = idleConfig.CronJobFailureStreak
+		}
+	}
+
+	return settings
+}
+
+// ScanIdleResources 扫描集群中的闲置和僵尸资源
+// 工作负载闲置判定基于历史采集数据，Job、CronJob和PVC基于实时API状态
+// 参数:
+//   - ctx: 上下文对象，用于控制请求生命周期和超时
+//   - clusterID: 集群ID
+//   - namespace: 命名空间过滤，为空时扫描全部命名空间
+//
+// 返回:
+//   - *IdleResourceReport: 闲置资源报告
+//   - error: 扫描过程中的错误信息
+func (mc *MultiClusterResourceCollector) ScanIdleResources(ctx context.Context, clusterID uint, namespace string) (*IdleResourceReport, error) {
+	cluster, err := mc.clusterService.GetClusterByID(clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("获取集群配置失败: %v", err)
+	}
+
+	singleCollector, err := mc.newClusterCollector(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := loadIdleSettings()
+	now := time.Now()
+	listNamespace := namespace
+	if listNamespace == "" {
+		listNamespace = metav1.NamespaceAll
+	}
+
+	var findings []IdleFinding
+
+	activities, err := mc.historyService.GetWorkloadActivity(cluster.ID, now.AddDate(0, 0, -settings.lookbackDays), settings.cpuIdleThreshold)
+	if err != nil {
+		return nil, err
+	}
+	workloadFindings, err := singleCollector.scanIdleWorkloads(ctx, listNamespace, activities, settings)
+	if err != nil {
+		return nil, err
+	}
+	findings = append(findings, workloadFindings...)
+
+	jobFindings, err := singleCollector.scanJobs(ctx, listNamespace, settings, now)
+	if err != nil {
+		return nil, err
+	}
+	findings = append(findings, jobFindings...)
+
+	pvcFindings, err := singleCollector.scanPersistentVolumeClaims(ctx, listNamespace, now)
+	if err != nil {
+		return nil, err
+	}
+	findings = append(findings, pvcFindings...)
+
+	report := buildIdleReport(findings)
+	report.ClusterID = cluster.ID
+	report.ClusterName = cluster.ClusterName
+	report.LookbackDays = settings.lookbackDays
+	report.GeneratedAt = now
+
+	logger.Info("集群 %s 闲置资源扫描完成: 发现 %d 项, 可回收CPU %dm, 内存 %d bytes",
+		cluster.ClusterName, len(report.Findings), report.ReclaimableCPU, report.ReclaimableMemory)
+	return report, nil
+}
+
+// scanIdleWorkloads 根据历史活动情况识别长期闲置的Deployment和StatefulSet
+func (rc *ResourceCollector) scanIdleWorkloads(ctx context.Context, namespace string, activities []service.WorkloadActivity, settings idleSettings) ([]IdleFinding, error) {
+	activityMap := make(map[string]service.WorkloadActivity, len(activities))
+	for _, activity := range activities {
+		activityMap[activity.Namespace+"/"+activity.WorkloadKind+"/"+activity.WorkloadName] = activity
+	}
+
+	type liveWorkload struct {
+		namespace string
+		kind      string
+		name      string
+		replicas  int32
+		template  corev1.PodSpec
+	}
+	var workloads []liveWorkload
+
+	deployments, err := rc.kubeClient.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取Deployment列表失败: %v", err)
+	}
+	for _, deployment := range deployments.Items {
+		workloads = append(workloads, liveWorkload{deployment.Namespace, "Deployment", deployment.Name, deployment.Status.Replicas, deployment.Spec.Template.Spec})
+	}
+
+	statefulSets, err := rc.kubeClient.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取StatefulSet列表失败: %v", err)
+	}
+	for _, statefulSet := range statefulSets.Items {
+		workloads = append(workloads, liveWorkload{statefulSet.Namespace, "StatefulSet", statefulSet.Name, statefulSet.Status.Replicas, statefulSet.Spec.Template.Spec})
+	}
+
+	var findings []IdleFinding
+	for _, workload := range workloads {
+		if workload.replicas == 0 {
+			continue
+		}
+		activity, ok := activityMap[workload.namespace+"/"+workload.kind+"/"+workload.name]
+		if !ok {
+			continue
+		}
+
+		// 窗口内从未活跃时，闲置时长从首次采样算起；只计到最近一次有真实CPU数据的采样，
+		// 避免监控数据中断期间被误算为闲置
+		idleSince := activity.FirstSeen
+		if activity.LastActive != nil {
+			idleSince = *activity.LastActive
+		}
+		idleDays := activity.LastSeen.Sub(idleSince).Hours() / 24
+		if idleDays < float64(settings.minIdleDays) {
+			continue
+		}
+
+		cpuRequest, memoryRequest := podSpecRequests(workload.template)
+		reason := fmt.Sprintf("最近 %.1f 天内单Pod CPU使用量未超过 %dm", idleDays, settings.cpuIdleThreshold)
+		if activity.LastActive == nil {
+			reason = fmt.Sprintf("观察期内（%.1f 天）单Pod CPU使用量从未超过 %dm", idleDays, settings.cpuIdleThreshold)
+		}
+
+		findings = append(findings, IdleFinding{
+			Type:         IdleTypeIdleWorkload,
+			Namespace:    workload.namespace,
+			Kind:         workload.kind,
+			Name:         workload.name,
+			Reason:       reason,
+			IdleDays:     idleDays,
+			LastActivity: activity.LastActive,
+			Evidence: map[string]interface{}{
+				"replicas":      workload.replicas,
+				"sample_count":  activity.SampleCount,
+				"first_seen":    activity.FirstSeen,
+				"last_seen":     activity.LastSeen,
+				"max_cpu_usage": activity.MaxCPUUsage,
+				"avg_cpu_usage": activity.AvgCPUUsage,
+			},
+			ReclaimableCPU:    cpuRequest * int64(workload.replicas),
+			ReclaimableMemory: memoryRequest * int64(workload.replicas),
+		})
+	}
+
+	return findings, nil
+}
+
+// scanJobs 识别持续失败的CronJob和长期未清理的已结束Job
+func (rc *ResourceCollector) scanJobs(ctx context.Context, namespace string, settings idleSettings, now time.Time) ([]IdleFinding, error) {
+	jobs, err := rc.kubeClient.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取Job列表失败: %v", err)
+	}
+	cronJobs, err := rc.kubeClient.BatchV1().CronJobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取CronJob列表失败: %v", err)
+	}
+
+	var findings []IdleFinding
+	cronJobJobs := make(map[string][]batchv1.Job)
+	retention := time.Duration(settings.completedJobHours) * time.Hour
+
+	for _, job := range jobs.Items {
+		if owner := metav1.GetControllerOf(&job); owner != nil && owner.Kind == "CronJob" {
+			cronJobJobs[job.Namespace+"/"+owner.Name] = append(cronJobJobs[job.Namespace+"/"+owner.Name], job)
+			continue
+		}
+
+		// 独立Job：已结束、未设置自动清理且超过保留时长
+		finished, status, finishedAt := jobFinishState(&job)
+		if !finished || job.Spec.TTLSecondsAfterFinished != nil || finishedAt == nil || now.Sub(*finishedAt) < retention {
+			continue
+		}
+		idleDays := now.Sub(*finishedAt).Hours() / 24
+		findings = append(findings, IdleFinding{
+			Type:         IdleTypeCompletedJob,
+			Namespace:    job.Namespace,
+			Kind:         "Job",
+			Name:         job.Name,
+			Reason:       fmt.Sprintf("Job已%s %.1f 天，未设置ttlSecondsAfterFinished且未清理", status, idleDays),
+			IdleDays:     idleDays,
+			LastActivity: finishedAt,
+			Evidence: map[string]interface{}{
+				"status":      status,
+				"finished_at": *finishedAt,
+				"succeeded":   job.Status.Succeeded,
+				"failed":      job.Status.Failed,
+			},
+		})
+	}
+
+	for _, cronJob := range cronJobs.Items {
+		if cronJob.Spec.Suspend != nil && *cronJob.Spec.Suspend {
+			continue
+		}
+		ownedJobs := cronJobJobs[cronJob.Namespace+"/"+cronJob.Name]
+		sort.Slice(ownedJobs, func(i, j int) bool {
+			return ownedJobs[i].CreationTimestamp.After(ownedJobs[j].CreationTimestamp.Time)
+		})
+
+		// 从最近的Job开始统计连续失败次数，跳过仍在运行的Job
+		streak := 0
+		var lastFailureMessage string
+		for i := range ownedJobs {
+			finished, status, _ := jobFinishState(&ownedJobs[i])
+			if !finished {
+				continue
+			}
+			if status != "失败" {
+				break
+			}
+			if streak == 0 {
+				lastFailureMessage = jobFailureMessage(&ownedJobs[i])
+			}
+			streak++
+		}
+		if streak < settings.cronJobFailureStreak {
+			continue
+		}
+
+		var lastSuccess *time.Time
+		idleSince := cronJob.CreationTimestamp.Time
+		if cronJob.Status.LastSuccessfulTime != nil {
+			t := cronJob.Status.LastSuccessfulTime.Time
+			lastSuccess = &t
+			idleSince = t
+		}
+		evidence := map[string]interface{}{
+			"failure_streak":       streak,
+			"retained_jobs":        len(ownedJobs),
+			"schedule":             cronJob.Spec.Schedule,
+			"last_failure_message": lastFailureMessage,
+		}
+		if cronJob.Status.LastScheduleTime != nil {
+			evidence["last_schedule_time"] = cronJob.Status.LastScheduleTime.Time
+		}
+
+		findings = append(findings, IdleFinding{
+			Type:         IdleTypeFailingCronJob,
+			Namespace:    cronJob.Namespace,
+			Kind:         "CronJob",
+			Name:         cronJob.Name,
+			Reason:       fmt.Sprintf("最近 %d 个Job连续失败", streak),
+			IdleDays:     now.Sub(idleSince).Hours() / 24,
+			LastActivity: lastSuccess,
+			Evidence:     evidence,
+		})
+	}
+
+	return findings, nil
+}
+
+// scanPersistentVolumeClaims 识别未绑定或没有Pod挂载的PVC
+func (rc *ResourceCollector) scanPersistentVolumeClaims(ctx context.Context, namespace string, now time.Time) ([]IdleFinding, error) {
+	pvcs, err := rc.kubeClient.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取PVC列表失败: %v", err)
+	}
+	pods, err := rc.kubeClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取Pod列表失败: %v", err)
+	}
+
+	mounted := make(map[string]bool)
+	for _, pod := range pods.Items {
+		for _, volume := range pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim != nil {
+				mounted[pod.Namespace+"/"+volume.PersistentVolumeClaim.ClaimName] = true
+			}
+		}
+	}
+
+	var findings []IdleFinding
+	for _, pvc := range pvcs.Items {
+		age := now.Sub(pvc.CreationTimestamp.Time).Hours() / 24
+		storage := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		evidence := map[string]interface{}{
+			"phase":         string(pvc.Status.Phase),
+			"storage_class": pvc.Spec.StorageClassName,
+			"created_at":    pvc.CreationTimestamp.Time,
+		}
+
+		switch {
+		case pvc.Status.Phase == corev1.ClaimPending || pvc.Status.Phase == corev1.ClaimLost:
+			findings = append(findings, IdleFinding{
+				Type:      IdleTypeUnboundPVC,
+				Namespace: pvc.Namespace,
+				Kind:      "PersistentVolumeClaim",
+				Name:      pvc.Name,
+				Reason:    fmt.Sprintf("PVC处于 %s 状态 %.1f 天", pvc.Status.Phase, age),
+				IdleDays:  age,
+				Evidence:  evidence,
+			})
+		case pvc.Status.Phase == corev1.ClaimBound && !mounted[pvc.Namespace+"/"+pvc.Name]:
+			if capacity, ok := pvc.Status.Capacity[corev1.ResourceStorage]; ok {
+				storage = capacity
+			}
+			evidence["volume_name"] = pvc.Spec.VolumeName
+			findings = append(findings, IdleFinding{
+				Type:               IdleTypeUnusedPVC,
+				Namespace:          pvc.Namespace,
+				Kind:               "PersistentVolumeClaim",
+				Name:               pvc.Name,
+				Reason:             "PVC已绑定但当前没有Pod挂载",
+				IdleDays:           age,
+				Evidence:           evidence,
+				ReclaimableStorage: storage.Value(),
+			})
+		}
+	}
+
+	return findings, nil
+}
+
+// jobFinishState 返回Job是否已结束、结束状态和结束时间
+func jobFinishState(job *batchv1.Job) (bool, string, *time.Time) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			finishedAt := condition.LastTransitionTime.Time
+			if job.Status.CompletionTime != nil {
+				finishedAt = job.Status.CompletionTime.Time
+			}
+			return true, "完成", &finishedAt
+		case batchv1.JobFailed:
+			finishedAt := condition.LastTransitionTime.Time
+			return true, "失败", &finishedAt
+		}
+	}
+	return false, "", nil
+}
+
+// jobFailureMessage 获取Job失败原因
+func jobFailureMessage(job *batchv1.Job) string {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			if condition.Message != "" {
+				return condition.Reason + ": " + condition.Message
+			}
+			return condition.Reason
+		}
+	}
+	return ""
+}
+
+// podSpecRequests 计算Pod模板中各容器的请求总量
+func podSpecRequests(spec corev1.PodSpec) (int64, int64) {
+	var cpu, memory int64
+	for _, container := range spec.Containers {
+		if quantity, ok := container.Resources.Requests[corev1.ResourceCPU]; ok {
+			cpu += quantity.MilliValue()
+		}
+		if quantity, ok := container.Resources.Requests[corev1.ResourceMemory]; ok {
+			memory += quantity.Value()
+		}
+	}
+	return cpu, memory
+}
+
+// buildIdleReport 汇总闲置资源发现，按可回收资源从大到小排序
+func buildIdleReport(findings []IdleFinding) *IdleResourceReport {
+	report := &IdleResourceReport{
+		Findings:    []IdleFinding{},
+		Namespaces:  []IdleNamespaceSummary{},
+		CountByType: make(map[string]int),
+	}
+
+	namespaceMap := make(map[string]*IdleNamespaceSummary)
+	for _, finding := range findings {
+		summary, exists := namespaceMap[finding.Namespace]
+		if !exists {
+			summary = &IdleNamespaceSummary{Namespace: finding.Namespace, CountByType: make(map[string]int)}
+			namespaceMap[finding.Namespace] = summary
+		}
+		summary.FindingCount++
+		summary.CountByType[finding.Type]++
+		summary.ReclaimableCPU += finding.ReclaimableCPU
+		summary.ReclaimableMemory += finding.ReclaimableMemory
+		summary.ReclaimableStorage += finding.ReclaimableStorage
+
+		report.CountByType[finding.Type]++
+		report.ReclaimableCPU += finding.ReclaimableCPU
+		report.ReclaimableMemory += finding.ReclaimableMemory
+		report.ReclaimableStorage += finding.ReclaimableStorage
+		report.Findings = append(report.Findings, finding)
+	}
+
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		if report.Findings[i].ReclaimableMemory != report.Findings[j].ReclaimableMemory {
+			return report.Findings[i].ReclaimableMemory > report.Findings[j].ReclaimableMemory
+		}
+		return report.Findings[i].IdleDays > report.Findings[j].IdleDays
+	})
+
+	for _, summary := range namespaceMap {
+		report.Namespaces = append(report.Namespaces, *summary)
+	}
+	sort.Slice(report.Namespaces, func(i, j int) bool {
+		if report.Namespaces[i].ReclaimableMemory != report.Namespaces[j].ReclaimableMemory {
+			return report.Namespaces[i].ReclaimableMemory > report.Namespaces[j].ReclaimableMemory
+		}
+		return report.Namespaces[i].Namespace < report.Namespaces[j].Namespace
+	})
+
+	return report
+}
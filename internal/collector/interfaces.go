@@ -2,6 +2,8 @@ package collector
 
 import (
 	"context"
+
+	"cluster-resource-insight/pkg/ranking"
 )
 
 // DataCollector 数据收集器接口 - 定义数据收集的核心契约
@@ -13,8 +15,7 @@ type DataCollector interface {
 // DataAnalyzer 数据分析器接口 - 定义数据分析的核心契约
 type DataAnalyzer interface {
 	AnalyzeResourceUsage(pods []PodResourceInfo) *AnalysisResult
-	CalculateProblemScore(pod PodResourceInfo) float64
-	SortByProblemSeverity(pods []PodResourceInfo)
+	RankPods(ranker *ranking.Ranker, pods []PodResourceInfo, limit int) []PodResourceInfo
 }
 
 // CacheManager 缓存管理器接口 - 定义缓存操作的核心契约
@@ -60,6 +61,6 @@ type MonitoringDataProvider interface {
 		CPUUsage    float64 `json:"cpu_usage"`
 		MemoryUsage float64 `json:"memory_usage"`
 	}, error)
-	GetSimilarPodsInNamespace(clusterName, namespace string) ([]PodResourceInfo, error)
+	GetSimilarPods(ctx context.Context, target *PodResourceInfo) ([]SimilarPod, []ImagePeerSummary, []PodResourceInfo, error)
 	GetClusterAverageUsage(clusterName string) (cpuAvg, memoryAvg float64, err error)
 }
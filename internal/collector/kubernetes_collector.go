@@ -207,3 +207,78 @@ func (rc *ResourceCollector) getNamespacesSummary(ctx context.Context, clusterNa
 
 	return summaries, nil
 }
+
+// collectNodeCapacity 收集集群内所有节点的可分配资源信息
+func (rc *ResourceCollector) collectNodeCapacity(ctx context.Context, clusterName string) ([]NodeCapacityInfo, error) {
+	nodes, err := rc.kubeClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %v", err)
+	}
+
+	nodeInfos := make([]NodeCapacityInfo, 0, len(nodes.Items))
+	for _, node := range nodes.Items {
+		nodeInfo := NodeCapacityInfo{
+			NodeName:      node.Name,
+			ClusterName:   strings.TrimSpace(clusterName),
+			Labels:        node.Labels,
+			Taints:        node.Spec.Taints,
+			Unschedulable: node.Spec.Unschedulable,
+		}
+
+		// 优先使用可分配量，缺失时退化为节点容量
+		if cpu, ok := node.Status.Allocatable[corev1.ResourceCPU]; ok {
+			nodeInfo.AllocatableCPU = cpu.MilliValue()
+		} else if cpu, ok := node.Status.Capacity[corev1.ResourceCPU]; ok {
+			nodeInfo.AllocatableCPU = cpu.MilliValue()
+		}
+		if memory, ok := node.Status.Allocatable[corev1.ResourceMemory]; ok {
+			nodeInfo.AllocatableMemory = memory.Value()
+		} else if memory, ok := node.Status.Capacity[corev1.ResourceMemory]; ok {
+			nodeInfo.AllocatableMemory = memory.Value()
+		}
+
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == corev1.NodeReady {
+				nodeInfo.Ready = condition.Status == corev1.ConditionTrue
+				break
+			}
+		}
+
+		nodeInfos = append(nodeInfos, nodeInfo)
+	}
+
+	return nodeInfos, nil
+}
+
+// collectResourceQuotas 收集集群内所有ResourceQuota的上限和已用量
+func (rc *ResourceCollector) collectResourceQuotas(ctx context.Context) ([]ResourceQuotaInfo, error) {
+	quotas, err := rc.kubeClient.CoreV1().ResourceQuotas(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resource quotas: %v", err)
+	}
+
+	var quotaInfos []ResourceQuotaInfo
+	for _, quota := range quotas.Items {
+		for resourceName, hard := range quota.Status.Hard {
+			used := quota.Status.Used[resourceName]
+
+			info := ResourceQuotaInfo{
+				Namespace: quota.Namespace,
+				QuotaName: quota.Name,
+				Resource:  string(resourceName),
+			}
+			// CPU类资源使用millicores，其余资源使用原始数值
+			if strings.HasSuffix(string(resourceName), "cpu") {
+				info.Hard = hard.MilliValue()
+				info.Used = used.MilliValue()
+			} else {
+				info.Hard = hard.Value()
+				info.Used = used.Value()
+			}
+
+			quotaInfos = append(quotaInfos, info)
+		}
+	}
+
+	return quotaInfos, nil
+}
@@ -27,6 +27,11 @@ func NewMultiClusterResourceCollector() *MultiClusterResourceCollector {
 		clusterService:   service.NewClusterService(),
 		historyService:   service.NewHistoryService(),
 		activityService:  service.NewActivityService(),
+		forecastService:  service.NewForecastService(),
+		anomalyService:   service.NewAnomalyService(),
+		adoptionService:  service.NewAdoptionService(),
+		policyService:    service.NewPolicyService(),
+		configChangeService: service.NewConfigChangeService(),
 		podCacheTTL:      2 * time.Minute, // Pod数据缓存2分钟
 		analysisCacheTTL: 3 * time.Minute, // 分析结果缓存3分钟
 	}
@@ -200,6 +205,40 @@ func (mc *MultiClusterResourceCollector) CollectAllClustersDataWithPersistence(c
 						logger.Error("保存集群 %s 历史数据失败: %v", c.ClusterName, saveErr)
 					} else {
 						logger.Info("成功保存集群 %s 的 %d 条Pod监控数据", c.ClusterName, len(allClusterPods))
+
+						// 与历史基线比较，检测使用量异常
+						if mc.anomalyService != nil {
+							if _, detectErr := mc.anomalyService.DetectAnomalies(c.ID, c.ClusterName); detectErr != nil {
+								logger.Error("集群 %s 异常检测失败: %v", c.ClusterName, detectErr)
+							}
+						}
+					}
+				}
+
+				if err == nil {
+					nodes, nodeErr := singleCollector.collectNodeCapacity(clusterCtx, c.ClusterName)
+					if nodeErr != nil {
+						logger.Error("获取集群 %s 节点容量失败: %v", c.ClusterName, nodeErr)
+					}
+
+					// 保存容量和配额快照，用于容量预测
+					if mc.forecastService != nil {
+						mc.saveForecastSnapshots(clusterCtx, singleCollector, c, nodes, allClusterPods)
+					}
+
+					// 检查节点超售风险
+					if nodeErr == nil && mc.activityService != nil {
+						mc.checkOvercommitAlerts(c, nodes, allClusterPods)
+					}
+
+					// 执行资源策略检查，记录合规评分和违规
+					if mc.policyService != nil && loadPolicySettings().enabled {
+						mc.evaluateClusterPolicies(clusterCtx, singleCollector, &c, allClusterPods)
+					}
+
+					// 比较工作负载资源配置，记录 request/limit 变更
+					if mc.configChangeService != nil && service.ConfigChangeEnabled() {
+						mc.recordConfigChanges(clusterCtx, singleCollector, &c)
 					}
 				}
 			}
@@ -526,15 +565,16 @@ func (mc *MultiClusterResourceCollector) GetPodDetailAnalysis(ctx context.Contex
 		return nil, fmt.Errorf("查找Pod失败: %v", err)
 	}
 	
-	// 获取命名空间下的相似Pod用于对比
-	similarPods, err := helper.GetSimilarPodsInNamespace(ctx, clusterName, namespace)
+	// 获取相似Pod和相同镜像的对比数据
+	similarPods, imagePeers, namespacePods, err := helper.GetSimilarPods(ctx, targetPod)
 	if err != nil {
 		logger.Error("获取相似Pod失败: %v", err)
-		similarPods = []PodResourceInfo{} // 使用空切片作为后备
+		similarPods = []SimilarPod{} // 使用空切片作为后备
+		imagePeers = []ImagePeerSummary{}
 	}
 	
 	// 计算命名空间和集群平均值
-	nsAvg := helper.CalculateNamespaceAverage(similarPods)
+	nsAvg := helper.CalculateNamespaceAverage(namespacePods)
 	
 	// 获取集群所有Pod数据用于计算平均值
 	allPods, err := helper.GetAllClusterPods(ctx, clusterName)
@@ -570,6 +610,7 @@ func (mc *MultiClusterResourceCollector) GetPodDetailAnalysis(ctx context.Contex
 	analysis.ComparisonAnalysis.ClusterAverage.MemoryUsagePct = clusterAvg.MemoryUsagePct
 	analysis.ComparisonAnalysis.ClusterAverage.CPUUsagePct = clusterAvg.CPUUsagePct
 	analysis.ComparisonAnalysis.SimilarPods = similarPods
+	analysis.ComparisonAnalysis.ImagePeers = imagePeers
 	
 	// 告警信息
 	analysis.AlertsInfo.ActiveAlerts = helper.GetActiveAlerts(clusterName, namespace, podName)
@@ -638,6 +679,19 @@ func findClusterByID(clusters []models.ClusterConfig, clusterID uint) (models.Cl
 	return models.ClusterConfig{}, false
 }
 
+// newClusterCollector 为指定集群创建单集群收集器
+func (mc *MultiClusterResourceCollector) newClusterCollector(cluster *models.ClusterConfig) (*ResourceCollector, error) {
+	kubeClient, metricsClient, err := mc.clusterService.CreateKubernetesClient(cluster)
+	if err != nil {
+		return nil, fmt.Errorf("创建集群 %s 客户端失败: %v", cluster.ClusterName, err)
+	}
+
+	return &ResourceCollector{
+		kubeClient:    kubeClient,
+		metricsClient: metricsClient,
+	}, nil
+}
+
 // CollectSpecificClusterData 收集特定集群的数据 - 为Dashboard的集群筛选功能提供支持
 // 参数:
 //   - ctx: 上下文对象，用于控制请求生命周期和超时
@@ -808,6 +862,75 @@ func (mc *MultiClusterResourceCollector) GetResourceDistributionStats(ctx contex
 	return stats, nil
 }
 
+// GetNodeOvercommitStats 获取节点超售统计 - 计算各节点限制量和使用量相对可分配量的比例及超售风险
+// 参数:
+//   - ctx: 上下文对象，用于控制请求生命周期和超时
+//   - clusterID: 集群ID筛选条件，为nil时统计所有在线集群
+//
+// 返回:
+//   - *NodeOvercommitStats: 各集群节点的超售情况，按风险评分排序
+//   - error: 统计过程中的错误信息
+func (mc *MultiClusterResourceCollector) GetNodeOvercommitStats(ctx context.Context, clusterID *uint) (*NodeOvercommitStats, error) {
+	clusters, err := mc.clusterService.GetAllClusters()
+	if err != nil {
+		return nil, fmt.Errorf("获取集群列表失败: %v", err)
+	}
+
+	if clusterID != nil {
+		targetCluster, found := findClusterByID(clusters, *clusterID)
+		if !found {
+			return nil, fmt.Errorf("集群ID %d 不存在", *clusterID)
+		}
+		clusters = []models.ClusterConfig{targetCluster}
+	}
+
+	stats := &NodeOvercommitStats{
+		Clusters:    []ClusterOvercommitStats{},
+		GeneratedAt: time.Now(),
+	}
+
+	for _, cluster := range clusters {
+		if cluster.Status != "online" {
+			continue
+		}
+
+		singleCollector, err := mc.newClusterCollector(&cluster)
+		if err != nil {
+			logger.Error("%v，跳过超售统计", err)
+			continue
+		}
+
+		nodes, err := singleCollector.collectNodeCapacity(ctx, cluster.ClusterName)
+		if err != nil {
+			logger.Error("获取集群 %s 节点容量失败，跳过超售统计: %v", cluster.ClusterName, err)
+			continue
+		}
+
+		pods, err := singleCollector.collectAllPodsWithoutFiltering(ctx, cluster.ClusterName)
+		if err != nil {
+			logger.Error("收集集群 %s Pod数据失败，跳过超售统计: %v", cluster.ClusterName, err)
+			continue
+		}
+
+		clusterStats := ClusterOvercommitStats{
+			ClusterID:   cluster.ID,
+			ClusterName: cluster.ClusterName,
+			Nodes:       AnalyzeNodeOvercommit(nodes, pods),
+		}
+		clusterStats.NodesAnalyzed = len(clusterStats.Nodes)
+		for _, node := range clusterStats.Nodes {
+			if node.MemoryLimitRatio > 1 {
+				clusterStats.OvercommittedNodes++
+			}
+		}
+
+		stats.Clusters = append(stats.Clusters, clusterStats)
+		stats.ClustersAnalyzed++
+	}
+
+	return stats, nil
+}
+
 // ClusterResourceStats 单集群资源统计中间结构 - 用于聚合计算的临时数据结构
 type ClusterResourceStats struct {
 	CPUTotalRequest    int64 // CPU总请求量 (millicores)
@@ -0,0 +1,283 @@
+package collector
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"cluster-resource-insight/internal/config"
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/models"
+)
+
+// 节点超售风险等级
+const (
+	OvercommitRiskCritical = "critical"
+	OvercommitRiskHigh     = "high"
+	OvercommitRiskMedium   = "medium"
+	OvercommitRiskLow      = "low"
+)
+
+// overcommitSettings 节点超售风险分析参数
+type overcommitSettings struct {
+	alertEnabled        bool
+	alertScoreThreshold float64
+	topContributors     int
+}
+
+// OvercommitContributor 对节点超售贡献较大的Pod
+type OvercommitContributor struct {
+	Namespace     string `json:"namespace"`
+	PodName       string `json:"pod_name"`
+	QoSClass      string `json:"qos_class"`
+	MemoryRequest int64  `json:"memory_request"` // 内存请求量 (bytes)
+	MemoryLimit   int64  `json:"memory_limit"`   // 已设置的内存限制总量 (bytes)
+	MemoryUsage   int64  `json:"memory_usage"`   // 内存使用量 (bytes)，无真实metrics时为0
+	Unlimited     bool   `json:"unlimited"`      // 是否有容器未设置内存限制
+	Overcommit    int64  `json:"overcommit"`     // 可超出请求量的内存 (bytes)，未设置限制时按节点可分配内存计算
+}
+
+// NodeOvercommitInfo 节点超售情况
+type NodeOvercommitInfo struct {
+	NodeName            string                  `json:"node_name"`
+	AllocatableCPU      int64                   `json:"allocatable_cpu"`       // 可分配CPU (millicores)
+	AllocatableMemory   int64                   `json:"allocatable_memory"`    // 可分配内存 (bytes)
+	PodCount            int                     `json:"pod_count"`             // 节点Pod数量
+	CPURequest          int64                   `json:"cpu_request"`           // CPU请求总量
+	CPULimit            int64                   `json:"cpu_limit"`             // CPU限制总量（仅统计已设置的限制）
+	CPUUsage            int64                   `json:"cpu_usage"`             // CPU使用总量（仅统计真实metrics数据）
+	MemoryRequest       int64                   `json:"memory_request"`        // 内存请求总量
+	MemoryLimit         int64                   `json:"memory_limit"`          // 内存限制总量（仅统计已设置的限制）
+	MemoryUsage         int64                   `json:"memory_usage"`          // 内存使用总量（仅统计真实metrics数据）
+	CPULimitRatio       float64                 `json:"cpu_limit_ratio"`       // CPU限制总量/可分配CPU
+	CPUUsageRatio       float64                 `json:"cpu_usage_ratio"`       // CPU使用总量/可分配CPU
+	MemoryLimitRatio    float64                 `json:"memory_limit_ratio"`    // 内存限制总量/可分配内存
+	MemoryUsageRatio    float64                 `json:"memory_usage_ratio"`    // 内存使用总量/可分配内存
+	UnlimitedMemoryPods int                     `json:"unlimited_memory_pods"` // 有容器未设置内存限制的Pod数量
+	RiskScore           float64                 `json:"risk_score"`            // 超售风险评分 0-100
+	RiskLevel           string                  `json:"risk_level"`            // 风险等级：critical/high/medium/low
+	RiskFactors         []string                `json:"risk_factors"`          // 风险因素说明
+	TopContributors     []OvercommitContributor `json:"top_contributors"`      // 超售贡献最大的Pod
+}
+
+// ClusterOvercommitStats 集群节点超售统计
+type ClusterOvercommitStats struct {
+	ClusterID          uint                 `json:"cluster_id"`
+	ClusterName        string               `json:"cluster_name"`
+	NodesAnalyzed      int                  `json:"nodes_analyzed"`
+	OvercommittedNodes int                  `json:"overcommitted_nodes"` // 内存限制总量超过可分配内存的节点数
+	Nodes              []NodeOvercommitInfo `json:"nodes"`               // 按风险评分从高到低排序
+}
+
+// NodeOvercommitStats 节点超售统计结果
+type NodeOvercommitStats struct {
+	Clusters         []ClusterOvercommitStats `json:"clusters"`
+	ClustersAnalyzed int                      `json:"clusters_analyzed"`
+	GeneratedAt      time.Time                `json:"generated_at"`
+}
+
+// loadOvercommitSettings 读取节点超售风险配置，未配置项使用默认值
+func loadOvercommitSettings() overcommitSettings {
+	settings := overcommitSettings{
+		alertEnabled:        true,
+		alertScoreThreshold: 60,
+		topContributors:     10,
+	}
+
+	if overcommitConfig := config.GetOvercommitConfig(); overcommitConfig != nil {
+		settings.alertEnabled = overcommitConfig.AlertEnabled
+		if overcommitConfig.AlertScoreThreshold > 0 {
+			settings.alertScoreThreshold = overcommitConfig.AlertScoreThreshold
+		}
+		if overcommitConfig.TopContributors > 0 {
+			settings.topContributors = overcommitConfig.TopContributors
+		}
+	}
+
+	return settings
+}
+
+// AnalyzeNodeOvercommit 计算各节点的限制量、使用量与可分配量之比，并评估超售风险
+// 内存不可压缩，超售时会触发OOM，因此风险评分只基于内存：
+// 限制总量超出可分配内存的比例最多计40分，使用量占比从60%到95%线性计0-40分，
+// 未设置内存限制的Pod最多计20分（每个5分）
+func AnalyzeNodeOvercommit(nodes []NodeCapacityInfo, pods []PodResourceInfo) []NodeOvercommitInfo {
+	settings := loadOvercommitSettings()
+
+	podsByNode := make(map[string][]PodResourceInfo)
+	for _, pod := range pods {
+		if pod.NodeName != "" {
+			podsByNode[pod.NodeName] = append(podsByNode[pod.NodeName], pod)
+		}
+	}
+
+	result := make([]NodeOvercommitInfo, 0, len(nodes))
+	for _, node := range nodes {
+		info := NodeOvercommitInfo{
+			NodeName:          node.NodeName,
+			AllocatableCPU:    node.AllocatableCPU,
+			AllocatableMemory: node.AllocatableMemory,
+			RiskFactors:       []string{},
+		}
+
+		var contributors []OvercommitContributor
+		for _, pod := range podsByNode[node.NodeName] {
+			cpuRequest, memoryRequest := podContainerRequests(&pod)
+			limits := podContainerLimits(&pod)
+			var cpuUsage, memoryUsage int64
+			if pod.CPUMetricsAvailable {
+				cpuUsage = pod.CPUUsage
+			}
+			if pod.MemoryMetricsAvailable {
+				memoryUsage = pod.MemoryUsage
+			}
+
+			info.PodCount++
+			info.CPURequest += cpuRequest
+			info.CPULimit += limits.cpu
+			info.CPUUsage += cpuUsage
+			info.MemoryRequest += memoryRequest
+			info.MemoryLimit += limits.memory
+			info.MemoryUsage += memoryUsage
+
+			contributor := OvercommitContributor{
+				Namespace:     pod.Namespace,
+				PodName:       pod.PodName,
+				QoSClass:      pod.QoSClass,
+				MemoryRequest: memoryRequest,
+				MemoryLimit:   limits.memory,
+				MemoryUsage:   memoryUsage,
+			}
+			if limits.memoryUnlimited {
+				// 任一容器未设置内存限制时，该容器可用满节点内存
+				info.UnlimitedMemoryPods++
+				contributor.Unlimited = true
+				contributor.Overcommit = maxInt64(0, node.AllocatableMemory-memoryRequest)
+			} else {
+				contributor.Overcommit = maxInt64(0, limits.memory-memoryRequest)
+			}
+			if contributor.Overcommit > 0 {
+				contributors = append(contributors, contributor)
+			}
+		}
+
+		info.CPULimitRatio = ratio(info.CPULimit, node.AllocatableCPU)
+		info.CPUUsageRatio = ratio(info.CPUUsage, node.AllocatableCPU)
+		info.MemoryLimitRatio = ratio(info.MemoryLimit, node.AllocatableMemory)
+		info.MemoryUsageRatio = ratio(info.MemoryUsage, node.AllocatableMemory)
+
+		scoreNodeOvercommit(&info)
+
+		sort.SliceStable(contributors, func(i, j int) bool {
+			return contributors[i].Overcommit > contributors[j].Overcommit
+		})
+		if len(contributors) > settings.topContributors {
+			contributors = contributors[:settings.topContributors]
+		}
+		info.TopContributors = contributors
+		if info.TopContributors == nil {
+			info.TopContributors = []OvercommitContributor{}
+		}
+
+		result = append(result, info)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].RiskScore > result[j].RiskScore
+	})
+	return result
+}
+
+// containerLimits Pod清单中的真实限制量
+type containerLimits struct {
+	cpu             int64 // 已设置的CPU限制总量 (millicores)
+	memory          int64 // 已设置的内存限制总量 (bytes)
+	memoryUnlimited bool  // 是否有容器未设置内存限制
+}
+
+// podContainerLimits 汇总Pod各容器实际配置的限制量（不含默认填充值）
+func podContainerLimits(pod *PodResourceInfo) containerLimits {
+	if len(pod.Containers) == 0 {
+		return containerLimits{cpu: pod.CPULimit, memory: pod.MemoryLimit, memoryUnlimited: pod.MemoryLimit == 0}
+	}
+	var limits containerLimits
+	for _, container := range pod.Containers {
+		limits.cpu += container.CPULimit
+		limits.memory += container.MemoryLimit
+		if container.MemoryLimit == 0 {
+			limits.memoryUnlimited = true
+		}
+	}
+	return limits
+}
+
+// scoreNodeOvercommit 计算节点超售风险评分和风险因素
+func scoreNodeOvercommit(info *NodeOvercommitInfo) {
+	var score float64
+
+	if info.MemoryLimitRatio > 1 {
+		score += minFloat(40, (info.MemoryLimitRatio-1)*40)
+		info.RiskFactors = append(info.RiskFactors, fmt.Sprintf("内存限制总量为可分配内存的 %.0f%%", info.MemoryLimitRatio*100))
+	}
+	if info.MemoryUsageRatio > 0.6 {
+		score += minFloat(40, (info.MemoryUsageRatio-0.6)/0.35*40)
+		info.RiskFactors = append(info.RiskFactors, fmt.Sprintf("内存使用量达到可分配内存的 %.0f%%", info.MemoryUsageRatio*100))
+	}
+	if info.UnlimitedMemoryPods > 0 {
+		score += minFloat(20, float64(info.UnlimitedMemoryPods)*5)
+		info.RiskFactors = append(info.RiskFactors, fmt.Sprintf("%d 个Pod未设置内存限制", info.UnlimitedMemoryPods))
+	}
+	if info.CPULimitRatio > 1 {
+		info.RiskFactors = append(info.RiskFactors, fmt.Sprintf("CPU限制总量为可分配CPU的 %.0f%%（CPU可压缩，仅影响性能）", info.CPULimitRatio*100))
+	}
+
+	info.RiskScore = minFloat(100, score)
+	switch {
+	case info.RiskScore >= 80:
+		info.RiskLevel = OvercommitRiskCritical
+	case info.RiskScore >= 60:
+		info.RiskLevel = OvercommitRiskHigh
+	case info.RiskScore >= 30:
+		info.RiskLevel = OvercommitRiskMedium
+	default:
+		info.RiskLevel = OvercommitRiskLow
+	}
+}
+
+// ratio 计算数值与总量之比
+func ratio(value, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(value) / float64(total)
+}
+
+// checkOvercommitAlerts 对超售风险评分超过阈值的节点生成告警
+// 参数:
+//   - cluster: 集群配置
+//   - nodes: 集群节点容量信息
+//   - pods: 本次采集的全部Pod数据
+func (mc *MultiClusterResourceCollector) checkOvercommitAlerts(cluster models.ClusterConfig, nodes []NodeCapacityInfo, pods []PodResourceInfo) {
+	settings := loadOvercommitSettings()
+	if !settings.alertEnabled {
+		return
+	}
+
+	for _, node := range AnalyzeNodeOvercommit(nodes, pods) {
+		if node.RiskScore < settings.alertScoreThreshold {
+			break
+		}
+
+		level := "warning"
+		if node.RiskLevel == OvercommitRiskCritical {
+			level = "critical"
+		}
+		title := fmt.Sprintf("节点超售风险: %s", node.NodeName)
+		message := fmt.Sprintf("集群 %s 节点 %s 超售风险评分 %.0f（%s），内存限制总量/可分配=%.0f%%，内存使用量/可分配=%.0f%%",
+			cluster.ClusterName, node.NodeName, node.RiskScore, strings.Join(node.RiskFactors, "；"), node.MemoryLimitRatio*100, node.MemoryUsageRatio*100)
+		if err := mc.activityService.CreateAlert(cluster.ID, level, title, message, "active"); err != nil {
+			logger.Error("创建节点 %s 超售风险告警失败: %v", node.NodeName, err)
+		}
+	}
+}
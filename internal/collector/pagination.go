@@ -14,9 +14,13 @@ func NewPodSorter() *PodSorter {
 }
 
 // SortProblems 对问题Pod进行排序
-// 支持按CPU浪费、内存浪费、总浪费程度排序
+// 支持按问题严重程度得分、CPU浪费、内存浪费、总浪费程度排序
 func (sorter *PodSorter) SortProblems(problems []PodResourceInfo, sortBy string) {
 	switch sortBy {
+	case "score":
+		sort.SliceStable(problems, func(i, j int) bool {
+			return problems[i].Score > problems[j].Score
+		})
 	case "cpu_waste":
 		sort.Slice(problems, func(i, j int) bool {
 			cpuWasteI := sorter.calculateCPUWaste(problems[i])
@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"time"
-	
-	"cluster-resource-insight/pkg/utils"
+
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/models"
+	"cluster-resource-insight/internal/service"
 )
 
 // PodAnalysisHelper Pod分析辅助器 - 提供Pod详细分析和趋势数据的辅助方法
@@ -46,21 +48,26 @@ func (helper *PodAnalysisHelper) FindPodByIdentifier(ctx context.Context, cluste
 	return nil, fmt.Errorf("未找到Pod: %s/%s/%s", clusterName, namespace, podName)
 }
 
-// GetSimilarPodsInNamespace 获取同命名空间下的相似Pod
-func (helper *PodAnalysisHelper) GetSimilarPodsInNamespace(ctx context.Context, clusterName, namespace string) ([]PodResourceInfo, error) {
-	searchReq := PodSearchRequest{
-		Namespace: namespace,
-		Cluster:   clusterName,
-		Page:      1,
-		Size:      50, // 限制数量
+// GetSimilarPods 查找与目标Pod相似的Pod，并汇总运行相同镜像的容器使用量
+// 返回:
+//   - []SimilarPod: 相似Pod，按相似度从高到低排列
+//   - []ImagePeerSummary: 各容器镜像的对比结果
+//   - []PodResourceInfo: 目标Pod所在命名空间的全部Pod，用于计算命名空间平均值
+//   - error: 获取Pod数据失败时的错误信息
+func (helper *PodAnalysisHelper) GetSimilarPods(ctx context.Context, target *PodResourceInfo) ([]SimilarPod, []ImagePeerSummary, []PodResourceInfo, error) {
+	candidates, err := helper.collector.getComparisonPods(ctx)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
-	searchResult, err := helper.collector.SearchPods(ctx, searchReq)
-	if err != nil {
-		return nil, err
+	var namespacePods []PodResourceInfo
+	for _, pod := range candidates {
+		if pod.ClusterName == target.ClusterName && pod.Namespace == target.Namespace {
+			namespacePods = append(namespacePods, pod)
+		}
 	}
 
-	return searchResult.Pods, nil
+	return FindSimilarPods(*target, candidates), SummarizeImagePeers(*target, candidates), namespacePods, nil
 }
 
 // CalculateNamespaceAverage 计算命名空间平均资源使用率
@@ -467,6 +474,31 @@ func (helper *PodAnalysisHelper) GenerateEventMarkers(clusterName, namespace, po
 		Severity    string    `json:"severity"`
 	}
 
+	// 叠加该Pod所属工作负载的资源配置变更，同一次检测的多个容器合并为一个标记
+	if changes := helper.configChangesForPod(clusterName, namespace, podName, startTime, endTime); len(changes) > 0 {
+		index := make(map[time.Time]int)
+		for i := len(changes) - 1; i >= 0; i-- {
+			change := changes[i]
+			timestamp := change.DetectedAt
+			if position, ok := index[timestamp]; ok {
+				events[position].Description += "；" + service.DescribeConfigChange(change)
+				continue
+			}
+			index[timestamp] = len(events)
+			events = append(events, struct {
+				Timestamp   time.Time `json:"timestamp"`
+				EventType   string    `json:"event_type"`
+				Description string    `json:"description"`
+				Severity    string    `json:"severity"`
+			}{
+				Timestamp:   timestamp,
+				EventType:   "config_change",
+				Description: service.DescribeConfigChange(change),
+				Severity:    "info",
+			})
+		}
+	}
+
 	// 模拟一些事件标记
 	midTime := startTime.Add(endTime.Sub(startTime) / 2)
 
@@ -485,6 +517,42 @@ func (helper *PodAnalysisHelper) GenerateEventMarkers(clusterName, namespace, po
 	return events
 }
 
+// configChangesForPod 查询Pod所属工作负载在时间范围内的资源配置变更，查询失败时只记录日志
+func (helper *PodAnalysisHelper) configChangesForPod(clusterName, namespace, podName string, startTime, endTime time.Time) []models.ResourceConfigChange {
+	if helper.collector.configChangeService == nil || helper.collector.clusterService == nil {
+		return nil
+	}
+
+	clusters, err := helper.collector.clusterService.GetAllClusters()
+	if err != nil {
+		logger.Error("获取集群列表失败: %v", err)
+		return nil
+	}
+	var clusterID uint
+	for _, cluster := range clusters {
+		if cluster.ClusterName == clusterName {
+			clusterID = cluster.ID
+			break
+		}
+	}
+	if clusterID == 0 {
+		return nil
+	}
+
+	changes, err := helper.collector.configChangeService.ListChanges(service.ConfigChangeQuery{
+		ClusterID: clusterID,
+		Namespace: namespace,
+		PodName:   podName,
+		StartTime: startTime,
+		EndTime:   endTime,
+	})
+	if err != nil {
+		logger.Error("查询Pod %s/%s 资源配置变更失败: %v", namespace, podName, err)
+		return nil
+	}
+	return changes
+}
+
 // GetPodDetailAnalysis 获取Pod详细分析
 func (helper *PodAnalysisHelper) GetPodDetailAnalysis(ctx context.Context, clusterName, namespace, podName string) (*PodDetailAnalysis, error) {
 	// 查找目标Pod
@@ -493,14 +561,14 @@ func (helper *PodAnalysisHelper) GetPodDetailAnalysis(ctx context.Context, clust
 		return nil, fmt.Errorf("查找Pod失败: %w", err)
 	}
 
-	// 获取同命名空间的相似Pod
-	similarPods, err := helper.GetSimilarPodsInNamespace(ctx, clusterName, namespace)
+	// 获取相似Pod和相同镜像的对比数据
+	similarPods, imagePeers, namespacePods, err := helper.GetSimilarPods(ctx, targetPod)
 	if err != nil {
 		return nil, fmt.Errorf("获取相似Pod失败: %w", err)
 	}
 
 	// 计算命名空间平均值
-	namespaceAvg := helper.CalculateNamespaceAverage(similarPods)
+	namespaceAvg := helper.CalculateNamespaceAverage(namespacePods)
 
 	// 计算集群平均值 - 获取所有集群Pod进行计算
 	allPods, err := helper.GetAllClusterPods(ctx, clusterName)
@@ -532,7 +600,8 @@ func (helper *PodAnalysisHelper) GetPodDetailAnalysis(ctx context.Context, clust
 	analysis.ComparisonAnalysis.NamespaceAverage.CPUUsagePct = namespaceAvg.CPUUsagePct
 	analysis.ComparisonAnalysis.ClusterAverage.MemoryUsagePct = clusterAvg.MemoryUsagePct
 	analysis.ComparisonAnalysis.ClusterAverage.CPUUsagePct = clusterAvg.CPUUsagePct
-	analysis.ComparisonAnalysis.SimilarPods = similarPods[:utils.MinInt(5, len(similarPods))] // 最多返回5个相似Pod
+	analysis.ComparisonAnalysis.SimilarPods = similarPods
+	analysis.ComparisonAnalysis.ImagePeers = imagePeers
 
 	// 设置告警信息
 	analysis.AlertsInfo.ActiveAlerts = helper.GetActiveAlerts(clusterName, namespace, podName)
@@ -540,6 +609,14 @@ func (helper *PodAnalysisHelper) GetPodDetailAnalysis(ctx context.Context, clust
 	analysis.AlertsInfo.SeverityLevel = helper.CalculateSeverityLevel(targetPod)
 	analysis.AlertsInfo.AlertCount = len(helper.GetActiveAlerts(clusterName, namespace, podName))
 
+	// 设置QoS分析，节点内存压力未知时按中性系数评估
+	evictionRisk := EvaluateEvictionRisk(*targetPod, -1)
+	analysis.QoSAnalysis.QoSClass = targetPod.QoSClass
+	analysis.QoSAnalysis.EvictionRisk = evictionRisk.RiskLevel
+	analysis.QoSAnalysis.RiskScore = evictionRisk.RiskScore
+	analysis.QoSAnalysis.RiskReason = evictionRisk.Reason
+	analysis.QoSAnalysis.Guidance = QoSGuidance(targetPod)
+
 	analysis.GeneratedAt = time.Now()
 
 	return analysis, nil
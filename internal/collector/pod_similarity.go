@@ -0,0 +1,368 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"cluster-resource-insight/pkg/ranking"
+	"cluster-resource-insight/pkg/utils"
+)
+
+// 相似Pod所在范围
+const (
+	SimilarScopeSameWorkload   = "same_workload"   // 同一工作负载的其他副本
+	SimilarScopeSameNamespace  = "same_namespace"  // 同集群同命名空间
+	SimilarScopeCrossNamespace = "cross_namespace" // 同集群其他命名空间
+	SimilarScopeCrossCluster   = "cross_cluster"   // 其他集群
+)
+
+// 相似度各维度权重，合计为1
+const (
+	similarityWeightWorkload = 0.35
+	similarityWeightImage    = 0.30
+	similarityWeightLabels   = 0.15
+	similarityWeightShape    = 0.20
+
+	// minSimilarity 低于该相似度的Pod不作为对比对象
+	minSimilarity = 0.3
+	// maxSimilarPods 返回的相似Pod数量上限
+	maxSimilarPods = 10
+	// usageRatioNotable 使用量相差超过该倍数时在镜像对比中给出提示
+	usageRatioNotable = 1.5
+)
+
+// volatileLabelKeys 由控制器自动生成、每个副本或每个版本不同的标签，不参与标签相似度计算
+var volatileLabelKeys = map[string]bool{
+	"pod-template-hash":                        true,
+	"controller-revision-hash":                 true,
+	"pod-template-generation":                  true,
+	"statefulset.kubernetes.io/pod-name":       true,
+	"apps.kubernetes.io/pod-index":             true,
+	"controller-uid":                           true,
+	"batch.kubernetes.io/controller-uid":       true,
+	"batch.kubernetes.io/job-completion-index": true,
+}
+
+// SimilarPod 相似Pod及与目标Pod的对比
+type SimilarPod struct {
+	PodResourceInfo
+	Similarity       float64  `json:"similarity"`         // 相似度（0-1）
+	Scope            string   `json:"scope"`              // 所在范围：same_workload/same_namespace/cross_namespace/cross_cluster
+	MatchReasons     []string `json:"match_reasons"`      // 相似的原因
+	CPUUsageRatio    float64  `json:"cpu_usage_ratio"`    // 目标Pod CPU使用量 / 该Pod CPU使用量，任一方没有真实数据时为0
+	MemoryUsageRatio float64  `json:"memory_usage_ratio"` // 目标Pod内存使用量 / 该Pod内存使用量，任一方没有真实数据时为0
+}
+
+// ImagePeerSummary 运行相同镜像的其他容器与目标容器的使用量对比
+type ImagePeerSummary struct {
+	Container           string  `json:"container"`             // 目标容器名称
+	Image               string  `json:"image"`                 // 镜像（不含标签和摘要）
+	PeerCount           int     `json:"peer_count"`            // 运行该镜像的其他容器数量
+	PeerClusters        int     `json:"peer_clusters"`         // 对比容器分布的集群数量
+	MedianCPUUsage      int64   `json:"median_cpu_usage"`      // 有真实数据的其他容器CPU使用量中位数 (millicores)
+	MedianMemoryUsage   int64   `json:"median_memory_usage"`   // 有真实数据的其他容器内存使用量中位数 (bytes)
+	MedianCPURequest    int64   `json:"median_cpu_request"`    // 其他容器CPU请求量中位数 (millicores)
+	MedianMemoryRequest int64   `json:"median_memory_request"` // 其他容器内存请求量中位数 (bytes)
+	CPUUsageRatio       float64 `json:"cpu_usage_ratio"`       // 目标容器CPU使用量 / 中位数，无法比较时为0
+	MemoryUsageRatio    float64 `json:"memory_usage_ratio"`    // 目标容器内存使用量 / 中位数，无法比较时为0
+	Summary             string  `json:"summary"`               // 对比结论
+}
+
+// FindSimilarPods 在候选Pod中查找与目标Pod相似的Pod，按相似度从高到低返回
+// 同命名空间的Pod都参与比较；其他命名空间和集群的Pod只有运行相同镜像时才参与比较
+func FindSimilarPods(target PodResourceInfo, candidates []PodResourceInfo) []SimilarPod {
+	targetRepos := imageRepositories(target)
+	targetLabels := stableLabels(target)
+
+	var similar []SimilarPod
+	for _, candidate := range candidates {
+		if candidate.ClusterName == target.ClusterName && candidate.Namespace == target.Namespace && candidate.PodName == target.PodName {
+			continue
+		}
+
+		scope := similarScope(target, candidate)
+		candidateRepos := imageRepositories(candidate)
+		if scope == SimilarScopeCrossNamespace || scope == SimilarScopeCrossCluster {
+			if !sharesAny(targetRepos, candidateRepos) {
+				continue
+			}
+		}
+
+		var reasons []string
+		score := 0.0
+		if scope == SimilarScopeSameWorkload {
+			score += similarityWeightWorkload
+			reasons = append(reasons, fmt.Sprintf("同属 %s/%s", target.WorkloadKind, target.WorkloadName))
+		}
+
+		if imageScore, shared := imageSimilarity(target, candidate); imageScore > 0 {
+			score += similarityWeightImage * imageScore
+			reasons = append(reasons, "相同镜像 "+strings.Join(shared, ", "))
+		}
+
+		if labelScore := jaccard(targetLabels, stableLabels(candidate)); labelScore > 0 {
+			score += similarityWeightLabels * labelScore
+			if labelScore >= 0.5 {
+				reasons = append(reasons, fmt.Sprintf("标签相似度 %.0f%%", labelScore*100))
+			}
+		}
+
+		shapeScore := resourceShapeSimilarity(target, candidate)
+		score += similarityWeightShape * shapeScore
+		if shapeScore >= 0.8 {
+			reasons = append(reasons, "资源规格相近")
+		}
+
+		if score < minSimilarity {
+			continue
+		}
+
+		similar = append(similar, SimilarPod{
+			PodResourceInfo:  candidate,
+			Similarity:       roundTo(score, 4),
+			Scope:            scope,
+			MatchReasons:     reasons,
+			CPUUsageRatio:    measuredUsageRatio(target.CPUUsage, candidate.CPUUsage, target.CPUMetricsAvailable && candidate.CPUMetricsAvailable),
+			MemoryUsageRatio: measuredUsageRatio(target.MemoryUsage, candidate.MemoryUsage, target.MemoryMetricsAvailable && candidate.MemoryMetricsAvailable),
+		})
+	}
+
+	return ranking.TopN(similar, maxSimilarPods, func(a, b SimilarPod) bool {
+		if a.Similarity != b.Similarity {
+			return a.Similarity > b.Similarity
+		}
+		return a.ClusterName+"/"+a.Namespace+"/"+a.PodName < b.ClusterName+"/"+b.Namespace+"/"+b.PodName
+	})
+}
+
+// SummarizeImagePeers 按目标Pod的每个容器镜像，汇总其他Pod中运行相同镜像的容器使用量
+// 使用量中位数只统计有真实监控数据的容器，目标容器没有真实数据时不计算使用量比值
+func SummarizeImagePeers(target PodResourceInfo, candidates []PodResourceInfo) []ImagePeerSummary {
+	summaries := []ImagePeerSummary{}
+	for _, container := range target.Containers {
+		repo := imageRepository(container.Image)
+		if repo == "" {
+			continue
+		}
+
+		var cpuUsages, memoryUsages, cpuRequests, memoryRequests []float64
+		clusters := make(map[string]bool)
+		for _, candidate := range candidates {
+			if candidate.ClusterName == target.ClusterName && candidate.Namespace == target.Namespace && candidate.PodName == target.PodName {
+				continue
+			}
+			for _, peer := range candidate.Containers {
+				if imageRepository(peer.Image) != repo {
+					continue
+				}
+				if candidate.CPUMetricsAvailable {
+					cpuUsages = append(cpuUsages, float64(peer.CPUUsage))
+				}
+				if candidate.MemoryMetricsAvailable {
+					memoryUsages = append(memoryUsages, float64(peer.MemoryUsage))
+				}
+				cpuRequests = append(cpuRequests, float64(peer.CPURequest))
+				memoryRequests = append(memoryRequests, float64(peer.MemoryRequest))
+				clusters[candidate.ClusterName] = true
+			}
+		}
+		if len(cpuRequests) == 0 {
+			continue
+		}
+
+		summary := ImagePeerSummary{
+			Container:           container.Name,
+			Image:               repo,
+			PeerCount:           len(cpuRequests),
+			PeerClusters:        len(clusters),
+			MedianCPURequest:    int64(medianOf(cpuRequests)),
+			MedianMemoryRequest: int64(medianOf(memoryRequests)),
+		}
+		if len(cpuUsages) > 0 {
+			summary.MedianCPUUsage = int64(medianOf(cpuUsages))
+			summary.CPUUsageRatio = measuredUsageRatio(container.CPUUsage, summary.MedianCPUUsage, target.CPUMetricsAvailable)
+		}
+		if len(memoryUsages) > 0 {
+			summary.MedianMemoryUsage = int64(medianOf(memoryUsages))
+			summary.MemoryUsageRatio = measuredUsageRatio(container.MemoryUsage, summary.MedianMemoryUsage, target.MemoryMetricsAvailable)
+		}
+		summary.Summary = imagePeerConclusion(summary)
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// imagePeerConclusion 生成镜像对比结论，使用量相差不明显时说明与其他副本一致
+func imagePeerConclusion(summary ImagePeerSummary) string {
+	var parts []string
+	if ratio := summary.MemoryUsageRatio; ratio >= usageRatioNotable {
+		parts = append(parts, fmt.Sprintf("内存使用量少 %.1f 倍（中位数 %s）", ratio, utils.FormatBytes(summary.MedianMemoryUsage)))
+	} else if ratio > 0 && ratio <= 1/usageRatioNotable {
+		parts = append(parts, fmt.Sprintf("内存使用量多 %.1f 倍（中位数 %s）", 1/ratio, utils.FormatBytes(summary.MedianMemoryUsage)))
+	}
+	if ratio := summary.CPUUsageRatio; ratio >= usageRatioNotable {
+		parts = append(parts, fmt.Sprintf("CPU使用量少 %.1f 倍（中位数 %s）", ratio, utils.FormatMillicores(summary.MedianCPUUsage)))
+	} else if ratio > 0 && ratio <= 1/usageRatioNotable {
+		parts = append(parts, fmt.Sprintf("CPU使用量多 %.1f 倍（中位数 %s）", 1/ratio, utils.FormatMillicores(summary.MedianCPUUsage)))
+	}
+
+	if len(parts) == 0 {
+		return fmt.Sprintf("运行镜像 %s 的其他 %d 个容器使用量与本容器相近", summary.Image, summary.PeerCount)
+	}
+	return fmt.Sprintf("运行镜像 %s 的其他 %d 个容器%s", summary.Image, summary.PeerCount, strings.Join(parts, "，"))
+}
+
+// similarScope 判断候选Pod相对目标Pod所在的范围
+func similarScope(target, candidate PodResourceInfo) string {
+	switch {
+	case candidate.ClusterName != target.ClusterName:
+		return SimilarScopeCrossCluster
+	case candidate.Namespace != target.Namespace:
+		return SimilarScopeCrossNamespace
+	case target.WorkloadKind != "Pod" && candidate.WorkloadKind == target.WorkloadKind && candidate.WorkloadName == target.WorkloadName:
+		return SimilarScopeSameWorkload
+	default:
+		return SimilarScopeSameNamespace
+	}
+}
+
+// imageSimilarity 镜像相似度：完全相同的镜像计1，仅仓库相同（标签不同）计0.5，按容器取Jaccard
+func imageSimilarity(target, candidate PodResourceInfo) (float64, []string) {
+	targetRepos := imageRepositories(target)
+	candidateRepos := imageRepositories(candidate)
+	if len(targetRepos) == 0 || len(candidateRepos) == 0 {
+		return 0, nil
+	}
+
+	candidateImages := make(map[string]bool, len(candidate.Containers))
+	for _, container := range candidate.Containers {
+		candidateImages[container.Image] = true
+	}
+
+	var matched float64
+	var shared []string
+	for repo := range targetRepos {
+		if !candidateRepos[repo] {
+			continue
+		}
+		shared = append(shared, repo)
+		matched += 0.5
+		for _, container := range target.Containers {
+			if imageRepository(container.Image) == repo && candidateImages[container.Image] {
+				matched += 0.5
+				break
+			}
+		}
+	}
+
+	union := len(targetRepos) + len(candidateRepos) - len(shared)
+	return matched / float64(union), shared
+}
+
+// imageRepositories 返回Pod中各容器镜像的仓库集合
+func imageRepositories(pod PodResourceInfo) map[string]bool {
+	repos := make(map[string]bool, len(pod.Containers))
+	for _, container := range pod.Containers {
+		if repo := imageRepository(container.Image); repo != "" {
+			repos[repo] = true
+		}
+	}
+	return repos
+}
+
+// imageRepository 去除镜像的摘要和标签，registry端口中的冒号不受影响
+func imageRepository(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// stableLabels 返回Pod的标签（key=value），排除控制器自动生成的标签
+func stableLabels(pod PodResourceInfo) map[string]bool {
+	labels := make(map[string]bool)
+	if pod.Scheduling == nil {
+		return labels
+	}
+	for key, value := range pod.Scheduling.Labels {
+		if !volatileLabelKeys[key] {
+			labels[key+"="+value] = true
+		}
+	}
+	return labels
+}
+
+// resourceShapeSimilarity 资源规格相似度：各容器实际配置的CPU和内存请求量之和，较小值/较大值取平均
+func resourceShapeSimilarity(target, candidate PodResourceInfo) float64 {
+	targetCPU, targetMemory := podContainerRequests(&target)
+	candidateCPU, candidateMemory := podContainerRequests(&candidate)
+	return (quantitySimilarity(targetCPU, candidateCPU) + quantitySimilarity(targetMemory, candidateMemory)) / 2
+}
+
+func quantitySimilarity(a, b int64) float64 {
+	if a == 0 && b == 0 {
+		return 1
+	}
+	if a <= 0 || b <= 0 {
+		return 0
+	}
+	if a > b {
+		a, b = b, a
+	}
+	return float64(a) / float64(b)
+}
+
+// jaccard 计算两个集合的Jaccard相似度
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	intersection := 0
+	for key := range a {
+		if b[key] {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(a)+len(b)-intersection)
+}
+
+func sharesAny(a, b map[string]bool) bool {
+	for key := range a {
+		if b[key] {
+			return true
+		}
+	}
+	return false
+}
+
+// measuredUsageRatio 计算 目标使用量/对比使用量，measured 为false（任一方没有真实监控数据）或任一方为0时返回0
+func measuredUsageRatio(target, peer int64, measured bool) float64 {
+	if !measured || target <= 0 || peer <= 0 {
+		return 0
+	}
+	return roundTo(float64(target)/float64(peer), 2)
+}
+
+func roundTo(value float64, digits int) float64 {
+	scale := math.Pow(10, float64(digits))
+	return math.Round(value*scale) / scale
+}
+
+// getComparisonPods 获取用于相似度比较的全部Pod，优先使用Pod缓存
+func (mc *MultiClusterResourceCollector) getComparisonPods(ctx context.Context) ([]PodResourceInfo, error) {
+	if pods, cached := mc.getCachedPods(); cached {
+		return pods, nil
+	}
+
+	pods, err := mc.collectOnlineClusterPods(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	mc.setCachedPods(pods)
+	return pods, nil
+}
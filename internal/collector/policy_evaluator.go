@@ -0,0 +1,305 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"cluster-resource-insight/internal/config"
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/models"
+	"cluster-resource-insight/internal/service"
+	"cluster-resource-insight/pkg/policy"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// policySettings 策略检查参数
+type policySettings struct {
+	enabled   bool
+	rulesFile string
+}
+
+// loadPolicySettings 读取策略检查配置，未配置时使用默认值
+func loadPolicySettings() policySettings {
+	settings := policySettings{
+		enabled:   true,
+		rulesFile: "policies.yaml",
+	}
+
+	if policyConfig := config.GetPolicyConfig(); policyConfig != nil {
+		settings.enabled = policyConfig.Enabled
+		if policyConfig.RulesFile != "" {
+			settings.rulesFile = policyConfig.RulesFile
+		}
+	}
+
+	return settings
+}
+
+// LoadPolicies 加载当前生效的策略定义，每次调用都重新读取文件，修改策略无需重启服务
+func LoadPolicies() (*policy.Set, error) {
+	return policy.LoadFile(loadPolicySettings().rulesFile)
+}
+
+// policyTally 单个命名空间或集群的检查计数
+type policyTally struct {
+	checks        int
+	passed        int
+	violations    int
+	errors        int
+	checkedWeight float64
+	failedWeight  float64
+}
+
+func (t *policyTally) add(weight float64, passed bool) {
+	t.checks++
+	t.checkedWeight += weight
+	if passed {
+		t.passed++
+	} else {
+		t.violations++
+		t.failedWeight += weight
+	}
+}
+
+// score 按严重程度加权的合规评分，没有可检查对象时为100
+func (t *policyTally) score() float64 {
+	if t.checkedWeight == 0 {
+		return 100
+	}
+	return 100 * (1 - t.failedWeight/t.checkedWeight)
+}
+
+func (t *policyTally) toInput(namespace string) service.NamespaceComplianceInput {
+	return service.NamespaceComplianceInput{
+		Namespace:  namespace,
+		Checks:     t.checks,
+		Passed:     t.passed,
+		Violations: t.violations,
+		Errors:     t.errors,
+		Score:      t.score(),
+	}
+}
+
+// evaluateClusterPolicies 在采集完成后对集群执行策略检查并保存合规评分和违规记录
+func (mc *MultiClusterResourceCollector) evaluateClusterPolicies(ctx context.Context, singleCollector *ResourceCollector, cluster *models.ClusterConfig, pods []PodResourceInfo) {
+	set, err := LoadPolicies()
+	if err != nil {
+		logger.Error("加载资源策略失败: %v", err)
+		return
+	}
+	for _, msg := range set.Errors {
+		logger.Warn("忽略无效的资源策略: %s", msg)
+	}
+	if len(set.Policies) == 0 {
+		return
+	}
+
+	namespaceLabels, err := singleCollector.collectNamespaceLabels(ctx)
+	if err != nil {
+		// 命名空间标签缺失时依赖标签的策略不会适用，其余策略照常检查
+		logger.Warn("获取集群 %s 命名空间标签失败: %v", cluster.ClusterName, err)
+	}
+
+	result := EvaluatePolicies(set, cluster, namespaceLabels, pods)
+	if err := mc.policyService.SaveEvaluation(cluster.ID, result); err != nil {
+		logger.Error("保存集群 %s 策略检查结果失败: %v", cluster.ClusterName, err)
+		return
+	}
+
+	logger.Info("集群 %s 策略检查完成，合规评分 %.1f，违规 %d 项", cluster.ClusterName, result.Cluster.Score, len(result.Violations))
+}
+
+// EvaluatePolicies 对集群中的命名空间、Pod和容器逐一执行策略检查
+// 参数:
+//   - set: 已编译的策略集合
+//   - cluster: 集群配置
+//   - namespaceLabels: 各命名空间的标签，也决定需要检查的命名空间范围
+//   - pods: 集群中全部Pod
+//
+// 返回:
+//   - *service.PolicyEvaluationResult: 集群和各命名空间的合规评分及违规列表
+func EvaluatePolicies(set *policy.Set, cluster *models.ClusterConfig, namespaceLabels map[string]map[string]string, pods []PodResourceInfo) *service.PolicyEvaluationResult {
+	podsByNamespace := make(map[string][]PodResourceInfo)
+	for _, pod := range pods {
+		podsByNamespace[pod.Namespace] = append(podsByNamespace[pod.Namespace], pod)
+	}
+
+	namespaces := make([]string, 0, len(namespaceLabels))
+	for namespace := range namespaceLabels {
+		namespaces = append(namespaces, namespace)
+	}
+	for namespace := range podsByNamespace {
+		if _, ok := namespaceLabels[namespace]; !ok {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	sort.Strings(namespaces)
+
+	clusterEnv := map[string]interface{}{
+		"name": cluster.ClusterName,
+		"id":   int(cluster.ID),
+	}
+
+	result := &service.PolicyEvaluationResult{
+		PolicyCount: len(set.Policies),
+		EvaluatedAt: time.Now(),
+	}
+	var clusterTally policyTally
+	// 同一策略求值出错时只记录一次日志，避免刷屏
+	reportedErrors := make(map[string]bool)
+
+	check := func(tally *policyTally, p *policy.Policy, env map[string]interface{}, namespace, targetName string) {
+		applies, passed, err := p.Evaluate(env)
+		if err != nil {
+			if !reportedErrors[p.Name] {
+				reportedErrors[p.Name] = true
+				logger.Warn("集群 %s 策略 %s 对 %s 求值失败: %v", cluster.ClusterName, p.Name, targetName, err)
+			}
+			// 记为出错而不是跳过，保存时该对象已有的违规记录不会被误判为已解决
+			tally.errors++
+			clusterTally.errors++
+			result.Errors = append(result.Errors, service.PolicyEvaluationError{
+				Namespace:  namespace,
+				PolicyName: p.Name,
+				TargetKind: p.Target,
+				TargetName: targetName,
+			})
+			return
+		}
+		if !applies {
+			return
+		}
+
+		tally.add(p.Weight(), passed)
+		clusterTally.add(p.Weight(), passed)
+		if passed {
+			return
+		}
+
+		message := p.Description
+		if message == "" {
+			message = fmt.Sprintf("不满足策略条件: %s", p.Rule.Rule)
+		}
+		result.Violations = append(result.Violations, service.PolicyViolationInput{
+			Namespace:  namespace,
+			PolicyName: p.Name,
+			Severity:   p.Severity,
+			TargetKind: p.Target,
+			TargetName: targetName,
+			Message:    message,
+		})
+	}
+
+	for _, namespace := range namespaces {
+		var tally policyTally
+		namespacePods := podsByNamespace[namespace]
+		namespaceEnv := map[string]interface{}{
+			"name":      namespace,
+			"labels":    namespaceLabels[namespace],
+			"pod_count": len(namespacePods),
+		}
+
+		for _, p := range set.Policies {
+			if p.Target != policy.TargetNamespace {
+				continue
+			}
+			check(&tally, p, map[string]interface{}{
+				"cluster":   clusterEnv,
+				"namespace": namespaceEnv,
+			}, namespace, namespace)
+		}
+
+		for _, pod := range namespacePods {
+			podEnv := buildPodPolicyEnv(pod)
+			for _, p := range set.Policies {
+				switch p.Target {
+				case policy.TargetPod:
+					check(&tally, p, map[string]interface{}{
+						"cluster":   clusterEnv,
+						"namespace": namespaceEnv,
+						"pod":       podEnv,
+					}, namespace, pod.PodName)
+				case policy.TargetContainer:
+					for _, container := range pod.Containers {
+						check(&tally, p, map[string]interface{}{
+							"cluster":   clusterEnv,
+							"namespace": namespaceEnv,
+							"pod":       podEnv,
+							"container": buildContainerPolicyEnv(container),
+						}, namespace, pod.PodName+"/"+container.Name)
+					}
+				}
+			}
+		}
+
+		if tally.checks > 0 || tally.errors > 0 {
+			result.Namespaces = append(result.Namespaces, tally.toInput(namespace))
+		}
+	}
+
+	result.Cluster = clusterTally.toInput("")
+	return result
+}
+
+// buildPodPolicyEnv 构造Pod级策略变量，请求和限制取各容器原始配置之和
+func buildPodPolicyEnv(pod PodResourceInfo) map[string]interface{} {
+	var cpuRequest, cpuLimit, memoryRequest, memoryLimit int64
+	for _, container := range pod.Containers {
+		cpuRequest += container.CPURequest
+		cpuLimit += container.CPULimit
+		memoryRequest += container.MemoryRequest
+		memoryLimit += container.MemoryLimit
+	}
+
+	var labels map[string]string
+	if pod.Scheduling != nil {
+		labels = pod.Scheduling.Labels
+	}
+
+	return map[string]interface{}{
+		"name":           pod.PodName,
+		"namespace":      pod.Namespace,
+		"node_name":      pod.NodeName,
+		"workload_kind":  pod.WorkloadKind,
+		"workload_name":  pod.WorkloadName,
+		"qos_class":      pod.QoSClass,
+		"labels":         labels,
+		"restart_count":  pod.RestartCount,
+		"cpu_request":    cpuRequest,
+		"cpu_limit":      cpuLimit,
+		"memory_request": memoryRequest,
+		"memory_limit":   memoryLimit,
+		"cpu_usage":      pod.CPUUsage,
+		"memory_usage":   pod.MemoryUsage,
+	}
+}
+
+// buildContainerPolicyEnv 构造容器级策略变量
+func buildContainerPolicyEnv(container ContainerResourceInfo) map[string]interface{} {
+	return map[string]interface{}{
+		"name":           container.Name,
+		"cpu_request":    container.CPURequest,
+		"cpu_limit":      container.CPULimit,
+		"memory_request": container.MemoryRequest,
+		"memory_limit":   container.MemoryLimit,
+		"cpu_usage":      container.CPUUsage,
+		"memory_usage":   container.MemoryUsage,
+	}
+}
+
+// collectNamespaceLabels 读取集群中全部命名空间的标签
+func (rc *ResourceCollector) collectNamespaceLabels(ctx context.Context) (map[string]map[string]string, error) {
+	namespaces, err := rc.kubeClient.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取命名空间列表失败: %v", err)
+	}
+
+	labels := make(map[string]map[string]string, len(namespaces.Items))
+	for _, namespace := range namespaces.Items {
+		labels[namespace.Name] = namespace.Labels
+	}
+	return labels, nil
+}
@@ -0,0 +1,102 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+
+	"cluster-resource-insight/internal/models"
+	"cluster-resource-insight/internal/service"
+	"cluster-resource-insight/pkg/policy"
+)
+
+// policyPod 构造策略检查测试Pod，容器均设置内存限制
+func policyPod(namespace, name string, cpuRequest, cpuLimit int64) PodResourceInfo {
+	pod := testPod(name, "node-a", cpuRequest, 1)
+	pod.Namespace = namespace
+	pod.Containers[0].CPULimit = cpuLimit
+	pod.Containers[0].MemoryLimit = gib
+	return pod
+}
+
+func TestEvaluatePolicies(t *testing.T) {
+	set, err := policy.Parse([]byte(`
+policies:
+  - name: limit-ratio
+    severity: error
+    rule: pod.cpu_limit / pod.cpu_request <= 2
+  - name: memory-limit
+    target: container
+    rule: container.memory_limit > 0
+`))
+	if err != nil || len(set.Policies) != 2 {
+		t.Fatalf("解析策略失败: %v %v", err, set)
+	}
+
+	tests := []struct {
+		name           string
+		pods           []PodResourceInfo
+		wantCluster    service.NamespaceComplianceInput
+		wantNamespaces []string
+		wantViolations []string // 策略/对象
+		wantErrors     []string // 策略/对象
+	}{
+		{
+			name:        "空输入",
+			wantCluster: service.NamespaceComplianceInput{Score: 100},
+		},
+		{
+			name:           "合规与违规",
+			pods:           []PodResourceInfo{policyPod("default", "web-1", 500, 500), policyPod("default", "web-2", 500, 2000)},
+			wantCluster:    service.NamespaceComplianceInput{Checks: 4, Passed: 3, Violations: 1, Score: 70},
+			wantNamespaces: []string{"default"},
+			wantViolations: []string{"limit-ratio/web-2"},
+		},
+		{
+			name:           "求值出错记为出错，不计入检查次数",
+			pods:           []PodResourceInfo{policyPod("default", "web-1", 500, 500), policyPod("batch", "job-1", 0, 1000)},
+			wantCluster:    service.NamespaceComplianceInput{Checks: 3, Passed: 3, Errors: 1, Score: 100},
+			wantNamespaces: []string{"batch", "default"},
+			wantErrors:     []string{"limit-ratio/job-1"},
+		},
+		{
+			name:           "同一策略多次出错逐个记录",
+			pods:           []PodResourceInfo{policyPod("batch", "job-1", 0, 1000), policyPod("batch", "job-2", 0, 1000)},
+			wantCluster:    service.NamespaceComplianceInput{Checks: 2, Passed: 2, Errors: 2, Score: 100},
+			wantNamespaces: []string{"batch"},
+			wantErrors:     []string{"limit-ratio/job-1", "limit-ratio/job-2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := EvaluatePolicies(set, &models.ClusterConfig{ID: 1, ClusterName: "test"}, nil, tt.pods)
+
+			if got := result.Cluster; got.Checks != tt.wantCluster.Checks || got.Passed != tt.wantCluster.Passed ||
+				got.Violations != tt.wantCluster.Violations || got.Errors != tt.wantCluster.Errors || !nearlyEqual(got.Score, tt.wantCluster.Score) {
+				t.Errorf("集群结果 %+v，期望 %+v", got, tt.wantCluster)
+			}
+
+			var namespaces, violations, errors []string
+			for _, ns := range result.Namespaces {
+				namespaces = append(namespaces, ns.Namespace)
+			}
+			for _, v := range result.Violations {
+				violations = append(violations, v.PolicyName+"/"+v.TargetName)
+			}
+			for _, e := range result.Errors {
+				errors = append(errors, e.PolicyName+"/"+e.TargetName)
+				if e.TargetKind != policy.TargetPod {
+					t.Errorf("出错对象类型 %s，期望 %s", e.TargetKind, policy.TargetPod)
+				}
+			}
+			if !reflect.DeepEqual(namespaces, tt.wantNamespaces) {
+				t.Errorf("命名空间 %v，期望 %v", namespaces, tt.wantNamespaces)
+			}
+			if !reflect.DeepEqual(violations, tt.wantViolations) {
+				t.Errorf("违规 %v，期望 %v", violations, tt.wantViolations)
+			}
+			if !reflect.DeepEqual(errors, tt.wantErrors) {
+				t.Errorf("求值出错 %v，期望 %v", errors, tt.wantErrors)
+			}
+		})
+	}
+}
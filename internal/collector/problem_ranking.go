@@ -0,0 +1,270 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/pkg/ranking"
+)
+
+// 排名粒度
+const (
+	RankingLevelPod      = "pod"
+	RankingLevelWorkload = "workload"
+)
+
+// RankingRequest 问题严重程度排名请求
+type RankingRequest struct {
+	ClusterID *uint  // 集群ID，为nil时包含全部在线集群
+	Namespace string // 命名空间，为空时包含全部命名空间
+	Level     string // 排名粒度：pod/workload，默认pod
+	Limit     int    // 返回数量
+}
+
+// RankingFactorInfo 参与排名的因子及权重
+type RankingFactorInfo struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Weight      float64 `json:"weight"`
+}
+
+// RankedWorkload 按工作负载汇总后的排名结果
+type RankedWorkload struct {
+	ClusterName    string                `json:"cluster_name"`
+	Namespace      string                `json:"namespace"`
+	WorkloadKind   string                `json:"workload_kind"`
+	WorkloadName   string                `json:"workload_name"`
+	PodCount       int                   `json:"pod_count"`
+	CPURequest     int64                 `json:"cpu_request"`    // 各Pod实际配置的CPU请求量之和 (millicores)
+	CPUUsage       int64                 `json:"cpu_usage"`      // 各Pod真实CPU使用量之和 (millicores)
+	MemoryRequest  int64                 `json:"memory_request"` // 各Pod实际配置的内存请求量之和 (bytes)
+	MemoryUsage    int64                 `json:"memory_usage"`   // 各Pod真实内存使用量之和 (bytes)
+	RestartCount   int32                 `json:"restart_count"`
+	OOMKilled      bool                  `json:"oom_killed"`
+	Score          float64               `json:"score"`
+	ScoreBreakdown []ranking.FactorScore `json:"score_breakdown"`
+
+	subject ranking.Subject
+}
+
+// RankingResult 问题严重程度排名结果
+type RankingResult struct {
+	Level       string              `json:"level"`
+	Factors     []RankingFactorInfo `json:"factors"`    // 参与排名的因子及权重
+	Candidates  int                 `json:"candidates"` // 参与排名的对象数量
+	Pods        []PodResourceInfo   `json:"pods,omitempty"`
+	Workloads   []RankedWorkload    `json:"workloads,omitempty"`
+	GeneratedAt time.Time           `json:"generated_at"`
+}
+
+// podRankingSubject 将Pod资源数据转换为排名对象
+// 请求和限制使用各容器实际配置之和（不含默认填充值），使用量只取真实监控数据
+func podRankingSubject(pod PodResourceInfo) ranking.Subject {
+	cpuRequest, memoryRequest := podContainerRequests(&pod)
+	limits := podContainerLimits(&pod)
+	subject := ranking.Subject{
+		CPURequest:     cpuRequest,
+		CPULimit:       limits.cpu,
+		MemoryRequest:  memoryRequest,
+		MemoryLimit:    limits.memory,
+		CPUMeasured:    pod.CPUMetricsAvailable,
+		MemoryMeasured: pod.MemoryMetricsAvailable,
+		RestartCount:   pod.RestartCount,
+		OOMKilled:      pod.OOMKilled,
+		PodCount:       1,
+	}
+	if pod.CPUMetricsAvailable {
+		subject.CPUUsage = pod.CPUUsage
+	}
+	if pod.MemoryMetricsAvailable {
+		subject.MemoryUsage = pod.MemoryUsage
+	}
+	return subject
+}
+
+// rankPods 为Pod打分并按得分从高到低返回前limit个，limit<=0时返回全部
+func rankPods(ranker *ranking.Ranker, pods []PodResourceInfo, limit int) []PodResourceInfo {
+	subjects := make([]ranking.Subject, len(pods))
+	for i, pod := range pods {
+		subjects[i] = podRankingSubject(pod)
+	}
+
+	ranked := ranker.Rank(subjects, limit)
+	result := make([]PodResourceInfo, len(ranked))
+	for i, item := range ranked {
+		result[i] = pods[item.Index]
+		result[i].Score = item.Result.Score
+		result[i].ScoreBreakdown = item.Result.Breakdown
+	}
+	return result
+}
+
+// rankWorkloads 按 集群/命名空间/工作负载 汇总Pod后打分，返回前limit个
+func rankWorkloads(ranker *ranking.Ranker, pods []PodResourceInfo, limit int) []RankedWorkload {
+	index := make(map[string]int)
+	var workloads []RankedWorkload
+	for _, pod := range pods {
+		key := pod.ClusterName + "/" + pod.Namespace + "/" + pod.WorkloadKind + "/" + pod.WorkloadName
+		i, ok := index[key]
+		if !ok {
+			i = len(workloads)
+			index[key] = i
+			workloads = append(workloads, RankedWorkload{
+				ClusterName:  pod.ClusterName,
+				Namespace:    pod.Namespace,
+				WorkloadKind: pod.WorkloadKind,
+				WorkloadName: pod.WorkloadName,
+			})
+		}
+
+		// 工作负载中全部Pod都有真实使用量时才计算该资源的浪费，避免缺失数据的Pod请求量被计为浪费
+		podSubject := podRankingSubject(pod)
+		subject := &workloads[i].subject
+		if subject.PodCount == 0 {
+			subject.CPUMeasured, subject.MemoryMeasured = true, true
+		}
+		subject.CPURequest += podSubject.CPURequest
+		subject.CPULimit += podSubject.CPULimit
+		subject.CPUUsage += podSubject.CPUUsage
+		subject.MemoryRequest += podSubject.MemoryRequest
+		subject.MemoryLimit += podSubject.MemoryLimit
+		subject.MemoryUsage += podSubject.MemoryUsage
+		subject.CPUMeasured = subject.CPUMeasured && podSubject.CPUMeasured
+		subject.MemoryMeasured = subject.MemoryMeasured && podSubject.MemoryMeasured
+		subject.RestartCount += podSubject.RestartCount
+		subject.OOMKilled = subject.OOMKilled || podSubject.OOMKilled
+		subject.PodCount++
+	}
+
+	subjects := make([]ranking.Subject, len(workloads))
+	for i := range workloads {
+		subjects[i] = workloads[i].subject
+	}
+
+	ranked := ranker.Rank(subjects, limit)
+	result := make([]RankedWorkload, len(ranked))
+	for i, item := range ranked {
+		workload := workloads[item.Index]
+		workload.PodCount = workload.subject.PodCount
+		workload.CPURequest = workload.subject.CPURequest
+		workload.CPUUsage = workload.subject.CPUUsage
+		workload.MemoryRequest = workload.subject.MemoryRequest
+		workload.MemoryUsage = workload.subject.MemoryUsage
+		workload.RestartCount = workload.subject.RestartCount
+		workload.OOMKilled = workload.subject.OOMKilled
+		workload.Score = item.Result.Score
+		workload.ScoreBreakdown = item.Result.Breakdown
+		result[i] = workload
+	}
+	return result
+}
+
+// RankProblems 按加权因子为Pod或工作负载计算问题严重程度得分，返回得分最高的前N个及各因子明细
+// 参数:
+//   - ctx: 上下文对象
+//   - req: 排名请求，包括集群、命名空间、排名粒度和返回数量
+//
+// 返回:
+//   - *RankingResult: 排名结果
+//   - error: 收集过程中的错误信息
+func (mc *MultiClusterResourceCollector) RankProblems(ctx context.Context, req RankingRequest) (*RankingResult, error) {
+	if req.Level == "" {
+		req.Level = RankingLevelPod
+	}
+	if req.Level != RankingLevelPod && req.Level != RankingLevelWorkload {
+		return nil, fmt.Errorf("不支持的排名粒度: %s", req.Level)
+	}
+
+	pods, err := mc.collectOnlineClusterPods(ctx, req.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Namespace != "" {
+		filtered := pods[:0]
+		for _, pod := range pods {
+			if pod.Namespace == req.Namespace {
+				filtered = append(filtered, pod)
+			}
+		}
+		pods = filtered
+	}
+
+	ranker := ranking.DefaultRanker()
+	result := &RankingResult{
+		Level:       req.Level,
+		Factors:     rankingFactorInfos(ranker),
+		GeneratedAt: time.Now(),
+	}
+
+	if req.Level == RankingLevelWorkload {
+		result.Workloads = rankWorkloads(ranker, pods, req.Limit)
+		result.Candidates = countWorkloads(pods)
+	} else {
+		result.Pods = rankPods(ranker, pods, req.Limit)
+		result.Candidates = len(pods)
+	}
+
+	return result, nil
+}
+
+// GetRankingFactors 返回当前配置下参与排名的因子及权重
+func GetRankingFactors() []RankingFactorInfo {
+	return rankingFactorInfos(ranking.DefaultRanker())
+}
+
+func rankingFactorInfos(ranker *ranking.Ranker) []RankingFactorInfo {
+	factors := ranker.Factors()
+	infos := make([]RankingFactorInfo, 0, len(factors))
+	for _, factor := range factors {
+		infos = append(infos, RankingFactorInfo{
+			Name:        factor.Factor.Name(),
+			Description: factor.Factor.Description(),
+			Weight:      factor.Weight,
+		})
+	}
+	return infos
+}
+
+// countWorkloads 统计Pod所属的工作负载数量
+func countWorkloads(pods []PodResourceInfo) int {
+	seen := make(map[string]struct{})
+	for _, pod := range pods {
+		seen[pod.ClusterName+"/"+pod.Namespace+"/"+pod.WorkloadKind+"/"+pod.WorkloadName] = struct{}{}
+	}
+	return len(seen)
+}
+
+// collectOnlineClusterPods 收集在线集群的全部Pod，单个集群失败时跳过
+// 参数:
+//   - clusterID: 集群ID，为nil时收集全部在线集群
+func (mc *MultiClusterResourceCollector) collectOnlineClusterPods(ctx context.Context, clusterID *uint) ([]PodResourceInfo, error) {
+	clusters, err := mc.clusterService.GetAllClusters()
+	if err != nil {
+		return nil, fmt.Errorf("获取集群列表失败: %v", err)
+	}
+
+	allPods := []PodResourceInfo{}
+	for _, cluster := range clusters {
+		if cluster.Status != "online" || (clusterID != nil && cluster.ID != *clusterID) {
+			continue
+		}
+
+		singleCollector, err := mc.newClusterCollector(&cluster)
+		if err != nil {
+			logger.Error("%v，跳过该集群", err)
+			continue
+		}
+
+		clusterPods, err := singleCollector.collectAllPodsWithoutFiltering(ctx, cluster.ClusterName)
+		if err != nil {
+			logger.Error("收集集群 %s Pod数据失败，跳过该集群: %v", cluster.ClusterName, err)
+			continue
+		}
+
+		allPods = append(allPods, clusterPods...)
+	}
+
+	return allPods, nil
+}
@@ -0,0 +1,431 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	"cluster-resource-insight/internal/config"
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/models"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// QoS等级
+const (
+	QoSGuaranteed = string(corev1.PodQOSGuaranteed)
+	QoSBurstable  = string(corev1.PodQOSBurstable)
+	QoSBestEffort = string(corev1.PodQOSBestEffort)
+)
+
+// 驱逐风险等级
+const (
+	EvictionRiskHigh   = "high"
+	EvictionRiskMedium = "medium"
+	EvictionRiskLow    = "low"
+)
+
+// qosSettings QoS分析参数
+type qosSettings struct {
+	productionNamespaces  []string
+	guaranteedLowUsagePct float64
+}
+
+// QoSCounts 各QoS等级的Pod数量
+type QoSCounts struct {
+	Guaranteed int `json:"guaranteed"`
+	Burstable  int `json:"burstable"`
+	BestEffort int `json:"best_effort"`
+}
+
+// add 按QoS等级计数
+func (qc *QoSCounts) add(qosClass string) {
+	switch qosClass {
+	case QoSGuaranteed:
+		qc.Guaranteed++
+	case QoSBestEffort:
+		qc.BestEffort++
+	default:
+		qc.Burstable++
+	}
+}
+
+// PodEvictionRisk Pod驱逐风险评估
+type PodEvictionRisk struct {
+	Namespace    string  `json:"namespace"`
+	PodName      string  `json:"pod_name"`
+	NodeName     string  `json:"node_name"`
+	QoSClass     string  `json:"qos_class"`
+	RiskScore    float64 `json:"risk_score"`    // 风险评分 0-100
+	RiskLevel    string  `json:"risk_level"`    // 风险等级：high/medium/low
+	NodePressure float64 `json:"node_pressure"` // 所在节点内存使用占可分配量的比例，未知时为-1
+	Reason       string  `json:"reason"`        // 风险说明
+}
+
+// NamespaceQoSDistribution 命名空间QoS分布
+type NamespaceQoSDistribution struct {
+	Namespace    string    `json:"namespace"`
+	Production   bool      `json:"production"` // 是否为生产命名空间
+	Counts       QoSCounts `json:"counts"`
+	HighRiskPods int       `json:"high_risk_pods"`
+}
+
+// NodeQoSDistribution 节点QoS分布
+type NodeQoSDistribution struct {
+	NodeName              string    `json:"node_name"`
+	Counts                QoSCounts `json:"counts"`
+	MemoryPressure        float64   `json:"memory_pressure"`          // 节点Pod内存使用量占可分配内存的比例
+	BestEffortMemoryUsage int64     `json:"best_effort_memory_usage"` // BestEffort Pod内存使用量 (bytes)
+	HighRiskPods          int       `json:"high_risk_pods"`
+}
+
+// ClusterQoSReport 集群QoS分布报告
+type ClusterQoSReport struct {
+	ClusterID   uint                       `json:"cluster_id"`
+	ClusterName string                     `json:"cluster_name"`
+	Counts      QoSCounts                  `json:"counts"`
+	Namespaces  []NamespaceQoSDistribution `json:"namespaces"`
+	Nodes       []NodeQoSDistribution      `json:"nodes"`
+	AtRiskPods  []PodEvictionRisk          `json:"at_risk_pods"` // 驱逐风险最高的Pod，最多50个
+}
+
+// QoSDistributionReport QoS分布报告
+type QoSDistributionReport struct {
+	Clusters         []ClusterQoSReport `json:"clusters"`
+	ClustersAnalyzed int                `json:"clusters_analyzed"`
+	GeneratedAt      time.Time          `json:"generated_at"`
+}
+
+// loadQoSSettings 读取QoS分析配置，未配置项使用默认值
+func loadQoSSettings() qosSettings {
+	settings := qosSettings{
+		productionNamespaces:  []string{"prod", "prod-*", "*-prod", "production"},
+		guaranteedLowUsagePct: 30,
+	}
+
+	if qosConfig := config.GetQoSConfig(); qosConfig != nil {
+		if len(qosConfig.ProductionNamespaces) > 0 {
+			settings.productionNamespaces = qosConfig.ProductionNamespaces
+		}
+		if qosConfig.GuaranteedLowUsagePct > 0 {
+			settings.guaranteedLowUsagePct = qosConfig.GuaranteedLowUsagePct
+		}
+	}
+
+	return settings
+}
+
+// resolveQoSClass 获取Pod的QoS等级，状态中未填写时按容器资源配置推算
+func resolveQoSClass(pod *corev1.Pod) string {
+	if pod.Status.QOSClass != "" {
+		return string(pod.Status.QOSClass)
+	}
+	return computeQoSClass(append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...))
+}
+
+// computeQoSClass 按Kubernetes规则推算QoS等级
+// 所有容器均未设置CPU和内存的请求与限制时为BestEffort；
+// 每个容器都设置了CPU和内存限制且请求等于限制（未设置请求时默认等于限制）时为Guaranteed；其余为Burstable
+func computeQoSClass(containers []corev1.Container) string {
+	hasAny := false
+	guaranteed := true
+
+	for _, container := range containers {
+		for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			request, hasRequest := container.Resources.Requests[name]
+			limit, hasLimit := container.Resources.Limits[name]
+			if (hasRequest && !request.IsZero()) || (hasLimit && !limit.IsZero()) {
+				hasAny = true
+			}
+			if !hasLimit || limit.IsZero() {
+				guaranteed = false
+				continue
+			}
+			if hasRequest && request.Cmp(limit) != 0 {
+				guaranteed = false
+			}
+		}
+	}
+
+	switch {
+	case !hasAny:
+		return QoSBestEffort
+	case guaranteed:
+		return QoSGuaranteed
+	default:
+		return QoSBurstable
+	}
+}
+
+// isProductionNamespace 判断命名空间是否匹配生产命名空间规则
+func isProductionNamespace(namespace string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, namespace); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// qosIssues 检查Pod的QoS相关问题
+// 生产命名空间中的BestEffort Pod在节点压力时最先被驱逐；
+// Guaranteed Pod的请求等于限制，使用率长期偏低时锁定的资源无法被其他Pod使用
+func qosIssues(pod *PodResourceInfo, settings qosSettings) []string {
+	var issues []string
+
+	switch pod.QoSClass {
+	case QoSBestEffort:
+		if isProductionNamespace(pod.Namespace, settings.productionNamespaces) {
+			issues = append(issues, "生产命名空间中的BestEffort Pod")
+		}
+	case QoSGuaranteed:
+		if pod.MetricsAvailable && pod.CPURequest > 0 && pod.MemoryRequest > 0 &&
+			pod.CPUReqPct < settings.guaranteedLowUsagePct && pod.MemoryReqPct < settings.guaranteedLowUsagePct {
+			issues = append(issues, "Guaranteed Pod使用量远低于请求量")
+		}
+	}
+
+	return issues
+}
+
+// EvaluateEvictionRisk 评估Pod在节点内存压力下被驱逐的风险
+// kubelet驱逐时优先选择使用量超过请求量的Pod，BestEffort没有请求量因此最先被驱逐，Guaranteed最后；
+// 基础分按QoS和超出请求量的程度计算，再按所在节点的内存压力放大或缩小
+// nodePressure为节点Pod内存使用量占可分配内存的比例，小于0表示未知
+func EvaluateEvictionRisk(pod PodResourceInfo, nodePressure float64) PodEvictionRisk {
+	risk := PodEvictionRisk{
+		Namespace:    pod.Namespace,
+		PodName:      pod.PodName,
+		NodeName:     pod.NodeName,
+		QoSClass:     pod.QoSClass,
+		NodePressure: nodePressure,
+	}
+
+	var base float64
+	switch pod.QoSClass {
+	case QoSBestEffort:
+		base = 60
+		risk.Reason = "BestEffort Pod没有资源请求，节点压力时最先被驱逐"
+	case QoSGuaranteed:
+		base = 5
+		risk.Reason = "Guaranteed Pod仅在系统保留资源不足时被驱逐"
+	default:
+		if pod.MemoryMetricsAvailable && pod.MemoryRequest > 0 && pod.MemoryUsage > pod.MemoryRequest {
+			overPct := float64(pod.MemoryUsage-pod.MemoryRequest) / float64(pod.MemoryRequest) * 100
+			base = 40 + minFloat(30, overPct/2)
+			risk.Reason = fmt.Sprintf("内存使用量超出请求量 %.0f%%，节点压力时优先被驱逐", overPct)
+		} else {
+			base = 20
+			risk.Reason = "Burstable Pod内存使用量未超出请求量"
+		}
+	}
+
+	factor := 1.0
+	if nodePressure >= 0 {
+		factor = 0.5 + nodePressure
+	}
+	risk.RiskScore = minFloat(100, base*factor)
+
+	switch {
+	case risk.RiskScore >= 60:
+		risk.RiskLevel = EvictionRiskHigh
+	case risk.RiskScore >= 30:
+		risk.RiskLevel = EvictionRiskMedium
+	default:
+		risk.RiskLevel = EvictionRiskLow
+	}
+
+	return risk
+}
+
+// QoSGuidance 生成QoS相关的配置建议
+func QoSGuidance(pod *PodResourceInfo) []string {
+	var guidance []string
+	settings := loadQoSSettings()
+
+	switch pod.QoSClass {
+	case QoSBestEffort:
+		guidance = append(guidance, "建议设置CPU和内存请求量，避免作为BestEffort在节点压力时被优先驱逐")
+		if isProductionNamespace(pod.Namespace, settings.productionNamespaces) {
+			guidance = append(guidance, "生产命名空间建议至少使用Burstable，关键服务使用Guaranteed")
+		}
+	case QoSBurstable:
+		if pod.MemoryMetricsAvailable && pod.MemoryRequest > 0 && pod.MemoryUsage > pod.MemoryRequest {
+			guidance = append(guidance, "内存使用量已超过请求量，建议提高内存请求量以降低驱逐风险")
+		}
+	case QoSGuaranteed:
+		if pod.MetricsAvailable && pod.CPUReqPct < settings.guaranteedLowUsagePct && pod.MemoryReqPct < settings.guaranteedLowUsagePct {
+			guidance = append(guidance, "Guaranteed Pod使用率偏低，建议同步下调请求量和限制量以保持Guaranteed，非关键服务可改为Burstable")
+		}
+	}
+
+	return guidance
+}
+
+// BuildClusterQoSReport 汇总集群的QoS分布和驱逐风险
+func BuildClusterQoSReport(nodes []NodeCapacityInfo, pods []PodResourceInfo) ClusterQoSReport {
+	settings := loadQoSSettings()
+	report := ClusterQoSReport{
+		Namespaces: []NamespaceQoSDistribution{},
+		Nodes:      []NodeQoSDistribution{},
+		AtRiskPods: []PodEvictionRisk{},
+	}
+
+	allocatable := make(map[string]int64, len(nodes))
+	for _, node := range nodes {
+		allocatable[node.NodeName] = node.AllocatableMemory
+	}
+
+	nodeMap := make(map[string]*NodeQoSDistribution)
+	nodeUsage := make(map[string]int64)
+	// 节点内存压力只累计有真实内存使用量的Pod
+	for _, pod := range pods {
+		if pod.MemoryMetricsAvailable {
+			nodeUsage[pod.NodeName] += pod.MemoryUsage
+		}
+	}
+
+	namespaceMap := make(map[string]*NamespaceQoSDistribution)
+	var risks []PodEvictionRisk
+
+	for _, pod := range pods {
+		report.Counts.add(pod.QoSClass)
+
+		pressure := -1.0
+		if capacity := allocatable[pod.NodeName]; capacity > 0 {
+			pressure = float64(nodeUsage[pod.NodeName]) / float64(capacity)
+		}
+		risk := EvaluateEvictionRisk(pod, pressure)
+		risks = append(risks, risk)
+
+		namespace, exists := namespaceMap[pod.Namespace]
+		if !exists {
+			namespace = &NamespaceQoSDistribution{
+				Namespace:  pod.Namespace,
+				Production: isProductionNamespace(pod.Namespace, settings.productionNamespaces),
+			}
+			namespaceMap[pod.Namespace] = namespace
+		}
+		namespace.Counts.add(pod.QoSClass)
+
+		if pod.NodeName == "" {
+			if risk.RiskLevel == EvictionRiskHigh {
+				namespace.HighRiskPods++
+			}
+			continue
+		}
+		node, exists := nodeMap[pod.NodeName]
+		if !exists {
+			node = &NodeQoSDistribution{NodeName: pod.NodeName, MemoryPressure: maxFloat(pressure, 0)}
+			nodeMap[pod.NodeName] = node
+		}
+		node.Counts.add(pod.QoSClass)
+		if pod.QoSClass == QoSBestEffort && pod.MemoryMetricsAvailable {
+			node.BestEffortMemoryUsage += pod.MemoryUsage
+		}
+		if risk.RiskLevel == EvictionRiskHigh {
+			namespace.HighRiskPods++
+			node.HighRiskPods++
+		}
+	}
+
+	for _, namespace := range namespaceMap {
+		report.Namespaces = append(report.Namespaces, *namespace)
+	}
+	sort.Slice(report.Namespaces, func(i, j int) bool {
+		return report.Namespaces[i].Namespace < report.Namespaces[j].Namespace
+	})
+
+	for _, node := range nodeMap {
+		report.Nodes = append(report.Nodes, *node)
+	}
+	sort.Slice(report.Nodes, func(i, j int) bool {
+		if report.Nodes[i].MemoryPressure != report.Nodes[j].MemoryPressure {
+			return report.Nodes[i].MemoryPressure > report.Nodes[j].MemoryPressure
+		}
+		return report.Nodes[i].NodeName < report.Nodes[j].NodeName
+	})
+
+	sort.SliceStable(risks, func(i, j int) bool {
+		return risks[i].RiskScore > risks[j].RiskScore
+	})
+	for _, risk := range risks {
+		if risk.RiskLevel == EvictionRiskLow || len(report.AtRiskPods) >= 50 {
+			break
+		}
+		report.AtRiskPods = append(report.AtRiskPods, risk)
+	}
+
+	return report
+}
+
+// GetQoSDistribution 获取QoS分布和驱逐风险报告
+// 参数:
+//   - ctx: 上下文对象，用于控制请求生命周期和超时
+//   - clusterID: 目标集群ID，为空时统计所有在线集群
+//
+// 返回:
+//   - *QoSDistributionReport: 各集群按命名空间和节点汇总的QoS分布
+//   - error: 统计过程中的错误信息
+func (mc *MultiClusterResourceCollector) GetQoSDistribution(ctx context.Context, clusterID *uint) (*QoSDistributionReport, error) {
+	clusters, err := mc.clusterService.GetAllClusters()
+	if err != nil {
+		return nil, fmt.Errorf("获取集群列表失败: %v", err)
+	}
+
+	if clusterID != nil {
+		targetCluster, found := findClusterByID(clusters, *clusterID)
+		if !found {
+			return nil, fmt.Errorf("集群ID %d 不存在", *clusterID)
+		}
+		clusters = []models.ClusterConfig{targetCluster}
+	}
+
+	report := &QoSDistributionReport{
+		Clusters:    []ClusterQoSReport{},
+		GeneratedAt: time.Now(),
+	}
+
+	for _, cluster := range clusters {
+		if cluster.Status != "online" {
+			continue
+		}
+
+		singleCollector, err := mc.newClusterCollector(&cluster)
+		if err != nil {
+			logger.Error("%v，跳过QoS统计", err)
+			continue
+		}
+
+		nodes, err := singleCollector.collectNodeCapacity(ctx, cluster.ClusterName)
+		if err != nil {
+			logger.Error("获取集群 %s 节点容量失败，跳过QoS统计: %v", cluster.ClusterName, err)
+			continue
+		}
+
+		pods, err := singleCollector.collectAllPodsWithoutFiltering(ctx, cluster.ClusterName)
+		if err != nil {
+			logger.Error("收集集群 %s Pod数据失败，跳过QoS统计: %v", cluster.ClusterName, err)
+			continue
+		}
+
+		clusterReport := BuildClusterQoSReport(nodes, pods)
+		clusterReport.ClusterID = cluster.ID
+		clusterReport.ClusterName = cluster.ClusterName
+		report.Clusters = append(report.Clusters, clusterReport)
+		report.ClustersAnalyzed++
+	}
+
+	return report, nil
+}
+
+// minFloat 返回两个float64中的较小值
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,559 @@
+package collector
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/service"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// 导出归档格式
+const (
+	ExportFormatZip = "zip"
+	ExportFormatTar = "tar" // gzip压缩的tar包
+)
+
+// kustomizeBasePath 根目录 kustomization.yaml 引用的基础清单目录，导出后需放入或链接现有的工作负载清单
+const kustomizeBasePath = "base"
+
+// patchableWorkloadKinds 支持生成补丁的工作负载类型及其 kubectl 资源名
+// Job的Pod模板不可修改，独立Pod的资源不可原地修改，因此不在导出范围内
+var patchableWorkloadKinds = map[string]string{
+	"Deployment":  "deployment",
+	"StatefulSet": "statefulset",
+	"DaemonSet":   "daemonset",
+	"ReplicaSet":  "replicaset",
+}
+
+// workloadAPIVersions 工作负载类型对应的API版本
+var workloadAPIVersions = map[string]string{
+	"Deployment":  "apps/v1",
+	"StatefulSet": "apps/v1",
+	"DaemonSet":   "apps/v1",
+	"ReplicaSet":  "apps/v1",
+}
+
+// WorkloadSelector 导出时选择的工作负载
+type WorkloadSelector struct {
+	Namespace string `json:"namespace" binding:"required"` // 命名空间
+	Kind      string `json:"kind"`                         // 工作负载类型，为空时匹配任意类型
+	Name      string `json:"name" binding:"required"`      // 工作负载名称
+}
+
+// RecommendationExportRequest 资源建议导出请求
+type RecommendationExportRequest struct {
+	ClusterID uint               `json:"cluster_id" binding:"required"` // 集群ID
+	Namespace string             `json:"namespace"`                     // 命名空间过滤，未指定工作负载时生效
+	Workloads []WorkloadSelector `json:"workloads"`                     // 指定导出的工作负载，为空时导出全部可调整的工作负载
+	Format    string             `json:"format"`                        // 归档格式：zip/tar，默认zip
+}
+
+// WorkloadRecommendation 工作负载级别的资源建议 - 同一工作负载下各Pod按容器取最大值
+type WorkloadRecommendation struct {
+	ClusterName  string                    `json:"cluster_name"`
+	Namespace    string                    `json:"namespace"`
+	WorkloadKind string                    `json:"workload_kind"`
+	WorkloadName string                    `json:"workload_name"`
+	PodCount     int                       `json:"pod_count"`
+	Containers   []ContainerRecommendation `json:"containers"`
+}
+
+// RecommendationExport 资源建议导出结果
+type RecommendationExport struct {
+	FileName      string                   `json:"file_name"`      // 归档文件名
+	ContentType   string                   `json:"content_type"`   // 归档MIME类型
+	Content       []byte                   `json:"-"`              // 归档内容
+	Workloads     []WorkloadRecommendation `json:"workloads"`      // 导出的工作负载建议
+	SkippedReason map[string]string        `json:"skipped_reason"` // 未导出的工作负载及原因
+}
+
+// exportFile 归档中的单个文件
+type exportFile struct {
+	name    string
+	content string
+}
+
+// BuildWorkloadRecommendations 将Pod级建议按工作负载聚合
+// 同一工作负载的多个副本按容器名取建议值最大者，保证每个副本都有足够余量
+func BuildWorkloadRecommendations(pods []PodResourceInfo) []WorkloadRecommendation {
+	index := make(map[string]*WorkloadRecommendation)
+	containerIndex := make(map[string]map[string]int)
+	var keys []string
+
+	for _, pod := range pods {
+		key := pod.Namespace + "/" + pod.WorkloadKind + "/" + pod.WorkloadName
+		workload, exists := index[key]
+		if !exists {
+			workload = &WorkloadRecommendation{
+				ClusterName:  pod.ClusterName,
+				Namespace:    pod.Namespace,
+				WorkloadKind: pod.WorkloadKind,
+				WorkloadName: pod.WorkloadName,
+			}
+			index[key] = workload
+			containerIndex[key] = make(map[string]int)
+			keys = append(keys, key)
+		}
+		workload.PodCount++
+
+		for _, rec := range BuildPodRecommendation(pod).Containers {
+			pos, seen := containerIndex[key][rec.ContainerName]
+			if !seen {
+				containerIndex[key][rec.ContainerName] = len(workload.Containers)
+				workload.Containers = append(workload.Containers, rec)
+				continue
+			}
+			mergeContainerRecommendation(&workload.Containers[pos], rec)
+		}
+	}
+
+	sort.Strings(keys)
+	result := make([]WorkloadRecommendation, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, *index[key])
+	}
+	return result
+}
+
+// RecommendWorkloadResources 计算单个工作负载的容器资源建议，用于生成资源调整变更单
+// 仅返回基于真实使用量计算的容器
+func (mc *MultiClusterResourceCollector) RecommendWorkloadResources(ctx context.Context, clusterID uint, namespace, kind, name string) ([]service.ContainerResourceSpec, error) {
+	cluster, err := mc.clusterService.GetClusterByID(clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("获取集群配置失败: %v", err)
+	}
+
+	singleCollector, err := mc.newClusterCollector(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := singleCollector.collectNamespacePodsData(ctx, namespace, cluster.ClusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	var workloadPods []PodResourceInfo
+	for _, pod := range pods {
+		if pod.WorkloadKind == kind && pod.WorkloadName == name {
+			workloadPods = append(workloadPods, pod)
+		}
+	}
+
+	workloads := BuildWorkloadRecommendations(workloadPods)
+	var specs []service.ContainerResourceSpec
+	for _, workload := range workloads {
+		for _, container := range workload.Containers {
+			if !container.BasedOnMetrics {
+				continue
+			}
+			specs = append(specs, service.ContainerResourceSpec{
+				Name:          container.ContainerName,
+				CPURequest:    container.RecommendedCPURequest,
+				CPULimit:      container.RecommendedCPULimit,
+				MemoryRequest: container.RecommendedMemoryRequest,
+				MemoryLimit:   container.RecommendedMemoryLimit,
+			})
+		}
+	}
+
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("工作负载 %s/%s 没有可用的资源建议（缺少运行中的Pod或真实使用量指标）", namespace, name)
+	}
+
+	mc.recordRecommendationSnapshots(ctx, singleCollector, cluster, workloads, service.RecommendationSourceChangeRequest)
+	return specs, nil
+}
+
+// mergeContainerRecommendation 合并同名容器的建议，各项取较大值
+func mergeContainerRecommendation(target *ContainerRecommendation, other ContainerRecommendation) {
+	target.CurrentCPURequest = maxInt64(target.CurrentCPURequest, other.CurrentCPURequest)
+	target.CurrentCPULimit = maxInt64(target.CurrentCPULimit, other.CurrentCPULimit)
+	target.CurrentMemoryRequest = maxInt64(target.CurrentMemoryRequest, other.CurrentMemoryRequest)
+	target.CurrentMemoryLimit = maxInt64(target.CurrentMemoryLimit, other.CurrentMemoryLimit)
+
+	if !other.BasedOnMetrics {
+		return
+	}
+	if !target.BasedOnMetrics {
+		// 已有记录没有真实指标时，直接采用有指标副本的建议值
+		target.RecommendedCPURequest = other.RecommendedCPURequest
+		target.RecommendedCPULimit = other.RecommendedCPULimit
+		target.RecommendedMemoryRequest = other.RecommendedMemoryRequest
+		target.RecommendedMemoryLimit = other.RecommendedMemoryLimit
+		target.BasedOnMetrics = true
+		return
+	}
+	target.RecommendedCPURequest = maxInt64(target.RecommendedCPURequest, other.RecommendedCPURequest)
+	target.RecommendedCPULimit = maxInt64(target.RecommendedCPULimit, other.RecommendedCPULimit)
+	target.RecommendedMemoryRequest = maxInt64(target.RecommendedMemoryRequest, other.RecommendedMemoryRequest)
+	target.RecommendedMemoryLimit = maxInt64(target.RecommendedMemoryLimit, other.RecommendedMemoryLimit)
+}
+
+// matchesSelectors 判断工作负载是否在选择范围内
+func matchesSelectors(workload WorkloadRecommendation, namespace string, selectors []WorkloadSelector) bool {
+	if len(selectors) == 0 {
+		return namespace == "" || workload.Namespace == namespace
+	}
+	for _, selector := range selectors {
+		if selector.Namespace != workload.Namespace || selector.Name != workload.WorkloadName {
+			continue
+		}
+		if selector.Kind == "" || strings.EqualFold(selector.Kind, workload.WorkloadKind) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExportRecommendations 生成指定集群工作负载的资源调整补丁归档
+// 参数:
+//   - ctx: 上下文对象，用于控制请求生命周期和超时
+//   - req: 导出请求参数
+//
+// 返回:
+//   - *RecommendationExport: 导出结果，包含归档内容和导出的工作负载建议
+//   - error: 导出过程中的错误信息
+func (mc *MultiClusterResourceCollector) ExportRecommendations(ctx context.Context, req RecommendationExportRequest) (*RecommendationExport, error) {
+	cluster, err := mc.clusterService.GetClusterByID(req.ClusterID)
+	if err != nil {
+		return nil, fmt.Errorf("获取集群配置失败: %v", err)
+	}
+
+	singleCollector, err := mc.newClusterCollector(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := singleCollector.collectAllPodsWithoutFiltering(ctx, cluster.ClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("收集集群 %s Pod数据失败: %v", cluster.ClusterName, err)
+	}
+
+	export := &RecommendationExport{
+		Workloads:     []WorkloadRecommendation{},
+		SkippedReason: make(map[string]string),
+	}
+
+	for _, workload := range BuildWorkloadRecommendations(pods) {
+		if !matchesSelectors(workload, req.Namespace, req.Workloads) {
+			continue
+		}
+		key := fmt.Sprintf("%s/%s/%s", workload.Namespace, workload.WorkloadKind, workload.WorkloadName)
+		if _, ok := patchableWorkloadKinds[workload.WorkloadKind]; !ok {
+			export.SkippedReason[key] = fmt.Sprintf("不支持为 %s 类型生成补丁", workload.WorkloadKind)
+			continue
+		}
+		if !hasMetricsBasedContainer(workload) {
+			export.SkippedReason[key] = "缺少真实使用量指标"
+			continue
+		}
+		export.Workloads = append(export.Workloads, workload)
+	}
+
+	if len(export.Workloads) == 0 {
+		return nil, fmt.Errorf("集群 %s 中没有可导出资源建议的工作负载", cluster.ClusterName)
+	}
+
+	files := buildExportFiles(cluster.ClusterName, export.Workloads, export.SkippedReason)
+	baseName := fmt.Sprintf("rightsizing-%s-%s", cluster.ClusterName, time.Now().Format("20060102-150405"))
+
+	var buf bytes.Buffer
+	switch req.Format {
+	case ExportFormatTar:
+		err = writeTarGzArchive(&buf, baseName, files)
+		export.FileName = baseName + ".tar.gz"
+		export.ContentType = "application/gzip"
+	default:
+		err = writeZipArchive(&buf, baseName, files)
+		export.FileName = baseName + ".zip"
+		export.ContentType = "application/zip"
+	}
+	if err != nil {
+		return nil, fmt.Errorf("生成导出归档失败: %v", err)
+	}
+	export.Content = buf.Bytes()
+
+	mc.recordRecommendationSnapshots(ctx, singleCollector, cluster, export.Workloads, service.RecommendationSourceExport)
+
+	logger.Info("集群 %s 资源建议导出完成: 工作负载 %d 个, 跳过 %d 个", cluster.ClusterName, len(export.Workloads), len(export.SkippedReason))
+	return export, nil
+}
+
+// hasMetricsBasedContainer 判断工作负载是否存在基于真实指标的容器建议
+func hasMetricsBasedContainer(workload WorkloadRecommendation) bool {
+	for _, container := range workload.Containers {
+		if container.BasedOnMetrics {
+			return true
+		}
+	}
+	return false
+}
+
+// buildExportFiles 生成归档中的全部文件
+// 目录结构：<namespace>/<kind>-<name>/ 下包含strategic-merge补丁、kustomize补丁和Helm values片段，
+// 根目录包含 kustomization.yaml、set-resources.sh 和 README.md
+func buildExportFiles(clusterName string, workloads []WorkloadRecommendation, skipped map[string]string) []exportFile {
+	var files []exportFile
+	var kustomizePatches []string
+	var script strings.Builder
+	var readme strings.Builder
+
+	script.WriteString("#!/bin/sh\n")
+	script.WriteString(fmt.Sprintf("# 集群 %s 资源建议调整脚本，执行前请确认 kubectl 当前上下文指向该集群\n", clusterName))
+	script.WriteString("set -e\n")
+
+	readme.WriteString(fmt.Sprintf("# 集群 %s 资源建议\n\n", clusterName))
+	readme.WriteString(fmt.Sprintf("生成时间: %s\n\n", time.Now().Format("2006-01-02 15:04:05")))
+	readme.WriteString("- `*/strategic-merge-patch.yaml`: 用于 `kubectl patch --type strategic --patch-file`\n")
+	readme.WriteString("- `*/kustomize-patch.yaml`: kustomize补丁，已在根目录 `kustomization.yaml` 中引用\n")
+	readme.WriteString(fmt.Sprintf("- `kustomization.yaml`: 覆盖层，以 `%s/` 为基础清单，使用前需将现有工作负载清单（或其kustomization）放入该目录，再执行 `kubectl apply -k .`\n", kustomizeBasePath))
+	readme.WriteString("- `*/helm-values.yaml`: 按容器名称组织的Helm values片段\n")
+	readme.WriteString("- `set-resources.sh`: `kubectl set resources` 调整脚本\n\n")
+	readme.WriteString("| 命名空间 | 工作负载 | 容器 | CPU请求 | CPU限制 | 内存请求 | 内存限制 |\n")
+	readme.WriteString("|---|---|---|---|---|---|---|\n")
+
+	for _, workload := range workloads {
+		dir := fmt.Sprintf("%s/%s-%s", workload.Namespace, strings.ToLower(workload.WorkloadKind), workload.WorkloadName)
+		kustomizePatches = append(kustomizePatches, dir+"/kustomize-patch.yaml")
+
+		files = append(files,
+			exportFile{name: dir + "/strategic-merge-patch.yaml", content: renderStrategicMergePatch(workload)},
+			exportFile{name: dir + "/kustomize-patch.yaml", content: renderKustomizePatch(workload)},
+			exportFile{name: dir + "/helm-values.yaml", content: renderHelmValues(workload)},
+		)
+
+		script.WriteString(renderSetResourcesCommands(workload))
+
+		for _, container := range workload.Containers {
+			readme.WriteString(fmt.Sprintf("| %s | %s/%s | %s | %s | %s | %s | %s |\n",
+				workload.Namespace, workload.WorkloadKind, workload.WorkloadName, container.ContainerName,
+				beforeAfter(formatCPU(container.CurrentCPURequest), formatCPU(container.RecommendedCPURequest)),
+				beforeAfter(formatCPU(container.CurrentCPULimit), formatCPU(container.RecommendedCPULimit)),
+				beforeAfter(formatMemory(container.CurrentMemoryRequest), formatMemory(container.RecommendedMemoryRequest)),
+				beforeAfter(formatMemory(container.CurrentMemoryLimit), formatMemory(container.RecommendedMemoryLimit))))
+		}
+	}
+
+	if len(skipped) > 0 {
+		readme.WriteString("\n## 未导出的工作负载\n\n")
+		skippedKeys := make([]string, 0, len(skipped))
+		for key := range skipped {
+			skippedKeys = append(skippedKeys, key)
+		}
+		sort.Strings(skippedKeys)
+		for _, key := range skippedKeys {
+			readme.WriteString(fmt.Sprintf("- %s: %s\n", key, skipped[key]))
+		}
+	}
+
+	var kustomization strings.Builder
+	kustomization.WriteString("apiVersion: kustomize.config.k8s.io/v1beta1\n")
+	kustomization.WriteString("kind: Kustomization\n")
+	kustomization.WriteString(fmt.Sprintf("resources:\n  - %s\n", kustomizeBasePath))
+	kustomization.WriteString("patches:\n")
+	for _, patch := range kustomizePatches {
+		kustomization.WriteString(fmt.Sprintf("  - path: %s\n", patch))
+	}
+
+	files = append(files,
+		exportFile{name: "kustomization.yaml", content: kustomization.String()},
+		exportFile{name: "set-resources.sh", content: script.String()},
+		exportFile{name: "README.md", content: readme.String()},
+	)
+	return files
+}
+
+// renderStrategicMergePatch 生成strategic-merge补丁，容器按名称合并
+func renderStrategicMergePatch(workload WorkloadRecommendation) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("# %s %s/%s\n", workload.WorkloadKind, workload.Namespace, workload.WorkloadName))
+	b.WriteString(fmt.Sprintf("# kubectl patch %s %s -n %s --type strategic --patch-file strategic-merge-patch.yaml\n",
+		patchableWorkloadKinds[workload.WorkloadKind], workload.WorkloadName, workload.Namespace))
+	b.WriteString("spec:\n")
+	b.WriteString("  template:\n")
+	b.WriteString("    spec:\n")
+	b.WriteString("      containers:\n")
+	writeContainerResources(&b, workload.Containers, "        ")
+	return b.String()
+}
+
+// renderKustomizePatch 生成kustomize补丁，包含定位目标资源所需的元数据
+func renderKustomizePatch(workload WorkloadRecommendation) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("apiVersion: %s\n", workloadAPIVersions[workload.WorkloadKind]))
+	b.WriteString(fmt.Sprintf("kind: %s\n", workload.WorkloadKind))
+	b.WriteString("metadata:\n")
+	b.WriteString(fmt.Sprintf("  name: %s\n", workload.WorkloadName))
+	b.WriteString(fmt.Sprintf("  namespace: %s\n", workload.Namespace))
+	b.WriteString("spec:\n")
+	b.WriteString("  template:\n")
+	b.WriteString("    spec:\n")
+	b.WriteString("      containers:\n")
+	writeContainerResources(&b, workload.Containers, "        ")
+	return b.String()
+}
+
+// writeContainerResources 写入容器资源列表，缺少真实指标的容器不做调整
+func writeContainerResources(b *strings.Builder, containers []ContainerRecommendation, indent string) {
+	for _, container := range containers {
+		if !container.BasedOnMetrics {
+			b.WriteString(fmt.Sprintf("%s# 容器 %s 缺少真实使用量指标，保持当前配置\n", indent, container.ContainerName))
+			continue
+		}
+		b.WriteString(fmt.Sprintf("%s- name: %s\n", indent, container.ContainerName))
+		writeResourcesBlock(b, container, indent+"  ")
+	}
+}
+
+// writeResourcesBlock 写入 resources 段落，注释中标注调整前的值，未设置的限制量不输出
+func writeResourcesBlock(b *strings.Builder, container ContainerRecommendation, indent string) {
+	b.WriteString(fmt.Sprintf("%sresources:\n", indent))
+	b.WriteString(fmt.Sprintf("%s  requests:\n", indent))
+	b.WriteString(fmt.Sprintf("%s    cpu: %s # 调整前: %s\n", indent, formatCPU(container.RecommendedCPURequest), formatCPU(container.CurrentCPURequest)))
+	b.WriteString(fmt.Sprintf("%s    memory: %s # 调整前: %s\n", indent, formatMemory(container.RecommendedMemoryRequest), formatMemory(container.CurrentMemoryRequest)))
+	if container.RecommendedCPULimit > 0 || container.RecommendedMemoryLimit > 0 {
+		b.WriteString(fmt.Sprintf("%s  limits:\n", indent))
+		if container.RecommendedCPULimit > 0 {
+			b.WriteString(fmt.Sprintf("%s    cpu: %s # 调整前: %s\n", indent, formatCPU(container.RecommendedCPULimit), formatCPU(container.CurrentCPULimit)))
+		}
+		if container.RecommendedMemoryLimit > 0 {
+			b.WriteString(fmt.Sprintf("%s    memory: %s # 调整前: %s\n", indent, formatMemory(container.RecommendedMemoryLimit), formatMemory(container.CurrentMemoryLimit)))
+		}
+	}
+}
+
+// renderHelmValues 生成按容器名称组织的Helm values片段
+func renderHelmValues(workload WorkloadRecommendation) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("# %s %s/%s，请按Chart的values结构合并到对应位置\n", workload.WorkloadKind, workload.Namespace, workload.WorkloadName))
+	for _, container := range workload.Containers {
+		if !container.BasedOnMetrics {
+			b.WriteString(fmt.Sprintf("# 容器 %s 缺少真实使用量指标，保持当前配置\n", container.ContainerName))
+			continue
+		}
+		b.WriteString(fmt.Sprintf("%s:\n", container.ContainerName))
+		writeResourcesBlock(&b, container, "  ")
+	}
+	return b.String()
+}
+
+// renderSetResourcesCommands 生成 kubectl set resources 命令
+func renderSetResourcesCommands(workload WorkloadRecommendation) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("\n# %s %s/%s\n", workload.WorkloadKind, workload.Namespace, workload.WorkloadName))
+	for _, container := range workload.Containers {
+		if !container.BasedOnMetrics {
+			b.WriteString(fmt.Sprintf("# 容器 %s 缺少真实使用量指标，跳过\n", container.ContainerName))
+			continue
+		}
+		b.WriteString(fmt.Sprintf("# 容器 %s: CPU请求 %s, 内存请求 %s\n", container.ContainerName,
+			beforeAfter(formatCPU(container.CurrentCPURequest), formatCPU(container.RecommendedCPURequest)),
+			beforeAfter(formatMemory(container.CurrentMemoryRequest), formatMemory(container.RecommendedMemoryRequest))))
+
+		command := fmt.Sprintf("kubectl set resources %s/%s -n %s -c %s --requests=cpu=%s,memory=%s",
+			patchableWorkloadKinds[workload.WorkloadKind], workload.WorkloadName, workload.Namespace, container.ContainerName,
+			formatCPU(container.RecommendedCPURequest), formatMemory(container.RecommendedMemoryRequest))
+
+		var limits []string
+		if container.RecommendedCPULimit > 0 {
+			limits = append(limits, "cpu="+formatCPU(container.RecommendedCPULimit))
+		}
+		if container.RecommendedMemoryLimit > 0 {
+			limits = append(limits, "memory="+formatMemory(container.RecommendedMemoryLimit))
+		}
+		if len(limits) > 0 {
+			command += " --limits=" + strings.Join(limits, ",")
+		}
+		b.WriteString(command + "\n")
+	}
+	return b.String()
+}
+
+// formatCPU 将millicores格式化为Kubernetes数量表示，0表示未设置
+func formatCPU(milli int64) string {
+	if milli <= 0 {
+		return "未设置"
+	}
+	return resource.NewMilliQuantity(milli, resource.DecimalSI).String()
+}
+
+// formatMemory 将字节数格式化为Kubernetes数量表示，0表示未设置
+func formatMemory(bytes int64) string {
+	if bytes <= 0 {
+		return "未设置"
+	}
+	return resource.NewQuantity(bytes, resource.BinarySI).String()
+}
+
+// beforeAfter 格式化调整前后的值
+func beforeAfter(before, after string) string {
+	if before == after {
+		return after
+	}
+	return before + " → " + after
+}
+
+// writeZipArchive 将文件写入zip归档
+func writeZipArchive(w io.Writer, baseName string, files []exportFile) error {
+	zw := zip.NewWriter(w)
+	for _, file := range files {
+		header := &zip.FileHeader{
+			Name:     baseName + "/" + file.name,
+			Method:   zip.Deflate,
+			Modified: time.Now(),
+		}
+		if strings.HasSuffix(file.name, ".sh") {
+			header.SetMode(0755)
+		} else {
+			header.SetMode(0644)
+		}
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, file.content); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// writeTarGzArchive 将文件写入gzip压缩的tar归档
+func writeTarGzArchive(w io.Writer, baseName string, files []exportFile) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	for _, file := range files {
+		mode := int64(0644)
+		if strings.HasSuffix(file.name, ".sh") {
+			mode = 0755
+		}
+		header := &tar.Header{
+			Name:    baseName + "/" + file.name,
+			Mode:    mode,
+			Size:    int64(len(file.content)),
+			ModTime: time.Now(),
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(tw, file.content); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
@@ -2,85 +2,25 @@ package collector
 
 import (
 	"context"
-	"fmt"
 	"time"
+
+	"cluster-resource-insight/pkg/ranking"
 )
 
 // 分析Pod资源使用情况
 func (rc *ResourceCollector) analyzeResourceUsage(pods []PodResourceInfo) *AnalysisResult {
-	var unreasonablePods []PodResourceInfo
-
-	for i := range pods {
-		pod := &pods[i]
-		issues := []string{}
-
-		// 检查内存利用率 (现在总是有使用数据，包括估算值)
-		if pod.MemoryRequest > 0 && pod.MemoryReqPct > 0 && pod.MemoryReqPct < 20 {
-			issues = append(issues, "内存请求利用率过低")
-		}
-		if pod.MemoryLimit > 0 && pod.MemoryLimitPct > 0 && pod.MemoryLimitPct < 15 {
-			issues = append(issues, "内存限制利用率过低")
-		}
-
-		// 检查 CPU 利用率 (现在总是有使用数据，包括估算值)
-		if pod.CPURequest > 0 && pod.CPUReqPct > 0 && pod.CPUReqPct < 15 {
-			issues = append(issues, "CPU请求利用率过低")
-		}
-		if pod.CPULimit > 0 && pod.CPULimitPct > 0 && pod.CPULimitPct < 10 {
-			issues = append(issues, "CPU限制利用率过低")
-		}
-
-		// 检查配置缺失
-		if pod.MemoryRequest == 0 {
-			issues = append(issues, "缺少内存请求配置")
-		}
-		if pod.CPURequest == 0 {
-			issues = append(issues, "缺少CPU请求配置")
-		}
-
-		// 检查请求和限制差异过大
-		if pod.MemoryLimit > 0 && pod.MemoryRequest > 0 {
-			ratio := float64(pod.MemoryLimit) / float64(pod.MemoryRequest)
-			if ratio > 3.0 {
-				issues = append(issues, "内存请求和限制差异过大")
-			}
-		}
-		if pod.CPULimit > 0 && pod.CPURequest > 0 {
-			ratio := float64(pod.CPULimit) / float64(pod.CPURequest)
-			if ratio > 3.0 {
-				issues = append(issues, "CPU请求和限制差异过大")
-			}
-		}
-
-		// 移除之前的 Metrics 依赖检查，因为现在总是有数据
-
-		if len(issues) > 0 {
-			pod.Status = "不合理"
-			pod.Issues = issues
-			unreasonablePods = append(unreasonablePods, *pod)
-		}
-	}
-
-	// 按问题严重程度排序（利用率最低的排在前面）
-	rc.sortPodsByProblemSeverity(unreasonablePods)
-
-	// 取前50个
-	top50 := unreasonablePods
-	if len(unreasonablePods) > 50 {
-		top50 = unreasonablePods[:50]
-	}
-
-	return &AnalysisResult{
-		TotalPods:        len(pods),
-		UnreasonablePods: len(unreasonablePods),
-		Top50Problems:    top50,
-		GeneratedAt:      time.Now(),
-	}
+	return analyzePods(pods)
 }
 
 // analyzeMultiClusterData 分析多集群数据
 func (mc *MultiClusterResourceCollector) analyzeMultiClusterData(pods []PodResourceInfo) *AnalysisResult {
+	return analyzePods(pods)
+}
+
+// analyzePods 识别配置不合理的Pod，按问题严重程度得分取前50个
+func analyzePods(pods []PodResourceInfo) *AnalysisResult {
 	var unreasonablePods []PodResourceInfo
+	qos := loadQoSSettings()
 
 	for i := range pods {
 		pod := &pods[i]
@@ -124,7 +64,8 @@ func (mc *MultiClusterResourceCollector) analyzeMultiClusterData(pods []PodResou
 			}
 		}
 
-		// 移除之前的 Metrics 依赖检查，因为现在总是有数据
+		// 检查QoS等级相关问题
+		issues = append(issues, qosIssues(pod, qos)...)
 
 		if len(issues) > 0 {
 			pod.Status = "不合理"
@@ -133,14 +74,8 @@ func (mc *MultiClusterResourceCollector) analyzeMultiClusterData(pods []PodResou
 		}
 	}
 
-	// 按问题严重程度排序（利用率最低的排在前面）
-	mc.sortPodsByProblemSeverity(unreasonablePods)
-
-	// 取前50个
-	top50 := unreasonablePods
-	if len(unreasonablePods) > 50 {
-		top50 = unreasonablePods[:50]
-	}
+	// 按问题严重程度得分取前50个
+	top50 := rankPods(ranking.DefaultRanker(), unreasonablePods, 50)
 
 	return &AnalysisResult{
 		TotalPods:        len(pods),
@@ -150,165 +85,30 @@ func (mc *MultiClusterResourceCollector) analyzeMultiClusterData(pods []PodResou
 	}
 }
 
-// 按问题严重程度排序Pod
-func (rc *ResourceCollector) sortPodsByProblemSeverity(pods []PodResourceInfo) {
-	// 简单的排序：按内存和CPU的最低利用率排序
-	for i := 0; i < len(pods)-1; i++ {
-		for j := i + 1; j < len(pods); j++ {
-			// 计算问题严重程度分数（利用率越低分数越高）
-			scoreI := rc.calculateProblemScore(pods[i])
-			scoreJ := rc.calculateProblemScore(pods[j])
-
-			if scoreI < scoreJ {
-				pods[i], pods[j] = pods[j], pods[i]
-			}
-		}
-	}
-}
-
-// 多集群版本的排序方法
-func (mc *MultiClusterResourceCollector) sortPodsByProblemSeverity(pods []PodResourceInfo) {
-	// 简单的排序：按内存和CPU的最低利用率排序
-	for i := 0; i < len(pods)-1; i++ {
-		for j := i + 1; j < len(pods); j++ {
-			// 计算问题严重程度分数（利用率越低分数越高）
-			scoreI := mc.calculateProblemScore(pods[i])
-			scoreJ := mc.calculateProblemScore(pods[j])
-
-			if scoreI < scoreJ {
-				pods[i], pods[j] = pods[j], pods[i]
-			}
-		}
-	}
-}
-
-// 计算Pod问题严重程度分数
-func (rc *ResourceCollector) calculateProblemScore(pod PodResourceInfo) float64 {
-	score := 0.0
-
-	// 内存利用率问题得分
-	if pod.MemoryRequest > 0 {
-		score += (20.0 - pod.MemoryReqPct) / 20.0 * 100
-	}
-	if pod.MemoryLimit > 0 {
-		score += (15.0 - pod.MemoryLimitPct) / 15.0 * 100
-	}
-
-	// CPU 利用率问题得分
-	if pod.CPURequest > 0 {
-		score += (15.0 - pod.CPUReqPct) / 15.0 * 100
-	}
-	if pod.CPULimit > 0 {
-		score += (10.0 - pod.CPULimitPct) / 10.0 * 100
-	}
-
-	// 配置缺失问题得分
-	if pod.MemoryRequest == 0 {
-		score += 200
-	}
-	if pod.CPURequest == 0 {
-		score += 200
-	}
-
-	return score
-}
-
 // GetTopMemoryRequestPods 获取内存请求量最大的前N个Pod
 func (mc *MultiClusterResourceCollector) GetTopMemoryRequestPods(ctx context.Context, limit int) ([]PodResourceInfo, error) {
-	// 收集所有Pod并按内存请求量排序
-	allPods := []PodResourceInfo{}
-
-	clusters, err := mc.clusterService.GetAllClusters()
+	allPods, err := mc.collectOnlineClusterPods(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("获取集群列表失败: %v", err)
-	}
-
-	for _, cluster := range clusters {
-		if cluster.Status != "online" {
-			continue
-		}
-
-		kubeClient, metricsClient, err := mc.clusterService.CreateKubernetesClient(&cluster)
-		if err != nil {
-			continue
-		}
-
-		singleCollector := &ResourceCollector{
-			kubeClient:    kubeClient,
-			metricsClient: metricsClient,
-		}
-
-		clusterPods, err := singleCollector.collectAllPodsWithoutFiltering(ctx, cluster.ClusterName)
-		if err != nil {
-			continue
-		}
-
-		allPods = append(allPods, clusterPods...)
-	}
-
-	// 按内存请求量排序（从大到小）
-	for i := 0; i < len(allPods)-1; i++ {
-		for j := i + 1; j < len(allPods); j++ {
-			if allPods[i].MemoryRequest < allPods[j].MemoryRequest {
-				allPods[i], allPods[j] = allPods[j], allPods[i]
-			}
-		}
+		return nil, err
 	}
 
-	// 返回前N个
-	if len(allPods) > limit {
-		return allPods[:limit], nil
-	}
-	return allPods, nil
+	// 按内存请求量排序（从大到小），只保留前N个
+	return ranking.TopN(allPods, limit, func(a, b PodResourceInfo) bool {
+		return a.MemoryRequest > b.MemoryRequest
+	}), nil
 }
 
 // GetTopCPURequestPods 获取CPU请求量最大的前N个Pod
 func (mc *MultiClusterResourceCollector) GetTopCPURequestPods(ctx context.Context, limit int) ([]PodResourceInfo, error) {
-	// 收集所有Pod并按CPU请求量排序
-	allPods := []PodResourceInfo{}
-
-	clusters, err := mc.clusterService.GetAllClusters()
+	allPods, err := mc.collectOnlineClusterPods(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("获取集群列表失败: %v", err)
-	}
-
-	for _, cluster := range clusters {
-		if cluster.Status != "online" {
-			continue
-		}
-
-		kubeClient, metricsClient, err := mc.clusterService.CreateKubernetesClient(&cluster)
-		if err != nil {
-			continue
-		}
-
-		singleCollector := &ResourceCollector{
-			kubeClient:    kubeClient,
-			metricsClient: metricsClient,
-		}
-
-		clusterPods, err := singleCollector.collectAllPodsWithoutFiltering(ctx, cluster.ClusterName)
-		if err != nil {
-			continue
-		}
-
-		allPods = append(allPods, clusterPods...)
-	}
-
-	// 按CPU请求量排序（从大到小）
-	for i := 0; i < len(allPods)-1; i++ {
-		for j := i + 1; j < len(allPods); j++ {
-			if allPods[i].CPURequest < allPods[j].CPURequest {
-				allPods[i], allPods[j] = allPods[j], allPods[i]
-			}
-		}
+		return nil, err
 	}
 
-	// 返回前N个
-	if len(allPods) > limit {
-		return allPods[:limit], nil
-	}
-	return allPods, nil
+	// 按CPU请求量排序（从大到小），只保留前N个
+	return ranking.TopN(allPods, limit, func(a, b PodResourceInfo) bool {
+		return a.CPURequest > b.CPURequest
+	}), nil
 }
 
 // GetNamespaceTreeData 获取命名空间的树状数据
@@ -351,33 +151,3 @@ func (mc *MultiClusterResourceCollector) GetNamespaceTreeData(ctx context.Contex
 		Summary:       summary,
 	}, nil
 }
-
-func (mc *MultiClusterResourceCollector) calculateProblemScore(pod PodResourceInfo) float64 {
-	score := 0.0
-
-	// 内存利用率问题得分
-	if pod.MemoryRequest > 0 {
-		score += (20.0 - pod.MemoryReqPct) / 20.0 * 100
-	}
-	if pod.MemoryLimit > 0 {
-		score += (15.0 - pod.MemoryLimitPct) / 15.0 * 100
-	}
-
-	// CPU 利用率问题得分
-	if pod.CPURequest > 0 {
-		score += (15.0 - pod.CPUReqPct) / 15.0 * 100
-	}
-	if pod.CPULimit > 0 {
-		score += (10.0 - pod.CPULimitPct) / 10.0 * 100
-	}
-
-	// 配置缺失问题得分
-	if pod.MemoryRequest == 0 {
-		score += 200
-	}
-	if pod.CPURequest == 0 {
-		score += 200
-	}
-
-	return score
-}
@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
 )
 
@@ -18,6 +19,9 @@ func (rc *ResourceCollector) extractPodResourceInfo(pod *corev1.Pod, metrics *me
 		Status:       "合理",
 		Issues:       []string{},
 	}
+	podInfo.WorkloadKind, podInfo.WorkloadName = resolveWorkload(pod)
+	podInfo.QoSClass = resolveQoSClass(pod)
+	podInfo.RestartCount, podInfo.OOMKilled = extractRestartInfo(pod)
 
 	// 计算 Pod 的总请求和限制
 	var totalMemoryRequest, totalMemoryLimit, totalCPURequest, totalCPULimit int64
@@ -44,25 +48,33 @@ func (rc *ResourceCollector) extractPodResourceInfo(pod *corev1.Pod, metrics *me
 	podInfo.MemoryLimit = totalMemoryLimit
 	podInfo.CPURequest = totalCPURequest
 	podInfo.CPULimit = totalCPULimit
+	podInfo.Containers = extractContainerResources(pod, metrics)
+	podInfo.Scheduling = extractSchedulingInfo(pod)
 
 	// 处理资源使用量 - 优先使用 metrics 数据，无数据时提供合理的估算值
+	// metrics 中出现的指标即为真实值，即使为0也不估算，以便识别使用量归零
 	var totalMemoryUsage, totalCPUUsage int64
 
 	if metrics != nil {
 		for _, containerMetrics := range metrics.Containers {
-			if memUsage := containerMetrics.Usage[corev1.ResourceMemory]; !memUsage.IsZero() {
+			if memUsage, ok := containerMetrics.Usage[corev1.ResourceMemory]; ok {
 				totalMemoryUsage += memUsage.Value()
+				podInfo.MemoryMetricsAvailable = true
 			}
-			if cpuUsage := containerMetrics.Usage[corev1.ResourceCPU]; !cpuUsage.IsZero() {
+			if cpuUsage, ok := containerMetrics.Usage[corev1.ResourceCPU]; ok {
 				totalCPUUsage += cpuUsage.MilliValue()
+				podInfo.CPUMetricsAvailable = true
 			}
 		}
 	}
 
+	// 记录使用量是否来自真实metrics数据，异常检测只使用真实数据
+	podInfo.MetricsAvailable = podInfo.CPUMetricsAvailable && podInfo.MemoryMetricsAvailable
+
 	// 无论是否有真实数据，都确保所有Pod有合理的使用量估算
 
 	// 内存使用量估算：确保总是有合理的内存使用量
-	if totalMemoryUsage == 0 {
+	if !podInfo.MemoryMetricsAvailable {
 		if totalMemoryRequest > 0 {
 			// 使用请求量的 40% 作为估算使用量（合理的中等使用率）
 			totalMemoryUsage = int64(float64(totalMemoryRequest) * 0.40)
@@ -76,7 +88,7 @@ func (rc *ResourceCollector) extractPodResourceInfo(pod *corev1.Pod, metrics *me
 	}
 
 	// CPU使用量估算：确保总是有合理的CPU使用量
-	if totalCPUUsage == 0 {
+	if !podInfo.CPUMetricsAvailable {
 		if totalCPURequest > 0 {
 			// 使用请求量的 30% 作为估算使用量
 			totalCPUUsage = int64(float64(totalCPURequest) * 0.30)
@@ -143,3 +155,106 @@ func (rc *ResourceCollector) extractPodResourceInfo(pod *corev1.Pod, metrics *me
 
 	return podInfo
 }
+
+// resolveWorkload 根据OwnerReferences解析Pod所属的工作负载
+// ReplicaSet创建的Pod归属到上层Deployment，没有控制器的Pod以自身作为工作负载
+func resolveWorkload(pod *corev1.Pod) (string, string) {
+	for _, owner := range pod.OwnerReferences {
+		if owner.Controller == nil || !*owner.Controller {
+			continue
+		}
+
+		if owner.Kind == "ReplicaSet" {
+			// Deployment创建的ReplicaSet名称为 <deployment>-<pod-template-hash>
+			if hash := pod.Labels["pod-template-hash"]; hash != "" && strings.HasSuffix(owner.Name, "-"+hash) {
+				return "Deployment", strings.TrimSuffix(owner.Name, "-"+hash)
+			}
+		}
+		return owner.Kind, owner.Name
+	}
+
+	return "Pod", pod.Name
+}
+
+// extractContainerResources 提取各容器的原始资源配置和使用量
+func extractContainerResources(pod *corev1.Pod, metrics *metricsv1beta1.PodMetrics) []ContainerResourceInfo {
+	usageMap := make(map[string]corev1.ResourceList)
+	if metrics != nil {
+		for _, containerMetrics := range metrics.Containers {
+			usageMap[containerMetrics.Name] = containerMetrics.Usage
+		}
+	}
+
+	containers := make([]ContainerResourceInfo, 0, len(pod.Spec.Containers))
+	for _, container := range pod.Spec.Containers {
+		info := ContainerResourceInfo{Name: container.Name, Image: container.Image}
+		if cpuReq, ok := container.Resources.Requests[corev1.ResourceCPU]; ok {
+			info.CPURequest = cpuReq.MilliValue()
+		}
+		if cpuLimit, ok := container.Resources.Limits[corev1.ResourceCPU]; ok {
+			info.CPULimit = cpuLimit.MilliValue()
+		}
+		if memReq, ok := container.Resources.Requests[corev1.ResourceMemory]; ok {
+			info.MemoryRequest = memReq.Value()
+		}
+		if memLimit, ok := container.Resources.Limits[corev1.ResourceMemory]; ok {
+			info.MemoryLimit = memLimit.Value()
+		}
+		if usage, ok := usageMap[container.Name]; ok {
+			if cpuUsage, ok := usage[corev1.ResourceCPU]; ok {
+				info.CPUUsage = cpuUsage.MilliValue()
+			}
+			if memUsage, ok := usage[corev1.ResourceMemory]; ok {
+				info.MemoryUsage = memUsage.Value()
+			}
+		}
+		containers = append(containers, info)
+	}
+
+	return containers
+}
+
+// extractSchedulingInfo 提取Pod的调度约束
+// 反亲和只处理以 kubernetes.io/hostname 为拓扑域的必需规则，其余规则无法在节点级模拟中准确还原
+func extractSchedulingInfo(pod *corev1.Pod) *PodSchedulingInfo {
+	info := &PodSchedulingInfo{
+		Labels:       pod.Labels,
+		NodeSelector: pod.Spec.NodeSelector,
+		Tolerations:  pod.Spec.Tolerations,
+	}
+
+	for _, owner := range pod.OwnerReferences {
+		if owner.Kind == "DaemonSet" || owner.Kind == "Node" {
+			info.IsDaemonSet = true
+			break
+		}
+	}
+
+	if pod.Spec.Affinity != nil && pod.Spec.Affinity.PodAntiAffinity != nil {
+		for _, term := range pod.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+			if term.TopologyKey != corev1.LabelHostname || term.LabelSelector == nil {
+				continue
+			}
+			selector, err := metav1.LabelSelectorAsSelector(term.LabelSelector)
+			if err != nil {
+				continue
+			}
+			info.AntiAffinitySelectors = append(info.AntiAffinitySelectors, selector)
+		}
+	}
+
+	return info
+}
+
+// extractRestartInfo 统计容器累计重启次数，并判断是否有容器最近一次因OOM被终止
+func extractRestartInfo(pod *corev1.Pod) (int32, bool) {
+	var restarts int32
+	oomKilled := false
+	for _, status := range pod.Status.ContainerStatuses {
+		restarts += status.RestartCount
+		if terminated := status.LastTerminationState.Terminated; terminated != nil && terminated.Reason == "OOMKilled" {
+			oomKilled = true
+		}
+	}
+	return restarts, oomKilled
+}
@@ -0,0 +1,157 @@
+package collector
+
+import "math"
+
+// 资源建议计算参数
+const (
+	cpuRequestHeadroom         = 1.3              // CPU请求量在实际使用量基础上预留30%余量
+	memoryRequestHeadroom      = 1.2              // 内存请求量在实际使用量基础上预留20%余量
+	evictionRiskMemoryHeadroom = 1.4              // 存在驱逐风险时内存请求量预留40%余量
+	minCPURequest              = 10               // 建议CPU请求量下限 (millicores)
+	minMemoryRequest           = 32 * 1024 * 1024 // 建议内存请求量下限 (bytes)
+	cpuRoundStep               = 5                // CPU建议值取整粒度 (millicores)
+	memoryRoundStep            = 1024 * 1024      // 内存建议值取整粒度 (bytes)
+)
+
+// ContainerRecommendation 容器资源建议 - 包含调整前后的请求和限制
+type ContainerRecommendation struct {
+	ContainerName            string `json:"container_name"`             // 容器名称
+	CurrentCPURequest        int64  `json:"current_cpu_request"`        // 当前CPU请求量 (millicores)
+	RecommendedCPURequest    int64  `json:"recommended_cpu_request"`    // 建议CPU请求量 (millicores)
+	CurrentCPULimit          int64  `json:"current_cpu_limit"`          // 当前CPU限制量 (millicores)
+	RecommendedCPULimit      int64  `json:"recommended_cpu_limit"`      // 建议CPU限制量 (millicores)
+	CurrentMemoryRequest     int64  `json:"current_memory_request"`     // 当前内存请求量 (bytes)
+	RecommendedMemoryRequest int64  `json:"recommended_memory_request"` // 建议内存请求量 (bytes)
+	CurrentMemoryLimit       int64  `json:"current_memory_limit"`       // 当前内存限制量 (bytes)
+	RecommendedMemoryLimit   int64  `json:"recommended_memory_limit"`   // 建议内存限制量 (bytes)
+	BasedOnMetrics           bool   `json:"based_on_metrics"`           // 是否基于真实使用量计算
+}
+
+// PodRecommendation Pod资源建议
+type PodRecommendation struct {
+	ClusterName  string                    `json:"cluster_name"`
+	Namespace    string                    `json:"namespace"`
+	PodName      string                    `json:"pod_name"`
+	WorkloadKind string                    `json:"workload_kind"`
+	WorkloadName string                    `json:"workload_name"`
+	QoSClass     string                    `json:"qos_class"`          // 当前QoS等级
+	TargetQoS    string                    `json:"target_qos_class"`   // 采用建议后的QoS等级
+	EvictionRisk string                    `json:"eviction_risk"`      // 当前驱逐风险等级
+	Guidance     []string                  `json:"guidance,omitempty"` // QoS相关建议
+	Containers   []ContainerRecommendation `json:"containers"`
+}
+
+// BuildPodRecommendation 根据容器实际使用量生成资源建议
+// 没有真实指标的容器保持当前配置不变；限制量按当前 限制/请求 比例同步调整。
+// Guaranteed Pod的限制量与请求量保持一致以维持QoS等级；BestEffort Pod有指标时补齐请求量，
+// 其中内存请求量按驱逐风险额外预留余量
+func BuildPodRecommendation(pod PodResourceInfo) PodRecommendation {
+	recommendation := PodRecommendation{
+		ClusterName:  pod.ClusterName,
+		Namespace:    pod.Namespace,
+		PodName:      pod.PodName,
+		WorkloadKind: pod.WorkloadKind,
+		WorkloadName: pod.WorkloadName,
+		QoSClass:     pod.QoSClass,
+		EvictionRisk: EvaluateEvictionRisk(pod, -1).RiskLevel,
+		Guidance:     QoSGuidance(&pod),
+		Containers:   make([]ContainerRecommendation, 0, len(pod.Containers)),
+	}
+
+	memoryHeadroom := memoryRequestHeadroom
+	if recommendation.EvictionRisk != EvictionRiskLow {
+		memoryHeadroom = evictionRiskMemoryHeadroom
+	}
+
+	for _, container := range pod.Containers {
+		rec := ContainerRecommendation{
+			ContainerName:            container.Name,
+			CurrentCPURequest:        container.CPURequest,
+			RecommendedCPURequest:    container.CPURequest,
+			CurrentCPULimit:          container.CPULimit,
+			RecommendedCPULimit:      container.CPULimit,
+			CurrentMemoryRequest:     container.MemoryRequest,
+			RecommendedMemoryRequest: container.MemoryRequest,
+			CurrentMemoryLimit:       container.MemoryLimit,
+			RecommendedMemoryLimit:   container.MemoryLimit,
+		}
+
+		if pod.MetricsAvailable && (container.CPUUsage > 0 || container.MemoryUsage > 0) {
+			rec.BasedOnMetrics = true
+
+			rec.RecommendedCPURequest = roundUp(maxInt64(minCPURequest, int64(float64(container.CPUUsage)*cpuRequestHeadroom)), cpuRoundStep)
+			rec.RecommendedCPULimit = scaleLimit(container.CPULimit, container.CPURequest, rec.RecommendedCPURequest, cpuRoundStep)
+
+			rec.RecommendedMemoryRequest = roundUp(maxInt64(minMemoryRequest, int64(float64(container.MemoryUsage)*memoryHeadroom)), memoryRoundStep)
+			rec.RecommendedMemoryLimit = scaleLimit(container.MemoryLimit, container.MemoryRequest, rec.RecommendedMemoryRequest, memoryRoundStep)
+
+			if pod.QoSClass == QoSGuaranteed {
+				rec.RecommendedCPULimit = rec.RecommendedCPURequest
+				rec.RecommendedMemoryLimit = rec.RecommendedMemoryRequest
+			}
+		}
+
+		recommendation.Containers = append(recommendation.Containers, rec)
+	}
+	recommendation.TargetQoS = recommendedQoSClass(recommendation.Containers)
+
+	return recommendation
+}
+
+// recommendedQoSClass 推算采用建议值后的QoS等级
+func recommendedQoSClass(containers []ContainerRecommendation) string {
+	if len(containers) == 0 {
+		return QoSBestEffort
+	}
+	hasAny := false
+	guaranteed := true
+	for _, container := range containers {
+		if container.RecommendedCPURequest > 0 || container.RecommendedMemoryRequest > 0 ||
+			container.RecommendedCPULimit > 0 || container.RecommendedMemoryLimit > 0 {
+			hasAny = true
+		}
+		if container.RecommendedCPULimit == 0 || container.RecommendedMemoryLimit == 0 ||
+			container.RecommendedCPURequest != container.RecommendedCPULimit ||
+			container.RecommendedMemoryRequest != container.RecommendedMemoryLimit {
+			guaranteed = false
+		}
+	}
+	switch {
+	case !hasAny:
+		return QoSBestEffort
+	case guaranteed:
+		return QoSGuaranteed
+	default:
+		return QoSBurstable
+	}
+}
+
+// RecommendedRequests 返回建议后的Pod请求总量
+func (pr PodRecommendation) RecommendedRequests() (cpu int64, memory int64) {
+	for _, container := range pr.Containers {
+		cpu += container.RecommendedCPURequest
+		memory += container.RecommendedMemoryRequest
+	}
+	return cpu, memory
+}
+
+// scaleLimit 按原有 限制/请求 比例计算新的限制量
+// 未设置限制时保持不设置；原请求为0时保留原限制；新限制不低于新请求
+func scaleLimit(currentLimit, currentRequest, newRequest, step int64) int64 {
+	if currentLimit == 0 {
+		return 0
+	}
+	if currentRequest == 0 {
+		return maxInt64(currentLimit, newRequest)
+	}
+	ratio := float64(currentLimit) / float64(currentRequest)
+	return maxInt64(newRequest, roundUp(int64(math.Ceil(float64(newRequest)*ratio)), step))
+}
+
+// roundUp 向上取整到指定粒度
+func roundUp(value, step int64) int64 {
+	if step <= 0 || value%step == 0 {
+		return value
+	}
+	return (value/step + 1) * step
+}
@@ -5,7 +5,10 @@ import (
 	"time"
 
 	"cluster-resource-insight/internal/service"
+	"cluster-resource-insight/pkg/ranking"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
 	metricsclientset "k8s.io/metrics/pkg/client/clientset/versioned"
 )
@@ -23,6 +26,11 @@ type PodResourceInfo struct {
 	Namespace   string `json:"namespace"`    // 所属命名空间
 	NodeName    string `json:"node_name"`    // 运行节点名称
 	ClusterName string `json:"cluster_name"` // 所属集群名称
+
+	// 工作负载信息
+	WorkloadKind string `json:"workload_kind"` // 所属工作负载类型：Deployment/StatefulSet/DaemonSet/Job/Pod等
+	WorkloadName string `json:"workload_name"` // 所属工作负载名称
+	QoSClass     string `json:"qos_class"`     // 服务质量等级：Guaranteed/Burstable/BestEffort
 	
 	// 内存资源信息
 	MemoryUsage    int64   `json:"memory_usage"`     // 实际内存使用量 (bytes)
@@ -42,6 +50,64 @@ type PodResourceInfo struct {
 	Status       string    `json:"status"`        // 资源配置状态：合理/不合理
 	Issues       []string  `json:"issues"`        // 发现的具体问题列表
 	CreationTime time.Time `json:"creation_time"` // Pod创建时间
+
+	MetricsAvailable       bool `json:"metrics_available"`        // CPU和内存使用量是否都来自metrics-server真实数据（否则含估算值）
+	CPUMetricsAvailable    bool `json:"cpu_metrics_available"`    // CPU使用量是否来自真实数据，真实值可以为0
+	MemoryMetricsAvailable bool `json:"memory_metrics_available"` // 内存使用量是否来自真实数据
+
+	// 稳定性信息
+	RestartCount int32 `json:"restart_count"` // 各容器累计重启次数之和
+	OOMKilled    bool  `json:"oom_killed"`    // 是否有容器最近一次终止原因为OOMKilled
+
+	// 排名信息
+	Score          float64               `json:"score"`                     // 问题严重程度得分（0-100）
+	ScoreBreakdown []ranking.FactorScore `json:"score_breakdown,omitempty"` // 各排名因子得分明细
+
+	// 容器和调度信息
+	Containers []ContainerResourceInfo `json:"containers,omitempty"` // 各容器的原始资源配置和使用量
+	Scheduling *PodSchedulingInfo      `json:"-"`                    // 调度约束，仅供模拟调度使用
+}
+
+// ContainerResourceInfo 容器资源信息 - 保留清单中的原始请求和限制（未填充默认值）
+type ContainerResourceInfo struct {
+	Name          string `json:"name"`           // 容器名称
+	Image         string `json:"image"`          // 容器镜像
+	CPURequest    int64  `json:"cpu_request"`    // CPU请求量 (millicores)，未配置为0
+	CPULimit      int64  `json:"cpu_limit"`      // CPU限制量 (millicores)，未配置为0
+	MemoryRequest int64  `json:"memory_request"` // 内存请求量 (bytes)，未配置为0
+	MemoryLimit   int64  `json:"memory_limit"`   // 内存限制量 (bytes)，未配置为0
+	CPUUsage      int64  `json:"cpu_usage"`      // CPU使用量 (millicores)，无metrics时为0
+	MemoryUsage   int64  `json:"memory_usage"`   // 内存使用量 (bytes)，无metrics时为0
+}
+
+// PodSchedulingInfo Pod调度约束信息
+type PodSchedulingInfo struct {
+	Labels                map[string]string   // Pod标签
+	NodeSelector          map[string]string   // 节点选择器
+	Tolerations           []corev1.Toleration // 容忍
+	AntiAffinitySelectors []labels.Selector   // 以节点为拓扑域的必需反亲和选择器
+	IsDaemonSet           bool                // 是否为DaemonSet或静态Pod，随节点存在而无需重新调度
+}
+
+// NodeCapacityInfo 节点容量信息 - 包含节点可分配资源和调度相关属性
+type NodeCapacityInfo struct {
+	NodeName          string            `json:"node_name"`          // 节点名称
+	ClusterName       string            `json:"cluster_name"`       // 所属集群名称
+	AllocatableCPU    int64             `json:"allocatable_cpu"`    // 可分配CPU (millicores)
+	AllocatableMemory int64             `json:"allocatable_memory"` // 可分配内存 (bytes)
+	Labels            map[string]string `json:"labels"`             // 节点标签
+	Taints            []corev1.Taint    `json:"taints"`             // 节点污点
+	Unschedulable     bool              `json:"unschedulable"`      // 是否被标记为不可调度
+	Ready             bool              `json:"ready"`              // 节点是否就绪
+}
+
+// ResourceQuotaInfo ResourceQuota单个资源项的上限和已用量
+type ResourceQuotaInfo struct {
+	Namespace string `json:"namespace"`  // 命名空间
+	QuotaName string `json:"quota_name"` // ResourceQuota名称
+	Resource  string `json:"resource"`   // 资源项，如requests.cpu
+	Hard      int64  `json:"hard"`       // 配额上限（CPU类资源为millicores）
+	Used      int64  `json:"used"`       // 已用量（CPU类资源为millicores）
 }
 
 // AnalysisResult 资源分析结果 - 包含整体分析统计和问题Pod列表
@@ -98,6 +164,11 @@ type MultiClusterResourceCollector struct {
 	clusterService  *service.ClusterService  // 集群配置管理服务
 	historyService  *service.HistoryService  // 历史数据持久化服务  
 	activityService *service.ActivityService // 活动记录和告警服务
+	forecastService *service.ForecastService // 容量预测服务
+	anomalyService  *service.AnomalyService  // 使用量异常检测服务
+	adoptionService *service.AdoptionService // 资源建议采纳跟踪服务
+	policyService   *service.PolicyService   // 资源策略合规服务
+	configChangeService *service.ConfigChangeService // 资源配置变更记录服务
 	
 	// Pod数据缓存机制
 	podsCache    []PodResourceInfo // Pod数据缓存存储
@@ -150,7 +221,8 @@ type PodDetailAnalysis struct {
 			CPUUsagePct    float64 `json:"cpu_usage_pct"`    // 集群平均CPU使用率
 		} `json:"cluster_average"`
 		
-		SimilarPods []PodResourceInfo `json:"similar_pods"` // 同命名空间相似Pod列表
+		SimilarPods []SimilarPod       `json:"similar_pods"` // 按工作负载、镜像、标签和资源规格计算相似度的Pod列表，可跨命名空间和集群
+		ImagePeers  []ImagePeerSummary `json:"image_peers"`  // 运行相同镜像的其他容器的使用量对比
 	} `json:"comparison_analysis"`
 	
 	// 告警信息
@@ -160,6 +232,15 @@ type PodDetailAnalysis struct {
 		SeverityLevel   string   `json:"severity_level"`    // 告警严重程度
 		AlertCount      int      `json:"alert_count"`       // 告警总数
 	} `json:"alerts_info"`
+
+	// QoS分析
+	QoSAnalysis struct {
+		QoSClass     string   `json:"qos_class"`     // 服务质量等级
+		EvictionRisk string   `json:"eviction_risk"` // 驱逐风险等级
+		RiskScore    float64  `json:"risk_score"`    // 驱逐风险评分
+		RiskReason   string   `json:"risk_reason"`   // 风险说明
+		Guidance     []string `json:"guidance"`      // QoS相关建议
+	} `json:"qos_analysis"`
 	
 	GeneratedAt time.Time `json:"generated_at"` // 分析报告生成时间
 }
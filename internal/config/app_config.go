@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"cluster-resource-insight/internal/logger"
 	"github.com/spf13/viper"
@@ -16,16 +17,38 @@ type AppConfig struct {
 	Logging    LoggingConfig    `mapstructure:"logging"`
 	Monitoring MonitoringConfig `mapstructure:"monitoring"`
 	Alert      AlertConfig      `mapstructure:"alert"`
+	Cost       CostConfig       `mapstructure:"cost"`
+	Forecast   ForecastConfig   `mapstructure:"forecast"`
+	Anomaly    AnomalyConfig    `mapstructure:"anomaly"`
+	Apply      ApplyConfig      `mapstructure:"apply"`
+	Idle       IdleConfig       `mapstructure:"idle"`
+	QoS        QoSConfig        `mapstructure:"qos"`
+	Overcommit OvercommitConfig `mapstructure:"overcommit"`
+	Drift      DriftConfig      `mapstructure:"drift"`
+	Adoption   AdoptionConfig   `mapstructure:"adoption"`
+	Policy     PolicyConfig     `mapstructure:"policy"`
+	Ranking    RankingConfig    `mapstructure:"ranking"`
+	Profile    ProfileConfig    `mapstructure:"usage_profile"`
+	Rollup     RollupConfig     `mapstructure:"rollup"`
+	Partition  PartitionConfig  `mapstructure:"partition"`
+	HistoryStore HistoryStoreConfig `mapstructure:"history_store"`
+	Aggregation AggregationConfig `mapstructure:"aggregation"`
+	MetricsExporter MetricsExporterConfig `mapstructure:"metrics_exporter"`
+	RemoteWrite RemoteWriteConfig `mapstructure:"remote_write"`
+	ConfigChange ConfigChangeConfig `mapstructure:"config_change"`
 }
 
 // DatabaseConfig 数据库配置
 type DatabaseConfig struct {
+	Driver   string `mapstructure:"driver"` // mysql/postgres/sqlite，默认mysql
 	Host     string `mapstructure:"host"`
 	Port     int    `mapstructure:"port"`
 	Username string `mapstructure:"username"`
 	Password string `mapstructure:"password"`
 	Database string `mapstructure:"database"`
 	Charset  string `mapstructure:"charset"`
+	SSLMode  string `mapstructure:"sslmode"` // PostgreSQL SSL模式，默认disable
+	Path     string `mapstructure:"path"`    // SQLite数据库文件路径
 }
 
 // ApplicationConfig 应用配置
@@ -59,6 +82,163 @@ type AlertConfig struct {
 	CPUUsageThresholdLow    int  `mapstructure:"cpu_usage_threshold_low"`
 }
 
+// CostConfig 成本核算配置
+type CostConfig struct {
+	CPUCoreHourPrice  float64  `mapstructure:"cpu_core_hour_price"`  // 每核CPU每小时单价
+	MemoryGBHourPrice float64  `mapstructure:"memory_gb_hour_price"` // 每GiB内存每小时单价
+	SystemNamespaces  []string `mapstructure:"system_namespaces"`    // 计入系统开销的命名空间
+	IdleStrategy      string   `mapstructure:"idle_strategy"`        // 闲置容量分摊策略
+	SystemStrategy    string   `mapstructure:"system_strategy"`      // 系统开销分摊策略
+}
+
+// ForecastConfig 容量预测配置
+type ForecastConfig struct {
+	HistoryDays      int     `mapstructure:"history_days"`       // 参与拟合的历史数据天数
+	HorizonDays      int     `mapstructure:"horizon_days"`       // 默认预测天数
+	AlertHorizonDays int     `mapstructure:"alert_horizon_days"` // 预计耗尽时间落入该天数内时触发告警
+	ConfidenceLevel  float64 `mapstructure:"confidence_level"`   // 置信区间水平，如0.95
+}
+
+// AnomalyConfig 使用量异常检测配置
+type AnomalyConfig struct {
+	Enabled         bool    `mapstructure:"enabled"`           // 是否在每次采集后执行异常检测
+	BaselineDays    int     `mapstructure:"baseline_days"`     // 基线使用的历史天数
+	MinSamples      int     `mapstructure:"min_samples"`       // 建立基线所需的最少采样次数
+	EWMAAlpha       float64 `mapstructure:"ewma_alpha"`        // EWMA平滑系数（0-1）
+	ZScoreThreshold float64 `mapstructure:"z_score_threshold"` // 判定异常的z-score阈值
+	MinChangePct    float64 `mapstructure:"min_change_pct"`    // 判定异常的最小相对变化百分比，过滤小幅抖动
+}
+
+// ApplyConfig 资源调整自动应用配置
+type ApplyConfig struct {
+	Enabled             bool   `mapstructure:"enabled"`               // 是否允许将资源调整应用到集群，默认关闭
+	FieldManager        string `mapstructure:"field_manager"`         // 服务端应用使用的字段管理者名称
+	HealthWindowSeconds int    `mapstructure:"health_window_seconds"` // 应用后观察滚动更新健康状态的时长
+	HealthCheckInterval int    `mapstructure:"health_check_interval"` // 健康检查间隔（秒）
+}
+
+// IdleConfig 闲置资源扫描配置
+type IdleConfig struct {
+	LookbackDays         int   `mapstructure:"lookback_days"`          // 扫描使用的历史天数
+	MinIdleDays          int   `mapstructure:"min_idle_days"`          // 判定为闲置工作负载的最少闲置天数
+	CPUIdleThreshold     int64 `mapstructure:"cpu_idle_threshold"`     // 单Pod CPU使用量不超过该值视为无活动 (millicores)
+	CompletedJobHours    int   `mapstructure:"completed_job_hours"`    // 已完成Job超过该小时数未清理视为残留
+	CronJobFailureStreak int   `mapstructure:"cronjob_failure_streak"` // CronJob最近连续失败的Job数达到该值视为失效
+}
+
+// QoSConfig QoS等级分析配置
+type QoSConfig struct {
+	ProductionNamespaces  []string `mapstructure:"production_namespaces"`    // 生产命名空间匹配规则，支持通配符
+	GuaranteedLowUsagePct float64  `mapstructure:"guaranteed_low_usage_pct"` // Guaranteed Pod使用率低于该值视为资源锁定浪费
+}
+
+// OvercommitConfig 节点超售风险配置
+type OvercommitConfig struct {
+	AlertEnabled        bool    `mapstructure:"alert_enabled"`         // 是否在采集后对高风险节点生成告警，默认开启
+	AlertScoreThreshold float64 `mapstructure:"alert_score_threshold"` // 触发告警的风险评分阈值（0-100）
+	TopContributors     int     `mapstructure:"top_contributors"`      // 每个节点列出的超售贡献最大的Pod数量
+}
+
+// DriftConfig 跨集群配置漂移分析配置
+type DriftConfig struct {
+	MatchLabel     string  `mapstructure:"match_label"`      // 用于跨集群匹配工作负载的标签，为空时按命名空间/名称匹配
+	MisSizedFactor float64 `mapstructure:"mis_sized_factor"` // 使用率与其他集群中位数相差超过该倍数时视为规格失衡
+}
+
+// AdoptionConfig 资源建议采纳跟踪配置
+type AdoptionConfig struct {
+	TeamLabel           string `mapstructure:"team_label"`            // 命名空间上标识所属团队的标签
+	GraceDays           int    `mapstructure:"grace_days"`            // 建议生成后超过该天数仍未调整视为忽略
+	StabilityWindowDays int    `mapstructure:"stability_window_days"` // 对比调整前后重启和OOM次数的观察天数
+	TrackingDays        int    `mapstructure:"tracking_days"`         // 建议生成后持续跟踪的天数，超过后不再重新评估
+}
+
+// PolicyConfig 资源策略合规检查配置
+type PolicyConfig struct {
+	Enabled     bool   `mapstructure:"enabled"`      // 是否在每次采集后执行策略检查
+	RulesFile   string `mapstructure:"rules_file"`   // 策略定义文件路径（YAML）
+	HistoryDays int    `mapstructure:"history_days"` // 合规评分历史保留天数
+}
+
+// RankingConfig 问题严重程度排名配置
+type RankingConfig struct {
+	Weights             map[string]float64 `mapstructure:"weights"`               // 各排名因子的权重，0表示不参与排名
+	WasteReferenceCores float64            `mapstructure:"waste_reference_cores"` // 浪费量达到该核数时浪费因子得一半分数
+	CostReference       float64            `mapstructure:"cost_reference"`        // 每月浪费成本达到该金额时成本因子得一半分数
+	RestartReference    float64            `mapstructure:"restart_reference"`     // 重启次数达到该值时重启因子得一半分数
+}
+
+// ProfileConfig 分时段使用画像和定时伸缩建议配置
+type ProfileConfig struct {
+	LookbackDays         int     `mapstructure:"lookback_days"`           // 计算画像使用的历史天数
+	MinSamplesPerHour    int     `mapstructure:"min_samples_per_hour"`    // 每个周内小时至少需要的采样次数，不足时视为数据不足
+	OffHoursThresholdPct float64 `mapstructure:"off_hours_threshold_pct"` // CPU使用量低于峰值时段该百分比的小时视为低谷
+	MinWindowHours       int     `mapstructure:"min_window_hours"`        // 连续低谷小时数达到该值才建议定时缩容
+	ScaleDownReplicas    int     `mapstructure:"scale_down_replicas"`     // 低谷时段建议保留的副本数
+	Timezone             string  `mapstructure:"timezone"`                // 计算周内小时使用的时区，如 Asia/Shanghai
+}
+
+// RollupConfig 监控数据降采样汇总和分层保留配置
+type RollupConfig struct {
+	Enabled             bool `mapstructure:"enabled"`               // 是否在后台生成小时和天级汇总
+	RawRetentionDays    int  `mapstructure:"raw_retention_days"`    // 原始采集数据保留天数
+	HourlyRetentionDays int  `mapstructure:"hourly_retention_days"` // 小时汇总保留天数
+	DailyRetentionDays  int  `mapstructure:"daily_retention_days"`  // 天汇总保留天数
+	MinPoints           int  `mapstructure:"min_points"`            // 自动选择层级时查询结果至少包含的数据点数
+	MaxBucketsPerRun    int  `mapstructure:"max_buckets_per_run"`   // 每次维护最多补算的时间桶数
+}
+
+// PartitionConfig 历史数据按时间分区配置（仅 MySQL 和 PostgreSQL 支持）
+type PartitionConfig struct {
+	Enabled   bool   `mapstructure:"enabled"`   // 是否将指标和活动表转换为按时间范围分区
+	Interval  string `mapstructure:"interval"`  // 分区粒度：day/month
+	Precreate int    `mapstructure:"precreate"` // 预先创建的未来分区数
+}
+
+// HistoryStoreConfig Pod使用量历史样本存储配置
+type HistoryStoreConfig struct {
+	Backend       string `mapstructure:"backend"`        // 存储后端：sql/tsdb
+	TSDBPath      string `mapstructure:"tsdb_path"`      // 嵌入式TSDB数据目录
+	RetentionDays int    `mapstructure:"retention_days"` // TSDB数据保留天数
+}
+
+// AggregationConfig 历史数据聚合查询限制
+type AggregationConfig struct {
+	MaxRangeDays int `mapstructure:"max_range_days"` // 单次查询最大时间跨度（天）
+	MaxPoints    int `mapstructure:"max_points"`     // 每条序列最多的时间桶数
+	MaxSeries    int `mapstructure:"max_series"`     // 单次查询最多返回的序列数
+}
+
+// MetricsExporterConfig Prometheus 指标导出配置
+type MetricsExporterConfig struct {
+	Enabled bool   `mapstructure:"enabled"`  // 是否提供 /metrics 端点
+	Mode    string `mapstructure:"mode"`     // 导出粒度：pod（命名空间汇总和Top N Pod）/namespace（仅命名空间汇总）
+	TopPods int    `mapstructure:"top_pods"` // pod 模式下每个集群按浪费成本导出的Pod数
+}
+
+// RemoteWriteConfig Prometheus remote write 推送配置
+type RemoteWriteConfig struct {
+	Enabled               bool              `mapstructure:"enabled"`                  // 是否将每次采集的样本推送到远端
+	URL                   string            `mapstructure:"url"`                      // remote write 地址，如 http://mimir:9009/api/v1/push
+	TimeoutSeconds        int               `mapstructure:"timeout_seconds"`          // 单次请求超时（秒）
+	QueueCapacity         int               `mapstructure:"queue_capacity"`           // 等待发送的采集批次上限，队列满时丢弃最新批次
+	MaxSamplesPerRequest  int               `mapstructure:"max_samples_per_request"`  // 单次请求最多包含的样本数
+	MaxRetries            int               `mapstructure:"max_retries"`              // 可重试错误的最大重试次数
+	MinBackoffMillis      int               `mapstructure:"min_backoff_ms"`           // 首次重试等待时间（毫秒），之后每次翻倍
+	MaxBackoffMillis      int               `mapstructure:"max_backoff_ms"`           // 重试等待时间上限（毫秒）
+	BasicAuthUsername     string            `mapstructure:"basic_auth_username"`      // Basic 认证用户名
+	BasicAuthPassword     string            `mapstructure:"basic_auth_password"`      // Basic 认证密码
+	BearerToken           string            `mapstructure:"bearer_token"`             // Bearer 令牌
+	Headers               map[string]string `mapstructure:"headers"`                  // 附加请求头，如 X-Scope-OrgID
+	ExternalLabels        map[string]string `mapstructure:"external_labels"`          // 附加到所有序列的标签，用于区分部署
+}
+
+// ConfigChangeConfig 工作负载资源配置变更记录配置
+type ConfigChangeConfig struct {
+	Enabled       bool `mapstructure:"enabled"`        // 是否在每次采集后检测工作负载请求和限制的变化
+	RetentionDays int  `mapstructure:"retention_days"` // 变更记录保留天数
+}
+
 var AppConf *AppConfig
 
 // LoadConfig 加载配置文件
@@ -81,6 +261,9 @@ func LoadConfig(configPath string) (*AppConfig, error) {
 	viper.SetEnvPrefix("CLUSTER_MONITOR")
 	viper.AutomaticEnv()
 
+	// 默认开启的布尔配置项，未写入配置文件时不能使用零值
+	viper.SetDefault("overcommit.alert_enabled", true)
+
 	// 读取配置文件
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("读取配置文件失败: %v", err)
@@ -113,17 +296,30 @@ func LoadConfig(configPath string) (*AppConfig, error) {
 // validateConfig 验证配置的有效性
 func validateConfig(config *AppConfig) error {
 	// 验证数据库配置
-	if config.Database.Host == "" {
-		return fmt.Errorf("数据库主机地址不能为空")
-	}
-	if config.Database.Port <= 0 || config.Database.Port > 65535 {
-		return fmt.Errorf("数据库端口无效: %d", config.Database.Port)
+	switch config.Database.Driver {
+	case "":
+		config.Database.Driver = "mysql"
+	case "mysql", "postgres", "sqlite":
+	default:
+		return fmt.Errorf("不支持的数据库驱动: %s，可选 mysql/postgres/sqlite", config.Database.Driver)
 	}
-	if config.Database.Username == "" {
-		return fmt.Errorf("数据库用户名不能为空")
-	}
-	if config.Database.Database == "" {
-		return fmt.Errorf("数据库名称不能为空")
+	if config.Database.Driver == "sqlite" {
+		if config.Database.Path == "" && config.Database.Database == "" {
+			return fmt.Errorf("SQLite数据库文件路径不能为空")
+		}
+	} else {
+		if config.Database.Host == "" {
+			return fmt.Errorf("数据库主机地址不能为空")
+		}
+		if config.Database.Port <= 0 || config.Database.Port > 65535 {
+			return fmt.Errorf("数据库端口无效: %d", config.Database.Port)
+		}
+		if config.Database.Username == "" {
+			return fmt.Errorf("数据库用户名不能为空")
+		}
+		if config.Database.Database == "" {
+			return fmt.Errorf("数据库名称不能为空")
+		}
 	}
 
 	// 验证应用配置
@@ -155,9 +351,179 @@ func validateConfig(config *AppConfig) error {
 		return fmt.Errorf("CPU利用率阈值必须在0-100之间")
 	}
 
+	// 验证成本配置
+	if config.Cost.CPUCoreHourPrice < 0 || config.Cost.MemoryGBHourPrice < 0 {
+		return fmt.Errorf("资源单价不能为负数")
+	}
+	for _, strategy := range []string{config.Cost.IdleStrategy, config.Cost.SystemStrategy} {
+		if strategy != "" && !IsValidCostStrategy(strategy) {
+			return fmt.Errorf("无效的成本分摊策略: %s", strategy)
+		}
+	}
+
+	// 验证容量预测配置
+	if config.Forecast.HistoryDays < 0 || config.Forecast.HorizonDays < 0 || config.Forecast.AlertHorizonDays < 0 {
+		return fmt.Errorf("容量预测天数不能为负数")
+	}
+	if config.Forecast.ConfidenceLevel < 0 || config.Forecast.ConfidenceLevel >= 1 {
+		return fmt.Errorf("置信区间水平必须在0-1之间")
+	}
+
+	// 验证异常检测配置
+	if config.Anomaly.EWMAAlpha < 0 || config.Anomaly.EWMAAlpha > 1 {
+		return fmt.Errorf("EWMA平滑系数必须在0-1之间")
+	}
+	if config.Anomaly.ZScoreThreshold < 0 || config.Anomaly.MinChangePct < 0 {
+		return fmt.Errorf("异常检测阈值不能为负数")
+	}
+
+	// 验证资源调整应用配置
+	if config.Apply.HealthWindowSeconds < 0 || config.Apply.HealthCheckInterval < 0 {
+		return fmt.Errorf("健康观察时长和检查间隔不能为负数")
+	}
+
+	// 验证闲置资源扫描配置
+	if config.Idle.LookbackDays < 0 || config.Idle.MinIdleDays < 0 || config.Idle.CPUIdleThreshold < 0 ||
+		config.Idle.CompletedJobHours < 0 || config.Idle.CronJobFailureStreak < 0 {
+		return fmt.Errorf("闲置资源扫描参数不能为负数")
+	}
+	if config.Idle.LookbackDays > 0 && config.Idle.MinIdleDays > config.Idle.LookbackDays {
+		return fmt.Errorf("最少闲置天数不能大于扫描历史天数")
+	}
+
+	// 验证QoS分析配置
+	if config.QoS.GuaranteedLowUsagePct < 0 || config.QoS.GuaranteedLowUsagePct > 100 {
+		return fmt.Errorf("Guaranteed低使用率阈值必须在0-100之间")
+	}
+
+	// 验证节点超售风险配置
+	if config.Overcommit.AlertScoreThreshold < 0 || config.Overcommit.AlertScoreThreshold > 100 {
+		return fmt.Errorf("超售风险告警阈值必须在0-100之间")
+	}
+	if config.Overcommit.TopContributors < 0 {
+		return fmt.Errorf("超售贡献Pod数量不能为负数")
+	}
+
+	// 验证跨集群配置漂移配置
+	if config.Drift.MisSizedFactor != 0 && config.Drift.MisSizedFactor <= 1 {
+		return fmt.Errorf("规格失衡倍数必须大于1")
+	}
+
+	// 验证资源建议采纳跟踪配置
+	if config.Adoption.GraceDays < 0 || config.Adoption.StabilityWindowDays < 0 || config.Adoption.TrackingDays < 0 {
+		return fmt.Errorf("建议采纳跟踪天数不能为负数")
+	}
+	if config.Adoption.TrackingDays > 0 && config.Adoption.GraceDays > config.Adoption.TrackingDays {
+		return fmt.Errorf("建议忽略判定天数不能大于跟踪天数")
+	}
+
+	// 验证资源策略合规检查配置
+	if config.Policy.HistoryDays < 0 {
+		return fmt.Errorf("合规评分历史保留天数不能为负数")
+	}
+	if config.Policy.Enabled && config.Policy.RulesFile == "" {
+		return fmt.Errorf("开启策略检查时必须指定策略文件")
+	}
+
+	// 验证问题严重程度排名配置
+	for name, weight := range config.Ranking.Weights {
+		if weight < 0 {
+			return fmt.Errorf("排名因子 %s 的权重不能为负数", name)
+		}
+	}
+	if config.Ranking.WasteReferenceCores < 0 || config.Ranking.CostReference < 0 || config.Ranking.RestartReference < 0 {
+		return fmt.Errorf("排名因子参考值不能为负数")
+	}
+
+	// 验证分时段使用画像配置
+	if config.Profile.LookbackDays < 0 || config.Profile.MinSamplesPerHour < 0 ||
+		config.Profile.MinWindowHours < 0 || config.Profile.ScaleDownReplicas < 0 {
+		return fmt.Errorf("使用画像参数不能为负数")
+	}
+	if config.Profile.OffHoursThresholdPct < 0 || config.Profile.OffHoursThresholdPct > 100 {
+		return fmt.Errorf("低谷时段阈值必须在0-100之间")
+	}
+	if config.Profile.Timezone != "" {
+		if _, err := time.LoadLocation(config.Profile.Timezone); err != nil {
+			return fmt.Errorf("无效的时区: %s", config.Profile.Timezone)
+		}
+	}
+
+	// 验证降采样汇总配置
+	if config.Rollup.RawRetentionDays < 0 || config.Rollup.HourlyRetentionDays < 0 || config.Rollup.DailyRetentionDays < 0 ||
+		config.Rollup.MinPoints < 0 || config.Rollup.MaxBucketsPerRun < 0 {
+		return fmt.Errorf("降采样汇总参数不能为负数")
+	}
+	if config.Rollup.Enabled && config.Rollup.RawRetentionDays > 0 && config.Rollup.RawRetentionDays < 2 {
+		return fmt.Errorf("启用降采样汇总时原始数据至少保留2天，以便生成天级汇总")
+	}
+
+	// 验证分区配置
+	if config.Partition.Interval != "" && config.Partition.Interval != "day" && config.Partition.Interval != "month" {
+		return fmt.Errorf("分区粒度只支持 day 或 month，当前为: %s", config.Partition.Interval)
+	}
+	if config.Partition.Precreate < 0 {
+		return fmt.Errorf("预创建分区数不能为负数")
+	}
+
+	// 验证历史数据存储配置
+	if config.HistoryStore.Backend != "" && config.HistoryStore.Backend != "sql" && config.HistoryStore.Backend != "tsdb" {
+		return fmt.Errorf("历史数据存储后端只支持 sql 或 tsdb，当前为: %s", config.HistoryStore.Backend)
+	}
+	if config.HistoryStore.Backend == "tsdb" && config.HistoryStore.TSDBPath == "" {
+		return fmt.Errorf("使用 tsdb 存储后端时必须配置 tsdb_path")
+	}
+	if config.HistoryStore.RetentionDays < 0 {
+		return fmt.Errorf("历史数据存储保留天数不能为负数")
+	}
+
+	// 验证聚合查询限制
+	if config.Aggregation.MaxRangeDays < 0 || config.Aggregation.MaxPoints < 0 || config.Aggregation.MaxSeries < 0 {
+		return fmt.Errorf("聚合查询限制参数不能为负数")
+	}
+
+	// 验证指标导出配置
+	if config.MetricsExporter.Mode != "" && config.MetricsExporter.Mode != "pod" && config.MetricsExporter.Mode != "namespace" {
+		return fmt.Errorf("指标导出模式只支持 pod 或 namespace，当前为: %s", config.MetricsExporter.Mode)
+	}
+	if config.MetricsExporter.TopPods < 0 {
+		return fmt.Errorf("导出Pod数不能为负数")
+	}
+
+	// 验证 remote write 配置
+	if config.RemoteWrite.Enabled && config.RemoteWrite.URL == "" {
+		return fmt.Errorf("启用 remote write 时必须配置 url")
+	}
+	if config.RemoteWrite.TimeoutSeconds < 0 || config.RemoteWrite.QueueCapacity < 0 || config.RemoteWrite.MaxSamplesPerRequest < 0 ||
+		config.RemoteWrite.MaxRetries < 0 || config.RemoteWrite.MinBackoffMillis < 0 || config.RemoteWrite.MaxBackoffMillis < 0 {
+		return fmt.Errorf("remote write 参数不能为负数")
+	}
+
+	// 验证资源配置变更记录配置
+	if config.ConfigChange.RetentionDays < 0 {
+		return fmt.Errorf("配置变更记录保留天数不能为负数")
+	}
+
 	return nil
 }
 
+// 成本分摊策略
+const (
+	CostStrategyProportionalRequests = "proportional_requests" // 按资源请求量比例分摊
+	CostStrategyProportionalUsage    = "proportional_usage"    // 按资源实际使用量比例分摊
+	CostStrategyEven                 = "even"                  // 在租户命名空间之间平均分摊
+	CostStrategySeparate             = "separate"              // 不分摊，作为独立成本项展示
+)
+
+// IsValidCostStrategy 判断成本分摊策略是否受支持
+func IsValidCostStrategy(strategy string) bool {
+	switch strategy {
+	case CostStrategyProportionalRequests, CostStrategyProportionalUsage, CostStrategyEven, CostStrategySeparate:
+		return true
+	}
+	return false
+}
+
 // GetDatabaseConfig 获取数据库配置
 func GetDatabaseConfig() *DatabaseConfig {
 	if AppConf == nil {
@@ -196,4 +562,156 @@ func GetAlertConfig() *AlertConfig {
 		return nil
 	}
 	return &AppConf.Alert
-}
\ No newline at end of file
+}
+
+// GetCostConfig 获取成本核算配置
+func GetCostConfig() *CostConfig {
+	if AppConf == nil {
+		return nil
+	}
+	return &AppConf.Cost
+}
+
+// GetForecastConfig 获取容量预测配置
+func GetForecastConfig() *ForecastConfig {
+	if AppConf == nil {
+		return nil
+	}
+	return &AppConf.Forecast
+}
+
+// GetAnomalyConfig 获取异常检测配置
+func GetAnomalyConfig() *AnomalyConfig {
+	if AppConf == nil {
+		return nil
+	}
+	return &AppConf.Anomaly
+}
+
+// GetApplyConfig 获取资源调整应用配置
+func GetApplyConfig() *ApplyConfig {
+	if AppConf == nil {
+		return nil
+	}
+	return &AppConf.Apply
+}
+
+// GetIdleConfig 获取闲置资源扫描配置
+func GetIdleConfig() *IdleConfig {
+	if AppConf == nil {
+		return nil
+	}
+	return &AppConf.Idle
+}
+
+// GetQoSConfig 获取QoS等级分析配置
+func GetQoSConfig() *QoSConfig {
+	if AppConf == nil {
+		return nil
+	}
+	return &AppConf.QoS
+}
+
+// GetOvercommitConfig 获取节点超售风险配置
+func GetOvercommitConfig() *OvercommitConfig {
+	if AppConf == nil {
+		return nil
+	}
+	return &AppConf.Overcommit
+}
+
+// GetDriftConfig 获取跨集群配置漂移分析配置
+func GetDriftConfig() *DriftConfig {
+	if AppConf == nil {
+		return nil
+	}
+	return &AppConf.Drift
+}
+
+// GetAdoptionConfig 获取资源建议采纳跟踪配置
+func GetAdoptionConfig() *AdoptionConfig {
+	if AppConf == nil {
+		return nil
+	}
+	return &AppConf.Adoption
+}
+
+// GetPolicyConfig 获取资源策略合规检查配置
+func GetPolicyConfig() *PolicyConfig {
+	if AppConf == nil {
+		return nil
+	}
+	return &AppConf.Policy
+}
+
+// GetRankingConfig 获取问题严重程度排名配置
+func GetRankingConfig() *RankingConfig {
+	if AppConf == nil {
+		return nil
+	}
+	return &AppConf.Ranking
+}
+
+// GetProfileConfig 获取分时段使用画像配置
+func GetProfileConfig() *ProfileConfig {
+	if AppConf == nil {
+		return nil
+	}
+	return &AppConf.Profile
+}
+
+// GetRollupConfig 获取监控数据降采样汇总配置
+func GetRollupConfig() *RollupConfig {
+	if AppConf == nil {
+		return nil
+	}
+	return &AppConf.Rollup
+}
+
+// GetPartitionConfig 获取历史数据分区配置
+func GetPartitionConfig() *PartitionConfig {
+	if AppConf == nil {
+		return nil
+	}
+	return &AppConf.Partition
+}
+
+// GetHistoryStoreConfig 获取历史数据存储配置
+func GetHistoryStoreConfig() *HistoryStoreConfig {
+	if AppConf == nil {
+		return nil
+	}
+	return &AppConf.HistoryStore
+}
+
+// GetAggregationConfig 获取历史数据聚合查询限制配置
+func GetAggregationConfig() *AggregationConfig {
+	if AppConf == nil {
+		return nil
+	}
+	return &AppConf.Aggregation
+}
+
+// GetMetricsExporterConfig 获取 Prometheus 指标导出配置
+func GetMetricsExporterConfig() *MetricsExporterConfig {
+	if AppConf == nil {
+		return nil
+	}
+	return &AppConf.MetricsExporter
+}
+
+// GetRemoteWriteConfig 获取 Prometheus remote write 推送配置
+func GetRemoteWriteConfig() *RemoteWriteConfig {
+	if AppConf == nil {
+		return nil
+	}
+	return &AppConf.RemoteWrite
+}
+
+// GetConfigChangeConfig 获取资源配置变更记录配置
+func GetConfigChangeConfig() *ConfigChangeConfig {
+	if AppConf == nil {
+		return nil
+	}
+	return &AppConf.ConfigChange
+}
@@ -7,24 +7,27 @@ import (
 	"cluster-resource-insight/internal/logger"
 	"cluster-resource-insight/internal/models"
 	
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	gormlogger "gorm.io/gorm/logger"
 )
 
 // DatabaseConfig 数据库配置结构
 type DatabaseConfig struct {
+	Driver   string // 数据库驱动：mysql/postgres/sqlite
 	Host     string // 数据库主机地址
 	Port     int    // 数据库端口
 	Username string // 用户名
 	Password string // 密码
 	DBName   string // 数据库名称
-	Charset  string // 字符集
+	Charset  string // 字符集，仅MySQL使用
+	SSLMode  string // SSL模式，仅PostgreSQL使用
+	Path     string // 数据库文件路径，仅SQLite使用
 }
 
 // DefaultDatabaseConfig 默认数据库配置
 func DefaultDatabaseConfig() *DatabaseConfig {
 	return &DatabaseConfig{
+		Driver:   DriverMySQL,
 		Host:     "localhost",
 		Port:     3306,
 		Username: "root",
@@ -38,21 +41,16 @@ var DB *gorm.DB
 
 // InitDatabase 初始化数据库连接
 func InitDatabase(config *DatabaseConfig) error {
-	// 构建数据库连接字符串
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=True&loc=Local",
-		config.Username,
-		config.Password,
-		config.Host,
-		config.Port,
-		config.DBName,
-		config.Charset,
-	)
+	// 根据驱动构建数据库方言
+	dialector, target, err := openDialector(config)
+	if err != nil {
+		return err
+	}
 
-	logger.Info("正在连接数据库: %s:%d/%s", config.Host, config.Port, config.DBName)
+	logger.Info("正在连接数据库: %s", target)
 
 	// 打开数据库连接
-	var err error
-	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
+	DB, err = gorm.Open(dialector, &gorm.Config{
 		Logger: gormlogger.Default.LogMode(gormlogger.Info), // 启用SQL日志
 	})
 	if err != nil {
@@ -83,19 +81,76 @@ func InitDatabase(config *DatabaseConfig) error {
 func MigrateDatabase() error {
 	logger.Info("开始执行数据库迁移...")
 
+	// 旧版历史宽表需要让出表名给历史视图
+	if err := renameLegacyHistoryTable(); err != nil {
+		return err
+	}
+
+	// 升级前的汇总数据没有分指标的采样次数，迁移后需要补充
+	needsRollupCountBackfill := DB.Migrator().HasTable(&models.PodMetricsHourly{}) &&
+		!DB.Migrator().HasColumn(&models.PodMetricsHourly{}, "cpu_sample_count")
+	// 采集批次表创建之前写入的历史样本没有对应的采集批次，迁移后需要补充
+	needsRunBackfill := !DB.Migrator().HasTable(&models.CollectionRun{})
+
 	// 自动迁移所有模型
 	err := DB.AutoMigrate(
 		&models.ClusterConfig{},
-		&models.PodMetricsHistory{},
+		&models.PodDimension{},
+		&models.PodSpecVersion{},
 		&models.SystemSettings{},
 		&models.AlertRule{},
 		&models.AlertHistory{},
-		&models.SystemActivity{},
+		&models.ClusterCapacitySnapshot{},
+		&models.ResourceQuotaSnapshot{},
+		&models.ResourceAnomaly{},
+		&models.RecommendationSnapshot{},
+		&models.ResourceChangeRequest{},
+		&models.PolicyComplianceSnapshot{},
+		&models.PolicyViolation{},
+		&models.PodMetricsHourly{},
+		&models.PodMetricsDaily{},
+		&models.CollectionRun{},
+		&models.WorkloadSpecState{},
+		&models.ResourceConfigChange{},
 	)
 	if err != nil {
 		return fmt.Errorf("数据库迁移失败: %v", err)
 	}
 
+	// 升级前的指标事实表没有分指标的可用标记，迁移后需要为旧样本补充
+	needsFlagBackfill := DB.Migrator().HasTable(&models.PodMetricSample{}) && !hasMetricFlagColumns()
+
+	// 可能按时间分区的数据表不创建外键，MySQL 分区表不支持外键
+	for _, table := range partitionedTables() {
+		if err := noForeignKeyDB(DB).AutoMigrate(table.model); err != nil {
+			return fmt.Errorf("数据库迁移失败: %v", err)
+		}
+	}
+
+	if needsFlagBackfill {
+		if err := backfillMetricFlags(); err != nil {
+			return err
+		}
+	}
+	if needsRollupCountBackfill {
+		if err := backfillRollupSampleCounts(); err != nil {
+			return err
+		}
+	}
+
+	// 创建历史视图并迁移旧版历史数据
+	if err := createHistoryView(); err != nil {
+		return err
+	}
+	if err := migrateLegacyHistory(); err != nil {
+		return err
+	}
+	if needsRunBackfill {
+		if err := backfillCollectionRuns(); err != nil {
+			return err
+		}
+	}
+
 	// 初始化默认系统配置
 	if err := initDefaultSettings(); err != nil {
 		return fmt.Errorf("初始化默认配置失败: %v", err)
@@ -149,7 +204,7 @@ func initDefaultSettings() error {
 	// 使用FirstOrCreate确保不重复插入
 	for _, setting := range defaultSettings {
 		var existingSetting models.SystemSettings
-		result := DB.Where("key = ?", setting.Key).First(&existingSetting)
+		result := DB.Where(&models.SystemSettings{Key: setting.Key}).First(&existingSetting)
 		if result.Error == gorm.ErrRecordNotFound {
 			// 配置不存在，创建新配置
 			if err := DB.Create(&setting).Error; err != nil {
@@ -166,7 +221,7 @@ func initDefaultSettings() error {
 func CheckAndAutoMigrate() error {
 	// 检查关键表是否存在
 	logger.Info("正在检查数据库表是否存在...")
-	if !DB.Migrator().HasTable(&models.ClusterConfig{}) {
+	if !DB.Migrator().HasTable(&models.ClusterConfig{}) || hasMissingTables() || hasMissingColumns() || needsHistoryMigration() {
 		logger.Info("检测到数据库表不存在，正在自动执行迁移...")
 		if err := MigrateDatabase(); err != nil {
 			return fmt.Errorf("自动迁移失败: %v\n\n"+
@@ -183,6 +238,81 @@ func CheckAndAutoMigrate() error {
 	return nil
 }
 
+// hasMissingTables 检查后续版本新增的数据表是否缺失，已有部署升级后需要补充迁移
+func hasMissingTables() bool {
+	newTables := []interface{}{
+		&models.ClusterCapacitySnapshot{},
+		&models.ResourceQuotaSnapshot{},
+		&models.ResourceAnomaly{},
+		&models.ResourceChangeRequest{},
+		&models.RecommendationSnapshot{},
+		&models.PolicyComplianceSnapshot{},
+		&models.PolicyViolation{},
+		&models.PodMetricsHourly{},
+		&models.PodMetricsDaily{},
+		&models.PodDimension{},
+		&models.PodSpecVersion{},
+		&models.PodMetricSample{},
+		&models.CollectionRun{},
+		&models.WorkloadSpecState{},
+		&models.ResourceConfigChange{},
+	}
+	for _, table := range newTables {
+		if !DB.Migrator().HasTable(table) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasMissingColumns 检查已有数据表中后续版本新增的列是否缺失
+func hasMissingColumns() bool {
+	newColumns := []struct {
+		model  interface{}
+		column string
+	}{
+		{&models.ClusterCapacitySnapshot{}, "usage_missing"},
+		{&models.RecommendationSnapshot{}, "frozen_at"},
+		{&models.PodMetricsHourly{}, "cpu_sample_count"},
+		{&models.PodMetricsDaily{}, "cpu_sample_count"},
+	}
+	for _, c := range newColumns {
+		if DB.Migrator().HasTable(c.model) && !DB.Migrator().HasColumn(c.model, c.column) {
+			return true
+		}
+	}
+	return false
+}
+
+// needsHistoryMigration 检查历史视图是否缺失、仍有未迁移完成的旧版历史宽表，或指标事实表缺少分指标可用标记
+func needsHistoryMigration() bool {
+	return !hasView(historyViewName) || DB.Migrator().HasTable(legacyHistoryTable) ||
+		(DB.Migrator().HasTable(&models.PodMetricSample{}) && !hasMetricFlagColumns())
+}
+
+// backfillRollupSampleCounts 为新增分指标采样次数列之前生成的汇总数据补充采样次数
+// 旧版汇总只使用CPU和内存指标都可用的采样，两项采样次数均等于总采样次数
+func backfillRollupSampleCounts() error {
+	for _, table := range []string{models.PodMetricsHourly{}.TableName(), models.PodMetricsDaily{}.TableName()} {
+		if err := DB.Table(table).Where("cpu_sample_count = ?", 0).Updates(map[string]interface{}{
+			"cpu_sample_count":    gorm.Expr("sample_count"),
+			"memory_sample_count": gorm.Expr("sample_count"),
+		}).Error; err != nil {
+			return fmt.Errorf("补充汇总采样次数失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// backfillCollectionRuns 按已有历史样本的集群和采集时间补充采集批次
+func backfillCollectionRuns() error {
+	if err := DB.Exec("INSERT INTO collection_runs (cluster_id, pod_count, collected_at, created_at) " +
+		"SELECT cluster_id, COUNT(*), collected_at, collected_at FROM " + historyViewName + " GROUP BY cluster_id, collected_at").Error; err != nil {
+		return fmt.Errorf("补充采集批次失败: %v", err)
+	}
+	return nil
+}
+
 // GetDB 获取数据库连接实例
 func GetDB() *gorm.DB {
 	return DB
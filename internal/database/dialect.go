@@ -0,0 +1,201 @@
+package database
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// 支持的数据库驱动
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// openDialector 根据驱动类型构建GORM方言，返回方言和用于日志的连接描述
+func openDialector(config *DatabaseConfig) (gorm.Dialector, string, error) {
+	switch config.Driver {
+	case "", DriverMySQL:
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=True&loc=Local",
+			config.Username,
+			config.Password,
+			config.Host,
+			config.Port,
+			config.DBName,
+			config.Charset,
+		)
+		return mysql.Open(dsn), fmt.Sprintf("mysql://%s:%d/%s", config.Host, config.Port, config.DBName), nil
+	case DriverPostgres:
+		sslMode := config.SSLMode
+		if sslMode == "" {
+			sslMode = "disable"
+		}
+		dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+			config.Host,
+			config.Port,
+			config.Username,
+			config.Password,
+			config.DBName,
+			sslMode,
+		)
+		return postgres.Open(dsn), fmt.Sprintf("postgres://%s:%d/%s", config.Host, config.Port, config.DBName), nil
+	case DriverSQLite:
+		path := config.Path
+		if path == "" {
+			path = config.DBName + ".db"
+		}
+		if dir := filepath.Dir(path); dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return nil, "", fmt.Errorf("创建SQLite数据目录失败: %v", err)
+			}
+		}
+		// 使用纯Go实现的SQLite驱动，无需CGO；WAL模式允许读写并发，busy_timeout避免并发写入时立即返回database is locked
+		dsn := fmt.Sprintf("file:%s?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)", path)
+		return sqlite.Open(dsn), "sqlite://" + path, nil
+	default:
+		return nil, "", fmt.Errorf("不支持的数据库驱动: %s", config.Driver)
+	}
+}
+
+// Dialect 返回当前连接的数据库方言名称：mysql/postgres/sqlite
+func Dialect() string {
+	if DB == nil {
+		return DriverMySQL
+	}
+	return DB.Dialector.Name()
+}
+
+// hasView 判断当前数据库中是否存在指定名称的视图（Migrator().HasTable 只识别普通表）
+func hasView(name string) bool {
+	var query string
+	switch Dialect() {
+	case DriverPostgres:
+		query = "SELECT COUNT(*) FROM information_schema.views WHERE table_schema = CURRENT_SCHEMA() AND table_name = ?"
+	case DriverSQLite:
+		query = "SELECT COUNT(*) FROM sqlite_master WHERE type = 'view' AND name = ?"
+	default:
+		query = "SELECT COUNT(*) FROM information_schema.views WHERE table_schema = DATABASE() AND table_name = ?"
+	}
+
+	var count int64
+	if err := DB.Raw(query, name).Scan(&count).Error; err != nil {
+		return false
+	}
+	return count > 0
+}
+
+// TimeBucketExpr 返回按固定时长对时间列分组的SQL表达式，表达式中的占位符为分组时长（秒）
+func TimeBucketExpr(column string) string {
+	switch Dialect() {
+	case DriverPostgres:
+		return fmt.Sprintf("FLOOR(EXTRACT(EPOCH FROM %s) / ?)", column)
+	case DriverSQLite:
+		// 整数相除即向下取整，SQLite默认未编译FLOOR等数学函数
+		return fmt.Sprintf("(CAST(strftime('%%s', %s) AS INTEGER) / ?)", column)
+	default:
+		return fmt.Sprintf("FLOOR(UNIX_TIMESTAMP(%s) / ?)", column)
+	}
+}
+
+// JSONFieldExpr 返回从JSON对象列中按键提取文本值的SQL表达式及对应的参数，键不存在时表达式结果为NULL
+func JSONFieldExpr(column, key string) (string, interface{}) {
+	switch Dialect() {
+	case DriverPostgres:
+		return fmt.Sprintf("(%s ->> ?)", column), key
+	case DriverSQLite:
+		return fmt.Sprintf("json_extract(%s, ?)", column), jsonPath(key)
+	default:
+		return fmt.Sprintf("JSON_UNQUOTE(JSON_EXTRACT(%s, ?))", column), jsonPath(key)
+	}
+}
+
+// jsonPath 构造对象键的JSON路径，键名加引号以支持包含点号和斜杠的标签键
+func jsonPath(key string) string {
+	return `$."` + strings.ReplaceAll(key, `"`, `\"`) + `"`
+}
+
+// HourOfDayExpr 返回提取时间表达式小时数（0-23，服务器本地时区）的SQL表达式
+func HourOfDayExpr(expr string) string {
+	switch Dialect() {
+	case DriverPostgres:
+		return fmt.Sprintf("CAST(EXTRACT(HOUR FROM %s) AS INTEGER)", expr)
+	case DriverSQLite:
+		return fmt.Sprintf("CAST(strftime('%%H', %s, 'localtime') AS INTEGER)", expr)
+	default:
+		return fmt.Sprintf("HOUR(%s)", expr)
+	}
+}
+
+// sqliteTimestampFormats SQLite中时间文本的格式，第一种为驱动写入时间时使用的格式，其余为SQLite日期函数支持的格式
+var sqliteTimestampFormats = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	time.RFC3339Nano,
+}
+
+// Timestamp 用于扫描MIN/MAX等聚合表达式返回的时间值
+// SQLite不保留表达式结果的列类型，聚合后的时间以文本返回，需要按驱动的存储格式解析
+type Timestamp struct {
+	Time  time.Time
+	Valid bool
+}
+
+// Scan 实现sql.Scanner接口
+func (t *Timestamp) Scan(value interface{}) error {
+	t.Time, t.Valid = time.Time{}, false
+	var text string
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case time.Time:
+		t.Time, t.Valid = v, true
+		return nil
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	default:
+		return fmt.Errorf("无法将 %T 转换为时间", value)
+	}
+
+	for _, layout := range sqliteTimestampFormats {
+		if parsed, err := time.ParseInLocation(layout, text, time.Local); err == nil {
+			t.Time, t.Valid = parsed.In(time.Local), true
+			return nil
+		}
+	}
+	return fmt.Errorf("无法解析时间: %s", text)
+}
+
+// Value 实现driver.Valuer接口
+func (t Timestamp) Value() (driver.Value, error) {
+	if !t.Valid {
+		return nil, nil
+	}
+	return t.Time, nil
+}
+
+// Ptr 返回时间指针，无效时返回nil
+func (t Timestamp) Ptr() *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	value := t.Time
+	return &value
+}
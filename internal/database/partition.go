@@ -0,0 +1,390 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// 分区粒度
+const (
+	PartitionDaily   = "day"
+	PartitionMonthly = "month"
+)
+
+const (
+	// partitionNameLayout 分区名中的日期格式，日期为分区上界（不含）
+	partitionNameLayout = "20060102"
+	// partitionBoundLayout DDL 中分区边界的时间字面量格式
+	partitionBoundLayout = "2006-01-02 15:04:05"
+	// mysqlMaxPartition MySQL 兜底分区名，预创建不足时新数据写入该分区
+	mysqlMaxPartition = "pmax"
+)
+
+// partitionedTable 按时间范围分区的数据表
+type partitionedTable struct {
+	model  interface{}
+	name   string
+	column string
+}
+
+// partitionedTables 参与分区的数据表：指标事实表和系统活动表
+func partitionedTables() []partitionedTable {
+	return []partitionedTable{
+		{model: &models.PodMetricSample{}, name: models.PodMetricSample{}.TableName(), column: "collected_at"},
+		{model: &models.SystemActivity{}, name: models.SystemActivity{}.TableName(), column: "created_at"},
+	}
+}
+
+// PartitioningSupported 当前数据库是否支持原生范围分区
+func PartitioningSupported() bool {
+	dialect := Dialect()
+	return dialect == DriverMySQL || dialect == DriverPostgres
+}
+
+// noForeignKeyDB 迁移分区表时不创建外键，MySQL 分区表不支持外键
+func noForeignKeyDB(db *gorm.DB) *gorm.DB {
+	session := db.Session(&gorm.Session{})
+	session.Config.DisableForeignKeyConstraintWhenMigrating = true
+	return session
+}
+
+// periodStart 返回时间所在分区周期的起点（服务器本地时区）
+func periodStart(t time.Time, interval string) time.Time {
+	t = t.In(time.Local)
+	if interval == PartitionMonthly {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.Local)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// nextPeriod 返回下一个分区周期的起点
+func nextPeriod(t time.Time, interval string) time.Time {
+	if interval == PartitionMonthly {
+		return t.AddDate(0, 1, 0)
+	}
+	return t.AddDate(0, 0, 1)
+}
+
+// parsePartitionBound 从分区名末尾的日期解析分区上界
+func parsePartitionBound(name string) (time.Time, bool) {
+	if len(name) < len(partitionNameLayout) {
+		return time.Time{}, false
+	}
+	bound, err := time.ParseInLocation(partitionNameLayout, name[len(name)-len(partitionNameLayout):], time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return bound, true
+}
+
+// partitionRange 返回当前周期的起点和需要预创建到的分区上界，上界覆盖当前周期之后的 precreate 个周期
+func partitionRange(now time.Time, interval string, precreate int) (time.Time, time.Time) {
+	current := periodStart(now, interval)
+	target := current
+	for i := 0; i <= precreate; i++ {
+		target = nextPeriod(target, interval)
+	}
+	return current, target
+}
+
+// EnsurePartitions 将参与分区的数据表转换为按时间范围分区，并预先创建未来 precreate 个周期的分区
+// SQLite 不支持分区，直接返回
+func EnsurePartitions(interval string, precreate int) error {
+	if !PartitioningSupported() {
+		return nil
+	}
+	if interval != PartitionMonthly {
+		interval = PartitionDaily
+	}
+
+	current, target := partitionRange(time.Now(), interval, precreate)
+
+	for _, table := range partitionedTables() {
+		if !IsPartitioned(table.name) {
+			logger.Info("开始将数据表 %s 转换为按 %s 分区", table.name, table.column)
+			var err error
+			if Dialect() == DriverPostgres {
+				err = convertPostgresPartitions(table, current, interval, target)
+			} else {
+				err = convertMySQLPartitions(table, current, interval, target)
+			}
+			if err != nil {
+				return fmt.Errorf("数据表 %s 分区转换失败: %v", table.name, err)
+			}
+			logger.Info("数据表 %s 分区转换完成", table.name)
+			continue
+		}
+
+		created, err := createAheadPartitions(table, interval, target)
+		if err != nil {
+			return fmt.Errorf("数据表 %s 预创建分区失败: %v", table.name, err)
+		}
+		if created > 0 {
+			logger.Info("数据表 %s 新建 %d 个分区", table.name, created)
+		}
+	}
+	return nil
+}
+
+// IsPartitioned 判断数据表是否已按范围分区
+func IsPartitioned(table string) bool {
+	var query string
+	switch Dialect() {
+	case DriverPostgres:
+		query = `SELECT COUNT(*) FROM pg_partitioned_table pt
+			JOIN pg_class c ON c.oid = pt.partrelid
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE n.nspname = CURRENT_SCHEMA() AND c.relname = ?`
+	case DriverMySQL:
+		query = `SELECT COUNT(*) FROM information_schema.partitions
+			WHERE table_schema = DATABASE() AND table_name = ? AND partition_name IS NOT NULL`
+	default:
+		return false
+	}
+
+	var count int64
+	if err := DB.Raw(query, table).Scan(&count).Error; err != nil {
+		return false
+	}
+	return count > 0
+}
+
+// partitionBounds 查询数据表已有的按日期命名的分区，返回分区名到上界的映射
+func partitionBounds(table string) (map[string]time.Time, error) {
+	var names []string
+	var err error
+	if Dialect() == DriverPostgres {
+		err = DB.Raw(`SELECT c.relname FROM pg_inherits i
+			JOIN pg_class c ON c.oid = i.inhrelid
+			JOIN pg_class p ON p.oid = i.inhparent
+			JOIN pg_namespace n ON n.oid = p.relnamespace
+			WHERE n.nspname = CURRENT_SCHEMA() AND p.relname = ?`, table).Scan(&names).Error
+	} else {
+		err = DB.Raw(`SELECT partition_name FROM information_schema.partitions
+			WHERE table_schema = DATABASE() AND table_name = ? AND partition_name IS NOT NULL`, table).Scan(&names).Error
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询分区列表失败: %v", err)
+	}
+
+	bounds := make(map[string]time.Time, len(names))
+	for _, name := range names {
+		if bound, ok := parsePartitionBound(name); ok {
+			bounds[name] = bound
+		}
+	}
+	return bounds, nil
+}
+
+// partitionName 按上界生成分区名，PostgreSQL 分区是独立的表，需要带上父表名
+func partitionName(table string, bound time.Time) string {
+	if Dialect() == DriverPostgres {
+		return fmt.Sprintf("%s_p%s", table, bound.Format(partitionNameLayout))
+	}
+	return "p" + bound.Format(partitionNameLayout)
+}
+
+// convertMySQLPartitions 将 MySQL 普通表原地转换为 RANGE 分区表
+// 分区列必须包含在主键中，主键改为 (id, 分区列)；早于当前周期的数据全部放入第一个分区
+func convertMySQLPartitions(table partitionedTable, current time.Time, interval string, target time.Time) error {
+	// MySQL 分区表不支持外键，先删除已有外键
+	var foreignKeys []string
+	if err := DB.Raw(`SELECT constraint_name FROM information_schema.table_constraints
+		WHERE table_schema = DATABASE() AND table_name = ? AND constraint_type = 'FOREIGN KEY'`, table.name).
+		Scan(&foreignKeys).Error; err != nil {
+		return fmt.Errorf("查询外键失败: %v", err)
+	}
+	for _, foreignKey := range foreignKeys {
+		if err := DB.Exec(fmt.Sprintf("ALTER TABLE `%s` DROP FOREIGN KEY `%s`", table.name, foreignKey)).Error; err != nil {
+			return fmt.Errorf("删除外键 %s 失败: %v", foreignKey, err)
+		}
+	}
+
+	if err := DB.Exec(fmt.Sprintf("ALTER TABLE `%s` DROP PRIMARY KEY, ADD PRIMARY KEY (`id`, `%s`)",
+		table.name, table.column)).Error; err != nil {
+		return fmt.Errorf("调整主键失败: %v", err)
+	}
+
+	var definitions []string
+	for bound := current; !bound.After(target); bound = nextPeriod(bound, interval) {
+		definitions = append(definitions, mysqlPartitionDefinition(table.name, bound))
+	}
+	definitions = append(definitions, fmt.Sprintf("PARTITION %s VALUES LESS THAN MAXVALUE", mysqlMaxPartition))
+
+	sql := fmt.Sprintf("ALTER TABLE `%s` PARTITION BY RANGE (TO_DAYS(`%s`)) (%s)",
+		table.name, table.column, strings.Join(definitions, ", "))
+	if err := DB.Exec(sql).Error; err != nil {
+		return fmt.Errorf("创建分区失败: %v", err)
+	}
+	return nil
+}
+
+// mysqlPartitionDefinition 生成 MySQL 单个分区的定义
+func mysqlPartitionDefinition(table string, bound time.Time) string {
+	return fmt.Sprintf("PARTITION %s VALUES LESS THAN (TO_DAYS('%s'))",
+		partitionName(table, bound), bound.Format(partitionBoundLayout))
+}
+
+// convertPostgresPartitions 将 PostgreSQL 普通表重建为声明式分区表并复制已有数据
+// PostgreSQL 不支持原地转换，在同一事务内完成改名、建表、复制和删除旧表
+func convertPostgresPartitions(table partitionedTable, current time.Time, interval string, target time.Time) error {
+	legacy := table.name + "_unpartitioned"
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var sequence string
+		if err := tx.Raw("SELECT COALESCE(pg_get_serial_sequence(?, 'id'), '')", table.name).Scan(&sequence).Error; err != nil {
+			return fmt.Errorf("查询主键序列失败: %v", err)
+		}
+
+		// 视图按对象引用数据表，改名前先删除历史视图，转换完成后重建
+		statements := []string{
+			"DROP VIEW IF EXISTS " + historyViewName,
+			fmt.Sprintf(`ALTER TABLE "%s" RENAME TO "%s"`, table.name, legacy),
+			fmt.Sprintf(`CREATE TABLE "%s" (LIKE "%s" INCLUDING DEFAULTS) PARTITION BY RANGE ("%s")`,
+				table.name, legacy, table.column),
+			fmt.Sprintf(`CREATE TABLE "%s" PARTITION OF "%s" FOR VALUES FROM (MINVALUE) TO ('%s')`,
+				partitionName(table.name, current), table.name, current.Format(partitionBoundLayout)),
+		}
+		for lower, bound := current, nextPeriod(current, interval); !bound.After(target); lower, bound = bound, nextPeriod(bound, interval) {
+			statements = append(statements, postgresPartitionDefinition(table.name, lower, bound))
+		}
+		// 默认分区兜底预创建不足时写入的数据
+		statements = append(statements,
+			fmt.Sprintf(`CREATE TABLE "%s_pdefault" PARTITION OF "%s" DEFAULT`, table.name, table.name),
+			fmt.Sprintf(`INSERT INTO "%s" SELECT * FROM "%s"`, table.name, legacy),
+		)
+		// 主键序列归属旧表，删除旧表前先解除归属
+		if sequence != "" {
+			statements = append(statements, fmt.Sprintf(`ALTER SEQUENCE %s OWNED BY NONE`, sequence))
+		}
+		statements = append(statements, fmt.Sprintf(`DROP TABLE "%s"`, legacy))
+		if sequence != "" {
+			statements = append(statements, fmt.Sprintf(`ALTER SEQUENCE %s OWNED BY "%s".id`, sequence, table.name))
+		}
+		statements = append(statements,
+			fmt.Sprintf(`ALTER TABLE "%s" ADD PRIMARY KEY (id, "%s")`, table.name, table.column),
+			historyViewSQL,
+		)
+
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return fmt.Errorf("执行 %q 失败: %v", statement, err)
+			}
+		}
+
+		// 在父表上重建索引，PostgreSQL 会自动在各分区上创建
+		if err := noForeignKeyDB(tx).AutoMigrate(table.model); err != nil {
+			return fmt.Errorf("重建索引失败: %v", err)
+		}
+		return nil
+	})
+}
+
+// postgresPartitionDefinition 生成 PostgreSQL 单个分区的建表语句
+func postgresPartitionDefinition(table string, lower, bound time.Time) string {
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS "%s" PARTITION OF "%s" FOR VALUES FROM ('%s') TO ('%s')`,
+		partitionName(table, bound), table, lower.Format(partitionBoundLayout), bound.Format(partitionBoundLayout))
+}
+
+// createAheadPartitions 在最新分区之后补齐到 target 的分区，返回新建的分区数
+func createAheadPartitions(table partitionedTable, interval string, target time.Time) (int, error) {
+	bounds, err := partitionBounds(table.name)
+	if err != nil {
+		return 0, err
+	}
+	last, newBounds, err := aheadBounds(bounds, interval, target)
+	if err != nil || len(newBounds) == 0 {
+		return 0, err
+	}
+
+	if Dialect() == DriverPostgres {
+		lower := last
+		for _, bound := range newBounds {
+			if err := DB.Exec(postgresPartitionDefinition(table.name, lower, bound)).Error; err != nil {
+				return 0, fmt.Errorf("创建分区失败（默认分区中可能已有该时间段的数据）: %v", err)
+			}
+			lower = bound
+		}
+		return len(newBounds), nil
+	}
+
+	// MySQL 从兜底分区中拆分出新分区，兜底分区通常为空，拆分代价很小
+	definitions := make([]string, 0, len(newBounds)+1)
+	for _, bound := range newBounds {
+		definitions = append(definitions, mysqlPartitionDefinition(table.name, bound))
+	}
+	definitions = append(definitions, fmt.Sprintf("PARTITION %s VALUES LESS THAN MAXVALUE", mysqlMaxPartition))
+	sql := fmt.Sprintf("ALTER TABLE `%s` REORGANIZE PARTITION %s INTO (%s)",
+		table.name, mysqlMaxPartition, strings.Join(definitions, ", "))
+	if err := DB.Exec(sql).Error; err != nil {
+		return 0, fmt.Errorf("创建分区失败: %v", err)
+	}
+	return len(newBounds), nil
+}
+
+// aheadBounds 返回已有分区中最晚的上界，以及从其之后补齐到 target 需要新建的分区上界
+func aheadBounds(bounds map[string]time.Time, interval string, target time.Time) (time.Time, []time.Time, error) {
+	var last time.Time
+	for _, bound := range bounds {
+		if bound.After(last) {
+			last = bound
+		}
+	}
+	if last.IsZero() {
+		return last, nil, fmt.Errorf("未找到按日期命名的分区")
+	}
+
+	var newBounds []time.Time
+	for bound := nextPeriod(last, interval); !bound.After(target); bound = nextPeriod(bound, interval) {
+		newBounds = append(newBounds, bound)
+	}
+	return last, newBounds, nil
+}
+
+// expiredPartitions 返回上界不晚于 cutoffTime 的分区名，按名称排序
+func expiredPartitions(bounds map[string]time.Time, cutoffTime time.Time) []string {
+	var expired []string
+	for name, bound := range bounds {
+		if !bound.After(cutoffTime) {
+			expired = append(expired, name)
+		}
+	}
+	sort.Strings(expired)
+	return expired
+}
+
+// DropExpiredPartitions 删除上界不晚于 cutoffTime 的分区，整个分区内的数据均已过期
+// 数据表未分区时不做任何操作，返回删除的分区数
+func DropExpiredPartitions(table string, cutoffTime time.Time) (int, error) {
+	if !PartitioningSupported() || !IsPartitioned(table) {
+		return 0, nil
+	}
+
+	bounds, err := partitionBounds(table)
+	if err != nil {
+		return 0, err
+	}
+	expired := expiredPartitions(bounds, cutoffTime)
+	if len(expired) == 0 {
+		return 0, nil
+	}
+
+	if Dialect() == DriverPostgres {
+		for _, name := range expired {
+			if err := DB.Exec(fmt.Sprintf(`DROP TABLE "%s"`, name)).Error; err != nil {
+				return 0, fmt.Errorf("删除分区 %s 失败: %v", name, err)
+			}
+		}
+	} else if err := DB.Exec(fmt.Sprintf("ALTER TABLE `%s` DROP PARTITION %s", table, strings.Join(expired, ", "))).Error; err != nil {
+		return 0, fmt.Errorf("删除分区失败: %v", err)
+	}
+
+	logger.Info("数据表 %s 删除 %d 个过期分区: %s", table, len(expired), strings.Join(expired, ", "))
+	return len(expired), nil
+}
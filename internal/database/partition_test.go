@@ -0,0 +1,181 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// localDate 构造服务器本地时区的时间，分区边界按本地时区计算
+func localDate(year int, month time.Month, day, hour int) time.Time {
+	return time.Date(year, month, day, hour, 0, 0, 0, time.Local)
+}
+
+func TestPeriodStartAndNextPeriod(t *testing.T) {
+	tests := []struct {
+		name      string
+		t         time.Time
+		interval  string
+		wantStart time.Time
+		wantNext  time.Time
+	}{
+		{"按天", localDate(2026, 3, 2, 15), PartitionDaily, localDate(2026, 3, 2, 0), localDate(2026, 3, 3, 0)},
+		{"按天跨月", localDate(2026, 1, 31, 23), PartitionDaily, localDate(2026, 1, 31, 0), localDate(2026, 2, 1, 0)},
+		{"按月", localDate(2026, 3, 15, 8), PartitionMonthly, localDate(2026, 3, 1, 0), localDate(2026, 4, 1, 0)},
+		{"按月跨年", localDate(2026, 12, 31, 23), PartitionMonthly, localDate(2026, 12, 1, 0), localDate(2027, 1, 1, 0)},
+		{"未知粒度按天处理", localDate(2026, 3, 2, 15), "week", localDate(2026, 3, 2, 0), localDate(2026, 3, 3, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := periodStart(tt.t, tt.interval)
+			if !start.Equal(tt.wantStart) {
+				t.Errorf("周期起点 %v，期望 %v", start, tt.wantStart)
+			}
+			if next := nextPeriod(start, tt.interval); !next.Equal(tt.wantNext) {
+				t.Errorf("下一周期 %v，期望 %v", next, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestPartitionRange(t *testing.T) {
+	tests := []struct {
+		name        string
+		now         time.Time
+		interval    string
+		precreate   int
+		wantCurrent time.Time
+		wantTarget  time.Time
+	}{
+		{"不预创建时覆盖当前周期", localDate(2026, 3, 2, 15), PartitionDaily, 0, localDate(2026, 3, 2, 0), localDate(2026, 3, 3, 0)},
+		{"按天预创建7个周期", localDate(2026, 3, 2, 15), PartitionDaily, 7, localDate(2026, 3, 2, 0), localDate(2026, 3, 10, 0)},
+		{"按月预创建2个周期", localDate(2026, 11, 15, 8), PartitionMonthly, 2, localDate(2026, 11, 1, 0), localDate(2027, 2, 1, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current, target := partitionRange(tt.now, tt.interval, tt.precreate)
+			if !current.Equal(tt.wantCurrent) || !target.Equal(tt.wantTarget) {
+				t.Errorf("得到 %v ~ %v，期望 %v ~ %v", current, target, tt.wantCurrent, tt.wantTarget)
+			}
+		})
+	}
+}
+
+func TestParsePartitionBound(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		want   time.Time
+		wantOK bool
+	}{
+		{"MySQL分区名", "p20260302", localDate(2026, 3, 2, 0), true},
+		{"PostgreSQL分区名", "pod_metric_samples_p20260302", localDate(2026, 3, 2, 0), true},
+		{"MySQL兜底分区", "pmax", time.Time{}, false},
+		{"PostgreSQL默认分区", "pod_metric_samples_pdefault", time.Time{}, false},
+		{"日期无效", "p20261301", time.Time{}, false},
+		{"空名称", "", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bound, ok := parsePartitionBound(tt.input)
+			if ok != tt.wantOK || !bound.Equal(tt.want) {
+				t.Errorf("得到 %v/%v，期望 %v/%v", bound, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestAheadBounds(t *testing.T) {
+	tests := []struct {
+		name      string
+		bounds    map[string]time.Time
+		interval  string
+		target    time.Time
+		wantLast  time.Time
+		wantNew   []time.Time
+		wantError bool
+	}{
+		{
+			name:      "没有按日期命名的分区",
+			bounds:    map[string]time.Time{},
+			interval:  PartitionDaily,
+			target:    localDate(2026, 3, 6, 0),
+			wantError: true,
+		},
+		{
+			name:     "已覆盖到目标上界",
+			bounds:   map[string]time.Time{"p20260305": localDate(2026, 3, 5, 0), "p20260306": localDate(2026, 3, 6, 0)},
+			interval: PartitionDaily,
+			target:   localDate(2026, 3, 6, 0),
+			wantLast: localDate(2026, 3, 6, 0),
+		},
+		{
+			name:     "从最晚的分区之后按天补齐",
+			bounds:   map[string]time.Time{"p20260303": localDate(2026, 3, 3, 0), "p20260301": localDate(2026, 3, 1, 0), "p20260302": localDate(2026, 3, 2, 0)},
+			interval: PartitionDaily,
+			target:   localDate(2026, 3, 6, 0),
+			wantLast: localDate(2026, 3, 3, 0),
+			wantNew:  []time.Time{localDate(2026, 3, 4, 0), localDate(2026, 3, 5, 0), localDate(2026, 3, 6, 0)},
+		},
+		{
+			name:     "按月补齐并跨年",
+			bounds:   map[string]time.Time{"p20261101": localDate(2026, 11, 1, 0)},
+			interval: PartitionMonthly,
+			target:   localDate(2027, 2, 1, 0),
+			wantLast: localDate(2026, 11, 1, 0),
+			wantNew:  []time.Time{localDate(2026, 12, 1, 0), localDate(2027, 1, 1, 0), localDate(2027, 2, 1, 0)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			last, newBounds, err := aheadBounds(tt.bounds, tt.interval, tt.target)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("错误 %v，期望出错 %v", err, tt.wantError)
+			}
+			if !last.Equal(tt.wantLast) {
+				t.Errorf("最晚分区上界 %v，期望 %v", last, tt.wantLast)
+			}
+			if !reflect.DeepEqual(newBounds, tt.wantNew) {
+				t.Errorf("新建分区上界 %v，期望 %v", newBounds, tt.wantNew)
+			}
+		})
+	}
+}
+
+func TestExpiredPartitions(t *testing.T) {
+	bounds := map[string]time.Time{
+		"p20260303": localDate(2026, 3, 3, 0),
+		"p20260301": localDate(2026, 3, 1, 0),
+		"p20260302": localDate(2026, 3, 2, 0),
+		"p20260304": localDate(2026, 3, 4, 0),
+	}
+
+	tests := []struct {
+		name   string
+		bounds map[string]time.Time
+		cutoff time.Time
+		want   []string
+	}{
+		{"没有分区", nil, localDate(2026, 3, 3, 0), nil},
+		{"没有过期分区", bounds, localDate(2026, 2, 28, 0), nil},
+		{"上界等于截止时间的分区已过期", bounds, localDate(2026, 3, 3, 0), []string{"p20260301", "p20260302", "p20260303"}},
+		{"跨越截止时间的分区保留", bounds, localDate(2026, 3, 3, 12), []string{"p20260301", "p20260302", "p20260303"}},
+		{"全部过期", bounds, localDate(2026, 3, 10, 0), []string{"p20260301", "p20260302", "p20260303", "p20260304"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expiredPartitions(tt.bounds, tt.cutoff); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("得到 %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMySQLPartitionDefinition(t *testing.T) {
+	// 未初始化数据库连接时按 MySQL 方言生成分区名
+	got := mysqlPartitionDefinition("pod_metric_samples", localDate(2026, 3, 2, 0))
+	want := "PARTITION p20260302 VALUES LESS THAN (TO_DAYS('2026-03-02 00:00:00'))"
+	if got != want {
+		t.Errorf("得到 %q，期望 %q", got, want)
+	}
+}
@@ -0,0 +1,419 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// historyViewName Pod监控历史视图名称，沿用原历史表的表名，查询代码无需改动
+	historyViewName = "pod_metrics_history"
+	// legacyHistoryTable 旧版宽表在迁移期间的临时表名
+	legacyHistoryTable = "pod_metrics_history_legacy"
+	// legacyMigrateBatchSize 旧数据迁移每批处理的记录数
+	legacyMigrateBatchSize = 1000
+	// identityQueryChunk IN 查询每批的参数个数
+	identityQueryChunk = 500
+)
+
+// historyViewSQL 历史视图定义，利用率由使用量和生效规格计算，与采集时的计算方式一致
+const historyViewSQL = `CREATE VIEW pod_metrics_history AS
+SELECT
+	s.id AS id,
+	d.cluster_id AS cluster_id,
+	d.namespace AS namespace,
+	d.pod_name AS pod_name,
+	v.node_name AS node_name,
+	d.workload_kind AS workload_kind,
+	d.workload_name AS workload_name,
+	v.qos_class AS qo_s_class,
+	s.memory_usage AS memory_usage,
+	v.memory_request AS memory_request,
+	v.memory_limit AS memory_limit,
+	CASE WHEN v.memory_request > 0 THEN s.memory_usage * 100.0 / v.memory_request ELSE 0 END AS memory_req_pct,
+	CASE WHEN v.memory_limit > 0 THEN s.memory_usage * 100.0 / v.memory_limit ELSE 0 END AS memory_limit_pct,
+	s.cpu_usage AS cpu_usage,
+	v.cpu_request AS cpu_request,
+	v.cpu_limit AS cpu_limit,
+	CASE WHEN v.cpu_request > 0 THEN s.cpu_usage * 100.0 / v.cpu_request ELSE 0 END AS cpu_req_pct,
+	CASE WHEN v.cpu_limit > 0 THEN s.cpu_usage * 100.0 / v.cpu_limit ELSE 0 END AS cpu_limit_pct,
+	s.status AS status,
+	s.issues AS issues,
+	s.metrics_available AS metrics_available,
+	s.cpu_metrics_available AS cpu_metrics_available,
+	s.memory_metrics_available AS memory_metrics_available,
+	s.restart_count AS restart_count,
+	s.oom_killed AS oom_killed,
+	s.collected_at AS collected_at,
+	s.collected_at AS created_at,
+	d.labels AS labels
+FROM pod_metric_samples s
+JOIN pod_dimensions d ON d.id = s.pod_id
+JOIN pod_spec_versions v ON v.id = s.spec_version_id`
+
+// podIdentity Pod在维度表中的唯一标识
+type podIdentity struct {
+	clusterID uint
+	namespace string
+	podName   string
+}
+
+// SavePodMetricsHistory 将Pod监控记录拆分写入维度表、规格版本表和指标事实表
+// 维度只在首次出现或工作负载、标签变化时写入，规格只在变化时新增版本，每次采集只新增窄的事实行
+// records 需按采集时间升序排列
+func SavePodMetricsHistory(db *gorm.DB, records []models.PodMetricsHistory) error {
+	if len(records) == 0 {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		return savePodMetrics(tx, records)
+	})
+}
+
+// savePodMetrics 在事务内写入一批监控记录
+func savePodMetrics(tx *gorm.DB, records []models.PodMetricsHistory) error {
+	dimensions, err := resolvePodDimensions(tx, records)
+	if err != nil {
+		return err
+	}
+
+	podIDs := make([]uint, 0, len(dimensions))
+	for _, dimension := range dimensions {
+		podIDs = append(podIDs, dimension.ID)
+	}
+	openVersions, err := loadOpenSpecVersions(tx, podIDs)
+	if err != nil {
+		return err
+	}
+
+	samples := make([]models.PodMetricSample, 0, len(records))
+	for _, record := range records {
+		dimension := dimensions[podIdentity{record.ClusterID, record.Namespace, record.PodName}]
+
+		version := openVersions[dimension.ID]
+		if version == nil || !sameSpec(version, record) {
+			if version != nil {
+				if err := tx.Model(version).Update("valid_to", record.CollectedAt).Error; err != nil {
+					return fmt.Errorf("关闭Pod规格版本失败: %v", err)
+				}
+			}
+			version = &models.PodSpecVersion{
+				PodID:         dimension.ID,
+				NodeName:      record.NodeName,
+				QoSClass:      record.QoSClass,
+				MemoryRequest: record.MemoryRequest,
+				MemoryLimit:   record.MemoryLimit,
+				CPURequest:    record.CPURequest,
+				CPULimit:      record.CPULimit,
+				ValidFrom:     record.CollectedAt,
+			}
+			if err := tx.Create(version).Error; err != nil {
+				return fmt.Errorf("保存Pod规格版本失败: %v", err)
+			}
+			openVersions[dimension.ID] = version
+		}
+
+		// 事实表只保存真实使用量，缺失的指标保存为0
+		sample := models.PodMetricSample{
+			PodID:                  dimension.ID,
+			SpecVersionID:          version.ID,
+			Status:                 record.Status,
+			Issues:                 record.Issues,
+			MetricsAvailable:       record.CPUMetricsAvailable && record.MemoryMetricsAvailable,
+			CPUMetricsAvailable:    record.CPUMetricsAvailable,
+			MemoryMetricsAvailable: record.MemoryMetricsAvailable,
+			RestartCount:           record.RestartCount,
+			OOMKilled:              record.OOMKilled,
+			CollectedAt:            record.CollectedAt,
+		}
+		if record.CPUMetricsAvailable {
+			sample.CPUUsage = record.CPUUsage
+		}
+		if record.MemoryMetricsAvailable {
+			sample.MemoryUsage = record.MemoryUsage
+		}
+		samples = append(samples, sample)
+	}
+
+	if err := tx.CreateInBatches(samples, 200).Error; err != nil {
+		return fmt.Errorf("保存Pod指标数据失败: %v", err)
+	}
+	return nil
+}
+
+// sameSpec 判断记录的规格是否与当前版本一致
+func sameSpec(version *models.PodSpecVersion, record models.PodMetricsHistory) bool {
+	return version.NodeName == record.NodeName &&
+		version.QoSClass == record.QoSClass &&
+		version.MemoryRequest == record.MemoryRequest &&
+		version.MemoryLimit == record.MemoryLimit &&
+		version.CPURequest == record.CPURequest &&
+		version.CPULimit == record.CPULimit
+}
+
+// resolvePodDimensions 查找或创建记录涉及的Pod维度，并刷新最近采集时间、工作负载和标签
+func resolvePodDimensions(tx *gorm.DB, records []models.PodMetricsHistory) (map[podIdentity]*models.PodDimension, error) {
+	// 每个Pod取最后一条记录作为维度的最新状态
+	latest := make(map[podIdentity]models.PodMetricsHistory)
+	firstSeen := make(map[podIdentity]time.Time)
+	podNames := make(map[uint][]string)
+	for _, record := range records {
+		key := podIdentity{record.ClusterID, record.Namespace, record.PodName}
+		if _, ok := latest[key]; !ok {
+			firstSeen[key] = record.CollectedAt
+			podNames[record.ClusterID] = append(podNames[record.ClusterID], record.PodName)
+		}
+		latest[key] = record
+	}
+
+	dimensions := make(map[podIdentity]*models.PodDimension, len(latest))
+	for clusterID, names := range podNames {
+		for start := 0; start < len(names); start += identityQueryChunk {
+			end := start + identityQueryChunk
+			if end > len(names) {
+				end = len(names)
+			}
+			var found []models.PodDimension
+			if err := tx.Where("cluster_id = ? AND pod_name IN ?", clusterID, names[start:end]).Find(&found).Error; err != nil {
+				return nil, fmt.Errorf("查询Pod维度失败: %v", err)
+			}
+			for i := range found {
+				key := podIdentity{found[i].ClusterID, found[i].Namespace, found[i].PodName}
+				if _, ok := latest[key]; ok {
+					dimensions[key] = &found[i]
+				}
+			}
+		}
+	}
+
+	var created []models.PodDimension
+	seenAt := make(map[time.Time][]uint)
+	for key, record := range latest {
+		dimension, ok := dimensions[key]
+		if !ok {
+			created = append(created, models.PodDimension{
+				ClusterID:    key.clusterID,
+				Namespace:    key.namespace,
+				PodName:      key.podName,
+				WorkloadKind: record.WorkloadKind,
+				WorkloadName: record.WorkloadName,
+				Labels:       record.Labels,
+				FirstSeenAt:  firstSeen[key],
+				LastSeenAt:   record.CollectedAt,
+			})
+			continue
+		}
+
+		if dimension.WorkloadKind != record.WorkloadKind || dimension.WorkloadName != record.WorkloadName ||
+			(len(record.Labels) > 0 && string(dimension.Labels) != string(record.Labels)) {
+			updates := map[string]interface{}{
+				"workload_kind": record.WorkloadKind,
+				"workload_name": record.WorkloadName,
+			}
+			if len(record.Labels) > 0 {
+				updates["labels"] = record.Labels
+			}
+			if err := tx.Model(dimension).Updates(updates).Error; err != nil {
+				return nil, fmt.Errorf("更新Pod维度失败: %v", err)
+			}
+		}
+		if record.CollectedAt.After(dimension.LastSeenAt) {
+			seenAt[record.CollectedAt] = append(seenAt[record.CollectedAt], dimension.ID)
+		}
+	}
+
+	if len(created) > 0 {
+		if err := tx.CreateInBatches(created, 200).Error; err != nil {
+			return nil, fmt.Errorf("创建Pod维度失败: %v", err)
+		}
+		for i := range created {
+			dimensions[podIdentity{created[i].ClusterID, created[i].Namespace, created[i].PodName}] = &created[i]
+		}
+	}
+
+	// 同一批采集通常只有一个采集时间，按时间批量更新最近采集时间
+	for collectedAt, ids := range seenAt {
+		for start := 0; start < len(ids); start += identityQueryChunk {
+			end := start + identityQueryChunk
+			if end > len(ids) {
+				end = len(ids)
+			}
+			if err := tx.Model(&models.PodDimension{}).Where("id IN ?", ids[start:end]).
+				Update("last_seen_at", collectedAt).Error; err != nil {
+				return nil, fmt.Errorf("更新Pod最近采集时间失败: %v", err)
+			}
+		}
+	}
+
+	return dimensions, nil
+}
+
+// loadOpenSpecVersions 查询Pod当前生效的规格版本
+func loadOpenSpecVersions(tx *gorm.DB, podIDs []uint) (map[uint]*models.PodSpecVersion, error) {
+	versions := make(map[uint]*models.PodSpecVersion, len(podIDs))
+	for start := 0; start < len(podIDs); start += identityQueryChunk {
+		end := start + identityQueryChunk
+		if end > len(podIDs) {
+			end = len(podIDs)
+		}
+		var found []models.PodSpecVersion
+		if err := tx.Where("pod_id IN ? AND valid_to IS NULL", podIDs[start:end]).Find(&found).Error; err != nil {
+			return nil, fmt.Errorf("查询Pod规格版本失败: %v", err)
+		}
+		for i := range found {
+			versions[found[i].PodID] = &found[i]
+		}
+	}
+	return versions, nil
+}
+
+// CleanupPodMetricsHistory 删除采集时间早于 cutoffTime 的指标数据，
+// 以及已不再被引用的规格版本和长期未采集到的Pod维度，返回删除的指标记录数
+func CleanupPodMetricsHistory(db *gorm.DB, cutoffTime time.Time) (int64, error) {
+	// 已分区时先整体删除过期分区，剩余的 DELETE 只涉及跨越截止时间的分区
+	if _, err := DropExpiredPartitions(models.PodMetricSample{}.TableName(), cutoffTime); err != nil {
+		return 0, err
+	}
+
+	var deleted int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("collected_at < ?", cutoffTime).Delete(&models.PodMetricSample{})
+		if result.Error != nil {
+			return fmt.Errorf("清理Pod指标数据失败: %v", result.Error)
+		}
+		deleted = result.RowsAffected
+
+		// 在截止时间前已失效的版本不再有指标引用
+		if err := tx.Where("valid_to < ?", cutoffTime).Delete(&models.PodSpecVersion{}).Error; err != nil {
+			return fmt.Errorf("清理Pod规格版本失败: %v", err)
+		}
+
+		stalePods := tx.Model(&models.PodDimension{}).Select("id").Where("last_seen_at < ?", cutoffTime)
+		if err := tx.Where("pod_id IN (?)", stalePods).Delete(&models.PodSpecVersion{}).Error; err != nil {
+			return fmt.Errorf("清理Pod规格版本失败: %v", err)
+		}
+		if err := tx.Where("last_seen_at < ?", cutoffTime).Delete(&models.PodDimension{}).Error; err != nil {
+			return fmt.Errorf("清理Pod维度失败: %v", err)
+		}
+		return nil
+	})
+	return deleted, err
+}
+
+// renameLegacyHistoryTable 将旧版宽表重命名，为同名视图让出名称
+func renameLegacyHistoryTable() error {
+	if !DB.Migrator().HasTable(historyViewName) {
+		return nil
+	}
+	if DB.Migrator().HasTable(legacyHistoryTable) {
+		return fmt.Errorf("旧版历史表 %s 与 %s 同时存在，请确认上次迁移状态后手动处理", historyViewName, legacyHistoryTable)
+	}
+	logger.Info("检测到旧版历史表，重命名为 %s 等待迁移", legacyHistoryTable)
+	if err := DB.Migrator().RenameTable(historyViewName, legacyHistoryTable); err != nil {
+		return fmt.Errorf("重命名旧版历史表失败: %v", err)
+	}
+	return nil
+}
+
+// createHistoryView 重建Pod监控历史视图
+func createHistoryView() error {
+	if err := DB.Exec("DROP VIEW IF EXISTS " + historyViewName).Error; err != nil {
+		return fmt.Errorf("删除历史视图失败: %v", err)
+	}
+	if err := DB.Exec(historyViewSQL).Error; err != nil {
+		return fmt.Errorf("创建历史视图失败: %v", err)
+	}
+	return nil
+}
+
+// migrateLegacyHistory 将旧版宽表数据分批迁移到规范化表中
+// 每批的写入和删除在同一事务内完成，中断后重新执行迁移会从剩余数据继续
+func migrateLegacyHistory() error {
+	if !DB.Migrator().HasTable(legacyHistoryTable) {
+		return nil
+	}
+
+	// 早期版本的宽表没有 metrics_available 字段，当时的记录均按真实使用量处理
+	hasMetricsFlag := DB.Migrator().HasColumn(legacyHistoryTable, "metrics_available")
+
+	var total int64
+	if err := DB.Table(legacyHistoryTable).Count(&total).Error; err != nil {
+		return fmt.Errorf("统计旧版历史数据失败: %v", err)
+	}
+	logger.Info("开始迁移旧版历史数据，共 %d 条", total)
+
+	var migrated int64
+	for {
+		var batch []models.PodMetricsHistory
+		if err := DB.Table(legacyHistoryTable).Order("collected_at ASC, id ASC").
+			Limit(legacyMigrateBatchSize).Find(&batch).Error; err != nil {
+			return fmt.Errorf("读取旧版历史数据失败: %v", err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		ids := make([]uint, len(batch))
+		for i := range batch {
+			ids[i] = batch[i].ID
+			if !hasMetricsFlag {
+				batch[i].MetricsAvailable = true
+			}
+			// 旧表只有整体的可用标记，不可用时记录中的使用量为估算值
+			batch[i].CPUMetricsAvailable = batch[i].MetricsAvailable
+			batch[i].MemoryMetricsAvailable = batch[i].MetricsAvailable
+			// 旧表对每条记录都保存了问题列表，只保留确有问题的记录
+			if string(batch[i].Issues) == "null" || string(batch[i].Issues) == "[]" {
+				batch[i].Issues = ""
+			}
+		}
+
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			if err := savePodMetrics(tx, batch); err != nil {
+				return err
+			}
+			return tx.Table(legacyHistoryTable).Where("id IN ?", ids).Delete(&models.PodMetricsHistory{}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("迁移旧版历史数据失败: %v", err)
+		}
+
+		migrated += int64(len(batch))
+		logger.Info("旧版历史数据迁移进度: %d/%d", migrated, total)
+	}
+
+	if err := DB.Migrator().DropTable(legacyHistoryTable); err != nil {
+		return fmt.Errorf("删除旧版历史表失败: %v", err)
+	}
+	logger.Info("旧版历史数据迁移完成，共迁移 %d 条", migrated)
+	return nil
+}
+
+// hasMetricFlagColumns 检查指标事实表是否已有分指标的可用标记列
+func hasMetricFlagColumns() bool {
+	return DB.Migrator().HasColumn(&models.PodMetricSample{}, "cpu_metrics_available")
+}
+
+// backfillMetricFlags 为新增分指标可用标记列之前写入的样本补充标记
+// 旧样本只有整体可用标记，不可用的样本保存的是估算使用量，一并清零
+func backfillMetricFlags() error {
+	table := models.PodMetricSample{}.TableName()
+	if err := DB.Table(table).Where("metrics_available = ?", true).Updates(map[string]interface{}{
+		"cpu_metrics_available":    true,
+		"memory_metrics_available": true,
+	}).Error; err != nil {
+		return fmt.Errorf("补充指标可用标记失败: %v", err)
+	}
+	if err := DB.Table(table).Where("metrics_available = ?", false).Updates(map[string]interface{}{
+		"cpu_usage":    0,
+		"memory_usage": 0,
+	}).Error; err != nil {
+		return fmt.Errorf("清理估算使用量失败: %v", err)
+	}
+	return nil
+}
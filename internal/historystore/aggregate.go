@@ -0,0 +1,123 @@
+package historystore
+
+import (
+	"time"
+
+	"cluster-resource-insight/internal/models"
+)
+
+// aggregateKey 聚合桶标识
+type aggregateKey struct {
+	bucket       int64
+	namespace    string
+	workloadKind string
+	workloadName string
+	podName      string
+}
+
+// aggregateBucket 聚合桶中的累计值
+type aggregateBucket struct {
+	point         AggregatePoint
+	batches       map[int64]struct{}
+	pods          map[string]struct{}
+	cpuPods       map[string]struct{}
+	memoryPods    map[string]struct{}
+	cpuSamples    int
+	memorySamples int
+}
+
+// aggregator 按时间桶和分组累计样本，口径与SQL存储的聚合查询一致；样本可按任意顺序分批加入
+type aggregator struct {
+	step    time.Duration
+	groupBy string
+	buckets map[aggregateKey]*aggregateBucket
+}
+
+// newAggregator 创建聚合器
+func newAggregator(step time.Duration, groupBy string) *aggregator {
+	return &aggregator{step: step, groupBy: groupBy, buckets: make(map[aggregateKey]*aggregateBucket)}
+}
+
+// add 将一条样本计入所属的聚合桶
+func (a *aggregator) add(record *models.PodMetricsHistory) {
+	ts := record.CollectedAt.Unix()
+
+	key := aggregateKey{bucket: ts}
+	if seconds := int64(a.step / time.Second); seconds > 0 {
+		key.bucket = ts - ts%seconds
+	}
+	switch a.groupBy {
+	case GroupPod:
+		key.podName = record.PodName
+		fallthrough
+	case GroupWorkload:
+		key.workloadKind = record.WorkloadKind
+		key.workloadName = record.WorkloadName
+		fallthrough
+	case GroupNamespace:
+		key.namespace = record.Namespace
+	}
+
+	bucket, ok := a.buckets[key]
+	if !ok {
+		bucket = &aggregateBucket{
+			point: AggregatePoint{
+				Time:         record.CollectedAt,
+				Namespace:    key.namespace,
+				WorkloadKind: key.workloadKind,
+				WorkloadName: key.workloadName,
+				PodName:      key.podName,
+			},
+			batches:    make(map[int64]struct{}),
+			pods:       make(map[string]struct{}),
+			cpuPods:    make(map[string]struct{}),
+			memoryPods: make(map[string]struct{}),
+		}
+		a.buckets[key] = bucket
+	}
+	if record.CollectedAt.Before(bucket.point.Time) {
+		bucket.point.Time = record.CollectedAt
+	}
+	bucket.batches[record.CollectedAt.UnixMilli()] = struct{}{}
+	bucket.pods[record.PodName] = struct{}{}
+	bucket.point.CPURequest += float64(record.CPURequest)
+	bucket.point.MemoryRequest += float64(record.MemoryRequest)
+	if record.CPUMetricsAvailable {
+		bucket.cpuPods[record.PodName] = struct{}{}
+		bucket.cpuSamples++
+		bucket.point.CPUUsage += float64(record.CPUUsage)
+		bucket.point.CPUReqPct += record.CPUReqPct
+	}
+	if record.MemoryMetricsAvailable {
+		bucket.memoryPods[record.PodName] = struct{}{}
+		bucket.memorySamples++
+		bucket.point.MemoryUsage += float64(record.MemoryUsage)
+		bucket.point.MemoryReqPct += record.MemoryReqPct
+	}
+}
+
+// points 计算各聚合桶的结果，按时间升序返回
+func (a *aggregator) points() []AggregatePoint {
+	points := make([]AggregatePoint, 0, len(a.buckets))
+	for _, bucket := range a.buckets {
+		point := bucket.point
+		batches := float64(len(bucket.batches))
+		point.Batches = len(bucket.batches)
+		point.PodCount = len(bucket.pods)
+		point.CPUPodCount = len(bucket.cpuPods)
+		point.MemoryPodCount = len(bucket.memoryPods)
+		point.CPUUsage /= batches
+		point.MemoryUsage /= batches
+		point.CPURequest /= batches
+		point.MemoryRequest /= batches
+		if bucket.cpuSamples > 0 {
+			point.CPUReqPct /= float64(bucket.cpuSamples)
+		}
+		if bucket.memorySamples > 0 {
+			point.MemoryReqPct /= float64(bucket.memorySamples)
+		}
+		points = append(points, point)
+	}
+	sortPoints(points)
+	return points
+}
@@ -0,0 +1,207 @@
+package historystore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cluster-resource-insight/internal/database"
+	"cluster-resource-insight/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// sqlStore 基于关系型数据库的样本存储，读写 Pod 维度表、规格版本表和指标事实表
+type sqlStore struct{}
+
+// db 返回当前数据库连接，数据库在存储创建之后才完成初始化
+func (s *sqlStore) db(ctx context.Context) *gorm.DB {
+	return database.GetDB().WithContext(ctx)
+}
+
+// Name 返回存储后端名称
+func (s *sqlStore) Name() string {
+	return BackendSQL
+}
+
+// Write 写入一批采集样本
+func (s *sqlStore) Write(ctx context.Context, records []models.PodMetricsHistory) error {
+	return database.SavePodMetricsHistory(s.db(ctx), records)
+}
+
+// applyFilter 将筛选条件应用到历史视图查询
+func (s *sqlStore) applyFilter(query *gorm.DB, filter Filter, start, end time.Time) *gorm.DB {
+	query = query.Where("collected_at >= ? AND collected_at < ?", start, end)
+	if filter.ClusterID > 0 {
+		query = query.Where("cluster_id = ?", filter.ClusterID)
+	}
+	if filter.Namespace != "" {
+		query = query.Where("namespace = ?", filter.Namespace)
+	}
+	if filter.PodName != "" {
+		query = query.Where("pod_name = ?", filter.PodName)
+	}
+	if filter.WorkloadName != "" {
+		query = query.Where("workload_kind = ? AND workload_name = ?", filter.WorkloadKind, filter.WorkloadName)
+	}
+	if filter.MetricsOnly {
+		query = query.Where("(cpu_metrics_available = ? OR memory_metrics_available = ?)", true, true)
+	}
+	return query
+}
+
+// Query 查询时间范围内的原始样本
+func (s *sqlStore) Query(ctx context.Context, query RangeQuery) ([]models.PodMetricsHistory, error) {
+	var data []models.PodMetricsHistory
+	db := s.applyFilter(s.db(ctx).Model(&models.PodMetricsHistory{}), query.Filter, query.Start, query.End)
+	if err := db.Order("collected_at ASC").Find(&data).Error; err != nil {
+		return nil, fmt.Errorf("查询历史样本失败: %v", err)
+	}
+	return data, nil
+}
+
+// Scan 按采集时间升序逐行读取样本
+func (s *sqlStore) Scan(ctx context.Context, query RangeQuery, fn func(record *models.PodMetricsHistory) error) error {
+	db := s.applyFilter(s.db(ctx).Model(&models.PodMetricsHistory{}), query.Filter, query.Start, query.End)
+	rows, err := db.Order("collected_at ASC").Rows()
+	if err != nil {
+		return fmt.Errorf("查询历史样本失败: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var record models.PodMetricsHistory
+		if err := s.db(ctx).ScanRows(rows, &record); err != nil {
+			return fmt.Errorf("读取历史样本失败: %v", err)
+		}
+		if err := fn(&record); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("读取历史样本失败: %v", err)
+	}
+	return nil
+}
+
+// groupColumns 分组方式对应的列
+func groupColumns(groupBy string) []string {
+	switch groupBy {
+	case GroupNamespace:
+		return []string{"namespace"}
+	case GroupWorkload:
+		return []string{"namespace", "workload_kind", "workload_name"}
+	case GroupPod:
+		return []string{"namespace", "workload_kind", "workload_name", "pod_name"}
+	default:
+		return nil
+	}
+}
+
+// Aggregate 在数据库中按时间桶和分组聚合样本
+func (s *sqlStore) Aggregate(ctx context.Context, query AggregateQuery) ([]AggregatePoint, error) {
+	columns := groupColumns(query.GroupBy)
+
+	// 时间桶表达式中的占位符为桶长度（秒），分组子句不支持参数，直接写入整数秒数
+	bucket := "collected_at"
+	if query.Step > 0 {
+		bucket = strings.Replace(database.TimeBucketExpr("collected_at"), "?", strconv.FormatInt(int64(query.Step/time.Second), 10), 1)
+	}
+	groupBy := append([]string{bucket}, columns...)
+
+	selects := append([]string{
+		"MIN(collected_at) AS first_at",
+		"COUNT(DISTINCT collected_at) AS batches",
+		"COUNT(DISTINCT pod_name) AS pod_count",
+		"COUNT(DISTINCT CASE WHEN cpu_metrics_available THEN pod_name END) AS cpu_pod_count",
+		"COUNT(DISTINCT CASE WHEN memory_metrics_available THEN pod_name END) AS memory_pod_count",
+		"SUM(cpu_usage) AS cpu_usage",
+		"SUM(memory_usage) AS memory_usage",
+		"SUM(cpu_request) AS cpu_request",
+		"SUM(memory_request) AS memory_request",
+		"AVG(CASE WHEN cpu_metrics_available THEN cpu_req_pct END) AS cpu_req_pct",
+		"AVG(CASE WHEN memory_metrics_available THEN memory_req_pct END) AS memory_req_pct",
+	}, columns...)
+
+	type aggregateRow struct {
+		FirstAt        database.Timestamp
+		Batches        int
+		PodCount       int
+		CPUPodCount    int
+		MemoryPodCount int
+		CPUUsage       float64
+		MemoryUsage    float64
+		CPURequest     float64
+		MemoryRequest  float64
+		CPUReqPct      sql.NullFloat64
+		MemoryReqPct   sql.NullFloat64
+		Namespace      string
+		WorkloadKind   string
+		WorkloadName   string
+		PodName        string
+	}
+
+	var rows []aggregateRow
+	db := s.applyFilter(s.db(ctx).Model(&models.PodMetricsHistory{}), query.Filter, query.Start, query.End)
+	if err := db.Select(strings.Join(selects, ", ")).Group(strings.Join(groupBy, ", ")).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("聚合历史样本失败: %v", err)
+	}
+
+	points := make([]AggregatePoint, 0, len(rows))
+	for _, row := range rows {
+		if row.Batches == 0 {
+			continue
+		}
+		batches := float64(row.Batches)
+		points = append(points, AggregatePoint{
+			Time:           row.FirstAt.Time,
+			Namespace:      row.Namespace,
+			WorkloadKind:   row.WorkloadKind,
+			WorkloadName:   row.WorkloadName,
+			PodName:        row.PodName,
+			Batches:        row.Batches,
+			PodCount:       row.PodCount,
+			CPUPodCount:    row.CPUPodCount,
+			MemoryPodCount: row.MemoryPodCount,
+			CPUUsage:       row.CPUUsage / batches,
+			MemoryUsage:    row.MemoryUsage / batches,
+			CPURequest:     row.CPURequest / batches,
+			MemoryRequest:  row.MemoryRequest / batches,
+			CPUReqPct:      row.CPUReqPct.Float64,
+			MemoryReqPct:   row.MemoryReqPct.Float64,
+		})
+	}
+	sortPoints(points)
+	return points, nil
+}
+
+// Cleanup 删除早于 before 的样本
+func (s *sqlStore) Cleanup(ctx context.Context, before time.Time) (int64, error) {
+	return database.CleanupPodMetricsHistory(s.db(ctx), before)
+}
+
+// Close SQL存储由数据库连接管理，无需额外释放
+func (s *sqlStore) Close() error {
+	return nil
+}
+
+// sortPoints 按时间、命名空间、工作负载、Pod排序聚合结果
+func sortPoints(points []AggregatePoint) {
+	sort.Slice(points, func(i, j int) bool {
+		a, b := points[i], points[j]
+		if !a.Time.Equal(b.Time) {
+			return a.Time.Before(b.Time)
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.WorkloadName != b.WorkloadName {
+			return a.WorkloadName < b.WorkloadName
+		}
+		return a.PodName < b.PodName
+	})
+}
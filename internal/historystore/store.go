@@ -0,0 +1,201 @@
+package historystore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/models"
+)
+
+// 存储后端
+const (
+	BackendSQL  = "sql"
+	BackendTSDB = "tsdb"
+)
+
+// 聚合分组方式
+const (
+	GroupNone      = ""
+	GroupNamespace = "namespace"
+	GroupWorkload  = "workload"
+	GroupPod       = "pod"
+)
+
+// Filter 样本筛选条件，空值表示不筛选
+type Filter struct {
+	ClusterID    uint
+	Namespace    string
+	PodName      string
+	WorkloadKind string
+	WorkloadName string
+	MetricsOnly  bool // 只返回至少一项使用量来自真实metrics数据的样本，各项是否可用见分指标的可用标记
+}
+
+// RangeQuery 时间范围查询条件，包含 Start，不包含 End
+type RangeQuery struct {
+	Filter
+	Start time.Time
+	End   time.Time
+}
+
+// AggregateQuery 聚合查询条件
+type AggregateQuery struct {
+	Filter
+	Start   time.Time
+	End     time.Time
+	Step    time.Duration // 聚合时间桶长度，为0时每个采集批次一个点
+	GroupBy string        // 分组方式：空/namespace/workload/pod
+}
+
+// AggregatePoint 聚合结果中的一个数据点
+// 使用量和请求量为时间桶内各采集批次合计值的平均值，利用率为时间桶内该指标可用的Pod样本的平均值
+// 缺失的使用量按0计入合计，需要每Pod平均值时应除以对应指标的Pod数
+type AggregatePoint struct {
+	Time           time.Time `json:"time"` // 时间桶内最早的采集时间
+	Namespace      string    `json:"namespace,omitempty"`
+	WorkloadKind   string    `json:"workload_kind,omitempty"`
+	WorkloadName   string    `json:"workload_name,omitempty"`
+	PodName        string    `json:"pod_name,omitempty"`
+	Batches        int       `json:"batches"`          // 时间桶内的采集批次数
+	PodCount       int       `json:"pod_count"`        // 时间桶内出现过的Pod数
+	CPUPodCount    int       `json:"cpu_pod_count"`    // 时间桶内有真实CPU使用量的Pod数
+	MemoryPodCount int       `json:"memory_pod_count"` // 时间桶内有真实内存使用量的Pod数
+	CPUUsage       float64   `json:"cpu_usage"`
+	MemoryUsage    float64   `json:"memory_usage"`
+	CPURequest     float64   `json:"cpu_request"`
+	MemoryRequest  float64   `json:"memory_request"`
+	CPUReqPct      float64   `json:"cpu_req_pct"`
+	MemoryReqPct   float64   `json:"memory_req_pct"`
+}
+
+// HistoryStore Pod使用量样本存储接口 - 写入样本、按时间范围查询、聚合和过期清理
+type HistoryStore interface {
+	// Name 返回存储后端名称
+	Name() string
+	// Write 写入一批采集样本，records 需按采集时间升序排列
+	Write(ctx context.Context, records []models.PodMetricsHistory) error
+	// Query 查询时间范围内的原始样本，按采集时间升序返回
+	Query(ctx context.Context, query RangeQuery) ([]models.PodMetricsHistory, error)
+	// Scan 逐条遍历时间范围内的原始样本，不在内存中保留全部结果；同一Pod的样本按采集时间升序，
+	// 不同Pod之间不保证顺序，fn 返回错误时停止遍历并返回该错误
+	Scan(ctx context.Context, query RangeQuery, fn func(record *models.PodMetricsHistory) error) error
+	// Aggregate 按时间桶和分组聚合样本，按时间升序返回
+	Aggregate(ctx context.Context, query AggregateQuery) ([]AggregatePoint, error)
+	// Cleanup 删除早于 before 的样本，返回删除的样本数（无法统计时返回0）
+	Cleanup(ctx context.Context, before time.Time) (int64, error)
+	// Close 释放存储占用的资源
+	Close() error
+}
+
+// Config 历史数据存储配置
+type Config struct {
+	Backend       string // 存储后端：sql/tsdb
+	Path          string // TSDB 数据目录
+	RetentionDays int    // TSDB 数据保留天数
+}
+
+var (
+	defaultStore HistoryStore = &sqlStore{}
+	storeMutex   sync.RWMutex
+)
+
+// InitHistoryStore 按配置初始化全局历史数据存储，未配置时使用SQL存储
+func InitHistoryStore(config *Config) error {
+	if config == nil || config.Backend == "" || config.Backend == BackendSQL {
+		return nil
+	}
+	if config.Backend != BackendTSDB {
+		return fmt.Errorf("不支持的历史数据存储后端: %s", config.Backend)
+	}
+
+	tsdbStore, err := openTSDBStore(config.Path, config.RetentionDays)
+	if err != nil {
+		return err
+	}
+
+	storeMutex.Lock()
+	defaultStore = &mirroredStore{relational: &sqlStore{}, series: tsdbStore}
+	storeMutex.Unlock()
+
+	logger.Info("历史数据存储使用嵌入式TSDB，数据目录: %s，保留 %d 天", config.Path, config.RetentionDays)
+	return nil
+}
+
+// GetHistoryStore 获取全局历史数据存储
+func GetHistoryStore() HistoryStore {
+	storeMutex.RLock()
+	defer storeMutex.RUnlock()
+	return defaultStore
+}
+
+// CloseHistoryStore 关闭全局历史数据存储
+func CloseHistoryStore() error {
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+	err := defaultStore.Close()
+	defaultStore = &sqlStore{}
+	return err
+}
+
+// mirroredStore TSDB后端 - 关系型历史表继续写入，供分页查询等基于SQL的功能使用，
+// 时间范围查询、遍历和聚合由TSDB提供；TSDB只包含启用之后的样本，查询起点更早时由关系型历史表提供
+type mirroredStore struct {
+	relational HistoryStore
+	series     *tsdbStore
+}
+
+// Name 返回存储后端名称
+func (s *mirroredStore) Name() string {
+	return BackendTSDB
+}
+
+// Write 同时写入关系型历史表和TSDB
+func (s *mirroredStore) Write(ctx context.Context, records []models.PodMetricsHistory) error {
+	if err := s.relational.Write(ctx, records); err != nil {
+		return err
+	}
+	return s.series.Write(ctx, records)
+}
+
+// reader 选择查询使用的存储：TSDB没有样本或查询起点早于TSDB最早样本时使用关系型历史表
+func (s *mirroredStore) reader(start time.Time) HistoryStore {
+	if minTime, ok := s.series.minTime(); ok && !start.Before(minTime) {
+		return s.series
+	}
+	return s.relational
+}
+
+// Query 查询样本，时间范围完全在TSDB内时从TSDB读取
+func (s *mirroredStore) Query(ctx context.Context, query RangeQuery) ([]models.PodMetricsHistory, error) {
+	return s.reader(query.Start).Query(ctx, query)
+}
+
+// Scan 遍历样本，时间范围完全在TSDB内时从TSDB读取
+func (s *mirroredStore) Scan(ctx context.Context, query RangeQuery, fn func(record *models.PodMetricsHistory) error) error {
+	return s.reader(query.Start).Scan(ctx, query, fn)
+}
+
+// Aggregate 聚合样本，时间范围完全在TSDB内时由TSDB计算
+func (s *mirroredStore) Aggregate(ctx context.Context, query AggregateQuery) ([]AggregatePoint, error) {
+	return s.reader(query.Start).Aggregate(ctx, query)
+}
+
+// Cleanup 同时清理关系型历史表和TSDB
+func (s *mirroredStore) Cleanup(ctx context.Context, before time.Time) (int64, error) {
+	deleted, err := s.relational.Cleanup(ctx, before)
+	if err != nil {
+		return deleted, err
+	}
+	if _, err := s.series.Cleanup(ctx, before); err != nil {
+		return deleted, err
+	}
+	return deleted, nil
+}
+
+// Close 关闭TSDB
+func (s *mirroredStore) Close() error {
+	return s.series.Close()
+}
@@ -0,0 +1,257 @@
+package historystore
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"cluster-resource-insight/internal/database"
+	"cluster-resource-insight/internal/models"
+)
+
+// parityBase 测试样本的起始采集时间，取整到秒避免不同存储的时间精度差异
+var parityBase = time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+
+// paritySample 构造一条测试样本，cpu/memory 小于0表示该项指标缺失
+func paritySample(minute int, namespace, pod, workload, node, status string, cpu, memory int64) models.PodMetricsHistory {
+	record := models.PodMetricsHistory{
+		ClusterID:     1,
+		Namespace:     namespace,
+		PodName:       pod,
+		NodeName:      node,
+		WorkloadKind:  "Deployment",
+		WorkloadName:  workload,
+		QoSClass:      "Burstable",
+		Status:        status,
+		CPURequest:    500,
+		CPULimit:      1000,
+		MemoryRequest: 512 << 20,
+		MemoryLimit:   1024 << 20,
+		RestartCount:  int32(minute / 10),
+		CollectedAt:   parityBase.Add(time.Duration(minute) * time.Minute),
+	}
+	if cpu >= 0 {
+		record.CPUUsage, record.CPUMetricsAvailable = cpu, true
+	}
+	if memory >= 0 {
+		record.MemoryUsage, record.MemoryMetricsAvailable = memory, true
+	}
+	record.MetricsAvailable = record.CPUMetricsAvailable && record.MemoryMetricsAvailable
+	return record
+}
+
+// paritySamples 覆盖完整指标、只有单项指标、两项都缺失、节点迁移和状态变化的样本
+func paritySamples() []models.PodMetricsHistory {
+	return []models.PodMetricsHistory{
+		paritySample(0, "default", "web-1", "web", "node-a", "reasonable", 200, 300<<20),
+		paritySample(0, "default", "web-2", "web", "node-b", "reasonable", -1, 200<<20),
+		paritySample(0, "batch", "job-1", "job", "node-a", "unreasonable", 50, -1),
+		paritySample(5, "default", "web-1", "web", "node-c", "unreasonable", 400, 310<<20),
+		paritySample(5, "default", "web-2", "web", "node-b", "reasonable", -1, -1),
+		paritySample(5, "batch", "job-1", "job", "node-a", "unreasonable", 60, 100<<20),
+		paritySample(12, "default", "web-1", "web", "node-c", "reasonable", 300, -1),
+		paritySample(12, "batch", "job-1", "job", "node-a", "reasonable", 0, 0),
+	}
+}
+
+// openParityStores 打开写入相同样本的SQL存储和TSDB存储
+func openParityStores(t *testing.T) (HistoryStore, HistoryStore) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := database.InitDatabase(&database.DatabaseConfig{Driver: database.DriverSQLite, Path: filepath.Join(dir, "history.db")}); err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	t.Cleanup(func() { database.CloseDatabase() })
+	if err := database.CheckAndAutoMigrate(); err != nil {
+		t.Fatalf("数据库迁移失败: %v", err)
+	}
+	if err := database.GetDB().Create(&models.ClusterConfig{ID: 1, ClusterName: "test", APIServer: "https://127.0.0.1"}).Error; err != nil {
+		t.Fatalf("创建集群失败: %v", err)
+	}
+
+	series, err := openTSDBStore(filepath.Join(dir, "tsdb"), 0)
+	if err != nil {
+		t.Fatalf("打开TSDB失败: %v", err)
+	}
+	t.Cleanup(func() { series.Close() })
+
+	sql := &sqlStore{}
+	ctx := context.Background()
+	// 按采集批次写入，与采集流程一致
+	batches := make(map[time.Time][]models.PodMetricsHistory)
+	var times []time.Time
+	for _, record := range paritySamples() {
+		if _, ok := batches[record.CollectedAt]; !ok {
+			times = append(times, record.CollectedAt)
+		}
+		batches[record.CollectedAt] = append(batches[record.CollectedAt], record)
+	}
+	for _, at := range times {
+		for _, store := range []HistoryStore{sql, series} {
+			if err := store.Write(ctx, batches[at]); err != nil {
+				t.Fatalf("%s 写入样本失败: %v", store.Name(), err)
+			}
+		}
+	}
+	return sql, series
+}
+
+// comparableRecord 两种存储都保存的样本字段，问题描述和Pod标签不写入TSDB
+type comparableRecord struct {
+	CollectedAt                             int64
+	Namespace, PodName, NodeName, Status    string
+	WorkloadKind, WorkloadName, QoSClass    string
+	CPUUsage, CPURequest, CPULimit          int64
+	MemoryUsage, MemoryRequest, MemoryLimit int64
+	CPUReqPct, MemoryLimitPct               float64
+	CPUMetricsAvailable                     bool
+	MemoryMetricsAvailable                  bool
+	RestartCount                            int32
+}
+
+func comparableRecords(records []models.PodMetricsHistory) []comparableRecord {
+	result := make([]comparableRecord, 0, len(records))
+	for _, r := range records {
+		result = append(result, comparableRecord{
+			CollectedAt: r.CollectedAt.UnixMilli(),
+			Namespace:   r.Namespace, PodName: r.PodName, NodeName: r.NodeName, Status: r.Status,
+			WorkloadKind: r.WorkloadKind, WorkloadName: r.WorkloadName, QoSClass: r.QoSClass,
+			CPUUsage: r.CPUUsage, CPURequest: r.CPURequest, CPULimit: r.CPULimit,
+			MemoryUsage: r.MemoryUsage, MemoryRequest: r.MemoryRequest, MemoryLimit: r.MemoryLimit,
+			CPUReqPct:              math.Round(r.CPUReqPct*1e6) / 1e6,
+			MemoryLimitPct:         math.Round(r.MemoryLimitPct*1e6) / 1e6,
+			CPUMetricsAvailable:    r.CPUMetricsAvailable,
+			MemoryMetricsAvailable: r.MemoryMetricsAvailable,
+			RestartCount:           r.RestartCount,
+		})
+	}
+	// 相同采集时间的样本之间顺序不作要求
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].CollectedAt != result[j].CollectedAt {
+			return result[i].CollectedAt < result[j].CollectedAt
+		}
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].PodName < result[j].PodName
+	})
+	return result
+}
+
+func TestStoresReturnSameQueryResults(t *testing.T) {
+	sql, series := openParityStores(t)
+	end := parityBase.Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		query     RangeQuery
+		wantCount int
+	}{
+		{"全部样本", RangeQuery{Start: parityBase, End: end}, 8},
+		{"只含有真实指标的样本", RangeQuery{Filter: Filter{MetricsOnly: true}, Start: parityBase, End: end}, 7},
+		{"按命名空间", RangeQuery{Filter: Filter{ClusterID: 1, Namespace: "batch"}, Start: parityBase, End: end}, 3},
+		{"按工作负载", RangeQuery{Filter: Filter{WorkloadKind: "Deployment", WorkloadName: "web"}, Start: parityBase, End: end}, 5},
+		{"按Pod", RangeQuery{Filter: Filter{PodName: "web-1"}, Start: parityBase, End: end}, 3},
+		{"结束时间不包含", RangeQuery{Start: parityBase, End: parityBase.Add(5 * time.Minute)}, 3},
+		{"开始时间包含", RangeQuery{Start: parityBase.Add(5 * time.Minute), End: end}, 5},
+		{"时间范围内没有样本", RangeQuery{Start: end, End: end.Add(time.Hour)}, 0},
+		{"没有匹配的集群", RangeQuery{Filter: Filter{ClusterID: 2}, Start: parityBase, End: end}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			sqlRecords, err := sql.Query(ctx, tt.query)
+			if err != nil {
+				t.Fatalf("SQL查询失败: %v", err)
+			}
+			tsdbRecords, err := series.Query(ctx, tt.query)
+			if err != nil {
+				t.Fatalf("TSDB查询失败: %v", err)
+			}
+			if len(sqlRecords) != tt.wantCount {
+				t.Fatalf("SQL返回 %d 条样本，期望 %d 条", len(sqlRecords), tt.wantCount)
+			}
+			got, want := comparableRecords(tsdbRecords), comparableRecords(sqlRecords)
+			if len(got) != len(want) {
+				t.Fatalf("TSDB返回 %d 条样本，SQL返回 %d 条", len(got), len(want))
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Errorf("第 %d 条样本不一致:\nTSDB %+v\nSQL  %+v", i, got[i], want[i])
+				}
+			}
+
+			// Scan 与 Query 返回相同的样本
+			var scanned []models.PodMetricsHistory
+			if err := series.Scan(ctx, tt.query, func(record *models.PodMetricsHistory) error {
+				scanned = append(scanned, *record)
+				return nil
+			}); err != nil {
+				t.Fatalf("TSDB遍历失败: %v", err)
+			}
+			if got := comparableRecords(scanned); len(got) != len(want) {
+				t.Errorf("TSDB遍历返回 %d 条样本，SQL查询返回 %d 条", len(got), len(want))
+			}
+		})
+	}
+}
+
+func TestStoresReturnSameAggregateResults(t *testing.T) {
+	sql, series := openParityStores(t)
+	end := parityBase.Add(time.Hour)
+
+	tests := []struct {
+		name       string
+		query      AggregateQuery
+		wantPoints int
+	}{
+		{"每个采集批次一个点", AggregateQuery{Start: parityBase, End: end}, 3},
+		{"按时间桶合并批次", AggregateQuery{Start: parityBase, End: end, Step: 10 * time.Minute}, 2},
+		{"按命名空间分组", AggregateQuery{Start: parityBase, End: end, Step: 10 * time.Minute, GroupBy: GroupNamespace}, 4},
+		{"按工作负载分组", AggregateQuery{Start: parityBase, End: end, GroupBy: GroupWorkload}, 6},
+		{"按Pod分组", AggregateQuery{Start: parityBase, End: end, Step: time.Hour, GroupBy: GroupPod}, 3},
+		{"只含有真实指标的样本", AggregateQuery{Filter: Filter{MetricsOnly: true}, Start: parityBase, End: end, GroupBy: GroupPod}, 7},
+		{"按工作负载筛选", AggregateQuery{Filter: Filter{WorkloadKind: "Deployment", WorkloadName: "web"}, Start: parityBase, End: end}, 3},
+		{"时间范围内没有样本", AggregateQuery{Start: end, End: end.Add(time.Hour)}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			want, err := sql.Aggregate(ctx, tt.query)
+			if err != nil {
+				t.Fatalf("SQL聚合失败: %v", err)
+			}
+			got, err := series.Aggregate(ctx, tt.query)
+			if err != nil {
+				t.Fatalf("TSDB聚合失败: %v", err)
+			}
+			if len(want) != tt.wantPoints {
+				t.Fatalf("SQL返回 %d 个点，期望 %d 个", len(want), tt.wantPoints)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("TSDB返回 %d 个点，SQL返回 %d 个", len(got), len(want))
+			}
+			for i := range want {
+				if !samePoint(got[i], want[i]) {
+					t.Errorf("第 %d 个点不一致:\nTSDB %+v\nSQL  %+v", i, got[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+// samePoint 比较两个聚合点，浮点值允许计算顺序带来的误差
+func samePoint(a, b AggregatePoint) bool {
+	near := func(x, y float64) bool { return math.Abs(x-y) <= 1e-6*math.Max(1, math.Abs(y)) }
+	return a.Time.Equal(b.Time) &&
+		a.Namespace == b.Namespace && a.WorkloadKind == b.WorkloadKind &&
+		a.WorkloadName == b.WorkloadName && a.PodName == b.PodName &&
+		a.Batches == b.Batches && a.PodCount == b.PodCount &&
+		a.CPUPodCount == b.CPUPodCount && a.MemoryPodCount == b.MemoryPodCount &&
+		near(a.CPUUsage, b.CPUUsage) && near(a.MemoryUsage, b.MemoryUsage) &&
+		near(a.CPURequest, b.CPURequest) && near(a.MemoryRequest, b.MemoryRequest) &&
+		near(a.CPUReqPct, b.CPUReqPct) && near(a.MemoryReqPct, b.MemoryReqPct)
+}
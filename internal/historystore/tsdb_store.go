@@ -0,0 +1,433 @@
+package historystore
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"cluster-resource-insight/internal/models"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+)
+
+// TSDB 中的指标名称，单位与历史表一致：CPU为millicores，内存为字节
+const (
+	metricCPUUsage         = "pod_cpu_usage_millicores"
+	metricCPURequest       = "pod_cpu_request_millicores"
+	metricCPULimit         = "pod_cpu_limit_millicores"
+	metricMemoryUsage      = "pod_memory_usage_bytes"
+	metricMemoryRequest    = "pod_memory_request_bytes"
+	metricMemoryLimit      = "pod_memory_limit_bytes"
+	metricRestartCount     = "pod_restart_count"
+	metricMetricsAvailable = "pod_metrics_available"
+	metricCPUAvailable     = "pod_cpu_metrics_available"
+	metricMemoryAvailable  = "pod_memory_metrics_available"
+	metricOOMKilled        = "pod_oom_killed"
+	metricPodInfo          = "pod_info" // 值恒为1，节点和状态作为该序列的标签保存
+)
+
+// 序列标签名称
+const (
+	labelClusterID    = "cluster_id"
+	labelNamespace    = "namespace"
+	labelPod          = "pod"
+	labelWorkloadKind = "workload_kind"
+	labelWorkloadName = "workload_name"
+	labelNode         = "node"
+	labelQoSClass     = "qos_class"
+	labelStatus       = "status"
+)
+
+// tsdbMetricPattern 匹配本存储写入的全部指标
+const tsdbMetricPattern = "pod_.+"
+
+// tsdbStore 基于 Prometheus TSDB 的嵌入式时序存储，每个Pod的每项指标为一条时间序列
+// 指标序列只以Pod身份为标签；节点和状态会随采集变化，作为样本元数据写入单独的 pod_info 序列，
+// 状态变化时只产生一条新的 pod_info 序列，指标序列保持连续
+// 问题描述和Pod标签不写入TSDB，查询结果中这两个字段为空
+type tsdbStore struct {
+	db *tsdb.DB
+}
+
+// openTSDBStore 打开（不存在时创建）TSDB 数据目录
+func openTSDBStore(path string, retentionDays int) (*tsdbStore, error) {
+	if path == "" {
+		return nil, fmt.Errorf("TSDB数据目录不能为空")
+	}
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, fmt.Errorf("创建TSDB数据目录失败: %v", err)
+	}
+
+	opts := tsdb.DefaultOptions()
+	if retentionDays > 0 {
+		opts.RetentionDuration = int64(time.Duration(retentionDays) * 24 * time.Hour / time.Millisecond)
+	}
+
+	db, err := tsdb.Open(path, nil, nil, opts, nil)
+	if err != nil {
+		return nil, fmt.Errorf("打开TSDB失败: %v", err)
+	}
+	return &tsdbStore{db: db}, nil
+}
+
+// Name 返回存储后端名称
+func (s *tsdbStore) Name() string {
+	return BackendTSDB
+}
+
+// seriesLabels 构造样本的序列标签，空值标签不写入；节点和状态只写入 pod_info 序列
+func seriesLabels(metric string, record *models.PodMetricsHistory) labels.Labels {
+	builder := labels.NewBuilder(labels.EmptyLabels())
+	builder.Set(labels.MetricName, metric)
+	builder.Set(labelClusterID, fmt.Sprintf("%d", record.ClusterID))
+	builder.Set(labelNamespace, record.Namespace)
+	builder.Set(labelPod, record.PodName)
+	builder.Set(labelWorkloadKind, record.WorkloadKind)
+	builder.Set(labelWorkloadName, record.WorkloadName)
+	builder.Set(labelQoSClass, record.QoSClass)
+	if metric == metricPodInfo {
+		builder.Set(labelNode, record.NodeName)
+		builder.Set(labelStatus, record.Status)
+	}
+	return builder.Labels()
+}
+
+// boolValue 布尔值转换为样本值
+func boolValue(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// Write 将一批采集样本写入TSDB
+func (s *tsdbStore) Write(ctx context.Context, records []models.PodMetricsHistory) error {
+	app := s.db.Appender(ctx)
+	for i := range records {
+		record := &records[i]
+		ts := record.CollectedAt.UnixMilli()
+		values := map[string]float64{
+			metricCPURequest:       float64(record.CPURequest),
+			metricCPULimit:         float64(record.CPULimit),
+			metricMemoryRequest:    float64(record.MemoryRequest),
+			metricMemoryLimit:      float64(record.MemoryLimit),
+			metricRestartCount:     float64(record.RestartCount),
+			metricMetricsAvailable: boolValue(record.CPUMetricsAvailable && record.MemoryMetricsAvailable),
+			metricCPUAvailable:     boolValue(record.CPUMetricsAvailable),
+			metricMemoryAvailable:  boolValue(record.MemoryMetricsAvailable),
+			metricOOMKilled:        boolValue(record.OOMKilled),
+			metricPodInfo:          1,
+		}
+		// 只写入真实使用量，缺失的指标读取时为0
+		if record.CPUMetricsAvailable {
+			values[metricCPUUsage] = float64(record.CPUUsage)
+		}
+		if record.MemoryMetricsAvailable {
+			values[metricMemoryUsage] = float64(record.MemoryUsage)
+		}
+		for metric, value := range values {
+			if _, err := app.Append(0, seriesLabels(metric, record), ts, value); err != nil {
+				app.Rollback()
+				return fmt.Errorf("写入TSDB样本失败: %v", err)
+			}
+		}
+	}
+	if err := app.Commit(); err != nil {
+		return fmt.Errorf("提交TSDB样本失败: %v", err)
+	}
+	return nil
+}
+
+// filterMatchers 将筛选条件转换为标签匹配器
+func filterMatchers(filter Filter) []*labels.Matcher {
+	matchers := []*labels.Matcher{
+		labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, tsdbMetricPattern),
+	}
+	if filter.ClusterID > 0 {
+		matchers = append(matchers, labels.MustNewMatcher(labels.MatchEqual, labelClusterID, fmt.Sprintf("%d", filter.ClusterID)))
+	}
+	if filter.Namespace != "" {
+		matchers = append(matchers, labels.MustNewMatcher(labels.MatchEqual, labelNamespace, filter.Namespace))
+	}
+	if filter.PodName != "" {
+		matchers = append(matchers, labels.MustNewMatcher(labels.MatchEqual, labelPod, filter.PodName))
+	}
+	if filter.WorkloadName != "" {
+		matchers = append(matchers,
+			labels.MustNewMatcher(labels.MatchEqual, labelWorkloadKind, filter.WorkloadKind),
+			labels.MustNewMatcher(labels.MatchEqual, labelWorkloadName, filter.WorkloadName))
+	}
+	return matchers
+}
+
+// podKey 同一Pod的各指标序列
+type podKey struct {
+	clusterID string
+	namespace string
+	pod       string
+}
+
+// selectPodSeries 查询匹配的序列并按Pod分组，样本在迭代序列时才从块中读取
+func selectPodSeries(ctx context.Context, querier storage.Querier, filter Filter) ([][]storage.Series, error) {
+	index := make(map[podKey]int)
+	var groups [][]storage.Series
+	set := querier.Select(ctx, false, nil, filterMatchers(filter)...)
+	for set.Next() {
+		series := set.At()
+		lset := series.Labels()
+		key := podKey{clusterID: lset.Get(labelClusterID), namespace: lset.Get(labelNamespace), pod: lset.Get(labelPod)}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], series)
+	}
+	if err := set.Err(); err != nil {
+		return nil, fmt.Errorf("查询TSDB失败: %v", err)
+	}
+	return groups, nil
+}
+
+// readPodRecords 合并同一Pod各指标序列中相同采集时间的样本，还原为历史记录
+func readPodRecords(podSeries []storage.Series, metricsOnly bool, it chunkenc.Iterator) ([]*models.PodMetricsHistory, chunkenc.Iterator, error) {
+	records := make(map[int64]*models.PodMetricsHistory)
+	for _, series := range podSeries {
+		lset := series.Labels()
+		metric := lset.Get(labels.MetricName)
+
+		it = series.Iterator(it)
+		for it.Next() == chunkenc.ValFloat {
+			ts, value := it.At()
+			record, ok := records[ts]
+			if !ok {
+				record = recordFromLabels(lset, ts)
+				records[ts] = record
+			}
+			if metric == metricPodInfo {
+				record.NodeName, record.Status = lset.Get(labelNode), lset.Get(labelStatus)
+				continue
+			}
+			applySample(record, metric, value)
+		}
+		if err := it.Err(); err != nil {
+			return nil, it, fmt.Errorf("读取TSDB样本失败: %v", err)
+		}
+	}
+
+	data := make([]*models.PodMetricsHistory, 0, len(records))
+	for _, record := range records {
+		if metricsOnly && !record.CPUMetricsAvailable && !record.MemoryMetricsAvailable {
+			continue
+		}
+		fillPercentages(record)
+		data = append(data, record)
+	}
+	return data, it, nil
+}
+
+// Query 查询时间范围内的样本，将各指标序列还原为历史记录
+func (s *tsdbStore) Query(ctx context.Context, query RangeQuery) ([]models.PodMetricsHistory, error) {
+	// TSDB 查询区间两端都包含，结束时间减1毫秒保持左闭右开
+	querier, err := s.db.Querier(query.Start.UnixMilli(), query.End.UnixMilli()-1)
+	if err != nil {
+		return nil, fmt.Errorf("创建TSDB查询失败: %v", err)
+	}
+	defer querier.Close()
+
+	groups, err := selectPodSeries(ctx, querier, query.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []models.PodMetricsHistory
+	var it chunkenc.Iterator
+	for _, podSeries := range groups {
+		var records []*models.PodMetricsHistory
+		records, it, err = readPodRecords(podSeries, query.MetricsOnly, it)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			data = append(data, *record)
+		}
+	}
+	sort.Slice(data, func(i, j int) bool {
+		if !data[i].CollectedAt.Equal(data[j].CollectedAt) {
+			return data[i].CollectedAt.Before(data[j].CollectedAt)
+		}
+		if data[i].Namespace != data[j].Namespace {
+			return data[i].Namespace < data[j].Namespace
+		}
+		return data[i].PodName < data[j].PodName
+	})
+	return data, nil
+}
+
+// Scan 逐个Pod读取样本并按采集时间升序回调，内存中只保留当前Pod的样本
+func (s *tsdbStore) Scan(ctx context.Context, query RangeQuery, fn func(record *models.PodMetricsHistory) error) error {
+	querier, err := s.db.Querier(query.Start.UnixMilli(), query.End.UnixMilli()-1)
+	if err != nil {
+		return fmt.Errorf("创建TSDB查询失败: %v", err)
+	}
+	defer querier.Close()
+
+	groups, err := selectPodSeries(ctx, querier, query.Filter)
+	if err != nil {
+		return err
+	}
+
+	var it chunkenc.Iterator
+	for _, podSeries := range groups {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var records []*models.PodMetricsHistory
+		records, it, err = readPodRecords(podSeries, query.MetricsOnly, it)
+		if err != nil {
+			return err
+		}
+		sort.Slice(records, func(i, j int) bool { return records[i].CollectedAt.Before(records[j].CollectedAt) })
+		for _, record := range records {
+			if err := fn(record); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// recordFromLabels 由序列标签创建历史记录，早期版本写入的指标序列带有节点和状态标签，一并读取
+func recordFromLabels(lset labels.Labels, ts int64) *models.PodMetricsHistory {
+	var clusterID uint
+	fmt.Sscanf(lset.Get(labelClusterID), "%d", &clusterID)
+	collectedAt := time.UnixMilli(ts)
+	return &models.PodMetricsHistory{
+		ClusterID:    clusterID,
+		Namespace:    lset.Get(labelNamespace),
+		PodName:      lset.Get(labelPod),
+		NodeName:     lset.Get(labelNode),
+		WorkloadKind: lset.Get(labelWorkloadKind),
+		WorkloadName: lset.Get(labelWorkloadName),
+		QoSClass:     lset.Get(labelQoSClass),
+		Status:       lset.Get(labelStatus),
+		CollectedAt:  collectedAt,
+		CreatedAt:    collectedAt,
+	}
+}
+
+// applySample 将一个指标样本值写入历史记录
+func applySample(record *models.PodMetricsHistory, metric string, value float64) {
+	switch metric {
+	case metricCPUUsage:
+		record.CPUUsage = int64(value)
+	case metricCPURequest:
+		record.CPURequest = int64(value)
+	case metricCPULimit:
+		record.CPULimit = int64(value)
+	case metricMemoryUsage:
+		record.MemoryUsage = int64(value)
+	case metricMemoryRequest:
+		record.MemoryRequest = int64(value)
+	case metricMemoryLimit:
+		record.MemoryLimit = int64(value)
+	case metricRestartCount:
+		record.RestartCount = int32(value)
+	case metricMetricsAvailable:
+		// 早期写入的样本没有分指标的可用标记，整体可用即两项均可用
+		record.MetricsAvailable = value > 0
+		if value > 0 {
+			record.CPUMetricsAvailable = true
+			record.MemoryMetricsAvailable = true
+		}
+	case metricCPUAvailable:
+		record.CPUMetricsAvailable = value > 0
+	case metricMemoryAvailable:
+		record.MemoryMetricsAvailable = value > 0
+	case metricOOMKilled:
+		record.OOMKilled = value > 0
+	}
+}
+
+// percentage 计算使用量占比，与历史视图中的计算方式一致
+func percentage(usage, base int64) float64 {
+	if base <= 0 {
+		return 0
+	}
+	return float64(usage) * 100.0 / float64(base)
+}
+
+// fillPercentages 根据使用量、请求量和限制量计算利用率
+func fillPercentages(record *models.PodMetricsHistory) {
+	record.CPUReqPct = percentage(record.CPUUsage, record.CPURequest)
+	record.CPULimitPct = percentage(record.CPUUsage, record.CPULimit)
+	record.MemoryReqPct = percentage(record.MemoryUsage, record.MemoryRequest)
+	record.MemoryLimitPct = percentage(record.MemoryUsage, record.MemoryLimit)
+}
+
+// Aggregate 逐个Pod读取样本并累计到时间桶，内存中只保留当前Pod的样本和聚合结果
+func (s *tsdbStore) Aggregate(ctx context.Context, query AggregateQuery) ([]AggregatePoint, error) {
+	querier, err := s.db.Querier(query.Start.UnixMilli(), query.End.UnixMilli()-1)
+	if err != nil {
+		return nil, fmt.Errorf("创建TSDB查询失败: %v", err)
+	}
+	defer querier.Close()
+
+	groups, err := selectPodSeries(ctx, querier, query.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	agg := newAggregator(query.Step, query.GroupBy)
+	var it chunkenc.Iterator
+	for _, podSeries := range groups {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		var records []*models.PodMetricsHistory
+		records, it, err = readPodRecords(podSeries, query.MetricsOnly, it)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			agg.add(record)
+		}
+	}
+	return agg.points(), nil
+}
+
+// minTime 返回TSDB中最早样本的时间，没有样本时返回false
+// 块的最小时间不反映墓碑删除，清理后可能早于实际最早样本
+func (s *tsdbStore) minTime() (time.Time, bool) {
+	mint := s.db.Head().MinTime()
+	for _, block := range s.db.Blocks() {
+		if blockMin := block.Meta().MinTime; blockMin < mint {
+			mint = blockMin
+		}
+	}
+	if mint == math.MaxInt64 {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(mint), true
+}
+
+// Cleanup 删除早于 before 的样本，TSDB 删除为墓碑标记，无法统计删除数量
+func (s *tsdbStore) Cleanup(ctx context.Context, before time.Time) (int64, error) {
+	matcher := labels.MustNewMatcher(labels.MatchRegexp, labels.MetricName, tsdbMetricPattern)
+	if err := s.db.Delete(ctx, math.MinInt64, before.UnixMilli()-1, matcher); err != nil {
+		return 0, fmt.Errorf("清理TSDB样本失败: %v", err)
+	}
+	return 0, nil
+}
+
+// Close 关闭TSDB，将内存中的数据落盘
+func (s *tsdbStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// JSONText 以JSON文本存储的字段，列类型随数据库方言变化：MySQL为json、PostgreSQL为jsonb、SQLite为text
+type JSONText string
+
+// GormDBDataType 根据当前数据库方言返回列类型
+func (JSONText) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	switch db.Dialector.Name() {
+	case "mysql":
+		return "json"
+	case "postgres":
+		return "jsonb"
+	default:
+		return "text"
+	}
+}
+
+// Value 写入数据库，空字符串写为NULL，避免json/jsonb列拒绝空文档
+func (j JSONText) Value() (driver.Value, error) {
+	if j == "" {
+		return nil, nil
+	}
+	return string(j), nil
+}
+
+// Scan 从数据库读取，兼容驱动返回的字符串和字节切片
+func (j *JSONText) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*j = ""
+	case []byte:
+		*j = JSONText(v)
+	case string:
+		*j = JSONText(v)
+	default:
+		return fmt.Errorf("无法将 %T 转换为JSONText", value)
+	}
+	return nil
+}
@@ -14,7 +14,7 @@ type ClusterConfig struct {
 	AuthType        string    `gorm:"size:20;not null;default:'kubeconfig'" json:"auth_type"`    // 认证类型：token/cert/kubeconfig
 	AuthConfig      string    `gorm:"type:text" json:"auth_config"`                              // 认证配置（加密存储的JSON）
 	Status          string    `gorm:"size:20;default:'unknown'" json:"status"`                   // 集群状态：online/offline/unknown
-	Tags            string    `gorm:"type:json" json:"tags"`                                     // 集群标签（JSON格式）
+	Tags            JSONText  `json:"tags"`                                                      // 集群标签（JSON格式）
 	CollectInterval int       `gorm:"default:30" json:"collect_interval"`                        // 采集间隔（分钟）
 	LastCollectAt   *time.Time `json:"last_collect_at"`                                          // 最后采集时间
 	CreatedAt       time.Time `json:"created_at"`
@@ -22,13 +22,17 @@ type ClusterConfig struct {
 	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`                                       // 软删除
 }
 
-// PodMetricsHistory Pod 监控历史表模型
+// PodMetricsHistory Pod 监控历史模型 - 对应由维度表、规格版本表和指标事实表联接而成的只读视图
+// 写入请使用 database.SavePodMetricsHistory，视图不支持直接插入和删除
 type PodMetricsHistory struct {
 	ID            uint      `gorm:"primaryKey" json:"id"`
 	ClusterID     uint      `gorm:"index;not null" json:"cluster_id"`                    // 集群ID，建立索引
 	Namespace     string    `gorm:"size:100;not null;index" json:"namespace"`           // 命名空间，建立索引
 	PodName       string    `gorm:"size:255;not null;index" json:"pod_name"`            // Pod名称，建立索引
 	NodeName      string    `gorm:"size:100" json:"node_name"`                          // 节点名称
+	WorkloadKind  string    `gorm:"size:50" json:"workload_kind"`                       // 工作负载类型
+	WorkloadName  string    `gorm:"size:255;index" json:"workload_name"`               // 工作负载名称
+	QoSClass      string    `gorm:"size:20" json:"qos_class"`                           // 服务质量等级
 	
 	// 内存相关字段（单位：字节）
 	MemoryUsage   int64     `json:"memory_usage"`                                       // 内存实际使用量
@@ -46,10 +50,16 @@ type PodMetricsHistory struct {
 	
 	// 状态和问题描述
 	Status        string    `gorm:"size:20;default:'reasonable'" json:"status"`         // 状态：reasonable/unreasonable
-	Issues        string    `gorm:"type:json" json:"issues"`                            // 问题描述（JSON数组）
+	Issues        JSONText  `json:"issues"`                                             // 问题描述（JSON数组）
+	MetricsAvailable bool   `gorm:"default:true" json:"metrics_available"`              // CPU和内存使用量是否都来自真实metrics数据
+	CPUMetricsAvailable    bool `json:"cpu_metrics_available"`                      // CPU使用量是否来自真实数据，否则使用量为0
+	MemoryMetricsAvailable bool `json:"memory_metrics_available"`                   // 内存使用量是否来自真实数据，否则使用量为0
+	RestartCount  int32     `gorm:"default:0" json:"restart_count"`                     // 各容器累计重启次数之和
+	OOMKilled     bool      `gorm:"column:oom_killed;default:false" json:"oom_killed"`  // 是否有容器最近一次因OOM被终止
 	
 	CollectedAt   time.Time `gorm:"index" json:"collected_at"`                          // 采集时间，建立索引
 	CreatedAt     time.Time `json:"created_at"`
+	Labels        JSONText  `json:"labels,omitempty"`                                   // Pod标签（JSON对象），来自维度表
 	
 	// 外键关联
 	Cluster       ClusterConfig `gorm:"foreignKey:ClusterID" json:"cluster,omitempty"`
@@ -71,10 +81,10 @@ type AlertRule struct {
 	ID              uint      `gorm:"primaryKey" json:"id"`
 	Name            string    `gorm:"size:100;not null" json:"name"`                     // 规则名称
 	Type            string    `gorm:"size:50;not null" json:"type"`                      // 规则类型：resource_usage/config_missing/cluster_error
-	Conditions      string    `gorm:"type:json" json:"conditions"`                       // 告警条件（JSON格式）
+	Conditions      JSONText  `json:"conditions"`                                        // 告警条件（JSON格式）
 	Severity        string    `gorm:"size:20;default:'warning'" json:"severity"`         // 严重程度：info/warning/error/critical
 	Enabled         bool      `gorm:"default:true" json:"enabled"`                       // 是否启用
-	NotifyChannels  string    `gorm:"type:json" json:"notify_channels"`                  // 通知渠道（JSON数组）
+	NotifyChannels  JSONText  `json:"notify_channels"`                                   // 通知渠道（JSON数组）
 	Description     string    `gorm:"size:500" json:"description"`                       // 规则描述
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
@@ -116,13 +126,297 @@ type SystemActivity struct {
 	Title       string    `gorm:"size:255;not null" json:"title"`                // 活动标题
 	Message     string    `gorm:"type:text" json:"message"`                      // 活动详情
 	Source      string    `gorm:"size:50" json:"source"`                         // 来源：collector/scheduler/api/system
-	Details     string    `gorm:"type:json" json:"details"`                      // 详细信息（JSON格式）
+	Details     JSONText  `json:"details"`                                       // 详细信息（JSON格式）
 	CreatedAt   time.Time `gorm:"index" json:"created_at"`
 	
 	// 外键关联
 	Cluster     ClusterConfig `gorm:"foreignKey:ClusterID" json:"cluster,omitempty"`
 }
 
+// ClusterCapacitySnapshot 集群容量快照表模型 - 每次采集记录一次，用于容量预测
+type ClusterCapacitySnapshot struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	ClusterID         uint      `gorm:"index;not null" json:"cluster_id"`            // 集群ID
+	NodeCount         int       `json:"node_count"`                                  // 节点数量
+	AllocatableCPU    int64     `json:"allocatable_cpu"`                             // 可分配CPU总量 (millicores)
+	AllocatableMemory int64     `json:"allocatable_memory"`                          // 可分配内存总量 (bytes)
+	CPURequest        int64     `json:"cpu_request"`                                 // CPU请求总量 (millicores)
+	MemoryRequest     int64     `json:"memory_request"`                              // 内存请求总量 (bytes)
+	CPUUsage          int64     `json:"cpu_usage"`                                   // CPU使用总量 (millicores)
+	MemoryUsage       int64     `json:"memory_usage"`                                // 内存使用总量 (bytes)
+	PodCount          int       `json:"pod_count"`                                   // Pod数量
+	UsageMissing      bool      `gorm:"default:false" json:"usage_missing"`          // 本次采集没有任何Pod的真实使用量，使用量不参与预测
+	CollectedAt       time.Time `gorm:"index" json:"collected_at"`                   // 采集时间
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// ResourceQuotaSnapshot 资源配额快照表模型 - 记录每个ResourceQuota各资源项的上限和已用量
+type ResourceQuotaSnapshot struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	ClusterID   uint      `gorm:"index;not null" json:"cluster_id"`                  // 集群ID
+	Namespace   string    `gorm:"size:100;not null;index" json:"namespace"`          // 命名空间
+	QuotaName   string    `gorm:"size:255;not null" json:"quota_name"`               // ResourceQuota名称
+	Resource    string    `gorm:"size:100;not null" json:"resource"`                 // 资源项，如requests.cpu
+	Hard        int64     `json:"hard"`                                              // 配额上限（CPU为millicores，其余为原始值）
+	Used        int64     `json:"used"`                                              // 已用量
+	CollectedAt time.Time `gorm:"index" json:"collected_at"`                         // 采集时间
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ResourceAnomaly 资源使用异常记录表模型 - 记录工作负载或命名空间使用量偏离基线的事件
+type ResourceAnomaly struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	ClusterID    uint      `gorm:"index;not null" json:"cluster_id"`                 // 集群ID
+	Scope        string    `gorm:"size:20;not null" json:"scope"`                    // 检测范围：workload/namespace
+	Namespace    string    `gorm:"size:100;not null;index" json:"namespace"`         // 命名空间
+	WorkloadKind string    `gorm:"size:50" json:"workload_kind"`                     // 工作负载类型，命名空间范围时为空
+	WorkloadName string    `gorm:"size:255" json:"workload_name"`                    // 工作负载名称，命名空间范围时为空
+	Metric       string    `gorm:"size:50;not null" json:"metric"`                   // 指标：cpu_usage/memory_usage
+	AnomalyType  string    `gorm:"size:20;not null;index" json:"anomaly_type"`       // 异常类型：spike/drop/zero
+	Value        float64   `json:"value"`                                            // 当前值
+	Baseline     float64   `json:"baseline"`                                         // 基线值（EWMA）
+	StdDev       float64   `json:"std_dev"`                                          // 基线标准差
+	ZScore       float64   `json:"z_score"`                                          // 偏离程度
+	DeviationPct float64   `json:"deviation_pct"`                                    // 相对基线的变化百分比
+	Severity     string    `gorm:"size:20" json:"severity"`                          // 严重程度：warning/error/critical
+	DetectedAt   time.Time `gorm:"index" json:"detected_at"`                         // 检测时间
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ResourceChangeRequest 资源调整变更单表模型 - 记录资源调整从试运行、审批、应用到回滚的全过程
+type ResourceChangeRequest struct {
+	ID               uint       `gorm:"primaryKey" json:"id"`
+	ClusterID        uint       `gorm:"index;not null" json:"cluster_id"`           // 集群ID
+	Namespace        string     `gorm:"size:100;not null;index" json:"namespace"`   // 命名空间
+	WorkloadKind     string     `gorm:"size:50;not null" json:"workload_kind"`      // 工作负载类型：Deployment/StatefulSet/DaemonSet
+	WorkloadName     string     `gorm:"size:255;not null" json:"workload_name"`     // 工作负载名称
+	Status           string     `gorm:"size:30;not null;index" json:"status"`       // 变更状态
+	ProposedSpec     string     `gorm:"type:text" json:"proposed_spec"`             // 建议的容器资源配置（JSON数组）
+	PreviousSpec     string     `gorm:"type:text" json:"previous_spec"`             // 应用前的容器资源配置（JSON数组），用于回滚
+	DryRunResult     string     `gorm:"type:text" json:"dry_run_result"`            // 服务端试运行结果
+	RequestedBy      string     `gorm:"size:100" json:"requested_by"`               // 提交人
+	ApprovedBy       string     `gorm:"size:100" json:"approved_by"`                // 审批人
+	Reason           string     `gorm:"type:text" json:"reason"`                    // 拒绝、失败或回滚原因
+	ApprovedAt       *time.Time `json:"approved_at"`                                // 审批时间
+	AppliedAt        *time.Time `json:"applied_at"`                                 // 应用时间
+	HealthCheckUntil *time.Time `json:"health_check_until"`                         // 健康观察截止时间
+	RolledBackAt     *time.Time `json:"rolled_back_at"`                             // 回滚时间
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// RecommendationSnapshot 资源建议快照 - 记录生成建议时的配置和建议值，用于跟踪建议采纳情况
+// 资源量均为单Pod各容器之和
+type RecommendationSnapshot struct {
+	ID                       uint       `gorm:"primaryKey" json:"id"`
+	ClusterID                uint       `gorm:"index;not null" json:"cluster_id"`              // 集群ID
+	Namespace                string     `gorm:"size:100;not null;index" json:"namespace"`      // 命名空间
+	Team                     string     `gorm:"size:100;index" json:"team"`                    // 所属团队（取自命名空间标签）
+	WorkloadKind             string     `gorm:"size:50;not null" json:"workload_kind"`         // 工作负载类型
+	WorkloadName             string     `gorm:"size:255;not null;index" json:"workload_name"`  // 工作负载名称
+	Source                   string     `gorm:"size:30" json:"source"`                         // 建议来源：export/change_request/manual
+	PodCount                 int        `json:"pod_count"`                                     // 生成建议时的Pod数量
+	CurrentCPURequest        int64      `json:"current_cpu_request"`                           // 生成建议时的CPU请求量 (millicores)
+	CurrentCPULimit          int64      `json:"current_cpu_limit"`                             // 生成建议时的CPU限制量 (millicores)
+	CurrentMemoryRequest     int64      `json:"current_memory_request"`                        // 生成建议时的内存请求量 (bytes)
+	CurrentMemoryLimit       int64      `json:"current_memory_limit"`                          // 生成建议时的内存限制量 (bytes)
+	RecommendedCPURequest    int64      `json:"recommended_cpu_request"`                       // 建议CPU请求量 (millicores)
+	RecommendedCPULimit      int64      `json:"recommended_cpu_limit"`                         // 建议CPU限制量 (millicores)
+	RecommendedMemoryRequest int64      `json:"recommended_memory_request"`                    // 建议内存请求量 (bytes)
+	RecommendedMemoryLimit   int64      `json:"recommended_memory_limit"`                      // 建议内存限制量 (bytes)
+	Status                   string     `gorm:"size:30;not null;index" json:"status"`          // 采纳状态：pending/adopted/partially_adopted/ignored/removed
+	ObservedCPURequest       int64      `json:"observed_cpu_request"`                          // 最近一次采集的CPU请求量
+	ObservedCPULimit         int64      `json:"observed_cpu_limit"`                            // 最近一次采集的CPU限制量
+	ObservedMemoryRequest    int64      `json:"observed_memory_request"`                       // 最近一次采集的内存请求量
+	ObservedMemoryLimit      int64      `json:"observed_memory_limit"`                         // 最近一次采集的内存限制量
+	ObservedPodCount         int        `json:"observed_pod_count"`                            // 最近一次采集的Pod数量
+	ChangedAt                *time.Time `json:"changed_at"`                                    // 首次检测到配置变化的时间
+	BaselineCPURequest       int64      `json:"baseline_cpu_request"`                          // 建议生成前最近一次采集的CPU请求量（采集值，含默认填充）
+	BaselineCPULimit         int64      `json:"baseline_cpu_limit"`                            // 建议生成前最近一次采集的CPU限制量
+	BaselineMemoryRequest    int64      `json:"baseline_memory_request"`                       // 建议生成前最近一次采集的内存请求量
+	BaselineMemoryLimit      int64      `json:"baseline_memory_limit"`                         // 建议生成前最近一次采集的内存限制量
+	BaselineRecordedAt       *time.Time `json:"baseline_recorded_at"`                          // 建议生成前的基准配置和稳定性的记录时间，记录后不再重新计算
+	RestartsBefore           int64      `json:"restarts_before"`                               // 建议生成前观察窗口内的重启次数
+	RestartsAfter            int64      `json:"restarts_after"`                                // 配置变化后观察窗口内的重启次数
+	OOMKillsBefore           int64      `json:"oom_kills_before"`                              // 建议生成前观察窗口内发生OOM的Pod数
+	OOMKillsAfter            int64      `json:"oom_kills_after"`                               // 配置变化后观察窗口内发生OOM的Pod数
+	EvaluatedAt              *time.Time `json:"evaluated_at"`                                  // 最近一次评估时间
+	FrozenAt                 *time.Time `gorm:"index" json:"frozen_at"`                        // 评估结束时间，配置变化后的稳定性观察窗口结束后不再重新评估
+	GeneratedAt              time.Time  `gorm:"index;not null" json:"generated_at"`            // 建议生成时间
+	CreatedAt                time.Time  `json:"created_at"`
+	UpdatedAt                time.Time  `json:"updated_at"`
+}
+
+// PolicyComplianceSnapshot 策略合规评分快照 - 每次采集后按集群和命名空间记录一次
+type PolicyComplianceSnapshot struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	ClusterID   uint      `gorm:"index:idx_policy_compliance,priority:1;not null" json:"cluster_id"` // 集群ID
+	Namespace   string    `gorm:"size:100;index:idx_policy_compliance,priority:2" json:"namespace"`  // 命名空间，为空表示集群整体
+	Checks      int       `json:"checks"`                                                            // 参与检查的对象次数
+	Passed      int       `json:"passed"`                                                            // 合规次数
+	Violations  int       `json:"violations"`                                                        // 违规次数
+	Errors      int       `json:"errors"`                                                            // 求值出错次数，不计入检查次数和评分
+	Score       float64   `json:"score"`                                                             // 按严重程度加权的合规评分 0-100
+	PolicyCount int       `json:"policy_count"`                                                      // 生效的策略数
+	CollectedAt time.Time `gorm:"index:idx_policy_compliance,priority:3" json:"collected_at"`        // 检查时间
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// PolicyViolation 策略违规记录 - 同一对象持续违规时只更新最近发现时间，恢复合规后记录解决时间
+type PolicyViolation struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	ClusterID   uint       `gorm:"index;not null" json:"cluster_id"`           // 集群ID
+	Namespace   string     `gorm:"size:100;not null;index" json:"namespace"`   // 命名空间
+	PolicyName  string     `gorm:"size:100;not null;index" json:"policy_name"` // 策略名称
+	Severity    string     `gorm:"size:20;not null" json:"severity"`           // 严重程度
+	TargetKind  string     `gorm:"size:20;not null" json:"target_kind"`        // 违规对象类型：container/pod/namespace
+	TargetName  string     `gorm:"size:512;not null" json:"target_name"`       // 违规对象名称，容器为 pod/container
+	Message     string     `gorm:"type:text" json:"message"`                   // 违规说明
+	FirstSeenAt time.Time  `json:"first_seen_at"`                              // 首次发现时间
+	LastSeenAt  time.Time  `gorm:"index" json:"last_seen_at"`                  // 最近发现时间
+	ResolvedAt  *time.Time `gorm:"index" json:"resolved_at"`                   // 恢复合规时间，为空表示仍在违规
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// MetricsRollup 监控数据降采样汇总的公共字段，按 Pod、工作负载、命名空间三个范围分别汇总
+// 工作负载和命名空间范围先对同一采集批次内的Pod求和，再在时间桶内统计
+type MetricsRollup struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	ClusterID         uint      `gorm:"index;not null" json:"cluster_id"`    // 集群ID
+	Scope             string    `gorm:"size:20;not null;index" json:"scope"` // 汇总范围：pod/workload/namespace
+	Namespace         string    `gorm:"size:100;not null" json:"namespace"`  // 命名空间
+	WorkloadKind      string    `gorm:"size:50" json:"workload_kind"`        // 工作负载类型，命名空间范围为空
+	WorkloadName      string    `gorm:"size:255" json:"workload_name"`       // 工作负载名称，命名空间范围为空
+	PodName           string    `gorm:"size:255" json:"pod_name"`            // Pod名称，仅Pod范围有值
+	BucketStart       time.Time `gorm:"index;not null" json:"bucket_start"`  // 时间桶起点
+	SampleCount       int       `json:"sample_count"`                        // 时间桶内的采样次数
+	CPUSampleCount    int       `json:"cpu_sample_count"`                    // 有真实CPU使用量的采样次数，CPU使用量统计只基于这些采样
+	MemorySampleCount int       `json:"memory_sample_count"`                 // 有真实内存使用量的采样次数，内存使用量统计只基于这些采样
+	PodCount          float64   `json:"pod_count"`                           // 平均Pod数
+	CPUUsageAvg       float64   `json:"cpu_usage_avg"`                       // CPU使用量平均值 (millicores)
+	CPUUsageP95       float64   `json:"cpu_usage_p95"`                       // CPU使用量P95
+	CPUUsageMax       float64   `json:"cpu_usage_max"`                       // CPU使用量最大值
+	CPUUsageMin       float64   `json:"cpu_usage_min"`                       // CPU使用量最小值
+	MemoryUsageAvg    float64   `json:"memory_usage_avg"`                    // 内存使用量平均值 (bytes)
+	MemoryUsageP95    float64   `json:"memory_usage_p95"`                    // 内存使用量P95
+	MemoryUsageMax    float64   `json:"memory_usage_max"`                    // 内存使用量最大值
+	MemoryUsageMin    float64   `json:"memory_usage_min"`                    // 内存使用量最小值
+	CPURequest        float64   `json:"cpu_request"`                         // CPU请求量平均值
+	CPULimit          float64   `json:"cpu_limit"`                           // CPU限制量平均值
+	MemoryRequest     float64   `json:"memory_request"`                      // 内存请求量平均值
+	MemoryLimit       float64   `json:"memory_limit"`                        // 内存限制量平均值
+	CPUReqPct         float64   `json:"cpu_req_pct"`                         // CPU请求利用率平均值
+	MemoryReqPct      float64   `json:"memory_req_pct"`                      // 内存请求利用率平均值
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// PodMetricsHourly 按小时汇总的监控数据
+type PodMetricsHourly struct {
+	MetricsRollup
+}
+
+// PodMetricsDaily 按天汇总的监控数据
+type PodMetricsDaily struct {
+	MetricsRollup
+}
+
+// PodDimension Pod维度表模型 - 保存Pod身份、所属工作负载和标签，每个Pod一行
+type PodDimension struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	ClusterID    uint      `gorm:"not null;uniqueIndex:idx_pod_dimensions_identity,priority:1" json:"cluster_id"`  // 集群ID
+	Namespace    string    `gorm:"size:100;not null;uniqueIndex:idx_pod_dimensions_identity,priority:2" json:"namespace"` // 命名空间
+	PodName      string    `gorm:"size:255;not null;uniqueIndex:idx_pod_dimensions_identity,priority:3" json:"pod_name"`  // Pod名称
+	WorkloadKind string    `gorm:"size:50" json:"workload_kind"`          // 所属工作负载类型
+	WorkloadName string    `gorm:"size:255;index" json:"workload_name"`   // 所属工作负载名称
+	Labels       JSONText  `json:"labels"`                                // Pod标签（JSON对象）
+	FirstSeenAt  time.Time `json:"first_seen_at"`                         // 首次采集时间
+	LastSeenAt   time.Time `gorm:"index" json:"last_seen_at"`             // 最近采集时间，用于清理已消失的Pod
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// PodSpecVersion Pod规格版本表模型 - 节点、QoS、请求和限制发生变化时新增一个版本
+type PodSpecVersion struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	PodID         uint       `gorm:"not null;index:idx_pod_spec_versions_open,priority:1" json:"pod_id"` // Pod维度ID
+	NodeName      string     `gorm:"size:100" json:"node_name"`      // 节点名称
+	QoSClass      string     `gorm:"column:qos_class;size:20" json:"qos_class"` // 服务质量等级
+	MemoryRequest int64      `json:"memory_request"`                 // 内存请求量（字节）
+	MemoryLimit   int64      `json:"memory_limit"`                   // 内存限制量（字节）
+	CPURequest    int64      `json:"cpu_request"`                    // CPU请求量（millicores）
+	CPULimit      int64      `json:"cpu_limit"`                      // CPU限制量（millicores）
+	ValidFrom     time.Time  `gorm:"not null" json:"valid_from"`     // 生效时间
+	ValidTo       *time.Time `gorm:"index:idx_pod_spec_versions_open,priority:2" json:"valid_to"` // 失效时间，为空表示当前版本
+}
+
+// PodMetricSample Pod指标事实表模型 - 每次采集每个Pod一行，只保存使用量和状态
+// 利用率百分比由视图根据规格版本中的请求和限制计算
+type PodMetricSample struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	PodID            uint      `gorm:"not null;index:idx_pod_metric_samples_pod_time,priority:1" json:"pod_id"` // Pod维度ID
+	SpecVersionID    uint      `gorm:"not null;index" json:"spec_version_id"`                                  // 采集时生效的规格版本ID
+	MemoryUsage      int64     `json:"memory_usage"`                                   // 内存实际使用量（字节）
+	CPUUsage         int64     `json:"cpu_usage"`                                      // CPU实际使用量（millicores）
+	Status           string    `gorm:"size:20;default:'reasonable'" json:"status"`     // 状态：reasonable/unreasonable
+	Issues           JSONText  `json:"issues"`                                         // 问题描述（JSON数组），没有问题时为空
+	MetricsAvailable bool      `json:"metrics_available"`                              // CPU和内存使用量是否都来自真实metrics数据，不设默认值以便写入false
+	CPUMetricsAvailable    bool `json:"cpu_metrics_available"`                          // CPU使用量是否来自真实数据，否则 cpu_usage 为0
+	MemoryMetricsAvailable bool `json:"memory_metrics_available"`                       // 内存使用量是否来自真实数据，否则 memory_usage 为0
+	RestartCount     int32     `gorm:"default:0" json:"restart_count"`                 // 各容器累计重启次数之和
+	OOMKilled        bool      `gorm:"column:oom_killed;default:false" json:"oom_killed"` // 是否有容器最近一次因OOM被终止
+	CollectedAt      time.Time `gorm:"index;index:idx_pod_metric_samples_pod_time,priority:2" json:"collected_at"` // 采集时间
+}
+
+// CollectionRun 采集批次表模型 - 每个集群每次写入历史数据记录一行，用于按批次对比采集快照
+type CollectionRun struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	ClusterID   uint      `gorm:"not null;index:idx_collection_runs_cluster_time,priority:1" json:"cluster_id"` // 集群ID
+	PodCount    int       `json:"pod_count"`                                                                    // 本批次采集的Pod数量
+	CollectedAt time.Time `gorm:"not null;index:idx_collection_runs_cluster_time,priority:2" json:"collected_at"` // 采集时间，与本批次历史样本的采集时间一致
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// WorkloadSpecState 工作负载资源配置基线 - 每个工作负载一行，保存最近一次采集到的Pod模板容器资源配置，用于检测配置变化
+type WorkloadSpecState struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	ClusterID    uint      `gorm:"not null;uniqueIndex:idx_workload_spec_states_identity,priority:1" json:"cluster_id"`         // 集群ID
+	Namespace    string    `gorm:"size:100;not null;uniqueIndex:idx_workload_spec_states_identity,priority:2" json:"namespace"` // 命名空间
+	WorkloadKind string    `gorm:"size:50;not null;uniqueIndex:idx_workload_spec_states_identity,priority:3" json:"workload_kind"` // 工作负载类型：Deployment/StatefulSet/DaemonSet
+	WorkloadName string    `gorm:"size:255;not null;uniqueIndex:idx_workload_spec_states_identity,priority:4" json:"workload_name"` // 工作负载名称
+	Containers   string    `gorm:"type:text" json:"containers"`   // 容器资源配置（JSON数组）
+	Revision     string    `gorm:"size:64" json:"revision"`       // 发布版本
+	ObservedAt   time.Time `json:"observed_at"`                   // 最近一次配置变化或首次采集的时间
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ResourceConfigChange 资源配置变更记录 - 工作负载容器的请求或限制发生变化时，每个变化的容器记录一行
+type ResourceConfigChange struct {
+	ID               uint       `gorm:"primaryKey" json:"id"`
+	ClusterID        uint       `gorm:"index;not null" json:"cluster_id"`           // 集群ID
+	Namespace        string     `gorm:"size:100;not null;index" json:"namespace"`   // 命名空间
+	WorkloadKind     string     `gorm:"size:50;not null" json:"workload_kind"`      // 工作负载类型
+	WorkloadName     string     `gorm:"size:255;not null;index" json:"workload_name"` // 工作负载名称
+	ContainerName    string     `gorm:"size:255;not null" json:"container_name"`    // 容器名称
+	ChangeType       string     `gorm:"size:20;not null" json:"change_type"`        // 变化类型：modified/added/removed
+	OldCPURequest    int64      `json:"old_cpu_request"`                            // 变更前CPU请求量 (millicores)
+	NewCPURequest    int64      `json:"new_cpu_request"`                            // 变更后CPU请求量 (millicores)
+	OldCPULimit      int64      `json:"old_cpu_limit"`                              // 变更前CPU限制量 (millicores)
+	NewCPULimit      int64      `json:"new_cpu_limit"`                              // 变更后CPU限制量 (millicores)
+	OldMemoryRequest int64      `json:"old_memory_request"`                         // 变更前内存请求量 (bytes)
+	NewMemoryRequest int64      `json:"new_memory_request"`                         // 变更后内存请求量 (bytes)
+	OldMemoryLimit   int64      `json:"old_memory_limit"`                           // 变更前内存限制量 (bytes)
+	NewMemoryLimit   int64      `json:"new_memory_limit"`                           // 变更后内存限制量 (bytes)
+	Manager          string     `gorm:"size:255" json:"manager"`                    // 推断的修改者：managedFields 中拥有本次变化字段且最近写入的管理者，无法推断时为空
+	Operation        string     `gorm:"size:20" json:"operation"`                   // 推断的修改者的操作类型：Apply/Update
+	PreviousRevision string     `gorm:"size:64" json:"previous_revision"`           // 变更前的发布版本
+	Revision         string     `gorm:"size:64" json:"revision"`                    // 变更后的发布版本
+	ChangedAt        *time.Time `json:"changed_at"`                                 // 推断的修改者最近一次写入的时间（managedFields），不一定是本次变化的时间，无法推断时为空
+	DetectedAt       time.Time  `gorm:"index;not null" json:"detected_at"`          // 检测到变化的采集时间
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
 // TableName 指定表名
 func (ClusterConfig) TableName() string {
 	return "cluster_configs"
@@ -146,4 +440,64 @@ func (AlertHistory) TableName() string {
 
 func (SystemActivity) TableName() string {
 	return "system_activities"
-}
\ No newline at end of file
+}
+
+func (ClusterCapacitySnapshot) TableName() string {
+	return "cluster_capacity_snapshots"
+}
+
+func (ResourceQuotaSnapshot) TableName() string {
+	return "resource_quota_snapshots"
+}
+
+func (ResourceAnomaly) TableName() string {
+	return "resource_anomalies"
+}
+
+func (ResourceChangeRequest) TableName() string {
+	return "resource_change_requests"
+}
+
+func (RecommendationSnapshot) TableName() string {
+	return "recommendation_snapshots"
+}
+
+func (PolicyComplianceSnapshot) TableName() string {
+	return "policy_compliance_snapshots"
+}
+
+func (PolicyViolation) TableName() string {
+	return "policy_violations"
+}
+
+func (PodMetricsHourly) TableName() string {
+	return "pod_metrics_hourly"
+}
+
+func (PodMetricsDaily) TableName() string {
+	return "pod_metrics_daily"
+}
+
+func (PodDimension) TableName() string {
+	return "pod_dimensions"
+}
+
+func (PodSpecVersion) TableName() string {
+	return "pod_spec_versions"
+}
+
+func (PodMetricSample) TableName() string {
+	return "pod_metric_samples"
+}
+
+func (CollectionRun) TableName() string {
+	return "collection_runs"
+}
+
+func (WorkloadSpecState) TableName() string {
+	return "workload_spec_states"
+}
+
+func (ResourceConfigChange) TableName() string {
+	return "resource_config_changes"
+}
@@ -0,0 +1,137 @@
+package remotewrite
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"cluster-resource-insight/internal/database"
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/models"
+
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// 推送的指标名称，与 /metrics 端点的 Pod 级指标保持一致：CPU单位为核，内存单位为字节
+const (
+	metricCPUUsage         = "resource_insight_pod_cpu_usage_cores"
+	metricCPURequest       = "resource_insight_pod_cpu_request_cores"
+	metricCPULimit         = "resource_insight_pod_cpu_limit_cores"
+	metricMemoryUsage      = "resource_insight_pod_memory_usage_bytes"
+	metricMemoryRequest    = "resource_insight_pod_memory_request_bytes"
+	metricMemoryLimit      = "resource_insight_pod_memory_limit_bytes"
+	metricRestarts         = "resource_insight_pod_restarts"
+	metricOOMKilled        = "resource_insight_pod_oom_killed"
+	metricMetricsAvailable = "resource_insight_pod_metrics_available"
+)
+
+// seriesBuilder 将采集记录转换为 remote write 时间序列
+type seriesBuilder struct {
+	externalLabels []prompb.Label
+
+	mutex        sync.Mutex
+	clusterNames map[uint]string
+}
+
+// newSeriesBuilder 创建序列转换器，外部标签附加到所有序列
+func newSeriesBuilder(externalLabels map[string]string) *seriesBuilder {
+	builder := &seriesBuilder{clusterNames: make(map[uint]string)}
+	for name, value := range externalLabels {
+		if name != "" && value != "" {
+			builder.externalLabels = append(builder.externalLabels, prompb.Label{Name: name, Value: value})
+		}
+	}
+	return builder
+}
+
+// clusterName 返回集群名称，缓存未命中时从数据库读取，读取失败时使用集群ID
+func (b *seriesBuilder) clusterName(clusterID uint) string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if name, ok := b.clusterNames[clusterID]; ok {
+		return name
+	}
+
+	var cluster models.ClusterConfig
+	if err := database.GetDB().Select("id, cluster_name").First(&cluster, clusterID).Error; err != nil {
+		logger.Warn("remote write 查询集群 %d 名称失败，使用集群ID作为标签: %v", clusterID, err)
+		return fmt.Sprintf("%d", clusterID)
+	}
+	b.clusterNames[clusterID] = cluster.ClusterName
+	return cluster.ClusterName
+}
+
+// labels 构造一条序列的标签，空值标签不写入，按名称排序
+func (b *seriesBuilder) labels(metric string, record *models.PodMetricsHistory) []prompb.Label {
+	labels := []prompb.Label{{Name: "__name__", Value: metric}}
+	for _, label := range []prompb.Label{
+		{Name: "cluster", Value: b.clusterName(record.ClusterID)},
+		{Name: "namespace", Value: record.Namespace},
+		{Name: "pod", Value: record.PodName},
+		{Name: "workload_kind", Value: record.WorkloadKind},
+		{Name: "workload", Value: record.WorkloadName},
+		{Name: "node", Value: record.NodeName},
+		{Name: "qos_class", Value: record.QoSClass},
+	} {
+		if label.Value != "" {
+			labels = append(labels, label)
+		}
+	}
+
+	// 外部标签与序列标签同名时以序列标签为准
+	for _, external := range b.externalLabels {
+		duplicate := false
+		for _, label := range labels {
+			if label.Name == external.Name {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			labels = append(labels, external)
+		}
+	}
+
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+	return labels
+}
+
+// build 将一次采集的记录转换为时间序列，样本时间为实际采集时间
+// 使用量仅在来自真实metrics数据时推送，避免估算值进入远端存储
+func (b *seriesBuilder) build(records []models.PodMetricsHistory) []prompb.TimeSeries {
+	var timeseries []prompb.TimeSeries
+	for i := range records {
+		record := &records[i]
+		ts := record.CollectedAt.UnixMilli()
+		add := func(metric string, value float64) {
+			timeseries = append(timeseries, prompb.TimeSeries{
+				Labels:  b.labels(metric, record),
+				Samples: []prompb.Sample{{Value: value, Timestamp: ts}},
+			})
+		}
+
+		add(metricCPURequest, float64(record.CPURequest)/1000)
+		add(metricCPULimit, float64(record.CPULimit)/1000)
+		add(metricMemoryRequest, float64(record.MemoryRequest))
+		add(metricMemoryLimit, float64(record.MemoryLimit))
+		add(metricRestarts, float64(record.RestartCount))
+		add(metricOOMKilled, boolValue(record.OOMKilled))
+		add(metricMetricsAvailable, boolValue(record.MetricsAvailable))
+		if record.CPUMetricsAvailable {
+			add(metricCPUUsage, float64(record.CPUUsage)/1000)
+		}
+		if record.MemoryMetricsAvailable {
+			add(metricMemoryUsage, float64(record.MemoryUsage))
+		}
+	}
+	return timeseries
+}
+
+// boolValue 布尔值转换为样本值
+func boolValue(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
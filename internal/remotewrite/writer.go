@@ -0,0 +1,301 @@
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/models"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// 默认参数，配置文件未设置时使用
+const (
+	defaultTimeout              = 30 * time.Second
+	defaultQueueCapacity        = 100
+	defaultMaxSamplesPerRequest = 5000
+	defaultMaxRetries           = 5
+	defaultMinBackoff           = 500 * time.Millisecond
+	defaultMaxBackoff           = 30 * time.Second
+
+	// closeTimeout 关闭时等待队列中剩余批次发送完成的最长时间
+	closeTimeout = 10 * time.Second
+)
+
+// Config remote write 推送配置
+type Config struct {
+	URL                  string
+	Timeout              time.Duration
+	QueueCapacity        int
+	MaxSamplesPerRequest int
+	MaxRetries           int
+	MinBackoff           time.Duration
+	MaxBackoff           time.Duration
+	BasicAuthUsername    string
+	BasicAuthPassword    string
+	BearerToken          string
+	Headers              map[string]string
+	ExternalLabels       map[string]string
+}
+
+// withDefaults 为未设置的参数填充默认值
+func (c Config) withDefaults() Config {
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+	if c.QueueCapacity <= 0 {
+		c.QueueCapacity = defaultQueueCapacity
+	}
+	if c.MaxSamplesPerRequest <= 0 {
+		c.MaxSamplesPerRequest = defaultMaxSamplesPerRequest
+	}
+	if c.MaxRetries <= 0 {
+		c.MaxRetries = defaultMaxRetries
+	}
+	if c.MinBackoff <= 0 {
+		c.MinBackoff = defaultMinBackoff
+	}
+	if c.MaxBackoff < c.MinBackoff {
+		c.MaxBackoff = defaultMaxBackoff
+		if c.MaxBackoff < c.MinBackoff {
+			c.MaxBackoff = c.MinBackoff
+		}
+	}
+	return c
+}
+
+// Writer remote write 推送器 - 采集批次进入有界队列，由单个后台协程按顺序转换并推送，保证同一序列的样本时间递增
+type Writer struct {
+	config Config
+	client *http.Client
+	series *seriesBuilder
+
+	queue  chan []models.PodMetricsHistory
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mutex  sync.Mutex
+	closed bool
+}
+
+// NewWriter 创建推送器并启动后台发送协程
+func NewWriter(config Config) (*Writer, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("remote write 地址不能为空")
+	}
+	config = config.withDefaults()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &Writer{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+		series: newSeriesBuilder(config.ExternalLabels),
+		queue:  make(chan []models.PodMetricsHistory, config.QueueCapacity),
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go w.run()
+	return w, nil
+}
+
+// Enqueue 将一次采集的样本加入发送队列，队列已满时丢弃该批次并返回false，不阻塞采集流程
+func (w *Writer) Enqueue(records []models.PodMetricsHistory) bool {
+	if len(records) == 0 {
+		return true
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.closed {
+		return false
+	}
+
+	select {
+	case w.queue <- records:
+		return true
+	default:
+		logger.Warn("remote write 队列已满（%d 个批次），丢弃本次采集的 %d 条样本", w.config.QueueCapacity, len(records))
+		return false
+	}
+}
+
+// Close 停止接收新批次，等待队列中剩余批次发送完成，超时后放弃未发送的数据
+func (w *Writer) Close() {
+	w.mutex.Lock()
+	if w.closed {
+		w.mutex.Unlock()
+		return
+	}
+	w.closed = true
+	close(w.queue)
+	w.mutex.Unlock()
+
+	select {
+	case <-w.done:
+	case <-time.After(closeTimeout):
+		logger.Warn("remote write 关闭超时，放弃队列中未发送的 %d 个批次", len(w.queue))
+		w.cancel()
+		<-w.done
+	}
+	w.cancel()
+}
+
+// run 后台发送协程
+func (w *Writer) run() {
+	defer close(w.done)
+	for records := range w.queue {
+		if w.ctx.Err() != nil {
+			continue
+		}
+		w.sendBatch(records)
+	}
+}
+
+// sendBatch 将一次采集的样本转换为时间序列，按单次请求样本数上限拆分后发送
+func (w *Writer) sendBatch(records []models.PodMetricsHistory) {
+	timeseries := w.series.build(records)
+	var request []prompb.TimeSeries
+	samples := 0
+	for _, ts := range timeseries {
+		request = append(request, ts)
+		samples += len(ts.Samples)
+		if samples >= w.config.MaxSamplesPerRequest {
+			w.sendWithRetry(request, samples)
+			request, samples = nil, 0
+		}
+	}
+	if len(request) > 0 {
+		w.sendWithRetry(request, samples)
+	}
+}
+
+// sendWithRetry 发送一个请求，网络错误、5xx 和 429 按指数退避重试，其他错误直接丢弃
+func (w *Writer) sendWithRetry(timeseries []prompb.TimeSeries, samples int) {
+	body, err := encodeRequest(timeseries)
+	if err != nil {
+		logger.Error("remote write 请求编码失败，丢弃 %d 条样本: %v", samples, err)
+		return
+	}
+
+	backoff := w.config.MinBackoff
+	for attempt := 0; ; attempt++ {
+		retryable, err := w.send(body)
+		if err == nil {
+			return
+		}
+		if !retryable || attempt >= w.config.MaxRetries {
+			logger.Error("remote write 推送失败（已尝试 %d 次），丢弃 %d 条样本: %v", attempt+1, samples, err)
+			return
+		}
+
+		logger.Warn("remote write 推送失败，%v 后重试: %v", backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-w.ctx.Done():
+			return
+		}
+		backoff *= 2
+		if backoff > w.config.MaxBackoff {
+			backoff = w.config.MaxBackoff
+		}
+	}
+}
+
+// encodeRequest 将时间序列编码为 snappy 压缩的 protobuf 请求体
+func encodeRequest(timeseries []prompb.TimeSeries) ([]byte, error) {
+	request := &prompb.WriteRequest{Timeseries: timeseries}
+	data, err := request.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return snappy.Encode(nil, data), nil
+}
+
+// send 发送一次请求，返回错误是否可重试
+func (w *Writer) send(body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(w.ctx, http.MethodPost, w.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("User-Agent", "cluster-resource-insight")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	for name, value := range w.config.Headers {
+		req.Header.Set(name, value)
+	}
+	if w.config.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+w.config.BearerToken)
+	} else if w.config.BasicAuthUsername != "" {
+		req.SetBasicAuth(w.config.BasicAuthUsername, w.config.BasicAuthPassword)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return w.ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 == 2 {
+		io.Copy(io.Discard, resp.Body)
+		return false, nil
+	}
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("远端返回 %s: %s", resp.Status, bytes.TrimSpace(message))
+	return resp.StatusCode/100 == 5 || resp.StatusCode == http.StatusTooManyRequests, err
+}
+
+var (
+	defaultWriter *Writer
+	writerMutex   sync.RWMutex
+)
+
+// InitRemoteWriter 初始化全局推送器，config 为nil时不推送
+func InitRemoteWriter(config *Config) error {
+	if config == nil {
+		return nil
+	}
+	writer, err := NewWriter(*config)
+	if err != nil {
+		return err
+	}
+
+	writerMutex.Lock()
+	defaultWriter = writer
+	writerMutex.Unlock()
+
+	logger.Info("已启用 Prometheus remote write 推送: %s", config.URL)
+	return nil
+}
+
+// Enqueue 将一次采集的样本加入全局推送器的发送队列，未启用推送时忽略
+func Enqueue(records []models.PodMetricsHistory) {
+	writerMutex.RLock()
+	writer := defaultWriter
+	writerMutex.RUnlock()
+
+	if writer != nil {
+		writer.Enqueue(records)
+	}
+}
+
+// CloseRemoteWriter 关闭全局推送器
+func CloseRemoteWriter() {
+	writerMutex.Lock()
+	writer := defaultWriter
+	defaultWriter = nil
+	writerMutex.Unlock()
+
+	if writer != nil {
+		writer.Close()
+	}
+}
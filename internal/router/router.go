@@ -9,7 +9,7 @@ import (
 )
 
 // SetupRoutes 设置所有API路由 - 将路由配置从handlers.go中分离出来
-func SetupRoutes(r *gin.RouterGroup, resourceCollector *collector.ResourceCollector) {
+func SetupRoutes(r *gin.RouterGroup, resourceCollector *collector.ResourceCollector, scheduleService *service.ScheduleService) {
 	// 直接复制原handlers.go中的路由配置逻辑，但调用api包中的处理器函数
 
 	// 原有的分析接口
@@ -28,6 +28,85 @@ func SetupRoutes(r *gin.RouterGroup, resourceCollector *collector.ResourceCollec
 		statisticsGroup.GET("/top-cpu-request", api.GetTopCPURequestPods(multiCollector))
 		statisticsGroup.GET("/top-resource-namespaces", api.GetTopResourceNamespaces(multiCollector))
 		statisticsGroup.GET("/resource-distribution", api.GetResourceDistribution(multiCollector)) // 新增资源分布统计接口
+		statisticsGroup.GET("/node-overcommit", api.GetNodeOvercommit(multiCollector))
+		statisticsGroup.GET("/qos-distribution", api.GetQoSDistribution(multiCollector))
+	}
+
+	// 成本分摊接口
+	costGroup := r.Group("/cost")
+	{
+		costGroup.GET("/allocation", api.GetCostAllocation(multiCollector))
+	}
+
+	// 闲置资源扫描接口
+	idleGroup := r.Group("/idle")
+	{
+		idleGroup.GET("/report", api.GetIdleResourceReport(multiCollector))
+	}
+
+	// 跨集群配置漂移接口
+	driftGroup := r.Group("/drift")
+	{
+		driftGroup.GET("/workloads", api.GetWorkloadDrift(multiCollector))
+	}
+
+	// 装箱模拟接口
+	simulationGroup := r.Group("/simulation")
+	{
+		simulationGroup.POST("/bin-packing", api.SimulateBinPacking(multiCollector))
+	}
+
+	// 资源建议导出接口
+	recommendationsGroup := r.Group("/recommendations")
+	{
+		recommendationsGroup.POST("/export", api.ExportRecommendations(multiCollector))
+	}
+
+	// 资源建议采纳跟踪接口
+	adoptionService := service.NewAdoptionService()
+	adoptionGroup := r.Group("/adoption")
+	{
+		adoptionGroup.POST("/snapshots", api.CreateRecommendationSnapshots(multiCollector))
+		adoptionGroup.GET("/report", api.GetAdoptionReport(adoptionService))
+	}
+
+	// 问题严重程度排名接口
+	rankingGroup := r.Group("/ranking")
+	{
+		rankingGroup.GET("/problems", api.GetProblemRanking(multiCollector))
+		rankingGroup.GET("/factors", api.GetRankingFactors())
+	}
+
+	// 资源策略合规接口
+	policyService := service.NewPolicyService()
+	policiesGroup := r.Group("/policies")
+	{
+		policiesGroup.GET("", api.GetPolicies())
+		policiesGroup.GET("/compliance", api.GetPolicyCompliance(policyService))
+		policiesGroup.GET("/compliance/history", api.GetPolicyComplianceHistory(policyService))
+		policiesGroup.GET("/violations", api.GetPolicyViolations(policyService))
+	}
+
+	// 资源调整变更单路由（需在配置中开启 apply.enabled）
+	changeService := service.NewChangeService()
+	changesGroup := r.Group("/changes")
+	{
+		changesGroup.GET("", api.ListChanges(changeService))
+		changesGroup.POST("", api.ProposeChange(changeService, multiCollector))
+		changesGroup.GET("/:id", api.GetChange(changeService))
+		changesGroup.POST("/:id/approve", api.ApproveChange(changeService))
+		changesGroup.POST("/:id/reject", api.RejectChange(changeService))
+		changesGroup.POST("/:id/rollback", api.RollbackChange(changeService))
+	}
+
+	// 资源配置变更记录接口
+	configChangeService := service.NewConfigChangeService()
+	configChangesGroup := r.Group("/config-changes")
+	{
+		configChangesGroup.GET("", api.ListConfigChanges(configChangeService))
+		configChangesGroup.GET("/namespaces/:namespace", api.ListConfigChanges(configChangeService))
+		configChangesGroup.GET("/workloads/:namespace/:kind/:name", api.ListConfigChanges(configChangeService))
+		configChangesGroup.GET("/pods/:namespace/:pod", api.ListConfigChanges(configChangeService))
 	}
 
 	// 新增的命名空间相关接口
@@ -61,10 +140,40 @@ func SetupRoutes(r *gin.RouterGroup, resourceCollector *collector.ResourceCollec
 		historyGroup.GET("/statistics", api.GetHistoryStatistics(historyService))
 		historyGroup.POST("/collect", api.TriggerDataCollection(multiCollector))
 		historyGroup.DELETE("/cleanup", api.CleanupOldData(historyService))
+		historyGroup.GET("/rollups", api.GetRollupHistory(service.NewRollupService()))
+		historyGroup.POST("/aggregate", api.QueryHistoryAggregation(service.NewAggregationService()))
+
+		// 采集快照对比
+		snapshotDiffService := service.NewSnapshotDiffService()
+		historyGroup.GET("/runs", api.GetCollectionRuns(snapshotDiffService))
+		historyGroup.GET("/diff", api.GetSnapshotDiff(snapshotDiffService))
+	}
+
+	// 容量预测接口
+	forecastService := service.NewForecastService()
+	forecastGroup := r.Group("/forecast")
+	{
+		forecastGroup.GET("/cluster", api.GetClusterForecast(forecastService))
+		forecastGroup.GET("/namespace", api.GetNamespaceForecast(forecastService))
+		forecastGroup.GET("/quotas", api.GetQuotaForecasts(forecastService))
+	}
+
+	// 使用量异常检测接口
+	anomalyService := service.NewAnomalyService()
+	anomaliesGroup := r.Group("/anomalies")
+	{
+		anomaliesGroup.GET("", api.GetAnomalies(anomalyService))
+	}
+
+	// 分时段使用画像接口
+	usageProfileService := service.NewUsageProfileService()
+	usageProfilesGroup := r.Group("/usage-profiles")
+	{
+		usageProfilesGroup.GET("/heatmap", api.GetUsageHeatmap(usageProfileService))
+		usageProfilesGroup.GET("/scaling-recommendations", api.GetScaleScheduleRecommendations(usageProfileService))
 	}
 
-	// 新增的调度管理接口
-	scheduleService := service.NewScheduleService()
+	// 新增的调度管理接口，调度服务由服务器持有，关闭时随之停止
 	scheduleGroup := r.Group("/schedule")
 	{
 		scheduleGroup.GET("/status", api.GetScheduleStatus(scheduleService))
@@ -1,16 +1,20 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 
+	"cluster-resource-insight/internal/api"
 	"cluster-resource-insight/internal/collector"
 	"cluster-resource-insight/internal/config"
 	"cluster-resource-insight/internal/logger"
 	"cluster-resource-insight/internal/middleware"
 	"cluster-resource-insight/internal/response"
 	"cluster-resource-insight/internal/router"
+	"cluster-resource-insight/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,6 +24,8 @@ type Server struct {
 	engine   *gin.Engine
 	config   *config.AppConfig
 	collector *collector.ResourceCollector
+	httpServer *http.Server
+	scheduleService *service.ScheduleService
 }
 
 // New 创建新的服务器实例
@@ -27,6 +33,7 @@ func New(appConfig *config.AppConfig, resourceCollector *collector.ResourceColle
 	return &Server{
 		config:    appConfig,
 		collector: resourceCollector,
+		scheduleService: service.NewScheduleService(),
 	}
 }
 
@@ -48,17 +55,27 @@ func (s *Server) Setup() {
 	
 	// 设置静态文件服务
 	s.setupStaticFiles()
+
+	s.httpServer = &http.Server{
+		Addr:    fmt.Sprintf(":%d", s.config.App.Port),
+		Handler: s.engine,
+	}
 }
 
 // setupRoutes 设置API路由
 func (s *Server) setupRoutes() {
 	// API 路由 - 先设置API路由，避免被静态文件路由覆盖
 	apiGroup := s.engine.Group("/api")
-	router.SetupRoutes(apiGroup, s.collector)
+	router.SetupRoutes(apiGroup, s.collector, s.scheduleService)
 
 	// 添加v1兼容路由
 	v1ApiGroup := s.engine.Group("/api/v1")
-	router.SetupRoutes(v1ApiGroup, s.collector)
+	router.SetupRoutes(v1ApiGroup, s.collector, s.scheduleService)
+
+	// Prometheus 指标端点
+	if service.MetricsExporterEnabled() {
+		s.engine.GET("/metrics", api.ServeMetrics(service.NewMetricsExporter()))
+	}
 }
 
 // setupStaticFiles 设置静态文件服务
@@ -94,14 +111,34 @@ func (s *Server) setupStaticFiles() {
 	}
 }
 
-// Start 启动服务器
+// Start 启动服务器，阻塞直到服务器停止，调用 Shutdown 正常停止时返回nil
 func (s *Server) Start() error {
-	addr := fmt.Sprintf(":%d", s.config.App.Port)
 	logger.Info("服务器启动在端口 %d", s.config.App.Port)
 	logger.Info("访问地址:")
 	logger.Info("  - 资源监控: http://localhost:%d", s.config.App.Port)
 	logger.Info("  - 集群管理: http://localhost:%d/clusters", s.config.App.Port)
 	logger.Info("  - API文档: http://localhost:%d/api/v1/health", s.config.App.Port)
 
-	return s.engine.Run(addr)
+	if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown 停止接收新连接并等待处理中的请求完成，超过 ctx 期限时返回错误；
+// 随后停止调度服务和健康观察并等待正在执行的任务结束，确保调用方关闭存储前不再有后台写入
+func (s *Server) Shutdown(ctx context.Context) error {
+	var err error
+	if s.httpServer != nil {
+		err = s.httpServer.Shutdown(ctx)
+	}
+
+	if s.scheduleService.IsRunning() {
+		if stopErr := s.scheduleService.Stop(); stopErr != nil {
+			logger.Error("停止调度服务失败: %v", stopErr)
+		}
+	}
+	service.StopHealthWatches()
+
+	return err
 }
\ No newline at end of file
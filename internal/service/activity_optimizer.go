@@ -83,7 +83,7 @@ func (o *ActivityOptimizer) LoadOptimizationConfig() (*OptimizationConfig, error
 	}
 
 	var settings []models.SystemSettings
-	err := o.db.Where("`key` IN ?", configKeys).Find(&settings).Error
+	err := o.db.Where(map[string]interface{}{"key": configKeys}).Find(&settings).Error
 	if err != nil {
 		logger.Warn("加载活动优化配置失败，使用默认配置: %v", err)
 		return config, nil
@@ -163,7 +163,7 @@ func (o *ActivityOptimizer) SaveOptimizationConfig(config *OptimizationConfig) e
 			ValueType: valueType,
 		}
 
-		err := o.db.Where("`key` = ?", key).
+		err := o.db.Where(&models.SystemSettings{Key: key}).
 			Assign(setting).
 			FirstOrCreate(&setting).Error
 		if err != nil {
@@ -342,8 +342,8 @@ func (o *ActivityOptimizer) createAggregatedActivities(config *OptimizationConfi
 		Type      string
 		Title     string
 		Count     int64
-		FirstTime time.Time
-		LastTime  time.Time
+		FirstTime database.Timestamp
+		LastTime  database.Timestamp
 	}
 
 	err := o.db.Model(&models.SystemActivity{}).
@@ -380,8 +380,8 @@ func (o *ActivityOptimizer) createAggregatedActivities(config *OptimizationConfi
 			Type:       candidate.Type,
 			Title:      candidate.Title,
 			Count:      int(candidate.Count),
-			FirstTime:  candidate.FirstTime,
-			LastTime:   candidate.LastTime,
+			FirstTime:  candidate.FirstTime.Time,
+			LastTime:   candidate.LastTime.Time,
 			ClusterIDs: clusterIDs,
 			Sources:    sources,
 		}
@@ -424,7 +424,7 @@ func (o *ActivityOptimizer) createAggregationRecord(agg ActivityAggregation) err
 		Title:     fmt.Sprintf("[聚合] %s", agg.Title),
 		Message:   message,
 		Source:    "aggregator",
-		Details:   string(detailsJSON),
+		Details:   models.JSONText(detailsJSON),
 		CreatedAt: time.Now(),
 	}
 
@@ -435,6 +435,10 @@ func (o *ActivityOptimizer) createAggregationRecord(agg ActivityAggregation) err
 func (o *ActivityOptimizer) cleanupExpiredActivities(retentionDays int) error {
 	cutoffTime := time.Now().AddDate(0, 0, -retentionDays)
 
+	if _, err := database.DropExpiredPartitions(models.SystemActivity{}.TableName(), cutoffTime); err != nil {
+		return fmt.Errorf("清理过期活动分区失败: %w", err)
+	}
+
 	result := o.db.Where("created_at < ?", cutoffTime).Delete(&models.SystemActivity{})
 	if result.Error != nil {
 		return fmt.Errorf("清理过期活动失败: %w", result.Error)
@@ -81,7 +81,7 @@ func (s *ActivityService) RecordActivity(activityType, title, message, source st
 			detailsJSON = string(detailsBytes)
 		}
 	}
-	activity.Details = detailsJSON
+	activity.Details = models.JSONText(detailsJSON)
 
 	if err := s.db.Create(activity).Error; err != nil {
 		return fmt.Errorf("记录系统活动失败: %w", err)
@@ -119,7 +119,7 @@ func (s *ActivityService) GetRecentActivities(limit int) ([]ActivityItem, error)
 			Message: activity.Message,
 			Time:    s.formatRelativeTime(activity.CreatedAt),
 			Source:  activity.Source,
-			Details: activity.Details,
+			Details: string(activity.Details),
 		}
 
 		// 如果有集群信息，在消息中包含集群名称
@@ -213,7 +213,10 @@ func (s *ActivityService) CreateSampleAlert(clusterID uint, level, title, messag
 func (s *ActivityService) CleanupOldActivities(ctx context.Context, retentionDays int) error {
 	cutoffTime := time.Now().AddDate(0, 0, -retentionDays)
 
-	// 清理过期活动
+	// 清理过期活动，已分区时先整体删除过期分区
+	if _, err := database.DropExpiredPartitions(models.SystemActivity{}.TableName(), cutoffTime); err != nil {
+		return fmt.Errorf("清理过期活动分区失败: %w", err)
+	}
 	result := s.db.Where("created_at < ?", cutoffTime).Delete(&models.SystemActivity{})
 	if result.Error != nil {
 		return fmt.Errorf("清理过期活动记录失败: %w", result.Error)
@@ -259,7 +262,10 @@ func (s *ActivityService) CleanupOldActivitiesWithStats(ctx context.Context, ret
 		logger.Error("告警去重清理失败: %v", err)
 	}
 
-	// 清理过期活动
+	// 清理过期活动，已分区时先整体删除过期分区
+	if _, err := database.DropExpiredPartitions(models.SystemActivity{}.TableName(), cutoffTime); err != nil {
+		return nil, fmt.Errorf("清理过期活动分区失败: %w", err)
+	}
 	activityResult := s.db.Where("created_at < ?", cutoffTime).Delete(&models.SystemActivity{})
 	if activityResult.Error != nil {
 		return nil, fmt.Errorf("清理过期活动记录失败: %w", activityResult.Error)
@@ -959,10 +965,11 @@ func (s *ActivityService) GetActivityStats(hours int) (map[string]interface{}, e
 		Hour  int   `json:"hour"`
 		Count int64 `json:"count"`
 	}
+	hourExpr := database.HourOfDayExpr("created_at")
 	err = s.db.Model(&models.SystemActivity{}).
-		Select("EXTRACT(HOUR FROM created_at) as hour, COUNT(*) as count").
+		Select(hourExpr+" as hour, COUNT(*) as count").
 		Where("created_at > ?", startTime).
-		Group("EXTRACT(HOUR FROM created_at)").
+		Group(hourExpr).
 		Order("hour").
 		Find(&hourlyStats).Error
 	if err != nil {
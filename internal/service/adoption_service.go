@@ -0,0 +1,696 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"cluster-resource-insight/internal/config"
+	"cluster-resource-insight/internal/database"
+	"cluster-resource-insight/internal/historystore"
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/models"
+	"cluster-resource-insight/pkg/cost"
+
+	"gorm.io/gorm"
+)
+
+// 建议采纳状态
+const (
+	AdoptionStatusPending          = "pending"           // 尚在观察期内，未检测到调整
+	AdoptionStatusAdopted          = "adopted"           // 配置已基本调整到建议值
+	AdoptionStatusPartiallyAdopted = "partially_adopted" // 配置向建议值方向做了部分调整
+	AdoptionStatusIgnored          = "ignored"           // 超过观察期仍未按建议调整
+	AdoptionStatusRemoved          = "removed"           // 建议生成后工作负载不再出现在采集数据中
+)
+
+// 建议来源
+const (
+	RecommendationSourceExport        = "export"         // 导出补丁归档
+	RecommendationSourceChangeRequest = "change_request" // 生成资源调整变更单
+	RecommendationSourceManual        = "manual"         // 手动记录快照
+)
+
+// 采纳判定参数
+const (
+	adoptedProgress       = 0.8  // 向建议值调整的幅度达到差距的80%视为已采纳
+	partialProgress       = 0.2  // 调整幅度达到差距的20%视为部分采纳
+	minRelativeGap        = 0.05 // 建议值与当前值相差不足5%时不参与判定
+	defaultAdoptionTeam   = "未分配"
+	adoptionSnapshotBatch = 100
+)
+
+// adoptionSettings 建议采纳跟踪参数
+type adoptionSettings struct {
+	teamLabel       string
+	grace           time.Duration
+	stabilityWindow time.Duration
+	tracking        time.Duration
+}
+
+// AdoptionService 资源建议采纳跟踪服务 - 记录建议快照，根据采集历史判断建议是否被采纳并计算实际节省
+type AdoptionService struct {
+	db        *gorm.DB
+	costModel *cost.Model
+}
+
+// NewAdoptionService 创建资源建议采纳跟踪服务实例
+func NewAdoptionService() *AdoptionService {
+	return &AdoptionService{
+		db:        database.GetDB(),
+		costModel: cost.DefaultModel(),
+	}
+}
+
+// loadAdoptionSettings 读取建议采纳跟踪配置，未配置项使用默认值
+func loadAdoptionSettings() adoptionSettings {
+	settings := adoptionSettings{
+		teamLabel:       "team",
+		grace:           14 * 24 * time.Hour,
+		stabilityWindow: 7 * 24 * time.Hour,
+		tracking:        60 * 24 * time.Hour,
+	}
+
+	if adoptionConfig := config.GetAdoptionConfig(); adoptionConfig != nil {
+		if adoptionConfig.TeamLabel != "" {
+			settings.teamLabel = adoptionConfig.TeamLabel
+		}
+		if adoptionConfig.GraceDays > 0 {
+			settings.grace = time.Duration(adoptionConfig.GraceDays) * 24 * time.Hour
+		}
+		if adoptionConfig.StabilityWindowDays > 0 {
+			settings.stabilityWindow = time.Duration(adoptionConfig.StabilityWindowDays) * 24 * time.Hour
+		}
+		if adoptionConfig.TrackingDays > 0 {
+			settings.tracking = time.Duration(adoptionConfig.TrackingDays) * 24 * time.Hour
+		}
+	}
+
+	return settings
+}
+
+// TeamLabel 返回命名空间上标识所属团队的标签
+func (as *AdoptionService) TeamLabel() string {
+	return loadAdoptionSettings().teamLabel
+}
+
+// RecommendationSnapshotInput 待记录的工作负载资源建议，资源量为单Pod各容器之和
+type RecommendationSnapshotInput struct {
+	ClusterID                uint
+	Namespace                string
+	Team                     string
+	WorkloadKind             string
+	WorkloadName             string
+	Source                   string
+	PodCount                 int
+	CurrentCPURequest        int64
+	CurrentCPULimit          int64
+	CurrentMemoryRequest     int64
+	CurrentMemoryLimit       int64
+	RecommendedCPURequest    int64
+	RecommendedCPULimit      int64
+	RecommendedMemoryRequest int64
+	RecommendedMemoryLimit   int64
+}
+
+// SaveRecommendationSnapshots 保存资源建议快照
+// 建议值与当前配置没有明显差距的工作负载不记录；同一工作负载已有待评估快照时不重复记录，
+// 以最早给出的建议作为采纳判定基准
+// 返回:
+//   - int: 新记录的快照数量
+//   - error: 保存过程中的错误信息
+func (as *AdoptionService) SaveRecommendationSnapshots(inputs []RecommendationSnapshotInput) (int, error) {
+	now := time.Now()
+	var snapshots []models.RecommendationSnapshot
+
+	for _, input := range inputs {
+		if !hasSignificantGap(input.CurrentCPURequest, input.RecommendedCPURequest) &&
+			!hasSignificantGap(input.CurrentMemoryRequest, input.RecommendedMemoryRequest) &&
+			!hasSignificantGap(input.CurrentCPULimit, input.RecommendedCPULimit) &&
+			!hasSignificantGap(input.CurrentMemoryLimit, input.RecommendedMemoryLimit) {
+			continue
+		}
+
+		var pendingCount int64
+		err := as.db.Model(&models.RecommendationSnapshot{}).
+			Where("cluster_id = ? AND namespace = ? AND workload_kind = ? AND workload_name = ? AND status = ?",
+				input.ClusterID, input.Namespace, input.WorkloadKind, input.WorkloadName, AdoptionStatusPending).
+			Count(&pendingCount).Error
+		if err != nil {
+			return 0, fmt.Errorf("查询建议快照失败: %v", err)
+		}
+		if pendingCount > 0 {
+			continue
+		}
+
+		team := input.Team
+		if team == "" {
+			team = defaultAdoptionTeam
+		}
+		snapshots = append(snapshots, models.RecommendationSnapshot{
+			ClusterID:                input.ClusterID,
+			Namespace:                input.Namespace,
+			Team:                     team,
+			WorkloadKind:             input.WorkloadKind,
+			WorkloadName:             input.WorkloadName,
+			Source:                   input.Source,
+			PodCount:                 input.PodCount,
+			CurrentCPURequest:        input.CurrentCPURequest,
+			CurrentCPULimit:          input.CurrentCPULimit,
+			CurrentMemoryRequest:     input.CurrentMemoryRequest,
+			CurrentMemoryLimit:       input.CurrentMemoryLimit,
+			RecommendedCPURequest:    input.RecommendedCPURequest,
+			RecommendedCPULimit:      input.RecommendedCPULimit,
+			RecommendedMemoryRequest: input.RecommendedMemoryRequest,
+			RecommendedMemoryLimit:   input.RecommendedMemoryLimit,
+			Status:                   AdoptionStatusPending,
+			ObservedCPURequest:       input.CurrentCPURequest,
+			ObservedCPULimit:         input.CurrentCPULimit,
+			ObservedMemoryRequest:    input.CurrentMemoryRequest,
+			ObservedMemoryLimit:      input.CurrentMemoryLimit,
+			ObservedPodCount:         input.PodCount,
+			GeneratedAt:              now,
+		})
+	}
+
+	if len(snapshots) == 0 {
+		return 0, nil
+	}
+	if err := as.db.CreateInBatches(snapshots, adoptionSnapshotBatch).Error; err != nil {
+		return 0, fmt.Errorf("保存建议快照失败: %v", err)
+	}
+	return len(snapshots), nil
+}
+
+// workloadRequestSample 单次采集中工作负载的配置，资源量取各Pod最大值
+type workloadRequestSample struct {
+	CollectedAt   time.Time
+	PodCount      int
+	CPURequest    int64
+	CPULimit      int64
+	MemoryRequest int64
+	MemoryLimit   int64
+}
+
+// EvaluateSnapshots 根据采集历史重新评估跟踪期内尚未结束评估的建议快照，由定时维护任务在清理原始数据之前调用
+// 参数:
+//   - clusterID: 集群ID，为nil时评估所有集群
+func (as *AdoptionService) EvaluateSnapshots(clusterID *uint) error {
+	settings := loadAdoptionSettings()
+	now := time.Now()
+
+	query := as.db.Where("generated_at >= ? AND frozen_at IS NULL", now.Add(-settings.tracking))
+	if clusterID != nil {
+		query = query.Where("cluster_id = ?", *clusterID)
+	}
+
+	var snapshots []models.RecommendationSnapshot
+	if err := query.Find(&snapshots).Error; err != nil {
+		return fmt.Errorf("查询建议快照失败: %v", err)
+	}
+
+	for i := range snapshots {
+		snapshot := &snapshots[i]
+		if err := as.evaluateSnapshot(snapshot, settings, now); err != nil {
+			logger.Error("评估建议快照 %d 失败: %v", snapshot.ID, err)
+			continue
+		}
+		if err := as.db.Save(snapshot).Error; err != nil {
+			logger.Error("更新建议快照 %d 失败: %v", snapshot.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// evaluateSnapshot 对比快照与建议生成后的采集数据，更新采纳状态和前后稳定性
+// 建议生成前的基准只在首次评估时记录一次，原始数据过了保留期后不会被覆盖为0；
+// 配置变化后的稳定性观察窗口结束后冻结快照，之后不再评估
+func (as *AdoptionService) evaluateSnapshot(snapshot *models.RecommendationSnapshot, settings adoptionSettings, now time.Time) error {
+	samples, err := workloadBatches(snapshot, snapshot.GeneratedAt, now)
+	if err != nil {
+		return fmt.Errorf("查询工作负载配置历史失败: %v", err)
+	}
+	// 只使用建议生成之后的采集
+	for len(samples) > 0 && !samples[0].CollectedAt.After(snapshot.GeneratedAt) {
+		samples = samples[1:]
+	}
+
+	snapshot.EvaluatedAt = &now
+	elapsed := now.Sub(snapshot.GeneratedAt)
+
+	if len(samples) == 0 {
+		snapshot.Status = AdoptionStatusPending
+		if elapsed > settings.grace {
+			snapshot.Status = AdoptionStatusRemoved
+		}
+		return nil
+	}
+
+	latest := samples[len(samples)-1]
+	snapshot.ObservedPodCount = latest.PodCount
+
+	if snapshot.BaselineRecordedAt == nil {
+		if err := as.recordBaseline(snapshot, samples[0], settings, now); err != nil {
+			return err
+		}
+	}
+
+	specFound, err := as.observeWorkloadSpec(snapshot)
+	if err != nil {
+		return err
+	}
+	if !specFound {
+		observeHistorySpec(snapshot, samples)
+	}
+	snapshot.Status = classifyAdoption(snapshot, elapsed > settings.grace)
+
+	snapshot.RestartsAfter, snapshot.OOMKillsAfter = 0, 0
+	if snapshot.ChangedAt != nil {
+		windowEnd := snapshot.ChangedAt.Add(settings.stabilityWindow)
+		restarts, oomKills, err := as.workloadStability(snapshot, *snapshot.ChangedAt, windowEnd)
+		if err != nil {
+			return err
+		}
+		snapshot.RestartsAfter, snapshot.OOMKillsAfter = restarts, oomKills
+		if !now.Before(windowEnd) {
+			snapshot.FrozenAt = &now
+		}
+	}
+
+	return nil
+}
+
+// recordBaseline 记录建议生成前最近一次采集的配置和观察窗口内的稳定性
+// 建议生成前没有采集数据时以建议生成后的第一次采集作为基准；观察窗口已超出原始数据保留期时
+// （升级前生成的快照）保留已有的稳定性统计，不用残缺的数据覆盖
+func (as *AdoptionService) recordBaseline(snapshot *models.RecommendationSnapshot, first workloadRequestSample, settings adoptionSettings, now time.Time) error {
+	windowStart := snapshot.GeneratedAt.Add(-settings.stabilityWindow)
+
+	baselines, err := workloadBatches(snapshot, windowStart, snapshot.GeneratedAt)
+	if err != nil {
+		return fmt.Errorf("查询工作负载基准配置失败: %v", err)
+	}
+	baseline := first
+	if len(baselines) > 0 {
+		baseline = baselines[len(baselines)-1]
+	}
+	snapshot.BaselineCPURequest, snapshot.BaselineCPULimit = baseline.CPURequest, baseline.CPULimit
+	snapshot.BaselineMemoryRequest, snapshot.BaselineMemoryLimit = baseline.MemoryRequest, baseline.MemoryLimit
+
+	if !windowStart.Before(now.AddDate(0, 0, -RawRetentionDays())) {
+		restarts, oomKills, err := as.workloadStability(snapshot, windowStart, snapshot.GeneratedAt)
+		if err != nil {
+			return err
+		}
+		snapshot.RestartsBefore, snapshot.OOMKillsBefore = restarts, oomKills
+	}
+
+	snapshot.BaselineRecordedAt = &now
+	return nil
+}
+
+// observeWorkloadSpec 从工作负载配置基线读取Pod模板实际配置的请求和限制（各容器之和，不含默认填充值），
+// 调整时间取建议生成后首次检测到资源配置变化的采集时间
+// 未开启资源配置变更记录或工作负载没有基线（如ReplicaSet）时返回false
+func (as *AdoptionService) observeWorkloadSpec(snapshot *models.RecommendationSnapshot) (bool, error) {
+	if !ConfigChangeEnabled() {
+		return false, nil
+	}
+
+	var state models.WorkloadSpecState
+	result := as.db.Where("cluster_id = ? AND namespace = ? AND workload_kind = ? AND workload_name = ?",
+		snapshot.ClusterID, snapshot.Namespace, snapshot.WorkloadKind, snapshot.WorkloadName).
+		Limit(1).Find(&state)
+	if result.Error != nil {
+		return false, fmt.Errorf("查询工作负载配置基线失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	var containers []ContainerResourceSpec
+	if err := json.Unmarshal([]byte(state.Containers), &containers); err != nil {
+		logger.Warn("工作负载 %s/%s/%s 配置基线解析失败，改用采集历史评估: %v", snapshot.Namespace, snapshot.WorkloadKind, snapshot.WorkloadName, err)
+		return false, nil
+	}
+
+	snapshot.ObservedCPURequest, snapshot.ObservedCPULimit = 0, 0
+	snapshot.ObservedMemoryRequest, snapshot.ObservedMemoryLimit = 0, 0
+	for _, container := range containers {
+		snapshot.ObservedCPURequest += container.CPURequest
+		snapshot.ObservedCPULimit += container.CPULimit
+		snapshot.ObservedMemoryRequest += container.MemoryRequest
+		snapshot.ObservedMemoryLimit += container.MemoryLimit
+	}
+
+	var changes []models.ResourceConfigChange
+	err := as.db.Where("cluster_id = ? AND namespace = ? AND workload_kind = ? AND workload_name = ? AND detected_at > ?",
+		snapshot.ClusterID, snapshot.Namespace, snapshot.WorkloadKind, snapshot.WorkloadName, snapshot.GeneratedAt).
+		Order("detected_at ASC").
+		Limit(1).
+		Find(&changes).Error
+	if err != nil {
+		return false, fmt.Errorf("查询资源配置变更记录失败: %v", err)
+	}
+	snapshot.ChangedAt = nil
+	if len(changes) > 0 {
+		changedAt := changes[0].DetectedAt
+		snapshot.ChangedAt = &changedAt
+	}
+	return true, nil
+}
+
+// observeHistorySpec 没有配置基线时根据采集历史推断调整后的配置
+// 采集数据中未配置的请求量会被填充默认值，因此以首次评估时记录的建议生成前配置作为比较基准，与基准一致时沿用快照中的原始配置
+func observeHistorySpec(snapshot *models.RecommendationSnapshot, samples []workloadRequestSample) {
+	baseline := workloadRequestSample{
+		CPURequest:    snapshot.BaselineCPURequest,
+		CPULimit:      snapshot.BaselineCPULimit,
+		MemoryRequest: snapshot.BaselineMemoryRequest,
+		MemoryLimit:   snapshot.BaselineMemoryLimit,
+	}
+
+	snapshot.ChangedAt = nil
+	for _, sample := range samples {
+		if sample.CPURequest != baseline.CPURequest || sample.CPULimit != baseline.CPULimit ||
+			sample.MemoryRequest != baseline.MemoryRequest || sample.MemoryLimit != baseline.MemoryLimit {
+			changedAt := sample.CollectedAt
+			snapshot.ChangedAt = &changedAt
+			break
+		}
+	}
+
+	latest := samples[len(samples)-1]
+	snapshot.ObservedCPURequest = observedValue(latest.CPURequest, baseline.CPURequest, snapshot.CurrentCPURequest)
+	snapshot.ObservedCPULimit = observedValue(latest.CPULimit, baseline.CPULimit, snapshot.CurrentCPULimit)
+	snapshot.ObservedMemoryRequest = observedValue(latest.MemoryRequest, baseline.MemoryRequest, snapshot.CurrentMemoryRequest)
+	snapshot.ObservedMemoryLimit = observedValue(latest.MemoryLimit, baseline.MemoryLimit, snapshot.CurrentMemoryLimit)
+}
+
+// observedValue 采集值与基准一致时视为未调整，沿用快照中的原始配置
+func observedValue(latest, baseline, current int64) int64 {
+	if latest == baseline {
+		return current
+	}
+	return latest
+}
+
+// classifyAdoption 根据配置向建议值调整的幅度判定采纳状态
+// 各项资源中差距明显的部分都调整到位视为采纳，任一项调整幅度达到阈值视为部分采纳
+func classifyAdoption(snapshot *models.RecommendationSnapshot, graceExpired bool) string {
+	type resourceValues struct{ current, recommended, observed int64 }
+	resources := []resourceValues{
+		{snapshot.CurrentCPURequest, snapshot.RecommendedCPURequest, snapshot.ObservedCPURequest},
+		{snapshot.CurrentMemoryRequest, snapshot.RecommendedMemoryRequest, snapshot.ObservedMemoryRequest},
+		{snapshot.CurrentCPULimit, snapshot.RecommendedCPULimit, snapshot.ObservedCPULimit},
+		{snapshot.CurrentMemoryLimit, snapshot.RecommendedMemoryLimit, snapshot.ObservedMemoryLimit},
+	}
+
+	considered, adopted, partial := 0, 0, 0
+	for _, resource := range resources {
+		if !hasSignificantGap(resource.current, resource.recommended) {
+			continue
+		}
+		considered++
+		progress := float64(resource.observed-resource.current) / float64(resource.recommended-resource.current)
+		if progress >= adoptedProgress {
+			adopted++
+		}
+		if progress >= partialProgress {
+			partial++
+		}
+	}
+
+	switch {
+	case considered > 0 && adopted == considered:
+		return AdoptionStatusAdopted
+	case partial > 0:
+		return AdoptionStatusPartiallyAdopted
+	case graceExpired:
+		return AdoptionStatusIgnored
+	default:
+		return AdoptionStatusPending
+	}
+}
+
+// hasSignificantGap 判断建议值与当前值的差距是否值得跟踪
+func hasSignificantGap(current, recommended int64) bool {
+	gap := math.Abs(float64(recommended - current))
+	return gap > 0 && gap >= float64(current)*minRelativeGap
+}
+
+// scanWorkloadSamples 遍历时间范围内（两端都包含）快照对应工作负载的采集样本
+func scanWorkloadSamples(snapshot *models.RecommendationSnapshot, start, end time.Time, fn func(record *models.PodMetricsHistory)) error {
+	return historystore.GetHistoryStore().Scan(context.Background(), historystore.RangeQuery{
+		Filter: historystore.Filter{
+			ClusterID:    snapshot.ClusterID,
+			Namespace:    snapshot.Namespace,
+			WorkloadKind: snapshot.WorkloadKind,
+			WorkloadName: snapshot.WorkloadName,
+		},
+		Start: start,
+		End:   end.Add(time.Millisecond),
+	}, func(record *models.PodMetricsHistory) error {
+		if !record.CollectedAt.After(end) {
+			fn(record)
+		}
+		return nil
+	})
+}
+
+// workloadBatches 按采集批次汇总时间范围内（两端都包含）工作负载的Pod数和单Pod最大请求、限制，按采集时间升序返回
+func workloadBatches(snapshot *models.RecommendationSnapshot, start, end time.Time) ([]workloadRequestSample, error) {
+	batches := make(map[int64]*workloadRequestSample)
+	err := scanWorkloadSamples(snapshot, start, end, func(record *models.PodMetricsHistory) {
+		key := record.CollectedAt.UnixNano()
+		batch, ok := batches[key]
+		if !ok {
+			batch = &workloadRequestSample{CollectedAt: record.CollectedAt}
+			batches[key] = batch
+		}
+		batch.PodCount++
+		batch.CPURequest = max(batch.CPURequest, record.CPURequest)
+		batch.CPULimit = max(batch.CPULimit, record.CPULimit)
+		batch.MemoryRequest = max(batch.MemoryRequest, record.MemoryRequest)
+		batch.MemoryLimit = max(batch.MemoryLimit, record.MemoryLimit)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]workloadRequestSample, 0, len(batches))
+	for _, batch := range batches {
+		samples = append(samples, *batch)
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].CollectedAt.Before(samples[j].CollectedAt) })
+	return samples, nil
+}
+
+// workloadStability 统计时间窗口内工作负载的重启次数和发生OOM的Pod数
+// 重启次数按各Pod窗口内累计重启数的增量求和
+func (as *AdoptionService) workloadStability(snapshot *models.RecommendationSnapshot, start, end time.Time) (int64, int64, error) {
+	type restartRange struct {
+		min, max int32
+	}
+	restartRanges := make(map[string]*restartRange)
+	oomPods := make(map[string]bool)
+	err := scanWorkloadSamples(snapshot, start, end, func(record *models.PodMetricsHistory) {
+		if r, ok := restartRanges[record.PodName]; ok {
+			r.min = min(r.min, record.RestartCount)
+			r.max = max(r.max, record.RestartCount)
+		} else {
+			restartRanges[record.PodName] = &restartRange{min: record.RestartCount, max: record.RestartCount}
+		}
+		if record.OOMKilled {
+			oomPods[record.PodName] = true
+		}
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("统计工作负载稳定性失败: %v", err)
+	}
+
+	var restarts int64
+	for _, r := range restartRanges {
+		restarts += int64(r.max - r.min)
+	}
+	return restarts, int64(len(oomPods)), nil
+}
+
+// AdoptionReportRequest 建议采纳报告查询条件
+type AdoptionReportRequest struct {
+	ClusterID *uint  // 集群ID，为nil时不限
+	Namespace string // 命名空间，为空时不限
+	Team      string // 团队，为空时不限
+}
+
+// AdoptionRecord 单条建议的采纳情况
+type AdoptionRecord struct {
+	models.RecommendationSnapshot
+	RealizedCPUSavings    int64   `json:"realized_cpu_savings"`    // 实际节省的CPU请求总量 (millicores)，为负表示增加
+	RealizedMemorySavings int64   `json:"realized_memory_savings"` // 实际节省的内存请求总量 (bytes)，为负表示增加
+	RealizedMonthlyCost   float64 `json:"realized_monthly_cost"`   // 实际节省的月度成本
+	MissedMonthlyCost     float64 `json:"missed_monthly_cost"`     // 被忽略建议未实现的月度成本节省
+}
+
+// AdoptionSummary 团队或命名空间维度的采纳汇总
+type AdoptionSummary struct {
+	Key                   string  `json:"key"` // 团队名，或 集群ID/命名空间
+	Total                 int     `json:"total"`
+	Pending               int     `json:"pending"`
+	Adopted               int     `json:"adopted"`
+	PartiallyAdopted      int     `json:"partially_adopted"`
+	Ignored               int     `json:"ignored"`
+	Removed               int     `json:"removed"`
+	AdoptionRate          float64 `json:"adoption_rate"` // (采纳+部分采纳)/已有结论的建议数，百分比
+	RealizedCPUSavings    int64   `json:"realized_cpu_savings"`
+	RealizedMemorySavings int64   `json:"realized_memory_savings"`
+	RealizedMonthlyCost   float64 `json:"realized_monthly_cost"`
+	MissedMonthlyCost     float64 `json:"missed_monthly_cost"`
+	RestartsBefore        int64   `json:"restarts_before"` // 仅统计已调整的建议
+	RestartsAfter         int64   `json:"restarts_after"`
+	OOMKillsBefore        int64   `json:"oom_kills_before"`
+	OOMKillsAfter         int64   `json:"oom_kills_after"`
+}
+
+// AdoptionReport 建议采纳报告
+type AdoptionReport struct {
+	Overall     AdoptionSummary   `json:"overall"`
+	Teams       []AdoptionSummary `json:"teams"`      // 按实际节省从高到低排序
+	Namespaces  []AdoptionSummary `json:"namespaces"` // 按实际节省从高到低排序
+	Records     []AdoptionRecord  `json:"records"`
+	GeneratedAt time.Time         `json:"generated_at"`
+}
+
+// GetAdoptionReport 生成建议采纳报告 - 按团队和命名空间汇总快照最近一次评估的结果，评估由定时维护任务完成
+func (as *AdoptionService) GetAdoptionReport(req AdoptionReportRequest) (*AdoptionReport, error) {
+	query := as.db.Model(&models.RecommendationSnapshot{})
+	if req.ClusterID != nil {
+		query = query.Where("cluster_id = ?", *req.ClusterID)
+	}
+	if req.Namespace != "" {
+		query = query.Where("namespace = ?", req.Namespace)
+	}
+	if req.Team != "" {
+		query = query.Where("team = ?", req.Team)
+	}
+
+	var snapshots []models.RecommendationSnapshot
+	if err := query.Order("generated_at DESC").Find(&snapshots).Error; err != nil {
+		return nil, fmt.Errorf("查询建议快照失败: %v", err)
+	}
+
+	report := &AdoptionReport{
+		Overall:     AdoptionSummary{Key: "overall"},
+		Teams:       []AdoptionSummary{},
+		Namespaces:  []AdoptionSummary{},
+		Records:     make([]AdoptionRecord, 0, len(snapshots)),
+		GeneratedAt: time.Now(),
+	}
+
+	teams := make(map[string]*AdoptionSummary)
+	namespaces := make(map[string]*AdoptionSummary)
+	for _, snapshot := range snapshots {
+		record := as.buildAdoptionRecord(snapshot)
+		report.Records = append(report.Records, record)
+
+		teamSummary, ok := teams[snapshot.Team]
+		if !ok {
+			teamSummary = &AdoptionSummary{Key: snapshot.Team}
+			teams[snapshot.Team] = teamSummary
+		}
+		namespaceKey := fmt.Sprintf("%d/%s", snapshot.ClusterID, snapshot.Namespace)
+		namespaceSummary, ok := namespaces[namespaceKey]
+		if !ok {
+			namespaceSummary = &AdoptionSummary{Key: namespaceKey}
+			namespaces[namespaceKey] = namespaceSummary
+		}
+
+		for _, summary := range []*AdoptionSummary{&report.Overall, teamSummary, namespaceSummary} {
+			addToAdoptionSummary(summary, record)
+		}
+	}
+
+	finalizeAdoptionSummary(&report.Overall)
+	report.Teams = sortedAdoptionSummaries(teams)
+	report.Namespaces = sortedAdoptionSummaries(namespaces)
+
+	return report, nil
+}
+
+// buildAdoptionRecord 计算单条建议的实际节省和未实现节省
+// 实际节省按 (建议前请求量-当前请求量)×当前Pod数 计算；被忽略的建议按 (建议前请求量-建议请求量)×Pod数 估算
+func (as *AdoptionService) buildAdoptionRecord(snapshot models.RecommendationSnapshot) AdoptionRecord {
+	record := AdoptionRecord{RecommendationSnapshot: snapshot}
+
+	switch snapshot.Status {
+	case AdoptionStatusAdopted, AdoptionStatusPartiallyAdopted:
+		pods := int64(snapshot.ObservedPodCount)
+		record.RealizedCPUSavings = (snapshot.CurrentCPURequest - snapshot.ObservedCPURequest) * pods
+		record.RealizedMemorySavings = (snapshot.CurrentMemoryRequest - snapshot.ObservedMemoryRequest) * pods
+		record.RealizedMonthlyCost = as.costModel.MonthlyCost(record.RealizedCPUSavings, record.RealizedMemorySavings)
+	case AdoptionStatusIgnored:
+		pods := int64(snapshot.PodCount)
+		missedCPU := (snapshot.CurrentCPURequest - snapshot.RecommendedCPURequest) * pods
+		missedMemory := (snapshot.CurrentMemoryRequest - snapshot.RecommendedMemoryRequest) * pods
+		record.MissedMonthlyCost = as.costModel.MonthlyCost(missedCPU, missedMemory)
+	}
+
+	return record
+}
+
+// addToAdoptionSummary 将单条建议计入汇总
+func addToAdoptionSummary(summary *AdoptionSummary, record AdoptionRecord) {
+	summary.Total++
+	switch record.Status {
+	case AdoptionStatusPending:
+		summary.Pending++
+	case AdoptionStatusAdopted:
+		summary.Adopted++
+	case AdoptionStatusPartiallyAdopted:
+		summary.PartiallyAdopted++
+	case AdoptionStatusIgnored:
+		summary.Ignored++
+	case AdoptionStatusRemoved:
+		summary.Removed++
+	}
+
+	summary.RealizedCPUSavings += record.RealizedCPUSavings
+	summary.RealizedMemorySavings += record.RealizedMemorySavings
+	summary.RealizedMonthlyCost += record.RealizedMonthlyCost
+	summary.MissedMonthlyCost += record.MissedMonthlyCost
+
+	if record.ChangedAt != nil {
+		summary.RestartsBefore += record.RestartsBefore
+		summary.RestartsAfter += record.RestartsAfter
+		summary.OOMKillsBefore += record.OOMKillsBefore
+		summary.OOMKillsAfter += record.OOMKillsAfter
+	}
+}
+
+// finalizeAdoptionSummary 计算采纳率
+func finalizeAdoptionSummary(summary *AdoptionSummary) {
+	decided := summary.Adopted + summary.PartiallyAdopted + summary.Ignored
+	if decided > 0 {
+		summary.AdoptionRate = float64(summary.Adopted+summary.PartiallyAdopted) / float64(decided) * 100
+	}
+}
+
+// sortedAdoptionSummaries 计算采纳率并按实际节省从高到低排序
+func sortedAdoptionSummaries(index map[string]*AdoptionSummary) []AdoptionSummary {
+	result := make([]AdoptionSummary, 0, len(index))
+	for _, summary := range index {
+		finalizeAdoptionSummary(summary)
+		result = append(result, *summary)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].RealizedMonthlyCost != result[j].RealizedMonthlyCost {
+			return result[i].RealizedMonthlyCost > result[j].RealizedMonthlyCost
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
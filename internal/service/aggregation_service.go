@@ -0,0 +1,461 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"cluster-resource-insight/internal/config"
+	"cluster-resource-insight/internal/database"
+
+	"gorm.io/gorm"
+)
+
+// 聚合分组维度
+const (
+	AggregateByCluster   = "cluster"
+	AggregateByNamespace = "namespace"
+	AggregateByNode      = "node"
+	AggregateByWorkload  = "workload"
+	AggregateByLabel     = "label" // 按Pod标签分组，需同时指定 label_keys
+)
+
+// 聚合函数
+const (
+	AggregateAvg = "avg"
+	AggregateMin = "min"
+	AggregateMax = "max"
+	AggregateSum = "sum" // 各采集批次合计值的平均值，适合展示命名空间、工作负载等分组的总量
+	AggregateP50 = "p50"
+	AggregateP95 = "p95"
+	AggregateP99 = "p99"
+)
+
+// aggregationSteps 支持的时间桶长度
+var aggregationSteps = map[string]time.Duration{
+	"5m": 5 * time.Minute,
+	"1h": time.Hour,
+	"1d": 24 * time.Hour,
+}
+
+// aggregationMetrics 支持聚合的指标及对应的历史视图列
+var aggregationMetrics = map[string]string{
+	"cpu_usage":      "cpu_usage",
+	"cpu_request":    "cpu_request",
+	"cpu_limit":      "cpu_limit",
+	"cpu_req_pct":    "cpu_req_pct",
+	"memory_usage":   "memory_usage",
+	"memory_request": "memory_request",
+	"memory_limit":   "memory_limit",
+	"memory_req_pct": "memory_req_pct",
+	"restart_count":  "restart_count",
+}
+
+// aggregationPercentiles 百分位函数对应的百分位数
+var aggregationPercentiles = map[string]int{
+	AggregateP50: 50,
+	AggregateP95: 95,
+	AggregateP99: 99,
+}
+
+// ErrAggregationLimit 查询结果超过聚合查询限制
+var ErrAggregationLimit = errors.New("超过聚合查询限制")
+
+// labelKeyPattern Kubernetes 标签键格式（可选前缀/名称）
+var labelKeyPattern = regexp.MustCompile(`^([A-Za-z0-9]([-A-Za-z0-9.]*[A-Za-z0-9])?/)?[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`)
+
+// aggregationSettings 聚合查询限制
+type aggregationSettings struct {
+	maxRangeDays int
+	maxPoints    int
+	maxSeries    int
+}
+
+// loadAggregationSettings 读取聚合查询限制，未配置项使用默认值
+func loadAggregationSettings() aggregationSettings {
+	settings := aggregationSettings{
+		maxRangeDays: 90,
+		maxPoints:    2000,
+		maxSeries:    200,
+	}
+
+	if aggregationConfig := config.GetAggregationConfig(); aggregationConfig != nil {
+		if aggregationConfig.MaxRangeDays > 0 {
+			settings.maxRangeDays = aggregationConfig.MaxRangeDays
+		}
+		if aggregationConfig.MaxPoints > 0 {
+			settings.maxPoints = aggregationConfig.MaxPoints
+		}
+		if aggregationConfig.MaxSeries > 0 {
+			settings.maxSeries = aggregationConfig.MaxSeries
+		}
+	}
+
+	return settings
+}
+
+// AggregationRequest 历史数据聚合查询请求
+type AggregationRequest struct {
+	GroupBy   []string          `json:"group_by"`   // 分组维度：cluster/namespace/node/workload/label，为空时聚合为一条序列
+	LabelKeys []string          `json:"label_keys"` // 按标签分组时使用的标签键
+	Step      string            `json:"step"`       // 时间桶：5m/1h/1d
+	Metrics   []string          `json:"metrics"`    // 指标列表
+	Functions []string          `json:"functions"`  // 聚合函数：avg/min/max/sum/p50/p95/p99
+	Start     time.Time         `json:"start"`
+	End       time.Time         `json:"end"`
+	Filters   AggregationFilter `json:"filters"`
+}
+
+// AggregationFilter 聚合查询筛选条件
+type AggregationFilter struct {
+	ClusterID    uint              `json:"cluster_id"`
+	Namespace    string            `json:"namespace"`
+	NodeName     string            `json:"node_name"`
+	WorkloadKind string            `json:"workload_kind"`
+	WorkloadName string            `json:"workload_name"`
+	PodName      string            `json:"pod_name"`
+	Labels       map[string]string `json:"labels"`       // Pod标签等值匹配
+	MetricsOnly  bool              `json:"metrics_only"` // 只统计来自真实metrics数据的样本
+}
+
+// AggregationPoint 序列中的一个时间桶
+type AggregationPoint struct {
+	Time    time.Time          `json:"time"`    // 时间桶起点（UTC对齐）
+	Samples int64              `json:"samples"` // 时间桶内的样本数
+	Batches int64              `json:"batches"` // 时间桶内的采集批次数
+	Values  map[string]float64 `json:"values"`  // 键为 指标_函数，如 cpu_usage_p95
+}
+
+// AggregationSeries 一个分组的聚合序列
+type AggregationSeries struct {
+	Labels map[string]string  `json:"labels"` // 分组维度取值
+	Points []AggregationPoint `json:"points"`
+}
+
+// AggregationResponse 聚合查询响应
+type AggregationResponse struct {
+	Step    string              `json:"step"`
+	Start   time.Time           `json:"start"`
+	End     time.Time           `json:"end"`
+	Columns []string            `json:"columns"` // 每个数据点 values 中的键
+	Series  []AggregationSeries `json:"series"`
+}
+
+// AggregationService 历史数据聚合查询服务 - 在数据库中完成分组、时间分桶和聚合计算
+type AggregationService struct {
+	db *gorm.DB
+}
+
+// NewAggregationService 创建聚合查询服务实例
+func NewAggregationService() *AggregationService {
+	return &AggregationService{
+		db: database.GetDB(),
+	}
+}
+
+// aggregationGroup 分组列定义
+type aggregationGroup struct {
+	name string      // 响应中的标签名
+	expr string      // 历史视图上的SQL表达式
+	arg  interface{} // 表达式参数，没有参数时为nil
+}
+
+// Validate 校验聚合查询请求参数和查询限制，未指定聚合函数时默认使用 avg
+func (as *AggregationService) Validate(req *AggregationRequest) error {
+	_, err := validateAggregationRequest(req, loadAggregationSettings())
+	return err
+}
+
+// validateAggregationRequest 校验请求参数并检查查询限制，返回时间桶长度
+func validateAggregationRequest(req *AggregationRequest, settings aggregationSettings) (time.Duration, error) {
+	step, ok := aggregationSteps[req.Step]
+	if !ok {
+		return 0, fmt.Errorf("step 仅支持 5m、1h、1d")
+	}
+	if req.Start.IsZero() || req.End.IsZero() || !req.Start.Before(req.End) {
+		return 0, fmt.Errorf("必须指定开始和结束时间，且开始时间早于结束时间")
+	}
+	if req.End.Sub(req.Start) > time.Duration(settings.maxRangeDays)*24*time.Hour {
+		return 0, fmt.Errorf("查询时间跨度不能超过 %d 天", settings.maxRangeDays)
+	}
+	if points := int(req.End.Sub(req.Start)/step) + 1; points > settings.maxPoints {
+		return 0, fmt.Errorf("时间桶数 %d 超过限制 %d，请缩短时间范围或增大 step", points, settings.maxPoints)
+	}
+
+	req.Metrics = uniqueStrings(req.Metrics)
+	req.Functions = uniqueStrings(req.Functions)
+	if len(req.Metrics) == 0 {
+		return 0, fmt.Errorf("至少指定一个指标")
+	}
+	for _, metric := range req.Metrics {
+		if _, ok := aggregationMetrics[metric]; !ok {
+			return 0, fmt.Errorf("不支持的指标: %s", metric)
+		}
+	}
+	if len(req.Functions) == 0 {
+		req.Functions = []string{AggregateAvg}
+	}
+	for _, function := range req.Functions {
+		switch function {
+		case AggregateAvg, AggregateMin, AggregateMax, AggregateSum, AggregateP50, AggregateP95, AggregateP99:
+		default:
+			return 0, fmt.Errorf("不支持的聚合函数: %s", function)
+		}
+	}
+
+	for _, group := range req.GroupBy {
+		switch group {
+		case AggregateByCluster, AggregateByNamespace, AggregateByNode, AggregateByWorkload:
+		case AggregateByLabel:
+			if len(req.LabelKeys) == 0 {
+				return 0, fmt.Errorf("按标签分组时必须指定 label_keys")
+			}
+		default:
+			return 0, fmt.Errorf("不支持的分组维度: %s", group)
+		}
+	}
+	for _, key := range req.LabelKeys {
+		if !labelKeyPattern.MatchString(key) {
+			return 0, fmt.Errorf("标签键格式错误: %s", key)
+		}
+	}
+	for key := range req.Filters.Labels {
+		if !labelKeyPattern.MatchString(key) {
+			return 0, fmt.Errorf("标签键格式错误: %s", key)
+		}
+	}
+
+	return step, nil
+}
+
+// uniqueStrings 去除重复项并保持原有顺序
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// aggregationGroups 根据分组维度生成分组列，同一维度只分组一次
+func aggregationGroups(req *AggregationRequest) []aggregationGroup {
+	var groups []aggregationGroup
+	seen := make(map[string]bool)
+	for _, group := range req.GroupBy {
+		if seen[group] {
+			continue
+		}
+		seen[group] = true
+		switch group {
+		case AggregateByCluster:
+			groups = append(groups, aggregationGroup{name: "cluster_id", expr: "cluster_id"})
+		case AggregateByNamespace:
+			groups = append(groups, aggregationGroup{name: "namespace", expr: "namespace"})
+		case AggregateByNode:
+			groups = append(groups, aggregationGroup{name: "node_name", expr: "node_name"})
+		case AggregateByWorkload:
+			groups = append(groups,
+				aggregationGroup{name: "workload_kind", expr: "workload_kind"},
+				aggregationGroup{name: "workload_name", expr: "workload_name"})
+		case AggregateByLabel:
+			for _, key := range req.LabelKeys {
+				expr, arg := database.JSONFieldExpr("labels", key)
+				groups = append(groups, aggregationGroup{name: "label:" + key, expr: expr, arg: arg})
+			}
+		}
+	}
+	return groups
+}
+
+// Query 执行聚合查询
+// 先按分组和时间桶计算样本所在的桶，百分位使用窗口函数按最近秩法在数据库中计算
+func (as *AggregationService) Query(req AggregationRequest) (*AggregationResponse, error) {
+	settings := loadAggregationSettings()
+	step, err := validateAggregationRequest(&req, settings)
+	if err != nil {
+		return nil, err
+	}
+	groups := aggregationGroups(&req)
+
+	// base：筛选样本并计算分组列和时间桶
+	var args []interface{}
+	var baseColumns, groupAliases []string
+	for i, group := range groups {
+		alias := fmt.Sprintf("g%d", i)
+		groupAliases = append(groupAliases, alias)
+		baseColumns = append(baseColumns, fmt.Sprintf("%s AS %s", group.expr, alias))
+		if group.arg != nil {
+			args = append(args, group.arg)
+		}
+	}
+	baseColumns = append(baseColumns, database.TimeBucketExpr("collected_at")+" AS bucket", "collected_at")
+	args = append(args, int64(step/time.Second))
+	for _, metric := range req.Metrics {
+		baseColumns = append(baseColumns, fmt.Sprintf("%s AS m_%s", aggregationMetrics[metric], metric))
+	}
+
+	var conditions []string
+	conditions = append(conditions, "collected_at >= ? AND collected_at < ?")
+	args = append(args, req.Start, req.End)
+	filters := req.Filters
+	if filters.ClusterID > 0 {
+		conditions = append(conditions, "cluster_id = ?")
+		args = append(args, filters.ClusterID)
+	}
+	if filters.Namespace != "" {
+		conditions = append(conditions, "namespace = ?")
+		args = append(args, filters.Namespace)
+	}
+	if filters.NodeName != "" {
+		conditions = append(conditions, "node_name = ?")
+		args = append(args, filters.NodeName)
+	}
+	if filters.WorkloadName != "" {
+		conditions = append(conditions, "workload_kind = ? AND workload_name = ?")
+		args = append(args, filters.WorkloadKind, filters.WorkloadName)
+	}
+	if filters.PodName != "" {
+		conditions = append(conditions, "pod_name = ?")
+		args = append(args, filters.PodName)
+	}
+	for key, value := range filters.Labels {
+		expr, arg := database.JSONFieldExpr("labels", key)
+		conditions = append(conditions, expr+" = ?")
+		args = append(args, arg, value)
+	}
+	if filters.MetricsOnly {
+		conditions = append(conditions, "metrics_available = ?")
+		args = append(args, true)
+	}
+
+	base := fmt.Sprintf("SELECT %s FROM pod_metrics_history WHERE %s",
+		strings.Join(baseColumns, ", "), strings.Join(conditions, " AND "))
+
+	// ranked：需要百分位时为每个指标计算桶内排名
+	partition := strings.Join(append(append([]string{}, groupAliases...), "bucket"), ", ")
+	needRank := false
+	for _, function := range req.Functions {
+		if _, ok := aggregationPercentiles[function]; ok {
+			needRank = true
+		}
+	}
+	source := "base"
+	withClause := "WITH base AS (" + base + ")"
+	if needRank {
+		rankColumns := []string{"base.*", fmt.Sprintf("COUNT(*) OVER (PARTITION BY %s) AS cnt", partition)}
+		for _, metric := range req.Metrics {
+			rankColumns = append(rankColumns, fmt.Sprintf("ROW_NUMBER() OVER (PARTITION BY %s ORDER BY m_%s) AS rn_%s", partition, metric, metric))
+		}
+		withClause += ", ranked AS (SELECT " + strings.Join(rankColumns, ", ") + " FROM base)"
+		source = "ranked"
+	}
+
+	// 外层按分组和时间桶聚合
+	selects := append(append([]string{}, groupAliases...), "bucket",
+		"COUNT(*) AS samples", "COUNT(DISTINCT collected_at) AS batches")
+	var columns []string
+	for _, metric := range req.Metrics {
+		m := "m_" + metric
+		for _, function := range req.Functions {
+			column := metric + "_" + function
+			columns = append(columns, column)
+			var expr string
+			switch function {
+			case AggregateAvg:
+				expr = fmt.Sprintf("AVG(%s)", m)
+			case AggregateMin:
+				expr = fmt.Sprintf("MIN(%s)", m)
+			case AggregateMax:
+				expr = fmt.Sprintf("MAX(%s)", m)
+			case AggregateSum:
+				expr = fmt.Sprintf("SUM(%s) * 1.0 / COUNT(DISTINCT collected_at)", m)
+			default:
+				// 最近秩法：第 ceil(p/100*n) 个值，即排名满足 rn*100 >= n*p 的最小值
+				expr = fmt.Sprintf("MIN(CASE WHEN rn_%s * 100 >= cnt * %d THEN %s END)", metric, aggregationPercentiles[function], m)
+			}
+			selects = append(selects, fmt.Sprintf("%s AS %s", expr, column))
+		}
+	}
+
+	// 多取一个序列的数据行用于判断序列数是否超过限制
+	maxPoints := int(req.End.Sub(req.Start)/step) + 1
+	limit := (settings.maxSeries + 1) * maxPoints
+	query := fmt.Sprintf("%s SELECT %s FROM %s GROUP BY %s ORDER BY %s LIMIT %d",
+		withClause, strings.Join(selects, ", "), source, partition, partition, limit)
+
+	rows, err := as.db.Raw(query, args...).Rows()
+	if err != nil {
+		return nil, fmt.Errorf("执行聚合查询失败: %v", err)
+	}
+	defer rows.Close()
+
+	result := &AggregationResponse{
+		Step:    req.Step,
+		Start:   req.Start,
+		End:     req.End,
+		Columns: columns,
+		Series:  []AggregationSeries{},
+	}
+
+	groupValues := make([]sql.NullString, len(groups))
+	metricValues := make([]sql.NullFloat64, len(columns))
+	var bucket float64
+	var samples, batches int64
+	dest := make([]interface{}, 0, len(groups)+3+len(columns))
+	for i := range groupValues {
+		dest = append(dest, &groupValues[i])
+	}
+	dest = append(dest, &bucket, &samples, &batches)
+	for i := range metricValues {
+		dest = append(dest, &metricValues[i])
+	}
+
+	var current *AggregationSeries
+	var currentKey string
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("读取聚合结果失败: %v", err)
+		}
+
+		labels := make(map[string]string, len(groups))
+		keyParts := make([]string, 0, len(groups))
+		for i, group := range groups {
+			labels[group.name] = groupValues[i].String
+			keyParts = append(keyParts, groupValues[i].String)
+		}
+		key := strings.Join(keyParts, "\x00")
+		if current == nil || key != currentKey {
+			if len(result.Series) >= settings.maxSeries {
+				return nil, fmt.Errorf("%w: 结果序列数超过 %d，请增加筛选条件或减少分组维度", ErrAggregationLimit, settings.maxSeries)
+			}
+			result.Series = append(result.Series, AggregationSeries{Labels: labels})
+			current = &result.Series[len(result.Series)-1]
+			currentKey = key
+		}
+
+		point := AggregationPoint{
+			Time:    time.Unix(int64(bucket)*int64(step/time.Second), 0).UTC(),
+			Samples: samples,
+			Batches: batches,
+			Values:  make(map[string]float64, len(columns)),
+		}
+		for i, column := range columns {
+			if metricValues[i].Valid {
+				point.Values[column] = metricValues[i].Float64
+			}
+		}
+		current.Points = append(current.Points, point)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取聚合结果失败: %v", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,431 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"cluster-resource-insight/internal/config"
+	"cluster-resource-insight/internal/database"
+	"cluster-resource-insight/internal/historystore"
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/models"
+	"cluster-resource-insight/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+// 异常类型
+const (
+	AnomalyTypeSpike = "spike" // 使用量突增
+	AnomalyTypeDrop  = "drop"  // 使用量骤降
+	AnomalyTypeZero  = "zero"  // 使用量降为0
+)
+
+// 异常检测范围
+const (
+	AnomalyScopeWorkload  = "workload"
+	AnomalyScopeNamespace = "namespace"
+)
+
+// maxAnomalyAlertsPerRun 单次检测最多创建的异常告警数量，超出部分汇总为一条告警
+const maxAnomalyAlertsPerRun = 10
+
+// anomalySettings 异常检测参数
+type anomalySettings struct {
+	enabled         bool
+	baselineDays    int
+	minSamples      int
+	alpha           float64
+	zScoreThreshold float64
+	minChangePct    float64
+}
+
+// AnomalyQuery 异常记录查询条件
+type AnomalyQuery struct {
+	ClusterID   uint   // 集群ID，0表示全部
+	Namespace   string // 命名空间筛选
+	Metric      string // 指标筛选：cpu_usage/memory_usage
+	AnomalyType string // 异常类型筛选：spike/drop/zero
+	Hours       int    // 查询最近多少小时
+	Limit       int    // 返回数量限制
+}
+
+// AnomalyService 使用量异常检测服务 - 将最新采集数据与EWMA基线比较，识别突增、骤降和归零
+type AnomalyService struct {
+	db              *gorm.DB
+	activityService *ActivityService
+}
+
+// NewAnomalyService 创建异常检测服务实例
+func NewAnomalyService() *AnomalyService {
+	return &AnomalyService{
+		db:              database.GetDB(),
+		activityService: NewActivityService(),
+	}
+}
+
+// loadAnomalySettings 读取异常检测配置，未配置项使用默认值
+func loadAnomalySettings() anomalySettings {
+	settings := anomalySettings{
+		enabled:         true,
+		baselineDays:    7,
+		minSamples:      6,
+		alpha:           0.3,
+		zScoreThreshold: 3.0,
+		minChangePct:    50,
+	}
+
+	if anomalyConfig := config.GetAnomalyConfig(); anomalyConfig != nil {
+		settings.enabled = anomalyConfig.Enabled
+		if anomalyConfig.BaselineDays > 0 {
+			settings.baselineDays = anomalyConfig.BaselineDays
+		}
+		if anomalyConfig.MinSamples > 0 {
+			settings.minSamples = anomalyConfig.MinSamples
+		}
+		if anomalyConfig.EWMAAlpha > 0 {
+			settings.alpha = anomalyConfig.EWMAAlpha
+		}
+		if anomalyConfig.ZScoreThreshold > 0 {
+			settings.zScoreThreshold = anomalyConfig.ZScoreThreshold
+		}
+		if anomalyConfig.MinChangePct > 0 {
+			settings.minChangePct = anomalyConfig.MinChangePct
+		}
+	}
+
+	return settings
+}
+
+// workloadKey 工作负载唯一标识
+type workloadKey struct {
+	Namespace    string
+	WorkloadKind string
+	WorkloadName string
+}
+
+// DetectAnomalies 对集群最新一次采集的数据执行异常检测
+// 工作负载范围使用每Pod平均使用量，避免扩缩容被误判为异常；命名空间范围使用总使用量
+// 参数:
+//   - clusterID: 集群ID
+//   - clusterName: 集群名称，用于告警消息
+//
+// 返回:
+//   - []models.ResourceAnomaly: 本次检测到的异常列表
+//   - error: 检测过程中的错误信息
+func (as *AnomalyService) DetectAnomalies(clusterID uint, clusterName string) ([]models.ResourceAnomaly, error) {
+	settings := loadAnomalySettings()
+	if !settings.enabled {
+		return nil, nil
+	}
+
+	startTime := time.Now().AddDate(0, 0, -settings.baselineDays)
+
+	// 按采集批次和工作负载汇总真实使用量，历史中不保存估算值，各指标按有真实数据的Pod数计算
+	type workloadTotal struct {
+		CollectedAt    time.Time
+		Namespace      string
+		WorkloadKind   string
+		WorkloadName   string
+		MemoryUsage    int64
+		CPUUsage       int64
+		MemoryPodCount int64
+		CPUPodCount    int64
+	}
+
+	points, err := historystore.GetHistoryStore().Aggregate(context.Background(), historystore.AggregateQuery{
+		Filter:  historystore.Filter{ClusterID: clusterID, MetricsOnly: true},
+		Start:   startTime,
+		End:     time.Now(),
+		GroupBy: historystore.GroupWorkload,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("查询异常检测历史数据失败: %v", err)
+	}
+
+	totals := make([]workloadTotal, 0, len(points))
+	for _, point := range points {
+		totals = append(totals, workloadTotal{
+			CollectedAt:    point.Time,
+			Namespace:      point.Namespace,
+			WorkloadKind:   point.WorkloadKind,
+			WorkloadName:   point.WorkloadName,
+			MemoryUsage:    int64(point.MemoryUsage),
+			CPUUsage:       int64(point.CPUUsage),
+			MemoryPodCount: int64(point.MemoryPodCount),
+			CPUPodCount:    int64(point.CPUPodCount),
+		})
+	}
+	if len(totals) == 0 {
+		return nil, nil
+	}
+
+	// 只有包含真实指标的采集批次参与比较，metrics-server不可用的批次整体跳过
+	var timestamps []time.Time
+	seen := make(map[time.Time]bool)
+	for _, t := range totals {
+		if !seen[t.CollectedAt] {
+			seen[t.CollectedAt] = true
+			timestamps = append(timestamps, t.CollectedAt)
+		}
+	}
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i].Before(timestamps[j]) })
+	latest := timestamps[len(timestamps)-1]
+
+	// 最新一次采集没有任何真实指标时跳过，避免重复检测上一批次
+	newer, err := historystore.GetHistoryStore().Aggregate(context.Background(), historystore.AggregateQuery{
+		Filter: historystore.Filter{ClusterID: clusterID},
+		Start:  latest.Add(time.Millisecond),
+		End:    time.Now(),
+	})
+	if err == nil && len(newer) > 0 {
+		logger.Info("集群 %s 最新采集批次缺少真实指标数据，跳过异常检测", clusterName)
+		return nil, nil
+	}
+
+	workloadMemory := make(map[workloadKey]map[time.Time]float64)
+	workloadCPU := make(map[workloadKey]map[time.Time]float64)
+	namespaceMemory := make(map[string]map[time.Time]float64)
+	namespaceCPU := make(map[string]map[time.Time]float64)
+
+	for _, t := range totals {
+		key := workloadKey{Namespace: t.Namespace, WorkloadKind: t.WorkloadKind, WorkloadName: t.WorkloadName}
+		if key.WorkloadName == "" {
+			continue
+		}
+		if workloadMemory[key] == nil {
+			workloadMemory[key] = make(map[time.Time]float64)
+			workloadCPU[key] = make(map[time.Time]float64)
+		}
+		if t.MemoryPodCount > 0 {
+			workloadMemory[key][t.CollectedAt] = float64(t.MemoryUsage) / float64(t.MemoryPodCount)
+		}
+		if t.CPUPodCount > 0 {
+			workloadCPU[key][t.CollectedAt] = float64(t.CPUUsage) / float64(t.CPUPodCount)
+		}
+
+		if namespaceMemory[t.Namespace] == nil {
+			namespaceMemory[t.Namespace] = make(map[time.Time]float64)
+			namespaceCPU[t.Namespace] = make(map[time.Time]float64)
+		}
+		if t.MemoryPodCount > 0 {
+			namespaceMemory[t.Namespace][t.CollectedAt] += float64(t.MemoryUsage)
+		}
+		if t.CPUPodCount > 0 {
+			namespaceCPU[t.Namespace][t.CollectedAt] += float64(t.CPUUsage)
+		}
+	}
+
+	var anomalies []models.ResourceAnomaly
+
+	// 工作负载范围：只检测最新批次中仍存在且该指标有真实数据的工作负载
+	for key, memorySeries := range workloadMemory {
+		for metric, series := range map[string]map[time.Time]float64{"memory_usage": memorySeries, "cpu_usage": workloadCPU[key]} {
+			if _, exists := series[latest]; !exists {
+				continue
+			}
+			baseline := seriesBefore(series, timestamps, latest, false)
+			if anomaly, found := evaluateAnomaly(baseline, series[latest], settings); found {
+				anomaly.ClusterID = clusterID
+				anomaly.Scope = AnomalyScopeWorkload
+				anomaly.Namespace = key.Namespace
+				anomaly.WorkloadKind = key.WorkloadKind
+				anomaly.WorkloadName = key.WorkloadName
+				anomaly.Metric = metric
+				anomaly.DetectedAt = latest
+				anomalies = append(anomalies, anomaly)
+			}
+		}
+	}
+
+	// 命名空间范围：曾经有使用量但最新批次缺失的命名空间按0处理，用于发现整体归零
+	for namespace, memorySeries := range namespaceMemory {
+		for metric, series := range map[string]map[time.Time]float64{"memory_usage": memorySeries, "cpu_usage": namespaceCPU[namespace]} {
+			baseline := seriesBefore(series, timestamps, latest, true)
+			if anomaly, found := evaluateAnomaly(baseline, series[latest], settings); found {
+				anomaly.ClusterID = clusterID
+				anomaly.Scope = AnomalyScopeNamespace
+				anomaly.Namespace = namespace
+				anomaly.Metric = metric
+				anomaly.DetectedAt = latest
+				anomalies = append(anomalies, anomaly)
+			}
+		}
+	}
+
+	if len(anomalies) == 0 {
+		return anomalies, nil
+	}
+
+	// 按偏离程度排序，优先为最严重的异常创建告警
+	sort.Slice(anomalies, func(i, j int) bool {
+		return math.Abs(anomalies[i].ZScore) > math.Abs(anomalies[j].ZScore)
+	})
+
+	if err := as.db.CreateInBatches(anomalies, 100).Error; err != nil {
+		return nil, fmt.Errorf("保存异常记录失败: %v", err)
+	}
+
+	as.raiseAnomalyAlerts(clusterID, clusterName, anomalies)
+
+	logger.Info("集群 %s 异常检测完成，发现 %d 个异常", clusterName, len(anomalies))
+	return anomalies, nil
+}
+
+// GetAnomalies 查询异常记录
+func (as *AnomalyService) GetAnomalies(query AnomalyQuery) ([]models.ResourceAnomaly, error) {
+	if query.Hours <= 0 {
+		query.Hours = 24
+	}
+	if query.Limit <= 0 {
+		query.Limit = 100
+	}
+
+	db := as.db.Model(&models.ResourceAnomaly{}).
+		Where("detected_at >= ?", time.Now().Add(-time.Duration(query.Hours)*time.Hour))
+	if query.ClusterID > 0 {
+		db = db.Where("cluster_id = ?", query.ClusterID)
+	}
+	if query.Namespace != "" {
+		db = db.Where("namespace = ?", query.Namespace)
+	}
+	if query.Metric != "" {
+		db = db.Where("metric = ?", query.Metric)
+	}
+	if query.AnomalyType != "" {
+		db = db.Where("anomaly_type = ?", query.AnomalyType)
+	}
+
+	var anomalies []models.ResourceAnomaly
+	if err := db.Order("detected_at DESC").Limit(query.Limit).Find(&anomalies).Error; err != nil {
+		return nil, fmt.Errorf("查询异常记录失败: %v", err)
+	}
+	return anomalies, nil
+}
+
+// raiseAnomalyAlerts 为检测到的异常创建告警，超出上限的部分汇总为一条告警
+func (as *AnomalyService) raiseAnomalyAlerts(clusterID uint, clusterName string, anomalies []models.ResourceAnomaly) {
+	for i, anomaly := range anomalies {
+		if i >= maxAnomalyAlertsPerRun {
+			break
+		}
+
+		target := anomaly.Namespace
+		if anomaly.Scope == AnomalyScopeWorkload {
+			target = fmt.Sprintf("%s/%s %s", anomaly.Namespace, anomaly.WorkloadKind, anomaly.WorkloadName)
+		}
+		title := fmt.Sprintf("资源使用异常(%s): %s %s", anomalyTypeDisplayName(anomaly.AnomalyType), target, anomaly.Metric)
+		message := fmt.Sprintf("集群 %s 中 %s 的 %s 当前为 %s，基线为 %s，偏离 %.1f%%（z-score %.1f）",
+			clusterName, target, anomaly.Metric,
+			formatMetricValue(anomaly.Metric, anomaly.Value), formatMetricValue(anomaly.Metric, anomaly.Baseline),
+			anomaly.DeviationPct, anomaly.ZScore)
+
+		if err := as.activityService.CreateAlert(clusterID, anomaly.Severity, title, message, "active"); err != nil {
+			logger.Error("创建异常告警失败: %v", err)
+		}
+	}
+
+	if len(anomalies) > maxAnomalyAlertsPerRun {
+		message := fmt.Sprintf("集群 %s 本次采集共检测到 %d 个使用量异常，仅为前 %d 个创建了独立告警",
+			clusterName, len(anomalies), maxAnomalyAlertsPerRun)
+		if err := as.activityService.CreateAlert(clusterID, "warning", "资源使用异常汇总", message, "active"); err != nil {
+			logger.Error("创建异常汇总告警失败: %v", err)
+		}
+	}
+}
+
+// seriesBefore 按时间顺序提取最新批次之前的样本
+// fillMissing为true时，序列首次出现之后缺失的批次按0补齐
+func seriesBefore(series map[time.Time]float64, timestamps []time.Time, latest time.Time, fillMissing bool) []float64 {
+	var values []float64
+	started := false
+	for _, ts := range timestamps {
+		if !ts.Before(latest) {
+			break
+		}
+		value, exists := series[ts]
+		if exists {
+			started = true
+			values = append(values, value)
+		} else if fillMissing && started {
+			values = append(values, 0)
+		}
+	}
+	return values
+}
+
+// evaluateAnomaly 使用EWMA均值和方差建立基线，并判断当前值是否异常
+func evaluateAnomaly(baseline []float64, current float64, settings anomalySettings) (models.ResourceAnomaly, bool) {
+	var anomaly models.ResourceAnomaly
+	if len(baseline) < settings.minSamples {
+		return anomaly, false
+	}
+
+	mean := baseline[0]
+	variance := 0.0
+	for _, value := range baseline[1:] {
+		diff := value - mean
+		mean += settings.alpha * diff
+		variance = (1 - settings.alpha) * (variance + settings.alpha*diff*diff)
+	}
+	if mean <= 0 {
+		return anomaly, false
+	}
+
+	// 标准差下限为均值的5%，避免平稳序列上的微小波动被放大
+	stdDev := math.Max(math.Sqrt(variance), mean*0.05)
+	zScore := (current - mean) / stdDev
+	deviationPct := (current - mean) / mean * 100
+
+	anomaly.Value = current
+	anomaly.Baseline = mean
+	anomaly.StdDev = stdDev
+	anomaly.ZScore = zScore
+	anomaly.DeviationPct = deviationPct
+
+	switch {
+	case current == 0 && baseline[len(baseline)-1] > 0 && math.Abs(zScore) >= settings.zScoreThreshold:
+		// 只在由非0转为0的批次判定归零，避免已下线的负载反复告警
+		anomaly.AnomalyType = AnomalyTypeZero
+		anomaly.Severity = "error"
+	case math.Abs(zScore) >= settings.zScoreThreshold && math.Abs(deviationPct) >= settings.minChangePct:
+		if zScore > 0 {
+			anomaly.AnomalyType = AnomalyTypeSpike
+		} else {
+			anomaly.AnomalyType = AnomalyTypeDrop
+		}
+		anomaly.Severity = "warning"
+		if deviationPct >= 200 || math.Abs(zScore) >= settings.zScoreThreshold*2 {
+			anomaly.Severity = "error"
+		}
+	default:
+		return anomaly, false
+	}
+
+	return anomaly, true
+}
+
+// anomalyTypeDisplayName 获取异常类型的显示名称
+func anomalyTypeDisplayName(anomalyType string) string {
+	switch anomalyType {
+	case AnomalyTypeSpike:
+		return "突增"
+	case AnomalyTypeDrop:
+		return "骤降"
+	case AnomalyTypeZero:
+		return "归零"
+	default:
+		return anomalyType
+	}
+}
+
+// formatMetricValue 按指标类型格式化数值
+func formatMetricValue(metric string, value float64) string {
+	if metric == "memory_usage" {
+		return utils.FormatBytes(int64(value))
+	}
+	return utils.FormatMillicores(int64(value))
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testAnomalySettings 与默认配置一致的异常检测参数
+var testAnomalySettings = anomalySettings{
+	enabled:         true,
+	baselineDays:    7,
+	minSamples:      6,
+	alpha:           0.3,
+	zScoreThreshold: 3.0,
+	minChangePct:    50,
+}
+
+func TestEvaluateAnomaly(t *testing.T) {
+	flat := []float64{100, 100, 100, 100, 100, 100}
+	noisy := []float64{60, 140, 60, 140, 60, 140}
+
+	tests := []struct {
+		name         string
+		baseline     []float64
+		current      float64
+		wantFound    bool
+		wantType     string
+		wantSeverity string
+	}{
+		{"空基线", nil, 100, false, "", ""},
+		{"基线样本不足", []float64{100, 100, 100, 100, 100}, 1000, false, "", ""},
+		{"平稳序列无变化", flat, 100, false, "", ""},
+		{"变化幅度低于最小变化百分比", flat, 130, false, "", ""},
+		{"基线均值为0", []float64{0, 0, 0, 0, 0, 0}, 50, false, "", ""},
+		{"突增超过200%为错误", flat, 300, true, AnomalyTypeSpike, "error"},
+		{"波动序列上的突增为警告", noisy, 250, true, AnomalyTypeSpike, "warning"},
+		{"波动序列上的正常值", noisy, 150, false, "", ""},
+		{"骤降", flat, 40, true, AnomalyTypeDrop, "error"},
+		{"由非0转为0判定归零", flat, 0, true, AnomalyTypeZero, "error"},
+		{"上一批次已为0时不再判定归零", []float64{100, 100, 100, 100, 100, 0}, 0, false, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			anomaly, found := evaluateAnomaly(tt.baseline, tt.current, testAnomalySettings)
+			if found != tt.wantFound {
+				t.Fatalf("found = %v，期望 %v（%+v）", found, tt.wantFound, anomaly)
+			}
+			if !found {
+				return
+			}
+			if anomaly.AnomalyType != tt.wantType || anomaly.Severity != tt.wantSeverity {
+				t.Errorf("得到 %s/%s，期望 %s/%s", anomaly.AnomalyType, anomaly.Severity, tt.wantType, tt.wantSeverity)
+			}
+			if anomaly.Value != tt.current || anomaly.Baseline <= 0 || anomaly.StdDev <= 0 {
+				t.Errorf("异常记录的当前值、基线或标准差不正确: %+v", anomaly)
+			}
+		})
+	}
+}
+
+func TestEvaluateAnomalyStdDevFloor(t *testing.T) {
+	// 平稳序列方差为0，标准差取均值的5%
+	anomaly, found := evaluateAnomaly([]float64{200, 200, 200, 200, 200, 200}, 500, testAnomalySettings)
+	if !found {
+		t.Fatal("期望检测到突增")
+	}
+	if anomaly.StdDev != 10 || anomaly.ZScore != 30 || anomaly.DeviationPct != 150 {
+		t.Errorf("标准差 %v、Z分数 %v、偏离 %v，期望 10、30、150", anomaly.StdDev, anomaly.ZScore, anomaly.DeviationPct)
+	}
+}
+
+func TestSeriesBefore(t *testing.T) {
+	base := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	at := func(i int) time.Time { return base.Add(time.Duration(i) * time.Hour) }
+	timestamps := []time.Time{at(0), at(1), at(2), at(3), at(4)}
+
+	tests := []struct {
+		name        string
+		series      map[time.Time]float64
+		timestamps  []time.Time
+		fillMissing bool
+		want        []float64
+	}{
+		{"没有采集批次", map[time.Time]float64{at(0): 1}, nil, false, nil},
+		{"没有样本", map[time.Time]float64{}, timestamps, true, nil},
+		{"只取最新批次之前的样本", map[time.Time]float64{at(0): 1, at(1): 2, at(2): 3, at(3): 4, at(4): 5}, timestamps, false, []float64{1, 2, 3, 4}},
+		{"缺失批次不补齐时跳过", map[time.Time]float64{at(0): 1, at(2): 3, at(4): 5}, timestamps, false, []float64{1, 3}},
+		{"首次出现之后的缺失批次补0", map[time.Time]float64{at(1): 2, at(3): 4, at(4): 5}, timestamps, true, []float64{2, 0, 4}},
+		{"首次出现之前的批次不补0", map[time.Time]float64{at(2): 3}, timestamps, true, []float64{3, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := seriesBefore(tt.series, tt.timestamps, at(4), tt.fillMissing)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("得到 %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,672 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"cluster-resource-insight/internal/config"
+	"cluster-resource-insight/internal/database"
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/models"
+
+	"gorm.io/gorm"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	appsv1ac "k8s.io/client-go/applyconfigurations/apps/v1"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// 变更单状态
+const (
+	ChangeStatusPendingApproval = "pending_approval" // 试运行通过，等待审批
+	ChangeStatusDryRunFailed    = "dry_run_failed"   // 服务端试运行失败
+	ChangeStatusRejected        = "rejected"         // 审批拒绝
+	ChangeStatusApplying        = "applying"         // 已审批，正在应用
+	ChangeStatusMonitoring      = "monitoring"       // 已应用，正在观察滚动更新健康状态
+	ChangeStatusCompleted       = "completed"        // 观察期结束，变更生效
+	ChangeStatusFailed          = "failed"           // 应用失败
+	ChangeStatusRolledBack      = "rolled_back"      // 已回滚
+)
+
+// activeHealthWatches 正在进行健康观察的变更单，避免同一变更单重复观察
+var activeHealthWatches sync.Map
+
+// 健康观察协程的生命周期，服务关闭时取消并等待全部退出
+var (
+	healthWatchCtx, cancelHealthWatches = context.WithCancel(context.Background())
+	healthWatchGroup                    sync.WaitGroup
+)
+
+// StopHealthWatches 停止所有健康观察并等待正在进行的检查或自动回滚结束，未结束的观察在服务重启后由 ResumeHealthWatches 恢复
+func StopHealthWatches() {
+	cancelHealthWatches()
+	healthWatchGroup.Wait()
+}
+
+// ContainerResourceSpec 容器资源配置，数值为0表示不设置
+type ContainerResourceSpec struct {
+	Name          string `json:"name" binding:"required"` // 容器名称
+	CPURequest    int64  `json:"cpu_request"`             // CPU请求量 (millicores)
+	CPULimit      int64  `json:"cpu_limit"`               // CPU限制量 (millicores)
+	MemoryRequest int64  `json:"memory_request"`          // 内存请求量 (bytes)
+	MemoryLimit   int64  `json:"memory_limit"`            // 内存限制量 (bytes)
+}
+
+// ProposeChangeRequest 提交资源调整请求
+type ProposeChangeRequest struct {
+	ClusterID    uint                    `json:"cluster_id" binding:"required"`
+	Namespace    string                  `json:"namespace" binding:"required"`
+	WorkloadKind string                  `json:"workload_kind" binding:"required"`
+	WorkloadName string                  `json:"workload_name" binding:"required"`
+	Containers   []ContainerResourceSpec `json:"containers"`
+	RequestedBy  string                  `json:"requested_by"`
+}
+
+// applySettings 资源调整应用参数
+type applySettings struct {
+	enabled        bool
+	fieldManager   string
+	healthWindow   time.Duration
+	healthInterval time.Duration
+}
+
+// ChangeService 资源调整变更服务 - 通过服务端应用试运行、审批后应用到集群，并在观察期内异常时自动回滚
+type ChangeService struct {
+	db              *gorm.DB
+	clusterService  *ClusterService
+	activityService *ActivityService
+}
+
+// NewChangeService 创建资源调整变更服务实例
+func NewChangeService() *ChangeService {
+	return &ChangeService{
+		db:              database.GetDB(),
+		clusterService:  NewClusterService(),
+		activityService: NewActivityService(),
+	}
+}
+
+// loadApplySettings 读取资源调整应用配置，未配置项使用默认值
+func loadApplySettings() applySettings {
+	settings := applySettings{
+		fieldManager:   "cluster-resource-insight",
+		healthWindow:   10 * time.Minute,
+		healthInterval: 15 * time.Second,
+	}
+
+	if applyConfig := config.GetApplyConfig(); applyConfig != nil {
+		settings.enabled = applyConfig.Enabled
+		if applyConfig.FieldManager != "" {
+			settings.fieldManager = applyConfig.FieldManager
+		}
+		if applyConfig.HealthWindowSeconds > 0 {
+			settings.healthWindow = time.Duration(applyConfig.HealthWindowSeconds) * time.Second
+		}
+		if applyConfig.HealthCheckInterval > 0 {
+			settings.healthInterval = time.Duration(applyConfig.HealthCheckInterval) * time.Second
+		}
+	}
+
+	return settings
+}
+
+// IsValidWorkloadKind 判断是否支持对该类型工作负载应用资源调整
+func IsValidWorkloadKind(kind string) bool {
+	switch kind {
+	case "Deployment", "StatefulSet", "DaemonSet":
+		return true
+	}
+	return false
+}
+
+// ProposeChange 提交资源调整变更单
+// 先对目标工作负载执行服务端应用试运行，通过后进入待审批状态；试运行失败的变更单同样保存以便排查
+// 参数:
+//   - ctx: 上下文对象
+//   - req: 变更请求，包含目标工作负载和各容器的目标资源配置
+//
+// 返回:
+//   - *models.ResourceChangeRequest: 创建的变更单
+//   - error: 校验或保存过程中的错误信息
+func (cs *ChangeService) ProposeChange(ctx context.Context, req *ProposeChangeRequest) (*models.ResourceChangeRequest, error) {
+	settings := loadApplySettings()
+	if !settings.enabled {
+		return nil, fmt.Errorf("资源调整应用功能未开启")
+	}
+	if !IsValidWorkloadKind(req.WorkloadKind) {
+		return nil, fmt.Errorf("不支持的工作负载类型: %s", req.WorkloadKind)
+	}
+	if len(req.Containers) == 0 {
+		return nil, fmt.Errorf("容器资源配置不能为空")
+	}
+
+	cluster, err := cs.clusterService.GetClusterByID(req.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+	kubeClient, _, err := cs.clusterService.CreateKubernetesClient(cluster)
+	if err != nil {
+		return nil, fmt.Errorf("创建集群 %s 客户端失败: %v", cluster.ClusterName, err)
+	}
+
+	current, _, err := getWorkloadContainers(ctx, kubeClient, req.WorkloadKind, req.Namespace, req.WorkloadName)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool, len(current))
+	for _, container := range current {
+		existing[container.Name] = true
+	}
+	for _, container := range req.Containers {
+		if !existing[container.Name] {
+			return nil, fmt.Errorf("工作负载 %s/%s 中不存在容器 %s", req.Namespace, req.WorkloadName, container.Name)
+		}
+	}
+
+	proposedJSON, err := json.Marshal(req.Containers)
+	if err != nil {
+		return nil, fmt.Errorf("序列化资源配置失败: %v", err)
+	}
+
+	change := &models.ResourceChangeRequest{
+		ClusterID:    req.ClusterID,
+		Namespace:    req.Namespace,
+		WorkloadKind: req.WorkloadKind,
+		WorkloadName: req.WorkloadName,
+		ProposedSpec: string(proposedJSON),
+		RequestedBy:  req.RequestedBy,
+	}
+
+	result, err := applyWorkloadResources(ctx, kubeClient, req.WorkloadKind, req.Namespace, req.WorkloadName, req.Containers, settings.fieldManager, true)
+	if err != nil {
+		change.Status = ChangeStatusDryRunFailed
+		change.DryRunResult = err.Error()
+	} else {
+		change.Status = ChangeStatusPendingApproval
+		resultJSON, _ := json.Marshal(result)
+		change.DryRunResult = string(resultJSON)
+	}
+
+	if err := cs.db.Create(change).Error; err != nil {
+		return nil, fmt.Errorf("保存变更单失败: %v", err)
+	}
+
+	logger.Info("资源调整变更单 %d 已创建: %s %s/%s, 状态 %s", change.ID, req.WorkloadKind, req.Namespace, req.WorkloadName, change.Status)
+	return change, nil
+}
+
+// ApproveChange 审批并应用变更单
+// 应用前记录当前资源配置用于回滚，应用后在观察期内监控滚动更新健康状态
+func (cs *ChangeService) ApproveChange(ctx context.Context, changeID uint, approver string) (*models.ResourceChangeRequest, error) {
+	settings := loadApplySettings()
+	if !settings.enabled {
+		return nil, fmt.Errorf("资源调整应用功能未开启")
+	}
+
+	change, err := cs.GetChange(changeID)
+	if err != nil {
+		return nil, err
+	}
+	if change.Status != ChangeStatusPendingApproval {
+		return nil, fmt.Errorf("变更单当前状态为 %s，无法审批", change.Status)
+	}
+
+	var proposed []ContainerResourceSpec
+	if err := json.Unmarshal([]byte(change.ProposedSpec), &proposed); err != nil {
+		return nil, fmt.Errorf("解析建议资源配置失败: %v", err)
+	}
+
+	cluster, err := cs.clusterService.GetClusterByID(change.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+	kubeClient, _, err := cs.clusterService.CreateKubernetesClient(cluster)
+	if err != nil {
+		return nil, fmt.Errorf("创建集群 %s 客户端失败: %v", cluster.ClusterName, err)
+	}
+
+	// 记录应用前的配置，仅保留本次调整涉及的容器
+	current, _, err := getWorkloadContainers(ctx, kubeClient, change.WorkloadKind, change.Namespace, change.WorkloadName)
+	if err != nil {
+		return nil, err
+	}
+	previous := filterContainers(current, proposed)
+	previousJSON, err := json.Marshal(previous)
+	if err != nil {
+		return nil, fmt.Errorf("序列化原资源配置失败: %v", err)
+	}
+
+	// 以条件更新抢占审批，并发审批或拒绝同一变更单时只有一方能将其从待审批状态转出
+	now := time.Now()
+	result := cs.db.Model(&models.ResourceChangeRequest{}).
+		Where("id = ? AND status = ?", change.ID, ChangeStatusPendingApproval).
+		Updates(map[string]interface{}{
+			"status":        ChangeStatusApplying,
+			"approved_by":   approver,
+			"approved_at":   now,
+			"previous_spec": string(previousJSON),
+		})
+	if result.Error != nil {
+		return nil, fmt.Errorf("更新变更单状态失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("变更单 %d 已被其他请求处理，无法审批", change.ID)
+	}
+	change.Status = ChangeStatusApplying
+	change.ApprovedBy = approver
+	change.ApprovedAt = &now
+	change.PreviousSpec = string(previousJSON)
+
+	if _, err := applyWorkloadResources(ctx, kubeClient, change.WorkloadKind, change.Namespace, change.WorkloadName, proposed, settings.fieldManager, false); err != nil {
+		change.Status = ChangeStatusFailed
+		change.Reason = err.Error()
+		if saveErr := cs.db.Save(change).Error; saveErr != nil {
+			logger.Error("保存变更单 %d 状态失败: %v", change.ID, saveErr)
+		}
+		return change, fmt.Errorf("应用资源调整失败: %v", err)
+	}
+
+	appliedAt := time.Now()
+	until := appliedAt.Add(settings.healthWindow)
+	change.Status = ChangeStatusMonitoring
+	change.AppliedAt = &appliedAt
+	change.HealthCheckUntil = &until
+	if err := cs.db.Save(change).Error; err != nil {
+		return nil, fmt.Errorf("保存变更单失败: %v", err)
+	}
+
+	cs.activityService.RecordActivity("info", "资源调整已应用",
+		fmt.Sprintf("%s %s/%s 资源调整已由 %s 审批并应用，观察期至 %s", change.WorkloadKind, change.Namespace, change.WorkloadName, approver, until.Format("15:04:05")),
+		"change", change.ClusterID, map[string]interface{}{"change_id": change.ID})
+
+	cs.startHealthWatch(change.ID)
+	return change, nil
+}
+
+// RejectChange 拒绝变更单
+func (cs *ChangeService) RejectChange(changeID uint, approver, reason string) (*models.ResourceChangeRequest, error) {
+	change, err := cs.GetChange(changeID)
+	if err != nil {
+		return nil, err
+	}
+	if change.Status != ChangeStatusPendingApproval && change.Status != ChangeStatusDryRunFailed {
+		return nil, fmt.Errorf("变更单当前状态为 %s，无法拒绝", change.Status)
+	}
+
+	now := time.Now()
+	result := cs.db.Model(&models.ResourceChangeRequest{}).
+		Where("id = ? AND status IN ?", change.ID, []string{ChangeStatusPendingApproval, ChangeStatusDryRunFailed}).
+		Updates(map[string]interface{}{
+			"status":      ChangeStatusRejected,
+			"approved_by": approver,
+			"approved_at": now,
+			"reason":      reason,
+		})
+	if result.Error != nil {
+		return nil, fmt.Errorf("保存变更单失败: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("变更单 %d 已被其他请求处理，无法拒绝", change.ID)
+	}
+	change.Status = ChangeStatusRejected
+	change.ApprovedBy = approver
+	change.ApprovedAt = &now
+	change.Reason = reason
+	return change, nil
+}
+
+// RollbackChange 将已应用的变更回滚到应用前的资源配置，与审批应用一样需要开启资源调整应用功能
+func (cs *ChangeService) RollbackChange(ctx context.Context, changeID uint, reason string) (*models.ResourceChangeRequest, error) {
+	settings := loadApplySettings()
+	if !settings.enabled {
+		return nil, fmt.Errorf("资源调整应用功能未开启")
+	}
+
+	change, err := cs.GetChange(changeID)
+	if err != nil {
+		return nil, err
+	}
+	if change.Status != ChangeStatusMonitoring && change.Status != ChangeStatusCompleted {
+		return nil, fmt.Errorf("变更单当前状态为 %s，无法回滚", change.Status)
+	}
+
+	var previous []ContainerResourceSpec
+	if err := json.Unmarshal([]byte(change.PreviousSpec), &previous); err != nil {
+		return nil, fmt.Errorf("解析原资源配置失败: %v", err)
+	}
+
+	cluster, err := cs.clusterService.GetClusterByID(change.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+	kubeClient, _, err := cs.clusterService.CreateKubernetesClient(cluster)
+	if err != nil {
+		return nil, fmt.Errorf("创建集群 %s 客户端失败: %v", cluster.ClusterName, err)
+	}
+
+	if _, err := applyWorkloadResources(ctx, kubeClient, change.WorkloadKind, change.Namespace, change.WorkloadName, previous, settings.fieldManager, false); err != nil {
+		return nil, fmt.Errorf("回滚资源调整失败: %v", err)
+	}
+
+	now := time.Now()
+	change.Status = ChangeStatusRolledBack
+	change.RolledBackAt = &now
+	change.Reason = reason
+	if err := cs.db.Save(change).Error; err != nil {
+		return nil, fmt.Errorf("保存变更单失败: %v", err)
+	}
+
+	cs.activityService.RecordActivity("warning", "资源调整已回滚",
+		fmt.Sprintf("%s %s/%s 资源调整已回滚: %s", change.WorkloadKind, change.Namespace, change.WorkloadName, reason),
+		"change", change.ClusterID, map[string]interface{}{"change_id": change.ID})
+
+	logger.Info("资源调整变更单 %d 已回滚: %s", change.ID, reason)
+	return change, nil
+}
+
+// GetChange 获取变更单详情
+func (cs *ChangeService) GetChange(changeID uint) (*models.ResourceChangeRequest, error) {
+	var change models.ResourceChangeRequest
+	if err := cs.db.First(&change, changeID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("变更单不存在")
+		}
+		return nil, fmt.Errorf("获取变更单失败: %v", err)
+	}
+	return &change, nil
+}
+
+// ListChanges 查询变更单列表
+func (cs *ChangeService) ListChanges(clusterID uint, status string, limit int) ([]models.ResourceChangeRequest, error) {
+	query := cs.db.Model(&models.ResourceChangeRequest{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var changes []models.ResourceChangeRequest
+	if err := query.Order("created_at DESC").Limit(limit).Find(&changes).Error; err != nil {
+		return nil, fmt.Errorf("查询变更单失败: %v", err)
+	}
+	return changes, nil
+}
+
+// ResumeHealthWatches 恢复服务重启前未结束的健康观察，已过观察期的变更单直接标记为完成
+func (cs *ChangeService) ResumeHealthWatches() {
+	var changes []models.ResourceChangeRequest
+	if err := cs.db.Where("status = ?", ChangeStatusMonitoring).Find(&changes).Error; err != nil {
+		logger.Error("查询观察中的变更单失败: %v", err)
+		return
+	}
+
+	for _, change := range changes {
+		if change.HealthCheckUntil == nil || time.Now().After(*change.HealthCheckUntil) {
+			cs.db.Model(&models.ResourceChangeRequest{}).Where("id = ?", change.ID).Update("status", ChangeStatusCompleted)
+			continue
+		}
+		cs.startHealthWatch(change.ID)
+	}
+}
+
+// startHealthWatch 启动变更单的健康观察
+func (cs *ChangeService) startHealthWatch(changeID uint) {
+	if healthWatchCtx.Err() != nil {
+		return
+	}
+	if _, running := activeHealthWatches.LoadOrStore(changeID, true); running {
+		return
+	}
+	healthWatchGroup.Add(1)
+	go func() {
+		defer healthWatchGroup.Done()
+		defer activeHealthWatches.Delete(changeID)
+		cs.watchRolloutHealth(healthWatchCtx, changeID)
+	}()
+}
+
+// watchRolloutHealth 在观察期内定期检查工作负载的Pod，出现CrashLoopBackOff或OOMKilled时自动回滚
+func (cs *ChangeService) watchRolloutHealth(ctx context.Context, changeID uint) {
+	settings := loadApplySettings()
+	ticker := time.NewTicker(settings.healthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		change, err := cs.GetChange(changeID)
+		if err != nil {
+			logger.Error("健康观察获取变更单 %d 失败: %v", changeID, err)
+			return
+		}
+		// 变更单已被手动回滚等操作结束观察
+		if change.Status != ChangeStatusMonitoring || change.AppliedAt == nil || change.HealthCheckUntil == nil {
+			return
+		}
+
+		problem, err := cs.checkRolloutHealth(change)
+		if err != nil {
+			logger.Warn("变更单 %d 健康检查失败: %v", changeID, err)
+		} else if problem != "" {
+			logger.Warn("变更单 %d 检测到异常，自动回滚: %s", changeID, problem)
+			ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+			_, rollbackErr := cs.RollbackChange(ctx, changeID, "自动回滚: "+problem)
+			cancel()
+
+			title := fmt.Sprintf("资源调整自动回滚: %s/%s", change.Namespace, change.WorkloadName)
+			message := fmt.Sprintf("%s %s/%s 应用资源调整后出现异常（%s），已自动回滚", change.WorkloadKind, change.Namespace, change.WorkloadName, problem)
+			if rollbackErr != nil {
+				title = fmt.Sprintf("资源调整自动回滚失败: %s/%s", change.Namespace, change.WorkloadName)
+				message = fmt.Sprintf("%s %s/%s 应用资源调整后出现异常（%s），自动回滚失败: %v", change.WorkloadKind, change.Namespace, change.WorkloadName, problem, rollbackErr)
+				logger.Error("变更单 %d 自动回滚失败: %v", changeID, rollbackErr)
+			}
+			if err := cs.activityService.CreateAlert(change.ClusterID, "error", title, message, "active"); err != nil {
+				logger.Error("创建回滚告警失败: %v", err)
+			}
+			return
+		}
+
+		if time.Now().After(*change.HealthCheckUntil) {
+			if err := cs.db.Model(&models.ResourceChangeRequest{}).Where("id = ? AND status = ?", changeID, ChangeStatusMonitoring).
+				Update("status", ChangeStatusCompleted).Error; err != nil {
+				logger.Error("更新变更单 %d 状态失败: %v", changeID, err)
+			}
+			logger.Info("变更单 %d 观察期结束，未发现异常", changeID)
+			return
+		}
+	}
+}
+
+// checkRolloutHealth 检查变更应用后工作负载的Pod状态，返回发现的问题描述
+// 应用后新建的Pod处于CrashLoopBackOff，或任意Pod在应用后发生OOMKilled均视为异常
+func (cs *ChangeService) checkRolloutHealth(change *models.ResourceChangeRequest) (string, error) {
+	cluster, err := cs.clusterService.GetClusterByID(change.ClusterID)
+	if err != nil {
+		return "", err
+	}
+	kubeClient, _, err := cs.clusterService.CreateKubernetesClient(cluster)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, selector, err := getWorkloadContainers(ctx, kubeClient, change.WorkloadKind, change.Namespace, change.WorkloadName)
+	if err != nil {
+		return "", err
+	}
+	labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return "", fmt.Errorf("解析工作负载选择器失败: %v", err)
+	}
+
+	pods, err := kubeClient.CoreV1().Pods(change.Namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector.String()})
+	if err != nil {
+		return "", fmt.Errorf("获取工作负载Pod失败: %v", err)
+	}
+
+	appliedAt := *change.AppliedAt
+	for _, pod := range pods.Items {
+		createdAfterApply := !pod.CreationTimestamp.Time.Before(appliedAt)
+		for _, status := range pod.Status.ContainerStatuses {
+			if createdAfterApply && status.State.Waiting != nil && status.State.Waiting.Reason == "CrashLoopBackOff" {
+				return fmt.Sprintf("Pod %s 容器 %s 处于CrashLoopBackOff", pod.Name, status.Name), nil
+			}
+			for _, terminated := range []*corev1.ContainerStateTerminated{status.State.Terminated, status.LastTerminationState.Terminated} {
+				if terminated != nil && terminated.Reason == "OOMKilled" && terminated.FinishedAt.Time.After(appliedAt) {
+					return fmt.Sprintf("Pod %s 容器 %s 发生OOMKilled", pod.Name, status.Name), nil
+				}
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// filterContainers 从当前配置中筛选出变更涉及的容器
+func filterContainers(current, proposed []ContainerResourceSpec) []ContainerResourceSpec {
+	names := make(map[string]bool, len(proposed))
+	for _, container := range proposed {
+		names[container.Name] = true
+	}
+	result := make([]ContainerResourceSpec, 0, len(proposed))
+	for _, container := range current {
+		if names[container.Name] {
+			result = append(result, container)
+		}
+	}
+	return result
+}
+
+// getWorkloadContainers 获取工作负载Pod模板中的容器资源配置和Pod选择器
+func getWorkloadContainers(ctx context.Context, kubeClient kubernetes.Interface, kind, namespace, name string) ([]ContainerResourceSpec, *metav1.LabelSelector, error) {
+	var containers []corev1.Container
+	var selector *metav1.LabelSelector
+
+	switch kind {
+	case "Deployment":
+		workload, err := kubeClient.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, nil, fmt.Errorf("获取Deployment %s/%s 失败: %v", namespace, name, err)
+		}
+		containers, selector = workload.Spec.Template.Spec.Containers, workload.Spec.Selector
+	case "StatefulSet":
+		workload, err := kubeClient.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, nil, fmt.Errorf("获取StatefulSet %s/%s 失败: %v", namespace, name, err)
+		}
+		containers, selector = workload.Spec.Template.Spec.Containers, workload.Spec.Selector
+	case "DaemonSet":
+		workload, err := kubeClient.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, nil, fmt.Errorf("获取DaemonSet %s/%s 失败: %v", namespace, name, err)
+		}
+		containers, selector = workload.Spec.Template.Spec.Containers, workload.Spec.Selector
+	default:
+		return nil, nil, fmt.Errorf("不支持的工作负载类型: %s", kind)
+	}
+
+	return containerSpecsFromPodSpec(containers), selector, nil
+}
+
+// applyWorkloadResources 使用服务端应用更新工作负载的容器资源，dryRun为true时仅试运行
+// 返回服务端合并后的容器资源配置
+func applyWorkloadResources(ctx context.Context, kubeClient kubernetes.Interface, kind, namespace, name string, containers []ContainerResourceSpec, fieldManager string, dryRun bool) ([]ContainerResourceSpec, error) {
+	options := metav1.ApplyOptions{FieldManager: fieldManager, Force: true}
+	if dryRun {
+		options.DryRun = []string{metav1.DryRunAll}
+	}
+
+	template := corev1ac.PodTemplateSpec().WithSpec(corev1ac.PodSpec().WithContainers(buildContainerApplyConfigs(containers)...))
+
+	switch kind {
+	case "Deployment":
+		applied, err := kubeClient.AppsV1().Deployments(namespace).Apply(ctx,
+			appsv1ac.Deployment(name, namespace).WithSpec(appsv1ac.DeploymentSpec().WithTemplate(template)), options)
+		if err != nil {
+			return nil, err
+		}
+		return containerSpecsFromPodSpec(applied.Spec.Template.Spec.Containers), nil
+	case "StatefulSet":
+		applied, err := kubeClient.AppsV1().StatefulSets(namespace).Apply(ctx,
+			appsv1ac.StatefulSet(name, namespace).WithSpec(appsv1ac.StatefulSetSpec().WithTemplate(template)), options)
+		if err != nil {
+			return nil, err
+		}
+		return containerSpecsFromPodSpec(applied.Spec.Template.Spec.Containers), nil
+	case "DaemonSet":
+		applied, err := kubeClient.AppsV1().DaemonSets(namespace).Apply(ctx,
+			appsv1ac.DaemonSet(name, namespace).WithSpec(appsv1ac.DaemonSetSpec().WithTemplate(template)), options)
+		if err != nil {
+			return nil, err
+		}
+		return containerSpecsFromPodSpec(applied.Spec.Template.Spec.Containers), nil
+	}
+
+	return nil, fmt.Errorf("不支持的工作负载类型: %s", kind)
+}
+
+// buildContainerApplyConfigs 构建容器资源的服务端应用配置，数值为0的字段不设置
+// 回滚时未设置的字段会因字段管理者不再声明而被移除，从而恢复原始的未设置状态
+func buildContainerApplyConfigs(containers []ContainerResourceSpec) []*corev1ac.ContainerApplyConfiguration {
+	configs := make([]*corev1ac.ContainerApplyConfiguration, 0, len(containers))
+	for _, container := range containers {
+		requests := corev1.ResourceList{}
+		limits := corev1.ResourceList{}
+		if container.CPURequest > 0 {
+			requests[corev1.ResourceCPU] = *resource.NewMilliQuantity(container.CPURequest, resource.DecimalSI)
+		}
+		if container.MemoryRequest > 0 {
+			requests[corev1.ResourceMemory] = *resource.NewQuantity(container.MemoryRequest, resource.BinarySI)
+		}
+		if container.CPULimit > 0 {
+			limits[corev1.ResourceCPU] = *resource.NewMilliQuantity(container.CPULimit, resource.DecimalSI)
+		}
+		if container.MemoryLimit > 0 {
+			limits[corev1.ResourceMemory] = *resource.NewQuantity(container.MemoryLimit, resource.BinarySI)
+		}
+
+		resources := corev1ac.ResourceRequirements()
+		if len(requests) > 0 {
+			resources.WithRequests(requests)
+		}
+		if len(limits) > 0 {
+			resources.WithLimits(limits)
+		}
+		configs = append(configs, corev1ac.Container().WithName(container.Name).WithResources(resources))
+	}
+	return configs
+}
+
+// containerSpecsFromPodSpec 提取容器的资源配置
+func containerSpecsFromPodSpec(containers []corev1.Container) []ContainerResourceSpec {
+	specs := make([]ContainerResourceSpec, 0, len(containers))
+	for _, container := range containers {
+		spec := ContainerResourceSpec{Name: container.Name}
+		if quantity, ok := container.Resources.Requests[corev1.ResourceCPU]; ok {
+			spec.CPURequest = quantity.MilliValue()
+		}
+		if quantity, ok := container.Resources.Requests[corev1.ResourceMemory]; ok {
+			spec.MemoryRequest = quantity.Value()
+		}
+		if quantity, ok := container.Resources.Limits[corev1.ResourceCPU]; ok {
+			spec.CPULimit = quantity.MilliValue()
+		}
+		if quantity, ok := container.Resources.Limits[corev1.ResourceMemory]; ok {
+			spec.MemoryLimit = quantity.Value()
+		}
+		specs = append(specs, spec)
+	}
+	return specs
+}
@@ -120,7 +120,7 @@ func (cs *ClusterService) CreateCluster(req *CreateClusterRequest) (*models.Clus
 		AuthType:        req.AuthType,
 		AuthConfig:      encryptedAuthConfig,
 		Status:          "unknown", // 初始状态为未知，需要连接测试后更新
-		Tags:            tagsJSON,
+		Tags:            models.JSONText(tagsJSON),
 		CollectInterval: collectInterval,
 	}
 
@@ -195,7 +195,7 @@ func (cs *ClusterService) UpdateCluster(clusterID uint, req *UpdateClusterReques
 		if err != nil {
 			return nil, fmt.Errorf("序列化标签失败: %v", err)
 		}
-		cluster.Tags = string(tagsBytes)
+		cluster.Tags = models.JSONText(tagsBytes)
 	}
 	if req.CollectInterval != nil {
 		collectInterval := *req.CollectInterval
@@ -0,0 +1,421 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"cluster-resource-insight/internal/config"
+	"cluster-resource-insight/internal/database"
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/models"
+	"cluster-resource-insight/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+// 容器资源配置变化类型
+const (
+	ConfigChangeModified = "modified" // 请求或限制被修改
+	ConfigChangeAdded    = "added"    // 新增容器
+	ConfigChangeRemoved  = "removed"  // 删除容器
+)
+
+const (
+	// defaultConfigChangeLimit 变更记录查询默认返回条数
+	defaultConfigChangeLimit = 100
+	// maxConfigChangeLimit 变更记录查询最大返回条数
+	maxConfigChangeLimit = 1000
+)
+
+// WorkloadSpecObservation 一次采集中观察到的工作负载Pod模板资源配置
+type WorkloadSpecObservation struct {
+	Namespace     string
+	WorkloadKind  string
+	WorkloadName  string
+	Containers    []ContainerResourceSpec
+	Revision      string                 // 当前Pod模板对应的发布版本
+	FieldManagers []ResourceFieldManager // managedFields 中拥有容器资源字段的条目
+}
+
+// 容器资源字段名称，与 managedFields 中的字段路径对应
+const (
+	ResourceFieldCPURequest    = "requests.cpu"
+	ResourceFieldCPULimit      = "limits.cpu"
+	ResourceFieldMemoryRequest = "requests.memory"
+	ResourceFieldMemoryLimit   = "limits.memory"
+)
+
+// ResourceFieldManager managedFields 中的一个字段管理者条目及其拥有的容器资源字段
+type ResourceFieldManager struct {
+	Manager   string
+	Operation string              // 操作类型：Apply/Update
+	Time      *time.Time          // 该管理者最近一次写入的时间，覆盖它拥有的全部字段，不只是资源字段
+	Fields    map[string][]string // 容器名称 → 拥有的资源字段，如 requests.cpu
+}
+
+// owns 判断条目是否拥有容器的任一指定资源字段
+func (m ResourceFieldManager) owns(container string, fields []string) bool {
+	for _, owned := range m.Fields[container] {
+		for _, field := range fields {
+			if owned == field {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// changedResourceFields 返回容器变化涉及的资源字段：修改时为新旧值不同的字段，新增时为新设置的字段
+func changedResourceFields(diff containerSpecDiff) []string {
+	var fields []string
+	check := func(field string, old, new int64) {
+		if old != new {
+			fields = append(fields, field)
+		}
+	}
+	check(ResourceFieldCPURequest, diff.old.CPURequest, diff.new.CPURequest)
+	check(ResourceFieldCPULimit, diff.old.CPULimit, diff.new.CPULimit)
+	check(ResourceFieldMemoryRequest, diff.old.MemoryRequest, diff.new.MemoryRequest)
+	check(ResourceFieldMemoryLimit, diff.old.MemoryLimit, diff.new.MemoryLimit)
+	return fields
+}
+
+// attributeChange 推断容器资源变化的修改者：在拥有变化字段的管理者中取最近写入的一个
+// managedFields 只记录字段归属和管理者最近一次写入的时间，写入时间早于上次检测到变化（notBefore）的管理者不可能做出本次修改；
+// 删除容器后其字段不再有归属，无法推断，返回nil
+func attributeChange(managers []ResourceFieldManager, diff containerSpecDiff, notBefore time.Time) *ResourceFieldManager {
+	if diff.changeType == ConfigChangeRemoved {
+		return nil
+	}
+	fields := changedResourceFields(diff)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	notBefore = notBefore.Truncate(time.Second) // managedFields 的时间精度为秒
+	var latest *ResourceFieldManager
+	for i := range managers {
+		manager := &managers[i]
+		if !manager.owns(diff.name, fields) {
+			continue
+		}
+		if manager.Time != nil && manager.Time.Before(notBefore) {
+			continue
+		}
+		if latest == nil || (manager.Time != nil && (latest.Time == nil || manager.Time.After(*latest.Time))) {
+			latest = manager
+		}
+	}
+	return latest
+}
+
+// ConfigChangeQuery 资源配置变更记录查询条件
+type ConfigChangeQuery struct {
+	ClusterID    uint      `form:"cluster_id"`    // 集群ID筛选
+	Namespace    string    `form:"namespace"`     // 命名空间筛选
+	WorkloadKind string    `form:"workload_kind"` // 工作负载类型筛选
+	WorkloadName string    `form:"workload_name"` // 工作负载名称筛选
+	PodName      string    `form:"pod_name"`      // 按Pod所属工作负载筛选，需同时指定集群和命名空间
+	StartTime    time.Time `form:"start_time"`    // 检测时间起点
+	EndTime      time.Time `form:"end_time"`      // 检测时间终点
+	Limit        int       `form:"limit"`         // 返回条数
+}
+
+// ConfigChangeService 资源配置变更记录服务 - 对比相邻两次采集的工作负载Pod模板，记录容器请求和限制的变化
+type ConfigChangeService struct {
+	db *gorm.DB
+}
+
+// NewConfigChangeService 创建资源配置变更记录服务实例
+func NewConfigChangeService() *ConfigChangeService {
+	return &ConfigChangeService{
+		db: database.GetDB(),
+	}
+}
+
+// ConfigChangeEnabled 返回是否在采集后检测资源配置变化
+func ConfigChangeEnabled() bool {
+	if changeConfig := config.GetConfigChangeConfig(); changeConfig != nil {
+		return changeConfig.Enabled
+	}
+	return true
+}
+
+// ConfigChangeRetentionDays 返回变更记录保留天数
+func ConfigChangeRetentionDays() int {
+	if changeConfig := config.GetConfigChangeConfig(); changeConfig != nil && changeConfig.RetentionDays > 0 {
+		return changeConfig.RetentionDays
+	}
+	return 180
+}
+
+// workloadSpecKey 工作负载在集群内的唯一标识
+func workloadSpecKey(namespace, kind, name string) string {
+	return namespace + "/" + kind + "/" + name
+}
+
+// RecordObservations 将本次采集的工作负载资源配置与基线比较，记录变化的容器并更新基线
+// 首次出现的工作负载只建立基线；本次未出现的工作负载删除基线，重新创建时不视为配置变化
+// 参数:
+//   - clusterID: 集群ID
+//   - observations: 集群中全部工作负载的资源配置，需为完整列表
+//   - observedAt: 采集时间
+//
+// 返回:
+//   - []models.ResourceConfigChange: 本次记录的变更
+//   - error: 读取或保存过程中的错误信息
+func (cs *ConfigChangeService) RecordObservations(clusterID uint, observations []WorkloadSpecObservation, observedAt time.Time) ([]models.ResourceConfigChange, error) {
+	var states []models.WorkloadSpecState
+	if err := cs.db.Where("cluster_id = ?", clusterID).Find(&states).Error; err != nil {
+		return nil, fmt.Errorf("查询工作负载配置基线失败: %v", err)
+	}
+	existing := make(map[string]*models.WorkloadSpecState, len(states))
+	for i := range states {
+		existing[workloadSpecKey(states[i].Namespace, states[i].WorkloadKind, states[i].WorkloadName)] = &states[i]
+	}
+
+	var changes []models.ResourceConfigChange
+	err := cs.db.Transaction(func(tx *gorm.DB) error {
+		var created []models.WorkloadSpecState
+		seen := make(map[string]bool, len(observations))
+
+		for _, observation := range observations {
+			key := workloadSpecKey(observation.Namespace, observation.WorkloadKind, observation.WorkloadName)
+			seen[key] = true
+			containersJSON, err := json.Marshal(observation.Containers)
+			if err != nil {
+				return fmt.Errorf("序列化容器资源配置失败: %v", err)
+			}
+
+			state, ok := existing[key]
+			if !ok {
+				created = append(created, models.WorkloadSpecState{
+					ClusterID:    clusterID,
+					Namespace:    observation.Namespace,
+					WorkloadKind: observation.WorkloadKind,
+					WorkloadName: observation.WorkloadName,
+					Containers:   string(containersJSON),
+					Revision:     observation.Revision,
+					ObservedAt:   observedAt,
+				})
+				continue
+			}
+
+			if state.Containers == string(containersJSON) {
+				// 资源未变化的发布只更新版本号，保证后续变更记录的变更前版本准确
+				if state.Revision != observation.Revision {
+					if err := tx.Model(state).Update("revision", observation.Revision).Error; err != nil {
+						return fmt.Errorf("更新工作负载配置基线失败: %v", err)
+					}
+				}
+				continue
+			}
+
+			var previous []ContainerResourceSpec
+			if err := json.Unmarshal([]byte(state.Containers), &previous); err != nil {
+				// 基线损坏时重新建立基线，不记录变化
+				logger.Warn("工作负载 %s 配置基线解析失败，重新建立基线: %v", key, err)
+			} else {
+				for _, diff := range diffContainerSpecs(previous, observation.Containers) {
+					change := models.ResourceConfigChange{
+						ClusterID:        clusterID,
+						Namespace:        observation.Namespace,
+						WorkloadKind:     observation.WorkloadKind,
+						WorkloadName:     observation.WorkloadName,
+						ContainerName:    diff.name,
+						ChangeType:       diff.changeType,
+						OldCPURequest:    diff.old.CPURequest,
+						NewCPURequest:    diff.new.CPURequest,
+						OldCPULimit:      diff.old.CPULimit,
+						NewCPULimit:      diff.new.CPULimit,
+						OldMemoryRequest: diff.old.MemoryRequest,
+						NewMemoryRequest: diff.new.MemoryRequest,
+						OldMemoryLimit:   diff.old.MemoryLimit,
+						NewMemoryLimit:   diff.new.MemoryLimit,
+						PreviousRevision: state.Revision,
+						Revision:         observation.Revision,
+						DetectedAt:       observedAt,
+					}
+					if manager := attributeChange(observation.FieldManagers, diff, state.ObservedAt); manager != nil {
+						change.Manager = manager.Manager
+						change.Operation = manager.Operation
+						change.ChangedAt = manager.Time
+					}
+					changes = append(changes, change)
+				}
+			}
+
+			if err := tx.Model(state).Updates(map[string]interface{}{
+				"containers":  string(containersJSON),
+				"revision":    observation.Revision,
+				"observed_at": observedAt,
+			}).Error; err != nil {
+				return fmt.Errorf("更新工作负载配置基线失败: %v", err)
+			}
+		}
+
+		if len(created) > 0 {
+			if err := tx.CreateInBatches(created, 200).Error; err != nil {
+				return fmt.Errorf("保存工作负载配置基线失败: %v", err)
+			}
+		}
+
+		var removedIDs []uint
+		for key, state := range existing {
+			if !seen[key] {
+				removedIDs = append(removedIDs, state.ID)
+			}
+		}
+		if len(removedIDs) > 0 {
+			if err := tx.Where("id IN ?", removedIDs).Delete(&models.WorkloadSpecState{}).Error; err != nil {
+				return fmt.Errorf("删除工作负载配置基线失败: %v", err)
+			}
+		}
+
+		if len(changes) > 0 {
+			if err := tx.CreateInBatches(changes, 200).Error; err != nil {
+				return fmt.Errorf("保存资源配置变更记录失败: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// containerSpecDiff 单个容器的资源配置变化
+type containerSpecDiff struct {
+	name       string
+	changeType string
+	old        ContainerResourceSpec
+	new        ContainerResourceSpec
+}
+
+// diffContainerSpecs 按容器名称比较两组资源配置，只调整容器顺序不视为变化
+func diffContainerSpecs(previous, current []ContainerResourceSpec) []containerSpecDiff {
+	previousByName := make(map[string]ContainerResourceSpec, len(previous))
+	for _, container := range previous {
+		previousByName[container.Name] = container
+	}
+
+	var diffs []containerSpecDiff
+	currentNames := make(map[string]bool, len(current))
+	for _, container := range current {
+		currentNames[container.Name] = true
+		old, ok := previousByName[container.Name]
+		switch {
+		case !ok:
+			diffs = append(diffs, containerSpecDiff{name: container.Name, changeType: ConfigChangeAdded, new: container})
+		case old != container:
+			diffs = append(diffs, containerSpecDiff{name: container.Name, changeType: ConfigChangeModified, old: old, new: container})
+		}
+	}
+	for _, container := range previous {
+		if !currentNames[container.Name] {
+			diffs = append(diffs, containerSpecDiff{name: container.Name, changeType: ConfigChangeRemoved, old: container})
+		}
+	}
+	return diffs
+}
+
+// ListChanges 查询资源配置变更记录，按检测时间倒序返回
+// 指定Pod名称时按Pod所属的工作负载查询，Pod没有历史记录或不属于任何工作负载时返回空列表
+func (cs *ConfigChangeService) ListChanges(query ConfigChangeQuery) ([]models.ResourceConfigChange, error) {
+	if query.PodName != "" {
+		if query.ClusterID == 0 || query.Namespace == "" {
+			return nil, fmt.Errorf("按Pod查询时必须指定集群ID和命名空间")
+		}
+		var dimensions []models.PodDimension
+		if err := cs.db.Where("cluster_id = ? AND namespace = ? AND pod_name = ?", query.ClusterID, query.Namespace, query.PodName).
+			Limit(1).Find(&dimensions).Error; err != nil {
+			return nil, fmt.Errorf("查询Pod所属工作负载失败: %v", err)
+		}
+		if len(dimensions) == 0 || dimensions[0].WorkloadName == "" || dimensions[0].WorkloadKind == "Pod" {
+			return []models.ResourceConfigChange{}, nil
+		}
+		query.WorkloadKind, query.WorkloadName = dimensions[0].WorkloadKind, dimensions[0].WorkloadName
+	}
+
+	db := cs.db.Model(&models.ResourceConfigChange{})
+	if query.ClusterID > 0 {
+		db = db.Where("cluster_id = ?", query.ClusterID)
+	}
+	if query.Namespace != "" {
+		db = db.Where("namespace = ?", query.Namespace)
+	}
+	if query.WorkloadKind != "" {
+		db = db.Where("workload_kind = ?", query.WorkloadKind)
+	}
+	if query.WorkloadName != "" {
+		db = db.Where("workload_name = ?", query.WorkloadName)
+	}
+	if !query.StartTime.IsZero() {
+		db = db.Where("detected_at >= ?", query.StartTime)
+	}
+	if !query.EndTime.IsZero() {
+		db = db.Where("detected_at <= ?", query.EndTime)
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultConfigChangeLimit
+	}
+	if limit > maxConfigChangeLimit {
+		limit = maxConfigChangeLimit
+	}
+
+	changes := []models.ResourceConfigChange{}
+	if err := db.Order("detected_at DESC, id ASC").Limit(limit).Find(&changes).Error; err != nil {
+		return nil, fmt.Errorf("查询资源配置变更记录失败: %v", err)
+	}
+	return changes, nil
+}
+
+// CleanupOldRecords 删除检测时间早于保留天数的变更记录
+func (cs *ConfigChangeService) CleanupOldRecords(retentionDays int) error {
+	cutoffTime := time.Now().AddDate(0, 0, -retentionDays)
+	result := cs.db.Where("detected_at < ?", cutoffTime).Delete(&models.ResourceConfigChange{})
+	if result.Error != nil {
+		return fmt.Errorf("清理资源配置变更记录失败: %v", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		logger.Info("清理了 %d 条过期资源配置变更记录（超过 %d 天）", result.RowsAffected, retentionDays)
+	}
+	return nil
+}
+
+// DescribeConfigChange 生成变更记录的可读描述，如 "容器 app CPU请求 100m→200m，内存限制 256Mi→512Mi（推断修改者: kubectl）"
+func DescribeConfigChange(change models.ResourceConfigChange) string {
+	var description string
+	switch change.ChangeType {
+	case ConfigChangeAdded:
+		description = fmt.Sprintf("新增容器 %s", change.ContainerName)
+	case ConfigChangeRemoved:
+		description = fmt.Sprintf("删除容器 %s", change.ContainerName)
+	default:
+		var parts []string
+		if change.OldCPURequest != change.NewCPURequest {
+			parts = append(parts, fmt.Sprintf("CPU请求 %s→%s", utils.FormatMillicores(change.OldCPURequest), utils.FormatMillicores(change.NewCPURequest)))
+		}
+		if change.OldCPULimit != change.NewCPULimit {
+			parts = append(parts, fmt.Sprintf("CPU限制 %s→%s", utils.FormatMillicores(change.OldCPULimit), utils.FormatMillicores(change.NewCPULimit)))
+		}
+		if change.OldMemoryRequest != change.NewMemoryRequest {
+			parts = append(parts, fmt.Sprintf("内存请求 %s→%s", utils.FormatBytes(change.OldMemoryRequest), utils.FormatBytes(change.NewMemoryRequest)))
+		}
+		if change.OldMemoryLimit != change.NewMemoryLimit {
+			parts = append(parts, fmt.Sprintf("内存限制 %s→%s", utils.FormatBytes(change.OldMemoryLimit), utils.FormatBytes(change.NewMemoryLimit)))
+		}
+		description = fmt.Sprintf("容器 %s %s", change.ContainerName, strings.Join(parts, "，"))
+	}
+
+	if change.Manager != "" {
+		description += fmt.Sprintf("（推断修改者: %s）", change.Manager)
+	}
+	return description
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffContainerSpecs(t *testing.T) {
+	app := ContainerResourceSpec{Name: "app", CPURequest: 500, CPULimit: 1000, MemoryRequest: 256 << 20, MemoryLimit: 512 << 20}
+	sidecar := ContainerResourceSpec{Name: "sidecar", CPURequest: 100, MemoryRequest: 64 << 20}
+	resized := app
+	resized.CPURequest = 250
+
+	tests := []struct {
+		name     string
+		previous []ContainerResourceSpec
+		current  []ContainerResourceSpec
+		want     []containerSpecDiff
+	}{
+		{"空输入", nil, nil, nil},
+		{"无变化", []ContainerResourceSpec{app, sidecar}, []ContainerResourceSpec{app, sidecar}, nil},
+		{"只调整容器顺序", []ContainerResourceSpec{app, sidecar}, []ContainerResourceSpec{sidecar, app}, nil},
+		{
+			name:     "修改请求量",
+			previous: []ContainerResourceSpec{app, sidecar},
+			current:  []ContainerResourceSpec{sidecar, resized},
+			want:     []containerSpecDiff{{name: "app", changeType: ConfigChangeModified, old: app, new: resized}},
+		},
+		{
+			name:     "新增容器",
+			previous: []ContainerResourceSpec{app},
+			current:  []ContainerResourceSpec{sidecar, app},
+			want:     []containerSpecDiff{{name: "sidecar", changeType: ConfigChangeAdded, new: sidecar}},
+		},
+		{
+			name:     "删除容器",
+			previous: []ContainerResourceSpec{sidecar, app},
+			current:  []ContainerResourceSpec{app},
+			want:     []containerSpecDiff{{name: "sidecar", changeType: ConfigChangeRemoved, old: sidecar}},
+		},
+		{
+			name:     "首次出现时所有容器均为新增",
+			previous: nil,
+			current:  []ContainerResourceSpec{app, sidecar},
+			want: []containerSpecDiff{
+				{name: "app", changeType: ConfigChangeAdded, new: app},
+				{name: "sidecar", changeType: ConfigChangeAdded, new: sidecar},
+			},
+		},
+		{
+			name:     "同时修改和删除",
+			previous: []ContainerResourceSpec{app, sidecar},
+			current:  []ContainerResourceSpec{resized},
+			want: []containerSpecDiff{
+				{name: "app", changeType: ConfigChangeModified, old: app, new: resized},
+				{name: "sidecar", changeType: ConfigChangeRemoved, old: sidecar},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffContainerSpecs(tt.previous, tt.current); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("得到 %+v，期望 %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttributeChange(t *testing.T) {
+	base := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		managedAt := base.Add(d)
+		return &managedAt
+	}
+	// 多个管理者分别拥有同一容器的不同资源字段，其中 kubectl 和 vpa 都拥有 app 的CPU请求量
+	managers := []ResourceFieldManager{
+		{Manager: "legacy", Operation: "Update", Fields: map[string][]string{
+			"app": {ResourceFieldCPULimit}, "worker": {ResourceFieldCPULimit},
+		}},
+		{Manager: "kubectl", Operation: "Update", Time: at(time.Hour), Fields: map[string][]string{
+			"app": {ResourceFieldCPURequest, ResourceFieldCPULimit},
+		}},
+		{Manager: "helm", Operation: "Apply", Time: at(2 * time.Hour), Fields: map[string][]string{
+			"app": {ResourceFieldMemoryRequest, ResourceFieldMemoryLimit}, "sidecar": {ResourceFieldCPURequest},
+		}},
+		{Manager: "vpa", Operation: "Update", Time: at(3 * time.Hour), Fields: map[string][]string{
+			"app": {ResourceFieldCPURequest},
+		}},
+		{Manager: "old-operator", Operation: "Update", Time: at(-time.Hour), Fields: map[string][]string{
+			"app": {ResourceFieldMemoryLimit},
+		}},
+	}
+	app := ContainerResourceSpec{Name: "app", CPURequest: 500, CPULimit: 1000, MemoryRequest: 256 << 20, MemoryLimit: 512 << 20}
+	modified := func(name string, modify func(spec *ContainerResourceSpec)) containerSpecDiff {
+		old := app
+		old.Name = name
+		new := old
+		modify(&new)
+		return containerSpecDiff{name: name, changeType: ConfigChangeModified, old: old, new: new}
+	}
+
+	tests := []struct {
+		name      string
+		managers  []ResourceFieldManager
+		diff      containerSpecDiff
+		notBefore time.Time
+		want      string // 推断的管理者，为空表示无法推断
+	}{
+		{"没有管理者", nil, modified("app", func(s *ContainerResourceSpec) { s.CPURequest = 250 }), base, ""},
+		{"删除容器无法推断", managers, containerSpecDiff{name: "app", changeType: ConfigChangeRemoved, old: app}, base, ""},
+		{"资源字段没有变化", managers, modified("app", func(s *ContainerResourceSpec) {}), base, ""},
+		{"只有一个管理者拥有变化的字段", managers, modified("app", func(s *ContainerResourceSpec) { s.MemoryRequest = 1 << 30 }), base, "helm"},
+		{"多个管理者拥有变化的字段时取最近写入的", managers, modified("app", func(s *ContainerResourceSpec) { s.CPURequest = 250 }), base, "vpa"},
+		{
+			name:      "写入时间早于上次检测的管理者不参与推断",
+			managers:  managers,
+			diff:      modified("app", func(s *ContainerResourceSpec) { s.MemoryLimit = 1 << 30 }),
+			notBefore: base.Add(150 * time.Minute),
+			want:      "",
+		},
+		{
+			name:      "上次检测时间按秒截断后比较",
+			managers:  managers,
+			diff:      modified("app", func(s *ContainerResourceSpec) { s.MemoryLimit = 1 << 30 }),
+			notBefore: base.Add(2*time.Hour + 500*time.Millisecond),
+			want:      "helm",
+		},
+		{"有写入时间的管理者优先于没有写入时间的", managers, modified("app", func(s *ContainerResourceSpec) { s.CPULimit = 2000 }), base, "kubectl"},
+		{"只有没有写入时间的管理者", managers, modified("worker", func(s *ContainerResourceSpec) { s.CPULimit = 2000 }), base, "legacy"},
+		{
+			name:      "新增容器按新设置的字段推断",
+			managers:  managers,
+			diff:      containerSpecDiff{name: "sidecar", changeType: ConfigChangeAdded, new: ContainerResourceSpec{Name: "sidecar", CPURequest: 100}},
+			notBefore: base,
+			want:      "helm",
+		},
+		{"其他容器的字段归属不参与推断", managers, modified("sidecar", func(s *ContainerResourceSpec) { s.MemoryRequest = 1 << 30 }), base, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if manager := attributeChange(tt.managers, tt.diff, tt.notBefore); manager != nil {
+				got = manager.Manager
+			}
+			if got != tt.want {
+				t.Errorf("得到 %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
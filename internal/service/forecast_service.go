@@ -0,0 +1,544 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cluster-resource-insight/internal/config"
+	"cluster-resource-insight/internal/database"
+	"cluster-resource-insight/internal/historystore"
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/models"
+	"cluster-resource-insight/pkg/forecast"
+
+	"gorm.io/gorm"
+)
+
+// 默认预测参数，配置文件未设置时使用
+const (
+	defaultForecastHistoryDays      = 60
+	defaultForecastHorizonDays      = 90
+	defaultForecastAlertHorizonDays = 30
+	defaultForecastConfidenceLevel  = 0.95
+)
+
+// CapacitySnapshotInfo 集群容量快照信息（避免循环导入）
+type CapacitySnapshotInfo struct {
+	NodeCount         int   `json:"node_count"`
+	AllocatableCPU    int64 `json:"allocatable_cpu"`
+	AllocatableMemory int64 `json:"allocatable_memory"`
+	CPURequest        int64 `json:"cpu_request"`
+	MemoryRequest     int64 `json:"memory_request"`
+	CPUUsage          int64 `json:"cpu_usage"`
+	MemoryUsage       int64 `json:"memory_usage"`
+	PodCount          int   `json:"pod_count"`
+	UsageMissing      bool  `json:"usage_missing"` // 没有任何Pod的真实使用量
+}
+
+// QuotaUsageInfo 资源配额使用信息（避免循环导入）
+type QuotaUsageInfo struct {
+	Namespace string `json:"namespace"`
+	QuotaName string `json:"quota_name"`
+	Resource  string `json:"resource"`
+	Hard      int64  `json:"hard"`
+	Used      int64  `json:"used"`
+}
+
+// ResourceForecast 单项资源的预测结果
+type ResourceForecast struct {
+	Resource                       string                   `json:"resource"`                          // 资源项名称
+	Current                        float64                  `json:"current"`                           // 最近一天的实际值
+	Capacity                       float64                  `json:"capacity"`                          // 容量上限，为0表示无上限
+	SlopePerDay                    float64                  `json:"slope_per_day"`                     // 每天增长量
+	DaysUntilExhaustion            int                      `json:"days_until_exhaustion"`             // 预计耗尽天数，-1表示预测期内不会耗尽
+	DaysUntilExhaustionPessimistic int                      `json:"days_until_exhaustion_pessimistic"` // 按置信区间上界计算的耗尽天数
+	ExhaustionDate                 *time.Time               `json:"exhaustion_date,omitempty"`         // 预计耗尽日期
+	SampleDays                     int                      `json:"sample_days"`                       // 参与拟合的天数
+	History                        []forecast.Point         `json:"history"`                           // 按天聚合的历史数据
+	Forecast                       []forecast.ForecastPoint `json:"forecast"`                          // 预测数据
+	Message                        string                   `json:"message,omitempty"`                 // 无法预测时的说明
+}
+
+// ClusterForecast 集群级容量预测结果
+type ClusterForecast struct {
+	ClusterID       uint               `json:"cluster_id"`
+	HorizonDays     int                `json:"horizon_days"`
+	ConfidenceLevel float64            `json:"confidence_level"`
+	Resources       []ResourceForecast `json:"resources"`
+	GeneratedAt     time.Time          `json:"generated_at"`
+}
+
+// NamespaceForecast 命名空间级资源预测结果
+type NamespaceForecast struct {
+	ClusterID       uint               `json:"cluster_id"`
+	Namespace       string             `json:"namespace"`
+	HorizonDays     int                `json:"horizon_days"`
+	HistoryDays     int                `json:"history_days"` // 实际使用的历史天数
+	ConfidenceLevel float64            `json:"confidence_level"`
+	Resources       []ResourceForecast `json:"resources"`
+	Message         string             `json:"message,omitempty"` // 历史范围被缩短时的说明
+	GeneratedAt     time.Time          `json:"generated_at"`
+}
+
+// QuotaForecast ResourceQuota资源项预测结果
+type QuotaForecast struct {
+	ClusterID uint   `json:"cluster_id"`
+	Namespace string `json:"namespace"`
+	QuotaName string `json:"quota_name"`
+	ResourceForecast
+}
+
+// ForecastService 容量预测服务 - 基于历史快照拟合趋势和周季节性，预测资源耗尽时间
+type ForecastService struct {
+	db              *gorm.DB
+	activityService *ActivityService
+}
+
+// NewForecastService 创建容量预测服务实例
+func NewForecastService() *ForecastService {
+	return &ForecastService{
+		db:              database.GetDB(),
+		activityService: NewActivityService(),
+	}
+}
+
+// forecastSettings 读取预测配置，未配置项使用默认值
+func forecastSettings() (historyDays, horizonDays, alertHorizonDays int, confidence float64) {
+	historyDays = defaultForecastHistoryDays
+	horizonDays = defaultForecastHorizonDays
+	alertHorizonDays = defaultForecastAlertHorizonDays
+	confidence = defaultForecastConfidenceLevel
+
+	if forecastConfig := config.GetForecastConfig(); forecastConfig != nil {
+		if forecastConfig.HistoryDays > 0 {
+			historyDays = forecastConfig.HistoryDays
+		}
+		if forecastConfig.HorizonDays > 0 {
+			horizonDays = forecastConfig.HorizonDays
+		}
+		if forecastConfig.AlertHorizonDays > 0 {
+			alertHorizonDays = forecastConfig.AlertHorizonDays
+		}
+		if forecastConfig.ConfidenceLevel > 0 {
+			confidence = forecastConfig.ConfidenceLevel
+		}
+	}
+	return
+}
+
+// SaveCapacitySnapshot 保存集群容量快照
+func (fs *ForecastService) SaveCapacitySnapshot(clusterID uint, snapshot CapacitySnapshotInfo) error {
+	record := models.ClusterCapacitySnapshot{
+		ClusterID:         clusterID,
+		NodeCount:         snapshot.NodeCount,
+		AllocatableCPU:    snapshot.AllocatableCPU,
+		AllocatableMemory: snapshot.AllocatableMemory,
+		CPURequest:        snapshot.CPURequest,
+		MemoryRequest:     snapshot.MemoryRequest,
+		CPUUsage:          snapshot.CPUUsage,
+		MemoryUsage:       snapshot.MemoryUsage,
+		PodCount:          snapshot.PodCount,
+		UsageMissing:      snapshot.UsageMissing,
+		CollectedAt:       time.Now(),
+	}
+
+	if err := fs.db.Create(&record).Error; err != nil {
+		return fmt.Errorf("保存集群容量快照失败: %v", err)
+	}
+	return nil
+}
+
+// SaveQuotaSnapshots 批量保存资源配额快照
+func (fs *ForecastService) SaveQuotaSnapshots(clusterID uint, quotas []QuotaUsageInfo) error {
+	if len(quotas) == 0 {
+		return nil
+	}
+
+	collectedAt := time.Now()
+	records := make([]models.ResourceQuotaSnapshot, 0, len(quotas))
+	for _, quota := range quotas {
+		records = append(records, models.ResourceQuotaSnapshot{
+			ClusterID:   clusterID,
+			Namespace:   quota.Namespace,
+			QuotaName:   quota.QuotaName,
+			Resource:    quota.Resource,
+			Hard:        quota.Hard,
+			Used:        quota.Used,
+			CollectedAt: collectedAt,
+		})
+	}
+
+	if err := fs.db.CreateInBatches(records, 100).Error; err != nil {
+		return fmt.Errorf("保存资源配额快照失败: %v", err)
+	}
+	return nil
+}
+
+// GetClusterForecast 获取集群级容量预测 - 预测CPU/内存的请求量和使用量，并以可分配量作为耗尽阈值
+// 参数:
+//   - clusterID: 集群ID
+//   - horizonDays: 预测天数，<=0时使用配置值
+//
+// 返回:
+//   - *ClusterForecast: 集群容量预测结果
+//   - error: 查询过程中的错误信息
+func (fs *ForecastService) GetClusterForecast(clusterID uint, horizonDays int) (*ClusterForecast, error) {
+	historyDays, defaultHorizon, _, confidence := forecastSettings()
+	if horizonDays <= 0 {
+		horizonDays = defaultHorizon
+	}
+
+	var snapshots []models.ClusterCapacitySnapshot
+	if err := fs.db.Where("cluster_id = ? AND collected_at >= ?", clusterID, time.Now().AddDate(0, 0, -historyDays)).
+		Order("collected_at ASC").Find(&snapshots).Error; err != nil {
+		return nil, fmt.Errorf("查询集群容量快照失败: %v", err)
+	}
+
+	result := &ClusterForecast{
+		ClusterID:       clusterID,
+		HorizonDays:     horizonDays,
+		ConfidenceLevel: confidence,
+		Resources:       []ResourceForecast{},
+		GeneratedAt:     time.Now(),
+	}
+	if len(snapshots) == 0 {
+		return result, nil
+	}
+
+	// 以最新快照的可分配量作为容量上限
+	latest := snapshots[len(snapshots)-1]
+	series := map[string][]forecast.Point{}
+	for _, s := range snapshots {
+		series["cpu_request"] = append(series["cpu_request"], forecast.Point{Time: s.CollectedAt, Value: float64(s.CPURequest)})
+		series["memory_request"] = append(series["memory_request"], forecast.Point{Time: s.CollectedAt, Value: float64(s.MemoryRequest)})
+		// metrics-server 不可用时采集的快照没有使用量，不参与使用量预测
+		if s.UsageMissing {
+			continue
+		}
+		series["cpu_usage"] = append(series["cpu_usage"], forecast.Point{Time: s.CollectedAt, Value: float64(s.CPUUsage)})
+		series["memory_usage"] = append(series["memory_usage"], forecast.Point{Time: s.CollectedAt, Value: float64(s.MemoryUsage)})
+	}
+
+	capacities := map[string]float64{
+		"cpu_request":    float64(latest.AllocatableCPU),
+		"memory_request": float64(latest.AllocatableMemory),
+		"cpu_usage":      float64(latest.AllocatableCPU),
+		"memory_usage":   float64(latest.AllocatableMemory),
+	}
+
+	for _, resource := range []string{"cpu_request", "memory_request", "cpu_usage", "memory_usage"} {
+		result.Resources = append(result.Resources,
+			buildResourceForecast(resource, series[resource], capacities[resource], horizonDays, confidence))
+	}
+
+	return result, nil
+}
+
+// GetNamespaceForecast 获取命名空间级资源预测 - 基于Pod历史数据按采集批次汇总后预测
+// 原始数据只保留 raw_retention_days 天，更早的历史使用命名空间范围的天级汇总；
+// 未启用降采样汇总时历史范围缩短为原始数据保留天数，并在结果中说明
+// 命名空间配置了ResourceQuota时，以requests.cpu/requests.memory配额作为耗尽阈值
+func (fs *ForecastService) GetNamespaceForecast(clusterID uint, namespace string, horizonDays int) (*NamespaceForecast, error) {
+	historyDays, defaultHorizon, _, confidence := forecastSettings()
+	if horizonDays <= 0 {
+		horizonDays = defaultHorizon
+	}
+
+	now := time.Now()
+	start := now.AddDate(0, 0, -historyDays)
+	rawStart := start
+	var message string
+	rollupConf := loadRollupSettings()
+	if historyDays > rollupConf.rawRetentionDays {
+		if rollupConf.enabled {
+			// 原始数据保留期起点所在的天可能已被部分清理，从下一个整天开始使用原始数据
+			dailyTier, _ := findRollupTier(rollupConf, TierDaily)
+			rawStart = dailyTier.next(dailyTier.truncate(now.AddDate(0, 0, -rollupConf.rawRetentionDays)))
+		} else {
+			historyDays = rollupConf.rawRetentionDays
+			start = now.AddDate(0, 0, -historyDays)
+			rawStart = start
+			message = fmt.Sprintf("未启用降采样汇总，历史数据范围缩短为原始数据保留的 %d 天", historyDays)
+		}
+	}
+
+	type batchTotal struct {
+		CollectedAt    time.Time
+		CPURequest     int64
+		MemoryRequest  int64
+		CPUUsage       int64
+		MemoryUsage    int64
+		CPUPodCount    int
+		MemoryPodCount int
+	}
+
+	var totals []batchTotal
+	if rawStart.After(start) {
+		series, err := NewRollupService().QueryRollups(RollupQuery{
+			ClusterID: clusterID,
+			Scope:     RollupScopeNamespace,
+			Namespace: namespace,
+			Start:     start,
+			End:       rawStart,
+			Tier:      TierDaily,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("查询命名空间天级汇总数据失败: %v", err)
+		}
+		// 天级汇总的请求量和使用量为当天各采集批次的平均值，作为当天的一个数据点参与预测
+		for _, rollup := range series.Data {
+			totals = append(totals, batchTotal{
+				CollectedAt:    rollup.BucketStart.In(time.Local),
+				CPURequest:     int64(rollup.CPURequest),
+				MemoryRequest:  int64(rollup.MemoryRequest),
+				CPUUsage:       int64(rollup.CPUUsageAvg),
+				MemoryUsage:    int64(rollup.MemoryUsageAvg),
+				CPUPodCount:    rollup.CPUSampleCount,
+				MemoryPodCount: rollup.MemorySampleCount,
+			})
+		}
+	}
+
+	points, err := historystore.GetHistoryStore().Aggregate(context.Background(), historystore.AggregateQuery{
+		Filter: historystore.Filter{ClusterID: clusterID, Namespace: namespace},
+		Start:  rawStart,
+		End:    now,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("查询命名空间历史数据失败: %v", err)
+	}
+
+	for _, point := range points {
+		totals = append(totals, batchTotal{
+			CollectedAt:    point.Time,
+			CPURequest:     int64(point.CPURequest),
+			MemoryRequest:  int64(point.MemoryRequest),
+			CPUUsage:       int64(point.CPUUsage),
+			MemoryUsage:    int64(point.MemoryUsage),
+			CPUPodCount:    point.CPUPodCount,
+			MemoryPodCount: point.MemoryPodCount,
+		})
+	}
+
+	result := &NamespaceForecast{
+		ClusterID:       clusterID,
+		Namespace:       namespace,
+		HorizonDays:     horizonDays,
+		HistoryDays:     historyDays,
+		ConfidenceLevel: confidence,
+		Resources:       []ResourceForecast{},
+		Message:         message,
+		GeneratedAt:     now,
+	}
+	if len(totals) == 0 {
+		return result, nil
+	}
+
+	series := map[string][]forecast.Point{}
+	for _, t := range totals {
+		series["cpu_request"] = append(series["cpu_request"], forecast.Point{Time: t.CollectedAt, Value: float64(t.CPURequest)})
+		series["memory_request"] = append(series["memory_request"], forecast.Point{Time: t.CollectedAt, Value: float64(t.MemoryRequest)})
+		// 没有真实使用量的采集批次不参与使用量预测
+		if t.CPUPodCount > 0 {
+			series["cpu_usage"] = append(series["cpu_usage"], forecast.Point{Time: t.CollectedAt, Value: float64(t.CPUUsage)})
+		}
+		if t.MemoryPodCount > 0 {
+			series["memory_usage"] = append(series["memory_usage"], forecast.Point{Time: t.CollectedAt, Value: float64(t.MemoryUsage)})
+		}
+	}
+
+	// 读取最新的命名空间配额作为请求量上限
+	capacities := map[string]float64{}
+	latestQuotas, err := fs.latestQuotaSnapshots(clusterID, namespace)
+	if err == nil {
+		for _, quota := range latestQuotas {
+			switch quota.Resource {
+			case "requests.cpu", "cpu":
+				capacities["cpu_request"] += float64(quota.Hard)
+			case "requests.memory", "memory":
+				capacities["memory_request"] += float64(quota.Hard)
+			}
+		}
+	}
+
+	for _, resource := range []string{"cpu_request", "memory_request", "cpu_usage", "memory_usage"} {
+		result.Resources = append(result.Resources,
+			buildResourceForecast(resource, series[resource], capacities[resource], horizonDays, confidence))
+	}
+
+	return result, nil
+}
+
+// GetQuotaForecasts 获取ResourceQuota各资源项的耗尽预测
+// 参数:
+//   - clusterID: 集群ID
+//   - namespace: 命名空间筛选条件，为空时返回所有命名空间
+//   - horizonDays: 预测天数，<=0时使用配置值
+func (fs *ForecastService) GetQuotaForecasts(clusterID uint, namespace string, horizonDays int) ([]QuotaForecast, error) {
+	historyDays, defaultHorizon, _, confidence := forecastSettings()
+	if horizonDays <= 0 {
+		horizonDays = defaultHorizon
+	}
+
+	query := fs.db.Where("cluster_id = ? AND collected_at >= ?", clusterID, time.Now().AddDate(0, 0, -historyDays))
+	if namespace != "" {
+		query = query.Where("namespace = ?", namespace)
+	}
+
+	var snapshots []models.ResourceQuotaSnapshot
+	if err := query.Order("collected_at ASC").Find(&snapshots).Error; err != nil {
+		return nil, fmt.Errorf("查询资源配额快照失败: %v", err)
+	}
+
+	// 按 命名空间/配额/资源项 分组构建时间序列
+	type quotaKey struct {
+		Namespace string
+		QuotaName string
+		Resource  string
+	}
+	series := make(map[quotaKey][]forecast.Point)
+	latestHard := make(map[quotaKey]int64)
+	var keys []quotaKey
+	for _, s := range snapshots {
+		key := quotaKey{Namespace: s.Namespace, QuotaName: s.QuotaName, Resource: s.Resource}
+		if _, exists := series[key]; !exists {
+			keys = append(keys, key)
+		}
+		series[key] = append(series[key], forecast.Point{Time: s.CollectedAt, Value: float64(s.Used)})
+		latestHard[key] = s.Hard
+	}
+
+	results := make([]QuotaForecast, 0, len(keys))
+	for _, key := range keys {
+		results = append(results, QuotaForecast{
+			ClusterID:        clusterID,
+			Namespace:        key.Namespace,
+			QuotaName:        key.QuotaName,
+			ResourceForecast: buildResourceForecast(key.Resource, series[key], float64(latestHard[key]), horizonDays, confidence),
+		})
+	}
+
+	return results, nil
+}
+
+// CheckExhaustionAlerts 检查集群容量和配额的预计耗尽时间，落入告警窗口时创建告警
+// 参数:
+//   - clusterID: 集群ID
+//   - clusterName: 集群名称，用于告警消息
+func (fs *ForecastService) CheckExhaustionAlerts(clusterID uint, clusterName string) error {
+	_, _, alertHorizonDays, _ := forecastSettings()
+
+	clusterForecast, err := fs.GetClusterForecast(clusterID, alertHorizonDays)
+	if err != nil {
+		return err
+	}
+	for _, resource := range clusterForecast.Resources {
+		if resource.DaysUntilExhaustion < 0 || resource.DaysUntilExhaustion > alertHorizonDays {
+			continue
+		}
+		title := fmt.Sprintf("集群容量预计耗尽: %s", resource.Resource)
+		message := fmt.Sprintf("集群 %s 的 %s 预计在 %d 天后达到可分配容量上限（当前 %.0f / 容量 %.0f）",
+			clusterName, resource.Resource, resource.DaysUntilExhaustion, resource.Current, resource.Capacity)
+		if err := fs.activityService.CreateAlert(clusterID, exhaustionAlertLevel(resource.DaysUntilExhaustion), title, message, "active"); err != nil {
+			logger.Error("创建容量耗尽告警失败: %v", err)
+		}
+	}
+
+	quotaForecasts, err := fs.GetQuotaForecasts(clusterID, "", alertHorizonDays)
+	if err != nil {
+		return err
+	}
+	for _, quota := range quotaForecasts {
+		if quota.DaysUntilExhaustion < 0 || quota.DaysUntilExhaustion > alertHorizonDays {
+			continue
+		}
+		title := fmt.Sprintf("资源配额预计耗尽: %s/%s %s", quota.Namespace, quota.QuotaName, quota.Resource)
+		message := fmt.Sprintf("集群 %s 命名空间 %s 的配额 %s 中 %s 预计在 %d 天后用尽（当前 %.0f / 上限 %.0f）",
+			clusterName, quota.Namespace, quota.QuotaName, quota.Resource, quota.DaysUntilExhaustion, quota.Current, quota.Capacity)
+		if err := fs.activityService.CreateAlert(clusterID, exhaustionAlertLevel(quota.DaysUntilExhaustion), title, message, "active"); err != nil {
+			logger.Error("创建配额耗尽告警失败: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// CleanupOldSnapshots 清理超出预测历史窗口的容量和配额快照
+func (fs *ForecastService) CleanupOldSnapshots(retentionDays int) error {
+	cutoffTime := time.Now().AddDate(0, 0, -retentionDays)
+
+	if err := fs.db.Where("collected_at < ?", cutoffTime).Delete(&models.ClusterCapacitySnapshot{}).Error; err != nil {
+		return fmt.Errorf("清理集群容量快照失败: %v", err)
+	}
+	if err := fs.db.Where("collected_at < ?", cutoffTime).Delete(&models.ResourceQuotaSnapshot{}).Error; err != nil {
+		return fmt.Errorf("清理资源配额快照失败: %v", err)
+	}
+	return nil
+}
+
+// latestQuotaSnapshots 获取命名空间最近一次采集的配额快照
+func (fs *ForecastService) latestQuotaSnapshots(clusterID uint, namespace string) ([]models.ResourceQuotaSnapshot, error) {
+	var latest models.ResourceQuotaSnapshot
+	err := fs.db.Where("cluster_id = ? AND namespace = ?", clusterID, namespace).
+		Order("collected_at DESC").First(&latest).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []models.ResourceQuotaSnapshot
+	err = fs.db.Where("cluster_id = ? AND namespace = ? AND collected_at = ?", clusterID, namespace, latest.CollectedAt).
+		Find(&snapshots).Error
+	return snapshots, err
+}
+
+// buildResourceForecast 对单个时间序列按天聚合、拟合并生成预测结果
+func buildResourceForecast(resource string, points []forecast.Point, capacity float64, horizonDays int, confidence float64) ResourceForecast {
+	daily := forecast.AggregateDaily(points)
+	result := ResourceForecast{
+		Resource:                       resource,
+		Capacity:                       capacity,
+		DaysUntilExhaustion:            -1,
+		DaysUntilExhaustionPessimistic: -1,
+		SampleDays:                     len(daily),
+		History:                        daily,
+		Forecast:                       []forecast.ForecastPoint{},
+	}
+	if len(daily) > 0 {
+		result.Current = daily[len(daily)-1].Value
+	}
+
+	model, err := forecast.Fit(daily, confidence)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+
+	now := time.Now()
+	result.SlopePerDay = model.Slope()
+	result.Forecast = model.Project(now, horizonDays)
+
+	if capacity > 0 {
+		expected, pessimistic := model.DaysUntil(capacity, now, horizonDays)
+		result.DaysUntilExhaustion = expected
+		result.DaysUntilExhaustionPessimistic = pessimistic
+		if expected >= 0 {
+			exhaustionDate := now.AddDate(0, 0, expected)
+			result.ExhaustionDate = &exhaustionDate
+		}
+	}
+
+	return result
+}
+
+// exhaustionAlertLevel 根据剩余天数确定告警级别
+func exhaustionAlertLevel(days int) string {
+	switch {
+	case days <= 7:
+		return "critical"
+	case days <= 14:
+		return "error"
+	default:
+		return "warning"
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"cluster-resource-insight/internal/database"
+	"cluster-resource-insight/internal/historystore"
 	"cluster-resource-insight/internal/models"
+	"cluster-resource-insight/internal/remotewrite"
 	"cluster-resource-insight/pkg/pagination"
 
 	"gorm.io/gorm"
@@ -19,6 +21,9 @@ type PodResourceInfo struct {
 	Namespace      string    `json:"namespace"`
 	NodeName       string    `json:"node_name"`
 	ClusterName    string    `json:"cluster_name"`
+	WorkloadKind   string    `json:"workload_kind"`
+	WorkloadName   string    `json:"workload_name"`
+	QoSClass       string    `json:"qos_class"`
 	MemoryUsage    int64     `json:"memory_usage"`
 	MemoryRequest  int64     `json:"memory_request"`
 	MemoryLimit    int64     `json:"memory_limit"`
@@ -32,17 +37,25 @@ type PodResourceInfo struct {
 	Status         string    `json:"status"`
 	Issues         []string  `json:"issues"`
 	CreationTime   time.Time `json:"creation_time"`
+	MetricsAvailable bool    `json:"metrics_available"`
+	CPUMetricsAvailable    bool `json:"cpu_metrics_available"`
+	MemoryMetricsAvailable bool `json:"memory_metrics_available"`
+	RestartCount   int32     `json:"restart_count"`
+	OOMKilled      bool      `json:"oom_killed"`
+	Labels         map[string]string `json:"labels,omitempty"`
 }
 
 // HistoryService 历史数据服务
 type HistoryService struct {
-	db *gorm.DB
+	db    *gorm.DB
+	store historystore.HistoryStore
 }
 
 // NewHistoryService 创建历史数据服务实例
 func NewHistoryService() *HistoryService {
 	return &HistoryService{
-		db: database.GetDB(),
+		db:    database.GetDB(),
+		store: historystore.GetHistoryStore(),
 	}
 }
 
@@ -57,6 +70,7 @@ type HistoryQueryRequest struct {
 	Size        int       `form:"size"`         // 每页大小
 	OrderBy     string    `form:"order_by"`     // 排序字段
 	OrderDesc   bool      `form:"order_desc"`   // 是否降序
+	Tier        string    `form:"tier"`         // 数据层级：raw/hourly/daily/auto，默认auto
 }
 
 // HistoryQueryResponse 历史数据查询响应
@@ -66,6 +80,7 @@ type HistoryQueryResponse struct {
 	Page       int                        `json:"page"`
 	Size       int                        `json:"size"`
 	TotalPages int                        `json:"total_pages"`
+	Tier       string                     `json:"tier"`
 }
 
 // SavePodMetrics 批量保存Pod监控数据
@@ -79,14 +94,23 @@ func (hs *HistoryService) SavePodMetrics(clusterID uint, pods []PodResourceInfo)
 	collectedAt := time.Now()
 
 	for _, pod := range pods {
-		// 序列化问题列表为JSON
-		issuesJSON, _ := json.Marshal(pod.Issues)
+		// 序列化问题列表为JSON，没有问题的Pod不保存问题列表
+		var issuesJSON, labelsJSON []byte
+		if len(pod.Issues) > 0 {
+			issuesJSON, _ = json.Marshal(pod.Issues)
+		}
+		if len(pod.Labels) > 0 {
+			labelsJSON, _ = json.Marshal(pod.Labels)
+		}
 
 		record := models.PodMetricsHistory{
 			ClusterID:      clusterID,
 			Namespace:      pod.Namespace,
 			PodName:        pod.PodName,
 			NodeName:       pod.NodeName,
+			WorkloadKind:   pod.WorkloadKind,
+			WorkloadName:   pod.WorkloadName,
+			QoSClass:       pod.QoSClass,
 			MemoryUsage:    pod.MemoryUsage,
 			MemoryRequest:  pod.MemoryRequest,
 			MemoryLimit:    pod.MemoryLimit,
@@ -98,18 +122,41 @@ func (hs *HistoryService) SavePodMetrics(clusterID uint, pods []PodResourceInfo)
 			CPUReqPct:      pod.CPUReqPct,
 			CPULimitPct:    pod.CPULimitPct,
 			Status:         pod.Status,
-			Issues:         string(issuesJSON),
+			Issues:         models.JSONText(issuesJSON),
+			MetricsAvailable: pod.MetricsAvailable,
+			CPUMetricsAvailable:    pod.CPUMetricsAvailable,
+			MemoryMetricsAvailable: pod.MemoryMetricsAvailable,
+			RestartCount:   pod.RestartCount,
+			OOMKilled:      pod.OOMKilled,
+			Labels:         models.JSONText(labelsJSON),
 			CollectedAt:    collectedAt,
 		}
 
+		// 估算的使用量不写入历史，缺失的指标保存为0并由可用标记区分
+		if !pod.CPUMetricsAvailable {
+			record.CPUUsage, record.CPUReqPct, record.CPULimitPct = 0, 0, 0
+		}
+		if !pod.MemoryMetricsAvailable {
+			record.MemoryUsage, record.MemoryReqPct, record.MemoryLimitPct = 0, 0, 0
+		}
+
 		historyRecords = append(historyRecords, record)
 	}
 
-	// 批量插入数据
-	if err := hs.db.CreateInBatches(historyRecords, 100).Error; err != nil {
+	// 写入历史数据存储，SQL存储拆分写入维度表、规格版本表和指标事实表
+	if err := hs.store.Write(context.Background(), historyRecords); err != nil {
 		return fmt.Errorf("保存Pod监控历史数据失败: %v", err)
 	}
 
+	// 记录采集批次，供快照对比按批次定位本次写入的样本
+	run := models.CollectionRun{ClusterID: clusterID, PodCount: len(historyRecords), CollectedAt: collectedAt}
+	if err := hs.db.Create(&run).Error; err != nil {
+		return fmt.Errorf("保存采集批次失败: %v", err)
+	}
+
+	// 推送到 remote write 远端，未启用时忽略
+	remotewrite.Enqueue(historyRecords)
+
 	return nil
 }
 
@@ -125,6 +172,22 @@ func (hs *HistoryService) QueryHistory(req HistoryQueryRequest) (*HistoryQueryRe
 		req.OrderDesc = true
 	}
 
+	// 指定了时间范围时按范围选择数据层级，未指定范围时查询原始数据
+	tier := req.Tier
+	if tier == "" || tier == TierAuto {
+		tier = TierRaw
+		if !req.StartTime.IsZero() {
+			endTime := req.EndTime
+			if endTime.IsZero() {
+				endTime = time.Now()
+			}
+			tier = NewRollupService().SelectTier(req.StartTime, endTime, true)
+		}
+	}
+	if tier != TierRaw {
+		return hs.queryRollupHistory(req, tier, paginationParams)
+	}
+
 	// 构建查询条件
 	query := hs.db.Model(&models.PodMetricsHistory{})
 
@@ -177,46 +240,123 @@ func (hs *HistoryService) QueryHistory(req HistoryQueryRequest) (*HistoryQueryRe
 		Page:       paginationResult.Page,
 		Size:       paginationResult.Size,
 		TotalPages: paginationResult.TotalPages,
+		Tier:       TierRaw,
 	}, nil
 }
 
-// GetTrendData 获取趋势数据
-func (hs *HistoryService) GetTrendData(clusterID uint, namespace, podName string, hours int) ([]models.PodMetricsHistory, error) {
-	startTime := time.Now().Add(-time.Duration(hours) * time.Hour)
+// queryRollupHistory 从汇总表分页查询Pod历史数据，采集时间取时间桶起点
+func (hs *HistoryService) queryRollupHistory(req HistoryQueryRequest, tierName string, paginationParams pagination.PaginationParams) (*HistoryQueryResponse, error) {
+	paginationHandler := pagination.NewDatabasePaginationHandler()
+	tier, ok := findRollupTier(loadRollupSettings(), tierName)
+	if !ok {
+		return nil, fmt.Errorf("不支持的数据层级: %s", tierName)
+	}
 
-	query := hs.db.Model(&models.PodMetricsHistory{}).
-		Where("collected_at >= ?", startTime).
-		Order("collected_at ASC")
+	query := hs.db.Table(tier.tableName()).Where("scope = ?", RollupScopePod)
+	if req.ClusterID > 0 {
+		query = query.Where("cluster_id = ?", req.ClusterID)
+	}
+	if req.Namespace != "" {
+		query = query.Where("namespace = ?", req.Namespace)
+	}
+	if req.PodName != "" {
+		query = query.Where("pod_name LIKE ?", "%"+req.PodName+"%")
+	}
+	if !req.StartTime.IsZero() {
+		query = query.Where("bucket_start >= ?", tier.truncate(req.StartTime))
+	}
+	if !req.EndTime.IsZero() {
+		query = query.Where("bucket_start <= ?", req.EndTime)
+	}
 
-	if clusterID > 0 {
-		query = query.Where("cluster_id = ?", clusterID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("查询历史数据总数失败: %v", err)
 	}
-	if namespace != "" {
-		query = query.Where("namespace = ?", namespace)
+
+	// 汇总表没有采集时间列，按时间排序时使用时间桶起点
+	orderClause := req.OrderBy
+	if orderClause == "collected_at" {
+		orderClause = "bucket_start"
 	}
-	if podName != "" {
-		query = query.Where("pod_name = ?", podName)
+	if req.OrderDesc {
+		orderClause += " DESC"
 	}
 
-	var data []models.PodMetricsHistory
-	if err := query.Find(&data).Error; err != nil {
-		return nil, fmt.Errorf("查询趋势数据失败: %v", err)
+	offset, limit := paginationHandler.CalculatePaginationOffset(paginationParams)
+	var rollups []models.MetricsRollup
+	if err := query.Order(orderClause).Offset(offset).Limit(limit).Find(&rollups).Error; err != nil {
+		return nil, fmt.Errorf("查询历史数据失败: %v", err)
 	}
 
-	return data, nil
+	data := make([]models.PodMetricsHistory, 0, len(rollups))
+	for _, rollup := range rollups {
+		data = append(data, rollupToHistory(rollup))
+	}
+
+	paginationResult := paginationHandler.BuildPaginationResult(paginationParams, total)
+
+	return &HistoryQueryResponse{
+		Data:       data,
+		Total:      total,
+		Page:       paginationResult.Page,
+		Size:       paginationResult.Size,
+		TotalPages: paginationResult.TotalPages,
+		Tier:       tier.name,
+	}, nil
+}
+
+// GetTrendData 获取趋势数据 - 按时间范围自动选择原始数据或汇总数据，返回所用的数据层级
+func (hs *HistoryService) GetTrendData(clusterID uint, namespace, podName string, hours int) ([]models.PodMetricsHistory, string, error) {
+	now := time.Now()
+	startTime := now.Add(-time.Duration(hours) * time.Hour)
+
+	if tier := NewRollupService().SelectTier(startTime, now, true); tier != TierRaw {
+		series, err := NewRollupService().QueryRollups(RollupQuery{
+			ClusterID: clusterID,
+			Scope:     RollupScopePod,
+			Namespace: namespace,
+			PodName:   podName,
+			Start:     startTime,
+			End:       now,
+			Tier:      tier,
+		})
+		if err != nil {
+			return nil, tier, fmt.Errorf("查询趋势数据失败: %v", err)
+		}
+		data := make([]models.PodMetricsHistory, 0, len(series.Data))
+		for _, rollup := range series.Data {
+			data = append(data, rollupToHistory(rollup))
+		}
+		return data, tier, nil
+	}
+
+	data, err := hs.store.Query(context.Background(), historystore.RangeQuery{
+		Filter: historystore.Filter{ClusterID: clusterID, Namespace: namespace, PodName: podName},
+		Start:  startTime,
+		End:    now,
+	})
+	if err != nil {
+		return nil, TierRaw, fmt.Errorf("查询趋势数据失败: %v", err)
+	}
+
+	return data, TierRaw, nil
 }
 
 // CleanupOldData 清理过期数据
 func (hs *HistoryService) CleanupOldData(ctx context.Context, retentionDays int) error {
 	cutoffTime := time.Now().AddDate(0, 0, -retentionDays)
 
-	result := hs.db.Where("collected_at < ?", cutoffTime).Delete(&models.PodMetricsHistory{})
-	if result.Error != nil {
-		return fmt.Errorf("清理过期数据失败: %v", result.Error)
+	rowsAffected, err := hs.store.Cleanup(ctx, cutoffTime)
+	if err != nil {
+		return fmt.Errorf("清理过期数据失败: %v", err)
+	}
+	if err := hs.db.WithContext(ctx).Where("collected_at < ?", cutoffTime).Delete(&models.CollectionRun{}).Error; err != nil {
+		return fmt.Errorf("清理过期采集批次失败: %v", err)
 	}
 
-	if result.RowsAffected > 0 {
-		fmt.Printf("清理了 %d 条过期历史记录（超过 %d 天）\n", result.RowsAffected, retentionDays)
+	if rowsAffected > 0 {
+		fmt.Printf("清理了 %d 条过期历史记录（超过 %d 天）\n", rowsAffected, retentionDays)
 	}
 
 	return nil
@@ -248,12 +388,12 @@ func (hs *HistoryService) GetStatistics() (map[string]interface{}, error) {
 	stats["namespace_count"] = namespaceCount
 
 	// 最早和最新的记录时间
-	var earliestTime, latestTime time.Time
+	var earliestTime, latestTime database.Timestamp
 	if err := hs.db.Model(&models.PodMetricsHistory{}).Select("MIN(collected_at)").Scan(&earliestTime).Error; err == nil {
-		stats["earliest_record"] = earliestTime
+		stats["earliest_record"] = earliestTime.Time
 	}
 	if err := hs.db.Model(&models.PodMetricsHistory{}).Select("MAX(collected_at)").Scan(&latestTime).Error; err == nil {
-		stats["latest_record"] = latestTime
+		stats["latest_record"] = latestTime.Time
 	}
 
 	return stats, nil
@@ -270,7 +410,25 @@ type SystemTrendData struct {
 // GetSystemTrendData 获取系统级聚合趋势数据 - 为Dashboard提供图表数据
 // 聚合所有集群的CPU、内存使用率平均值和Pod总数按时间分组
 func (hs *HistoryService) GetSystemTrendData(hours int) ([]SystemTrendData, error) {
-	startTime := time.Now().Add(-time.Duration(hours) * time.Hour)
+	return hs.GetSystemTrendDataWithCluster(hours, nil)
+}
+
+// GetSystemTrendDataWithCluster 获取系统级聚合趋势数据，支持集群筛选 - 为Dashboard提供图表数据
+// 聚合指定集群或所有集群的CPU、内存使用率平均值和Pod总数按时间分组
+// 参数:
+//   - hours: 时间范围（小时）
+//   - clusterID: 集群ID，为nil时查询所有集群
+// 返回:
+//   - []SystemTrendData: 系统趋势数据数组
+//   - error: 查询过程中的错误
+func (hs *HistoryService) GetSystemTrendDataWithCluster(hours int, clusterID *uint) ([]SystemTrendData, error) {
+	now := time.Now()
+	startTime := now.Add(-time.Duration(hours) * time.Hour)
+
+	// 时间范围较长时改用汇总数据，避免扫描大量原始记录
+	if tier := NewRollupService().SelectTier(startTime, now, true); tier != TierRaw {
+		return hs.getSystemTrendFromRollups(startTime, clusterID, tier)
+	}
 	
 	// 计算时间分组间隔 - 根据时间范围动态调整
 	var intervalMinutes int
@@ -285,42 +443,29 @@ func (hs *HistoryService) GetSystemTrendData(hours int) ([]SystemTrendData, erro
 		intervalMinutes = 240 // 7天内，4小时一个点
 	}
 	
-	// 构建SQL查询 - 按时间区间分组聚合数据
-	query := `
-		SELECT 
-			DATE_FORMAT(MIN(collected_at), '%H:%i') as time_label,
-			AVG(cpu_req_pct) as avg_cpu_usage,
-			AVG(memory_req_pct) as avg_memory_usage,
-			COUNT(DISTINCT pod_name) as pod_count,
-			MIN(collected_at) as collected_at
-		FROM pod_metrics_history 
-		WHERE collected_at >= ? 
-		GROUP BY 
-			FLOOR(UNIX_TIMESTAMP(collected_at) / (? * 60))
-		ORDER BY collected_at ASC
-	`
-	
-	type QueryResult struct {
-		TimeLabel      string    `json:"time_label"`
-		AvgCPUUsage    float64   `json:"avg_cpu_usage"`
-		AvgMemoryUsage float64   `json:"avg_memory_usage"`
-		PodCount       int       `json:"pod_count"`
-		CollectedAt    time.Time `json:"collected_at"`
-	}
-	
-	var results []QueryResult
-	if err := hs.db.Raw(query, startTime, intervalMinutes).Scan(&results).Error; err != nil {
+	// 按时间区间聚合数据，支持集群筛选
+	filter := historystore.Filter{}
+	if clusterID != nil {
+		filter.ClusterID = *clusterID
+	}
+	points, err := hs.store.Aggregate(context.Background(), historystore.AggregateQuery{
+		Filter: filter,
+		Start:  startTime,
+		End:    now,
+		Step:   time.Duration(intervalMinutes) * time.Minute,
+	})
+	if err != nil {
 		return nil, fmt.Errorf("查询系统趋势数据失败: %v", err)
 	}
 	
 	// 转换为前端期望的格式
 	var trendData []SystemTrendData
-	for _, result := range results {
+	for _, point := range points {
 		trendData = append(trendData, SystemTrendData{
-			Time:        result.TimeLabel,
-			CPUUsage:    result.AvgCPUUsage,
-			MemoryUsage: result.AvgMemoryUsage,
-			PodCount:    result.PodCount,
+			Time:        point.Time.In(time.Local).Format("15:04"),
+			CPUUsage:    point.CPUReqPct,
+			MemoryUsage: point.MemoryReqPct,
+			PodCount:    point.PodCount,
 		})
 	}
 	
@@ -332,102 +477,51 @@ func (hs *HistoryService) GetSystemTrendData(hours int) ([]SystemTrendData, erro
 	return trendData, nil
 }
 
-// GetSystemTrendDataWithCluster 获取系统级聚合趋势数据，支持集群筛选 - 为Dashboard提供图表数据
-// 聚合指定集群或所有集群的CPU、内存使用率平均值和Pod总数按时间分组
-// 参数:
-//   - hours: 时间范围（小时）
-//   - clusterID: 集群ID，为nil时查询所有集群
-// 返回:
-//   - []SystemTrendData: 系统趋势数据数组
-//   - error: 查询过程中的错误
-func (hs *HistoryService) GetSystemTrendDataWithCluster(hours int, clusterID *uint) ([]SystemTrendData, error) {
-	startTime := time.Now().Add(-time.Duration(hours) * time.Hour)
-	
-	// 计算时间分组间隔 - 根据时间范围动态调整
-	var intervalMinutes int
-	switch {
-	case hours <= 1:
-		intervalMinutes = 5  // 1小时内，5分钟一个点
-	case hours <= 6:
-		intervalMinutes = 15 // 6小时内，15分钟一个点
-	case hours <= 24:
-		intervalMinutes = 60 // 24小时内，1小时一个点
-	default:
-		intervalMinutes = 240 // 7天内，4小时一个点
+// getSystemTrendFromRollups 基于Pod范围的汇总数据计算系统级趋势，每个时间桶一个数据点
+func (hs *HistoryService) getSystemTrendFromRollups(startTime time.Time, clusterID *uint, tierName string) ([]SystemTrendData, error) {
+	tier, ok := findRollupTier(loadRollupSettings(), tierName)
+	if !ok {
+		return nil, fmt.Errorf("不支持的数据层级: %s", tierName)
 	}
-	
-	// 构建SQL查询 - 按时间区间分组聚合数据，支持集群筛选
-	var query string
-	var queryParams []interface{}
-	
+
+	query := hs.db.Table(tier.tableName()).
+		Select("bucket_start, AVG(cpu_req_pct) as avg_cpu_usage, AVG(memory_req_pct) as avg_memory_usage, "+
+			"COUNT(DISTINCT pod_name) as pod_count").
+		Where("scope = ? AND bucket_start >= ?", RollupScopePod, tier.truncate(startTime))
 	if clusterID != nil {
-		// 筛选特定集群的数据
-		query = `
-			SELECT 
-				DATE_FORMAT(MIN(collected_at), '%H:%i') as time_label,
-				AVG(cpu_req_pct) as avg_cpu_usage,
-				AVG(memory_req_pct) as avg_memory_usage,
-				COUNT(DISTINCT pod_name) as pod_count,
-				MIN(collected_at) as collected_at
-			FROM pod_metrics_history 
-			WHERE collected_at >= ? AND cluster_id = ?
-			GROUP BY 
-				FLOOR(UNIX_TIMESTAMP(collected_at) / (? * 60))
-			ORDER BY collected_at ASC
-		`
-		queryParams = []interface{}{startTime, *clusterID, intervalMinutes}
-	} else {
-		// 聚合所有集群的数据
-		query = `
-			SELECT 
-				DATE_FORMAT(MIN(collected_at), '%H:%i') as time_label,
-				AVG(cpu_req_pct) as avg_cpu_usage,
-				AVG(memory_req_pct) as avg_memory_usage,
-				COUNT(DISTINCT pod_name) as pod_count,
-				MIN(collected_at) as collected_at
-			FROM pod_metrics_history 
-			WHERE collected_at >= ? 
-			GROUP BY 
-				FLOOR(UNIX_TIMESTAMP(collected_at) / (? * 60))
-			ORDER BY collected_at ASC
-		`
-		queryParams = []interface{}{startTime, intervalMinutes}
+		query = query.Where("cluster_id = ?", *clusterID)
 	}
-	
+
 	type QueryResult struct {
-		TimeLabel      string    `json:"time_label"`
-		AvgCPUUsage    float64   `json:"avg_cpu_usage"`
-		AvgMemoryUsage float64   `json:"avg_memory_usage"`
-		PodCount       int       `json:"pod_count"`
-		CollectedAt    time.Time `json:"collected_at"`
+		BucketStart    database.Timestamp
+		AvgCPUUsage    float64
+		AvgMemoryUsage float64
+		PodCount       int
 	}
-	
+
 	var results []QueryResult
-	if err := hs.db.Raw(query, queryParams...).Scan(&results).Error; err != nil {
+	if err := query.Group("bucket_start").Order("bucket_start ASC").Scan(&results).Error; err != nil {
 		return nil, fmt.Errorf("查询系统趋势数据失败: %v", err)
 	}
-	
-	// 转换为前端期望的格式
-	var trendData []SystemTrendData
+
+	layout := "01-02 15:04"
+	if tier.name == TierDaily {
+		layout = "01-02"
+	}
+
+	trendData := make([]SystemTrendData, 0, len(results))
 	for _, result := range results {
 		trendData = append(trendData, SystemTrendData{
-			Time:        result.TimeLabel,
+			Time:        result.BucketStart.Time.In(time.Local).Format(layout),
 			CPUUsage:    result.AvgCPUUsage,
 			MemoryUsage: result.AvgMemoryUsage,
 			PodCount:    result.PodCount,
 		})
 	}
-	
-	// 如果没有历史数据，返回空数组
-	if len(trendData) == 0 {
-		return []SystemTrendData{}, nil
-	}
-	
+
 	return trendData, nil
 }
 
-
-
 // GetLatestPodMetrics 获取最新的Pod指标数据 - 按Pod聚合获取最近时间范围内每个Pod的最新记录
 // 参数:
 //   - clusterID: 集群ID
@@ -474,3 +568,87 @@ func (hs *HistoryService) GetLatestPodMetrics(clusterID uint, namespace string,
 	
 	return latestPods, nil
 }
+
+// WorkloadActivity 工作负载在时间窗口内的活动情况
+type WorkloadActivity struct {
+	Namespace    string     `json:"namespace"`
+	WorkloadKind string     `json:"workload_kind"`
+	WorkloadName string     `json:"workload_name"`
+	SampleCount  int64      `json:"sample_count"`  // 有真实CPU使用量的采样次数
+	FirstSeen    time.Time  `json:"first_seen"`    // 窗口内首次有真实CPU使用量的采样时间
+	LastSeen     time.Time  `json:"last_seen"`     // 窗口内最近有真实CPU使用量的采样时间
+	LastActive   *time.Time `json:"last_active"`   // 最近一次CPU使用量超过阈值的时间，窗口内从未超过时为空
+	MaxCPUUsage  int64      `json:"max_cpu_usage"` // 单Pod最大CPU使用量 (millicores)
+	AvgCPUUsage  float64    `json:"avg_cpu_usage"` // 单Pod平均CPU使用量 (millicores)
+}
+
+// GetWorkloadActivity 按工作负载汇总时间窗口内的CPU活动情况，仅统计CPU使用量来自真实指标的采样
+// 参数:
+//   - clusterID: 集群ID
+//   - since: 时间窗口起点
+//   - cpuThreshold: 判定为活跃的CPU使用量阈值 (millicores)
+//
+// 返回:
+//   - []WorkloadActivity: 各工作负载的活动情况
+//   - error: 查询过程中的错误信息
+func (hs *HistoryService) GetWorkloadActivity(clusterID uint, since time.Time, cpuThreshold int64) ([]WorkloadActivity, error) {
+	type activityKey struct {
+		namespace, kind, name string
+	}
+	type activitySum struct {
+		activity WorkloadActivity
+		cpuTotal int64
+	}
+
+	// 逐条遍历样本按工作负载累计，内存中只保留各工作负载的汇总值
+	sums := make(map[activityKey]*activitySum)
+	var keys []activityKey
+	err := hs.store.Scan(context.Background(), historystore.RangeQuery{
+		Filter: historystore.Filter{ClusterID: clusterID, MetricsOnly: true},
+		Start:  since,
+		End:    time.Now(),
+	}, func(record *models.PodMetricsHistory) error {
+		if !record.CPUMetricsAvailable || record.WorkloadName == "" {
+			return nil
+		}
+		key := activityKey{record.Namespace, record.WorkloadKind, record.WorkloadName}
+		sum, ok := sums[key]
+		if !ok {
+			sum = &activitySum{activity: WorkloadActivity{
+				Namespace:    record.Namespace,
+				WorkloadKind: record.WorkloadKind,
+				WorkloadName: record.WorkloadName,
+				FirstSeen:    record.CollectedAt,
+				LastSeen:     record.CollectedAt,
+			}}
+			sums[key] = sum
+			keys = append(keys, key)
+		}
+		activity := &sum.activity
+		activity.SampleCount++
+		sum.cpuTotal += record.CPUUsage
+		if record.CollectedAt.Before(activity.FirstSeen) {
+			activity.FirstSeen = record.CollectedAt
+		}
+		if record.CollectedAt.After(activity.LastSeen) {
+			activity.LastSeen = record.CollectedAt
+		}
+		if record.CPUUsage > cpuThreshold && (activity.LastActive == nil || record.CollectedAt.After(*activity.LastActive)) {
+			lastActive := record.CollectedAt
+			activity.LastActive = &lastActive
+		}
+		activity.MaxCPUUsage = max(activity.MaxCPUUsage, record.CPUUsage)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("查询工作负载活动情况失败: %v", err)
+	}
+
+	activities := make([]WorkloadActivity, 0, len(keys))
+	for _, key := range keys {
+		sum := sums[key]
+		sum.activity.AvgCPUUsage = float64(sum.cpuTotal) / float64(sum.activity.SampleCount)
+		activities = append(activities, sum.activity)
+	}
+	return activities, nil
+}
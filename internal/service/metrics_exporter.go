@@ -0,0 +1,281 @@
+package service
+
+import (
+	"encoding/json"
+	"sort"
+
+	"cluster-resource-insight/internal/config"
+	"cluster-resource-insight/internal/database"
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/models"
+	"cluster-resource-insight/pkg/cost"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+)
+
+// 指标导出粒度
+const (
+	ExportModePod       = "pod"
+	ExportModeNamespace = "namespace"
+)
+
+// metricsNamespace 导出指标名称前缀
+const metricsNamespace = "resource_insight"
+
+// exporterSettings 指标导出参数
+type exporterSettings struct {
+	enabled bool
+	mode    string
+	topPods int
+}
+
+// loadExporterSettings 读取指标导出配置，未配置项使用默认值
+func loadExporterSettings() exporterSettings {
+	settings := exporterSettings{
+		enabled: true,
+		mode:    ExportModePod,
+		topPods: 100,
+	}
+
+	if exporterConfig := config.GetMetricsExporterConfig(); exporterConfig != nil {
+		settings.enabled = exporterConfig.Enabled
+		if exporterConfig.Mode != "" {
+			settings.mode = exporterConfig.Mode
+		}
+		if exporterConfig.TopPods > 0 {
+			settings.topPods = exporterConfig.TopPods
+		}
+	}
+
+	return settings
+}
+
+// MetricsExporterEnabled 是否启用 /metrics 端点
+func MetricsExporterEnabled() bool {
+	return loadExporterSettings().enabled
+}
+
+// resourceGauges 一组资源指标描述，命名空间和Pod两个层级各一组
+type resourceGauges struct {
+	cpuRequest     *prometheus.Desc
+	cpuLimit       *prometheus.Desc
+	cpuUsage       *prometheus.Desc
+	cpuUtilization *prometheus.Desc
+	memoryRequest  *prometheus.Desc
+	memoryLimit    *prometheus.Desc
+	memoryUsage    *prometheus.Desc
+	memoryUtil     *prometheus.Desc
+	cpuWaste       *prometheus.Desc
+	memoryWaste    *prometheus.Desc
+	monthlyCost    *prometheus.Desc
+	wasteCost      *prometheus.Desc
+	issues         *prometheus.Desc
+}
+
+// newResourceGauges 创建指定层级的指标描述
+func newResourceGauges(subsystem string, labels []string) resourceGauges {
+	desc := func(name, help string, extra ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, subsystem, name), help, append(append([]string{}, labels...), extra...), nil)
+	}
+	return resourceGauges{
+		cpuRequest:     desc("cpu_request_cores", "CPU请求量（核）"),
+		cpuLimit:       desc("cpu_limit_cores", "CPU限制量（核）"),
+		cpuUsage:       desc("cpu_usage_cores", "CPU实际使用量（核）"),
+		cpuUtilization: desc("cpu_request_utilization_percent", "CPU使用量占请求量的百分比"),
+		memoryRequest:  desc("memory_request_bytes", "内存请求量（字节）"),
+		memoryLimit:    desc("memory_limit_bytes", "内存限制量（字节）"),
+		memoryUsage:    desc("memory_usage_bytes", "内存实际使用量（字节）"),
+		memoryUtil:     desc("memory_request_utilization_percent", "内存使用量占请求量的百分比"),
+		cpuWaste:       desc("cpu_waste_cores", "CPU请求量超出使用量的部分（核）"),
+		memoryWaste:    desc("memory_waste_bytes", "内存请求量超出使用量的部分（字节）"),
+		monthlyCost:    desc("monthly_cost", "按请求量折算的月度成本"),
+		wasteCost:      desc("monthly_waste_cost", "请求量超出使用量部分的月度成本"),
+		issues:         desc("issues", "按问题类型统计的资源配置问题数", "issue"),
+	}
+}
+
+// resourceTotals 一个导出对象的资源合计
+type resourceTotals struct {
+	cpuRequest, cpuLimit, cpuUsage          int64
+	memoryRequest, memoryLimit, memoryUsage int64
+	cpuWaste, memoryWaste                   int64
+	issues                                  map[string]int
+}
+
+// add 累加一条Pod记录，只有来自真实metrics数据的样本计算浪费量
+func (t *resourceTotals) add(record *models.PodMetricsHistory, issues []string) {
+	t.cpuRequest += record.CPURequest
+	t.cpuLimit += record.CPULimit
+	t.cpuUsage += record.CPUUsage
+	t.memoryRequest += record.MemoryRequest
+	t.memoryLimit += record.MemoryLimit
+	t.memoryUsage += record.MemoryUsage
+	if record.CPUMetricsAvailable && record.CPURequest > record.CPUUsage {
+		t.cpuWaste += record.CPURequest - record.CPUUsage
+	}
+	if record.MemoryMetricsAvailable && record.MemoryRequest > record.MemoryUsage {
+		t.memoryWaste += record.MemoryRequest - record.MemoryUsage
+	}
+	if t.issues == nil {
+		t.issues = make(map[string]int)
+	}
+	for _, issue := range issues {
+		t.issues[issue]++
+	}
+}
+
+// MetricsExporter Prometheus 指标收集器 - 每次抓取时读取各集群最近一次采集批次的分析结果并导出为 gauge
+type MetricsExporter struct {
+	db *gorm.DB
+
+	namespaceGauges resourceGauges
+	podGauges       resourceGauges
+	clusterPods     *prometheus.Desc
+	exportedPods    *prometheus.Desc
+	lastCollection  *prometheus.Desc
+}
+
+// NewMetricsExporter 创建指标收集器
+func NewMetricsExporter() *MetricsExporter {
+	return &MetricsExporter{
+		db:              database.GetDB(),
+		namespaceGauges: newResourceGauges("namespace", []string{"cluster", "namespace"}),
+		podGauges:       newResourceGauges("pod", []string{"cluster", "namespace", "workload_kind", "workload", "pod"}),
+		clusterPods: prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "cluster", "pods"),
+			"最近一次采集的Pod总数", []string{"cluster"}, nil),
+		exportedPods: prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "cluster", "exported_pods"),
+			"导出了Pod级指标的Pod数", []string{"cluster"}, nil),
+		lastCollection: prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "cluster", "last_collection_timestamp_seconds"),
+			"最近一次采集时间（Unix秒）", []string{"cluster"}, nil),
+	}
+}
+
+// Describe 实现 prometheus.Collector 接口
+func (me *MetricsExporter) Describe(ch chan<- *prometheus.Desc) {
+	for _, gauges := range []resourceGauges{me.namespaceGauges, me.podGauges} {
+		for _, desc := range []*prometheus.Desc{gauges.cpuRequest, gauges.cpuLimit, gauges.cpuUsage, gauges.cpuUtilization,
+			gauges.memoryRequest, gauges.memoryLimit, gauges.memoryUsage, gauges.memoryUtil,
+			gauges.cpuWaste, gauges.memoryWaste, gauges.monthlyCost, gauges.wasteCost, gauges.issues} {
+			ch <- desc
+		}
+	}
+	ch <- me.clusterPods
+	ch <- me.exportedPods
+	ch <- me.lastCollection
+}
+
+// Collect 实现 prometheus.Collector 接口
+func (me *MetricsExporter) Collect(ch chan<- prometheus.Metric) {
+	settings := loadExporterSettings()
+	costModel := cost.DefaultModel()
+
+	var clusters []models.ClusterConfig
+	if err := me.db.Select("id, cluster_name").Find(&clusters).Error; err != nil {
+		logger.Error("导出指标时查询集群列表失败: %v", err)
+		return
+	}
+
+	for _, cluster := range clusters {
+		var latest database.Timestamp
+		if err := me.db.Model(&models.PodMetricsHistory{}).Select("MAX(collected_at)").
+			Where("cluster_id = ?", cluster.ID).Scan(&latest).Error; err != nil {
+			logger.Error("导出指标时查询集群 %s 最近采集时间失败: %v", cluster.ClusterName, err)
+			continue
+		}
+		if !latest.Valid {
+			continue
+		}
+
+		var records []models.PodMetricsHistory
+		if err := me.db.Model(&models.PodMetricsHistory{}).
+			Where("cluster_id = ? AND collected_at = ?", cluster.ID, latest.Time).
+			Find(&records).Error; err != nil {
+			logger.Error("导出指标时查询集群 %s 最新数据失败: %v", cluster.ClusterName, err)
+			continue
+		}
+
+		me.collectCluster(ch, cluster.ClusterName, records, settings, costModel)
+		ch <- prometheus.MustNewConstMetric(me.lastCollection, prometheus.GaugeValue, float64(latest.Time.Unix()), cluster.ClusterName)
+	}
+}
+
+// collectCluster 导出一个集群的命名空间汇总和Top N Pod指标
+func (me *MetricsExporter) collectCluster(ch chan<- prometheus.Metric, clusterName string, records []models.PodMetricsHistory,
+	settings exporterSettings, costModel *cost.Model) {
+	namespaces := make(map[string]*resourceTotals)
+	pods := make([]resourceTotals, len(records))
+	for i := range records {
+		var issues []string
+		if records[i].Issues != "" {
+			_ = json.Unmarshal([]byte(records[i].Issues), &issues)
+		}
+		pods[i].add(&records[i], issues)
+
+		totals, ok := namespaces[records[i].Namespace]
+		if !ok {
+			totals = &resourceTotals{}
+			namespaces[records[i].Namespace] = totals
+		}
+		totals.add(&records[i], issues)
+	}
+
+	for namespace, totals := range namespaces {
+		me.collectTotals(ch, me.namespaceGauges, totals, costModel, clusterName, namespace)
+	}
+
+	exported := 0
+	if settings.mode == ExportModePod {
+		// 按浪费成本从高到低导出，限制每个集群的Pod序列数
+		order := make([]int, len(records))
+		for i := range order {
+			order[i] = i
+		}
+		wasteCost := func(i int) float64 {
+			return costModel.MonthlyCost(pods[i].cpuWaste, pods[i].memoryWaste)
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			return wasteCost(order[a]) > wasteCost(order[b])
+		})
+		if len(order) > settings.topPods {
+			order = order[:settings.topPods]
+		}
+		for _, i := range order {
+			record := &records[i]
+			me.collectTotals(ch, me.podGauges, &pods[i], costModel,
+				clusterName, record.Namespace, record.WorkloadKind, record.WorkloadName, record.PodName)
+		}
+		exported = len(order)
+	}
+
+	ch <- prometheus.MustNewConstMetric(me.clusterPods, prometheus.GaugeValue, float64(len(records)), clusterName)
+	ch <- prometheus.MustNewConstMetric(me.exportedPods, prometheus.GaugeValue, float64(exported), clusterName)
+}
+
+// collectTotals 导出一个对象的全部资源指标
+func (me *MetricsExporter) collectTotals(ch chan<- prometheus.Metric, gauges resourceGauges, totals *resourceTotals,
+	costModel *cost.Model, labels ...string) {
+	gauge := func(desc *prometheus.Desc, value float64, extra ...string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, append(append([]string{}, labels...), extra...)...)
+	}
+
+	gauge(gauges.cpuRequest, float64(totals.cpuRequest)/1000)
+	gauge(gauges.cpuLimit, float64(totals.cpuLimit)/1000)
+	gauge(gauges.cpuUsage, float64(totals.cpuUsage)/1000)
+	gauge(gauges.memoryRequest, float64(totals.memoryRequest))
+	gauge(gauges.memoryLimit, float64(totals.memoryLimit))
+	gauge(gauges.memoryUsage, float64(totals.memoryUsage))
+	if totals.cpuRequest > 0 {
+		gauge(gauges.cpuUtilization, float64(totals.cpuUsage)*100/float64(totals.cpuRequest))
+	}
+	if totals.memoryRequest > 0 {
+		gauge(gauges.memoryUtil, float64(totals.memoryUsage)*100/float64(totals.memoryRequest))
+	}
+	gauge(gauges.cpuWaste, float64(totals.cpuWaste)/1000)
+	gauge(gauges.memoryWaste, float64(totals.memoryWaste))
+	gauge(gauges.monthlyCost, costModel.MonthlyCost(totals.cpuRequest, totals.memoryRequest))
+	gauge(gauges.wasteCost, costModel.MonthlyCost(totals.cpuWaste, totals.memoryWaste))
+	for issue, count := range totals.issues {
+		gauge(gauges.issues, float64(count), issue)
+	}
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"cluster-resource-insight/internal/config"
+	"cluster-resource-insight/internal/database"
+)
+
+// partitionSettings 历史数据分区参数
+type partitionSettings struct {
+	enabled   bool
+	interval  string
+	precreate int
+}
+
+// loadPartitionSettings 读取分区配置，未配置项使用默认值
+func loadPartitionSettings() partitionSettings {
+	settings := partitionSettings{
+		enabled:   false,
+		interval:  database.PartitionDaily,
+		precreate: 7,
+	}
+
+	if partitionConfig := config.GetPartitionConfig(); partitionConfig != nil {
+		settings.enabled = partitionConfig.Enabled
+		if partitionConfig.Interval != "" {
+			settings.interval = partitionConfig.Interval
+		}
+		if partitionConfig.Precreate > 0 {
+			settings.precreate = partitionConfig.Precreate
+		}
+	}
+
+	return settings
+}
+
+// MaintainPartitions 按配置转换分区表并预先创建未来的分区，未启用或数据库不支持分区时直接返回
+// 过期分区在各自的数据清理流程中删除
+func MaintainPartitions() error {
+	settings := loadPartitionSettings()
+	if !settings.enabled || !database.PartitioningSupported() {
+		return nil
+	}
+	return database.EnsurePartitions(settings.interval, settings.precreate)
+}
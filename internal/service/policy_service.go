@@ -0,0 +1,290 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"cluster-resource-insight/internal/config"
+	"cluster-resource-insight/internal/database"
+	"cluster-resource-insight/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// NamespaceComplianceInput 单个命名空间（或集群整体）的策略检查结果
+type NamespaceComplianceInput struct {
+	Namespace  string  // 命名空间，为空表示集群整体
+	Checks     int     // 参与检查的对象次数
+	Passed     int     // 合规次数
+	Violations int     // 违规次数
+	Errors     int     // 求值出错次数
+	Score      float64 // 按严重程度加权的合规评分
+}
+
+// PolicyViolationInput 本次检查发现的违规
+type PolicyViolationInput struct {
+	Namespace  string
+	PolicyName string
+	Severity   string
+	TargetKind string
+	TargetName string
+	Message    string
+}
+
+// PolicyEvaluationError 策略对单个对象求值出错，无法判断该对象是否合规
+type PolicyEvaluationError struct {
+	Namespace  string
+	PolicyName string
+	TargetKind string
+	TargetName string
+}
+
+// PolicyEvaluationResult 单个集群一次策略检查的结果
+type PolicyEvaluationResult struct {
+	PolicyCount int
+	Cluster     NamespaceComplianceInput
+	Namespaces  []NamespaceComplianceInput
+	Violations  []PolicyViolationInput
+	Errors      []PolicyEvaluationError
+	EvaluatedAt time.Time
+}
+
+// PolicyService 资源策略合规服务 - 保存每次采集后的策略检查结果，提供合规评分、历史和违规列表
+type PolicyService struct {
+	db *gorm.DB
+}
+
+// NewPolicyService 创建资源策略合规服务实例
+func NewPolicyService() *PolicyService {
+	return &PolicyService{
+		db: database.GetDB(),
+	}
+}
+
+// PolicyHistoryDays 返回合规评分历史保留天数
+func PolicyHistoryDays() int {
+	if policyConfig := config.GetPolicyConfig(); policyConfig != nil && policyConfig.HistoryDays > 0 {
+		return policyConfig.HistoryDays
+	}
+	return 30
+}
+
+// violationKey 违规记录的唯一标识
+func violationKey(namespace, policyName, targetKind, targetName string) string {
+	return namespace + "|" + policyName + "|" + targetKind + "|" + targetName
+}
+
+// SaveEvaluation 保存策略检查结果
+// 合规评分每次检查都追加一条快照；违规记录按对象合并，持续违规只更新最近发现时间，
+// 本次未再出现的违规标记为已解决；求值出错的对象无法判断是否恢复合规，其违规记录保持不变
+func (ps *PolicyService) SaveEvaluation(clusterID uint, result *PolicyEvaluationResult) error {
+	return ps.db.Transaction(func(tx *gorm.DB) error {
+		snapshots := make([]models.PolicyComplianceSnapshot, 0, len(result.Namespaces)+1)
+		for _, item := range append([]NamespaceComplianceInput{result.Cluster}, result.Namespaces...) {
+			snapshots = append(snapshots, models.PolicyComplianceSnapshot{
+				ClusterID:   clusterID,
+				Namespace:   item.Namespace,
+				Checks:      item.Checks,
+				Passed:      item.Passed,
+				Violations:  item.Violations,
+				Errors:      item.Errors,
+				Score:       item.Score,
+				PolicyCount: result.PolicyCount,
+				CollectedAt: result.EvaluatedAt,
+			})
+		}
+		if err := tx.CreateInBatches(snapshots, 100).Error; err != nil {
+			return fmt.Errorf("保存合规评分失败: %v", err)
+		}
+
+		var open []models.PolicyViolation
+		if err := tx.Where("cluster_id = ? AND resolved_at IS NULL", clusterID).Find(&open).Error; err != nil {
+			return fmt.Errorf("查询未解决的违规记录失败: %v", err)
+		}
+		openIndex := make(map[string]*models.PolicyViolation, len(open))
+		for i := range open {
+			openIndex[violationKey(open[i].Namespace, open[i].PolicyName, open[i].TargetKind, open[i].TargetName)] = &open[i]
+		}
+		for _, evalErr := range result.Errors {
+			delete(openIndex, violationKey(evalErr.Namespace, evalErr.PolicyName, evalErr.TargetKind, evalErr.TargetName))
+		}
+
+		var created []models.PolicyViolation
+		var stillOpen []uint
+		for _, violation := range result.Violations {
+			key := violationKey(violation.Namespace, violation.PolicyName, violation.TargetKind, violation.TargetName)
+			if existing, ok := openIndex[key]; ok {
+				stillOpen = append(stillOpen, existing.ID)
+				delete(openIndex, key)
+				continue
+			}
+			created = append(created, models.PolicyViolation{
+				ClusterID:   clusterID,
+				Namespace:   violation.Namespace,
+				PolicyName:  violation.PolicyName,
+				Severity:    violation.Severity,
+				TargetKind:  violation.TargetKind,
+				TargetName:  violation.TargetName,
+				Message:     violation.Message,
+				FirstSeenAt: result.EvaluatedAt,
+				LastSeenAt:  result.EvaluatedAt,
+			})
+		}
+
+		if len(stillOpen) > 0 {
+			if err := tx.Model(&models.PolicyViolation{}).Where("id IN ?", stillOpen).
+				Update("last_seen_at", result.EvaluatedAt).Error; err != nil {
+				return fmt.Errorf("更新违规记录失败: %v", err)
+			}
+		}
+		if len(created) > 0 {
+			if err := tx.CreateInBatches(created, 100).Error; err != nil {
+				return fmt.Errorf("保存违规记录失败: %v", err)
+			}
+		}
+
+		if len(openIndex) > 0 {
+			resolved := make([]uint, 0, len(openIndex))
+			for _, violation := range openIndex {
+				resolved = append(resolved, violation.ID)
+			}
+			if err := tx.Model(&models.PolicyViolation{}).Where("id IN ?", resolved).
+				Update("resolved_at", result.EvaluatedAt).Error; err != nil {
+				return fmt.Errorf("标记违规已解决失败: %v", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// ClusterCompliance 集群最近一次策略检查的合规情况
+type ClusterCompliance struct {
+	ClusterID   uint                              `json:"cluster_id"`
+	Cluster     models.PolicyComplianceSnapshot   `json:"cluster"`    // 集群整体评分
+	Namespaces  []models.PolicyComplianceSnapshot `json:"namespaces"` // 各命名空间评分，从低到高排序
+	CollectedAt time.Time                         `json:"collected_at"`
+}
+
+// GetLatestCompliance 获取各集群最近一次策略检查的合规评分
+// 参数:
+//   - clusterID: 集群ID，为nil时返回所有集群
+//   - namespace: 命名空间，为空时返回全部命名空间
+func (ps *PolicyService) GetLatestCompliance(clusterID *uint, namespace string) ([]ClusterCompliance, error) {
+	var latest []struct {
+		ClusterID   uint
+		CollectedAt database.Timestamp
+	}
+	query := ps.db.Model(&models.PolicyComplianceSnapshot{}).
+		Select("cluster_id, MAX(collected_at) AS collected_at").
+		Group("cluster_id")
+	if clusterID != nil {
+		query = query.Where("cluster_id = ?", *clusterID)
+	}
+	if err := query.Scan(&latest).Error; err != nil {
+		return nil, fmt.Errorf("查询最近一次合规检查失败: %v", err)
+	}
+
+	result := make([]ClusterCompliance, 0, len(latest))
+	for _, item := range latest {
+		var snapshots []models.PolicyComplianceSnapshot
+		snapshotQuery := ps.db.Where("cluster_id = ? AND collected_at = ?", item.ClusterID, item.CollectedAt.Time)
+		if namespace != "" {
+			snapshotQuery = snapshotQuery.Where("namespace = ? OR namespace = ''", namespace)
+		}
+		if err := snapshotQuery.Find(&snapshots).Error; err != nil {
+			return nil, fmt.Errorf("查询合规评分失败: %v", err)
+		}
+
+		compliance := ClusterCompliance{
+			ClusterID:   item.ClusterID,
+			Namespaces:  []models.PolicyComplianceSnapshot{},
+			CollectedAt: item.CollectedAt.Time,
+		}
+		for _, snapshot := range snapshots {
+			if snapshot.Namespace == "" {
+				compliance.Cluster = snapshot
+			} else {
+				compliance.Namespaces = append(compliance.Namespaces, snapshot)
+			}
+		}
+		sort.Slice(compliance.Namespaces, func(i, j int) bool {
+			if compliance.Namespaces[i].Score != compliance.Namespaces[j].Score {
+				return compliance.Namespaces[i].Score < compliance.Namespaces[j].Score
+			}
+			return compliance.Namespaces[i].Namespace < compliance.Namespaces[j].Namespace
+		})
+		result = append(result, compliance)
+	}
+
+	return result, nil
+}
+
+// GetComplianceHistory 获取合规评分历史
+// 参数:
+//   - clusterID: 集群ID
+//   - namespace: 命名空间，为空时返回集群整体评分
+//   - since: 起始时间
+func (ps *PolicyService) GetComplianceHistory(clusterID uint, namespace string, since time.Time) ([]models.PolicyComplianceSnapshot, error) {
+	var snapshots []models.PolicyComplianceSnapshot
+	err := ps.db.Where("cluster_id = ? AND namespace = ? AND collected_at >= ?", clusterID, namespace, since).
+		Order("collected_at").
+		Find(&snapshots).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询合规评分历史失败: %v", err)
+	}
+	return snapshots, nil
+}
+
+// PolicyViolationQuery 违规记录查询条件
+type PolicyViolationQuery struct {
+	ClusterID       *uint
+	Namespace       string
+	PolicyName      string
+	Severity        string
+	IncludeResolved bool // 是否包含已解决的违规
+	Limit           int
+}
+
+// GetViolations 查询违规记录，按最近发现时间倒序
+func (ps *PolicyService) GetViolations(req PolicyViolationQuery) ([]models.PolicyViolation, error) {
+	query := ps.db.Model(&models.PolicyViolation{})
+	if req.ClusterID != nil {
+		query = query.Where("cluster_id = ?", *req.ClusterID)
+	}
+	if req.Namespace != "" {
+		query = query.Where("namespace = ?", req.Namespace)
+	}
+	if req.PolicyName != "" {
+		query = query.Where("policy_name = ?", req.PolicyName)
+	}
+	if req.Severity != "" {
+		query = query.Where("severity = ?", req.Severity)
+	}
+	if !req.IncludeResolved {
+		query = query.Where("resolved_at IS NULL")
+	}
+	if req.Limit > 0 {
+		query = query.Limit(req.Limit)
+	}
+
+	var violations []models.PolicyViolation
+	if err := query.Order("last_seen_at DESC").Find(&violations).Error; err != nil {
+		return nil, fmt.Errorf("查询违规记录失败: %v", err)
+	}
+	return violations, nil
+}
+
+// CleanupOldRecords 清理超出保留天数的合规评分快照和已解决的违规记录
+func (ps *PolicyService) CleanupOldRecords(retentionDays int) error {
+	cutoffTime := time.Now().AddDate(0, 0, -retentionDays)
+
+	if err := ps.db.Where("collected_at < ?", cutoffTime).Delete(&models.PolicyComplianceSnapshot{}).Error; err != nil {
+		return fmt.Errorf("清理合规评分快照失败: %v", err)
+	}
+	if err := ps.db.Where("resolved_at < ?", cutoffTime).Delete(&models.PolicyViolation{}).Error; err != nil {
+		return fmt.Errorf("清理已解决的违规记录失败: %v", err)
+	}
+	return nil
+}
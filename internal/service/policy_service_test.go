@@ -0,0 +1,102 @@
+package service
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"cluster-resource-insight/internal/database"
+	"cluster-resource-insight/internal/models"
+)
+
+func TestSaveEvaluation(t *testing.T) {
+	first := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	violation := func(policyName, target string) PolicyViolationInput {
+		return PolicyViolationInput{Namespace: "default", PolicyName: policyName, Severity: "error", TargetKind: "pod", TargetName: target}
+	}
+	evalError := func(policyName, target string) PolicyEvaluationError {
+		return PolicyEvaluationError{Namespace: "default", PolicyName: policyName, TargetKind: "pod", TargetName: target}
+	}
+	// 首次检查时 web-1、web-2 违反 limit-ratio，web-1 违反 memory-limit
+	initial := []PolicyViolationInput{violation("limit-ratio", "web-1"), violation("limit-ratio", "web-2"), violation("memory-limit", "web-1")}
+
+	tests := []struct {
+		name         string
+		violations   []PolicyViolationInput
+		errors       []PolicyEvaluationError
+		wantOpen     []string // 仍未解决的违规，格式为 策略/对象
+		wantResolved []string
+	}{
+		{
+			name:       "持续违规保持未解决",
+			violations: initial,
+			wantOpen:   []string{"limit-ratio/web-1", "limit-ratio/web-2", "memory-limit/web-1"},
+		},
+		{
+			name:         "未再出现的违规标记为已解决",
+			violations:   []PolicyViolationInput{violation("limit-ratio", "web-1")},
+			wantOpen:     []string{"limit-ratio/web-1"},
+			wantResolved: []string{"limit-ratio/web-2", "memory-limit/web-1"},
+		},
+		{
+			name:         "求值出错的对象保留已有违规",
+			violations:   []PolicyViolationInput{violation("limit-ratio", "web-1")},
+			errors:       []PolicyEvaluationError{evalError("limit-ratio", "web-2"), evalError("limit-ratio", "web-3")},
+			wantOpen:     []string{"limit-ratio/web-1", "limit-ratio/web-2"},
+			wantResolved: []string{"memory-limit/web-1"},
+		},
+		{
+			name:         "同一对象的其他策略出错不影响判断",
+			errors:       []PolicyEvaluationError{evalError("memory-limit", "web-2")},
+			wantResolved: []string{"limit-ratio/web-1", "limit-ratio/web-2", "memory-limit/web-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			openTestDB(t)
+			ps := NewPolicyService()
+			if err := ps.SaveEvaluation(1, &PolicyEvaluationResult{Violations: initial, EvaluatedAt: first}); err != nil {
+				t.Fatalf("保存首次检查结果失败: %v", err)
+			}
+
+			second := first.Add(10 * time.Minute)
+			result := &PolicyEvaluationResult{
+				Cluster:     NamespaceComplianceInput{Errors: len(tt.errors)},
+				Violations:  tt.violations,
+				Errors:      tt.errors,
+				EvaluatedAt: second,
+			}
+			if err := ps.SaveEvaluation(1, result); err != nil {
+				t.Fatalf("保存检查结果失败: %v", err)
+			}
+
+			var violations []models.PolicyViolation
+			if err := database.GetDB().Find(&violations).Error; err != nil {
+				t.Fatalf("查询违规记录失败: %v", err)
+			}
+			var open, resolved []string
+			for _, v := range violations {
+				key := v.PolicyName + "/" + v.TargetName
+				if v.ResolvedAt == nil {
+					open = append(open, key)
+				} else {
+					resolved = append(resolved, key)
+				}
+			}
+			sort.Strings(open)
+			sort.Strings(resolved)
+			if !reflect.DeepEqual(open, tt.wantOpen) || !reflect.DeepEqual(resolved, tt.wantResolved) {
+				t.Errorf("未解决 %v、已解决 %v，期望 %v、%v", open, resolved, tt.wantOpen, tt.wantResolved)
+			}
+
+			var snapshot models.PolicyComplianceSnapshot
+			if err := database.GetDB().Where("collected_at = ?", second).First(&snapshot).Error; err != nil {
+				t.Fatalf("查询合规评分失败: %v", err)
+			}
+			if snapshot.Errors != len(tt.errors) {
+				t.Errorf("求值出错次数 %d，期望 %d", snapshot.Errors, len(tt.errors))
+			}
+		})
+	}
+}
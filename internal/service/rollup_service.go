@@ -0,0 +1,746 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"cluster-resource-insight/internal/config"
+	"cluster-resource-insight/internal/database"
+	"cluster-resource-insight/internal/historystore"
+	"cluster-resource-insight/internal/logger"
+	"cluster-resource-insight/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// 数据层级
+const (
+	TierRaw    = "raw"
+	TierHourly = "hourly"
+	TierDaily  = "daily"
+	TierAuto   = "auto"
+)
+
+// 汇总范围
+const (
+	RollupScopePod       = "pod"
+	RollupScopeWorkload  = "workload"
+	RollupScopeNamespace = "namespace"
+)
+
+// rollupSettings 降采样汇总参数
+type rollupSettings struct {
+	enabled             bool
+	rawRetentionDays    int
+	hourlyRetentionDays int
+	dailyRetentionDays  int
+	minPoints           int
+	maxBucketsPerRun    int
+}
+
+// loadRollupSettings 读取降采样汇总配置，未配置项使用默认值
+func loadRollupSettings() rollupSettings {
+	settings := rollupSettings{
+		enabled:             true,
+		rawRetentionDays:    30,
+		hourlyRetentionDays: 90,
+		dailyRetentionDays:  730,
+		minPoints:           48,
+		maxBucketsPerRun:    48,
+	}
+
+	if rollupConfig := config.GetRollupConfig(); rollupConfig != nil {
+		settings.enabled = rollupConfig.Enabled
+		if rollupConfig.RawRetentionDays > 0 {
+			settings.rawRetentionDays = rollupConfig.RawRetentionDays
+		}
+		if rollupConfig.HourlyRetentionDays > 0 {
+			settings.hourlyRetentionDays = rollupConfig.HourlyRetentionDays
+		}
+		if rollupConfig.DailyRetentionDays > 0 {
+			settings.dailyRetentionDays = rollupConfig.DailyRetentionDays
+		}
+		if rollupConfig.MinPoints > 0 {
+			settings.minPoints = rollupConfig.MinPoints
+		}
+		if rollupConfig.MaxBucketsPerRun > 0 {
+			settings.maxBucketsPerRun = rollupConfig.MaxBucketsPerRun
+		}
+	}
+
+	return settings
+}
+
+// RawRetentionDays 原始采集数据保留天数
+func RawRetentionDays() int {
+	return loadRollupSettings().rawRetentionDays
+}
+
+// rollupTier 汇总层级定义
+type rollupTier struct {
+	name          string
+	source        string // 汇总的数据来源层级：小时汇总读取原始数据，天汇总读取小时汇总
+	step          time.Duration
+	retentionDays int
+	model         interface{}
+	truncate      func(t time.Time) time.Time
+	next          func(t time.Time) time.Time
+	wrap          func(rows []models.MetricsRollup) interface{}
+}
+
+// tableName 层级对应的数据表
+func (tier rollupTier) tableName() string {
+	if tier.name == TierDaily {
+		return models.PodMetricsDaily{}.TableName()
+	}
+	return models.PodMetricsHourly{}.TableName()
+}
+
+// rollupTiers 由细到粗的汇总层级，天按服务器本地时区划分
+func rollupTiers(settings rollupSettings) []rollupTier {
+	return []rollupTier{
+		{
+			name:          TierHourly,
+			source:        TierRaw,
+			step:          time.Hour,
+			retentionDays: settings.hourlyRetentionDays,
+			model:         &models.PodMetricsHourly{},
+			truncate: func(t time.Time) time.Time {
+				t = t.In(time.Local)
+				return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, time.Local)
+			},
+			next: func(t time.Time) time.Time { return t.Add(time.Hour) },
+			wrap: func(rows []models.MetricsRollup) interface{} {
+				records := make([]models.PodMetricsHourly, len(rows))
+				for i := range rows {
+					records[i] = models.PodMetricsHourly{MetricsRollup: rows[i]}
+				}
+				return records
+			},
+		},
+		{
+			name:          TierDaily,
+			source:        TierHourly,
+			step:          24 * time.Hour,
+			retentionDays: settings.dailyRetentionDays,
+			model:         &models.PodMetricsDaily{},
+			truncate: func(t time.Time) time.Time {
+				t = t.In(time.Local)
+				return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+			},
+			next: func(t time.Time) time.Time { return t.AddDate(0, 0, 1) },
+			wrap: func(rows []models.MetricsRollup) interface{} {
+				records := make([]models.PodMetricsDaily, len(rows))
+				for i := range rows {
+					records[i] = models.PodMetricsDaily{MetricsRollup: rows[i]}
+				}
+				return records
+			},
+		},
+	}
+}
+
+// findRollupTier 按名称查找汇总层级
+func findRollupTier(settings rollupSettings, name string) (rollupTier, bool) {
+	for _, tier := range rollupTiers(settings) {
+		if tier.name == name {
+			return tier, true
+		}
+	}
+	return rollupTier{}, false
+}
+
+// RollupService 监控数据降采样服务 - 将原始采集数据汇总为小时和天级数据，并按层级保留
+type RollupService struct {
+	db *gorm.DB
+}
+
+// NewRollupService 创建降采样服务实例
+func NewRollupService() *RollupService {
+	return &RollupService{
+		db: database.GetDB(),
+	}
+}
+
+// RunRollups 补算所有已结束但尚未汇总的时间桶，每个层级单次最多处理 max_buckets_per_run 个
+func (rs *RollupService) RunRollups(ctx context.Context) error {
+	settings := loadRollupSettings()
+	if !settings.enabled {
+		return nil
+	}
+
+	for _, tier := range rollupTiers(settings) {
+		processed, err := rs.runTier(ctx, tier, settings)
+		if err != nil {
+			return err
+		}
+		if processed > 0 {
+			logger.Info("完成 %d 个%s汇总时间桶", processed, tier.name)
+		}
+	}
+	return nil
+}
+
+// runTier 从层级中最新的时间桶之后开始补算，只处理数据来源已完整覆盖的时间桶
+func (rs *RollupService) runTier(ctx context.Context, tier rollupTier, settings rollupSettings) (int, error) {
+	now := time.Now()
+	sourceRetentionDays := settings.rawRetentionDays
+	ready := now
+	if tier.source == TierHourly {
+		sourceRetentionDays = settings.hourlyRetentionDays
+		// 小时汇总在同一次维护中先于天汇总执行，天汇总只处理小时汇总已补算到的时间桶
+		var latestHourly database.Timestamp
+		if err := rs.db.Model(&models.PodMetricsHourly{}).Select("MAX(bucket_start)").Scan(&latestHourly).Error; err != nil {
+			return 0, fmt.Errorf("查询小时汇总进度失败: %v", err)
+		}
+		if !latestHourly.Valid {
+			return 0, nil
+		}
+		ready = latestHourly.Time.Add(time.Hour)
+	}
+	earliest := tier.truncate(now.AddDate(0, 0, -sourceRetentionDays))
+
+	var latestBucket database.Timestamp
+	if err := rs.db.Model(tier.model).Select("MAX(bucket_start)").Scan(&latestBucket).Error; err != nil {
+		return 0, fmt.Errorf("查询%s汇总进度失败: %v", tier.name, err)
+	}
+
+	var bucket time.Time
+	if latestBucket.Valid {
+		bucket = tier.next(tier.truncate(latestBucket.Time))
+	} else {
+		firstSource, err := rs.nextSourceTime(tier, time.Time{})
+		if err != nil {
+			return 0, err
+		}
+		if !firstSource.Valid {
+			return 0, nil
+		}
+		bucket = tier.truncate(firstSource.Time)
+	}
+	if bucket.Before(earliest) {
+		bucket = earliest
+	}
+
+	processed := 0
+	for processed < settings.maxBucketsPerRun {
+		if ctx.Err() != nil {
+			return processed, ctx.Err()
+		}
+		end := tier.next(bucket)
+		if end.After(ready) {
+			break
+		}
+
+		rollups, err := rs.loadBucket(ctx, tier, bucket, end)
+		if err != nil {
+			return processed, err
+		}
+		if len(rollups) == 0 {
+			// 采集中断期间没有数据，直接跳到下一次有数据的时间桶
+			nextSource, err := rs.nextSourceTime(tier, end)
+			if err != nil {
+				return processed, err
+			}
+			if !nextSource.Valid {
+				break
+			}
+			bucket = tier.truncate(nextSource.Time)
+			continue
+		}
+
+		if err := rs.saveBucket(tier, bucket, rollups); err != nil {
+			return processed, err
+		}
+		processed++
+		bucket = end
+	}
+
+	return processed, nil
+}
+
+// nextSourceTime 查询数据来源层级中不早于 from 的最早时间，from 为零值时不限制
+// 原始样本可能只保存在时序存储中，原始层级按采集批次表定位
+func (rs *RollupService) nextSourceTime(tier rollupTier, from time.Time) (database.Timestamp, error) {
+	var model interface{} = &models.CollectionRun{}
+	column := "collected_at"
+	if tier.source == TierHourly {
+		model, column = &models.PodMetricsHourly{}, "bucket_start"
+	}
+
+	var next database.Timestamp
+	db := rs.db.Model(model).Select("MIN(" + column + ")")
+	if !from.IsZero() {
+		db = db.Where(column+" >= ?", from)
+	}
+	if err := db.Scan(&next).Error; err != nil {
+		return next, fmt.Errorf("查询%s汇总数据来源时间失败: %v", tier.name, err)
+	}
+	return next, nil
+}
+
+// loadBucket 计算一个时间桶的汇总结果，没有来源数据时返回空
+func (rs *RollupService) loadBucket(ctx context.Context, tier rollupTier, bucket, end time.Time) ([]models.MetricsRollup, error) {
+	if tier.source == TierHourly {
+		var hourly []models.MetricsRollup
+		if err := rs.db.Table(models.PodMetricsHourly{}.TableName()).
+			Where("bucket_start >= ? AND bucket_start < ?", bucket, end).
+			Find(&hourly).Error; err != nil {
+			return nil, fmt.Errorf("读取小时汇总数据失败: %v", err)
+		}
+		return mergeRollups(hourly, bucket), nil
+	}
+
+	rows, err := rs.loadSamples(ctx, bucket, end)
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	return buildRollups(rows, bucket), nil
+}
+
+// rollupSample 参与汇总的原始采样
+type rollupSample struct {
+	ClusterID     uint
+	Namespace     string
+	PodName       string
+	WorkloadKind  string
+	WorkloadName  string
+	CPUUsage      int64
+	CPURequest    int64
+	CPULimit      int64
+	MemoryUsage   int64
+	MemoryRequest int64
+	MemoryLimit   int64
+	CollectedAt   time.Time
+
+	CPUMetricsAvailable    bool
+	MemoryMetricsAvailable bool
+}
+
+// loadSamples 读取时间桶内至少有一项真实使用量的原始采样，没有监控数据的采样不参与汇总
+func (rs *RollupService) loadSamples(ctx context.Context, start, end time.Time) ([]rollupSample, error) {
+	var rows []rollupSample
+	err := historystore.GetHistoryStore().Scan(ctx, historystore.RangeQuery{
+		Filter: historystore.Filter{MetricsOnly: true},
+		Start:  start,
+		End:    end,
+	}, func(record *models.PodMetricsHistory) error {
+		rows = append(rows, rollupSample{
+			ClusterID:              record.ClusterID,
+			Namespace:              record.Namespace,
+			PodName:                record.PodName,
+			WorkloadKind:           record.WorkloadKind,
+			WorkloadName:           record.WorkloadName,
+			CPUUsage:               record.CPUUsage,
+			CPURequest:             record.CPURequest,
+			CPULimit:               record.CPULimit,
+			MemoryUsage:            record.MemoryUsage,
+			MemoryRequest:          record.MemoryRequest,
+			MemoryLimit:            record.MemoryLimit,
+			CollectedAt:            record.CollectedAt,
+			CPUMetricsAvailable:    record.CPUMetricsAvailable,
+			MemoryMetricsAvailable: record.MemoryMetricsAvailable,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取汇总原始数据失败: %v", err)
+	}
+	return rows, nil
+}
+
+// saveBucket 覆盖写入一个时间桶的汇总结果，重复执行不会产生重复记录
+func (rs *RollupService) saveBucket(tier rollupTier, bucket time.Time, rows []models.MetricsRollup) error {
+	return rs.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("bucket_start = ?", bucket).Delete(tier.model).Error; err != nil {
+			return fmt.Errorf("清除%s汇总旧数据失败: %v", tier.name, err)
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		if err := tx.CreateInBatches(tier.wrap(rows), 200).Error; err != nil {
+			return fmt.Errorf("保存%s汇总数据失败: %v", tier.name, err)
+		}
+		return nil
+	})
+}
+
+// rollupSeriesKey 汇总序列的唯一标识
+type rollupSeriesKey struct {
+	clusterID    uint
+	scope        string
+	namespace    string
+	workloadKind string
+	workloadName string
+	podName      string
+}
+
+// rollupPoint 同一采集批次内的汇总值，使用量只累加对应指标有真实数据的Pod，
+// 计算请求利用率时也只使用这些Pod的请求量
+type rollupPoint struct {
+	pods          int
+	cpuRequest    float64
+	cpuLimit      float64
+	memoryRequest float64
+	memoryLimit   float64
+
+	cpuPods            int
+	cpuUsage           float64
+	cpuUsageRequest    float64
+	memoryPods         int
+	memoryUsage        float64
+	memoryUsageRequest float64
+}
+
+// buildRollups 按Pod、工作负载、命名空间分别汇总时间桶内的采样
+func buildRollups(rows []rollupSample, bucket time.Time) []models.MetricsRollup {
+	series := make(map[rollupSeriesKey]map[time.Time]*rollupPoint)
+	add := func(key rollupSeriesKey, row rollupSample) {
+		points, ok := series[key]
+		if !ok {
+			points = make(map[time.Time]*rollupPoint)
+			series[key] = points
+		}
+		point, ok := points[row.CollectedAt]
+		if !ok {
+			point = &rollupPoint{}
+			points[row.CollectedAt] = point
+		}
+		point.pods++
+		point.cpuRequest += float64(row.CPURequest)
+		point.cpuLimit += float64(row.CPULimit)
+		point.memoryRequest += float64(row.MemoryRequest)
+		point.memoryLimit += float64(row.MemoryLimit)
+		if row.CPUMetricsAvailable {
+			point.cpuPods++
+			point.cpuUsage += float64(row.CPUUsage)
+			point.cpuUsageRequest += float64(row.CPURequest)
+		}
+		if row.MemoryMetricsAvailable {
+			point.memoryPods++
+			point.memoryUsage += float64(row.MemoryUsage)
+			point.memoryUsageRequest += float64(row.MemoryRequest)
+		}
+	}
+
+	for _, row := range rows {
+		add(rollupSeriesKey{clusterID: row.ClusterID, scope: RollupScopePod, namespace: row.Namespace,
+			workloadKind: row.WorkloadKind, workloadName: row.WorkloadName, podName: row.PodName}, row)
+		if row.WorkloadName != "" {
+			add(rollupSeriesKey{clusterID: row.ClusterID, scope: RollupScopeWorkload, namespace: row.Namespace,
+				workloadKind: row.WorkloadKind, workloadName: row.WorkloadName}, row)
+		}
+		add(rollupSeriesKey{clusterID: row.ClusterID, scope: RollupScopeNamespace, namespace: row.Namespace}, row)
+	}
+
+	rollups := make([]models.MetricsRollup, 0, len(series))
+	for key, points := range series {
+		rollups = append(rollups, summarizeRollup(key, points, bucket))
+	}
+	return rollups
+}
+
+// summarizeRollup 计算单个序列在时间桶内的平均值、P95、最大值和最小值
+// 请求量、限制量和Pod数按所有采样统计，各项使用量只统计该指标有真实数据的采样
+func summarizeRollup(key rollupSeriesKey, points map[time.Time]*rollupPoint, bucket time.Time) models.MetricsRollup {
+	rollup := models.MetricsRollup{
+		ClusterID:    key.clusterID,
+		Scope:        key.scope,
+		Namespace:    key.namespace,
+		WorkloadKind: key.workloadKind,
+		WorkloadName: key.workloadName,
+		PodName:      key.podName,
+		BucketStart:  bucket,
+		SampleCount:  len(points),
+	}
+
+	cpuValues := make([]float64, 0, len(points))
+	memoryValues := make([]float64, 0, len(points))
+	var cpuPctTotal, memoryPctTotal float64
+	for _, point := range points {
+		rollup.PodCount += float64(point.pods)
+		rollup.CPURequest += point.cpuRequest
+		rollup.CPULimit += point.cpuLimit
+		rollup.MemoryRequest += point.memoryRequest
+		rollup.MemoryLimit += point.memoryLimit
+		if point.cpuPods > 0 {
+			cpuValues = append(cpuValues, point.cpuUsage)
+			if point.cpuUsageRequest > 0 {
+				cpuPctTotal += point.cpuUsage / point.cpuUsageRequest * 100
+			}
+		}
+		if point.memoryPods > 0 {
+			memoryValues = append(memoryValues, point.memoryUsage)
+			if point.memoryUsageRequest > 0 {
+				memoryPctTotal += point.memoryUsage / point.memoryUsageRequest * 100
+			}
+		}
+	}
+
+	n := float64(len(points))
+	rollup.PodCount /= n
+	rollup.CPURequest /= n
+	rollup.CPULimit /= n
+	rollup.MemoryRequest /= n
+	rollup.MemoryLimit /= n
+	rollup.CPUSampleCount = len(cpuValues)
+	rollup.MemorySampleCount = len(memoryValues)
+	if rollup.CPUSampleCount > 0 {
+		rollup.CPUReqPct = cpuPctTotal / float64(rollup.CPUSampleCount)
+	}
+	if rollup.MemorySampleCount > 0 {
+		rollup.MemoryReqPct = memoryPctTotal / float64(rollup.MemorySampleCount)
+	}
+	rollup.CPUUsageAvg, rollup.CPUUsageP95, rollup.CPUUsageMax, rollup.CPUUsageMin = distribution(cpuValues)
+	rollup.MemoryUsageAvg, rollup.MemoryUsageP95, rollup.MemoryUsageMax, rollup.MemoryUsageMin = distribution(memoryValues)
+
+	return rollup
+}
+
+// mergeRollups 将较细层级的汇总结果按序列合并为一个时间桶：平均值类字段按采样次数加权，
+// 使用量相关字段按该指标有真实数据的采样次数加权，没有该指标数据的时间桶不参与使用量统计；
+// 最大值和最小值取极值，P95 无法由各时间桶的P95精确合并，取各时间桶P95的P95作为近似值
+func mergeRollups(rows []models.MetricsRollup, bucket time.Time) []models.MetricsRollup {
+	series := make(map[rollupSeriesKey][]models.MetricsRollup)
+	for _, row := range rows {
+		key := rollupSeriesKey{clusterID: row.ClusterID, scope: row.Scope, namespace: row.Namespace,
+			workloadKind: row.WorkloadKind, workloadName: row.WorkloadName, podName: row.PodName}
+		series[key] = append(series[key], row)
+	}
+
+	merged := make([]models.MetricsRollup, 0, len(series))
+	for key, parts := range series {
+		rollup := models.MetricsRollup{
+			ClusterID:    key.clusterID,
+			Scope:        key.scope,
+			Namespace:    key.namespace,
+			WorkloadKind: key.workloadKind,
+			WorkloadName: key.workloadName,
+			PodName:      key.podName,
+			BucketStart:  bucket,
+		}
+		cpuP95s := make([]float64, 0, len(parts))
+		memoryP95s := make([]float64, 0, len(parts))
+		cpuMins := make([]float64, 0, len(parts))
+		memoryMins := make([]float64, 0, len(parts))
+		for _, part := range parts {
+			weight := float64(part.SampleCount)
+			rollup.SampleCount += part.SampleCount
+			rollup.PodCount += part.PodCount * weight
+			rollup.CPURequest += part.CPURequest * weight
+			rollup.CPULimit += part.CPULimit * weight
+			rollup.MemoryRequest += part.MemoryRequest * weight
+			rollup.MemoryLimit += part.MemoryLimit * weight
+
+			if part.CPUSampleCount > 0 {
+				cpuWeight := float64(part.CPUSampleCount)
+				rollup.CPUSampleCount += part.CPUSampleCount
+				rollup.CPUUsageAvg += part.CPUUsageAvg * cpuWeight
+				rollup.CPUReqPct += part.CPUReqPct * cpuWeight
+				rollup.CPUUsageMax = math.Max(rollup.CPUUsageMax, part.CPUUsageMax)
+				cpuMins = append(cpuMins, part.CPUUsageMin)
+				cpuP95s = append(cpuP95s, part.CPUUsageP95)
+			}
+			if part.MemorySampleCount > 0 {
+				memoryWeight := float64(part.MemorySampleCount)
+				rollup.MemorySampleCount += part.MemorySampleCount
+				rollup.MemoryUsageAvg += part.MemoryUsageAvg * memoryWeight
+				rollup.MemoryReqPct += part.MemoryReqPct * memoryWeight
+				rollup.MemoryUsageMax = math.Max(rollup.MemoryUsageMax, part.MemoryUsageMax)
+				memoryMins = append(memoryMins, part.MemoryUsageMin)
+				memoryP95s = append(memoryP95s, part.MemoryUsageP95)
+			}
+		}
+
+		if rollup.SampleCount > 0 {
+			n := float64(rollup.SampleCount)
+			rollup.PodCount /= n
+			rollup.CPURequest /= n
+			rollup.CPULimit /= n
+			rollup.MemoryRequest /= n
+			rollup.MemoryLimit /= n
+		}
+		if rollup.CPUSampleCount > 0 {
+			rollup.CPUUsageAvg /= float64(rollup.CPUSampleCount)
+			rollup.CPUReqPct /= float64(rollup.CPUSampleCount)
+		}
+		if rollup.MemorySampleCount > 0 {
+			rollup.MemoryUsageAvg /= float64(rollup.MemorySampleCount)
+			rollup.MemoryReqPct /= float64(rollup.MemorySampleCount)
+		}
+		_, rollup.CPUUsageP95, _, _ = distribution(cpuP95s)
+		_, rollup.MemoryUsageP95, _, _ = distribution(memoryP95s)
+		_, _, _, rollup.CPUUsageMin = distribution(cpuMins)
+		_, _, _, rollup.MemoryUsageMin = distribution(memoryMins)
+		merged = append(merged, rollup)
+	}
+	return merged
+}
+
+// distribution 返回平均值、P95（最近秩法）、最大值和最小值
+func distribution(values []float64) (avg, p95, max, min float64) {
+	if len(values) == 0 {
+		return 0, 0, 0, 0
+	}
+	sort.Float64s(values)
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	rank := int(math.Ceil(0.95*float64(len(values)))) - 1
+	return sum / float64(len(values)), values[rank], values[len(values)-1], values[0]
+}
+
+// CleanupExpiredRollups 按各层级的保留天数清理汇总数据
+func (rs *RollupService) CleanupExpiredRollups() error {
+	settings := loadRollupSettings()
+	for _, tier := range rollupTiers(settings) {
+		cutoffTime := time.Now().AddDate(0, 0, -tier.retentionDays)
+		result := rs.db.Where("bucket_start < ?", cutoffTime).Delete(tier.model)
+		if result.Error != nil {
+			return fmt.Errorf("清理%s汇总数据失败: %v", tier.name, result.Error)
+		}
+		if result.RowsAffected > 0 {
+			logger.Info("清理了 %d 条过期%s汇总数据（超过 %d 天）", result.RowsAffected, tier.name, tier.retentionDays)
+		}
+	}
+	return nil
+}
+
+// SelectTier 为查询时间范围选择数据层级：在保留期覆盖查询起点的层级中，
+// 选择能提供至少 min_points 个数据点的最粗层级；都不满足时使用原始数据
+// 参数:
+//   - start, end: 查询时间范围
+//   - allowRaw: 是否允许选择原始数据层级
+func (rs *RollupService) SelectTier(start, end time.Time, allowRaw bool) string {
+	settings := loadRollupSettings()
+	now := time.Now()
+	covers := func(retentionDays int) bool {
+		return !start.Before(now.AddDate(0, 0, -retentionDays))
+	}
+
+	if !settings.enabled {
+		return TierRaw
+	}
+
+	tiers := rollupTiers(settings)
+	span := end.Sub(start)
+	for i := len(tiers) - 1; i >= 0; i-- {
+		tier := tiers[i]
+		if covers(tier.retentionDays) && span >= time.Duration(settings.minPoints)*tier.step {
+			return tier.name
+		}
+	}
+
+	if allowRaw && covers(settings.rawRetentionDays) {
+		return TierRaw
+	}
+	// 查询起点超出原始数据保留期时，使用仍保留该时间段数据的最细层级
+	for _, tier := range tiers {
+		if covers(tier.retentionDays) {
+			return tier.name
+		}
+	}
+	return TierDaily
+}
+
+// RollupQuery 汇总数据查询条件
+type RollupQuery struct {
+	ClusterID    uint
+	Scope        string
+	Namespace    string
+	WorkloadKind string
+	WorkloadName string
+	PodName      string
+	Start        time.Time
+	End          time.Time
+	Tier         string // hourly/daily/auto
+}
+
+// RollupSeries 汇总数据查询结果
+type RollupSeries struct {
+	Tier  string                 `json:"tier"`
+	Scope string                 `json:"scope"`
+	Start time.Time              `json:"start"`
+	End   time.Time              `json:"end"`
+	Data  []models.MetricsRollup `json:"data"`
+}
+
+// QueryRollups 查询汇总数据，tier 为 auto 时按时间范围自动选择小时或天级汇总
+func (rs *RollupService) QueryRollups(query RollupQuery) (*RollupSeries, error) {
+	if query.Tier == "" || query.Tier == TierAuto {
+		query.Tier = rs.SelectTier(query.Start, query.End, false)
+		if query.Tier == TierRaw {
+			query.Tier = TierHourly
+		}
+	}
+	tier, ok := findRollupTier(loadRollupSettings(), query.Tier)
+	if !ok {
+		return nil, fmt.Errorf("不支持的汇总层级: %s", query.Tier)
+	}
+	if query.Scope == "" {
+		query.Scope = RollupScopeNamespace
+	}
+
+	db := rs.db.Table(tier.tableName()).
+		Where("scope = ? AND bucket_start >= ? AND bucket_start < ?", query.Scope, tier.truncate(query.Start), query.End)
+	if query.ClusterID > 0 {
+		db = db.Where("cluster_id = ?", query.ClusterID)
+	}
+	if query.Namespace != "" {
+		db = db.Where("namespace = ?", query.Namespace)
+	}
+	if query.WorkloadName != "" {
+		db = db.Where("workload_kind = ? AND workload_name = ?", query.WorkloadKind, query.WorkloadName)
+	}
+	if query.PodName != "" {
+		db = db.Where("pod_name = ?", query.PodName)
+	}
+
+	var data []models.MetricsRollup
+	if err := db.Order("bucket_start ASC").Find(&data).Error; err != nil {
+		return nil, fmt.Errorf("查询%s汇总数据失败: %v", tier.name, err)
+	}
+
+	return &RollupSeries{
+		Tier:  tier.name,
+		Scope: query.Scope,
+		Start: query.Start,
+		End:   query.End,
+		Data:  data,
+	}, nil
+}
+
+// rollupToHistory 将Pod范围的汇总数据转换为历史记录格式，使用量取时间桶内平均值，
+// 时间桶内没有真实数据的指标标记为不可用
+func rollupToHistory(rollup models.MetricsRollup) models.PodMetricsHistory {
+	record := models.PodMetricsHistory{
+		ClusterID:              rollup.ClusterID,
+		Namespace:              rollup.Namespace,
+		PodName:                rollup.PodName,
+		WorkloadKind:           rollup.WorkloadKind,
+		WorkloadName:           rollup.WorkloadName,
+		MemoryUsage:            int64(rollup.MemoryUsageAvg),
+		MemoryRequest:          int64(rollup.MemoryRequest),
+		MemoryLimit:            int64(rollup.MemoryLimit),
+		MemoryReqPct:           rollup.MemoryReqPct,
+		CPUUsage:               int64(rollup.CPUUsageAvg),
+		CPURequest:             int64(rollup.CPURequest),
+		CPULimit:               int64(rollup.CPULimit),
+		CPUReqPct:              rollup.CPUReqPct,
+		MetricsAvailable:       rollup.CPUSampleCount > 0 && rollup.MemorySampleCount > 0,
+		CPUMetricsAvailable:    rollup.CPUSampleCount > 0,
+		MemoryMetricsAvailable: rollup.MemorySampleCount > 0,
+		CollectedAt:            rollup.BucketStart,
+	}
+	if rollup.MemorySampleCount > 0 && rollup.MemoryLimit > 0 {
+		record.MemoryLimitPct = rollup.MemoryUsageAvg / rollup.MemoryLimit * 100
+	}
+	if rollup.CPUSampleCount > 0 && rollup.CPULimit > 0 {
+		record.CPULimitPct = rollup.CPUUsageAvg / rollup.CPULimit * 100
+	}
+	return record
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"cluster-resource-insight/internal/models"
+)
+
+var rollupBucket = time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+
+// testRollupSample 构造汇总测试采样，cpu/memory 小于0表示该项指标缺失
+func testRollupSample(minute int, pod, workload string, cpu, memory int64) rollupSample {
+	sample := rollupSample{
+		ClusterID:     1,
+		Namespace:     "default",
+		PodName:       pod,
+		WorkloadName:  workload,
+		CPURequest:    500,
+		CPULimit:      1000,
+		MemoryRequest: 1000,
+		MemoryLimit:   2000,
+		CollectedAt:   rollupBucket.Add(time.Duration(minute) * time.Minute),
+	}
+	if workload != "" {
+		sample.WorkloadKind = "Deployment"
+	}
+	if cpu >= 0 {
+		sample.CPUUsage, sample.CPUMetricsAvailable = cpu, true
+	}
+	if memory >= 0 {
+		sample.MemoryUsage, sample.MemoryMetricsAvailable = memory, true
+	}
+	return sample
+}
+
+// rollupSummary 汇总结果中参与比较的字段
+type rollupSummary struct {
+	SampleCount, CPUSampleCount, MemorySampleCount int
+	PodCount, CPURequest                           float64
+	CPUUsageAvg, CPUUsageP95, CPUUsageMax          float64
+	CPUUsageMin, CPUReqPct                         float64
+	MemoryUsageAvg, MemoryUsageMin, MemoryReqPct   float64
+}
+
+func summaryOf(rollup models.MetricsRollup) rollupSummary {
+	return rollupSummary{
+		SampleCount: rollup.SampleCount, CPUSampleCount: rollup.CPUSampleCount, MemorySampleCount: rollup.MemorySampleCount,
+		PodCount: rollup.PodCount, CPURequest: rollup.CPURequest,
+		CPUUsageAvg: rollup.CPUUsageAvg, CPUUsageP95: rollup.CPUUsageP95, CPUUsageMax: rollup.CPUUsageMax,
+		CPUUsageMin: rollup.CPUUsageMin, CPUReqPct: rollup.CPUReqPct,
+		MemoryUsageAvg: rollup.MemoryUsageAvg, MemoryUsageMin: rollup.MemoryUsageMin, MemoryReqPct: rollup.MemoryReqPct,
+	}
+}
+
+// findRollup 按范围和Pod名称查找汇总结果
+func findRollup(rollups []models.MetricsRollup, scope, pod string) (models.MetricsRollup, bool) {
+	for _, rollup := range rollups {
+		if rollup.Scope == scope && rollup.PodName == pod {
+			return rollup, true
+		}
+	}
+	return models.MetricsRollup{}, false
+}
+
+func TestBuildRollups(t *testing.T) {
+	tests := []struct {
+		name      string
+		rows      []rollupSample
+		scope     string
+		pod       string
+		wantCount int
+		want      rollupSummary
+	}{
+		{
+			name:      "空输入",
+			wantCount: 0,
+		},
+		{
+			name:      "指标完整",
+			rows:      []rollupSample{testRollupSample(0, "web-1", "web", 100, 400), testRollupSample(5, "web-1", "web", 300, 600)},
+			scope:     RollupScopePod,
+			pod:       "web-1",
+			wantCount: 3,
+			want: rollupSummary{SampleCount: 2, CPUSampleCount: 2, MemorySampleCount: 2, PodCount: 1, CPURequest: 500,
+				CPUUsageAvg: 200, CPUUsageP95: 300, CPUUsageMax: 300, CPUUsageMin: 100, CPUReqPct: 40,
+				MemoryUsageAvg: 500, MemoryUsageMin: 400, MemoryReqPct: 50},
+		},
+		{
+			name:      "部分批次缺少CPU指标时不按0计入",
+			rows:      []rollupSample{testRollupSample(0, "web-1", "web", 100, 400), testRollupSample(5, "web-1", "web", -1, 600)},
+			scope:     RollupScopePod,
+			pod:       "web-1",
+			wantCount: 3,
+			want: rollupSummary{SampleCount: 2, CPUSampleCount: 1, MemorySampleCount: 2, PodCount: 1, CPURequest: 500,
+				CPUUsageAvg: 100, CPUUsageP95: 100, CPUUsageMax: 100, CPUUsageMin: 100, CPUReqPct: 20,
+				MemoryUsageAvg: 500, MemoryUsageMin: 400, MemoryReqPct: 50},
+		},
+		{
+			name:      "完全缺少内存指标",
+			rows:      []rollupSample{testRollupSample(0, "web-1", "web", 100, -1), testRollupSample(5, "web-1", "web", 300, -1)},
+			scope:     RollupScopePod,
+			pod:       "web-1",
+			wantCount: 3,
+			want: rollupSummary{SampleCount: 2, CPUSampleCount: 2, MemorySampleCount: 0, PodCount: 1, CPURequest: 500,
+				CPUUsageAvg: 200, CPUUsageP95: 300, CPUUsageMax: 300, CPUUsageMin: 100, CPUReqPct: 40},
+		},
+		{
+			name: "工作负载的利用率只使用有指标的Pod的请求量",
+			rows: []rollupSample{
+				testRollupSample(0, "web-1", "web", 200, 500),
+				testRollupSample(0, "web-2", "web", -1, 300),
+			},
+			scope:     RollupScopeWorkload,
+			wantCount: 4,
+			want: rollupSummary{SampleCount: 1, CPUSampleCount: 1, MemorySampleCount: 1, PodCount: 2, CPURequest: 1000,
+				CPUUsageAvg: 200, CPUUsageP95: 200, CPUUsageMax: 200, CPUUsageMin: 200, CPUReqPct: 40,
+				MemoryUsageAvg: 800, MemoryUsageMin: 800, MemoryReqPct: 40},
+		},
+		{
+			name: "同一批次的多个Pod先相加再统计",
+			rows: []rollupSample{
+				testRollupSample(0, "web-1", "web", 100, 100),
+				testRollupSample(0, "job-1", "", 300, 100),
+				testRollupSample(5, "web-1", "web", 100, 100),
+			},
+			scope:     RollupScopeNamespace,
+			wantCount: 4,
+			want: rollupSummary{SampleCount: 2, CPUSampleCount: 2, MemorySampleCount: 2, PodCount: 1.5, CPURequest: 750,
+				CPUUsageAvg: 250, CPUUsageP95: 400, CPUUsageMax: 400, CPUUsageMin: 100, CPUReqPct: 30,
+				MemoryUsageAvg: 150, MemoryUsageMin: 100, MemoryReqPct: 10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rollups := buildRollups(tt.rows, rollupBucket)
+			if len(rollups) != tt.wantCount {
+				t.Fatalf("汇总结果 %d 条，期望 %d 条", len(rollups), tt.wantCount)
+			}
+			if tt.wantCount == 0 {
+				return
+			}
+			rollup, ok := findRollup(rollups, tt.scope, tt.pod)
+			if !ok {
+				t.Fatalf("没有找到 %s 范围的汇总结果", tt.scope)
+			}
+			if !rollup.BucketStart.Equal(rollupBucket) {
+				t.Errorf("时间桶 %v，期望 %v", rollup.BucketStart, rollupBucket)
+			}
+			if got := summaryOf(rollup); got != tt.want {
+				t.Errorf("汇总结果不一致:\n得到 %+v\n期望 %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeRollups(t *testing.T) {
+	part := func(samples, cpuSamples, memorySamples int, cpuAvg, cpuMin, cpuMax, cpuP95, memoryAvg float64) models.MetricsRollup {
+		return models.MetricsRollup{
+			ClusterID: 1, Scope: RollupScopePod, Namespace: "default", PodName: "web-1",
+			SampleCount: samples, CPUSampleCount: cpuSamples, MemorySampleCount: memorySamples,
+			PodCount: 1, CPURequest: 500,
+			CPUUsageAvg: cpuAvg, CPUUsageMin: cpuMin, CPUUsageMax: cpuMax, CPUUsageP95: cpuP95, CPUReqPct: cpuAvg / 5,
+			MemoryUsageAvg: memoryAvg, MemoryUsageMin: memoryAvg, MemoryReqPct: memoryAvg / 10,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		rows      []models.MetricsRollup
+		wantCount int
+		want      rollupSummary
+	}{
+		{
+			name:      "空输入",
+			wantCount: 0,
+		},
+		{
+			name:      "按采样次数加权",
+			rows:      []models.MetricsRollup{part(2, 2, 2, 100, 50, 150, 150, 100), part(6, 6, 6, 300, 200, 400, 400, 300)},
+			wantCount: 1,
+			want: rollupSummary{SampleCount: 8, CPUSampleCount: 8, MemorySampleCount: 8, PodCount: 1, CPURequest: 500,
+				CPUUsageAvg: 250, CPUUsageP95: 400, CPUUsageMax: 400, CPUUsageMin: 50, CPUReqPct: 50,
+				MemoryUsageAvg: 250, MemoryUsageMin: 100, MemoryReqPct: 25},
+		},
+		{
+			name:      "使用量按该指标的采样次数加权",
+			rows:      []models.MetricsRollup{part(2, 2, 2, 100, 50, 150, 150, 100), part(6, 2, 6, 300, 200, 400, 400, 300)},
+			wantCount: 1,
+			want: rollupSummary{SampleCount: 8, CPUSampleCount: 4, MemorySampleCount: 8, PodCount: 1, CPURequest: 500,
+				CPUUsageAvg: 200, CPUUsageP95: 400, CPUUsageMax: 400, CPUUsageMin: 50, CPUReqPct: 40,
+				MemoryUsageAvg: 250, MemoryUsageMin: 100, MemoryReqPct: 25},
+		},
+		{
+			name:      "没有CPU数据的时间桶不参与最小值",
+			rows:      []models.MetricsRollup{part(2, 0, 2, 0, 0, 0, 0, 100), part(2, 2, 2, 300, 200, 400, 400, 100)},
+			wantCount: 1,
+			want: rollupSummary{SampleCount: 4, CPUSampleCount: 2, MemorySampleCount: 4, PodCount: 1, CPURequest: 500,
+				CPUUsageAvg: 300, CPUUsageP95: 400, CPUUsageMax: 400, CPUUsageMin: 200, CPUReqPct: 60,
+				MemoryUsageAvg: 100, MemoryUsageMin: 100, MemoryReqPct: 10},
+		},
+		{
+			name:      "所有时间桶都缺少内存数据",
+			rows:      []models.MetricsRollup{part(2, 2, 0, 100, 100, 100, 100, 0), part(2, 2, 0, 100, 100, 100, 100, 0)},
+			wantCount: 1,
+			want: rollupSummary{SampleCount: 4, CPUSampleCount: 4, MemorySampleCount: 0, PodCount: 1, CPURequest: 500,
+				CPUUsageAvg: 100, CPUUsageP95: 100, CPUUsageMax: 100, CPUUsageMin: 100, CPUReqPct: 20},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := mergeRollups(tt.rows, rollupBucket)
+			if len(merged) != tt.wantCount {
+				t.Fatalf("合并结果 %d 条，期望 %d 条", len(merged), tt.wantCount)
+			}
+			if tt.wantCount == 0 {
+				return
+			}
+			if got := summaryOf(merged[0]); got != tt.want {
+				t.Errorf("合并结果不一致:\n得到 %+v\n期望 %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeRollupsKeepsSeriesSeparate(t *testing.T) {
+	rows := []models.MetricsRollup{
+		{ClusterID: 1, Scope: RollupScopePod, Namespace: "default", PodName: "web-1", SampleCount: 1},
+		{ClusterID: 1, Scope: RollupScopePod, Namespace: "default", PodName: "web-2", SampleCount: 1},
+		{ClusterID: 2, Scope: RollupScopePod, Namespace: "default", PodName: "web-1", SampleCount: 1},
+		{ClusterID: 1, Scope: RollupScopeNamespace, Namespace: "default", SampleCount: 1},
+		{ClusterID: 1, Scope: RollupScopeNamespace, Namespace: "default", SampleCount: 1},
+	}
+	if merged := mergeRollups(rows, rollupBucket); len(merged) != 4 {
+		t.Errorf("合并结果 %d 条，期望 4 条", len(merged))
+	}
+}
+
+func TestDistribution(t *testing.T) {
+	twenty := make([]float64, 20)
+	for i := range twenty {
+		twenty[i] = float64(20 - i)
+	}
+
+	tests := []struct {
+		name                     string
+		values                   []float64
+		avg, p95, maxVal, minVal float64
+	}{
+		{"空输入", nil, 0, 0, 0, 0},
+		{"单个值", []float64{5}, 5, 5, 5, 5},
+		{"未排序输入", []float64{3, 1, 2}, 2, 3, 3, 1},
+		{"P95取最近秩", twenty, 10.5, 19, 20, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			avg, p95, maxVal, minVal := distribution(tt.values)
+			if avg != tt.avg || p95 != tt.p95 || maxVal != tt.maxVal || minVal != tt.minVal {
+				t.Errorf("得到 %v/%v/%v/%v，期望 %v/%v/%v/%v", avg, p95, maxVal, minVal, tt.avg, tt.p95, tt.maxVal, tt.minVal)
+			}
+		})
+	}
+}
+
+func TestRollupToHistory(t *testing.T) {
+	tests := []struct {
+		name               string
+		cpuSamples         int
+		memorySamples      int
+		wantAvailable      bool
+		wantCPULimitPct    float64
+		wantMemoryLimitPct float64
+	}{
+		{"两项指标都有数据", 2, 2, true, 20, 25},
+		{"只有CPU数据", 2, 0, false, 20, 0},
+		{"只有内存数据", 0, 2, false, 0, 25},
+		{"没有指标数据", 0, 0, false, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := rollupToHistory(models.MetricsRollup{
+				SampleCount: 2, CPUSampleCount: tt.cpuSamples, MemorySampleCount: tt.memorySamples,
+				CPUUsageAvg: 200, CPULimit: 1000, MemoryUsageAvg: 500, MemoryLimit: 2000, BucketStart: rollupBucket,
+			})
+			if record.MetricsAvailable != tt.wantAvailable ||
+				record.CPUMetricsAvailable != (tt.cpuSamples > 0) || record.MemoryMetricsAvailable != (tt.memorySamples > 0) {
+				t.Errorf("可用标记 %v/%v/%v 不正确", record.MetricsAvailable, record.CPUMetricsAvailable, record.MemoryMetricsAvailable)
+			}
+			if record.CPULimitPct != tt.wantCPULimitPct || record.MemoryLimitPct != tt.wantMemoryLimitPct {
+				t.Errorf("限制利用率 %v/%v，期望 %v/%v", record.CPULimitPct, record.MemoryLimitPct, tt.wantCPULimitPct, tt.wantMemoryLimitPct)
+			}
+			if !record.CollectedAt.Equal(rollupBucket) {
+				t.Errorf("采集时间 %v，期望 %v", record.CollectedAt, rollupBucket)
+			}
+		})
+	}
+}
@@ -72,6 +72,11 @@ type ScheduleService struct {
 	stopChan     chan struct{}
 	running      bool
 	runningMutex sync.RWMutex
+
+	// 运行上下文，Stop 时取消，正在执行的采集和维护随之中止
+	ctx     context.Context
+	cancel  context.CancelFunc
+	workers sync.WaitGroup // 调度、健康检查和管理协程
 }
 
 // NewScheduleService 创建调度服务实例
@@ -109,12 +114,20 @@ func (ss *ScheduleService) Start(ctx context.Context) error {
 		return fmt.Errorf("加载集群任务失败: %v", err)
 	}
 
+	// 上一次 Stop 仍在等待协程退出时，等其结束后再替换控制通道
+	ss.workers.Wait()
+
+	// 调度任务的生命周期与调用方（如启动接口的HTTP请求）无关，由 Stop 结束
+	ss.ctx, ss.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	ss.stopChan = make(chan struct{})
+	ctx = ss.ctx
+
 	// 启动所有集群的调度任务
 	ss.startAllJobs(ctx)
 
 	// 启动健康检查和管理协程
-	go ss.healthCheckLoop(ctx)
-	go ss.managementLoop(ctx)
+	ss.goWorker(func() { ss.healthCheckLoop(ctx) })
+	ss.goWorker(func() { ss.managementLoop(ctx) })
 
 	ss.running = true
 	logger.Info("定时调度服务启动完成，共管理 %d 个集群任务", len(ss.jobs))
@@ -122,12 +135,11 @@ func (ss *ScheduleService) Start(ctx context.Context) error {
 	return nil
 }
 
-// Stop 停止调度服务
+// Stop 停止调度服务，取消正在执行的采集和维护任务并等待所有调度协程退出
 func (ss *ScheduleService) Stop() error {
 	ss.runningMutex.Lock()
-	defer ss.runningMutex.Unlock()
-
 	if !ss.running {
+		ss.runningMutex.Unlock()
 		return fmt.Errorf("调度服务未运行")
 	}
 
@@ -135,16 +147,36 @@ func (ss *ScheduleService) Stop() error {
 
 	// 发送停止信号
 	close(ss.stopChan)
+	ss.cancel()
 
 	// 停止所有任务
 	ss.stopAllJobs()
 
 	ss.running = false
+	ss.runningMutex.Unlock()
+
+	ss.workers.Wait()
 	logger.Info("定时调度服务已停止")
 
 	return nil
 }
 
+// IsRunning 调度服务是否在运行
+func (ss *ScheduleService) IsRunning() bool {
+	ss.runningMutex.RLock()
+	defer ss.runningMutex.RUnlock()
+	return ss.running
+}
+
+// goWorker 启动一个由 Stop 等待退出的调度协程
+func (ss *ScheduleService) goWorker(fn func()) {
+	ss.workers.Add(1)
+	go func() {
+		defer ss.workers.Done()
+		fn()
+	}()
+}
+
 // loadClusterJobs 加载集群配置并创建调度任务
 func (ss *ScheduleService) loadClusterJobs() error {
 	clusters, err := ss.clusterService.GetAllClusters()
@@ -318,7 +350,12 @@ func (ss *ScheduleService) RestartJob(clusterID uint) error {
 	job.mutex.Unlock()
 
 	// 重新启动任务
-	ctx := context.Background()
+	ss.runningMutex.RLock()
+	ctx, running := ss.ctx, ss.running
+	ss.runningMutex.RUnlock()
+	if !running {
+		return fmt.Errorf("调度服务未运行")
+	}
 	ss.startSingleJob(ctx, job)
 
 	logger.Info("集群 %s (ID: %d) 的调度任务已重启", job.ClusterName, clusterID)
@@ -377,7 +414,7 @@ func (ss *ScheduleService) startSingleJob(ctx context.Context, job *ScheduleJob)
 	job.mutex.Lock()
 	defer job.mutex.Unlock()
 
-	if job.isRunning {
+	if job.isRunning || ctx.Err() != nil {
 		return
 	}
 
@@ -387,8 +424,10 @@ func (ss *ScheduleService) startSingleJob(ctx context.Context, job *ScheduleJob)
 	job.Status = "running"
 	job.NextRun = time.Now().Add(job.Interval)
 
-	// 启动任务协程
-	go ss.runJobLoop(ctx, job)
+	// 启动任务协程，runJobLoop 中读取的停止通道在此取出，避免任务停止后被置空
+	stopChan := job.stopChan
+	ticker := job.ticker
+	ss.goWorker(func() { ss.runJobLoop(ctx, job, stopChan, ticker) })
 
 	logger.Info("启动集群 %s (ID: %d) 的调度任务，下次执行时间: %v", job.ClusterName, job.ClusterID, job.NextRun)
 }
@@ -417,16 +456,14 @@ func (ss *ScheduleService) stopSingleJob(job *ScheduleJob) {
 	logger.Info("停止集群 %s (ID: %d) 的调度任务", job.ClusterName, job.ClusterID)
 }
 
-func (ss *ScheduleService) runJobLoop(ctx context.Context, job *ScheduleJob) {
+func (ss *ScheduleService) runJobLoop(ctx context.Context, job *ScheduleJob, stopChan <-chan struct{}, ticker *time.Ticker) {
 	for {
 		select {
-		case <-job.stopChan:
-			return
-		case <-ss.stopChan:
+		case <-stopChan:
 			return
 		case <-ctx.Done():
 			return
-		case <-job.ticker.C:
+		case <-ticker.C:
 			ss.executeJob(ctx, job)
 		}
 	}
@@ -516,6 +553,11 @@ func (ss *ScheduleService) managementLoop(ctx context.Context) {
 	ticker := time.NewTicker(30 * time.Minute) // 每30分钟检查一次
 	defer ticker.Stop()
 
+	// 启动时立即检查分区，首次启用时完成分区表转换
+	if err := MaintainPartitions(); err != nil {
+		logger.Error("历史数据分区维护失败: %v", err)
+	}
+
 	for {
 		select {
 		case <-ss.stopChan:
@@ -536,6 +578,11 @@ func (ss *ScheduleService) performMaintenance(ctx context.Context) {
 		logger.Error("重新加载集群配置失败: %v", err)
 	}
 
+	// 预先创建未来的历史数据分区
+	if err := MaintainPartitions(); err != nil {
+		logger.Error("历史数据分区维护失败: %v", err)
+	}
+
 	// 执行自动化系统验证和维护
 	if err := ss.performSystemValidationAndMaintenance(ctx); err != nil {
 		logger.Error("系统验证和维护失败: %v", err)
@@ -633,13 +680,41 @@ func (ss *ScheduleService) performSystemValidationAndMaintenance(ctx context.Con
 			})
 	}
 
-	// 5. 历史数据清理（如果启用）
+	// 5. 建议采纳评估，需在清理原始数据之前完成，保证观察窗口内的采集数据仍然存在
+	if err := NewAdoptionService().EvaluateSnapshots(nil); err != nil {
+		logger.Error("评估资源建议采纳情况失败: %v", err)
+	}
+
+	// 6. 历史数据降采样与分层清理（如果启用），先汇总再删除原始数据，避免未汇总的数据被清理
 	if ss.globalSettings.EnablePersistence && ss.historyService != nil {
-		if err := ss.historyService.CleanupOldData(ctx, 30); err != nil {
+		rollupService := NewRollupService()
+		if err := rollupService.RunRollups(ctx); err != nil {
+			logger.Error("历史数据降采样失败: %v", err)
+		}
+		if err := ss.historyService.CleanupOldData(ctx, RawRetentionDays()); err != nil {
 			logger.Error("清理历史数据失败: %v", err)
 		} else {
 			logger.Info("历史数据清理完成")
 		}
+		if err := rollupService.CleanupExpiredRollups(); err != nil {
+			logger.Error("清理汇总数据失败: %v", err)
+		}
+	}
+
+	// 7. 容量快照清理，保留容量预测所需的历史窗口
+	historyDays, _, _, _ := forecastSettings()
+	if err := NewForecastService().CleanupOldSnapshots(historyDays); err != nil {
+		logger.Error("清理容量快照失败: %v", err)
+	}
+
+	// 8. 合规评分快照和已解决违规清理
+	if err := NewPolicyService().CleanupOldRecords(PolicyHistoryDays()); err != nil {
+		logger.Error("清理策略合规记录失败: %v", err)
+	}
+
+	// 9. 资源配置变更记录清理
+	if err := NewConfigChangeService().CleanupOldRecords(ConfigChangeRetentionDays()); err != nil {
+		logger.Error("清理资源配置变更记录失败: %v", err)
 	}
 
 	return nil
@@ -0,0 +1,899 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"cluster-resource-insight/internal/database"
+	"cluster-resource-insight/internal/historystore"
+	"cluster-resource-insight/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// 快照对比中的变化状态
+const (
+	DiffStatusAdded     = "added"
+	DiffStatusRemoved   = "removed"
+	DiffStatusChanged   = "changed"
+	DiffStatusUnchanged = "unchanged"
+)
+
+const (
+	// defaultSnapshotDiffTop 默认返回的使用量变化最大的工作负载数
+	defaultSnapshotDiffTop = 10
+	// maxSnapshotDiffTop 使用量变化排行的最大返回数
+	maxSnapshotDiffTop = 100
+	// snapshotDiffMaxItems 新增/删除的Pod和工作负载明细最多返回的条数，超出部分只计入统计
+	snapshotDiffMaxItems = 1000
+	// snapshotRunWindow 按批次查询样本的时间窗口，兼容数据库将采集时间截断到秒
+	snapshotRunWindow = time.Second
+	// capacitySnapshotWindow 容量快照在同一次采集中晚于历史数据写入，在该窗口内查找批次对应的节点数
+	capacitySnapshotWindow = 10 * time.Minute
+)
+
+// ErrSnapshotNotFound 未找到可用于对比的采集批次
+var ErrSnapshotNotFound = errors.New("未找到对应的采集批次")
+
+// SnapshotDiffRequest 采集快照对比请求，按时间或采集批次ID指定对比的两端
+type SnapshotDiffRequest struct {
+	ClusterID uint      `form:"cluster_id"` // 集群ID筛选，为0时对比所有集群
+	Namespace string    `form:"namespace"`  // 命名空间筛选
+	From      time.Time `form:"from"`       // 起点时间，取各集群在该时间及之前最近一次采集
+	To        time.Time `form:"to"`         // 终点时间
+	FromRun   uint      `form:"from_run"`   // 起点采集批次ID，需与 to_run 同时指定，指定后忽略时间参数
+	ToRun     uint      `form:"to_run"`     // 终点采集批次ID
+	Top       int       `form:"top"`        // 使用量变化排行返回的工作负载数
+}
+
+// ResourceTotals 一组Pod的资源合计，CPU单位为millicores，内存单位为字节
+type ResourceTotals struct {
+	PodCount      int   `json:"pod_count"`
+	CPURequest    int64 `json:"cpu_request"`
+	CPULimit      int64 `json:"cpu_limit"`
+	CPUUsage      int64 `json:"cpu_usage"`
+	MemoryRequest int64 `json:"memory_request"`
+	MemoryLimit   int64 `json:"memory_limit"`
+	MemoryUsage   int64 `json:"memory_usage"`
+}
+
+// TotalsChange 对比两端的资源合计及变化量
+type TotalsChange struct {
+	From  ResourceTotals `json:"from"`
+	To    ResourceTotals `json:"to"`
+	Delta ResourceTotals `json:"delta"`
+}
+
+// CountChange 数量变化
+type CountChange struct {
+	From  int `json:"from"`
+	To    int `json:"to"`
+	Delta int `json:"delta"`
+}
+
+// SnapshotChangeCounts 新增、删除和规格变化的对象数量
+type SnapshotChangeCounts struct {
+	AddedPods         int `json:"added_pods"`
+	RemovedPods       int `json:"removed_pods"`
+	AddedWorkloads    int `json:"added_workloads"`
+	RemovedWorkloads  int `json:"removed_workloads"`
+	SpecChanges       int `json:"spec_changes"`
+	AddedNamespaces   int `json:"added_namespaces"`
+	RemovedNamespaces int `json:"removed_namespaces"`
+}
+
+// SnapshotSide 对比一端实际使用的采集批次
+type SnapshotSide struct {
+	Time *time.Time             `json:"time,omitempty"` // 请求指定的时间，按批次对比时为空
+	Runs []models.CollectionRun `json:"runs"`
+}
+
+// ClusterSnapshotDiff 集群级变化汇总
+type ClusterSnapshotDiff struct {
+	ClusterID   uint         `json:"cluster_id"`
+	ClusterName string       `json:"cluster_name"`
+	Status      string       `json:"status"`               // changed/unchanged
+	NodeCount   *CountChange `json:"node_count,omitempty"` // 节点数变化，任一端缺少容量快照时为空
+	Totals      TotalsChange `json:"totals"`
+	SnapshotChangeCounts
+}
+
+// UnpairedCluster 只有一端存在采集批次的集群，无法判断其中的Pod是新增还是删除，不参与对比和合计
+type UnpairedCluster struct {
+	ClusterID   uint                 `json:"cluster_id"`
+	ClusterName string               `json:"cluster_name"`
+	Status      string               `json:"status"` // added 表示起点之前没有该集群的采集，removed 表示终点之前没有
+	Run         models.CollectionRun `json:"run"`    // 存在的那一端的采集批次
+}
+
+// NamespaceSnapshotDiff 命名空间级变化汇总
+type NamespaceSnapshotDiff struct {
+	ClusterID        uint         `json:"cluster_id"`
+	ClusterName      string       `json:"cluster_name"`
+	Namespace        string       `json:"namespace"`
+	Status           string       `json:"status"`
+	Totals           TotalsChange `json:"totals"`
+	AddedPods        int          `json:"added_pods"`
+	RemovedPods      int          `json:"removed_pods"`
+	AddedWorkloads   int          `json:"added_workloads"`
+	RemovedWorkloads int          `json:"removed_workloads"`
+	SpecChanges      int          `json:"spec_changes"`
+}
+
+// PodSnapshot 新增或删除的Pod
+type PodSnapshot struct {
+	ClusterID     uint   `json:"cluster_id"`
+	ClusterName   string `json:"cluster_name"`
+	Namespace     string `json:"namespace"`
+	PodName       string `json:"pod_name"`
+	WorkloadKind  string `json:"workload_kind"`
+	WorkloadName  string `json:"workload_name"`
+	NodeName      string `json:"node_name"`
+	CPURequest    int64  `json:"cpu_request"`
+	CPUUsage      int64  `json:"cpu_usage"`
+	MemoryRequest int64  `json:"memory_request"`
+	MemoryUsage   int64  `json:"memory_usage"`
+}
+
+// WorkloadSnapshot 新增或删除的工作负载
+type WorkloadSnapshot struct {
+	ClusterID    uint           `json:"cluster_id"`
+	ClusterName  string         `json:"cluster_name"`
+	Namespace    string         `json:"namespace"`
+	WorkloadKind string         `json:"workload_kind"`
+	WorkloadName string         `json:"workload_name"`
+	Totals       ResourceTotals `json:"totals"`
+}
+
+// SpecFieldChange 单项规格的变化，取工作负载内单Pod的最大值
+type SpecFieldChange struct {
+	Field string `json:"field"` // cpu_request/cpu_limit/memory_request/memory_limit
+	From  int64  `json:"from"`
+	To    int64  `json:"to"`
+}
+
+// WorkloadSpecChange 两端都存在且请求或限制发生变化的工作负载
+type WorkloadSpecChange struct {
+	ClusterID    uint              `json:"cluster_id"`
+	ClusterName  string            `json:"cluster_name"`
+	Namespace    string            `json:"namespace"`
+	WorkloadKind string            `json:"workload_kind"`
+	WorkloadName string            `json:"workload_name"`
+	PodCount     CountChange       `json:"pod_count"`
+	Changes      []SpecFieldChange `json:"changes"`
+}
+
+// WorkloadUsageMover 使用量变化排行中的工作负载，新增和删除的工作负载按另一端为0计算
+type WorkloadUsageMover struct {
+	ClusterID        uint        `json:"cluster_id"`
+	ClusterName      string      `json:"cluster_name"`
+	Namespace        string      `json:"namespace"`
+	WorkloadKind     string      `json:"workload_kind"`
+	WorkloadName     string      `json:"workload_name"`
+	Status           string      `json:"status"`
+	PodCount         CountChange `json:"pod_count"`
+	CPUUsageFrom     int64       `json:"cpu_usage_from"`
+	CPUUsageTo       int64       `json:"cpu_usage_to"`
+	CPUUsageDelta    int64       `json:"cpu_usage_delta"`
+	MemoryUsageFrom  int64       `json:"memory_usage_from"`
+	MemoryUsageTo    int64       `json:"memory_usage_to"`
+	MemoryUsageDelta int64       `json:"memory_usage_delta"`
+}
+
+// SnapshotDiff 两次采集之间的结构化变更集
+type SnapshotDiff struct {
+	From             SnapshotSide            `json:"from"`
+	To               SnapshotSide            `json:"to"`
+	Totals           TotalsChange            `json:"totals"`
+	Changes          SnapshotChangeCounts    `json:"changes"`
+	Clusters         []ClusterSnapshotDiff   `json:"clusters"`
+	UnpairedClusters []UnpairedCluster       `json:"unpaired_clusters"`
+	Namespaces       []NamespaceSnapshotDiff `json:"namespaces"`
+	AddedPods        []PodSnapshot           `json:"added_pods"`
+	RemovedPods      []PodSnapshot           `json:"removed_pods"`
+	AddedWorkloads   []WorkloadSnapshot      `json:"added_workloads"`
+	RemovedWorkloads []WorkloadSnapshot      `json:"removed_workloads"`
+	SpecChanges      []WorkloadSpecChange    `json:"spec_changes"`
+	TopCPUMovers     []WorkloadUsageMover    `json:"top_cpu_movers"`
+	TopMemoryMovers  []WorkloadUsageMover    `json:"top_memory_movers"`
+	Truncated        bool                    `json:"truncated"` // 明细列表是否因超过 snapshotDiffMaxItems 被截断
+	GeneratedAt      time.Time               `json:"generated_at"`
+}
+
+// SnapshotDiffService 采集快照对比服务 - 基于历史数据计算两次采集之间的Pod、工作负载、规格和使用量变化
+type SnapshotDiffService struct {
+	db *gorm.DB
+}
+
+// NewSnapshotDiffService 创建采集快照对比服务实例
+func NewSnapshotDiffService() *SnapshotDiffService {
+	return &SnapshotDiffService{
+		db: database.GetDB(),
+	}
+}
+
+// snapshotPodKey Pod唯一标识
+type snapshotPodKey struct {
+	clusterID uint
+	namespace string
+	podName   string
+}
+
+// snapshotWorkloadKey 工作负载唯一标识，独立Pod的工作负载类型为Pod、名称为Pod名称
+type snapshotWorkloadKey struct {
+	clusterID uint
+	namespace string
+	kind      string
+	name      string
+}
+
+// snapshotNamespaceKey 命名空间唯一标识
+type snapshotNamespaceKey struct {
+	clusterID uint
+	namespace string
+}
+
+// workloadState 工作负载在一端的资源合计和单Pod最大规格
+type workloadState struct {
+	totals        ResourceTotals
+	cpuRequest    int64
+	cpuLimit      int64
+	memoryRequest int64
+	memoryLimit   int64
+}
+
+// snapshotState 一端采集数据按Pod、工作负载和命名空间的索引
+type snapshotState struct {
+	pods       map[snapshotPodKey]models.PodMetricsHistory
+	workloads  map[snapshotWorkloadKey]*workloadState
+	namespaces map[snapshotNamespaceKey]*ResourceTotals
+	clusters   map[uint]*ResourceTotals
+}
+
+// Validate 校验请求参数，未指定排行数量时使用默认值
+func (sd *SnapshotDiffService) Validate(req *SnapshotDiffRequest) error {
+	if (req.FromRun > 0) != (req.ToRun > 0) {
+		return fmt.Errorf("from_run 和 to_run 需同时指定")
+	}
+	if req.FromRun == 0 {
+		if req.From.IsZero() || req.To.IsZero() || !req.From.Before(req.To) {
+			return fmt.Errorf("必须指定 from 和 to 时间且 from 早于 to，或同时指定 from_run 和 to_run")
+		}
+	}
+	if req.Top <= 0 {
+		req.Top = defaultSnapshotDiffTop
+	}
+	if req.Top > maxSnapshotDiffTop {
+		req.Top = maxSnapshotDiffTop
+	}
+	return nil
+}
+
+// Diff 计算两次采集之间的变更集
+// 按时间对比时，每个集群取指定时间及之前最近一次采集；按批次对比时两个批次需属于同一集群
+// 只有一端存在采集批次的集群单独列出，不参与Pod、工作负载和命名空间的对比
+func (sd *SnapshotDiffService) Diff(ctx context.Context, req SnapshotDiffRequest) (*SnapshotDiff, error) {
+	fromRuns, toRuns, err := sd.resolveRuns(req)
+	if err != nil {
+		return nil, err
+	}
+	fromRuns, toRuns, unpaired := splitUnpairedRuns(fromRuns, toRuns)
+
+	result := &SnapshotDiff{
+		From:             SnapshotSide{Runs: sortedRuns(fromRuns)},
+		To:               SnapshotSide{Runs: sortedRuns(toRuns)},
+		Clusters:         []ClusterSnapshotDiff{},
+		UnpairedClusters: []UnpairedCluster{},
+		Namespaces:       []NamespaceSnapshotDiff{},
+		AddedPods:        []PodSnapshot{},
+		RemovedPods:      []PodSnapshot{},
+		AddedWorkloads:   []WorkloadSnapshot{},
+		RemovedWorkloads: []WorkloadSnapshot{},
+		SpecChanges:      []WorkloadSpecChange{},
+		GeneratedAt:      time.Now(),
+	}
+	if req.FromRun == 0 {
+		from, to := req.From, req.To
+		result.From.Time = &from
+		result.To.Time = &to
+	}
+
+	from, err := sd.loadSnapshot(ctx, fromRuns, req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	to, err := sd.loadSnapshot(ctx, toRuns, req.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterIDs := make([]uint, 0, len(fromRuns))
+	for id := range fromRuns {
+		clusterIDs = append(clusterIDs, id)
+	}
+	sort.Slice(clusterIDs, func(i, j int) bool { return clusterIDs[i] < clusterIDs[j] })
+	namedIDs := append([]uint{}, clusterIDs...)
+	for _, cluster := range unpaired {
+		namedIDs = append(namedIDs, cluster.ClusterID)
+	}
+	clusterNames := sd.clusterNames(namedIDs)
+	for _, cluster := range unpaired {
+		cluster.ClusterName = clusterNames[cluster.ClusterID]
+		result.UnpairedClusters = append(result.UnpairedClusters, cluster)
+	}
+
+	clusterCounts := make(map[uint]*SnapshotChangeCounts, len(clusterIDs))
+	namespaceDiffs := make(map[snapshotNamespaceKey]*NamespaceSnapshotDiff)
+	namespaceDiff := func(key snapshotNamespaceKey) *NamespaceSnapshotDiff {
+		if diff, ok := namespaceDiffs[key]; ok {
+			return diff
+		}
+		diff := &NamespaceSnapshotDiff{ClusterID: key.clusterID, ClusterName: clusterNames[key.clusterID], Namespace: key.namespace}
+		namespaceDiffs[key] = diff
+		return diff
+	}
+	for _, id := range clusterIDs {
+		clusterCounts[id] = &SnapshotChangeCounts{}
+	}
+
+	// Pod新增和删除
+	for key, pod := range to.pods {
+		if _, ok := from.pods[key]; !ok {
+			clusterCounts[key.clusterID].AddedPods++
+			namespaceDiff(snapshotNamespaceKey{key.clusterID, key.namespace}).AddedPods++
+			result.AddedPods = append(result.AddedPods, newPodSnapshot(pod, clusterNames[key.clusterID]))
+		}
+	}
+	for key, pod := range from.pods {
+		if _, ok := to.pods[key]; !ok {
+			clusterCounts[key.clusterID].RemovedPods++
+			namespaceDiff(snapshotNamespaceKey{key.clusterID, key.namespace}).RemovedPods++
+			result.RemovedPods = append(result.RemovedPods, newPodSnapshot(pod, clusterNames[key.clusterID]))
+		}
+	}
+
+	// 工作负载新增、删除、规格变化和使用量变化
+	var movers []WorkloadUsageMover
+	for key, state := range to.workloads {
+		namespaceKey := snapshotNamespaceKey{key.clusterID, key.namespace}
+		previous, ok := from.workloads[key]
+		if !ok {
+			clusterCounts[key.clusterID].AddedWorkloads++
+			namespaceDiff(namespaceKey).AddedWorkloads++
+			result.AddedWorkloads = append(result.AddedWorkloads, newWorkloadSnapshot(key, state, clusterNames[key.clusterID]))
+			movers = append(movers, newUsageMover(key, nil, state, clusterNames[key.clusterID]))
+			continue
+		}
+		if changes := specFieldChanges(previous, state); len(changes) > 0 {
+			clusterCounts[key.clusterID].SpecChanges++
+			namespaceDiff(namespaceKey).SpecChanges++
+			result.SpecChanges = append(result.SpecChanges, WorkloadSpecChange{
+				ClusterID:    key.clusterID,
+				ClusterName:  clusterNames[key.clusterID],
+				Namespace:    key.namespace,
+				WorkloadKind: key.kind,
+				WorkloadName: key.name,
+				PodCount:     newCountChange(previous.totals.PodCount, state.totals.PodCount),
+				Changes:      changes,
+			})
+		}
+		movers = append(movers, newUsageMover(key, previous, state, clusterNames[key.clusterID]))
+	}
+	for key, state := range from.workloads {
+		if _, ok := to.workloads[key]; !ok {
+			clusterCounts[key.clusterID].RemovedWorkloads++
+			namespaceDiff(snapshotNamespaceKey{key.clusterID, key.namespace}).RemovedWorkloads++
+			result.RemovedWorkloads = append(result.RemovedWorkloads, newWorkloadSnapshot(key, state, clusterNames[key.clusterID]))
+			movers = append(movers, newUsageMover(key, state, nil, clusterNames[key.clusterID]))
+		}
+	}
+
+	// 命名空间合计
+	for key := range to.namespaces {
+		namespaceDiff(key)
+	}
+	for key := range from.namespaces {
+		namespaceDiff(key)
+	}
+	for key, diff := range namespaceDiffs {
+		diff.Totals = newTotalsChange(from.namespaces[key], to.namespaces[key])
+		diff.Status = diffStatus(from.namespaces[key] != nil, to.namespaces[key] != nil,
+			diff.Totals.Delta != ResourceTotals{} || diff.AddedPods > 0 || diff.RemovedPods > 0 || diff.SpecChanges > 0)
+		switch diff.Status {
+		case DiffStatusAdded:
+			clusterCounts[key.clusterID].AddedNamespaces++
+		case DiffStatusRemoved:
+			clusterCounts[key.clusterID].RemovedNamespaces++
+		}
+		result.Namespaces = append(result.Namespaces, *diff)
+	}
+
+	// 集群合计和节点数变化
+	var fromTotal, toTotal ResourceTotals
+	for _, id := range clusterIDs {
+		counts := clusterCounts[id]
+		clusterDiff := ClusterSnapshotDiff{
+			ClusterID:            id,
+			ClusterName:          clusterNames[id],
+			Totals:               newTotalsChange(from.clusters[id], to.clusters[id]),
+			SnapshotChangeCounts: *counts,
+		}
+
+		fromNodes, fromOK := sd.nodeCount(fromRuns[id])
+		toNodes, toOK := sd.nodeCount(toRuns[id])
+		if fromOK && toOK {
+			nodeCount := newCountChange(fromNodes, toNodes)
+			clusterDiff.NodeCount = &nodeCount
+		}
+		changed := clusterDiff.Totals.Delta != ResourceTotals{} || *counts != SnapshotChangeCounts{} ||
+			(clusterDiff.NodeCount != nil && clusterDiff.NodeCount.Delta != 0)
+		clusterDiff.Status = diffStatus(true, true, changed)
+		result.Clusters = append(result.Clusters, clusterDiff)
+
+		addChangeCounts(&result.Changes, *counts)
+		fromTotal = addResourceTotals(fromTotal, clusterDiff.Totals.From)
+		toTotal = addResourceTotals(toTotal, clusterDiff.Totals.To)
+	}
+	result.Totals = newTotalsChange(&fromTotal, &toTotal)
+
+	sortSnapshotDiff(result)
+	result.TopCPUMovers = topUsageMovers(movers, req.Top, func(m WorkloadUsageMover) int64 { return m.CPUUsageDelta })
+	result.TopMemoryMovers = topUsageMovers(movers, req.Top, func(m WorkloadUsageMover) int64 { return m.MemoryUsageDelta })
+
+	// 明细列表截断，统计数量不受影响
+	if len(result.AddedPods) > snapshotDiffMaxItems {
+		result.AddedPods, result.Truncated = result.AddedPods[:snapshotDiffMaxItems], true
+	}
+	if len(result.RemovedPods) > snapshotDiffMaxItems {
+		result.RemovedPods, result.Truncated = result.RemovedPods[:snapshotDiffMaxItems], true
+	}
+	if len(result.AddedWorkloads) > snapshotDiffMaxItems {
+		result.AddedWorkloads, result.Truncated = result.AddedWorkloads[:snapshotDiffMaxItems], true
+	}
+	if len(result.RemovedWorkloads) > snapshotDiffMaxItems {
+		result.RemovedWorkloads, result.Truncated = result.RemovedWorkloads[:snapshotDiffMaxItems], true
+	}
+	if len(result.SpecChanges) > snapshotDiffMaxItems {
+		result.SpecChanges, result.Truncated = result.SpecChanges[:snapshotDiffMaxItems], true
+	}
+
+	return result, nil
+}
+
+// ListRuns 查询时间范围内的采集批次，按采集时间倒序返回
+func (sd *SnapshotDiffService) ListRuns(clusterID uint, start, end time.Time, limit int) ([]models.CollectionRun, error) {
+	query := sd.db.Model(&models.CollectionRun{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if !start.IsZero() {
+		query = query.Where("collected_at >= ?", start)
+	}
+	if !end.IsZero() {
+		query = query.Where("collected_at <= ?", end)
+	}
+
+	var runs []models.CollectionRun
+	if err := query.Order("collected_at DESC").Limit(limit).Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("查询采集批次失败: %v", err)
+	}
+	return runs, nil
+}
+
+// resolveRuns 确定对比两端每个集群使用的采集批次
+func (sd *SnapshotDiffService) resolveRuns(req SnapshotDiffRequest) (map[uint]models.CollectionRun, map[uint]models.CollectionRun, error) {
+	if req.FromRun > 0 {
+		var runs []models.CollectionRun
+		if err := sd.db.Where("id IN ?", []uint{req.FromRun, req.ToRun}).Find(&runs).Error; err != nil {
+			return nil, nil, fmt.Errorf("查询采集批次失败: %v", err)
+		}
+		var fromRun, toRun *models.CollectionRun
+		for i := range runs {
+			if runs[i].ID == req.FromRun {
+				fromRun = &runs[i]
+			}
+			if runs[i].ID == req.ToRun {
+				toRun = &runs[i]
+			}
+		}
+		if fromRun == nil || toRun == nil {
+			return nil, nil, ErrSnapshotNotFound
+		}
+		if fromRun.ClusterID != toRun.ClusterID {
+			return nil, nil, fmt.Errorf("采集批次 %d 和 %d 不属于同一集群", fromRun.ID, toRun.ID)
+		}
+		if req.ClusterID > 0 && fromRun.ClusterID != req.ClusterID {
+			return nil, nil, fmt.Errorf("采集批次不属于集群 %d", req.ClusterID)
+		}
+		if !fromRun.CollectedAt.Before(toRun.CollectedAt) {
+			return nil, nil, fmt.Errorf("起点批次的采集时间需早于终点批次")
+		}
+		return map[uint]models.CollectionRun{fromRun.ClusterID: *fromRun},
+			map[uint]models.CollectionRun{toRun.ClusterID: *toRun}, nil
+	}
+
+	fromRuns, err := sd.runsAt(req.From, req.ClusterID)
+	if err != nil {
+		return nil, nil, err
+	}
+	toRuns, err := sd.runsAt(req.To, req.ClusterID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(fromRuns) == 0 || len(toRuns) == 0 {
+		return nil, nil, ErrSnapshotNotFound
+	}
+	return fromRuns, toRuns, nil
+}
+
+// splitUnpairedRuns 拆出只有一端存在采集批次的集群，返回两端都有批次的集群及按集群ID排序的单端集群
+func splitUnpairedRuns(fromRuns, toRuns map[uint]models.CollectionRun) (map[uint]models.CollectionRun, map[uint]models.CollectionRun, []UnpairedCluster) {
+	pairedFrom := make(map[uint]models.CollectionRun, len(fromRuns))
+	pairedTo := make(map[uint]models.CollectionRun, len(toRuns))
+	var unpaired []UnpairedCluster
+	for id, run := range fromRuns {
+		if toRun, ok := toRuns[id]; ok {
+			pairedFrom[id], pairedTo[id] = run, toRun
+			continue
+		}
+		unpaired = append(unpaired, UnpairedCluster{ClusterID: id, Status: DiffStatusRemoved, Run: run})
+	}
+	for id, run := range toRuns {
+		if _, ok := fromRuns[id]; !ok {
+			unpaired = append(unpaired, UnpairedCluster{ClusterID: id, Status: DiffStatusAdded, Run: run})
+		}
+	}
+	sort.Slice(unpaired, func(i, j int) bool { return unpaired[i].ClusterID < unpaired[j].ClusterID })
+	return pairedFrom, pairedTo, unpaired
+}
+
+// runsAt 查询各集群在指定时间及之前最近一次采集批次
+func (sd *SnapshotDiffService) runsAt(at time.Time, clusterID uint) (map[uint]models.CollectionRun, error) {
+	query := sd.db.Model(&models.CollectionRun{}).Where("collected_at <= ?", at)
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	var clusterIDs []uint
+	if err := query.Distinct("cluster_id").Pluck("cluster_id", &clusterIDs).Error; err != nil {
+		return nil, fmt.Errorf("查询采集批次失败: %v", err)
+	}
+
+	runs := make(map[uint]models.CollectionRun, len(clusterIDs))
+	for _, id := range clusterIDs {
+		var run models.CollectionRun
+		if err := sd.db.Where("cluster_id = ? AND collected_at <= ?", id, at).
+			Order("collected_at DESC").Limit(1).Find(&run).Error; err != nil {
+			return nil, fmt.Errorf("查询采集批次失败: %v", err)
+		}
+		runs[id] = run
+	}
+	return runs, nil
+}
+
+// loadSnapshot 读取各集群采集批次的样本，按Pod、工作负载、命名空间和集群建立索引
+func (sd *SnapshotDiffService) loadSnapshot(ctx context.Context, runs map[uint]models.CollectionRun, namespace string) (*snapshotState, error) {
+	state := &snapshotState{
+		pods:       make(map[snapshotPodKey]models.PodMetricsHistory),
+		workloads:  make(map[snapshotWorkloadKey]*workloadState),
+		namespaces: make(map[snapshotNamespaceKey]*ResourceTotals),
+		clusters:   make(map[uint]*ResourceTotals),
+	}
+
+	store := historystore.GetHistoryStore()
+	for clusterID, run := range runs {
+		records, err := store.Query(ctx, historystore.RangeQuery{
+			Filter: historystore.Filter{ClusterID: clusterID, Namespace: namespace},
+			Start:  run.CollectedAt,
+			End:    run.CollectedAt.Add(snapshotRunWindow),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("查询采集批次 %d 的样本失败: %v", run.ID, err)
+		}
+
+		state.clusters[clusterID] = &ResourceTotals{}
+		for _, record := range records {
+			podKey := snapshotPodKey{clusterID, record.Namespace, record.PodName}
+			if _, ok := state.pods[podKey]; ok {
+				continue
+			}
+			state.pods[podKey] = record
+
+			workloadKey := snapshotWorkloadKey{clusterID, record.Namespace, record.WorkloadKind, record.WorkloadName}
+			if record.WorkloadName == "" {
+				workloadKey.kind, workloadKey.name = "Pod", record.PodName
+			}
+			workload, ok := state.workloads[workloadKey]
+			if !ok {
+				workload = &workloadState{}
+				state.workloads[workloadKey] = workload
+			}
+			addRecordTotals(&workload.totals, record)
+			workload.cpuRequest = max(workload.cpuRequest, record.CPURequest)
+			workload.cpuLimit = max(workload.cpuLimit, record.CPULimit)
+			workload.memoryRequest = max(workload.memoryRequest, record.MemoryRequest)
+			workload.memoryLimit = max(workload.memoryLimit, record.MemoryLimit)
+
+			namespaceKey := snapshotNamespaceKey{clusterID, record.Namespace}
+			if state.namespaces[namespaceKey] == nil {
+				state.namespaces[namespaceKey] = &ResourceTotals{}
+			}
+			addRecordTotals(state.namespaces[namespaceKey], record)
+			addRecordTotals(state.clusters[clusterID], record)
+		}
+	}
+	return state, nil
+}
+
+// nodeCount 查询采集批次对应的容量快照中的节点数
+func (sd *SnapshotDiffService) nodeCount(run models.CollectionRun) (int, bool) {
+	var snapshots []models.ClusterCapacitySnapshot
+	err := sd.db.Where("cluster_id = ? AND collected_at >= ? AND collected_at < ?",
+		run.ClusterID, run.CollectedAt, run.CollectedAt.Add(capacitySnapshotWindow)).
+		Order("collected_at ASC").Limit(1).Find(&snapshots).Error
+	if err != nil || len(snapshots) == 0 {
+		return 0, false
+	}
+	return snapshots[0].NodeCount, true
+}
+
+// clusterNames 查询集群名称，已删除的集群同样返回名称
+func (sd *SnapshotDiffService) clusterNames(clusterIDs []uint) map[uint]string {
+	names := make(map[uint]string, len(clusterIDs))
+	if len(clusterIDs) == 0 {
+		return names
+	}
+	var clusters []models.ClusterConfig
+	if err := sd.db.Unscoped().Where("id IN ?", clusterIDs).Find(&clusters).Error; err == nil {
+		for _, cluster := range clusters {
+			names[cluster.ID] = cluster.ClusterName
+		}
+	}
+	return names
+}
+
+// sortedRuns 按集群ID排序采集批次
+func sortedRuns(runs map[uint]models.CollectionRun) []models.CollectionRun {
+	sorted := make([]models.CollectionRun, 0, len(runs))
+	for _, run := range runs {
+		sorted = append(sorted, run)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ClusterID < sorted[j].ClusterID })
+	return sorted
+}
+
+// addRecordTotals 将一条样本计入资源合计
+func addRecordTotals(totals *ResourceTotals, record models.PodMetricsHistory) {
+	totals.PodCount++
+	totals.CPURequest += record.CPURequest
+	totals.CPULimit += record.CPULimit
+	totals.CPUUsage += record.CPUUsage
+	totals.MemoryRequest += record.MemoryRequest
+	totals.MemoryLimit += record.MemoryLimit
+	totals.MemoryUsage += record.MemoryUsage
+}
+
+// addResourceTotals 两组资源合计相加
+func addResourceTotals(a, b ResourceTotals) ResourceTotals {
+	return ResourceTotals{
+		PodCount:      a.PodCount + b.PodCount,
+		CPURequest:    a.CPURequest + b.CPURequest,
+		CPULimit:      a.CPULimit + b.CPULimit,
+		CPUUsage:      a.CPUUsage + b.CPUUsage,
+		MemoryRequest: a.MemoryRequest + b.MemoryRequest,
+		MemoryLimit:   a.MemoryLimit + b.MemoryLimit,
+		MemoryUsage:   a.MemoryUsage + b.MemoryUsage,
+	}
+}
+
+// newTotalsChange 计算资源合计的变化，缺失的一端按0计算
+func newTotalsChange(from, to *ResourceTotals) TotalsChange {
+	var change TotalsChange
+	if from != nil {
+		change.From = *from
+	}
+	if to != nil {
+		change.To = *to
+	}
+	change.Delta = ResourceTotals{
+		PodCount:      change.To.PodCount - change.From.PodCount,
+		CPURequest:    change.To.CPURequest - change.From.CPURequest,
+		CPULimit:      change.To.CPULimit - change.From.CPULimit,
+		CPUUsage:      change.To.CPUUsage - change.From.CPUUsage,
+		MemoryRequest: change.To.MemoryRequest - change.From.MemoryRequest,
+		MemoryLimit:   change.To.MemoryLimit - change.From.MemoryLimit,
+		MemoryUsage:   change.To.MemoryUsage - change.From.MemoryUsage,
+	}
+	return change
+}
+
+// newCountChange 计算数量变化
+func newCountChange(from, to int) CountChange {
+	return CountChange{From: from, To: to, Delta: to - from}
+}
+
+// addChangeCounts 累加变化数量
+func addChangeCounts(total *SnapshotChangeCounts, counts SnapshotChangeCounts) {
+	total.AddedPods += counts.AddedPods
+	total.RemovedPods += counts.RemovedPods
+	total.AddedWorkloads += counts.AddedWorkloads
+	total.RemovedWorkloads += counts.RemovedWorkloads
+	total.SpecChanges += counts.SpecChanges
+	total.AddedNamespaces += counts.AddedNamespaces
+	total.RemovedNamespaces += counts.RemovedNamespaces
+}
+
+// diffStatus 根据两端是否存在和是否有变化确定状态
+func diffStatus(inFrom, inTo, changed bool) string {
+	switch {
+	case !inFrom:
+		return DiffStatusAdded
+	case !inTo:
+		return DiffStatusRemoved
+	case changed:
+		return DiffStatusChanged
+	default:
+		return DiffStatusUnchanged
+	}
+}
+
+// specFieldChanges 比较工作负载两端的单Pod最大请求和限制
+func specFieldChanges(from, to *workloadState) []SpecFieldChange {
+	var changes []SpecFieldChange
+	fields := []struct {
+		name     string
+		from, to int64
+	}{
+		{"cpu_request", from.cpuRequest, to.cpuRequest},
+		{"cpu_limit", from.cpuLimit, to.cpuLimit},
+		{"memory_request", from.memoryRequest, to.memoryRequest},
+		{"memory_limit", from.memoryLimit, to.memoryLimit},
+	}
+	for _, field := range fields {
+		if field.from != field.to {
+			changes = append(changes, SpecFieldChange{Field: field.name, From: field.from, To: field.to})
+		}
+	}
+	return changes
+}
+
+// newPodSnapshot 由样本构造Pod明细
+func newPodSnapshot(record models.PodMetricsHistory, clusterName string) PodSnapshot {
+	return PodSnapshot{
+		ClusterID:     record.ClusterID,
+		ClusterName:   clusterName,
+		Namespace:     record.Namespace,
+		PodName:       record.PodName,
+		WorkloadKind:  record.WorkloadKind,
+		WorkloadName:  record.WorkloadName,
+		NodeName:      record.NodeName,
+		CPURequest:    record.CPURequest,
+		CPUUsage:      record.CPUUsage,
+		MemoryRequest: record.MemoryRequest,
+		MemoryUsage:   record.MemoryUsage,
+	}
+}
+
+// newWorkloadSnapshot 构造工作负载明细
+func newWorkloadSnapshot(key snapshotWorkloadKey, state *workloadState, clusterName string) WorkloadSnapshot {
+	return WorkloadSnapshot{
+		ClusterID:    key.clusterID,
+		ClusterName:  clusterName,
+		Namespace:    key.namespace,
+		WorkloadKind: key.kind,
+		WorkloadName: key.name,
+		Totals:       state.totals,
+	}
+}
+
+// newUsageMover 构造工作负载使用量变化，缺失的一端为nil
+func newUsageMover(key snapshotWorkloadKey, from, to *workloadState, clusterName string) WorkloadUsageMover {
+	var fromTotals, toTotals ResourceTotals
+	if from != nil {
+		fromTotals = from.totals
+	}
+	if to != nil {
+		toTotals = to.totals
+	}
+	return WorkloadUsageMover{
+		ClusterID:        key.clusterID,
+		ClusterName:      clusterName,
+		Namespace:        key.namespace,
+		WorkloadKind:     key.kind,
+		WorkloadName:     key.name,
+		Status:           diffStatus(from != nil, to != nil, fromTotals != toTotals),
+		PodCount:         newCountChange(fromTotals.PodCount, toTotals.PodCount),
+		CPUUsageFrom:     fromTotals.CPUUsage,
+		CPUUsageTo:       toTotals.CPUUsage,
+		CPUUsageDelta:    toTotals.CPUUsage - fromTotals.CPUUsage,
+		MemoryUsageFrom:  fromTotals.MemoryUsage,
+		MemoryUsageTo:    toTotals.MemoryUsage,
+		MemoryUsageDelta: toTotals.MemoryUsage - fromTotals.MemoryUsage,
+	}
+}
+
+// topUsageMovers 按使用量变化的绝对值取前 top 个工作负载，忽略没有变化的工作负载
+func topUsageMovers(movers []WorkloadUsageMover, top int, delta func(WorkloadUsageMover) int64) []WorkloadUsageMover {
+	abs := func(v int64) int64 {
+		if v < 0 {
+			return -v
+		}
+		return v
+	}
+
+	ranked := make([]WorkloadUsageMover, 0, len(movers))
+	for _, mover := range movers {
+		if delta(mover) != 0 {
+			ranked = append(ranked, mover)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if abs(delta(ranked[i])) != abs(delta(ranked[j])) {
+			return abs(delta(ranked[i])) > abs(delta(ranked[j]))
+		}
+		a, b := ranked[i], ranked[j]
+		return snapshotWorkloadKey{a.ClusterID, a.Namespace, a.WorkloadKind, a.WorkloadName}.
+			less(snapshotWorkloadKey{b.ClusterID, b.Namespace, b.WorkloadKind, b.WorkloadName})
+	})
+	if len(ranked) > top {
+		ranked = ranked[:top]
+	}
+	return ranked
+}
+
+// less 按集群、命名空间、工作负载类型和名称排序
+func (k snapshotWorkloadKey) less(other snapshotWorkloadKey) bool {
+	if k.clusterID != other.clusterID {
+		return k.clusterID < other.clusterID
+	}
+	if k.namespace != other.namespace {
+		return k.namespace < other.namespace
+	}
+	if k.kind != other.kind {
+		return k.kind < other.kind
+	}
+	return k.name < other.name
+}
+
+// sortSnapshotDiff 对明细列表按集群、命名空间和名称排序，保证结果稳定
+func sortSnapshotDiff(result *SnapshotDiff) {
+	sort.Slice(result.Namespaces, func(i, j int) bool {
+		a, b := result.Namespaces[i], result.Namespaces[j]
+		if a.ClusterID != b.ClusterID {
+			return a.ClusterID < b.ClusterID
+		}
+		return a.Namespace < b.Namespace
+	})
+	podLess := func(pods []PodSnapshot) func(i, j int) bool {
+		return func(i, j int) bool {
+			a, b := pods[i], pods[j]
+			if a.ClusterID != b.ClusterID {
+				return a.ClusterID < b.ClusterID
+			}
+			if a.Namespace != b.Namespace {
+				return a.Namespace < b.Namespace
+			}
+			return a.PodName < b.PodName
+		}
+	}
+	sort.Slice(result.AddedPods, podLess(result.AddedPods))
+	sort.Slice(result.RemovedPods, podLess(result.RemovedPods))
+
+	workloadLess := func(workloads []WorkloadSnapshot) func(i, j int) bool {
+		return func(i, j int) bool {
+			a, b := workloads[i], workloads[j]
+			return snapshotWorkloadKey{a.ClusterID, a.Namespace, a.WorkloadKind, a.WorkloadName}.
+				less(snapshotWorkloadKey{b.ClusterID, b.Namespace, b.WorkloadKind, b.WorkloadName})
+		}
+	}
+	sort.Slice(result.AddedWorkloads, workloadLess(result.AddedWorkloads))
+	sort.Slice(result.RemovedWorkloads, workloadLess(result.RemovedWorkloads))
+
+	sort.Slice(result.SpecChanges, func(i, j int) bool {
+		a, b := result.SpecChanges[i], result.SpecChanges[j]
+		return snapshotWorkloadKey{a.ClusterID, a.Namespace, a.WorkloadKind, a.WorkloadName}.
+			less(snapshotWorkloadKey{b.ClusterID, b.Namespace, b.WorkloadKind, b.WorkloadName})
+	})
+}
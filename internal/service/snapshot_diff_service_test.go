@@ -0,0 +1,475 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"cluster-resource-insight/internal/database"
+	"cluster-resource-insight/internal/historystore"
+	"cluster-resource-insight/internal/models"
+)
+
+// snapshotBase 起点批次的采集时间，终点批次晚10分钟
+var snapshotBase = time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+
+// snapshotPod 构造快照对比测试样本，使用量小于0表示该项指标缺失，内存单位为MiB
+func snapshotPod(clusterID uint, namespace, pod, workload string, cpuRequest, cpuUsage, memoryUsageMiB int64) models.PodMetricsHistory {
+	record := models.PodMetricsHistory{
+		ClusterID:     clusterID,
+		Namespace:     namespace,
+		PodName:       pod,
+		NodeName:      "node-a",
+		WorkloadKind:  "Deployment",
+		WorkloadName:  workload,
+		CPURequest:    cpuRequest,
+		CPULimit:      1000,
+		MemoryRequest: 256 << 20,
+		MemoryLimit:   512 << 20,
+	}
+	if workload == "" {
+		record.WorkloadKind = ""
+	}
+	if cpuUsage >= 0 {
+		record.CPUUsage, record.CPUMetricsAvailable = cpuUsage, true
+	}
+	if memoryUsageMiB >= 0 {
+		record.MemoryUsage, record.MemoryMetricsAvailable = memoryUsageMiB<<20, true
+	}
+	record.MetricsAvailable = record.CPUMetricsAvailable && record.MemoryMetricsAvailable
+	return record
+}
+
+// openTestDB 初始化带有两个集群的SQLite测试数据库，历史数据使用默认的SQL存储
+func openTestDB(t *testing.T) {
+	t.Helper()
+	if err := database.InitDatabase(&database.DatabaseConfig{Driver: database.DriverSQLite, Path: filepath.Join(t.TempDir(), "test.db")}); err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	t.Cleanup(func() { database.CloseDatabase() })
+	if err := database.CheckAndAutoMigrate(); err != nil {
+		t.Fatalf("数据库迁移失败: %v", err)
+	}
+	for _, id := range []uint{1, 2} {
+		cluster := models.ClusterConfig{ID: id, ClusterName: fmt.Sprintf("cluster-%d", id), APIServer: "https://127.0.0.1"}
+		if err := database.GetDB().Create(&cluster).Error; err != nil {
+			t.Fatalf("创建集群失败: %v", err)
+		}
+	}
+}
+
+// writeSnapshot 按集群写入一个采集批次的样本和批次记录
+func writeSnapshot(t *testing.T, at time.Time, records []models.PodMetricsHistory) {
+	t.Helper()
+	byCluster := make(map[uint][]models.PodMetricsHistory)
+	for _, record := range records {
+		record.CollectedAt = at
+		byCluster[record.ClusterID] = append(byCluster[record.ClusterID], record)
+	}
+	for clusterID, batch := range byCluster {
+		if err := historystore.GetHistoryStore().Write(context.Background(), batch); err != nil {
+			t.Fatalf("写入样本失败: %v", err)
+		}
+		run := models.CollectionRun{ClusterID: clusterID, PodCount: len(batch), CollectedAt: at}
+		if err := database.GetDB().Create(&run).Error; err != nil {
+			t.Fatalf("创建采集批次失败: %v", err)
+		}
+	}
+}
+
+func TestSnapshotDiff(t *testing.T) {
+	web1 := snapshotPod(1, "default", "web-1", "web", 500, 200, 100)
+
+	tests := []struct {
+		name             string
+		from, to         []models.PodMetricsHistory
+		wantChanges      SnapshotChangeCounts
+		wantStatus       string   // 集群1的变化状态
+		wantAddedPods    []string // 新增Pod名称
+		wantRemovedPods  []string // 删除Pod名称
+		wantAddedLoads   []string // 新增工作负载，格式为 类型/名称
+		wantSpecChanges  []SpecFieldChange
+		wantUnpaired     []string // 单端集群，格式为 集群ID:状态
+		wantDelta        ResourceTotals
+		wantCPUMovers    []string
+		wantMemoryMovers []string
+	}{
+		{
+			name:       "两端相同时无变化",
+			from:       []models.PodMetricsHistory{web1},
+			to:         []models.PodMetricsHistory{web1},
+			wantStatus: DiffStatusUnchanged,
+		},
+		{
+			name:            "同一工作负载的Pod替换",
+			from:            []models.PodMetricsHistory{web1, snapshotPod(1, "default", "web-2", "web", 500, 200, 100)},
+			to:              []models.PodMetricsHistory{web1, snapshotPod(1, "default", "web-3", "web", 500, 200, 100)},
+			wantChanges:     SnapshotChangeCounts{AddedPods: 1, RemovedPods: 1},
+			wantStatus:      DiffStatusChanged,
+			wantAddedPods:   []string{"web-3"},
+			wantRemovedPods: []string{"web-2"},
+		},
+		{
+			name:            "请求量变化",
+			from:            []models.PodMetricsHistory{web1},
+			to:              []models.PodMetricsHistory{snapshotPod(1, "default", "web-1", "web", 1000, 200, 100)},
+			wantChanges:     SnapshotChangeCounts{SpecChanges: 1},
+			wantStatus:      DiffStatusChanged,
+			wantSpecChanges: []SpecFieldChange{{Field: "cpu_request", From: 500, To: 1000}},
+			wantDelta:       ResourceTotals{CPURequest: 500},
+		},
+		{
+			name: "工作负载和命名空间的新增与删除",
+			from: []models.PodMetricsHistory{web1},
+			to:   []models.PodMetricsHistory{snapshotPod(1, "batch", "job-1", "job", 500, 300, 100)},
+			wantChanges: SnapshotChangeCounts{
+				AddedPods: 1, RemovedPods: 1, AddedWorkloads: 1, RemovedWorkloads: 1, AddedNamespaces: 1, RemovedNamespaces: 1,
+			},
+			wantStatus:       DiffStatusChanged,
+			wantAddedPods:    []string{"job-1"},
+			wantRemovedPods:  []string{"web-1"},
+			wantAddedLoads:   []string{"Deployment/job"},
+			wantDelta:        ResourceTotals{CPUUsage: 100},
+			wantCPUMovers:    []string{"job", "web"},
+			wantMemoryMovers: []string{"job", "web"}, // 变化量相同时按命名空间排序
+		},
+		{
+			name:           "独立Pod以Pod名称作为工作负载",
+			from:           []models.PodMetricsHistory{web1},
+			to:             []models.PodMetricsHistory{web1, snapshotPod(1, "default", "debug", "", 100, 10, 10)},
+			wantChanges:    SnapshotChangeCounts{AddedPods: 1, AddedWorkloads: 1},
+			wantStatus:     DiffStatusChanged,
+			wantAddedPods:  []string{"debug"},
+			wantAddedLoads: []string{"Pod/debug"},
+			wantDelta: ResourceTotals{
+				PodCount: 1, CPURequest: 100, CPULimit: 1000, CPUUsage: 10,
+				MemoryRequest: 256 << 20, MemoryLimit: 512 << 20, MemoryUsage: 10 << 20,
+			},
+			wantCPUMovers:    []string{"debug"},
+			wantMemoryMovers: []string{"debug"},
+		},
+		{
+			name:             "缺少指标的Pod仍参与Pod和规格对比，使用量按0计入",
+			from:             []models.PodMetricsHistory{web1},
+			to:               []models.PodMetricsHistory{snapshotPod(1, "default", "web-1", "web", 500, -1, 150)},
+			wantStatus:       DiffStatusChanged,
+			wantDelta:        ResourceTotals{CPUUsage: -200, MemoryUsage: 50 << 20},
+			wantCPUMovers:    []string{"web"},
+			wantMemoryMovers: []string{"web"},
+		},
+		{
+			name:       "两端都缺少指标时无使用量变化",
+			from:       []models.PodMetricsHistory{snapshotPod(1, "default", "web-1", "web", 500, -1, -1)},
+			to:         []models.PodMetricsHistory{snapshotPod(1, "default", "web-1", "web", 500, -1, -1)},
+			wantStatus: DiffStatusUnchanged,
+		},
+		{
+			name:         "终点新增的集群单独列出",
+			from:         []models.PodMetricsHistory{web1},
+			to:           []models.PodMetricsHistory{web1, snapshotPod(2, "default", "api-1", "api", 500, 200, 100)},
+			wantStatus:   DiffStatusUnchanged,
+			wantUnpaired: []string{"2:added"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			openTestDB(t)
+			writeSnapshot(t, snapshotBase, tt.from)
+			writeSnapshot(t, snapshotBase.Add(10*time.Minute), tt.to)
+
+			sd := NewSnapshotDiffService()
+			req := SnapshotDiffRequest{From: snapshotBase.Add(time.Minute), To: snapshotBase.Add(11 * time.Minute)}
+			if err := sd.Validate(&req); err != nil {
+				t.Fatalf("校验请求失败: %v", err)
+			}
+			result, err := sd.Diff(context.Background(), req)
+			if err != nil {
+				t.Fatalf("快照对比失败: %v", err)
+			}
+
+			if result.Changes != tt.wantChanges {
+				t.Errorf("变化数量 %+v，期望 %+v", result.Changes, tt.wantChanges)
+			}
+			if len(result.Clusters) != 1 || result.Clusters[0].ClusterID != 1 || result.Clusters[0].Status != tt.wantStatus {
+				t.Fatalf("集群对比结果 %+v，期望只有集群1且状态为 %s", result.Clusters, tt.wantStatus)
+			}
+			if result.Totals.Delta != tt.wantDelta {
+				t.Errorf("合计变化 %+v，期望 %+v", result.Totals.Delta, tt.wantDelta)
+			}
+
+			var addedPods, removedPods, addedLoads, unpaired, cpuMovers, memoryMovers []string
+			for _, pod := range result.AddedPods {
+				addedPods = append(addedPods, pod.PodName)
+			}
+			for _, pod := range result.RemovedPods {
+				removedPods = append(removedPods, pod.PodName)
+			}
+			for _, workload := range result.AddedWorkloads {
+				addedLoads = append(addedLoads, workload.WorkloadKind+"/"+workload.WorkloadName)
+			}
+			for _, cluster := range result.UnpairedClusters {
+				unpaired = append(unpaired, fmt.Sprintf("%d:%s", cluster.ClusterID, cluster.Status))
+			}
+			for _, mover := range result.TopCPUMovers {
+				cpuMovers = append(cpuMovers, mover.WorkloadName)
+			}
+			for _, mover := range result.TopMemoryMovers {
+				memoryMovers = append(memoryMovers, mover.WorkloadName)
+			}
+			checks := []struct {
+				label     string
+				got, want []string
+			}{
+				{"新增Pod", addedPods, tt.wantAddedPods},
+				{"删除Pod", removedPods, tt.wantRemovedPods},
+				{"新增工作负载", addedLoads, tt.wantAddedLoads},
+				{"单端集群", unpaired, tt.wantUnpaired},
+				{"CPU使用量变化排行", cpuMovers, tt.wantCPUMovers},
+				{"内存使用量变化排行", memoryMovers, tt.wantMemoryMovers},
+			}
+			for _, check := range checks {
+				if !reflect.DeepEqual(check.got, check.want) {
+					t.Errorf("%s %v，期望 %v", check.label, check.got, check.want)
+				}
+			}
+
+			var specChanges []SpecFieldChange
+			for _, change := range result.SpecChanges {
+				specChanges = append(specChanges, change.Changes...)
+			}
+			if !reflect.DeepEqual(specChanges, tt.wantSpecChanges) {
+				t.Errorf("规格变化 %+v，期望 %+v", specChanges, tt.wantSpecChanges)
+			}
+		})
+	}
+}
+
+func TestSnapshotDiffResolveRuns(t *testing.T) {
+	openTestDB(t)
+	writeSnapshot(t, snapshotBase, []models.PodMetricsHistory{snapshotPod(1, "default", "web-1", "web", 500, 200, 100)})
+	writeSnapshot(t, snapshotBase.Add(10*time.Minute), []models.PodMetricsHistory{snapshotPod(1, "default", "web-1", "web", 500, 200, 100)})
+	writeSnapshot(t, snapshotBase.Add(20*time.Minute), []models.PodMetricsHistory{snapshotPod(2, "default", "api-1", "api", 500, 200, 100)})
+	// 批次ID按写入顺序为 1、2、3，其中批次3属于集群2
+
+	tests := []struct {
+		name         string
+		req          SnapshotDiffRequest
+		wantNotFound bool
+		wantError    bool
+		wantFrom     int // 起点批次涉及的集群数
+		wantTo       int // 终点批次涉及的集群数
+	}{
+		{"起点之前没有采集", SnapshotDiffRequest{From: snapshotBase.Add(-time.Hour), To: snapshotBase.Add(time.Minute)}, true, true, 0, 0},
+		{"按时间取各集群最近一次采集", SnapshotDiffRequest{From: snapshotBase.Add(time.Minute), To: snapshotBase.Add(30 * time.Minute)}, false, false, 1, 2},
+		{"按集群筛选", SnapshotDiffRequest{ClusterID: 1, From: snapshotBase.Add(time.Minute), To: snapshotBase.Add(30 * time.Minute)}, false, false, 1, 1},
+		{"筛选的集群在起点之前没有采集", SnapshotDiffRequest{ClusterID: 2, From: snapshotBase.Add(time.Minute), To: snapshotBase.Add(30 * time.Minute)}, true, true, 0, 0},
+		{"按批次对比", SnapshotDiffRequest{FromRun: 1, ToRun: 2}, false, false, 1, 1},
+		{"批次不存在", SnapshotDiffRequest{FromRun: 1, ToRun: 99}, true, true, 0, 0},
+		{"批次不属于同一集群", SnapshotDiffRequest{FromRun: 1, ToRun: 3}, false, true, 0, 0},
+		{"起点批次晚于终点批次", SnapshotDiffRequest{FromRun: 2, ToRun: 1}, false, true, 0, 0},
+		{"批次不属于筛选的集群", SnapshotDiffRequest{ClusterID: 2, FromRun: 1, ToRun: 2}, false, true, 0, 0},
+	}
+	sd := NewSnapshotDiffService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fromRuns, toRuns, err := sd.resolveRuns(tt.req)
+			if (err != nil) != tt.wantError || errors.Is(err, ErrSnapshotNotFound) != tt.wantNotFound {
+				t.Fatalf("错误 %v，期望出错 %v、未找到批次 %v", err, tt.wantError, tt.wantNotFound)
+			}
+			if len(fromRuns) != tt.wantFrom || len(toRuns) != tt.wantTo {
+				t.Errorf("两端批次 %d、%d 个，期望 %d、%d 个", len(fromRuns), len(toRuns), tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestSplitUnpairedRuns(t *testing.T) {
+	run := func(clusterID uint) models.CollectionRun {
+		return models.CollectionRun{ID: clusterID * 10, ClusterID: clusterID}
+	}
+
+	tests := []struct {
+		name         string
+		fromRuns     map[uint]models.CollectionRun
+		toRuns       map[uint]models.CollectionRun
+		wantPaired   []uint
+		wantUnpaired []string
+	}{
+		{"空输入", nil, nil, nil, nil},
+		{"两端都有批次", map[uint]models.CollectionRun{1: run(1)}, map[uint]models.CollectionRun{1: run(1)}, []uint{1}, nil},
+		{"只有起点", map[uint]models.CollectionRun{1: run(1)}, nil, nil, []string{"1:removed"}},
+		{"只有终点", nil, map[uint]models.CollectionRun{1: run(1)}, nil, []string{"1:added"}},
+		{
+			name:         "单端集群按集群ID排序",
+			fromRuns:     map[uint]models.CollectionRun{1: run(1), 3: run(3), 4: run(4)},
+			toRuns:       map[uint]models.CollectionRun{1: run(1), 2: run(2), 5: run(5)},
+			wantPaired:   []uint{1},
+			wantUnpaired: []string{"2:added", "3:removed", "4:removed", "5:added"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pairedFrom, pairedTo, unpaired := splitUnpairedRuns(tt.fromRuns, tt.toRuns)
+
+			if len(pairedFrom) != len(tt.wantPaired) || len(pairedTo) != len(tt.wantPaired) {
+				t.Errorf("成对批次 %v、%v，期望集群 %v", pairedFrom, pairedTo, tt.wantPaired)
+			}
+			for _, id := range tt.wantPaired {
+				if pairedFrom[id] != tt.fromRuns[id] || pairedTo[id] != tt.toRuns[id] {
+					t.Errorf("集群 %d 的成对批次不正确", id)
+				}
+			}
+			var got []string
+			for _, cluster := range unpaired {
+				got = append(got, fmt.Sprintf("%d:%s", cluster.ClusterID, cluster.Status))
+				if cluster.Run.ClusterID != cluster.ClusterID {
+					t.Errorf("单端集群 %d 的批次属于集群 %d", cluster.ClusterID, cluster.Run.ClusterID)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.wantUnpaired) {
+				t.Errorf("单端集群 %v，期望 %v", got, tt.wantUnpaired)
+			}
+		})
+	}
+}
+
+func TestDiffStatus(t *testing.T) {
+	tests := []struct {
+		name                  string
+		inFrom, inTo, changed bool
+		want                  string
+	}{
+		{"只在终点", false, true, true, DiffStatusAdded},
+		{"只在起点", true, false, true, DiffStatusRemoved},
+		{"两端都有且有变化", true, true, true, DiffStatusChanged},
+		{"两端都有且无变化", true, true, false, DiffStatusUnchanged},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffStatus(tt.inFrom, tt.inTo, tt.changed); got != tt.want {
+				t.Errorf("得到 %s，期望 %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpecFieldChanges(t *testing.T) {
+	base := workloadState{cpuRequest: 500, cpuLimit: 1000, memoryRequest: 256 << 20, memoryLimit: 512 << 20}
+	with := func(modify func(state *workloadState)) *workloadState {
+		state := base
+		modify(&state)
+		return &state
+	}
+
+	tests := []struct {
+		name string
+		to   *workloadState
+		want []SpecFieldChange
+	}{
+		{"无变化", &base, nil},
+		{"只有合计变化不算规格变化", with(func(s *workloadState) { s.totals.PodCount = 3 }), nil},
+		{"请求量变化", with(func(s *workloadState) { s.cpuRequest = 250 }), []SpecFieldChange{{"cpu_request", 500, 250}}},
+		{
+			name: "多项变化按固定顺序返回",
+			to:   with(func(s *workloadState) { s.memoryLimit = 1 << 30; s.cpuLimit = 0 }),
+			want: []SpecFieldChange{{"cpu_limit", 1000, 0}, {"memory_limit", 512 << 20, 1 << 30}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := specFieldChanges(&base, tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("得到 %+v，期望 %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewTotalsChange(t *testing.T) {
+	totals := &ResourceTotals{PodCount: 2, CPURequest: 1000, CPUUsage: 300, MemoryUsage: 100}
+
+	tests := []struct {
+		name      string
+		from, to  *ResourceTotals
+		wantDelta ResourceTotals
+	}{
+		{"两端都缺失", nil, nil, ResourceTotals{}},
+		{"起点缺失按0计算", nil, totals, *totals},
+		{"终点缺失按0计算", totals, nil, ResourceTotals{PodCount: -2, CPURequest: -1000, CPUUsage: -300, MemoryUsage: -100}},
+		{"两端相同", totals, totals, ResourceTotals{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			change := newTotalsChange(tt.from, tt.to)
+			if change.Delta != tt.wantDelta {
+				t.Errorf("变化量 %+v，期望 %+v", change.Delta, tt.wantDelta)
+			}
+			if addResourceTotals(change.From, change.Delta) != change.To {
+				t.Errorf("起点加变化量不等于终点: %+v", change)
+			}
+		})
+	}
+}
+
+func TestTopUsageMovers(t *testing.T) {
+	mover := func(namespace, name string, cpuDelta int64) WorkloadUsageMover {
+		return WorkloadUsageMover{ClusterID: 1, Namespace: namespace, WorkloadKind: "Deployment", WorkloadName: name, CPUUsageDelta: cpuDelta}
+	}
+	cpuDelta := func(m WorkloadUsageMover) int64 { return m.CPUUsageDelta }
+
+	tests := []struct {
+		name   string
+		movers []WorkloadUsageMover
+		top    int
+		want   []string
+	}{
+		{"空输入", nil, 10, []string{}},
+		{"忽略没有变化的工作负载", []WorkloadUsageMover{mover("default", "a", 0), mover("default", "b", 100)}, 10, []string{"default/b"}},
+		{"按变化绝对值排序", []WorkloadUsageMover{mover("default", "a", 100), mover("default", "b", -300), mover("default", "c", 200)}, 10, []string{"default/b", "default/c", "default/a"}},
+		{"变化量相同时按命名空间和名称排序", []WorkloadUsageMover{mover("web", "a", 100), mover("default", "b", -100), mover("default", "a", 100)}, 10, []string{"default/a", "default/b", "web/a"}},
+		{"截断到指定数量", []WorkloadUsageMover{mover("default", "a", 100), mover("default", "b", 300), mover("default", "c", 200)}, 2, []string{"default/b", "default/c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, m := range topUsageMovers(tt.movers, tt.top, cpuDelta) {
+				got = append(got, m.Namespace+"/"+m.WorkloadName)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("得到 %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnapshotDiffValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       SnapshotDiffRequest
+		wantError bool
+		wantTop   int
+	}{
+		{"按时间对比使用默认排行数量", SnapshotDiffRequest{From: snapshotBase, To: snapshotBase.Add(time.Hour)}, false, defaultSnapshotDiffTop},
+		{"排行数量超过上限", SnapshotDiffRequest{From: snapshotBase, To: snapshotBase.Add(time.Hour), Top: 1000}, false, maxSnapshotDiffTop},
+		{"按批次对比", SnapshotDiffRequest{FromRun: 1, ToRun: 2, Top: 5}, false, 5},
+		{"只指定起点批次", SnapshotDiffRequest{FromRun: 1, From: snapshotBase, To: snapshotBase.Add(time.Hour)}, true, 0},
+		{"缺少时间", SnapshotDiffRequest{From: snapshotBase}, true, 0},
+		{"起点不早于终点", SnapshotDiffRequest{From: snapshotBase, To: snapshotBase}, true, 0},
+	}
+	sd := &SnapshotDiffService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			err := sd.Validate(&req)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("错误 %v，期望出错 %v", err, tt.wantError)
+			}
+			if err == nil && req.Top != tt.wantTop {
+				t.Errorf("排行数量 %d，期望 %d", req.Top, tt.wantTop)
+			}
+		})
+	}
+}
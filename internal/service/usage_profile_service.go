@@ -0,0 +1,673 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cluster-resource-insight/internal/config"
+	"cluster-resource-insight/internal/historystore"
+	"cluster-resource-insight/internal/models"
+	"cluster-resource-insight/pkg/cost"
+)
+
+// hoursPerWeek 一周的小时数，周内小时以周一0点为0
+const hoursPerWeek = 7 * 24
+
+// 使用画像范围
+const (
+	ProfileScopeWorkload  = "workload"
+	ProfileScopeNamespace = "namespace"
+)
+
+// scalableWorkloadKinds 支持定时伸缩副本数的工作负载类型
+var scalableWorkloadKinds = map[string]string{
+	"Deployment":  "deployment",
+	"StatefulSet": "statefulset",
+}
+
+var weekdayNames = []string{"周一", "周二", "周三", "周四", "周五", "周六", "周日"}
+
+// profileSettings 使用画像参数
+type profileSettings struct {
+	lookbackDays         int
+	minSamplesPerHour    int
+	offHoursThresholdPct float64
+	minWindowHours       int
+	scaleDownReplicas    int
+	timezone             string
+	location             *time.Location
+}
+
+// loadProfileSettings 读取使用画像配置，未配置项使用默认值
+func loadProfileSettings() profileSettings {
+	settings := profileSettings{
+		lookbackDays:         28,
+		minSamplesPerHour:    2,
+		offHoursThresholdPct: 20,
+		minWindowHours:       4,
+		location:             time.Local,
+	}
+
+	if profileConfig := config.GetProfileConfig(); profileConfig != nil {
+		if profileConfig.LookbackDays > 0 {
+			settings.lookbackDays = profileConfig.LookbackDays
+		}
+		if profileConfig.MinSamplesPerHour > 0 {
+			settings.minSamplesPerHour = profileConfig.MinSamplesPerHour
+		}
+		if profileConfig.OffHoursThresholdPct > 0 {
+			settings.offHoursThresholdPct = profileConfig.OffHoursThresholdPct
+		}
+		if profileConfig.MinWindowHours > 0 {
+			settings.minWindowHours = profileConfig.MinWindowHours
+		}
+		settings.scaleDownReplicas = profileConfig.ScaleDownReplicas
+		if profileConfig.Timezone != "" {
+			if location, err := time.LoadLocation(profileConfig.Timezone); err == nil {
+				settings.timezone = profileConfig.Timezone
+				settings.location = location
+			}
+		}
+	}
+
+	return settings
+}
+
+// HourOfWeekSlot 周内某一小时的平均使用情况
+type HourOfWeekSlot struct {
+	Hour             int     `json:"hour"`               // 周内小时，0为周一0点
+	Weekday          int     `json:"weekday"`            // 星期，0为周一
+	HourOfDay        int     `json:"hour_of_day"`        // 当天小时
+	Samples          int     `json:"samples"`            // 采样次数
+	Sufficient       bool    `json:"sufficient"`         // 采样次数是否足够
+	AvgPods          float64 `json:"avg_pods"`           // 平均Pod数
+	AvgCPUUsage      float64 `json:"avg_cpu_usage"`      // 平均CPU使用量 (millicores)
+	AvgMemoryUsage   float64 `json:"avg_memory_usage"`   // 平均内存使用量 (bytes)
+	AvgCPURequest    float64 `json:"avg_cpu_request"`    // 平均CPU请求量 (millicores)
+	AvgMemoryRequest float64 `json:"avg_memory_request"` // 平均内存请求量 (bytes)
+	CPUUtilPct       float64 `json:"cpu_util_pct"`       // CPU使用量/请求量百分比
+	MemoryUtilPct    float64 `json:"memory_util_pct"`    // 内存使用量/请求量百分比
+	RelativeLoad     float64 `json:"relative_load"`      // CPU使用量相对峰值小时的比例（0-1）
+	OffHours         bool    `json:"off_hours"`          // 是否为低谷时段
+}
+
+// UsageProfile 工作负载或命名空间的周内小时使用画像
+type UsageProfile struct {
+	Scope        string           `json:"scope"` // workload/namespace
+	Namespace    string           `json:"namespace"`
+	WorkloadKind string           `json:"workload_kind,omitempty"`
+	WorkloadName string           `json:"workload_name,omitempty"`
+	Timezone     string           `json:"timezone"`
+	Samples      int              `json:"samples"`        // 参与计算的采集批次数
+	PeakHour     int              `json:"peak_hour"`      // CPU使用量最高的周内小时
+	PeakCPUUsage float64          `json:"peak_cpu_usage"` // 峰值小时的平均CPU使用量 (millicores)
+	MaxPods      float64          `json:"max_pods"`       // 各小时平均Pod数的最大值
+	OffHours     int              `json:"off_hours"`      // 低谷小时数
+	Slots        []HourOfWeekSlot `json:"slots"`          // 168个周内小时
+}
+
+// UsageHeatmap 低谷时段热力图，按 星期 x 小时 排列，数据不足的格子为-1
+type UsageHeatmap struct {
+	UsageProfile
+	Weekdays      []string       `json:"weekdays"`
+	RelativeLoad  [7][24]float64 `json:"relative_load"`   // CPU使用量相对峰值的比例
+	CPUUtilPct    [7][24]float64 `json:"cpu_util_pct"`    // CPU利用率
+	MemoryUtilPct [7][24]float64 `json:"memory_util_pct"` // 内存利用率
+	OffHoursGrid  [7][24]bool    `json:"off_hours_grid"`  // 是否为低谷时段
+	ThresholdPct  float64        `json:"threshold_pct"`   // 低谷判定阈值
+}
+
+// ScalingWindow 连续的低谷时段
+type ScalingWindow struct {
+	StartHour int    `json:"start_hour"` // 起始周内小时
+	Hours     int    `json:"hours"`      // 持续小时数
+	Start     string `json:"start"`      // 如 "周五 20:00"
+	End       string `json:"end"`        // 如 "周一 08:00"
+}
+
+// CronSchedule 按相同起止时刻合并后的定时伸缩计划
+type CronSchedule struct {
+	Description string `json:"description"`
+	StartCron   string `json:"start_cron"` // 时段开始的cron表达式
+	EndCron     string `json:"end_cron"`   // 时段结束的cron表达式
+}
+
+// ScaleScheduleRecommendation 定时伸缩建议
+type ScaleScheduleRecommendation struct {
+	Namespace              string          `json:"namespace"`
+	WorkloadKind           string          `json:"workload_kind"`
+	WorkloadName           string          `json:"workload_name"`
+	CurrentReplicas        int             `json:"current_replicas"`         // 高峰时段平均副本数
+	ScaleDownReplicas      int             `json:"scale_down_replicas"`      // 低谷时段建议副本数
+	OffHoursPerWeek        int             `json:"off_hours_per_week"`       // 每周可缩容小时数
+	OffHoursCPUUtilPct     float64         `json:"off_hours_cpu_util_pct"`   // 低谷时段平均CPU利用率
+	EstimatedMonthlySaving float64         `json:"estimated_monthly_saving"` // 按请求量估算的月度节省
+	Windows                []ScalingWindow `json:"windows"`
+	ScaleDownSchedules     []CronSchedule  `json:"scale_down_schedules"` // CronJob方式：开始时缩容，结束时恢复
+	ScaleDownCommand       string          `json:"scale_down_command"`
+	ScaleUpCommand         string          `json:"scale_up_command"`
+	KEDAScaledObject       string          `json:"keda_scaled_object"` // KEDA方式：仅在高峰时段维持副本数
+}
+
+// ScaleScheduleReport 定时伸缩建议报告
+type ScaleScheduleReport struct {
+	ClusterID          uint                          `json:"cluster_id"`
+	Namespace          string                        `json:"namespace,omitempty"`
+	Timezone           string                        `json:"timezone"`
+	LookbackDays       int                           `json:"lookback_days"`
+	ThresholdPct       float64                       `json:"threshold_pct"`
+	WorkloadsAnalyzed  int                           `json:"workloads_analyzed"`
+	TotalMonthlySaving float64                       `json:"total_monthly_saving"`
+	Recommendations    []ScaleScheduleRecommendation `json:"recommendations"`
+	NamespaceProfiles  []UsageProfile                `json:"namespace_profiles"` // 各命名空间画像摘要（不含小时明细）
+	GeneratedAt        time.Time                     `json:"generated_at"`
+}
+
+// UsageProfileService 分时段使用画像服务 - 基于历史数据计算周内小时使用画像并给出定时伸缩建议
+type UsageProfileService struct{}
+
+// NewUsageProfileService 创建分时段使用画像服务实例
+func NewUsageProfileService() *UsageProfileService {
+	return &UsageProfileService{}
+}
+
+// profileRow 按采集批次和工作负载汇总的历史数据
+type profileRow struct {
+	CollectedAt   time.Time
+	Namespace     string
+	WorkloadKind  string
+	WorkloadName  string
+	CPUUsage      int64
+	MemoryUsage   int64
+	CPURequest    int64
+	MemoryRequest int64
+	PodCount      int64
+}
+
+// slotAccumulator 单个周内小时的累计值
+type slotAccumulator struct {
+	samples       int
+	pods          float64
+	cpuUsage      float64
+	memoryUsage   float64
+	cpuRequest    float64
+	memoryRequest float64
+}
+
+// profileAccumulator 单个工作负载或命名空间的累计值
+type profileAccumulator struct {
+	key     workloadKey
+	samples map[time.Time]bool
+	slots   [hoursPerWeek]slotAccumulator
+}
+
+// queryProfileRows 查询回看期内的历史数据，估算值不参与画像计算
+func (ps *UsageProfileService) queryProfileRows(clusterID uint, namespace, workloadKind, workloadName string, since time.Time) ([]profileRow, error) {
+	type rowKey struct {
+		collectedAt int64
+		workload    workloadKey
+	}
+	rows := make(map[rowKey]*profileRow)
+	err := historystore.GetHistoryStore().Scan(context.Background(), historystore.RangeQuery{
+		Filter: historystore.Filter{
+			ClusterID:    clusterID,
+			Namespace:    namespace,
+			WorkloadKind: workloadKind,
+			WorkloadName: workloadName,
+			MetricsOnly:  true,
+		},
+		Start: since,
+		End:   time.Now(),
+	}, func(record *models.PodMetricsHistory) error {
+		if !record.CPUMetricsAvailable || !record.MemoryMetricsAvailable || record.WorkloadName == "" {
+			return nil
+		}
+		key := rowKey{
+			collectedAt: record.CollectedAt.UnixNano(),
+			workload:    workloadKey{Namespace: record.Namespace, WorkloadKind: record.WorkloadKind, WorkloadName: record.WorkloadName},
+		}
+		row, ok := rows[key]
+		if !ok {
+			row = &profileRow{
+				CollectedAt:  record.CollectedAt,
+				Namespace:    record.Namespace,
+				WorkloadKind: record.WorkloadKind,
+				WorkloadName: record.WorkloadName,
+			}
+			rows[key] = row
+		}
+		row.CPUUsage += record.CPUUsage
+		row.MemoryUsage += record.MemoryUsage
+		row.CPURequest += record.CPURequest
+		row.MemoryRequest += record.MemoryRequest
+		row.PodCount++
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("查询使用画像历史数据失败: %v", err)
+	}
+
+	result := make([]profileRow, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, *row)
+	}
+	return result, nil
+}
+
+// accumulateProfiles 将历史数据按key汇总到周内小时，同一采集批次中同一key的多行先相加
+func accumulateProfiles(rows []profileRow, location *time.Location, keyOf func(row profileRow) workloadKey) map[workloadKey]*profileAccumulator {
+	type batchKey struct {
+		key         workloadKey
+		collectedAt time.Time
+	}
+	batches := make(map[batchKey]*profileRow)
+	for i := range rows {
+		row := rows[i]
+		bk := batchKey{key: keyOf(row), collectedAt: row.CollectedAt}
+		if existing, ok := batches[bk]; ok {
+			existing.CPUUsage += row.CPUUsage
+			existing.MemoryUsage += row.MemoryUsage
+			existing.CPURequest += row.CPURequest
+			existing.MemoryRequest += row.MemoryRequest
+			existing.PodCount += row.PodCount
+			continue
+		}
+		batches[bk] = &row
+	}
+
+	profiles := make(map[workloadKey]*profileAccumulator)
+	for bk, row := range batches {
+		profile, ok := profiles[bk.key]
+		if !ok {
+			profile = &profileAccumulator{key: bk.key, samples: make(map[time.Time]bool)}
+			profiles[bk.key] = profile
+		}
+		profile.samples[bk.collectedAt] = true
+
+		slot := &profile.slots[hourOfWeek(bk.collectedAt.In(location))]
+		slot.samples++
+		slot.pods += float64(row.PodCount)
+		slot.cpuUsage += float64(row.CPUUsage)
+		slot.memoryUsage += float64(row.MemoryUsage)
+		slot.cpuRequest += float64(row.CPURequest)
+		slot.memoryRequest += float64(row.MemoryRequest)
+	}
+	return profiles
+}
+
+// hourOfWeek 计算周内小时，周一0点为0
+func hourOfWeek(t time.Time) int {
+	weekday := (int(t.Weekday()) + 6) % 7
+	return weekday*24 + t.Hour()
+}
+
+// buildUsageProfile 计算各小时平均值，并按峰值小时的CPU使用量判定低谷时段
+func buildUsageProfile(acc *profileAccumulator, scope string, settings profileSettings) UsageProfile {
+	profile := UsageProfile{
+		Scope:     scope,
+		Namespace: acc.key.Namespace,
+		Timezone:  settings.location.String(),
+		Samples:   len(acc.samples),
+		PeakHour:  -1,
+		Slots:     make([]HourOfWeekSlot, hoursPerWeek),
+	}
+	if scope == ProfileScopeWorkload {
+		profile.WorkloadKind = acc.key.WorkloadKind
+		profile.WorkloadName = acc.key.WorkloadName
+	}
+
+	for hour := 0; hour < hoursPerWeek; hour++ {
+		sum := acc.slots[hour]
+		slot := HourOfWeekSlot{
+			Hour:         hour,
+			Weekday:      hour / 24,
+			HourOfDay:    hour % 24,
+			Samples:      sum.samples,
+			Sufficient:   sum.samples >= settings.minSamplesPerHour,
+			RelativeLoad: -1,
+		}
+		if sum.samples > 0 {
+			n := float64(sum.samples)
+			slot.AvgPods = sum.pods / n
+			slot.AvgCPUUsage = sum.cpuUsage / n
+			slot.AvgMemoryUsage = sum.memoryUsage / n
+			slot.AvgCPURequest = sum.cpuRequest / n
+			slot.AvgMemoryRequest = sum.memoryRequest / n
+			if slot.AvgCPURequest > 0 {
+				slot.CPUUtilPct = slot.AvgCPUUsage / slot.AvgCPURequest * 100
+			}
+			if slot.AvgMemoryRequest > 0 {
+				slot.MemoryUtilPct = slot.AvgMemoryUsage / slot.AvgMemoryRequest * 100
+			}
+		}
+		if slot.Sufficient {
+			if slot.AvgCPUUsage > profile.PeakCPUUsage || profile.PeakHour < 0 {
+				profile.PeakHour = hour
+				profile.PeakCPUUsage = slot.AvgCPUUsage
+			}
+			profile.MaxPods = math.Max(profile.MaxPods, slot.AvgPods)
+		}
+		profile.Slots[hour] = slot
+	}
+
+	// 内存使用量在空闲时通常不会回落，低谷时段只按CPU判定
+	if profile.PeakCPUUsage > 0 {
+		for hour := range profile.Slots {
+			slot := &profile.Slots[hour]
+			if !slot.Sufficient {
+				continue
+			}
+			slot.RelativeLoad = slot.AvgCPUUsage / profile.PeakCPUUsage
+			if slot.RelativeLoad*100 < settings.offHoursThresholdPct {
+				slot.OffHours = true
+				profile.OffHours++
+			}
+		}
+	}
+
+	return profile
+}
+
+// GetUsageHeatmap 获取工作负载或命名空间的周内低谷时段热力图
+// 参数:
+//   - clusterID: 集群ID
+//   - namespace: 命名空间
+//   - workloadKind, workloadName: 工作负载，为空时返回命名空间整体画像
+func (ps *UsageProfileService) GetUsageHeatmap(clusterID uint, namespace, workloadKind, workloadName string) (*UsageHeatmap, error) {
+	settings := loadProfileSettings()
+	rows, err := ps.queryProfileRows(clusterID, namespace, workloadKind, workloadName, time.Now().AddDate(0, 0, -settings.lookbackDays))
+	if err != nil {
+		return nil, err
+	}
+
+	scope := ProfileScopeNamespace
+	key := workloadKey{Namespace: namespace}
+	if workloadName != "" {
+		scope = ProfileScopeWorkload
+		key = workloadKey{Namespace: namespace, WorkloadKind: workloadKind, WorkloadName: workloadName}
+	}
+
+	acc := accumulateProfiles(rows, settings.location, func(row profileRow) workloadKey { return key })[key]
+	if acc == nil {
+		acc = &profileAccumulator{key: key, samples: map[time.Time]bool{}}
+	}
+
+	heatmap := &UsageHeatmap{
+		UsageProfile: buildUsageProfile(acc, scope, settings),
+		Weekdays:     weekdayNames,
+		ThresholdPct: settings.offHoursThresholdPct,
+	}
+	for _, slot := range heatmap.Slots {
+		heatmap.RelativeLoad[slot.Weekday][slot.HourOfDay] = -1
+		heatmap.CPUUtilPct[slot.Weekday][slot.HourOfDay] = -1
+		heatmap.MemoryUtilPct[slot.Weekday][slot.HourOfDay] = -1
+		if !slot.Sufficient {
+			continue
+		}
+		heatmap.RelativeLoad[slot.Weekday][slot.HourOfDay] = math.Round(slot.RelativeLoad*1000) / 1000
+		heatmap.CPUUtilPct[slot.Weekday][slot.HourOfDay] = math.Round(slot.CPUUtilPct*10) / 10
+		heatmap.MemoryUtilPct[slot.Weekday][slot.HourOfDay] = math.Round(slot.MemoryUtilPct*10) / 10
+		heatmap.OffHoursGrid[slot.Weekday][slot.HourOfDay] = slot.OffHours
+	}
+
+	return heatmap, nil
+}
+
+// GetScaleScheduleRecommendations 根据周内使用画像为可伸缩的工作负载推荐定时缩容时段
+// 参数:
+//   - clusterID: 集群ID
+//   - namespace: 命名空间，为空时分析全部命名空间
+//   - limit: 返回的建议数量上限，按预计节省从高到低
+func (ps *UsageProfileService) GetScaleScheduleRecommendations(clusterID uint, namespace string, limit int) (*ScaleScheduleReport, error) {
+	settings := loadProfileSettings()
+	rows, err := ps.queryProfileRows(clusterID, namespace, "", "", time.Now().AddDate(0, 0, -settings.lookbackDays))
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ScaleScheduleReport{
+		ClusterID:         clusterID,
+		Namespace:         namespace,
+		Timezone:          settings.location.String(),
+		LookbackDays:      settings.lookbackDays,
+		ThresholdPct:      settings.offHoursThresholdPct,
+		Recommendations:   []ScaleScheduleRecommendation{},
+		NamespaceProfiles: []UsageProfile{},
+		GeneratedAt:       time.Now(),
+	}
+
+	costModel := cost.DefaultModel()
+	workloads := accumulateProfiles(rows, settings.location, func(row profileRow) workloadKey {
+		return workloadKey{Namespace: row.Namespace, WorkloadKind: row.WorkloadKind, WorkloadName: row.WorkloadName}
+	})
+	for key, acc := range workloads {
+		if _, ok := scalableWorkloadKinds[key.WorkloadKind]; !ok {
+			continue
+		}
+		report.WorkloadsAnalyzed++
+
+		profile := buildUsageProfile(acc, ProfileScopeWorkload, settings)
+		if recommendation, ok := recommendScaleSchedule(profile, settings, costModel); ok {
+			report.Recommendations = append(report.Recommendations, recommendation)
+			report.TotalMonthlySaving += recommendation.EstimatedMonthlySaving
+		}
+	}
+
+	namespaces := accumulateProfiles(rows, settings.location, func(row profileRow) workloadKey {
+		return workloadKey{Namespace: row.Namespace}
+	})
+	for _, acc := range namespaces {
+		profile := buildUsageProfile(acc, ProfileScopeNamespace, settings)
+		profile.Slots = nil
+		report.NamespaceProfiles = append(report.NamespaceProfiles, profile)
+	}
+	sort.Slice(report.NamespaceProfiles, func(i, j int) bool {
+		return report.NamespaceProfiles[i].Namespace < report.NamespaceProfiles[j].Namespace
+	})
+
+	sort.Slice(report.Recommendations, func(i, j int) bool {
+		return report.Recommendations[i].EstimatedMonthlySaving > report.Recommendations[j].EstimatedMonthlySaving
+	})
+	if limit > 0 && len(report.Recommendations) > limit {
+		report.Recommendations = report.Recommendations[:limit]
+	}
+	report.TotalMonthlySaving = math.Round(report.TotalMonthlySaving*100) / 100
+
+	return report, nil
+}
+
+// recommendScaleSchedule 在画像中查找足够长的连续低谷时段，生成定时伸缩建议
+func recommendScaleSchedule(profile UsageProfile, settings profileSettings, costModel *cost.Model) (ScaleScheduleRecommendation, bool) {
+	currentReplicas := int(math.Round(profile.MaxPods))
+	if currentReplicas <= settings.scaleDownReplicas {
+		return ScaleScheduleRecommendation{}, false
+	}
+
+	windows := findOffHoursWindows(profile.Slots, settings.minWindowHours)
+	// 全周都处于低谷说明工作负载整体闲置，属于闲置资源扫描的范围
+	if len(windows) == 0 || (len(windows) == 1 && windows[0].Hours == hoursPerWeek) {
+		return ScaleScheduleRecommendation{}, false
+	}
+
+	recommendation := ScaleScheduleRecommendation{
+		Namespace:         profile.Namespace,
+		WorkloadKind:      profile.WorkloadKind,
+		WorkloadName:      profile.WorkloadName,
+		CurrentReplicas:   currentReplicas,
+		ScaleDownReplicas: settings.scaleDownReplicas,
+		Windows:           windows,
+	}
+
+	var weeklySaving, cpuUtilTotal float64
+	for _, window := range windows {
+		recommendation.OffHoursPerWeek += window.Hours
+		for i := 0; i < window.Hours; i++ {
+			slot := profile.Slots[(window.StartHour+i)%hoursPerWeek]
+			cpuUtilTotal += slot.CPUUtilPct
+			if slot.AvgPods <= float64(settings.scaleDownReplicas) {
+				continue
+			}
+			// 缩容节省的比例 = 减少的副本数 / 当前平均副本数
+			releasedShare := (slot.AvgPods - float64(settings.scaleDownReplicas)) / slot.AvgPods
+			hourlyCost := costModel.MonthlyCost(int64(slot.AvgCPURequest), int64(slot.AvgMemoryRequest)) / cost.HoursPerMonth
+			weeklySaving += hourlyCost * releasedShare
+		}
+	}
+	recommendation.OffHoursCPUUtilPct = math.Round(cpuUtilTotal/float64(recommendation.OffHoursPerWeek)*10) / 10
+	recommendation.EstimatedMonthlySaving = math.Round(weeklySaving*cost.HoursPerMonth/hoursPerWeek*100) / 100
+
+	resource := scalableWorkloadKinds[profile.WorkloadKind]
+	recommendation.ScaleDownSchedules = buildCronSchedules(windows)
+	recommendation.ScaleDownCommand = fmt.Sprintf("kubectl -n %s scale %s/%s --replicas=%d",
+		profile.Namespace, resource, profile.WorkloadName, settings.scaleDownReplicas)
+	recommendation.ScaleUpCommand = fmt.Sprintf("kubectl -n %s scale %s/%s --replicas=%d",
+		profile.Namespace, resource, profile.WorkloadName, currentReplicas)
+	recommendation.KEDAScaledObject = buildKEDAScaledObject(profile, windows, settings, currentReplicas)
+
+	return recommendation, true
+}
+
+// findOffHoursWindows 在周内小时环上查找连续低谷时段，跨周首尾相连的时段合并为一个
+func findOffHoursWindows(slots []HourOfWeekSlot, minHours int) []ScalingWindow {
+	offCount := 0
+	for _, slot := range slots {
+		if slot.OffHours {
+			offCount++
+		}
+	}
+	if offCount == 0 {
+		return nil
+	}
+	if offCount == hoursPerWeek {
+		return []ScalingWindow{newScalingWindow(0, hoursPerWeek)}
+	}
+
+	// 从一个非低谷小时之后开始扫描，保证跨周的时段不会被拆开
+	begin := 0
+	for slots[begin].OffHours {
+		begin++
+	}
+
+	var windows []ScalingWindow
+	start, length := -1, 0
+	for i := 1; i <= hoursPerWeek; i++ {
+		hour := (begin + i) % hoursPerWeek
+		if slots[hour].OffHours {
+			if start < 0 {
+				start = hour
+			}
+			length++
+			continue
+		}
+		if start >= 0 && length >= minHours {
+			windows = append(windows, newScalingWindow(start, length))
+		}
+		start, length = -1, 0
+	}
+
+	sort.Slice(windows, func(i, j int) bool { return windows[i].StartHour < windows[j].StartHour })
+	return windows
+}
+
+func newScalingWindow(startHour, hours int) ScalingWindow {
+	return ScalingWindow{
+		StartHour: startHour,
+		Hours:     hours,
+		Start:     formatHourOfWeek(startHour),
+		End:       formatHourOfWeek((startHour + hours) % hoursPerWeek),
+	}
+}
+
+func formatHourOfWeek(hour int) string {
+	return fmt.Sprintf("%s %02d:00", weekdayNames[hour/24], hour%24)
+}
+
+// cronWeekday 将星期（0为周一）转换为cron中的星期（0为周日）
+func cronWeekday(weekday int) int {
+	return (weekday + 1) % 7
+}
+
+// buildCronSchedules 将起止时刻和时长相同的时段合并为一组cron表达式
+func buildCronSchedules(windows []ScalingWindow) []CronSchedule {
+	type groupKey struct {
+		startHourOfDay int
+		hours          int
+	}
+	var order []groupKey
+	groups := make(map[groupKey][]ScalingWindow)
+	for _, window := range windows {
+		key := groupKey{startHourOfDay: window.StartHour % 24, hours: window.Hours}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], window)
+	}
+
+	schedules := make([]CronSchedule, 0, len(order))
+	for _, key := range order {
+		var startDays, endDays, names []string
+		for _, window := range groups[key] {
+			endHour := (window.StartHour + window.Hours) % hoursPerWeek
+			startDays = append(startDays, strconv.Itoa(cronWeekday(window.StartHour/24)))
+			endDays = append(endDays, strconv.Itoa(cronWeekday(endHour/24)))
+			names = append(names, weekdayNames[window.StartHour/24])
+		}
+		endHourOfDay := (key.startHourOfDay + key.hours) % 24
+		schedules = append(schedules, CronSchedule{
+			Description: fmt.Sprintf("%s %02d:00 起持续 %d 小时", strings.Join(names, "、"), key.startHourOfDay, key.hours),
+			StartCron:   fmt.Sprintf("0 %d * * %s", key.startHourOfDay, strings.Join(startDays, ",")),
+			EndCron:     fmt.Sprintf("0 %d * * %s", endHourOfDay, strings.Join(endDays, ",")),
+		})
+	}
+	return schedules
+}
+
+// activeWindows 低谷时段之间的高峰时段
+func activeWindows(offWindows []ScalingWindow) []ScalingWindow {
+	var active []ScalingWindow
+	for i, window := range offWindows {
+		next := offWindows[(i+1)%len(offWindows)]
+		start := (window.StartHour + window.Hours) % hoursPerWeek
+		length := (next.StartHour - start + hoursPerWeek) % hoursPerWeek
+		if length > 0 {
+			active = append(active, newScalingWindow(start, length))
+		}
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].StartHour < active[j].StartHour })
+	return active
+}
+
+// buildKEDAScaledObject 生成KEDA ScaledObject，cron触发器在高峰时段维持当前副本数，其余时间回落到最小副本数
+// 短于最小缩容时长的低谷不会被拆出，因此高峰时段可能包含少量低谷小时
+func buildKEDAScaledObject(profile UsageProfile, offWindows []ScalingWindow, settings profileSettings, replicas int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "apiVersion: keda.sh/v1alpha1\n")
+	fmt.Fprintf(&b, "kind: ScaledObject\n")
+	fmt.Fprintf(&b, "metadata:\n")
+	fmt.Fprintf(&b, "  name: %s-schedule\n", profile.WorkloadName)
+	fmt.Fprintf(&b, "  namespace: %s\n", profile.Namespace)
+	fmt.Fprintf(&b, "spec:\n")
+	fmt.Fprintf(&b, "  scaleTargetRef:\n")
+	fmt.Fprintf(&b, "    kind: %s\n", profile.WorkloadKind)
+	fmt.Fprintf(&b, "    name: %s\n", profile.WorkloadName)
+	fmt.Fprintf(&b, "  minReplicaCount: %d\n", settings.scaleDownReplicas)
+	fmt.Fprintf(&b, "  maxReplicaCount: %d\n", replicas)
+	fmt.Fprintf(&b, "  triggers:\n")
+	for _, schedule := range buildCronSchedules(activeWindows(offWindows)) {
+		fmt.Fprintf(&b, "    # %s\n", schedule.Description)
+		fmt.Fprintf(&b, "    - type: cron\n")
+		fmt.Fprintf(&b, "      metadata:\n")
+		if settings.timezone != "" {
+			fmt.Fprintf(&b, "        timezone: %s\n", settings.timezone)
+		}
+		fmt.Fprintf(&b, "        start: %s\n", schedule.StartCron)
+		fmt.Fprintf(&b, "        end: %s\n", schedule.EndCron)
+		fmt.Fprintf(&b, "        desiredReplicas: \"%d\"\n", replicas)
+	}
+	return b.String()
+}
@@ -0,0 +1,62 @@
+package cost
+
+import (
+	"cluster-resource-insight/internal/config"
+)
+
+const (
+	// HoursPerMonth 月度成本折算使用的小时数
+	HoursPerMonth = 730.0
+
+	defaultCPUCoreHourPrice  = 0.032 // 默认每核CPU每小时单价
+	defaultMemoryGBHourPrice = 0.004 // 默认每GiB内存每小时单价
+)
+
+// Model 资源成本模型 - 将CPU和内存资源量折算为金额
+type Model struct {
+	CPUCoreHourPrice  float64 `json:"cpu_core_hour_price"`  // 每核CPU每小时单价
+	MemoryGBHourPrice float64 `json:"memory_gb_hour_price"` // 每GiB内存每小时单价
+}
+
+// NewModel 创建指定单价的成本模型
+func NewModel(cpuCoreHourPrice, memoryGBHourPrice float64) *Model {
+	return &Model{
+		CPUCoreHourPrice:  cpuCoreHourPrice,
+		MemoryGBHourPrice: memoryGBHourPrice,
+	}
+}
+
+// DefaultModel 根据配置文件创建成本模型，未配置单价时使用默认值
+func DefaultModel() *Model {
+	model := NewModel(defaultCPUCoreHourPrice, defaultMemoryGBHourPrice)
+
+	if costConfig := config.GetCostConfig(); costConfig != nil {
+		if costConfig.CPUCoreHourPrice > 0 {
+			model.CPUCoreHourPrice = costConfig.CPUCoreHourPrice
+		}
+		if costConfig.MemoryGBHourPrice > 0 {
+			model.MemoryGBHourPrice = costConfig.MemoryGBHourPrice
+		}
+	}
+
+	return model
+}
+
+// CPUMonthlyCost 计算CPU资源的月度成本
+// 参数:
+//   - millicores: CPU资源量 (millicores)
+func (m *Model) CPUMonthlyCost(millicores int64) float64 {
+	return float64(millicores) / 1000.0 * m.CPUCoreHourPrice * HoursPerMonth
+}
+
+// MemoryMonthlyCost 计算内存资源的月度成本
+// 参数:
+//   - bytes: 内存资源量 (bytes)
+func (m *Model) MemoryMonthlyCost(bytes int64) float64 {
+	return float64(bytes) / (1024 * 1024 * 1024) * m.MemoryGBHourPrice * HoursPerMonth
+}
+
+// MonthlyCost 计算CPU和内存资源的月度总成本
+func (m *Model) MonthlyCost(millicores, bytes int64) float64 {
+	return m.CPUMonthlyCost(millicores) + m.MemoryMonthlyCost(bytes)
+}
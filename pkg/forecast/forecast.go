@@ -0,0 +1,240 @@
+package forecast
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// minSamplesForTrend 拟合线性趋势所需的最少样本数
+	minSamplesForTrend = 3
+	// minDaysForSeasonality 启用周季节性所需的最少跨度天数（至少覆盖两个完整周）
+	minDaysForSeasonality = 14
+	day                   = 24 * time.Hour
+)
+
+// Point 时间序列数据点
+type Point struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// ForecastPoint 预测数据点 - 包含预测值及置信区间上下界
+type ForecastPoint struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"` // 预测值
+	Lower float64   `json:"lower"` // 置信区间下界
+	Upper float64   `json:"upper"` // 置信区间上界
+}
+
+// Model 线性趋势叠加周季节性的预测模型
+type Model struct {
+	origin      time.Time  // 拟合起点，x轴以距起点的天数计
+	intercept   float64    // 线性趋势截距
+	slope       float64    // 线性趋势斜率（每天变化量）
+	seasonal    [7]float64 // 按星期几的季节性偏移量
+	hasSeason   bool       // 是否启用季节性
+	residualStd float64    // 残差标准差
+	meanX       float64    // x均值，用于计算预测区间
+	sxx         float64    // x离差平方和
+	samples     int        // 样本数量
+	z           float64    // 置信水平对应的正态分位数
+}
+
+// Fit 使用最小二乘法拟合线性趋势，并在数据跨度足够时叠加周季节性
+// 参数:
+//   - history: 按时间排列的历史数据点，建议按天聚合
+//   - confidence: 置信区间水平（0-1之间），非法值按0.95处理
+//
+// 返回:
+//   - *Model: 拟合完成的预测模型
+//   - error: 样本不足时返回错误
+func Fit(history []Point, confidence float64) (*Model, error) {
+	if len(history) < minSamplesForTrend {
+		return nil, fmt.Errorf("历史样本不足，至少需要%d个数据点，当前%d个", minSamplesForTrend, len(history))
+	}
+
+	points := make([]Point, len(history))
+	copy(points, history)
+	sort.Slice(points, func(i, j int) bool { return points[i].Time.Before(points[j].Time) })
+
+	m := &Model{
+		origin:  points[0].Time,
+		samples: len(points),
+		z:       zScore(confidence),
+	}
+
+	xs := make([]float64, len(points))
+	var sumX, sumY float64
+	for i, p := range points {
+		xs[i] = m.x(p.Time)
+		sumX += xs[i]
+		sumY += p.Value
+	}
+	n := float64(len(points))
+	m.meanX = sumX / n
+	meanY := sumY / n
+
+	var sxy float64
+	for i, p := range points {
+		dx := xs[i] - m.meanX
+		m.sxx += dx * dx
+		sxy += dx * (p.Value - meanY)
+	}
+	if m.sxx > 0 {
+		m.slope = sxy / m.sxx
+	}
+	m.intercept = meanY - m.slope*m.meanX
+
+	// 数据跨度覆盖两周以上时，按星期几计算残差均值作为季节性偏移
+	if xs[len(xs)-1]-xs[0] >= minDaysForSeasonality {
+		var sums [7]float64
+		var counts [7]int
+		for i, p := range points {
+			weekday := int(p.Time.Weekday())
+			sums[weekday] += p.Value - (m.intercept + m.slope*xs[i])
+			counts[weekday]++
+		}
+		for d := 0; d < 7; d++ {
+			if counts[d] > 0 {
+				m.seasonal[d] = sums[d] / float64(counts[d])
+			}
+		}
+		m.hasSeason = true
+	}
+
+	// 残差标准差，自由度扣除趋势的两个参数
+	var sse float64
+	for i, p := range points {
+		residual := p.Value - m.predict(xs[i], p.Time)
+		sse += residual * residual
+	}
+	if len(points) > 2 {
+		m.residualStd = math.Sqrt(sse / float64(len(points)-2))
+	}
+
+	return m, nil
+}
+
+// x 将时间转换为距拟合起点的天数
+func (m *Model) x(t time.Time) float64 {
+	return t.Sub(m.origin).Hours() / 24
+}
+
+// predict 计算指定位置的点预测值
+func (m *Model) predict(x float64, t time.Time) float64 {
+	value := m.intercept + m.slope*x
+	if m.hasSeason {
+		value += m.seasonal[int(t.Weekday())]
+	}
+	return value
+}
+
+// Predict 预测指定时间点的值及置信区间
+func (m *Model) Predict(t time.Time) ForecastPoint {
+	x := m.x(t)
+	value := m.predict(x, t)
+
+	// 预测区间随着距离样本中心的增大而变宽
+	spread := 1 + 1/float64(m.samples)
+	if m.sxx > 0 {
+		spread += (x - m.meanX) * (x - m.meanX) / m.sxx
+	}
+	margin := m.z * m.residualStd * math.Sqrt(spread)
+
+	return ForecastPoint{
+		Time:  t,
+		Value: value,
+		Lower: value - margin,
+		Upper: value + margin,
+	}
+}
+
+// Project 从起始时间开始逐天预测指定天数
+func (m *Model) Project(start time.Time, days int) []ForecastPoint {
+	points := make([]ForecastPoint, 0, days)
+	for i := 1; i <= days; i++ {
+		points = append(points, m.Predict(start.Add(time.Duration(i)*day)))
+	}
+	return points
+}
+
+// Slope 返回线性趋势斜率（每天变化量）
+func (m *Model) Slope() float64 {
+	return m.slope
+}
+
+// ResidualStd 返回拟合残差标准差
+func (m *Model) ResidualStd() float64 {
+	return m.residualStd
+}
+
+// DaysUntil 估算预测值达到阈值所需的天数
+// 参数:
+//   - threshold: 阈值，如可分配容量或配额上限
+//   - from: 起始时间
+//   - maxDays: 最大搜索天数
+//
+// 返回:
+//   - expected: 按预测值计算的耗尽天数，未在搜索范围内耗尽时为-1
+//   - pessimistic: 按置信区间上界计算的耗尽天数，未在搜索范围内耗尽时为-1
+func (m *Model) DaysUntil(threshold float64, from time.Time, maxDays int) (expected, pessimistic int) {
+	expected, pessimistic = -1, -1
+	for i := 0; i <= maxDays; i++ {
+		point := m.Predict(from.Add(time.Duration(i) * day))
+		if pessimistic < 0 && point.Upper >= threshold {
+			pessimistic = i
+		}
+		if point.Value >= threshold {
+			expected = i
+			break
+		}
+	}
+	return expected, pessimistic
+}
+
+// AggregateDaily 将时间序列按自然日聚合为日均值
+func AggregateDaily(points []Point) []Point {
+	type bucket struct {
+		sum   float64
+		count int
+	}
+	buckets := make(map[time.Time]*bucket)
+	for _, p := range points {
+		key := time.Date(p.Time.Year(), p.Time.Month(), p.Time.Day(), 0, 0, 0, 0, p.Time.Location())
+		b, exists := buckets[key]
+		if !exists {
+			b = &bucket{}
+			buckets[key] = b
+		}
+		b.sum += p.Value
+		b.count++
+	}
+
+	daily := make([]Point, 0, len(buckets))
+	for t, b := range buckets {
+		daily = append(daily, Point{Time: t, Value: b.sum / float64(b.count)})
+	}
+	sort.Slice(daily, func(i, j int) bool { return daily[i].Time.Before(daily[j].Time) })
+	return daily
+}
+
+// zScore 返回常用置信水平对应的双侧正态分位数
+func zScore(confidence float64) float64 {
+	switch {
+	case confidence >= 0.99:
+		return 2.576
+	case confidence >= 0.95:
+		return 1.960
+	case confidence >= 0.90:
+		return 1.645
+	case confidence >= 0.80:
+		return 1.282
+	case confidence > 0:
+		return 1.0
+	default:
+		return 1.960
+	}
+}
@@ -0,0 +1,249 @@
+package forecast
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// base 测试序列起点，2024-01-01 为星期一
+var base = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// linearSeries 生成逐日线性增长的序列
+func linearSeries(days int, start, slope float64) []Point {
+	points := make([]Point, days)
+	for i := range points {
+		points[i] = Point{Time: base.Add(time.Duration(i) * day), Value: start + slope*float64(i)}
+	}
+	return points
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestFitRequiresMinimumSamples(t *testing.T) {
+	for _, n := range []int{0, 1, minSamplesForTrend - 1} {
+		if _, err := Fit(linearSeries(n, 10, 1), 0.95); err == nil {
+			t.Errorf("%d 个样本应返回错误", n)
+		}
+	}
+	if _, err := Fit(linearSeries(minSamplesForTrend, 10, 1), 0.95); err != nil {
+		t.Errorf("%d 个样本不应返回错误: %v", minSamplesForTrend, err)
+	}
+}
+
+func TestFitLinearTrend(t *testing.T) {
+	tests := []struct {
+		name  string
+		start float64
+		slope float64
+	}{
+		{"增长", 100, 5},
+		{"下降", 100, -2.5},
+		{"持平", 42, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model, err := Fit(linearSeries(10, tt.start, tt.slope), 0.95)
+			if err != nil {
+				t.Fatalf("拟合失败: %v", err)
+			}
+			if !almostEqual(model.Slope(), tt.slope) {
+				t.Errorf("斜率 = %v, 期望 %v", model.Slope(), tt.slope)
+			}
+			if !almostEqual(model.ResidualStd(), 0) {
+				t.Errorf("完全线性的序列残差应为0，实际 %v", model.ResidualStd())
+			}
+			point := model.Predict(base.Add(20 * day))
+			if want := tt.start + tt.slope*20; !almostEqual(point.Value, want) {
+				t.Errorf("第20天预测值 = %v, 期望 %v", point.Value, want)
+			}
+		})
+	}
+}
+
+func TestFitSortsInput(t *testing.T) {
+	points := linearSeries(6, 10, 2)
+	reversed := make([]Point, len(points))
+	for i, p := range points {
+		reversed[len(points)-1-i] = p
+	}
+	model, err := Fit(reversed, 0.95)
+	if err != nil {
+		t.Fatalf("拟合失败: %v", err)
+	}
+	if !almostEqual(model.Slope(), 2) {
+		t.Errorf("乱序输入的斜率 = %v, 期望 2", model.Slope())
+	}
+	if !reversed[0].Time.Equal(points[len(points)-1].Time) {
+		t.Error("Fit 不应修改输入切片")
+	}
+}
+
+func TestFitWeeklySeasonality(t *testing.T) {
+	// 四周的平稳序列，周末比工作日低20
+	var points []Point
+	for i := 0; i < 28; i++ {
+		ts := base.Add(time.Duration(i) * day)
+		value := 100.0
+		if ts.Weekday() == time.Saturday || ts.Weekday() == time.Sunday {
+			value = 80
+		}
+		points = append(points, Point{Time: ts, Value: value})
+	}
+	model, err := Fit(points, 0.95)
+	if err != nil {
+		t.Fatalf("拟合失败: %v", err)
+	}
+
+	saturdayAt := base.Add(33 * day)
+	if saturdayAt.Weekday() != time.Saturday {
+		t.Fatalf("测试日期应为星期六，实际 %v", saturdayAt.Weekday())
+	}
+	monday := model.Predict(base.Add(28 * day))
+	saturday := model.Predict(saturdayAt)
+	if diff := monday.Value - saturday.Value; math.Abs(diff-20) > 1 {
+		t.Errorf("工作日与周末的预测差 = %v, 期望约 20", diff)
+	}
+
+	// 跨度不足两周时不启用季节性
+	short, err := Fit(points[:10], 0.95)
+	if err != nil {
+		t.Fatalf("拟合失败: %v", err)
+	}
+	if short.hasSeason {
+		t.Error("跨度不足两周时不应启用季节性")
+	}
+}
+
+func TestPredictIntervalWidensWithDistance(t *testing.T) {
+	points := linearSeries(10, 100, 3)
+	// 加入交替噪声，使残差不为0
+	for i := range points {
+		if i%2 == 0 {
+			points[i].Value += 4
+		} else {
+			points[i].Value -= 4
+		}
+	}
+	model, err := Fit(points, 0.95)
+	if err != nil {
+		t.Fatalf("拟合失败: %v", err)
+	}
+
+	near := model.Predict(base.Add(10 * day))
+	far := model.Predict(base.Add(60 * day))
+	if !(near.Lower < near.Value && near.Value < near.Upper) {
+		t.Errorf("预测值应位于置信区间内: %+v", near)
+	}
+	if far.Upper-far.Lower <= near.Upper-near.Lower {
+		t.Errorf("远期区间宽度 %v 应大于近期 %v", far.Upper-far.Lower, near.Upper-near.Lower)
+	}
+
+	narrow, _ := Fit(points, 0.80)
+	wide, _ := Fit(points, 0.99)
+	at := base.Add(20 * day)
+	if narrow.Predict(at).Upper >= wide.Predict(at).Upper {
+		t.Error("置信水平越高区间应越宽")
+	}
+}
+
+func TestDaysUntil(t *testing.T) {
+	model, err := Fit(linearSeries(10, 100, 10), 0.95)
+	if err != nil {
+		t.Fatalf("拟合失败: %v", err)
+	}
+	from := base.Add(9 * day) // 当前值 190
+
+	tests := []struct {
+		name      string
+		threshold float64
+		maxDays   int
+		want      int
+	}{
+		{"已超过阈值", 150, 30, 0},
+		{"10天后达到", 290, 30, 10},
+		{"超出搜索范围", 1000, 30, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected, pessimistic := model.DaysUntil(tt.threshold, from, tt.maxDays)
+			if expected != tt.want {
+				t.Errorf("耗尽天数 = %d, 期望 %d", expected, tt.want)
+			}
+			// 残差为0时上界与预测值一致
+			if pessimistic != tt.want {
+				t.Errorf("悲观耗尽天数 = %d, 期望 %d", pessimistic, tt.want)
+			}
+		})
+	}
+
+	// 下降趋势永远不会耗尽
+	declining, _ := Fit(linearSeries(10, 100, -1), 0.95)
+	if expected, _ := declining.DaysUntil(200, from, 365); expected != -1 {
+		t.Errorf("下降趋势的耗尽天数 = %d, 期望 -1", expected)
+	}
+}
+
+func TestProject(t *testing.T) {
+	model, err := Fit(linearSeries(5, 0, 1), 0.95)
+	if err != nil {
+		t.Fatalf("拟合失败: %v", err)
+	}
+	points := model.Project(base.Add(4*day), 3)
+	if len(points) != 3 {
+		t.Fatalf("预测点数 = %d, 期望 3", len(points))
+	}
+	for i, point := range points {
+		if want := float64(5 + i); !almostEqual(point.Value, want) {
+			t.Errorf("第%d个预测值 = %v, 期望 %v", i, point.Value, want)
+		}
+	}
+}
+
+func TestAggregateDaily(t *testing.T) {
+	points := []Point{
+		{Time: base.Add(26 * time.Hour), Value: 30},
+		{Time: base.Add(1 * time.Hour), Value: 10},
+		{Time: base.Add(23 * time.Hour), Value: 20},
+		{Time: base.Add(25 * time.Hour), Value: 10},
+	}
+	daily := AggregateDaily(points)
+	want := []Point{
+		{Time: base, Value: 15},
+		{Time: base.Add(day), Value: 20},
+	}
+	if len(daily) != len(want) {
+		t.Fatalf("日聚合点数 = %d, 期望 %d", len(daily), len(want))
+	}
+	for i := range want {
+		if !daily[i].Time.Equal(want[i].Time) || !almostEqual(daily[i].Value, want[i].Value) {
+			t.Errorf("第%d天 = %+v, 期望 %+v", i, daily[i], want[i])
+		}
+	}
+
+	if got := AggregateDaily(nil); len(got) != 0 {
+		t.Errorf("空输入应返回空结果，实际 %v", got)
+	}
+}
+
+func TestZScore(t *testing.T) {
+	tests := []struct {
+		confidence float64
+		want       float64
+	}{
+		{0.99, 2.576},
+		{0.95, 1.960},
+		{0.9, 1.645},
+		{0.8, 1.282},
+		{0.5, 1.0},
+		{0, 1.960},
+		{-1, 1.960},
+	}
+	for _, tt := range tests {
+		if got := zScore(tt.confidence); got != tt.want {
+			t.Errorf("zScore(%v) = %v, 期望 %v", tt.confidence, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,744 @@
+package policy
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expression 编译后的策略表达式
+// 语法为CEL的一个子集：
+//   - 字面量：数字、"字符串"/'字符串'、true/false/null、列表 [a, b]
+//   - 变量和字段访问：pod.memory_limit、namespace.labels["tier"]、namespace.labels.tier，不存在的字段取值为null
+//   - 运算符：|| && ! == != < <= > >= + - * / % in
+//   - 函数：size(x)、has(x)、startsWith/endsWith/contains/glob(s, pattern)，也可写作方法调用 s.startsWith("prod")
+//     glob 使用通配符（*、?）匹配，不是CEL的正则匹配 matches
+type Expression struct {
+	source string
+	root   node
+}
+
+// Compile 编译表达式
+func Compile(source string) (*Expression, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, fmt.Errorf("表达式第 %d 个字符处存在多余内容: %s", p.peek().pos+1, p.peek().text)
+	}
+
+	return &Expression{source: source, root: root}, nil
+}
+
+// String 返回表达式源码
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Eval 在给定变量环境中求值
+func (e *Expression) Eval(env map[string]interface{}) (interface{}, error) {
+	return e.root.eval(env)
+}
+
+// EvalBool 求值并要求结果为布尔值，null视为false
+func (e *Expression) EvalBool(env map[string]interface{}) (bool, error) {
+	value, err := e.Eval(env)
+	if err != nil {
+		return false, err
+	}
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case nil:
+		return false, nil
+	}
+	return false, fmt.Errorf("表达式结果不是布尔值: %v", value)
+}
+
+// ---- 词法分析 ----
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOperator
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// twoCharOperators 双字符运算符
+var twoCharOperators = map[string]bool{"||": true, "&&": true, "==": true, "!=": true, "<=": true, ">=": true}
+
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	runes := []rune(source)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokenNumber, string(runes[start:i]), start})
+		case r == '"' || r == '\'':
+			start := i
+			i++
+			var b strings.Builder
+			for i < len(runes) && runes[i] != r {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				b.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("表达式第 %d 个字符处的字符串未闭合", start+1)
+			}
+			i++
+			tokens = append(tokens, token{tokenString, b.String(), start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{tokenIdent, string(runes[start:i]), start})
+		default:
+			if i+1 < len(runes) && twoCharOperators[string(runes[i:i+2])] {
+				tokens = append(tokens, token{tokenOperator, string(runes[i : i+2]), i})
+				i += 2
+				continue
+			}
+			if strings.ContainsRune("!<>+-*/%()[],.", r) {
+				tokens = append(tokens, token{tokenOperator, string(r), i})
+				i++
+				continue
+			}
+			return nil, fmt.Errorf("表达式第 %d 个字符处存在无法识别的字符: %c", i+1, r)
+		}
+	}
+
+	return append(tokens, token{tokenEOF, "", len(runes)}), nil
+}
+
+// ---- 语法分析 ----
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isOperator(text string) bool {
+	t := p.peek()
+	return (t.kind == tokenOperator || t.kind == tokenIdent) && t.text == text
+}
+
+func (p *parser) expect(text string) error {
+	if !p.isOperator(text) {
+		t := p.peek()
+		return fmt.Errorf("表达式第 %d 个字符处应为 %s", t.pos+1, text)
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("&&") {
+		p.next()
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">", "in"} {
+		if p.isOperator(op) {
+			p.next()
+			right, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			return &binaryNode{op: op, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAdditive() (node, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("+") || p.isOperator("-") {
+		op := p.next().text
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseMultiplicative() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("*") || p.isOperator("/") || p.isOperator("%") {
+		op := p.next().text
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.isOperator("!") || p.isOperator("-") {
+		op := p.next().text
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: op, operand: operand}, nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *parser) parsePostfix() (node, error) {
+	current, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch {
+		case p.isOperator("."):
+			p.next()
+			name := p.next()
+			if name.kind != tokenIdent {
+				return nil, fmt.Errorf("表达式第 %d 个字符处应为字段名", name.pos+1)
+			}
+			if p.isOperator("(") {
+				args, err := p.parseArgs()
+				if err != nil {
+					return nil, err
+				}
+				current, err = newCallNode(name.text, append([]node{current}, args...))
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			current = &fieldNode{target: current, key: &literalNode{value: name.text}}
+		case p.isOperator("["):
+			p.next()
+			key, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			current = &fieldNode{target: current, key: key}
+		default:
+			return current, nil
+		}
+	}
+}
+
+func (p *parser) parseArgs() ([]node, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var args []node
+	for !p.isOperator(")") {
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if !p.isOperator(",") {
+			break
+		}
+		p.next()
+	}
+	return args, p.expect(")")
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		value, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("表达式第 %d 个字符处的数字格式错误: %s", t.pos+1, t.text)
+		}
+		return &literalNode{value: value}, nil
+	case tokenString:
+		return &literalNode{value: t.text}, nil
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		}
+		if p.isOperator("(") {
+			args, err := p.parseArgs()
+			if err != nil {
+				return nil, err
+			}
+			return newCallNode(t.text, args)
+		}
+		return &identNode{name: t.text}, nil
+	case tokenOperator:
+		switch t.text {
+		case "(":
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return inner, p.expect(")")
+		case "[":
+			list := &listNode{}
+			for !p.isOperator("]") {
+				item, err := p.parseOr()
+				if err != nil {
+					return nil, err
+				}
+				list.items = append(list.items, item)
+				if !p.isOperator(",") {
+					break
+				}
+				p.next()
+			}
+			return list, p.expect("]")
+		}
+	case tokenEOF:
+		return nil, fmt.Errorf("表达式不完整")
+	}
+	return nil, fmt.Errorf("表达式第 %d 个字符处存在非法内容: %s", t.pos+1, t.text)
+}
+
+// ---- 语法树与求值 ----
+
+type node interface {
+	eval(env map[string]interface{}) (interface{}, error)
+}
+
+type literalNode struct{ value interface{} }
+
+func (n *literalNode) eval(map[string]interface{}) (interface{}, error) { return n.value, nil }
+
+type identNode struct{ name string }
+
+func (n *identNode) eval(env map[string]interface{}) (interface{}, error) {
+	return normalize(env[n.name]), nil
+}
+
+type listNode struct{ items []node }
+
+func (n *listNode) eval(env map[string]interface{}) (interface{}, error) {
+	values := make([]interface{}, 0, len(n.items))
+	for _, item := range n.items {
+		value, err := item.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+type fieldNode struct {
+	target node
+	key    node
+}
+
+func (n *fieldNode) eval(env map[string]interface{}) (interface{}, error) {
+	target, err := n.target.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	key, err := n.key.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := target.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		name, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("字段名必须为字符串: %v", key)
+		}
+		return normalize(t[name]), nil
+	case []interface{}:
+		index, ok := key.(float64)
+		if !ok {
+			return nil, fmt.Errorf("列表下标必须为数字: %v", key)
+		}
+		if int(index) < 0 || int(index) >= len(t) {
+			return nil, nil
+		}
+		return t[int(index)], nil
+	}
+	return nil, fmt.Errorf("无法访问 %v 的字段 %v", target, key)
+}
+
+type unaryNode struct {
+	op      string
+	operand node
+}
+
+func (n *unaryNode) eval(env map[string]interface{}) (interface{}, error) {
+	value, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		b, err := asBool(value)
+		if err != nil {
+			return nil, err
+		}
+		return !b, nil
+	}
+	number, ok := value.(float64)
+	if !ok {
+		return nil, fmt.Errorf("取负运算的操作数不是数字: %v", value)
+	}
+	return -number, nil
+}
+
+type logicalNode struct {
+	op          string
+	left, right node
+}
+
+func (n *logicalNode) eval(env map[string]interface{}) (interface{}, error) {
+	leftValue, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	left, err := asBool(leftValue)
+	if err != nil {
+		return nil, err
+	}
+	// 短路求值
+	if n.op == "||" && left {
+		return true, nil
+	}
+	if n.op == "&&" && !left {
+		return false, nil
+	}
+
+	rightValue, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	return asBool(rightValue)
+}
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+func (n *binaryNode) eval(env map[string]interface{}) (interface{}, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "in":
+		return contains(right, left)
+	case "+":
+		if ls, ok := left.(string); ok {
+			if rs, ok := right.(string); ok {
+				return ls + rs, nil
+			}
+		}
+	}
+
+	// 比较运算同时支持数字和字符串
+	if ls, ok := left.(string); ok {
+		if rs, ok := right.(string); ok {
+			switch n.op {
+			case "<":
+				return ls < rs, nil
+			case "<=":
+				return ls <= rs, nil
+			case ">":
+				return ls > rs, nil
+			case ">=":
+				return ls >= rs, nil
+			}
+		}
+	}
+
+	l, lok := left.(float64)
+	r, rok := right.(float64)
+	if !lok || !rok {
+		return nil, fmt.Errorf("运算 %s 的操作数类型不匹配: %v, %v", n.op, left, right)
+	}
+	switch n.op {
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	case ">=":
+		return l >= r, nil
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/", "%":
+		if r == 0 {
+			return nil, fmt.Errorf("除数为0")
+		}
+		if n.op == "/" {
+			return l / r, nil
+		}
+		return float64(int64(l) % int64(r)), nil
+	}
+	return nil, fmt.Errorf("不支持的运算符: %s", n.op)
+}
+
+// builtinFunctions 内置函数及参数个数
+var builtinFunctions = map[string]int{
+	"size":       1,
+	"has":        1,
+	"startsWith": 2,
+	"endsWith":   2,
+	"contains":   2,
+	"glob":       2,
+}
+
+type callNode struct {
+	name string
+	args []node
+}
+
+func newCallNode(name string, args []node) (node, error) {
+	arity, ok := builtinFunctions[name]
+	if !ok {
+		return nil, fmt.Errorf("不支持的函数: %s", name)
+	}
+	if len(args) != arity {
+		return nil, fmt.Errorf("函数 %s 需要 %d 个参数，实际 %d 个", name, arity, len(args))
+	}
+	return &callNode{name: name, args: args}, nil
+}
+
+func (n *callNode) eval(env map[string]interface{}) (interface{}, error) {
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		value, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+
+	switch n.name {
+	case "has":
+		if s, ok := args[0].(string); ok {
+			return s != "", nil
+		}
+		return args[0] != nil, nil
+	case "size":
+		switch v := args[0].(type) {
+		case string:
+			return float64(len([]rune(v))), nil
+		case []interface{}:
+			return float64(len(v)), nil
+		case map[string]interface{}:
+			return float64(len(v)), nil
+		case nil:
+			return float64(0), nil
+		}
+		return nil, fmt.Errorf("size 不支持的参数类型: %v", args[0])
+	}
+
+	s, sok := args[0].(string)
+	pattern, pok := args[1].(string)
+	if args[0] == nil {
+		return false, nil
+	}
+	if n.name == "contains" && !sok {
+		return contains(args[0], args[1])
+	}
+	if !sok || !pok {
+		return nil, fmt.Errorf("%s 的参数必须为字符串", n.name)
+	}
+	switch n.name {
+	case "startsWith":
+		return strings.HasPrefix(s, pattern), nil
+	case "endsWith":
+		return strings.HasSuffix(s, pattern), nil
+	case "contains":
+		return strings.Contains(s, pattern), nil
+	default:
+		matched, err := path.Match(pattern, s)
+		if err != nil {
+			return nil, fmt.Errorf("通配符格式错误: %s", pattern)
+		}
+		return matched, nil
+	}
+}
+
+// ---- 辅助函数 ----
+
+// normalize 将各种整数类型统一转换为float64
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	case map[string]string:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[k] = val
+		}
+		return m
+	case []string:
+		list := make([]interface{}, len(v))
+		for i, val := range v {
+			list[i] = val
+		}
+		return list
+	}
+	return value
+}
+
+func asBool(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case nil:
+		return false, nil
+	}
+	return false, fmt.Errorf("逻辑运算的操作数不是布尔值: %v", value)
+}
+
+func equal(left, right interface{}) bool {
+	switch l := left.(type) {
+	case nil:
+		return right == nil
+	case float64, string, bool:
+		return l == right
+	}
+	return false
+}
+
+func contains(collection, item interface{}) (interface{}, error) {
+	switch c := collection.(type) {
+	case []interface{}:
+		for _, element := range c {
+			if equal(element, item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case map[string]interface{}:
+		key, ok := item.(string)
+		if !ok {
+			return false, nil
+		}
+		_, exists := c[key]
+		return exists, nil
+	case string:
+		s, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("字符串只能包含字符串: %v", item)
+		}
+		return strings.Contains(c, s), nil
+	case nil:
+		return false, nil
+	}
+	return nil, fmt.Errorf("in 运算的右侧必须为列表、映射或字符串: %v", collection)
+}
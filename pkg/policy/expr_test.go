@@ -0,0 +1,204 @@
+package policy
+
+import (
+	"testing"
+)
+
+// testEnv 表达式测试使用的变量环境，字段类型与策略求值时一致
+func testEnv() map[string]interface{} {
+	return map[string]interface{}{
+		"namespace": map[string]interface{}{
+			"name":      "prod-payments",
+			"labels":    map[string]string{"tier": "critical"},
+			"pod_count": 3,
+		},
+		"pod": map[string]interface{}{
+			"name":          "api-0",
+			"qos_class":     "Burstable",
+			"restart_count": int32(2),
+			"cpu_request":   int64(500),
+			"labels":        map[string]string{},
+		},
+		"names": []string{"a", "b"},
+	}
+}
+
+func TestEvalPrecedence(t *testing.T) {
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		{"1 + 2 * 3", 7.0},
+		{"(1 + 2) * 3", 9.0},
+		{"10 - 4 - 3", 3.0},
+		{"12 / 2 / 3", 2.0},
+		{"7 % 4 + 1", 4.0},
+		{"-2 * 3", -6.0},
+		{"--2", 2.0},
+		{"1 + 2 * 3 == 7", true},
+		{"true || false && false", true},
+		{"(true || false) && false", false},
+		{"false && false || true", true},
+		{"!false && false", false},
+		{"!(false && false)", true},
+		{"pod.cpu_request / 100 in [5, 6]", true},
+		{`"prod" + "-" + "payments" == namespace.name`, true},
+		{`"a" < "b" && "b" >= "b"`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expression, err := Compile(tt.expr)
+			if err != nil {
+				t.Fatalf("编译失败: %v", err)
+			}
+			got, err := expression.Eval(testEnv())
+			if err != nil {
+				t.Fatalf("求值失败: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("结果 = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalNullHandling(t *testing.T) {
+	tests := []struct {
+		expr string
+		want interface{}
+	}{
+		{"pod.missing", nil},
+		{"pod.missing == null", true},
+		{"pod.missing != null", false},
+		{"pod.missing.deeper == null", true},
+		{"unknown.field == null", true},
+		{`namespace.labels["owner"] == null`, true},
+		{"pod.labels.tier == null", true},
+		{"has(pod.missing)", false},
+		{"has(pod.name)", true},
+		{`has(pod.labels.tier)`, false},
+		{"size(pod.missing)", 0.0},
+		{"size(namespace.labels)", 1.0},
+		{"size(names)", 2.0},
+		{"pod.missing || true", true},
+		{"pod.missing && true", false},
+		{"!pod.missing", true},
+		{`pod.missing.startsWith("a")`, false},
+		{`pod.missing.glob("*")`, false},
+		{`"a" in pod.missing`, false},
+		{"null in [1, null]", true},
+		{"names[5] == null", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expression, err := Compile(tt.expr)
+			if err != nil {
+				t.Fatalf("编译失败: %v", err)
+			}
+			got, err := expression.Eval(testEnv())
+			if err != nil {
+				t.Fatalf("求值失败: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("结果 = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalBoolTreatsNullAsFalse(t *testing.T) {
+	expression, err := Compile("pod.missing")
+	if err != nil {
+		t.Fatalf("编译失败: %v", err)
+	}
+	got, err := expression.EvalBool(testEnv())
+	if err != nil || got {
+		t.Errorf("EvalBool(null) = %v, %v, 期望 false, nil", got, err)
+	}
+
+	expression, _ = Compile("pod.name")
+	if _, err := expression.EvalBool(testEnv()); err == nil {
+		t.Error("非布尔结果应返回错误")
+	}
+}
+
+func TestStringFunctions(t *testing.T) {
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`namespace.name.startsWith("prod")`, true},
+		{`startsWith(namespace.name, "dev")`, false},
+		{`namespace.name.endsWith("payments")`, true},
+		{`namespace.name.contains("pay")`, true},
+		{`names.contains("b")`, true},
+		{`namespace.name.glob("prod-*")`, true},
+		{`namespace.name.glob("*-prod")`, false},
+		{`namespace.name.glob("prod-????????")`, true},
+		{`"tier" in namespace.labels`, true},
+		{`"pay" in namespace.name`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expression, err := Compile(tt.expr)
+			if err != nil {
+				t.Fatalf("编译失败: %v", err)
+			}
+			got, err := expression.EvalBool(testEnv())
+			if err != nil {
+				t.Fatalf("求值失败: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("结果 = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"1 +",
+		"(1 + 2",
+		`"unterminated`,
+		"1 2",
+		"2 * 3 > 5 == true", // 比较运算不可链式
+		"pod.",
+		"a # b",
+		`namespace.name.matches("prod-.*")`, // 不支持CEL的正则匹配，通配符请使用 glob
+		`size()`,
+		`startsWith("a")`,
+	}
+	for _, source := range tests {
+		t.Run(source, func(t *testing.T) {
+			if _, err := Compile(source); err == nil {
+				t.Errorf("表达式 %q 应编译失败", source)
+			}
+		})
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	tests := []string{
+		"1 / 0",
+		"5 % 0",
+		"pod.missing > 0",
+		`pod.name > 1`,
+		"pod.name && true",
+		"-pod.name",
+		`pod.cpu_request.startsWith("5")`,
+		`namespace.name.glob("[")`,
+		"1 in 2",
+	}
+	for _, source := range tests {
+		t.Run(source, func(t *testing.T) {
+			expression, err := Compile(source)
+			if err != nil {
+				t.Fatalf("编译失败: %v", err)
+			}
+			if _, err := expression.Eval(testEnv()); err == nil {
+				t.Errorf("表达式 %q 应求值失败", source)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+
+	"sigs.k8s.io/yaml"
+)
+
+// 策略作用对象
+const (
+	TargetContainer = "container" // 对每个容器求值，变量：cluster、namespace、pod、container
+	TargetPod       = "pod"       // 对每个Pod求值，变量：cluster、namespace、pod
+	TargetNamespace = "namespace" // 对每个命名空间求值，变量：cluster、namespace
+)
+
+// 违规严重程度
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityError    = "error"
+	SeverityCritical = "critical"
+)
+
+// severityWeights 计算合规评分时各严重程度的权重
+var severityWeights = map[string]float64{
+	SeverityInfo:     1,
+	SeverityWarning:  2,
+	SeverityError:    3,
+	SeverityCritical: 5,
+}
+
+// Rule 声明式策略定义
+type Rule struct {
+	Name        string `json:"name"`                  // 策略名称，唯一
+	Description string `json:"description,omitempty"` // 策略说明，违规时作为提示信息
+	Severity    string `json:"severity,omitempty"`    // 严重程度，默认warning
+	Target      string `json:"target,omitempty"`      // 作用对象：container/pod/namespace，默认pod
+	When        string `json:"when,omitempty"`        // 适用条件表达式，为空时适用于全部对象
+	Rule        string `json:"rule"`                  // 合规条件表达式，结果为false时记为违规
+}
+
+// Policy 编译后的策略
+type Policy struct {
+	Rule
+	when *Expression
+	rule *Expression
+}
+
+// Set 策略集合
+type Set struct {
+	Policies []*Policy `json:"policies"`
+	Errors   []string  `json:"errors"` // 无法编译的策略及原因
+}
+
+// file 策略文件结构
+type file struct {
+	Policies []Rule `json:"policies"`
+}
+
+// LoadFile 从YAML文件加载策略
+func LoadFile(filePath string) (*Set, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("读取策略文件失败: %v", err)
+	}
+	return Parse(data)
+}
+
+// Parse 解析并编译YAML格式的策略，单条策略有误时跳过并记录原因，不影响其他策略
+func Parse(data []byte) (*Set, error) {
+	var f file
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("解析策略文件失败: %v", err)
+	}
+
+	set := &Set{Policies: []*Policy{}, Errors: []string{}}
+	seen := make(map[string]bool)
+	for _, rule := range f.Policies {
+		policy, err := compileRule(rule)
+		if err == nil && seen[rule.Name] {
+			err = fmt.Errorf("策略名称重复")
+		}
+		if err != nil {
+			set.Errors = append(set.Errors, fmt.Sprintf("策略 %q: %v", rule.Name, err))
+			continue
+		}
+		seen[rule.Name] = true
+		set.Policies = append(set.Policies, policy)
+	}
+
+	return set, nil
+}
+
+// compileRule 校验策略字段并编译表达式
+func compileRule(rule Rule) (*Policy, error) {
+	if rule.Name == "" {
+		return nil, fmt.Errorf("缺少策略名称")
+	}
+	if rule.Rule == "" {
+		return nil, fmt.Errorf("缺少合规条件")
+	}
+	if rule.Severity == "" {
+		rule.Severity = SeverityWarning
+	}
+	if _, ok := severityWeights[rule.Severity]; !ok {
+		return nil, fmt.Errorf("无效的严重程度: %s", rule.Severity)
+	}
+	if rule.Target == "" {
+		rule.Target = TargetPod
+	}
+	switch rule.Target {
+	case TargetContainer, TargetPod, TargetNamespace:
+	default:
+		return nil, fmt.Errorf("无效的作用对象: %s", rule.Target)
+	}
+
+	policy := &Policy{Rule: rule}
+	var err error
+	if rule.When != "" {
+		if policy.when, err = Compile(rule.When); err != nil {
+			return nil, fmt.Errorf("适用条件编译失败: %v", err)
+		}
+	}
+	if policy.rule, err = Compile(rule.Rule); err != nil {
+		return nil, fmt.Errorf("合规条件编译失败: %v", err)
+	}
+	return policy, nil
+}
+
+// Evaluate 对单个对象求值
+// 返回:
+//   - bool: 策略是否适用于该对象
+//   - bool: 是否合规
+//   - error: 求值错误
+func (p *Policy) Evaluate(env map[string]interface{}) (bool, bool, error) {
+	if p.when != nil {
+		applies, err := p.when.EvalBool(env)
+		if err != nil {
+			return false, false, fmt.Errorf("适用条件求值失败: %v", err)
+		}
+		if !applies {
+			return false, false, nil
+		}
+	}
+
+	passed, err := p.rule.EvalBool(env)
+	if err != nil {
+		return true, false, fmt.Errorf("合规条件求值失败: %v", err)
+	}
+	return true, passed, nil
+}
+
+// Weight 返回策略严重程度对应的评分权重
+func (p *Policy) Weight() float64 {
+	return severityWeights[p.Severity]
+}
@@ -0,0 +1,142 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+)
+
+// shippedPolicies 加载仓库根目录随程序发布的策略文件
+func shippedPolicies(t *testing.T) map[string]*Policy {
+	t.Helper()
+	set, err := LoadFile("../../policies.yaml")
+	if err != nil {
+		t.Fatalf("加载策略文件失败: %v", err)
+	}
+	if len(set.Errors) > 0 {
+		t.Fatalf("策略编译失败: %v", set.Errors)
+	}
+	policies := make(map[string]*Policy, len(set.Policies))
+	for _, p := range set.Policies {
+		policies[p.Name] = p
+	}
+	return policies
+}
+
+// policyEnv 构造与策略求值时相同结构的变量环境，container 为nil时不提供容器变量
+func policyEnv(namespace string, labels map[string]string, pod, container map[string]interface{}) map[string]interface{} {
+	env := map[string]interface{}{
+		"cluster":   map[string]interface{}{"name": "test", "id": 1},
+		"namespace": map[string]interface{}{"name": namespace, "labels": labels, "pod_count": 1},
+		"pod":       pod,
+	}
+	if container != nil {
+		env["container"] = container
+	}
+	return env
+}
+
+func containerEnv(cpuRequest, cpuLimit, memoryRequest, memoryLimit int64) map[string]interface{} {
+	return map[string]interface{}{
+		"name":           "app",
+		"cpu_request":    cpuRequest,
+		"cpu_limit":      cpuLimit,
+		"memory_request": memoryRequest,
+		"memory_limit":   memoryLimit,
+	}
+}
+
+func TestShippedPolicies(t *testing.T) {
+	policies := shippedPolicies(t)
+	if len(policies) != 3 {
+		t.Fatalf("策略数量 = %d, 期望 3", len(policies))
+	}
+
+	const mi = 1024 * 1024
+	burstable := map[string]interface{}{"name": "api-0", "qos_class": "Burstable"}
+	besteffort := map[string]interface{}{"name": "job-0", "qos_class": "BestEffort"}
+
+	tests := []struct {
+		name        string
+		policy      string
+		env         map[string]interface{}
+		wantApplies bool
+		wantPassed  bool
+	}{
+		{"设置了内存限制", "container-memory-limit",
+			policyEnv("default", nil, burstable, containerEnv(100, 0, 128*mi, 256*mi)), true, true},
+		{"未设置内存限制", "container-memory-limit",
+			policyEnv("default", nil, burstable, containerEnv(100, 200, 128*mi, 0)), true, false},
+
+		{"非生产命名空间不适用", "prod-limit-request-ratio",
+			policyEnv("staging", nil, burstable, containerEnv(100, 1000, 0, 0)), false, false},
+		{"prod 比例为2", "prod-limit-request-ratio",
+			policyEnv("prod", nil, burstable, containerEnv(100, 200, 128*mi, 256*mi)), true, true},
+		{"prod-* CPU比例超过2", "prod-limit-request-ratio",
+			policyEnv("prod-payments", nil, burstable, containerEnv(100, 300, 128*mi, 256*mi)), true, false},
+		{"*-prod 有请求无限制", "prod-limit-request-ratio",
+			policyEnv("payments-prod", nil, burstable, containerEnv(100, 200, 128*mi, 0)), true, false},
+		{"只设置限制", "prod-limit-request-ratio",
+			policyEnv("prod", nil, burstable, containerEnv(0, 500, 0, 512*mi)), true, true},
+		{"名称含prod但不匹配", "prod-limit-request-ratio",
+			policyEnv("preprod1", nil, burstable, containerEnv(100, 1000, 0, 0)), false, false},
+
+		{"critical 命名空间的 Burstable Pod", "critical-no-besteffort",
+			policyEnv("payments", map[string]string{"tier": "critical"}, burstable, nil), true, true},
+		{"critical 命名空间的 BestEffort Pod", "critical-no-besteffort",
+			policyEnv("payments", map[string]string{"tier": "critical"}, besteffort, nil), true, false},
+		{"无标签的命名空间不适用", "critical-no-besteffort",
+			policyEnv("batch", nil, besteffort, nil), false, false},
+		{"其他tier不适用", "critical-no-besteffort",
+			policyEnv("batch", map[string]string{"tier": "batch"}, besteffort, nil), false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ok := policies[tt.policy]
+			if !ok {
+				t.Fatalf("策略 %s 不存在", tt.policy)
+			}
+			applies, passed, err := p.Evaluate(tt.env)
+			if err != nil {
+				t.Fatalf("求值失败: %v", err)
+			}
+			if applies != tt.wantApplies || passed != tt.wantPassed {
+				t.Errorf("适用=%v 合规=%v, 期望 适用=%v 合规=%v", applies, passed, tt.wantApplies, tt.wantPassed)
+			}
+		})
+	}
+}
+
+func TestParseReportsInvalidRules(t *testing.T) {
+	data := []byte(`
+policies:
+  - name: ok
+    rule: pod.restart_count < 5
+  - name: ok
+    rule: true
+  - name: bad-severity
+    severity: fatal
+    rule: true
+  - name: bad-target
+    target: node
+    rule: true
+  - name: bad-expression
+    rule: pod.name.matches("x")
+  - rule: true
+`)
+	set, err := Parse(data)
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if len(set.Policies) != 1 || set.Policies[0].Name != "ok" {
+		t.Fatalf("有效策略 = %v, 期望只有 ok", set.Policies)
+	}
+	if set.Policies[0].Severity != SeverityWarning || set.Policies[0].Target != TargetPod {
+		t.Errorf("默认严重程度和作用对象 = %s/%s", set.Policies[0].Severity, set.Policies[0].Target)
+	}
+	if len(set.Errors) != 5 {
+		t.Errorf("错误数 = %d, 期望 5: %v", len(set.Errors), set.Errors)
+	}
+	if !strings.Contains(strings.Join(set.Errors, "\n"), "策略名称重复") {
+		t.Errorf("应报告重复的策略名称: %v", set.Errors)
+	}
+}
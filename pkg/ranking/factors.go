@@ -0,0 +1,128 @@
+package ranking
+
+// 内置排名因子名称
+const (
+	FactorWaste          = "waste"           // 绝对浪费量（核/GiB）
+	FactorCost           = "cost"            // 浪费资源的月度成本
+	FactorUtilizationGap = "utilization_gap" // 使用量与请求量的相对差距
+	FactorMissingConfig  = "missing_config"  // 缺失的请求和限制配置
+	FactorRestarts       = "restarts"        // 容器重启和OOMKilled
+)
+
+func init() {
+	Register(wasteFactor{})
+	Register(costFactor{})
+	Register(utilizationGapFactor{})
+	Register(missingConfigFactor{})
+	Register(restartsFactor{})
+}
+
+// wastedResources 请求量超出使用量的部分，没有真实使用量的资源不计浪费
+func wastedResources(subject Subject) (int64, int64) {
+	var cpuWaste, memoryWaste int64
+	if subject.CPUMeasured && subject.CPURequest > subject.CPUUsage {
+		cpuWaste = subject.CPURequest - subject.CPUUsage
+	}
+	if subject.MemoryMeasured && subject.MemoryRequest > subject.MemoryUsage {
+		memoryWaste = subject.MemoryRequest - subject.MemoryUsage
+	}
+	return cpuWaste, memoryWaste
+}
+
+// wasteFactor 绝对浪费量，内存按单价折算为等价核数后与CPU相加，大规格对象的浪费排名更靠前
+type wasteFactor struct{}
+
+func (wasteFactor) Name() string { return FactorWaste }
+func (wasteFactor) Description() string {
+	return "请求量超出使用量的绝对资源量，内存按单价折算为等价CPU核数"
+}
+
+func (wasteFactor) Score(subject Subject, params *Params) float64 {
+	cpuWaste, memoryWaste := wastedResources(subject)
+	cores := float64(cpuWaste) / 1000
+	memoryGiB := float64(memoryWaste) / bytesPerGiB
+
+	// 无成本模型时按 1核 = 4GiB 折算
+	coresPerGiB := 0.25
+	if params.CostModel != nil && params.CostModel.CPUCoreHourPrice > 0 {
+		coresPerGiB = params.CostModel.MemoryGBHourPrice / params.CostModel.CPUCoreHourPrice
+	}
+	return saturate(cores+memoryGiB*coresPerGiB, params.WasteReferenceCores)
+}
+
+// costFactor 浪费资源的月度成本
+type costFactor struct{}
+
+func (costFactor) Name() string        { return FactorCost }
+func (costFactor) Description() string { return "请求量超出使用量部分的月度成本" }
+
+func (costFactor) Score(subject Subject, params *Params) float64 {
+	if params.CostModel == nil {
+		return 0
+	}
+	cpuWaste, memoryWaste := wastedResources(subject)
+	return saturate(params.CostModel.MonthlyCost(cpuWaste, memoryWaste), params.CostReference)
+}
+
+// utilizationGapFactor 使用量与请求量的相对差距，取已配置请求且有真实使用量的资源的平均值
+type utilizationGapFactor struct{}
+
+func (utilizationGapFactor) Name() string { return FactorUtilizationGap }
+func (utilizationGapFactor) Description() string {
+	return "1 - 使用量/请求量，CPU和内存取平均"
+}
+
+func (utilizationGapFactor) Score(subject Subject, params *Params) float64 {
+	var total float64
+	var count int
+	if subject.CPUMeasured && subject.CPURequest > 0 {
+		total += clamp01(1 - float64(subject.CPUUsage)/float64(subject.CPURequest))
+		count++
+	}
+	if subject.MemoryMeasured && subject.MemoryRequest > 0 {
+		total += clamp01(1 - float64(subject.MemoryUsage)/float64(subject.MemoryRequest))
+		count++
+	}
+	if count == 0 {
+		return 0
+	}
+	return total / float64(count)
+}
+
+// missingConfigFactor 缺失的CPU请求、内存请求和内存限制所占比例
+type missingConfigFactor struct{}
+
+func (missingConfigFactor) Name() string { return FactorMissingConfig }
+func (missingConfigFactor) Description() string {
+	return "未配置CPU请求、内存请求、内存限制的比例"
+}
+
+func (missingConfigFactor) Score(subject Subject, params *Params) float64 {
+	missing := 0
+	if subject.CPURequest == 0 {
+		missing++
+	}
+	if subject.MemoryRequest == 0 {
+		missing++
+	}
+	if subject.MemoryLimit == 0 {
+		missing++
+	}
+	return float64(missing) / 3
+}
+
+// restartsFactor 容器重启次数，发生过OOMKilled时至少得0.5分
+type restartsFactor struct{}
+
+func (restartsFactor) Name() string { return FactorRestarts }
+func (restartsFactor) Description() string {
+	return "容器累计重启次数，发生OOMKilled时加重"
+}
+
+func (restartsFactor) Score(subject Subject, params *Params) float64 {
+	score := saturate(float64(subject.RestartCount), params.RestartReference)
+	if subject.OOMKilled {
+		score = 0.5 + score/2
+	}
+	return score
+}
@@ -0,0 +1,276 @@
+package ranking
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+	"sync"
+
+	"cluster-resource-insight/internal/config"
+	"cluster-resource-insight/pkg/cost"
+)
+
+const bytesPerGiB = 1024 * 1024 * 1024
+
+// Subject 参与排名的对象 - 单个Pod或按工作负载汇总后的资源数据
+type Subject struct {
+	CPURequest    int64 // CPU请求量 (millicores)
+	CPULimit      int64 // CPU限制量 (millicores)
+	CPUUsage      int64 // CPU使用量 (millicores)
+	MemoryRequest int64 // 内存请求量 (bytes)
+	MemoryLimit   int64 // 内存限制量 (bytes)
+	MemoryUsage   int64 // 内存使用量 (bytes)
+	// CPUMeasured/MemoryMeasured 使用量是否来自真实监控数据，为false时不计算该资源的浪费和利用率差距
+	CPUMeasured    bool
+	MemoryMeasured bool
+	RestartCount   int32 // 容器累计重启次数
+	OOMKilled      bool  // 是否发生过OOMKilled
+	PodCount       int   // 包含的Pod数量，单个Pod为1
+}
+
+// Params 因子计算使用的参数
+type Params struct {
+	CostModel           *cost.Model // 计算浪费成本使用的成本模型
+	WasteReferenceCores float64     // 浪费量达到该核数（内存按成本折算为核）时浪费因子得0.5分
+	CostReference       float64     // 每月浪费成本达到该金额时成本因子得0.5分
+	RestartReference    float64     // 重启次数达到该值时重启因子得0.5分
+}
+
+// Factor 排名因子 - 返回0到1之间的原始得分，越大表示问题越严重
+type Factor interface {
+	Name() string
+	Description() string
+	Score(subject Subject, params *Params) float64
+}
+
+var (
+	registryMux sync.RWMutex
+	registry    = map[string]Factor{}
+	// registryOrder 保持因子注册顺序，保证得分明细输出稳定
+	registryOrder []string
+)
+
+// Register 注册排名因子，同名因子会被替换
+func Register(factor Factor) {
+	registryMux.Lock()
+	defer registryMux.Unlock()
+
+	if _, exists := registry[factor.Name()]; !exists {
+		registryOrder = append(registryOrder, factor.Name())
+	}
+	registry[factor.Name()] = factor
+}
+
+// lookup 按名称查找已注册的因子
+func lookup(name string) (Factor, bool) {
+	registryMux.RLock()
+	defer registryMux.RUnlock()
+	factor, ok := registry[name]
+	return factor, ok
+}
+
+// registeredNames 返回全部已注册因子的名称，按注册顺序
+func registeredNames() []string {
+	registryMux.RLock()
+	defer registryMux.RUnlock()
+	return append([]string(nil), registryOrder...)
+}
+
+// WeightedFactor 带权重的排名因子
+type WeightedFactor struct {
+	Factor Factor
+	Weight float64
+}
+
+// FactorScore 单个因子的得分明细
+type FactorScore struct {
+	Name         string  `json:"name"`         // 因子名称
+	Weight       float64 `json:"weight"`       // 权重
+	Raw          float64 `json:"raw"`          // 原始得分（0-1）
+	Contribution float64 `json:"contribution"` // 对总分的贡献（0-100）
+}
+
+// Result 排名得分及各因子明细
+type Result struct {
+	Score     float64       `json:"score"`     // 总分（0-100），各因子原始得分按权重加权平均
+	Breakdown []FactorScore `json:"breakdown"` // 各因子得分明细
+}
+
+// Ranker 排名引擎 - 按加权因子为对象打分
+type Ranker struct {
+	factors     []WeightedFactor
+	totalWeight float64
+	params      *Params
+}
+
+// NewRanker 创建排名引擎，权重为0或负数的因子被忽略
+func NewRanker(factors []WeightedFactor, params *Params) *Ranker {
+	ranker := &Ranker{params: params}
+	for _, factor := range factors {
+		if factor.Weight <= 0 {
+			continue
+		}
+		ranker.factors = append(ranker.factors, factor)
+		ranker.totalWeight += factor.Weight
+	}
+	return ranker
+}
+
+// DefaultWeights 默认因子权重
+func DefaultWeights() map[string]float64 {
+	return map[string]float64{
+		FactorWaste:          3,
+		FactorCost:           2,
+		FactorUtilizationGap: 2,
+		FactorMissingConfig:  2,
+		FactorRestarts:       1,
+	}
+}
+
+// DefaultRanker 根据配置文件创建排名引擎，未配置的权重和参数使用默认值，未注册的因子名称被忽略
+func DefaultRanker() *Ranker {
+	weights := DefaultWeights()
+	params := &Params{
+		CostModel:           cost.DefaultModel(),
+		WasteReferenceCores: 1,
+		CostReference:       50,
+		RestartReference:    5,
+	}
+
+	if rankingConfig := config.GetRankingConfig(); rankingConfig != nil {
+		for name, weight := range rankingConfig.Weights {
+			weights[name] = weight
+		}
+		if rankingConfig.WasteReferenceCores > 0 {
+			params.WasteReferenceCores = rankingConfig.WasteReferenceCores
+		}
+		if rankingConfig.CostReference > 0 {
+			params.CostReference = rankingConfig.CostReference
+		}
+		if rankingConfig.RestartReference > 0 {
+			params.RestartReference = rankingConfig.RestartReference
+		}
+	}
+
+	var factors []WeightedFactor
+	for _, name := range registeredNames() {
+		if factor, ok := lookup(name); ok {
+			factors = append(factors, WeightedFactor{Factor: factor, Weight: weights[name]})
+		}
+	}
+	return NewRanker(factors, params)
+}
+
+// Factors 返回参与打分的因子及权重
+func (r *Ranker) Factors() []WeightedFactor {
+	return r.factors
+}
+
+// Score 计算对象的总分和各因子得分明细
+func (r *Ranker) Score(subject Subject) Result {
+	result := Result{Breakdown: make([]FactorScore, 0, len(r.factors))}
+	if r.totalWeight == 0 {
+		return result
+	}
+
+	for _, factor := range r.factors {
+		raw := clamp01(factor.Factor.Score(subject, r.params))
+		contribution := raw * factor.Weight / r.totalWeight * 100
+		result.Score += contribution
+		result.Breakdown = append(result.Breakdown, FactorScore{
+			Name:         factor.Factor.Name(),
+			Weight:       factor.Weight,
+			Raw:          round4(raw),
+			Contribution: round4(contribution),
+		})
+	}
+	result.Score = round4(result.Score)
+	return result
+}
+
+// Ranked 排名结果，Index为对象在输入中的下标
+type Ranked struct {
+	Index  int
+	Result Result
+}
+
+// Rank 为全部对象打分并返回得分最高的前limit个，limit<=0时返回全部
+// 每个对象只计算一次得分，选取前N个时使用堆，复杂度为O(n log limit)
+func (r *Ranker) Rank(subjects []Subject, limit int) []Ranked {
+	ranked := make([]Ranked, len(subjects))
+	for i, subject := range subjects {
+		ranked[i] = Ranked{Index: i, Result: r.Score(subject)}
+	}
+	return TopN(ranked, limit, func(a, b Ranked) bool {
+		if a.Result.Score != b.Result.Score {
+			return a.Result.Score > b.Result.Score
+		}
+		return a.Index < b.Index
+	})
+}
+
+// TopN 按less定义的顺序返回前n个元素，less(a, b)为true表示a应排在b之前
+// n<=0或n>=len(items)时直接对输入切片稳定排序并返回；否则使用堆选取，不改变输入切片
+func TopN[T any](items []T, n int, less func(a, b T) bool) []T {
+	if n <= 0 || n >= len(items) {
+		sort.SliceStable(items, func(i, j int) bool { return less(items[i], items[j]) })
+		return items
+	}
+
+	// 小顶堆保存当前排名最靠前的n个元素，堆顶为其中排名最靠后的一个
+	h := &boundedHeap[T]{less: less}
+	for _, item := range items {
+		if h.Len() < n {
+			heap.Push(h, item)
+			continue
+		}
+		if less(item, h.items[0]) {
+			h.items[0] = item
+			heap.Fix(h, 0)
+		}
+	}
+
+	result := make([]T, h.Len())
+	for i := len(result) - 1; i >= 0; i-- {
+		result[i] = heap.Pop(h).(T)
+	}
+	return result
+}
+
+// boundedHeap TopN使用的堆，排名越靠后越接近堆顶
+type boundedHeap[T any] struct {
+	items []T
+	less  func(a, b T) bool
+}
+
+func (h *boundedHeap[T]) Len() int           { return len(h.items) }
+func (h *boundedHeap[T]) Less(i, j int) bool { return h.less(h.items[j], h.items[i]) }
+func (h *boundedHeap[T]) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *boundedHeap[T]) Push(x interface{}) { h.items = append(h.items, x.(T)) }
+func (h *boundedHeap[T]) Pop() interface{} {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
+
+// saturate 将非负数值映射到0-1，数值等于reference时得0.5
+func saturate(value, reference float64) float64 {
+	if value <= 0 || reference <= 0 {
+		return 0
+	}
+	return value / (value + reference)
+}
+
+func clamp01(value float64) float64 {
+	if math.IsNaN(value) || value < 0 {
+		return 0
+	}
+	if value > 1 {
+		return 1
+	}
+	return value
+}
+
+func round4(value float64) float64 {
+	return math.Round(value*10000) / 10000
+}
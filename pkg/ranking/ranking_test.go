@@ -0,0 +1,180 @@
+package ranking
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"cluster-resource-insight/pkg/cost"
+)
+
+// item TopN测试元素，id用于检查相同key时的顺序
+type item struct {
+	key int
+	id  int
+}
+
+func byKeyDesc(a, b item) bool {
+	if a.key != b.key {
+		return a.key > b.key
+	}
+	return a.id < b.id
+}
+
+func TestTopNMatchesSort(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	items := make([]item, 200)
+	for i := range items {
+		// key范围较小，保证存在大量相同key
+		items[i] = item{key: rng.Intn(20), id: i}
+	}
+
+	expected := append([]item(nil), items...)
+	sort.SliceStable(expected, func(i, j int) bool { return byKeyDesc(expected[i], expected[j]) })
+
+	for _, n := range []int{1, 2, 5, 17, 100, 199} {
+		input := append([]item(nil), items...)
+		got := TopN(input, n, byKeyDesc)
+		if !reflect.DeepEqual(got, expected[:n]) {
+			t.Errorf("n=%d 结果与完整排序的前n个不一致:\n得到 %v\n期望 %v", n, got, expected[:n])
+		}
+		if !reflect.DeepEqual(input, items) {
+			t.Errorf("n=%d 时不应修改输入切片", n)
+		}
+	}
+}
+
+func TestTopNReturnsAllWhenLimitNotPositiveOrLarge(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+	}{
+		{"n为0", 0},
+		{"n为负数", -1},
+		{"n等于长度", 4},
+		{"n大于长度", 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := []item{{1, 0}, {3, 1}, {2, 2}, {3, 3}}
+			got := TopN(items, tt.n, byKeyDesc)
+			want := []item{{3, 1}, {3, 3}, {2, 2}, {1, 0}}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("结果 = %v, 期望 %v", got, want)
+			}
+		})
+	}
+
+	if got := TopN([]item{}, 3, byKeyDesc); len(got) != 0 {
+		t.Errorf("空输入应返回空结果，实际 %v", got)
+	}
+}
+
+// testRanker 使用全部内置因子、权重相同的排名引擎
+func testRanker(t *testing.T) *Ranker {
+	t.Helper()
+	var factors []WeightedFactor
+	for _, name := range []string{FactorWaste, FactorCost, FactorUtilizationGap, FactorMissingConfig, FactorRestarts} {
+		factor, ok := lookup(name)
+		if !ok {
+			t.Fatalf("因子 %s 未注册", name)
+		}
+		factors = append(factors, WeightedFactor{Factor: factor, Weight: 1})
+	}
+	return NewRanker(factors, &Params{
+		CostModel:           cost.DefaultModel(),
+		WasteReferenceCores: 1,
+		CostReference:       50,
+		RestartReference:    5,
+	})
+}
+
+func TestRankOrdersByScoreThenIndex(t *testing.T) {
+	ranker := testRanker(t)
+	healthy := Subject{CPURequest: 100, CPUUsage: 100, MemoryRequest: 1 << 30, MemoryUsage: 1 << 30, MemoryLimit: 1 << 30,
+		CPUMeasured: true, MemoryMeasured: true, PodCount: 1}
+	wasteful := healthy
+	wasteful.CPURequest = 4000
+	restarting := healthy
+	restarting.RestartCount = 20
+	restarting.OOMKilled = true
+
+	subjects := []Subject{healthy, wasteful, healthy, restarting, healthy}
+	ranked := ranker.Rank(subjects, 3)
+	if len(ranked) != 3 {
+		t.Fatalf("结果数 = %d, 期望 3", len(ranked))
+	}
+
+	var indexes []int
+	for _, r := range ranked {
+		indexes = append(indexes, r.Index)
+	}
+	// 浪费和重启的得分高于健康对象，得分相同的健康对象按输入顺序排列
+	if indexes[2] != 0 || (indexes[0] != 1 && indexes[0] != 3) || (indexes[1] != 1 && indexes[1] != 3) {
+		t.Errorf("排名顺序 = %v", indexes)
+	}
+	for i := 1; i < len(ranked); i++ {
+		if ranked[i].Result.Score > ranked[i-1].Result.Score {
+			t.Errorf("得分未按从高到低排列: %v", ranked)
+		}
+	}
+
+	all := ranker.Rank(subjects, 0)
+	if len(all) != len(subjects) {
+		t.Fatalf("limit<=0 时应返回全部，实际 %d", len(all))
+	}
+	if all[2].Index != 0 || all[3].Index != 2 || all[4].Index != 4 {
+		t.Errorf("得分相同的对象应保持输入顺序: %v", all)
+	}
+}
+
+func TestScoreBreakdown(t *testing.T) {
+	ranker := testRanker(t)
+	result := ranker.Score(Subject{PodCount: 1})
+	if len(result.Breakdown) != 5 {
+		t.Fatalf("得分明细数 = %d, 期望 5", len(result.Breakdown))
+	}
+
+	var sum float64
+	for _, factor := range result.Breakdown {
+		sum += factor.Contribution
+		if factor.Name == FactorMissingConfig && factor.Raw != 1 {
+			t.Errorf("完全缺失配置时 missing_config 原始得分 = %v, 期望 1", factor.Raw)
+		}
+	}
+	if diff := sum - result.Score; diff > 0.001 || diff < -0.001 {
+		t.Errorf("各因子贡献之和 %v 应等于总分 %v", sum, result.Score)
+	}
+
+	if got := NewRanker(nil, &Params{}).Score(Subject{}); got.Score != 0 || len(got.Breakdown) != 0 {
+		t.Errorf("没有因子时得分应为0，实际 %+v", got)
+	}
+}
+
+func TestFactorsIgnoreUnmeasuredUsage(t *testing.T) {
+	params := &Params{CostModel: cost.DefaultModel(), WasteReferenceCores: 1, CostReference: 50, RestartReference: 5}
+	tests := []struct {
+		name    string
+		subject Subject
+		factor  Factor
+		want    float64
+	}{
+		{"CPU无真实使用量不计浪费", Subject{CPURequest: 2000}, wasteFactor{}, 0},
+		{"CPU浪费1核", Subject{CPURequest: 2000, CPUUsage: 1000, CPUMeasured: true}, wasteFactor{}, 0.5},
+		{"无真实使用量不计利用率差距", Subject{CPURequest: 1000, MemoryRequest: 1 << 30}, utilizationGapFactor{}, 0},
+		{"只统计有真实使用量的资源", Subject{CPURequest: 1000, CPUUsage: 250, CPUMeasured: true, MemoryRequest: 1 << 30}, utilizationGapFactor{}, 0.75},
+		{"使用量超过请求", Subject{CPURequest: 100, CPUUsage: 300, CPUMeasured: true}, utilizationGapFactor{}, 0},
+		{"无真实使用量不计成本", Subject{MemoryRequest: 8 << 30}, costFactor{}, 0},
+		{"缺失配置比例", Subject{CPURequest: 100}, missingConfigFactor{}, 2.0 / 3},
+		{"OOMKilled至少0.5分", Subject{OOMKilled: true}, restartsFactor{}, 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.factor.Score(tt.subject, params)
+			if diff := got - tt.want; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("%s 得分 = %v, 期望 %v", tt.factor.Name(), got, tt.want)
+			}
+		})
+	}
+}